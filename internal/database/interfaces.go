@@ -19,9 +19,20 @@ type DataStore interface {
 	Labels() LabelRepository
 }
 
+// BackupStore is implemented by data stores that can keep rotating copies of
+// their data file and restore from them.
+type BackupStore interface {
+	Backup(ctx context.Context) (*models.Backup, error)
+	ListBackups() ([]models.Backup, error)
+	RestoreBackup(ctx context.Context, name string) error
+}
+
 // ParticipantRepository handles participant persistence
 type ParticipantRepository interface {
+	// List returns participants whose name or address contains search.
 	List(ctx context.Context, search string) ([]models.Participant, error)
+	// Search is List restricted to the given field.
+	Search(ctx context.Context, search string, field SearchField) ([]models.Participant, error)
 	GetByID(ctx context.Context, id int64) (*models.Participant, error)
 	GetByIDs(ctx context.Context, ids []int64) ([]models.Participant, error)
 	Create(ctx context.Context, p *models.Participant) (*models.Participant, error)
@@ -33,7 +44,10 @@ type ParticipantRepository interface {
 
 // DriverRepository handles driver persistence
 type DriverRepository interface {
+	// List returns drivers whose name or address contains search.
 	List(ctx context.Context, search string) ([]models.Driver, error)
+	// Search is List restricted to the given field.
+	Search(ctx context.Context, search string, field SearchField) ([]models.Driver, error)
 	GetByID(ctx context.Context, id int64) (*models.Driver, error)
 	GetByIDs(ctx context.Context, ids []int64) ([]models.Driver, error)
 	Create(ctx context.Context, d *models.Driver) (*models.Driver, error)
@@ -91,18 +105,39 @@ type OrganizationVehicleRepository interface {
 // EventRepository handles event/history persistence
 type EventRepository interface {
 	List(ctx context.Context, limit, offset int) ([]models.Event, int, error)
+	ListFiltered(ctx context.Context, filter models.EventFilter, limit, offset int) ([]models.Event, int, error)
 	GetSummariesByEventIDs(ctx context.Context, eventIDs []int64) (map[int64]*models.EventSummary, error)
 	GetByID(ctx context.Context, id int64) (*models.Event, []models.EventRoute, *models.EventSummary, error)
+	// RecentDriverRouteCounts counts the routes each driver drove across the
+	// eventLimit most recent events. Drivers with no routes are left out.
+	RecentDriverRouteCounts(ctx context.Context, eventLimit int) (map[int64]int, error)
 	Create(ctx context.Context, event *models.Event, routes []models.EventRoute, summary *models.EventSummary) (*models.Event, error)
 	Delete(ctx context.Context, id int64) error
 	HasLegacyArchive(ctx context.Context) (bool, error)
 }
 
-// DistanceCacheRepository handles distance cache persistence
+// ScopedDistanceCache is implemented by distance caches that can keep a
+// separate set of entries per scope, such as one per routing provider.
+type ScopedDistanceCache interface {
+	// Scoped returns a view of the cache holding only scope's entries.
+	Scoped(scope string) DistanceCacheRepository
+}
+
+// DistanceCacheRepository handles distance cache persistence. Implementations
+// must be safe for concurrent use: a calculation may read it from several
+// insertion workers at once.
 type DistanceCacheRepository interface {
 	Get(ctx context.Context, origin, dest models.Coordinates) (*models.DistanceCacheEntry, error)
 	GetBatch(ctx context.Context, pairs []struct{ Origin, Dest models.Coordinates }) (map[string]*models.DistanceCacheEntry, error)
 	Set(ctx context.Context, entry *models.DistanceCacheEntry) error
 	SetBatch(ctx context.Context, entries []models.DistanceCacheEntry) error
+	Delete(ctx context.Context, origin, dest models.Coordinates) error
+	// DeleteTouching removes every entry that starts or ends at point and
+	// reports how many were removed.
+	DeleteTouching(ctx context.Context, point models.Coordinates) (int, error)
 	Clear(ctx context.Context) error
+	// Count reports how many pairs are cached, and SizeBytes roughly how much
+	// disk they take; caches held in memory report zero bytes.
+	Count(ctx context.Context) (int, error)
+	SizeBytes(ctx context.Context) (int64, error)
 }
@@ -48,10 +48,32 @@ func GetConfigFilePath() (string, error) {
 	return filepath.Join(appDir, ConfigFileName), nil
 }
 
-// AppConfig stores application configuration
+// AppConfig stores application configuration.
+// BackupIntervalMinutes enables periodic database backups when positive.
+// DurationHourThresholdMinutes sets when durations switch to "1h 35m";
+// zero keeps the one-hour default. SamePointPrecision sets the decimal places
+// at which two coordinates count as one stop; zero keeps the default of 5.
+// MaxConcurrentCalculations caps how many route calculations run at once;
+// zero leaves them unlimited. InsertionWorkers is how many candidate
+// insertions one calculation costs in parallel; zero costs them in turn.
 type AppConfig struct {
-	DatabasePath     string `json:"database_path"`
-	GoogleMapsAPIKey string `json:"google_maps_api_key,omitempty"`
+	DatabasePath                 string             `json:"database_path"`
+	GoogleMapsAPIKey             string             `json:"google_maps_api_key,omitempty"`
+	BackupIntervalMinutes        int                `json:"backup_interval_minutes,omitempty"`
+	DurationHourThresholdMinutes int                `json:"duration_hour_threshold_minutes,omitempty"`
+	SamePointPrecision           int                `json:"same_point_precision,omitempty"`
+	MaxConcurrentCalculations    int                `json:"max_concurrent_calculations,omitempty"`
+	InsertionWorkers             int                `json:"insertion_workers,omitempty"`
+	GoogleSheets                 GoogleSheetsConfig `json:"google_sheets,omitzero"`
+}
+
+// GoogleSheetsConfig holds the optional OAuth credentials and default
+// spreadsheet used to export route plans.
+type GoogleSheetsConfig struct {
+	ClientID      string `json:"client_id,omitempty"`
+	ClientSecret  string `json:"client_secret,omitempty"`
+	RefreshToken  string `json:"refresh_token,omitempty"`
+	SpreadsheetID string `json:"spreadsheet_id,omitempty"`
 }
 
 func ensurePathUnderAppDir(path string) (string, error) {
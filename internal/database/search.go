@@ -0,0 +1,38 @@
+package database
+
+import "fmt"
+
+// SearchField restricts which column a participant or driver search matches.
+type SearchField string
+
+const (
+	// SearchFieldAny matches the search text against name or address.
+	SearchFieldAny SearchField = ""
+	// SearchFieldName matches the search text against name only.
+	SearchFieldName SearchField = "name"
+	// SearchFieldAddress matches the search text against address only.
+	SearchFieldAddress SearchField = "address"
+)
+
+// ParseSearchField validates a field query parameter. An empty value
+// searches every field.
+func ParseSearchField(value string) (SearchField, error) {
+	switch field := SearchField(value); field {
+	case SearchFieldAny, SearchFieldName, SearchFieldAddress:
+		return field, nil
+	default:
+		return "", fmt.Errorf("unknown search field %q", value)
+	}
+}
+
+// Columns returns the table columns the field matches.
+func (f SearchField) Columns() []string {
+	switch f {
+	case SearchFieldName:
+		return []string{"name"}
+	case SearchFieldAddress:
+		return []string{"address"}
+	default:
+		return []string{"name", "address"}
+	}
+}
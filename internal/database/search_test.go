@@ -0,0 +1,14 @@
+package database
+
+import "testing"
+
+func TestParseSearchField(t *testing.T) {
+	for _, value := range []string{"", "name", "address"} {
+		if _, err := ParseSearchField(value); err != nil {
+			t.Fatalf("ParseSearchField(%q) error = %v", value, err)
+		}
+	}
+	if _, err := ParseSearchField("email"); err == nil {
+		t.Fatal("ParseSearchField(\"email\") error = nil, want error")
+	}
+}
@@ -0,0 +1,86 @@
+package distance
+
+import (
+	"context"
+	"errors"
+	"ride-home-router/internal/database"
+	"ride-home-router/internal/models"
+	"sync/atomic"
+)
+
+// CacheStats counts a calculator's distance cache reads since startup. Each
+// pair read counts once, including pairs read in a batch.
+type CacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// CacheStatsReporter is implemented by calculators that count their cache
+// reads.
+type CacheStatsReporter interface {
+	CacheStats() CacheStats
+}
+
+// CacheProvider is implemented by calculators that can hand out the cache they
+// read, so callers can measure its coverage without counting the reads.
+type CacheProvider interface {
+	DistanceCache() database.DistanceCacheRepository
+}
+
+// countingCache counts the hits and misses of the cache it wraps.
+type countingCache struct {
+	database.DistanceCacheRepository
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+func newCountingCache(cache database.DistanceCacheRepository) *countingCache {
+	return &countingCache{DistanceCacheRepository: cache}
+}
+
+func (c *countingCache) Get(ctx context.Context, origin, dest models.Coordinates) (*models.DistanceCacheEntry, error) {
+	entry, err := c.DistanceCacheRepository.Get(ctx, origin, dest)
+	switch {
+	case err == nil && entry != nil:
+		c.hits.Add(1)
+	case err == nil || errors.Is(err, database.ErrCacheMiss):
+		c.misses.Add(1)
+	}
+	return entry, err
+}
+
+func (c *countingCache) GetBatch(ctx context.Context, pairs []struct{ Origin, Dest models.Coordinates }) (map[string]*models.DistanceCacheEntry, error) {
+	entries, err := c.DistanceCacheRepository.GetBatch(ctx, pairs)
+	if err != nil {
+		return entries, err
+	}
+	hits := 0
+	for _, pair := range pairs {
+		if entries[PairCacheKey(pair.Origin, pair.Dest)] != nil {
+			hits++
+		}
+	}
+	c.hits.Add(int64(hits))
+	c.misses.Add(int64(len(pairs) - hits))
+	return entries, nil
+}
+
+func (c *countingCache) stats() CacheStats {
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
+
+// uncountedCache returns the cache a countingCache wraps, or cache itself.
+func uncountedCache(cache database.DistanceCacheRepository) database.DistanceCacheRepository {
+	if counting, ok := cache.(*countingCache); ok {
+		return counting.DistanceCacheRepository
+	}
+	return cache
+}
+
+// cacheStatsOf reports cache's counts, or none when it is not counted.
+func cacheStatsOf(cache database.DistanceCacheRepository) CacheStats {
+	if counting, ok := cache.(*countingCache); ok {
+		return counting.stats()
+	}
+	return CacheStats{}
+}
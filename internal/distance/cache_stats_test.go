@@ -0,0 +1,34 @@
+package distance
+
+import (
+	"context"
+	"errors"
+	"ride-home-router/internal/database"
+	"ride-home-router/internal/models"
+	"testing"
+)
+
+func TestCountingCacheCountsHitsAndMissesPerPair(t *testing.T) {
+	ctx := context.Background()
+	inner := newMockDistanceCache()
+	a, b, c := models.Coordinates{Lat: 1}, models.Coordinates{Lat: 2}, models.Coordinates{Lat: 3}
+	if err := inner.Set(ctx, &models.DistanceCacheEntry{Origin: a, Destination: b, DistanceMeters: 1}); err != nil {
+		t.Fatal(err)
+	}
+	cache := newCountingCache(inner)
+
+	if _, err := cache.Get(ctx, a, b); err != nil {
+		t.Fatalf("Get(hit) error = %v", err)
+	}
+	if _, err := cache.Get(ctx, b, a); !errors.Is(err, database.ErrCacheMiss) {
+		t.Fatalf("Get(miss) error = %v, want cache miss", err)
+	}
+	if _, err := cache.GetBatch(ctx, []struct{ Origin, Dest models.Coordinates }{{a, b}, {a, c}, {b, c}}); err != nil {
+		t.Fatalf("GetBatch() error = %v", err)
+	}
+
+	calc := &osrmCalculator{cache: cache}
+	if got := calc.CacheStats(); got != (CacheStats{Hits: 2, Misses: 3}) {
+		t.Fatalf("CacheStats() = %+v, want 2 hits and 3 misses", got)
+	}
+}
@@ -0,0 +1,130 @@
+package distance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"ride-home-router/internal/models"
+	"strings"
+	"sync"
+)
+
+// ErrRouteGeometryUnavailable is returned by calculators whose provider
+// cannot draw the road path between points.
+var ErrRouteGeometryUnavailable = errors.New("route geometry is not available from this distance provider")
+
+// maxCachedGeometries bounds the in-memory geometry cache; when it fills up
+// the cache starts over rather than tracking recency.
+const maxCachedGeometries = 10000
+
+type osrmRouteResponse struct {
+	Code   string `json:"code"`
+	Routes []struct {
+		Geometry string `json:"geometry"`
+	} `json:"routes"`
+}
+
+// geometryCache holds encoded polylines keyed on the ordered list of points
+// they pass through.
+type geometryCache struct {
+	mu      sync.RWMutex
+	entries map[string]string
+}
+
+func newGeometryCache() *geometryCache {
+	return &geometryCache{entries: make(map[string]string)}
+}
+
+func geometryCacheKey(points []models.Coordinates) string {
+	keys := make([]string, len(points))
+	for i, point := range points {
+		keys[i] = coordinatePointKey(point)
+	}
+	return strings.Join(keys, ";")
+}
+
+func (c *geometryCache) get(points []models.Coordinates) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	polyline, ok := c.entries[geometryCacheKey(points)]
+	return polyline, ok
+}
+
+func (c *geometryCache) set(points []models.Coordinates, polyline string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxCachedGeometries {
+		c.entries = make(map[string]string)
+	}
+	c.entries[geometryCacheKey(points)] = polyline
+}
+
+// GetRouteGeometry returns the road path through points, in order, as an
+// encoded polyline from OSRM's route service. Paths are cached in memory by
+// their ordered points.
+func (c *osrmCalculator) GetRouteGeometry(ctx context.Context, points []models.Coordinates) (string, error) {
+	if len(points) < 2 {
+		return "", nil
+	}
+	if polyline, ok := c.geometries.get(points); ok {
+		return polyline, nil
+	}
+
+	coords := make([]string, len(points))
+	for i, p := range points {
+		coords[i] = fmt.Sprintf("%.6f,%.6f", p.Lng, p.Lat)
+	}
+	profile := c.profile
+	if profile == "" {
+		profile = osrmDefaultProfile
+	}
+	queryURL := fmt.Sprintf("%s/route/v1/%s/%s?overview=full&geometries=polyline", c.baseURL, profile, strings.Join(coords, ";"))
+	if c.exclude != "" {
+		queryURL += "&exclude=" + url.QueryEscape(c.exclude)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", queryURL, nil)
+	if err != nil {
+		return "", &ErrDistanceCalculationFailed{Reason: err.Error()}
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		log.Printf("[ERROR] OSRM route request failed: points=%d err=%v", len(points), err)
+		return "", &ErrDistanceCalculationFailed{Reason: err.Error()}
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		log.Printf("[ERROR] OSRM route error: points=%d status=%d body=%s", len(points), resp.StatusCode, string(body))
+		return "", &ErrDistanceCalculationFailed{Reason: fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(body))}
+	}
+
+	var routeResp osrmRouteResponse
+	if err := json.NewDecoder(resp.Body).Decode(&routeResp); err != nil {
+		return "", &ErrDistanceCalculationFailed{Reason: err.Error()}
+	}
+	if routeResp.Code != "Ok" || len(routeResp.Routes) == 0 {
+		return "", &ErrDistanceCalculationFailed{Reason: fmt.Sprintf("OSRM error: %s", routeResp.Code)}
+	}
+
+	polyline := routeResp.Routes[0].Geometry
+	c.geometries.set(points, polyline)
+	return polyline, nil
+}
+
+// GetRouteGeometry is unsupported: the Distance Matrix API returns no paths.
+func (c *googleCalculator) GetRouteGeometry(context.Context, []models.Coordinates) (string, error) {
+	return "", ErrRouteGeometryUnavailable
+}
+
+// GetRouteGeometry passes through to the wrapped calculator; the matrix holds
+// no paths.
+func (c *MatrixCalculator) GetRouteGeometry(ctx context.Context, points []models.Coordinates) (string, error) {
+	return c.fallback.GetRouteGeometry(ctx, points)
+}
@@ -0,0 +1,61 @@
+package distance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"ride-home-router/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestOSRMGetRouteGeometry_RequestsPolylineAndCachesByOrderedPoints(t *testing.T) {
+	var paths, geometries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		geometries = append(geometries, r.URL.Query().Get("geometries"))
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"code":   "Ok",
+			"routes": []map[string]string{{"geometry": "poly" + r.URL.Path}},
+		})
+	}))
+	defer server.Close()
+
+	calc := NewOSRMCalculatorWithOptions(newMockDistanceCache(), OSRMOptions{BaseURL: server.URL})
+	a := models.Coordinates{Lat: 1, Lng: 2}
+	b := models.Coordinates{Lat: 3, Lng: 4}
+
+	first, err := calc.GetRouteGeometry(context.Background(), []models.Coordinates{a, b})
+	if err != nil {
+		t.Fatalf("GetRouteGeometry: %v", err)
+	}
+	if len(paths) != 1 || paths[0] != "/route/v1/driving/2.000000,1.000000;4.000000,3.000000" {
+		t.Fatalf("requested paths = %v, want one driving route from a to b", paths)
+	}
+	if geometries[0] != "polyline" {
+		t.Fatalf("geometries = %q, want polyline", geometries[0])
+	}
+	if !strings.HasPrefix(first, "poly") {
+		t.Fatalf("geometry = %q, want the route's polyline", first)
+	}
+
+	again, err := calc.GetRouteGeometry(context.Background(), []models.Coordinates{a, b})
+	if err != nil || again != first || len(paths) != 1 {
+		t.Fatalf("repeat lookup = %q, %v after %d requests, want cached %q", again, err, len(paths), first)
+	}
+	if _, err := calc.GetRouteGeometry(context.Background(), []models.Coordinates{b, a}); err != nil {
+		t.Fatalf("reverse GetRouteGeometry: %v", err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("reverse order made %d requests in total, want a separate request", len(paths))
+	}
+}
+
+func TestGoogleGetRouteGeometry_IsUnavailable(t *testing.T) {
+	calc := &googleCalculator{}
+	if _, err := calc.GetRouteGeometry(context.Background(), nil); !errors.Is(err, ErrRouteGeometryUnavailable) {
+		t.Fatalf("err = %v, want ErrRouteGeometryUnavailable", err)
+	}
+}
@@ -38,12 +38,23 @@ type googleCalculator struct {
 func NewGoogleCalculator(cache database.DistanceCacheRepository, apiKey APIKeyProvider) DistanceCalculator {
 	return &googleCalculator{
 		httpClient: &http.Client{Timeout: googleHTTPTimeout},
-		cache:      cache,
+		cache:      newCountingCache(cache),
 		apiKey:     apiKey,
 		endpoint:   googleRouteMatrixURL,
 	}
 }
 
+// CacheStats reports the calculator's cache hits and misses since it was
+// created.
+func (c *googleCalculator) CacheStats() CacheStats {
+	return cacheStatsOf(c.cache)
+}
+
+// DistanceCache returns the cache the calculator reads and fills.
+func (c *googleCalculator) DistanceCache() database.DistanceCacheRepository {
+	return uncountedCache(c.cache)
+}
+
 func (c *googleCalculator) GetDistance(ctx context.Context, origin, dest models.Coordinates) (*DistanceResult, error) {
 	if sameRoundedPoint(origin, dest) {
 		return &DistanceResult{DistanceMeters: 0, DurationSecs: 0}, nil
@@ -54,7 +65,8 @@ func (c *googleCalculator) GetDistance(ctx context.Context, origin, dest models.
 
 	cached, err := c.cache.Get(ctx, origin, dest)
 	if err != nil && !errors.Is(err, database.ErrCacheMiss) {
-		return nil, err
+		log.Printf("[GOOGLE] Distance cache unreadable, recomputing: err=%v", err)
+		cached = nil
 	}
 	if cached != nil {
 		return &DistanceResult{
@@ -89,10 +101,7 @@ func (c *googleCalculator) GetDistanceMatrix(ctx context.Context, points []model
 		matrix[i] = make([]DistanceResult, n)
 	}
 
-	missingPairs, err := c.hydrateMatrixFromCache(ctx, points, matrix)
-	if err != nil {
-		return nil, err
-	}
+	missingPairs := c.hydrateMatrixFromCache(ctx, points, matrix)
 	if len(missingPairs) == 0 {
 		return matrix, nil
 	}
@@ -139,9 +148,7 @@ func (c *googleCalculator) GetDistanceMatrix(ctx context.Context, points []model
 		destStart = destEnd
 	}
 
-	if err := c.cache.SetBatch(ctx, cacheEntries); err != nil {
-		return nil, err
-	}
+	c.storeInCache(ctx, cacheEntries)
 
 	return matrix, nil
 }
@@ -167,10 +174,7 @@ func (c *googleCalculator) GetDistancesFromPoint(ctx context.Context, origin mod
 		cacheIndexes = append(cacheIndexes, i)
 	}
 
-	cached, err := c.cache.GetBatch(ctx, cachePairs)
-	if err != nil {
-		return nil, err
-	}
+	cached := c.readFromCache(ctx, cachePairs)
 
 	var missingDestinations []models.Coordinates
 	var missingIndexes []int
@@ -218,9 +222,7 @@ func (c *googleCalculator) GetDistancesFromPoint(ctx context.Context, origin mod
 				DurationSecs:   result.DurationSecs,
 			})
 		}
-		if err := c.cache.SetBatch(ctx, cacheEntries); err != nil {
-			return nil, err
-		}
+		c.storeInCache(ctx, cacheEntries)
 	}
 
 	return results, nil
@@ -285,12 +287,32 @@ func uniqueCoordinates(points []models.Coordinates) []models.Coordinates {
 	return unique
 }
 
+// readFromCache returns the cached entries for pairs. A cache that cannot be
+// read is treated as empty, so a damaged cache costs provider calls rather
+// than failing the calculation; the recomputed entries are written back.
+func (c *googleCalculator) readFromCache(ctx context.Context, pairs []struct{ Origin, Dest models.Coordinates }) map[string]*models.DistanceCacheEntry {
+	cached, err := c.cache.GetBatch(ctx, pairs)
+	if err != nil {
+		log.Printf("[GOOGLE] Distance cache unreadable, recomputing: pairs=%d err=%v", len(pairs), err)
+		return nil
+	}
+	return cached
+}
+
+// storeInCache saves fetched entries. A failed write only loses the cache
+// entries; the caller still has its results.
+func (c *googleCalculator) storeInCache(ctx context.Context, entries []models.DistanceCacheEntry) {
+	if err := c.cache.SetBatch(ctx, entries); err != nil {
+		log.Printf("[GOOGLE] Distance cache write failed: entries=%d err=%v", len(entries), err)
+	}
+}
+
 type matrixIndex struct {
 	origin      int
 	destination int
 }
 
-func (c *googleCalculator) hydrateMatrixFromCache(ctx context.Context, points []models.Coordinates, matrix [][]DistanceResult) (map[matrixIndex]struct{}, error) {
+func (c *googleCalculator) hydrateMatrixFromCache(ctx context.Context, points []models.Coordinates, matrix [][]DistanceResult) map[matrixIndex]struct{} {
 	var cachePairs []struct{ Origin, Dest models.Coordinates }
 	var indexes []matrixIndex
 	for originIndex, origin := range points {
@@ -303,10 +325,7 @@ func (c *googleCalculator) hydrateMatrixFromCache(ctx context.Context, points []
 		}
 	}
 
-	cached, err := c.cache.GetBatch(ctx, cachePairs)
-	if err != nil {
-		return nil, err
-	}
+	cached := c.readFromCache(ctx, cachePairs)
 
 	missing := make(map[matrixIndex]struct{})
 	for i, pair := range cachePairs {
@@ -321,7 +340,7 @@ func (c *googleCalculator) hydrateMatrixFromCache(ctx context.Context, points []
 			DurationSecs:   entry.DurationSecs,
 		}
 	}
-	return missing, nil
+	return missing
 }
 
 func collectGoogleMatrixBlock(originStart, originEnd, destStart, destEnd int, missingPairs map[matrixIndex]struct{}) ([]int, []int) {
@@ -464,8 +483,7 @@ func (c *googleCalculator) currentAPIKey() (string, error) {
 }
 
 func sameRoundedPoint(a, b models.Coordinates) bool {
-	return models.RoundCoordinate(a.Lat) == models.RoundCoordinate(b.Lat) &&
-		models.RoundCoordinate(a.Lng) == models.RoundCoordinate(b.Lng)
+	return models.SamePoint(a, b)
 }
 
 func matrixNeedsProvider(points []models.Coordinates) bool {
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"ride-home-router/internal/database"
 	"ride-home-router/internal/models"
 	"ride-home-router/internal/sqlite"
 	"strconv"
@@ -199,6 +200,56 @@ func TestGoogleCalculator_MissingAPIKeyFailsBeforeUsingCache(t *testing.T) {
 	}
 }
 
+// damagedCache fails every read and write, as a corrupt cache table would.
+type damagedCache struct {
+	database.DistanceCacheRepository
+}
+
+var errDamagedCache = errors.New("database disk image is malformed")
+
+func (damagedCache) Get(context.Context, models.Coordinates, models.Coordinates) (*models.DistanceCacheEntry, error) {
+	return nil, errDamagedCache
+}
+
+func (damagedCache) GetBatch(context.Context, []struct{ Origin, Dest models.Coordinates }) (map[string]*models.DistanceCacheEntry, error) {
+	return nil, errDamagedCache
+}
+
+func (damagedCache) SetBatch(context.Context, []models.DistanceCacheEntry) error {
+	return errDamagedCache
+}
+
+func TestGoogleCalculator_DamagedCacheFallsBackToProvider(t *testing.T) {
+	requests := 0
+	calc, _ := newTestGoogleCalculator(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var captured googleMatrixRequest
+		if err := json.NewDecoder(r.Body).Decode(&captured); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		for i := range captured.Origins {
+			for j := range captured.Destinations {
+				_, _ = w.Write([]byte(`{"originIndex":` + intToString(i) + `,"destinationIndex":` + intToString(j) + `,"status":{},"condition":"ROUTE_EXISTS","distanceMeters":1000,"duration":"60s"}` + "\n"))
+			}
+		}
+	})
+	calc.cache = damagedCache{}
+
+	origin := models.Coordinates{Lat: 35, Lng: -79}
+	dest := models.Coordinates{Lat: 36, Lng: -79}
+	result, err := calc.GetDistance(context.Background(), origin, dest)
+	if err != nil || result.DistanceMeters != 1000 {
+		t.Fatalf("GetDistance() = %+v, %v; want the provider's distance", result, err)
+	}
+	matrix, err := calc.GetDistanceMatrix(context.Background(), []models.Coordinates{origin, dest})
+	if err != nil || matrix[1][0].DurationSecs != 60 {
+		t.Fatalf("GetDistanceMatrix() = %+v, %v; want the provider's durations", matrix, err)
+	}
+	if requests != 2 {
+		t.Fatalf("requests = %d, want one per call with nothing cached", requests)
+	}
+}
+
 func intToString(v int) string {
 	return strconv.Itoa(v)
 }
@@ -0,0 +1,107 @@
+package distance
+
+import (
+	"context"
+	"ride-home-router/internal/models"
+)
+
+// MatrixCalculator answers from one distance matrix fetched up front, so
+// several routing runs over the same points share a single provider request.
+// Points outside the matrix fall through to the wrapped calculator.
+type MatrixCalculator struct {
+	fallback DistanceCalculator
+	index    map[string]int
+	matrix   [][]DistanceResult
+}
+
+// NewMatrixCalculator fetches the full matrix for points once. Duplicate
+// points are requested once.
+func NewMatrixCalculator(ctx context.Context, calc DistanceCalculator, points []models.Coordinates) (*MatrixCalculator, error) {
+	index := make(map[string]int, len(points))
+	unique := make([]models.Coordinates, 0, len(points))
+	for _, point := range points {
+		key := coordinatePointKey(point)
+		if _, ok := index[key]; ok {
+			continue
+		}
+		index[key] = len(unique)
+		unique = append(unique, point)
+	}
+
+	matrix, err := calc.GetDistanceMatrix(ctx, unique)
+	if err != nil {
+		return nil, err
+	}
+	return &MatrixCalculator{fallback: calc, index: index, matrix: matrix}, nil
+}
+
+func (c *MatrixCalculator) lookup(origin, dest models.Coordinates) (DistanceResult, bool) {
+	i, ok := c.index[coordinatePointKey(origin)]
+	if !ok {
+		return DistanceResult{}, false
+	}
+	j, ok := c.index[coordinatePointKey(dest)]
+	if !ok {
+		return DistanceResult{}, false
+	}
+	return c.matrix[i][j], true
+}
+
+func (c *MatrixCalculator) covers(points []models.Coordinates) bool {
+	for _, point := range points {
+		if _, ok := c.index[coordinatePointKey(point)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *MatrixCalculator) GetDistance(ctx context.Context, origin, dest models.Coordinates) (*DistanceResult, error) {
+	if result, ok := c.lookup(origin, dest); ok {
+		return &result, nil
+	}
+	return c.fallback.GetDistance(ctx, origin, dest)
+}
+
+func (c *MatrixCalculator) GetDistanceMatrix(ctx context.Context, points []models.Coordinates) ([][]DistanceResult, error) {
+	if !c.covers(points) {
+		return c.fallback.GetDistanceMatrix(ctx, points)
+	}
+	matrix := make([][]DistanceResult, len(points))
+	for i, origin := range points {
+		matrix[i] = make([]DistanceResult, len(points))
+		for j, dest := range points {
+			matrix[i][j], _ = c.lookup(origin, dest)
+		}
+	}
+	return matrix, nil
+}
+
+func (c *MatrixCalculator) GetDistancesFromPoint(ctx context.Context, origin models.Coordinates, destinations []models.Coordinates) ([]DistanceResult, error) {
+	if !c.covers(append([]models.Coordinates{origin}, destinations...)) {
+		return c.fallback.GetDistancesFromPoint(ctx, origin, destinations)
+	}
+	results := make([]DistanceResult, len(destinations))
+	for i, dest := range destinations {
+		results[i], _ = c.lookup(origin, dest)
+	}
+	return results, nil
+}
+
+func (c *MatrixCalculator) PrewarmCache(ctx context.Context, points []models.Coordinates) error {
+	if c.covers(points) {
+		return nil
+	}
+	return c.fallback.PrewarmCache(ctx, points)
+}
+
+// PrewarmPairs prewarms only the pairs the matrix does not hold.
+func (c *MatrixCalculator) PrewarmPairs(ctx context.Context, pairs []DistancePair) error {
+	var missing []DistancePair
+	for _, pair := range pairs {
+		if _, ok := c.lookup(pair.Origin, pair.Destination); !ok {
+			missing = append(missing, pair)
+		}
+	}
+	return PrewarmRoutingPairs(ctx, c.fallback, missing)
+}
@@ -0,0 +1,82 @@
+package distance
+
+import (
+	"context"
+	"ride-home-router/internal/models"
+	"testing"
+)
+
+// gridCalculator reports the Manhattan distance in degrees and counts calls.
+type gridCalculator struct {
+	matrixCalls   int
+	distanceCalls int
+}
+
+func (c *gridCalculator) GetDistance(_ context.Context, origin, dest models.Coordinates) (*DistanceResult, error) {
+	c.distanceCalls++
+	d := abs(dest.Lat-origin.Lat) + abs(dest.Lng-origin.Lng)
+	return &DistanceResult{DistanceMeters: d, DurationSecs: d}, nil
+}
+
+func (c *gridCalculator) GetDistanceMatrix(ctx context.Context, points []models.Coordinates) ([][]DistanceResult, error) {
+	c.matrixCalls++
+	matrix := make([][]DistanceResult, len(points))
+	for i := range points {
+		matrix[i] = make([]DistanceResult, len(points))
+		for j := range points {
+			d := abs(points[j].Lat-points[i].Lat) + abs(points[j].Lng-points[i].Lng)
+			matrix[i][j] = DistanceResult{DistanceMeters: d, DurationSecs: d}
+		}
+	}
+	return matrix, nil
+}
+
+func (c *gridCalculator) GetDistancesFromPoint(ctx context.Context, origin models.Coordinates, destinations []models.Coordinates) ([]DistanceResult, error) {
+	results := make([]DistanceResult, len(destinations))
+	for i, dest := range destinations {
+		result, _ := c.GetDistance(ctx, origin, dest)
+		results[i] = *result
+	}
+	return results, nil
+}
+
+func (c *gridCalculator) PrewarmCache(context.Context, []models.Coordinates) error { return nil }
+
+func (*gridCalculator) GetRouteGeometry(context.Context, []models.Coordinates) (string, error) {
+	return "", ErrRouteGeometryUnavailable
+}
+
+func abs(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func TestMatrixCalculator_ServesKnownPointsAndFallsBackForOthers(t *testing.T) {
+	inner := &gridCalculator{}
+	a, b, c := models.Coordinates{Lat: 1, Lng: 1}, models.Coordinates{Lat: 2, Lng: 3}, models.Coordinates{Lat: 5, Lng: 5}
+	matrix, err := NewMatrixCalculator(context.Background(), inner, []models.Coordinates{a, b, a})
+	if err != nil {
+		t.Fatalf("NewMatrixCalculator() error = %v", err)
+	}
+
+	got, err := matrix.GetDistance(context.Background(), a, b)
+	if err != nil || got.DistanceMeters != 3 {
+		t.Fatalf("GetDistance(a, b) = %+v, %v; want 3", got, err)
+	}
+	if err := matrix.PrewarmPairs(context.Background(), []DistancePair{{Origin: b, Destination: a}}); err != nil {
+		t.Fatalf("PrewarmPairs() error = %v", err)
+	}
+	if inner.matrixCalls != 1 || inner.distanceCalls != 0 {
+		t.Fatalf("inner calls: matrix=%d distance=%d, want 1 and 0", inner.matrixCalls, inner.distanceCalls)
+	}
+
+	got, err = matrix.GetDistance(context.Background(), a, c)
+	if err != nil || got.DistanceMeters != 8 {
+		t.Fatalf("GetDistance(a, c) = %+v, %v; want 8", got, err)
+	}
+	if inner.distanceCalls != 1 {
+		t.Fatalf("inner distance calls = %d, want 1 fallback for a point outside the matrix", inner.distanceCalls)
+	}
+}
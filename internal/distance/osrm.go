@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
+	"net/url"
 	"ride-home-router/internal/database"
 	"ride-home-router/internal/models"
 	"strings"
@@ -18,6 +20,9 @@ import (
 type DistanceResult struct {
 	DistanceMeters float64
 	DurationSecs   float64
+	// Estimated marks a straight-line estimate made because the routing
+	// service was unreachable. Estimates are never cached.
+	Estimated bool
 }
 
 // DistanceCalculator provides distance calculations between coordinates
@@ -26,6 +31,10 @@ type DistanceCalculator interface {
 	GetDistanceMatrix(ctx context.Context, points []models.Coordinates) ([][]DistanceResult, error)
 	GetDistancesFromPoint(ctx context.Context, origin models.Coordinates, destinations []models.Coordinates) ([]DistanceResult, error)
 	PrewarmCache(ctx context.Context, points []models.Coordinates) error
+	// GetRouteGeometry returns the road path through points, in order, as an
+	// encoded polyline, or ErrRouteGeometryUnavailable when the provider
+	// cannot draw one.
+	GetRouteGeometry(ctx context.Context, points []models.Coordinates) (string, error)
 }
 
 // ErrDistanceCalculationFailed is returned when OSRM API fails
@@ -43,6 +52,28 @@ type osrmCalculator struct {
 	baseURL    string
 	httpClient *http.Client
 	cache      database.DistanceCacheRepository
+	profile    string
+	exclude    string
+	// fallbackSpeedKPH is the average speed behind estimated durations;
+	// zero means osrmDefaultFallbackSpeedKPH.
+	fallbackSpeedKPH float64
+	geometries       *geometryCache
+}
+
+// OSRMOptions customizes the OSRM server, routing profile and excluded road
+// classes.
+type OSRMOptions struct {
+	// BaseURL points at a self-hosted OSRM server; empty means the public
+	// router.project-osrm.org.
+	BaseURL string
+	// Profile is the OSRM profile name; empty means "driving".
+	Profile string
+	// Exclude is passed through as the OSRM exclude parameter, e.g. "motorway"
+	// for drivers who prefer surface streets.
+	Exclude string
+	// FallbackSpeedKPH is the average speed used to estimate durations from
+	// straight-line distances while OSRM is unreachable; zero means 40 km/h.
+	FallbackSpeedKPH float64
 }
 
 type osrmTableResponse struct {
@@ -65,27 +96,60 @@ type missingMatrixPlan struct {
 
 // NewOSRMCalculator creates a new OSRM distance calculator with caching
 func NewOSRMCalculator(cache database.DistanceCacheRepository) DistanceCalculator {
-	return &osrmCalculator{
-		baseURL: "https://router.project-osrm.org",
+	return NewOSRMCalculatorWithOptions(cache, OSRMOptions{})
+}
+
+// NewOSRMCalculatorWithOptions creates an OSRM distance calculator for another
+// server, or for a non-default profile or exclude set. Its distances are cached
+// under a scope naming the server, profile and exclude set, so they never mix
+// with another provider's or server's.
+func NewOSRMCalculatorWithOptions(cache database.DistanceCacheRepository, options OSRMOptions) DistanceCalculator {
+	calc := &osrmCalculator{
+		baseURL: osrmPublicBaseURL,
 		httpClient: &http.Client{
 			Timeout: osrmClientTimeout,
 		},
-		cache: cache,
+		profile:          options.Profile,
+		exclude:          options.Exclude,
+		fallbackSpeedKPH: options.FallbackSpeedKPH,
+		geometries:       newGeometryCache(),
+	}
+	if baseURL := strings.TrimRight(options.BaseURL, "/"); baseURL != "" {
+		calc.baseURL = baseURL
 	}
+	calc.cache = newCountingCache(scopedCache(cache, osrmCacheScope(calc.baseURL, options)))
+	return calc
+}
+
+// CacheStats reports the calculator's cache hits and misses since it was
+// created.
+func (c *osrmCalculator) CacheStats() CacheStats {
+	return cacheStatsOf(c.cache)
+}
+
+// DistanceCache returns the scoped cache the calculator reads and fills.
+func (c *osrmCalculator) DistanceCache() database.DistanceCacheRepository {
+	return uncountedCache(c.cache)
+}
+
+// osrmCacheScope returns the cache scope for the server at baseURL with
+// options' profile and exclude set.
+func osrmCacheScope(baseURL string, options OSRMOptions) string {
+	profile := options.Profile
+	if profile == "" {
+		profile = osrmDefaultProfile
+	}
+	return fmt.Sprintf("osrm:%s:%s:exclude=%s", baseURL, profile, options.Exclude)
 }
 
 func (c *osrmCalculator) GetDistance(ctx context.Context, origin, dest models.Coordinates) (*DistanceResult, error) {
-	// Quick check: same point to same point = 0 (with rounding tolerance)
-	// Round to 5 decimal places (~1m precision) to match cache key rounding
-	if models.RoundCoordinate(origin.Lat) == models.RoundCoordinate(dest.Lat) &&
-		models.RoundCoordinate(origin.Lng) == models.RoundCoordinate(dest.Lng) {
+	// Quick check: same point to same point = 0, at the same precision that
+	// groups riders into one household stop
+	if models.SamePoint(origin, dest) {
 		return &DistanceResult{DistanceMeters: 0, DurationSecs: 0}, nil
 	}
 
-	cached, err := c.cache.Get(ctx, origin, dest)
-	if err != nil && !errors.Is(err, database.ErrCacheMiss) {
-		return nil, err
-	}
+	cached := c.readOneFromCache(ctx, origin, dest)
 	if cached != nil {
 		// Don't log every cache hit - too noisy
 		return &DistanceResult{
@@ -118,6 +182,12 @@ const (
 	maxOSRMCoordinates = 80
 	osrmClientTimeout  = 30 * time.Second
 	osrmBatchRateDelay = 100 * time.Millisecond
+	osrmDefaultProfile = "driving"
+	osrmPublicBaseURL  = "https://router.project-osrm.org"
+	// osrmDefaultFallbackSpeedKPH is a typical in-town average speed, used to
+	// estimate durations when OSRM is unreachable.
+	osrmDefaultFallbackSpeedKPH = 40.0
+	earthRadiusMeters           = 6371000.0
 )
 
 func (c *osrmCalculator) GetDistanceMatrix(ctx context.Context, points []models.Coordinates) ([][]DistanceResult, error) {
@@ -131,10 +201,7 @@ func (c *osrmCalculator) GetDistanceMatrix(ctx context.Context, points []models.
 		matrix[i] = make([]DistanceResult, n)
 	}
 
-	missingPlan, err := c.hydrateMatrixFromCache(ctx, points, matrix)
-	if err != nil {
-		return nil, err
-	}
+	missingPlan := c.hydrateMatrixFromCache(ctx, points, matrix)
 
 	if missingPlan.count == 0 {
 		log.Printf("[OSRM] Distance matrix all cached: points=%d", n)
@@ -166,9 +233,7 @@ func (c *osrmCalculator) fetchDistanceMatrixSingle(ctx context.Context, points [
 		if err != nil {
 			return nil, err
 		}
-		if err := c.persistCacheEntries(ctx, cacheEntries); err != nil {
-			return nil, err
-		}
+		c.storeInCache(ctx, cacheEntries)
 		return matrix, nil
 	}
 
@@ -178,9 +243,7 @@ func (c *osrmCalculator) fetchDistanceMatrixSingle(ctx context.Context, points [
 	if err != nil {
 		return nil, err
 	}
-	if err := c.persistCacheEntries(ctx, cacheEntries); err != nil {
-		return nil, err
-	}
+	c.storeInCache(ctx, cacheEntries)
 
 	return matrix, nil
 }
@@ -250,9 +313,7 @@ func (c *osrmCalculator) fetchDistanceMatrixBatched(ctx context.Context, points
 
 	log.Printf("[OSRM] Batched requests complete: requests=%d entries=%d", requestCount, len(allCacheEntries))
 
-	if err := c.persistCacheEntries(ctx, allCacheEntries); err != nil {
-		return nil, err
-	}
+	c.storeInCache(ctx, allCacheEntries)
 
 	return matrix, nil
 }
@@ -265,7 +326,12 @@ func (c *osrmCalculator) GetDistancesFromPoint(ctx context.Context, origin model
 	allPoints := append([]models.Coordinates{origin}, destinations...)
 	matrix, err := c.GetDistanceMatrix(ctx, allPoints)
 	if err != nil {
-		return nil, err
+		if !canEstimateDistances(ctx, err) {
+			return nil, err
+		}
+		log.Printf("[WARN] OSRM unavailable, estimating straight-line distances: origin=(%.6f,%.6f) destinations=%d err=%v",
+			origin.Lat, origin.Lng, len(destinations), err)
+		return c.estimateDistancesFromPoint(ctx, origin, destinations)
 	}
 
 	results := make([]DistanceResult, len(destinations))
@@ -276,12 +342,72 @@ func (c *osrmCalculator) GetDistancesFromPoint(ctx context.Context, origin model
 	return results, nil
 }
 
+// estimateDistancesFromPoint answers from the cache where it can and estimates
+// the rest from straight-line distance.
+func (c *osrmCalculator) estimateDistancesFromPoint(ctx context.Context, origin models.Coordinates, destinations []models.Coordinates) ([]DistanceResult, error) {
+	results := make([]DistanceResult, len(destinations))
+	for i, dest := range destinations {
+		if models.SamePoint(origin, dest) {
+			continue
+		}
+		if cached := c.readOneFromCache(ctx, origin, dest); cached != nil {
+			results[i] = DistanceResult{DistanceMeters: cached.DistanceMeters, DurationSecs: cached.DurationSecs}
+			continue
+		}
+		results[i] = c.estimateDistance(origin, dest)
+	}
+	return results, nil
+}
+
+// estimateDistance is the great-circle distance between two points, driven at
+// the fallback speed.
+func (c *osrmCalculator) estimateDistance(origin, dest models.Coordinates) DistanceResult {
+	speedKPH := c.fallbackSpeedKPH
+	if speedKPH <= 0 {
+		speedKPH = osrmDefaultFallbackSpeedKPH
+	}
+	meters := haversineMeters(origin, dest)
+	return DistanceResult{DistanceMeters: meters, DurationSecs: meters / (speedKPH * 1000 / 3600), Estimated: true}
+}
+
+func haversineMeters(a, b models.Coordinates) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// canEstimateDistances reports whether err is OSRM itself failing, rather
+// than the caller giving up, so straight-line estimates can stand in.
+func canEstimateDistances(ctx context.Context, err error) bool {
+	var failed *ErrDistanceCalculationFailed
+	return errors.As(err, &failed) && ctx.Err() == nil
+}
+
+// PrewarmCache fetches the matrix over points. An OSRM outage is not an
+// error here: nothing is cached and later lookups fall back to estimates.
 func (c *osrmCalculator) PrewarmCache(ctx context.Context, points []models.Coordinates) error {
 	_, err := c.GetDistanceMatrix(ctx, points)
+	if err != nil && canEstimateDistances(ctx, err) {
+		log.Printf("[WARN] OSRM unavailable, skipping prewarm: points=%d err=%v", len(points), err)
+		return nil
+	}
 	return err
 }
 
+// PrewarmPairs fetches the missing directed pairs. Like PrewarmCache, it
+// leaves an OSRM outage to the lookups' estimates.
 func (c *osrmCalculator) PrewarmPairs(ctx context.Context, pairs []DistancePair) error {
+	err := c.prewarmPairs(ctx, pairs)
+	if err != nil && canEstimateDistances(ctx, err) {
+		log.Printf("[WARN] OSRM unavailable, skipping pair prewarm: pairs=%d err=%v", len(pairs), err)
+		return nil
+	}
+	return err
+}
+
+func (c *osrmCalculator) prewarmPairs(ctx context.Context, pairs []DistancePair) error {
 	if len(pairs) == 0 {
 		return nil
 	}
@@ -300,10 +426,7 @@ func (c *osrmCalculator) PrewarmPairs(ctx context.Context, pairs []DistancePair)
 		cachePairs = append(cachePairs, struct{ Origin, Dest models.Coordinates }{Origin: pair.Origin, Dest: pair.Destination})
 	}
 
-	cached, err := c.cache.GetBatch(ctx, cachePairs)
-	if err != nil {
-		return err
-	}
+	cached := c.readFromCache(ctx, cachePairs)
 
 	byOrigin := make(map[string][]models.Coordinates)
 	originCoords := make(map[string]models.Coordinates)
@@ -361,9 +484,7 @@ func (c *osrmCalculator) PrewarmPairs(ctx context.Context, pairs []DistancePair)
 					DurationSecs:   dur,
 				})
 			}
-			if err := c.persistCacheEntries(ctx, cacheEntries); err != nil {
-				return err
-			}
+			c.storeInCache(ctx, cacheEntries)
 		}
 	}
 
@@ -466,10 +587,11 @@ func (c *osrmCalculator) prewarmPairRectangle(ctx context.Context, pairs []struc
 		}
 	}
 
-	return c.persistCacheEntries(ctx, cacheEntries)
+	c.storeInCache(ctx, cacheEntries)
+	return nil
 }
 
-func (c *osrmCalculator) hydrateMatrixFromCache(ctx context.Context, points []models.Coordinates, matrix [][]DistanceResult) (*missingMatrixPlan, error) {
+func (c *osrmCalculator) hydrateMatrixFromCache(ctx context.Context, points []models.Coordinates, matrix [][]DistanceResult) *missingMatrixPlan {
 	n := len(points)
 	plan := &missingMatrixPlan{
 		pairs: make(map[matrixPair]struct{}),
@@ -477,6 +599,16 @@ func (c *osrmCalculator) hydrateMatrixFromCache(ctx context.Context, points []mo
 	sourceSeen := make([]bool, n)
 	destinationSeen := make([]bool, n)
 
+	cachePairs := make([]struct{ Origin, Dest models.Coordinates }, 0, n*n)
+	for i := range n {
+		for j := range n {
+			if i != j {
+				cachePairs = append(cachePairs, struct{ Origin, Dest models.Coordinates }{Origin: points[i], Dest: points[j]})
+			}
+		}
+	}
+	cached := c.readFromCache(ctx, cachePairs)
+
 	for i := range n {
 		for j := range n {
 			if i == j {
@@ -484,14 +616,10 @@ func (c *osrmCalculator) hydrateMatrixFromCache(ctx context.Context, points []mo
 				continue
 			}
 
-			cached, err := c.cache.Get(ctx, points[i], points[j])
-			if err != nil && !errors.Is(err, database.ErrCacheMiss) {
-				return nil, err
-			}
-			if cached != nil {
+			if entry := cached[PairCacheKey(points[i], points[j])]; entry != nil {
 				matrix[i][j] = DistanceResult{
-					DistanceMeters: cached.DistanceMeters,
-					DurationSecs:   cached.DurationSecs,
+					DistanceMeters: entry.DistanceMeters,
+					DurationSecs:   entry.DurationSecs,
 				}
 				continue
 			}
@@ -510,7 +638,7 @@ func (c *osrmCalculator) hydrateMatrixFromCache(ctx context.Context, points []mo
 		}
 	}
 
-	return plan, nil
+	return plan
 }
 
 func (c *osrmCalculator) fetchTableIntoMatrix(
@@ -569,7 +697,14 @@ func (c *osrmCalculator) requestTable(ctx context.Context, points []models.Coord
 		coords[i] = fmt.Sprintf("%.6f,%.6f", p.Lng, p.Lat)
 	}
 
-	queryURL := fmt.Sprintf("%s/table/v1/driving/%s?annotations=distance,duration", c.baseURL, strings.Join(coords, ";"))
+	profile := c.profile
+	if profile == "" {
+		profile = osrmDefaultProfile
+	}
+	queryURL := fmt.Sprintf("%s/table/v1/%s/%s?annotations=distance,duration", c.baseURL, profile, strings.Join(coords, ";"))
+	if c.exclude != "" {
+		queryURL += "&exclude=" + url.QueryEscape(c.exclude)
+	}
 	if len(sources) > 0 {
 		queryURL += "&sources=" + joinIndices(sources)
 	}
@@ -613,11 +748,39 @@ func (c *osrmCalculator) requestTable(ctx context.Context, points []models.Coord
 	return &osrmResp, nil
 }
 
-func (c *osrmCalculator) persistCacheEntries(ctx context.Context, cacheEntries []models.DistanceCacheEntry) error {
-	if len(cacheEntries) == 0 {
+// readOneFromCache returns the cached entry for a pair, or nil on a miss. An
+// unreadable cache is treated as a miss so the pair is fetched again.
+func (c *osrmCalculator) readOneFromCache(ctx context.Context, origin, dest models.Coordinates) *models.DistanceCacheEntry {
+	cached, err := c.cache.Get(ctx, origin, dest)
+	if err != nil {
+		if !errors.Is(err, database.ErrCacheMiss) {
+			log.Printf("[OSRM] Distance cache unreadable, recomputing: err=%v", err)
+		}
 		return nil
 	}
-	return c.cache.SetBatch(ctx, cacheEntries)
+	return cached
+}
+
+// readFromCache returns the cached entries for pairs, keyed like GetBatch. An
+// unreadable cache is treated as all misses.
+func (c *osrmCalculator) readFromCache(ctx context.Context, pairs []struct{ Origin, Dest models.Coordinates }) map[string]*models.DistanceCacheEntry {
+	cached, err := c.cache.GetBatch(ctx, pairs)
+	if err != nil {
+		log.Printf("[OSRM] Distance cache unreadable, recomputing: pairs=%d err=%v", len(pairs), err)
+		return nil
+	}
+	return cached
+}
+
+// storeInCache saves fetched entries. A failed write only loses the cache
+// entries; the caller still has its results.
+func (c *osrmCalculator) storeInCache(ctx context.Context, cacheEntries []models.DistanceCacheEntry) {
+	if len(cacheEntries) == 0 {
+		return
+	}
+	if err := c.cache.SetBatch(ctx, cacheEntries); err != nil {
+		log.Printf("[OSRM] Distance cache write failed: entries=%d err=%v", len(cacheEntries), err)
+	}
 }
 
 func collectMissingBlockPlan(batchI, batchJ []int, missingPairs map[matrixPair]struct{}) *missingMatrixPlan {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"ride-home-router/internal/database"
@@ -61,12 +62,39 @@ func (c *mockDistanceCache) SetBatch(_ context.Context, entries []models.Distanc
 	return nil
 }
 
+func (c *mockDistanceCache) Delete(_ context.Context, origin, dest models.Coordinates) error {
+	delete(c.entries, c.cacheKey(origin, dest))
+	return nil
+}
+
+func (c *mockDistanceCache) DeleteTouching(_ context.Context, point models.Coordinates) (int, error) {
+	point = models.Coordinates{Lat: models.RoundCoordinate(point.Lat), Lng: models.RoundCoordinate(point.Lng)}
+	removed := 0
+	for key, entry := range c.entries {
+		origin := models.Coordinates{Lat: models.RoundCoordinate(entry.Origin.Lat), Lng: models.RoundCoordinate(entry.Origin.Lng)}
+		dest := models.Coordinates{Lat: models.RoundCoordinate(entry.Destination.Lat), Lng: models.RoundCoordinate(entry.Destination.Lng)}
+		if origin == point || dest == point {
+			delete(c.entries, key)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
 func (c *mockDistanceCache) Clear(_ context.Context) error {
 	c.entries = make(map[string]*models.DistanceCacheEntry)
 	return nil
 }
 
-func (c *mockDistanceCache) Count() int {
+func (c *mockDistanceCache) Count(context.Context) (int, error) {
+	return len(c.entries), nil
+}
+
+func (c *mockDistanceCache) SizeBytes(context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (c *mockDistanceCache) Len() int {
 	return len(c.entries)
 }
 
@@ -190,8 +218,8 @@ func TestGetDistanceMatrix_PartialCache(t *testing.T) {
 	if matrix[1][0].DistanceMeters != 5000 {
 		t.Errorf("expected cached reverse distance to remain 5000, got %f", matrix[1][0].DistanceMeters)
 	}
-	if cache.Count() != 2 {
-		t.Errorf("expected both directions to be cached after fetch, got %d entries", cache.Count())
+	if cache.Len() != 2 {
+		t.Errorf("expected both directions to be cached after fetch, got %d entries", cache.Len())
 	}
 
 	matrix, err = calc.GetDistanceMatrix(context.Background(), points)
@@ -207,6 +235,113 @@ func TestGetDistanceMatrix_PartialCache(t *testing.T) {
 	}
 }
 
+func TestGetDistance_ExcludeIsSentAndCachedSeparately(t *testing.T) {
+	shared := newMockDistanceCache()
+	origin := models.Coordinates{Lat: 0, Lng: 0}
+	dest := models.Coordinates{Lat: 0.1, Lng: 0}
+	_ = shared.Set(context.Background(), &models.DistanceCacheEntry{Origin: origin, Destination: dest, DistanceMeters: 9000, DurationSecs: 400})
+
+	requestCount := 0
+	var excludes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		excludes = append(excludes, r.URL.Query().Get("exclude"))
+		if !strings.Contains(r.URL.Path, "/table/v1/driving/") {
+			t.Errorf("unexpected profile path %q", r.URL.Path)
+		}
+		_ = json.NewEncoder(w).Encode(osrmTableResponse{
+			Code:      "Ok",
+			Distances: [][]float64{{0, 12500}, {12400, 0}},
+			Durations: [][]float64{{0, 700}, {690, 0}},
+		})
+	}))
+	defer server.Close()
+
+	calc := NewOSRMCalculatorWithOptions(shared, OSRMOptions{Exclude: "motorway"}).(*osrmCalculator)
+	calc.baseURL = server.URL
+	calc.httpClient = server.Client()
+
+	for range 2 {
+		result, err := calc.GetDistance(context.Background(), origin, dest)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.DistanceMeters != 12500 {
+			t.Fatalf("distance = %f, want excluded-route 12500 rather than shared 9000", result.DistanceMeters)
+		}
+	}
+	if requestCount != 1 || excludes[0] != "motorway" {
+		t.Fatalf("requests=%d excludes=%v, want one request with exclude=motorway", requestCount, excludes)
+	}
+	if entry, _ := shared.Get(context.Background(), origin, dest); shared.Len() != 1 || entry.DistanceMeters != 9000 {
+		t.Fatalf("excluded-route distance leaked into shared cache: %#v", shared.entries)
+	}
+
+}
+
+// scopingDistanceCache keeps one mockDistanceCache per scope, the way the
+// persistent cache keeps one set of rows per scope.
+type scopingDistanceCache struct {
+	*mockDistanceCache
+	scopes map[string]*mockDistanceCache
+}
+
+func newScopingDistanceCache() *scopingDistanceCache {
+	return &scopingDistanceCache{mockDistanceCache: newMockDistanceCache(), scopes: make(map[string]*mockDistanceCache)}
+}
+
+func (c *scopingDistanceCache) Scoped(scope string) database.DistanceCacheRepository {
+	if c.scopes[scope] == nil {
+		c.scopes[scope] = newMockDistanceCache()
+	}
+	return c.scopes[scope]
+}
+
+func TestOSRMCacheIsScopedByServerAndKeptFromOtherProviders(t *testing.T) {
+	cache := newScopingDistanceCache()
+	origin := models.Coordinates{Lat: 0, Lng: 0}
+	dest := models.Coordinates{Lat: 0.1, Lng: 0}
+	_ = cache.Set(context.Background(), &models.DistanceCacheEntry{Origin: origin, Destination: dest, DistanceMeters: 9000, DurationSecs: 400})
+
+	newServer := func(distance float64, requests *int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*requests++
+			_ = json.NewEncoder(w).Encode(osrmTableResponse{
+				Code:      "Ok",
+				Distances: [][]float64{{0, distance}, {distance, 0}},
+				Durations: [][]float64{{0, 700}, {690, 0}},
+			})
+		}))
+	}
+	var firstRequests, secondRequests int
+	first := newServer(12500, &firstRequests)
+	defer first.Close()
+	second := newServer(13700, &secondRequests)
+	defer second.Close()
+
+	for _, tc := range []struct {
+		server   *httptest.Server
+		want     float64
+		requests *int
+	}{
+		{first, 12500, &firstRequests},
+		{second, 13700, &secondRequests},
+		{first, 12500, &firstRequests},
+	} {
+		calc := NewOSRMCalculatorWithOptions(cache, OSRMOptions{BaseURL: tc.server.URL})
+		result, err := calc.GetDistance(context.Background(), origin, dest)
+		if err != nil || result.DistanceMeters != tc.want {
+			t.Fatalf("GetDistance via %s = %#v, %v; want %.0f from that server rather than another provider's 9000", tc.server.URL, result, err, tc.want)
+		}
+	}
+	if firstRequests != 1 || secondRequests != 1 {
+		t.Fatalf("requests = %d and %d, want one per server with the repeat served from its scope", firstRequests, secondRequests)
+	}
+	if len(cache.scopes) != 2 || cache.Len() != 1 {
+		t.Fatalf("scopes = %d, unscoped entries = %d; want one scope per server and the other provider's entry untouched", len(cache.scopes), cache.Len())
+	}
+}
+
 func TestPrewarmPairs_RequestsOnlyMissingDirectedPairs(t *testing.T) {
 	cache := newMockDistanceCache()
 	origin := models.Coordinates{Lat: 0, Lng: 0}
@@ -252,8 +387,8 @@ func TestPrewarmPairs_RequestsOnlyMissingDirectedPairs(t *testing.T) {
 	if requestSources != "0" || !strings.Contains(requestRawQuery, "destinations=1;2") {
 		t.Fatalf("expected exact directed request sources=0 destinations=1;2, got sources=%q destinations=%q rawQuery=%q", requestSources, requestDestinations, requestRawQuery)
 	}
-	if cache.Count() != 2 {
-		t.Fatalf("cache entries = %d, want 2", cache.Count())
+	if cache.Len() != 2 {
+		t.Fatalf("cache entries = %d, want 2", cache.Len())
 	}
 	cachedA, err := cache.Get(context.Background(), origin, destA)
 	if err != nil {
@@ -320,8 +455,8 @@ func TestPrewarmPairs_DensePairsUseSingleRectangularRequest(t *testing.T) {
 	if !strings.Contains(requestRawQuery, "sources=0;1") || !strings.Contains(requestRawQuery, "destinations=2;3") {
 		t.Fatalf("expected rectangular request sources=0;1 destinations=2;3, rawQuery=%q", requestRawQuery)
 	}
-	if cache.Count() != 4 {
-		t.Fatalf("cache entries = %d, want 4", cache.Count())
+	if cache.Len() != 4 {
+		t.Fatalf("cache entries = %d, want 4", cache.Len())
 	}
 	cached, err := cache.Get(context.Background(), originB, destB)
 	if err != nil {
@@ -628,6 +763,45 @@ func TestGetDistance_SamePoint(t *testing.T) {
 	}
 }
 
+func TestGetDistance_SamePointFollowsConfiguredPrecision(t *testing.T) {
+	t.Cleanup(func() { models.SetSamePointPrecision(0) })
+
+	requestCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		_ = json.NewEncoder(w).Encode(osrmTableResponse{
+			Code:      "Ok",
+			Distances: [][]float64{{0, 8}, {8, 0}},
+			Durations: [][]float64{{0, 2}, {2, 0}},
+		})
+	}))
+	defer server.Close()
+
+	// About 8m apart: distinct at 5 decimals, one point at 4.
+	origin := models.Coordinates{Lat: 40.71196, Lng: -74.00601}
+	dest := models.Coordinates{Lat: 40.71203, Lng: -74.00601}
+
+	models.SetSamePointPrecision(5)
+	calc := &osrmCalculator{baseURL: server.URL, httpClient: server.Client(), cache: newMockDistanceCache()}
+	result, err := calc.GetDistance(context.Background(), origin, dest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requestCount != 1 || result.DistanceMeters != 8 {
+		t.Fatalf("precision 5: requests=%d distance=%f, want one request and 8m", requestCount, result.DistanceMeters)
+	}
+
+	models.SetSamePointPrecision(4)
+	calc = &osrmCalculator{baseURL: server.URL, httpClient: server.Client(), cache: newMockDistanceCache()}
+	result, err = calc.GetDistance(context.Background(), origin, dest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requestCount != 1 || result.DistanceMeters != 0 {
+		t.Fatalf("precision 4: requests=%d distance=%f, want the same-point shortcut", requestCount, result.DistanceMeters)
+	}
+}
+
 func TestGetDistanceMatrix_Empty(t *testing.T) {
 	cache := newMockDistanceCache()
 
@@ -680,3 +854,130 @@ func TestGetDistanceMatrix_SinglePoint(t *testing.T) {
 		t.Errorf("expected 0 distance for single point, got %f", matrix[0][0].DistanceMeters)
 	}
 }
+
+func TestGetDistancesFromPoint_EstimatesWhenOSRMUnreachable(t *testing.T) {
+	cache := newMockDistanceCache()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	calc := &osrmCalculator{
+		baseURL:          server.URL,
+		httpClient:       server.Client(),
+		cache:            cache,
+		fallbackSpeedKPH: 36,
+	}
+	origin := models.Coordinates{Lat: 0, Lng: 0}
+	results, err := calc.GetDistancesFromPoint(context.Background(), origin, []models.Coordinates{{Lat: 0.01, Lng: 0}})
+	if err != nil {
+		t.Fatalf("GetDistancesFromPoint() error = %v", err)
+	}
+	if len(results) != 1 || !results[0].Estimated {
+		t.Fatalf("results = %+v, want one estimated result", results)
+	}
+	// 0.01 degrees of latitude is about 1112m, which takes 111s at 36 km/h.
+	if math.Abs(results[0].DistanceMeters-1112) > 1 || math.Abs(results[0].DurationSecs-111.2) > 0.1 {
+		t.Fatalf("estimate = %.1fm %.1fs, want about 1112m and 111.2s", results[0].DistanceMeters, results[0].DurationSecs)
+	}
+	if cache.Len() != 0 {
+		t.Fatalf("cache entries = %d, want estimates left uncached", cache.Len())
+	}
+	if err := calc.PrewarmCache(context.Background(), []models.Coordinates{origin, {Lat: 0.01, Lng: 0}}); err != nil {
+		t.Fatalf("PrewarmCache() error = %v, want outage left to estimates", err)
+	}
+}
+
+func TestNewOSRMCalculatorWithOptions_UsesBaseURLAndProfileForSingleAndBatchedMatrices(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		coords := strings.Split(strings.TrimPrefix(r.URL.Path, "/table/v1/cycling/"), ";")
+		count := func(param string) int {
+			if value := r.URL.Query().Get(param); value != "" {
+				return len(strings.Split(value, ";"))
+			}
+			return len(coords)
+		}
+		response := osrmTableResponse{Code: "Ok"}
+		for range count("sources") {
+			row := make([]float64, count("destinations"))
+			for i := range row {
+				row[i] = 100
+			}
+			response.Distances = append(response.Distances, row)
+			response.Durations = append(response.Durations, row)
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	calc := NewOSRMCalculatorWithOptions(newMockDistanceCache(), OSRMOptions{BaseURL: server.URL + "/", Profile: "cycling"})
+	for _, n := range []int{3, maxOSRMCoordinates + 5} {
+		points := make([]models.Coordinates, n)
+		for i := range points {
+			points[i] = models.Coordinates{Lat: float64(i) * 0.01, Lng: 0}
+		}
+		if _, err := calc.GetDistanceMatrix(context.Background(), points); err != nil {
+			t.Fatalf("GetDistanceMatrix(%d points) error = %v", n, err)
+		}
+	}
+
+	if len(paths) < 2 {
+		t.Fatalf("requests = %d, want a single request and batched requests", len(paths))
+	}
+	for _, path := range paths {
+		if !strings.HasPrefix(path, "/table/v1/cycling/") {
+			t.Fatalf("request path = %q, want the configured server and cycling profile", path)
+		}
+	}
+}
+
+func TestOSRMCalculator_DamagedCacheFallsBackToProvider(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		count := strings.Count(strings.TrimPrefix(r.URL.Path, "/table/v1/driving/"), ";") + 1
+		rows, cols := count, count
+		if sources := r.URL.Query().Get("sources"); sources != "" {
+			rows = strings.Count(sources, ";") + 1
+		}
+		if destinations := r.URL.Query().Get("destinations"); destinations != "" {
+			cols = strings.Count(destinations, ";") + 1
+		}
+		resp := osrmTableResponse{Code: "Ok"}
+		for i := 0; i < rows; i++ {
+			resp.Distances = append(resp.Distances, make([]float64, cols))
+			resp.Durations = append(resp.Durations, make([]float64, cols))
+			for j := 0; j < cols; j++ {
+				resp.Distances[i][j] = 1000
+				resp.Durations[i][j] = 60
+			}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	calc := &osrmCalculator{
+		baseURL:    server.URL,
+		httpClient: server.Client(),
+		cache:      damagedCache{},
+	}
+
+	origin := models.Coordinates{Lat: 35, Lng: -79}
+	dest := models.Coordinates{Lat: 36, Lng: -79}
+	result, err := calc.GetDistance(context.Background(), origin, dest)
+	if err != nil || result.DistanceMeters != 1000 {
+		t.Fatalf("GetDistance() = %+v, %v; want the provider's distance", result, err)
+	}
+	matrix, err := calc.GetDistanceMatrix(context.Background(), []models.Coordinates{origin, dest})
+	if err != nil || matrix[1][0].DurationSecs != 60 {
+		t.Fatalf("GetDistanceMatrix() = %+v, %v; want the provider's durations", matrix, err)
+	}
+	if err := calc.PrewarmPairs(context.Background(), []DistancePair{{Origin: dest, Destination: origin}}); err != nil {
+		t.Fatalf("PrewarmPairs() error = %v; want a damaged cache to be skipped", err)
+	}
+	if requests < 2 {
+		t.Fatalf("provider requests = %d, want the damaged cache bypassed", requests)
+	}
+}
@@ -3,6 +3,7 @@ package distance
 import (
 	"context"
 	"fmt"
+	"ride-home-router/internal/database"
 	"ride-home-router/internal/models"
 )
 
@@ -43,6 +44,68 @@ func PrewarmRoutingPairs(ctx context.Context, calc DistanceCalculator, pairs []D
 	return calc.PrewarmCache(ctx, points)
 }
 
+// MatrixPairs returns every directed pair between distinct points, the pairs a
+// full distance matrix over them holds. Points that round to the same
+// location are treated as one.
+func MatrixPairs(points []models.Coordinates) []DistancePair {
+	seen := make(map[string]struct{}, len(points))
+	unique := make([]models.Coordinates, 0, len(points))
+	for _, point := range points {
+		key := coordinatePointKey(point)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		unique = append(unique, point)
+	}
+
+	pairs := make([]DistancePair, 0, len(unique)*max(len(unique)-1, 0))
+	for i, origin := range unique {
+		for j, destination := range unique {
+			if i != j {
+				pairs = append(pairs, DistancePair{Origin: origin, Destination: destination})
+			}
+		}
+	}
+	return pairs
+}
+
+// CacheCoverage counts how many directed pairs already have a cached
+// distance. Missing pairs are what a calculation would fetch from the provider.
+type CacheCoverage struct {
+	TotalPairs   int `json:"total_pairs"`
+	CachedPairs  int `json:"cached_pairs"`
+	MissingPairs int `json:"missing_pairs"`
+}
+
+// MeasureCacheCoverage looks the pairs up in the cache without fetching the
+// missing ones. Duplicate pairs are counted once.
+func MeasureCacheCoverage(ctx context.Context, cache database.DistanceCacheRepository, pairs []DistancePair) (CacheCoverage, error) {
+	lookups := make([]struct{ Origin, Dest models.Coordinates }, 0, len(pairs))
+	seen := make(map[string]struct{}, len(pairs))
+	for _, pair := range pairs {
+		key := PairCacheKey(pair.Origin, pair.Destination)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		lookups = append(lookups, struct{ Origin, Dest models.Coordinates }{Origin: pair.Origin, Dest: pair.Destination})
+	}
+
+	cached, err := cache.GetBatch(ctx, lookups)
+	if err != nil {
+		return CacheCoverage{}, err
+	}
+	coverage := CacheCoverage{TotalPairs: len(lookups)}
+	for _, lookup := range lookups {
+		if cached[PairCacheKey(lookup.Origin, lookup.Dest)] != nil {
+			coverage.CachedPairs++
+		}
+	}
+	coverage.MissingPairs = coverage.TotalPairs - coverage.CachedPairs
+	return coverage, nil
+}
+
 func coordinatePointKey(coord models.Coordinates) string {
 	return fmt.Sprintf(
 		"%.5f,%.5f",
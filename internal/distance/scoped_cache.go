@@ -0,0 +1,116 @@
+package distance
+
+import (
+	"context"
+	"ride-home-router/internal/database"
+	"ride-home-router/internal/models"
+	"sync"
+)
+
+// scopedCache returns cache's view of scope, or an in-memory cache for scope
+// when cache cannot keep scopes apart.
+func scopedCache(cache database.DistanceCacheRepository, scope string) database.DistanceCacheRepository {
+	if scoped, ok := cache.(database.ScopedDistanceCache); ok {
+		return scoped.Scoped(scope)
+	}
+	return newScopedDistanceCache(scope)
+}
+
+// scopedDistanceCache is an in-memory DistanceCacheRepository whose keys carry
+// a routing scope (provider, server, profile and exclude set), keeping those
+// results separate from every other scope.
+type scopedDistanceCache struct {
+	scope   string
+	mu      sync.RWMutex
+	entries map[string]models.DistanceCacheEntry
+}
+
+func newScopedDistanceCache(scope string) *scopedDistanceCache {
+	return &scopedDistanceCache{scope: scope, entries: make(map[string]models.DistanceCacheEntry)}
+}
+
+func (c *scopedDistanceCache) key(origin, dest models.Coordinates) string {
+	return c.scope + "|" + PairCacheKey(origin, dest)
+}
+
+func (c *scopedDistanceCache) Get(_ context.Context, origin, dest models.Coordinates) (*models.DistanceCacheEntry, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.entries[c.key(origin, dest)]
+	if !ok {
+		return nil, database.ErrCacheMiss
+	}
+	return &entry, nil
+}
+
+// GetBatch returns hits keyed by PairCacheKey, matching the persistent cache.
+func (c *scopedDistanceCache) GetBatch(_ context.Context, pairs []struct{ Origin, Dest models.Coordinates }) (map[string]*models.DistanceCacheEntry, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	result := make(map[string]*models.DistanceCacheEntry, len(pairs))
+	for _, pair := range pairs {
+		if entry, ok := c.entries[c.key(pair.Origin, pair.Dest)]; ok {
+			result[PairCacheKey(pair.Origin, pair.Dest)] = &entry
+		}
+	}
+	return result, nil
+}
+
+func (c *scopedDistanceCache) Set(_ context.Context, entry *models.DistanceCacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[c.key(entry.Origin, entry.Destination)] = *entry
+	return nil
+}
+
+func (c *scopedDistanceCache) SetBatch(_ context.Context, entries []models.DistanceCacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, entry := range entries {
+		c.entries[c.key(entry.Origin, entry.Destination)] = entry
+	}
+	return nil
+}
+
+func (c *scopedDistanceCache) Delete(_ context.Context, origin, dest models.Coordinates) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, c.key(origin, dest))
+	return nil
+}
+
+func (c *scopedDistanceCache) DeleteTouching(_ context.Context, point models.Coordinates) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	removed := 0
+	for key, entry := range c.entries {
+		if sameCachePoint(entry.Origin, point) || sameCachePoint(entry.Destination, point) {
+			delete(c.entries, key)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func (c *scopedDistanceCache) Clear(_ context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]models.DistanceCacheEntry)
+	return nil
+}
+
+func (c *scopedDistanceCache) Count(_ context.Context) (int, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries), nil
+}
+
+// SizeBytes is zero: scoped entries live in memory only.
+func (c *scopedDistanceCache) SizeBytes(context.Context) (int64, error) {
+	return 0, nil
+}
+
+// sameCachePoint reports whether a and b share a cache key coordinate.
+func sameCachePoint(a, b models.Coordinates) bool {
+	return models.RoundCoordinate(a.Lat) == models.RoundCoordinate(b.Lat) && models.RoundCoordinate(a.Lng) == models.RoundCoordinate(b.Lng)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"ride-home-router/internal/database"
+	"ride-home-router/internal/models"
+	"strings"
+)
+
+// BackupListResponse lists the stored database backups, newest first.
+type BackupListResponse struct {
+	Backups []models.Backup `json:"backups"`
+}
+
+func (h *Handler) backupStore(w http.ResponseWriter) (database.BackupStore, bool) {
+	store, ok := h.DB.(database.BackupStore)
+	if !ok {
+		h.writeError(w, http.StatusNotImplemented, "NOT_SUPPORTED", messageBackupsNotSupported, nil)
+		return nil, false
+	}
+	return store, true
+}
+
+// HandleListBackups handles GET /api/v1/backups
+func (h *Handler) HandleListBackups(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[HTTP] GET /api/v1/backups")
+	store, ok := h.backupStore(w)
+	if !ok {
+		return
+	}
+
+	backups, err := store.ListBackups()
+	if err != nil {
+		log.Printf("[ERROR] Failed to list backups: err=%v", err)
+		h.handleInternalError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, BackupListResponse{Backups: backups})
+}
+
+// HandleRestoreBackup handles POST /api/v1/backups/restore
+func (h *Handler) HandleRestoreBackup(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleValidationError(w, messageInvalidRequestBody)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		h.handleValidationError(w, messageBackupNameRequired)
+		return
+	}
+
+	log.Printf("[HTTP] POST /api/v1/backups/restore: name=%s", req.Name)
+	store, ok := h.backupStore(w)
+	if !ok {
+		return
+	}
+
+	if err := store.RestoreBackup(r.Context(), req.Name); err != nil {
+		if h.checkNotFound(err) {
+			h.handleNotFound(w, messageBackupNotFound)
+			return
+		}
+		log.Printf("[ERROR] Failed to restore backup: name=%s err=%v", req.Name, err)
+		h.handleInternalError(w, err)
+		return
+	}
+
+	backups, err := store.ListBackups()
+	if err != nil {
+		h.handleInternalError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, BackupListResponse{Backups: backups})
+}
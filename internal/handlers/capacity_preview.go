@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"ride-home-router/internal/httpx"
+	"ride-home-router/internal/models"
+	"strconv"
+)
+
+// CapacityPreviewRequest is the selection to check before calculating routes.
+// OrgVehicleAssignments maps a selected driver to the van they drive.
+type CapacityPreviewRequest struct {
+	ParticipantIDs        []int64         `json:"participant_ids"`
+	DriverIDs             []int64         `json:"driver_ids"`
+	OrgVehicleAssignments map[int64]int64 `json:"org_vehicle_assignments"`
+}
+
+// CapacityPreviewDriver is one selected driver's seats for the event.
+type CapacityPreviewDriver struct {
+	DriverID       int64  `json:"driver_id"`
+	Name           string `json:"name"`
+	Capacity       int    `json:"capacity"`
+	OrgVehicleName string `json:"org_vehicle_name,omitempty"`
+}
+
+// CapacityPreviewResponse compares the selected participants with the seats
+// the selected drivers bring. Shortfall is how many more seats are needed.
+type CapacityPreviewResponse struct {
+	TotalParticipants int                     `json:"total_participants"`
+	TotalSeats        int                     `json:"total_seats"`
+	Shortfall         int                     `json:"shortfall"`
+	Drivers           []CapacityPreviewDriver `json:"drivers"`
+}
+
+// HandlePreviewCapacity handles POST /api/v1/routes/preview-capacity
+//
+// It counts seats only, so it never calls the distance provider and is cheap
+// enough to run whenever the event form changes.
+func (h *Handler) HandlePreviewCapacity(w http.ResponseWriter, r *http.Request) {
+	req, err := parseCapacityPreviewRequest(r)
+	if err != nil {
+		log.Printf("[HTTP] POST /api/v1/routes/preview-capacity: invalid_request err=%v", err)
+		h.handleValidationErrorHTMX(w, r, err.Error())
+		return
+	}
+
+	participants, err := h.DB.Participants().GetByIDs(r.Context(), req.ParticipantIDs)
+	if err != nil {
+		h.handleInternalError(w, err)
+		return
+	}
+	if len(participants) != len(req.ParticipantIDs) {
+		h.handleValidationErrorHTMX(w, r, routeCalculationValidationMessage(errSomeParticipantsNotFound))
+		return
+	}
+	drivers, err := h.DB.Drivers().GetByIDs(r.Context(), req.DriverIDs)
+	if err != nil {
+		h.handleInternalError(w, err)
+		return
+	}
+	if len(drivers) != len(req.DriverIDs) {
+		h.handleValidationErrorHTMX(w, r, routeCalculationValidationMessage(errSomeDriversNotFound))
+		return
+	}
+
+	orgVehicleMap, err := newRouteCalculation(h.DB, h.Router, h.RouteSession).loadAssignedOrgVehicles(r.Context(), req.OrgVehicleAssignments)
+	if err != nil {
+		if errors.Is(err, errSelectedVanNotFound) {
+			h.handleValidationErrorHTMX(w, r, err.Error())
+			return
+		}
+		h.handleInternalError(w, err)
+		return
+	}
+	drivers, driverOrgVehicles := applyOrgVehicleAssignments(drivers, req.OrgVehicleAssignments, orgVehicleMap)
+
+	preview := buildCapacityPreview(len(participants), drivers, driverOrgVehicles)
+	log.Printf("[HTTP] POST /api/v1/routes/preview-capacity: participants=%d seats=%d shortfall=%d", preview.TotalParticipants, preview.TotalSeats, preview.Shortfall)
+
+	if h.isHTMX(r) {
+		h.renderTemplate(w, "capacity_preview", preview)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, preview)
+}
+
+// parseCapacityPreviewRequest reads the selection from the event form or from
+// a JSON body. Van assignments are checked the same way route calculation
+// checks them.
+func parseCapacityPreviewRequest(r *http.Request) (CapacityPreviewRequest, error) {
+	var req CapacityPreviewRequest
+	if httpx.HasFormContentType(r.Header.Get(httpx.HeaderContentType)) {
+		if err := r.ParseForm(); err != nil {
+			return req, errors.New(messageInvalidFormData)
+		}
+		req.ParticipantIDs = parseFormIDs(r.Form["participant_ids"])
+		req.DriverIDs = parseFormIDs(r.Form["driver_ids"])
+		assignments, err := parseOrgVehicleAssignments(r.Form, req.DriverIDs)
+		if err != nil {
+			return req, err
+		}
+		req.OrgVehicleAssignments = assignments
+		return req, nil
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, errors.New(messageInvalidRequestBody)
+	}
+	form := make(url.Values, len(req.OrgVehicleAssignments))
+	for driverID, vehicleID := range req.OrgVehicleAssignments {
+		form.Set("org_vehicle_"+strconv.FormatInt(driverID, 10), strconv.FormatInt(vehicleID, 10))
+	}
+	assignments, err := parseOrgVehicleAssignments(form, req.DriverIDs)
+	if err != nil {
+		return req, err
+	}
+	req.OrgVehicleAssignments = assignments
+	return req, nil
+}
+
+// parseFormIDs parses repeated ID form values, skipping any that are not
+// numbers as the route calculation form does.
+func parseFormIDs(values []string) []int64 {
+	ids := make([]int64, 0, len(values))
+	for _, value := range values {
+		if id, err := strconv.ParseInt(value, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func buildCapacityPreview(participantCount int, drivers []models.Driver, driverOrgVehicles map[int64]*models.OrganizationVehicle) CapacityPreviewResponse {
+	preview := CapacityPreviewResponse{
+		TotalParticipants: participantCount,
+		Drivers:           make([]CapacityPreviewDriver, 0, len(drivers)),
+	}
+	for _, driver := range drivers {
+		// Route calculation drops inactive drivers, so their seats do not count.
+		if driver.Inactive {
+			continue
+		}
+		entry := CapacityPreviewDriver{
+			DriverID: driver.ID,
+			Name:     driver.Name,
+			Capacity: driver.VehicleCapacity,
+		}
+		if vehicle, ok := driverOrgVehicles[driver.ID]; ok && vehicle != nil {
+			entry.OrgVehicleName = vehicle.Name
+		}
+		preview.TotalSeats += entry.Capacity
+		preview.Drivers = append(preview.Drivers, entry)
+	}
+	if preview.TotalParticipants > preview.TotalSeats {
+		preview.Shortfall = preview.TotalParticipants - preview.TotalSeats
+	}
+	return preview
+}
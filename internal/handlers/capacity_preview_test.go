@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"ride-home-router/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestHandlePreviewCapacity_CountsAssignedVanSeats(t *testing.T) {
+	handler, store := newTestManagementHandler(t)
+	ctx := context.Background()
+
+	var participantIDs []int64
+	for _, name := range []string{"A", "B", "C", "D"} {
+		participant, err := store.Participants().Create(ctx, &models.Participant{Name: name, Address: name + " St", Lat: 40.1, Lng: -73.9})
+		if err != nil {
+			t.Fatalf("create participant: %v", err)
+		}
+		participantIDs = append(participantIDs, participant.ID)
+	}
+	near, err := store.Drivers().Create(ctx, &models.Driver{Name: "Near", Address: "1 Near Rd", Lat: 40.2, Lng: -73.8, VehicleCapacity: 1})
+	if err != nil {
+		t.Fatalf("create driver: %v", err)
+	}
+	far, err := store.Drivers().Create(ctx, &models.Driver{Name: "Far", Address: "2 Far Rd", Lat: 40.3, Lng: -73.7, VehicleCapacity: 1})
+	if err != nil {
+		t.Fatalf("create driver: %v", err)
+	}
+	van, err := store.OrganizationVehicles().Create(ctx, &models.OrganizationVehicle{Name: "Blue Van", Capacity: 2})
+	if err != nil {
+		t.Fatalf("create organization vehicle: %v", err)
+	}
+
+	body, err := json.Marshal(CapacityPreviewRequest{
+		ParticipantIDs:        participantIDs,
+		DriverIDs:             []int64{near.ID, far.ID},
+		OrgVehicleAssignments: map[int64]int64{far.ID: van.ID},
+	})
+	if err != nil {
+		t.Fatalf("marshal request: %v", err)
+	}
+	req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/api/v1/routes/preview-capacity", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	handler.HandlePreviewCapacity(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d body=%q", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var preview CapacityPreviewResponse
+	if err := json.NewDecoder(rr.Body).Decode(&preview); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if preview.TotalParticipants != 4 || preview.TotalSeats != 3 || preview.Shortfall != 1 {
+		t.Fatalf("preview = %+v, want 4 participants, 3 seats and a shortfall of 1", preview)
+	}
+	if len(preview.Drivers) != 2 {
+		t.Fatalf("drivers = %+v, want 2", preview.Drivers)
+	}
+	for _, driver := range preview.Drivers {
+		if driver.DriverID == far.ID && (driver.Capacity != van.Capacity || driver.OrgVehicleName != van.Name) {
+			t.Fatalf("van driver = %+v, want %d seats in %q", driver, van.Capacity, van.Name)
+		}
+	}
+}
+
+func TestHandlePreviewCapacity_SkipsInactiveDrivers(t *testing.T) {
+	handler, store := newTestManagementHandler(t)
+	ctx := context.Background()
+
+	var participantIDs []int64
+	for _, name := range []string{"A", "B"} {
+		participant, err := store.Participants().Create(ctx, &models.Participant{Name: name, Address: name + " St", Lat: 40.1, Lng: -73.9})
+		if err != nil {
+			t.Fatalf("create participant: %v", err)
+		}
+		participantIDs = append(participantIDs, participant.ID)
+	}
+	active, err := store.Drivers().Create(ctx, &models.Driver{Name: "Active", Address: "1 Near Rd", Lat: 40.2, Lng: -73.8, VehicleCapacity: 1})
+	if err != nil {
+		t.Fatalf("create driver: %v", err)
+	}
+	inactive, err := store.Drivers().Create(ctx, &models.Driver{Name: "Away", Address: "2 Far Rd", Lat: 40.3, Lng: -73.7, VehicleCapacity: 4, Inactive: true})
+	if err != nil {
+		t.Fatalf("create driver: %v", err)
+	}
+
+	body, err := json.Marshal(CapacityPreviewRequest{ParticipantIDs: participantIDs, DriverIDs: []int64{active.ID, inactive.ID}})
+	if err != nil {
+		t.Fatalf("marshal request: %v", err)
+	}
+	req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/api/v1/routes/preview-capacity", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	handler.HandlePreviewCapacity(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d body=%q", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var preview CapacityPreviewResponse
+	if err := json.NewDecoder(rr.Body).Decode(&preview); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if preview.TotalSeats != 1 || preview.Shortfall != 1 || len(preview.Drivers) != 1 || preview.Drivers[0].DriverID != active.ID {
+		t.Fatalf("preview = %+v, want only the active driver's seat and a shortfall of 1", preview)
+	}
+}
+
+func TestHandlePreviewCapacity_HTMXShowsSeatsNeeded(t *testing.T) {
+	handler, store := newTestManagementHandler(t)
+	ctx := context.Background()
+
+	form := url.Values{}
+	for _, name := range []string{"A", "B", "C"} {
+		participant, err := store.Participants().Create(ctx, &models.Participant{Name: name, Address: name + " St", Lat: 40.1, Lng: -73.9})
+		if err != nil {
+			t.Fatalf("create participant: %v", err)
+		}
+		form.Add("participant_ids", int64ToString(participant.ID))
+	}
+	driver, err := store.Drivers().Create(ctx, &models.Driver{Name: "Driver", Address: "1 Driver Rd", Lat: 40.2, Lng: -73.8, VehicleCapacity: 1})
+	if err != nil {
+		t.Fatalf("create driver: %v", err)
+	}
+	form.Add("driver_ids", int64ToString(driver.ID))
+
+	req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/api/v1/routes/preview-capacity", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("HX-Request", "true")
+	rr := httptest.NewRecorder()
+
+	handler.HandlePreviewCapacity(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d body=%q", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if body := rr.Body.String(); !strings.Contains(body, "2 more seats needed") {
+		t.Fatalf("expected shortfall message, body=%q", body)
+	}
+}
+
+func TestHandlePreviewCapacity_RejectsVanForUnselectedDriver(t *testing.T) {
+	handler, _ := newTestManagementHandler(t)
+
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/routes/preview-capacity", strings.NewReader(`{"driver_ids":[1],"org_vehicle_assignments":{"2":5}}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	handler.HandlePreviewCapacity(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d body=%q", rr.Code, http.StatusBadRequest, rr.Body.String())
+	}
+}
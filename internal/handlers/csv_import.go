@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"io"
+	"log"
+	"net/http"
+	"ride-home-router/internal/httpx"
+	"strings"
+)
+
+// maxCSVImportBytes bounds the CSV accepted by the driver and participant
+// imports.
+const maxCSVImportBytes = 1 << 20
+
+// csvImportRow is one data row of an uploaded CSV. Number is the 1-based line
+// number in the file.
+type csvImportRow struct {
+	number int
+	record []string
+}
+
+// field returns the trimmed value in column index, or "" when the row is
+// shorter than that.
+func (row csvImportRow) field(index int) string {
+	if index >= len(row.record) {
+		return ""
+	}
+	return strings.TrimSpace(row.record[index])
+}
+
+// fail logs why the row could not be imported and returns the message
+// reported for it.
+func (row csvImportRow) fail(kind string, err error) string {
+	log.Printf("[HTTP] %s import row %d failed: record=%q err=%v", kind, row.number, row.record, err)
+	return err.Error()
+}
+
+// readCSVImport reads the CSV uploaded to endpoint into its data rows,
+// skipping a header row whose first column is "name". When the body cannot be
+// read, is not valid CSV or holds no data rows, it writes the validation error
+// and returns false.
+func (h *Handler) readCSVImport(w http.ResponseWriter, r *http.Request, endpoint, invalidMessage, emptyMessage string) ([]csvImportRow, bool) {
+	body, err := csvImportBody(w, r)
+	if err != nil {
+		log.Printf("[HTTP] POST %s: invalid body err=%v", endpoint, err)
+		h.handleValidationError(w, messageInvalidRequestBody)
+		return nil, false
+	}
+	defer func() { _ = body.Close() }()
+
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		log.Printf("[HTTP] POST %s: invalid CSV err=%v", endpoint, err)
+		h.handleValidationError(w, invalidMessage)
+		return nil, false
+	}
+
+	startRow := 1
+	if len(records) > 0 && strings.EqualFold(strings.TrimSpace(records[0][0]), "name") {
+		records = records[1:]
+		startRow = 2
+	}
+	if len(records) == 0 {
+		h.handleValidationError(w, emptyMessage)
+		return nil, false
+	}
+
+	rows := make([]csvImportRow, len(records))
+	for i, record := range records {
+		rows[i] = csvImportRow{number: startRow + i, record: record}
+	}
+	return rows, true
+}
+
+// csvImportBody returns the uploaded "file" part for multipart requests and
+// the raw request body otherwise.
+func csvImportBody(w http.ResponseWriter, r *http.Request) (io.ReadCloser, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxCSVImportBytes)
+	if !strings.HasPrefix(r.Header.Get(httpx.HeaderContentType), "multipart/form-data") {
+		return r.Body, nil
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"ride-home-router/internal/database"
+	"ride-home-router/internal/distance"
+	"ride-home-router/internal/routing"
+)
+
+// DistanceCacheCoverageRequest names the selection a calculation would route.
+type DistanceCacheCoverageRequest struct {
+	ParticipantIDs     []int64 `json:"participant_ids"`
+	DriverIDs          []int64 `json:"driver_ids"`
+	ActivityLocationID int64   `json:"activity_location_id"`
+	Mode               string  `json:"mode"`
+}
+
+// HandleDistanceCacheCoverage handles POST /api/v1/routes/cache-coverage
+//
+// It reports how many of the distance pairs a calculation for the selection
+// needs are already cached. Missing pairs are not fetched, so the call is
+// cheap and shows how long the real calculation will wait on the provider.
+func (h *Handler) HandleDistanceCacheCoverage(w http.ResponseWriter, r *http.Request) {
+	var req DistanceCacheCoverageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("[HTTP] POST /api/v1/routes/cache-coverage: invalid_json err=%v", err)
+		h.handleValidationError(w, messageInvalidRequestBody)
+		return
+	}
+	if req.ActivityLocationID == 0 {
+		h.handleValidationError(w, messageChooseActivityLocationForEvent)
+		return
+	}
+	mode, err := normalizeRouteMode(req.Mode)
+	if err != nil {
+		h.handleValidationError(w, err.Error())
+		return
+	}
+
+	calculation := newRouteCalculation(h.DB, h.Router, h.RouteSession)
+	selection, err := calculation.loadSelection(r.Context(), routeCalculationInput{
+		ParticipantIDs:     req.ParticipantIDs,
+		DriverIDs:          req.DriverIDs,
+		ActivityLocationID: req.ActivityLocationID,
+	})
+	if err != nil {
+		if isRouteSelectionError(err) {
+			h.handleValidationError(w, routeCalculationValidationMessage(err))
+			return
+		}
+		h.handleInternalError(w, err)
+		return
+	}
+
+	pairs := routing.RequiredDistancePairs(&routing.RoutingRequest{
+		InstituteCoords: selection.activityLocation.GetCoords(),
+		Participants:    selection.participants,
+		Drivers:         selection.drivers,
+		Mode:            mode,
+	})
+	coverage, err := distance.MeasureCacheCoverage(r.Context(), h.distanceCache(), pairs)
+	if err != nil {
+		log.Printf("[ERROR] Failed to measure distance cache coverage: err=%v", err)
+		h.handleInternalError(w, err)
+		return
+	}
+
+	log.Printf("[HTTP] POST /api/v1/routes/cache-coverage: participants=%d drivers=%d mode=%s cached=%d missing=%d",
+		len(selection.participants), len(selection.drivers), mode, coverage.CachedPairs, coverage.MissingPairs)
+	h.writeJSON(w, http.StatusOK, coverage)
+}
+
+// distanceCache returns the cache the distance calculator reads, or the
+// store's when the calculator does not hand one out.
+func (h *Handler) distanceCache() database.DistanceCacheRepository {
+	if provider, ok := h.DistanceCalc.(distance.CacheProvider); ok {
+		return provider.DistanceCache()
+	}
+	return h.DB.DistanceCache()
+}
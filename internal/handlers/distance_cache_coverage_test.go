@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"ride-home-router/internal/distance"
+	"ride-home-router/internal/models"
+	"ride-home-router/internal/sqlite"
+	"testing"
+)
+
+func TestHandleDistanceCacheCoverageCountsCachedAndMissingPairs(t *testing.T) {
+	handler, store := newTestManagementHandler(t)
+	ctx := context.Background()
+	request := createCoverageSelection(t, store)
+
+	// Dropoff needs activity->each rider, rider<->rider, each rider->driver
+	// and activity->driver: 7 pairs. Warm two of them plus one the
+	// selection never reads.
+	if err := store.DistanceCache().SetBatch(ctx, []models.DistanceCacheEntry{
+		{Origin: models.Coordinates{Lat: 0, Lng: 0}, Destination: models.Coordinates{Lat: 1, Lng: 0}, DistanceMeters: 1000, DurationSecs: 60},
+		{Origin: models.Coordinates{Lat: 1, Lng: 0}, Destination: models.Coordinates{Lat: 2, Lng: 0}, DistanceMeters: 1000, DurationSecs: 60},
+		{Origin: models.Coordinates{Lat: 9, Lng: 9}, Destination: models.Coordinates{Lat: 1, Lng: 0}, DistanceMeters: 1000, DurationSecs: 60},
+	}); err != nil {
+		t.Fatalf("warm distance cache: %v", err)
+	}
+
+	coverage := requestCacheCoverage(t, handler, request)
+	if coverage != (distance.CacheCoverage{TotalPairs: 7, CachedPairs: 2, MissingPairs: 5}) {
+		t.Fatalf("coverage = %+v, want 7 total, 2 cached, 5 missing", coverage)
+	}
+}
+
+func TestHandleDistanceCacheCoverageReadsTheCalculatorsScope(t *testing.T) {
+	handler, store := newTestManagementHandler(t)
+	handler.DistanceCalc = distance.NewOSRMCalculator(store.DistanceCache())
+	ctx := context.Background()
+	request := createCoverageSelection(t, store)
+
+	// The store's default scope holds another provider's distances; only the
+	// pair warmed in the OSRM calculator's own scope counts.
+	if err := store.DistanceCache().Set(ctx, &models.DistanceCacheEntry{Origin: models.Coordinates{Lat: 0, Lng: 0}, Destination: models.Coordinates{Lat: 1, Lng: 0}, DistanceMeters: 1000, DurationSecs: 60}); err != nil {
+		t.Fatalf("warm shared distance cache: %v", err)
+	}
+	osrmCache := handler.DistanceCalc.(distance.CacheProvider).DistanceCache()
+	if err := osrmCache.Set(ctx, &models.DistanceCacheEntry{Origin: models.Coordinates{Lat: 1, Lng: 0}, Destination: models.Coordinates{Lat: 2, Lng: 0}, DistanceMeters: 1000, DurationSecs: 60}); err != nil {
+		t.Fatalf("warm OSRM distance cache: %v", err)
+	}
+
+	coverage := requestCacheCoverage(t, handler, request)
+	if coverage != (distance.CacheCoverage{TotalPairs: 7, CachedPairs: 1, MissingPairs: 6}) {
+		t.Fatalf("coverage = %+v, want 7 total, 1 cached, 6 missing", coverage)
+	}
+}
+
+// createCoverageSelection stores an activity location at (0,0), a driver at
+// (3,0) and riders at (1,0) and (2,0), and returns a dropoff request for them.
+func createCoverageSelection(t *testing.T, store *sqlite.Store) DistanceCacheCoverageRequest {
+	t.Helper()
+	ctx := context.Background()
+	location, err := store.ActivityLocations().Create(ctx, &models.ActivityLocation{Name: "Gym", Address: "Gym", Lat: 0, Lng: 0})
+	if err != nil {
+		t.Fatalf("create activity location: %v", err)
+	}
+	driver, err := store.Drivers().Create(ctx, &models.Driver{Name: "Driver", Address: "Driver", Lat: 3, Lng: 0, VehicleCapacity: 2})
+	if err != nil {
+		t.Fatalf("create driver: %v", err)
+	}
+	var participantIDs []int64
+	for _, lat := range []float64{1, 2} {
+		participant, err := store.Participants().Create(ctx, &models.Participant{Name: "Rider", Address: "Rider", Lat: lat, Lng: 0})
+		if err != nil {
+			t.Fatalf("create participant: %v", err)
+		}
+		participantIDs = append(participantIDs, participant.ID)
+	}
+	return DistanceCacheCoverageRequest{
+		ParticipantIDs:     participantIDs,
+		DriverIDs:          []int64{driver.ID},
+		ActivityLocationID: location.ID,
+		Mode:               string(models.RouteModeDropoff),
+	}
+}
+
+func requestCacheCoverage(t *testing.T, handler *Handler, request DistanceCacheCoverageRequest) distance.CacheCoverage {
+	t.Helper()
+	body, _ := json.Marshal(request)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/routes/cache-coverage", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.HandleDistanceCacheCoverage(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body=%s", w.Code, w.Body.String())
+	}
+	var coverage distance.CacheCoverage
+	if err := json.NewDecoder(w.Body).Decode(&coverage); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return coverage
+}
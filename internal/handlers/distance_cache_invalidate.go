@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"ride-home-router/internal/models"
+)
+
+// DistanceCacheInvalidateResponse reports how many cached pairs were removed.
+type DistanceCacheInvalidateResponse struct {
+	Removed int `json:"removed"`
+}
+
+// HandleInvalidateDistanceCache handles POST /api/v1/routes/cache-invalidate
+//
+// It removes every cached distance pair that starts or ends at the given
+// coordinate, so after an address correction only that point's legs are
+// fetched again instead of clearing the whole cache.
+func (h *Handler) HandleInvalidateDistanceCache(w http.ResponseWriter, r *http.Request) {
+	var point models.Coordinates
+	if err := json.NewDecoder(r.Body).Decode(&point); err != nil {
+		log.Printf("[HTTP] POST /api/v1/routes/cache-invalidate: invalid_json err=%v", err)
+		h.handleValidationError(w, messageInvalidRequestBody)
+		return
+	}
+	if point.Lat < -90 || point.Lat > 90 || point.Lng < -180 || point.Lng > 180 {
+		h.handleValidationError(w, messageInvalidCoordinates)
+		return
+	}
+
+	removed, err := h.DB.DistanceCache().DeleteTouching(r.Context(), point)
+	if err != nil {
+		log.Printf("[ERROR] Failed to invalidate distance cache: lat=%.5f lng=%.5f err=%v", point.Lat, point.Lng, err)
+		h.handleInternalError(w, err)
+		return
+	}
+
+	log.Printf("[HTTP] POST /api/v1/routes/cache-invalidate: lat=%.5f lng=%.5f removed=%d", point.Lat, point.Lng, removed)
+	h.writeJSON(w, http.StatusOK, DistanceCacheInvalidateResponse{Removed: removed})
+}
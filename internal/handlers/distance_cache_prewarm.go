@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"ride-home-router/internal/distance"
+	"ride-home-router/internal/models"
+	"strconv"
+	"time"
+)
+
+// defaultDistanceCachePrewarmTimeout bounds a prewarm when the caller sets no
+// timeout of their own.
+const defaultDistanceCachePrewarmTimeout = 5 * time.Minute
+
+// DistanceCachePrewarmResponse reports a prewarm of the full distance matrix
+// over every stored location. AlreadyCachedPairs were cached before it ran;
+// MissingPairs are still uncached afterwards, which is only nonzero when the
+// prewarm timed out.
+type DistanceCachePrewarmResponse struct {
+	Points             int  `json:"points"`
+	RequestedPairs     int  `json:"requested_pairs"`
+	AlreadyCachedPairs int  `json:"already_cached_pairs"`
+	MissingPairs       int  `json:"missing_pairs"`
+	TimedOut           bool `json:"timed_out"`
+}
+
+// HandlePrewarmDistanceCache handles POST /api/v1/distance-cache/prewarm
+//
+// It fetches the distance between every pair of stored participants, drivers
+// and activity locations through the calculator's own batched matrix fetch,
+// so later calculations over any selection read only from the cache. The
+// optional timeout_secs query value bounds the run; pairs fetched before it
+// expires stay cached.
+func (h *Handler) HandlePrewarmDistanceCache(w http.ResponseWriter, r *http.Request) {
+	timeout := defaultDistanceCachePrewarmTimeout
+	if value := r.URL.Query().Get("timeout_secs"); value != "" {
+		secs, err := strconv.Atoi(value)
+		if err != nil || secs <= 0 {
+			h.handleValidationError(w, messageInvalidPrewarmTimeout)
+			return
+		}
+		timeout = time.Duration(secs) * time.Second
+	}
+
+	points, err := h.storedCoordinates(r.Context())
+	if err != nil {
+		log.Printf("[ERROR] Failed to load locations for distance prewarm: err=%v", err)
+		h.handleInternalError(w, err)
+		return
+	}
+	pairs := distance.MatrixPairs(points)
+	before, err := distance.MeasureCacheCoverage(r.Context(), h.distanceCache(), pairs)
+	if err != nil {
+		log.Printf("[ERROR] Failed to measure distance cache coverage: err=%v", err)
+		h.handleInternalError(w, err)
+		return
+	}
+
+	response := DistanceCachePrewarmResponse{Points: len(points), RequestedPairs: before.TotalPairs, AlreadyCachedPairs: before.CachedPairs}
+	if before.MissingPairs > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		err = h.DistanceCalc.PrewarmCache(ctx, points)
+		cancel()
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			log.Printf("[ERROR] Failed to prewarm distance cache: points=%d err=%v", len(points), err)
+			h.handleInternalError(w, err)
+			return
+		}
+		response.TimedOut = err != nil
+	}
+	after, err := distance.MeasureCacheCoverage(r.Context(), h.distanceCache(), pairs)
+	if err != nil {
+		log.Printf("[ERROR] Failed to measure distance cache coverage: err=%v", err)
+		h.handleInternalError(w, err)
+		return
+	}
+	response.MissingPairs = after.MissingPairs
+
+	log.Printf("[HTTP] POST /api/v1/distance-cache/prewarm: points=%d pairs=%d already_cached=%d missing=%d timed_out=%t",
+		response.Points, response.RequestedPairs, response.AlreadyCachedPairs, response.MissingPairs, response.TimedOut)
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+// storedCoordinates returns every point a route over the stored participants,
+// drivers and activity locations can visit, including intermediate stops.
+func (h *Handler) storedCoordinates(ctx context.Context) ([]models.Coordinates, error) {
+	participants, err := h.DB.Participants().List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	drivers, err := h.DB.Drivers().List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	locations, err := h.DB.ActivityLocations().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]models.Coordinates, 0, len(participants)+len(drivers)+len(locations))
+	for i := range participants {
+		points = append(points, participants[i].DropoffPoints()...)
+	}
+	for i := range drivers {
+		points = append(points, drivers[i].GetCoords())
+	}
+	for i := range locations {
+		points = append(points, locations[i].GetCoords())
+	}
+	return points, nil
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"ride-home-router/internal/database"
+	"ride-home-router/internal/distance"
+	"ride-home-router/internal/models"
+	"testing"
+)
+
+// cachingPrewarmCalculator fills the cache with every pair of the points it
+// is asked to prewarm.
+type cachingPrewarmCalculator struct {
+	routeEditDistanceCalculator
+	cache  database.DistanceCacheRepository
+	points *int
+}
+
+func (c cachingPrewarmCalculator) PrewarmCache(ctx context.Context, points []models.Coordinates) error {
+	*c.points = len(points)
+	var entries []models.DistanceCacheEntry
+	for _, pair := range distance.MatrixPairs(points) {
+		entries = append(entries, models.DistanceCacheEntry{Origin: pair.Origin, Destination: pair.Destination, DistanceMeters: 1000, DurationSecs: 60})
+	}
+	return c.cache.SetBatch(ctx, entries)
+}
+
+func TestHandlePrewarmDistanceCacheFetchesEveryStoredPair(t *testing.T) {
+	handler, store := newTestManagementHandler(t)
+	ctx := context.Background()
+	if _, err := store.ActivityLocations().Create(ctx, &models.ActivityLocation{Name: "Gym", Address: "Gym", Lat: 0, Lng: 0}); err != nil {
+		t.Fatalf("create activity location: %v", err)
+	}
+	if _, err := store.Drivers().Create(ctx, &models.Driver{Name: "Driver", Address: "Driver", Lat: 3, Lng: 0, VehicleCapacity: 2}); err != nil {
+		t.Fatalf("create driver: %v", err)
+	}
+	for _, lat := range []float64{1, 2} {
+		if _, err := store.Participants().Create(ctx, &models.Participant{Name: "Rider", Address: "Rider", Lat: lat, Lng: 0}); err != nil {
+			t.Fatalf("create participant: %v", err)
+		}
+	}
+	if err := store.DistanceCache().SetBatch(ctx, []models.DistanceCacheEntry{
+		{Origin: models.Coordinates{Lat: 0, Lng: 0}, Destination: models.Coordinates{Lat: 1, Lng: 0}, DistanceMeters: 1000, DurationSecs: 60},
+	}); err != nil {
+		t.Fatalf("warm distance cache: %v", err)
+	}
+	prewarmed := 0
+	handler.DistanceCalc = cachingPrewarmCalculator{cache: store.DistanceCache(), points: &prewarmed}
+
+	w := httptest.NewRecorder()
+	handler.HandlePrewarmDistanceCache(w, httptest.NewRequestWithContext(ctx, http.MethodPost, "/api/v1/distance-cache/prewarm?timeout_secs=30", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body=%s", w.Code, w.Body.String())
+	}
+	var response DistanceCachePrewarmResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	// Four distinct points give 4*3 directed pairs.
+	want := DistanceCachePrewarmResponse{Points: 4, RequestedPairs: 12, AlreadyCachedPairs: 1}
+	if response != want || prewarmed != 4 {
+		t.Fatalf("response = %+v prewarmed %d points, want %+v over 4 points", response, prewarmed, want)
+	}
+
+	w = httptest.NewRecorder()
+	handler.HandlePrewarmDistanceCache(w, httptest.NewRequestWithContext(ctx, http.MethodPost, "/api/v1/distance-cache/prewarm?timeout_secs=0", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("zero timeout status = %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"ride-home-router/internal/distance"
+)
+
+// DistanceCacheStatsResponse describes the distance cache: how many pairs it
+// holds, its approximate size on disk, and how many reads it has served and
+// missed since startup.
+type DistanceCacheStatsResponse struct {
+	Entries   int   `json:"entries"`
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	SizeBytes int64 `json:"size_bytes"`
+}
+
+// HandleDistanceCacheStats handles GET /api/v1/distance-cache/stats
+func (h *Handler) HandleDistanceCacheStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.distanceCacheStats(r.Context())
+	if err != nil {
+		log.Printf("[ERROR] Failed to read distance cache stats: err=%v", err)
+		h.handleInternalError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, stats)
+}
+
+func (h *Handler) distanceCacheStats(ctx context.Context) (DistanceCacheStatsResponse, error) {
+	cache := h.DB.DistanceCache()
+	entries, err := cache.Count(ctx)
+	if err != nil {
+		return DistanceCacheStatsResponse{}, err
+	}
+	size, err := cache.SizeBytes(ctx)
+	if err != nil {
+		return DistanceCacheStatsResponse{}, err
+	}
+	stats := DistanceCacheStatsResponse{Entries: entries, SizeBytes: size}
+	if reporter, ok := h.DistanceCalc.(distance.CacheStatsReporter); ok {
+		counts := reporter.CacheStats()
+		stats.Hits, stats.Misses = counts.Hits, counts.Misses
+	}
+	return stats, nil
+}
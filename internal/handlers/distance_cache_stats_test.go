@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"ride-home-router/internal/distance"
+	"ride-home-router/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestHandleDistanceCacheStatsReportsEntriesHitsAndSize(t *testing.T) {
+	handler, store := newTestPageHandler(t)
+	handler.DistanceCalc = distance.NewOSRMCalculator(store.DistanceCache())
+	ctx := context.Background()
+	origin, dest := models.Coordinates{Lat: 35, Lng: -79}, models.Coordinates{Lat: 36, Lng: -79}
+	if err := handler.DistanceCalc.(distance.CacheProvider).DistanceCache().SetBatch(ctx, []models.DistanceCacheEntry{
+		{Origin: origin, Destination: dest, DistanceMeters: 1000, DurationSecs: 60},
+		{Origin: dest, Destination: origin, DistanceMeters: 1000, DurationSecs: 60},
+	}); err != nil {
+		t.Fatalf("seed distance cache: %v", err)
+	}
+	if _, err := handler.DistanceCalc.GetDistance(ctx, origin, dest); err != nil {
+		t.Fatalf("GetDistance() error = %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.HandleDistanceCacheStats(rr, httptest.NewRequestWithContext(ctx, http.MethodGet, "/api/v1/distance-cache/stats", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body=%s", rr.Code, rr.Body.String())
+	}
+	var stats DistanceCacheStatsResponse
+	if err := json.NewDecoder(rr.Body).Decode(&stats); err != nil {
+		t.Fatalf("decode stats: %v", err)
+	}
+	if stats.Entries != 2 || stats.Hits != 1 || stats.Misses != 0 || stats.SizeBytes <= 0 {
+		t.Fatalf("stats = %+v, want 2 entries, 1 hit and a nonzero size", stats)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.HandleSettingsPage(rr, httptest.NewRequestWithContext(ctx, http.MethodGet, "/settings", nil))
+	if body := rr.Body.String(); !strings.Contains(body, "Cached pairs: <strong>2</strong>") || !strings.Contains(body, "100% hit rate") {
+		t.Fatalf("settings page does not show the cache stats, body=%q", body)
+	}
+}
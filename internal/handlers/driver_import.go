@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"ride-home-router/internal/models"
+	"strconv"
+)
+
+// DriverImportRowResult reports the outcome of one CSV data row. Row is the
+// 1-based line number in the uploaded file.
+type DriverImportRowResult struct {
+	Row      int            `json:"row"`
+	Name     string         `json:"name"`
+	Created  bool           `json:"created"`
+	Driver   *models.Driver `json:"driver,omitempty"`
+	ErrorMsg string         `json:"error,omitempty"`
+}
+
+// DriverImportResponse summarizes a driver CSV import.
+type DriverImportResponse struct {
+	Created int                     `json:"created"`
+	Failed  int                     `json:"failed"`
+	Rows    []DriverImportRowResult `json:"rows"`
+}
+
+// HandleImportDrivers handles POST /api/v1/drivers/import
+//
+// The body is CSV with columns name,address,capacity and an optional
+// is_institute_vehicle column; a header row is skipped when present. Each
+// valid row is geocoded and created independently, so one bad row does not
+// stop the rest. Institute vehicles are organization vans in this app, so
+// rows flagged as one are reported as failures rather than created as drivers.
+func (h *Handler) HandleImportDrivers(w http.ResponseWriter, r *http.Request) {
+	rows, ok := h.readCSVImport(w, r, "/api/v1/drivers/import", messageInvalidDriverImportCSV, messageDriverImportEmpty)
+	if !ok {
+		return
+	}
+
+	log.Printf("[HTTP] POST /api/v1/drivers/import: rows=%d", len(rows))
+	response := DriverImportResponse{Rows: make([]DriverImportRowResult, 0, len(rows))}
+	for _, row := range rows {
+		result := h.importDriverRow(r, row)
+		if result.Created {
+			response.Created++
+		} else {
+			response.Failed++
+		}
+		response.Rows = append(response.Rows, result)
+	}
+
+	log.Printf("[HTTP] Imported drivers: created=%d failed=%d", response.Created, response.Failed)
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) importDriverRow(r *http.Request, row csvImportRow) DriverImportRowResult {
+	result := DriverImportRowResult{Row: row.number, Name: row.field(0)}
+	fail := func(err error) DriverImportRowResult {
+		result.ErrorMsg = row.fail("Driver", err)
+		return result
+	}
+
+	address := row.field(1)
+	if result.Name == "" || address == "" {
+		return fail(errors.New(messageNameAndAddressRequired))
+	}
+	capacity, err := strconv.Atoi(row.field(2))
+	if err != nil || capacity <= 0 {
+		return fail(errors.New(messageVehicleCapacityMustBeGreaterThanZero))
+	}
+	if flag := row.field(3); flag != "" {
+		isInstituteVehicle, err := strconv.ParseBool(flag)
+		if err != nil {
+			return fail(fmt.Errorf("invalid is_institute_vehicle value %q", flag))
+		}
+		if isInstituteVehicle {
+			return fail(errors.New(messageDriverImportInstituteVehicle))
+		}
+	}
+
+	geocodeResult, err := h.Geocoder.GeocodeWithRetry(r.Context(), address, 3)
+	if err != nil {
+		return fail(err)
+	}
+	driver, err := h.DB.Drivers().Create(r.Context(), &models.Driver{
+		Name:            result.Name,
+		Address:         address,
+		Lat:             geocodeResult.Coords.Lat,
+		Lng:             geocodeResult.Coords.Lng,
+		VehicleCapacity: capacity,
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	result.Created = true
+	result.Driver = driver
+	return result
+}
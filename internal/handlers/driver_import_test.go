@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleImportDriversReportsInvalidCapacityRow(t *testing.T) {
+	handler, store := newTestManagementHandler(t)
+	csvBody := strings.Join([]string{
+		"name,address,capacity,is_institute_vehicle",
+		"Alice,1 Oak Street,4,false",
+		"Bob,2 Oak Street,zero,false",
+		"Cara,3 Oak Street,6,",
+		"School Van,4 Oak Street,12,true",
+	}, "\n")
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/drivers/import", strings.NewReader(csvBody))
+	req.Header.Set("Content-Type", "text/csv")
+	handler.HandleImportDrivers(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body=%s", w.Code, w.Body.String())
+	}
+	var response DriverImportResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if response.Created != 2 || response.Failed != 2 || len(response.Rows) != 4 {
+		t.Fatalf("response = %+v, want 2 created and 2 failed of 4 rows", response)
+	}
+	bob := response.Rows[1]
+	if bob.Row != 3 || bob.Name != "Bob" || bob.Created || bob.ErrorMsg != messageVehicleCapacityMustBeGreaterThanZero {
+		t.Fatalf("invalid capacity row = %+v, want row 3 rejected for capacity", bob)
+	}
+	if van := response.Rows[3]; van.Created || van.ErrorMsg != messageDriverImportInstituteVehicle {
+		t.Fatalf("institute vehicle row = %+v, want rejected", van)
+	}
+	if cara := response.Rows[2]; !cara.Created || cara.Driver == nil || cara.Driver.VehicleCapacity != 6 {
+		t.Fatalf("Cara row = %+v, want created with capacity 6", cara)
+	}
+
+	drivers, err := store.Drivers().List(context.Background(), "")
+	if err != nil {
+		t.Fatalf("list drivers: %v", err)
+	}
+	if len(drivers) != 2 {
+		t.Fatalf("stored drivers = %d, want 2", len(drivers))
+	}
+}
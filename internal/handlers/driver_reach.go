@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"ride-home-router/internal/distance"
+	"ride-home-router/internal/models"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+const defaultDriverReachMinutes = 15
+
+// DriverReachResponse lists participants a driver can serve within a drive-time bound.
+type DriverReachResponse struct {
+	DriverID           int64                  `json:"driver_id"`
+	ActivityLocationID int64                  `json:"activity_location_id,omitempty"`
+	SessionID          string                 `json:"session_id,omitempty"`
+	MaxMinutes         float64                `json:"max_minutes"`
+	Participants       []ReachableParticipant `json:"participants"`
+}
+
+// ReachableParticipant is a participant with the extra drive time needed to reach them.
+type ReachableParticipant struct {
+	models.Participant
+	DriveTimeSecs float64 `json:"drive_time_secs"`
+}
+
+// HandleDriverReach handles GET /api/v1/drivers/reach
+//
+// Without an activity location the drive time is measured from the driver's
+// home. With one, it is the detour added to the driver's activity-to-home trip.
+// Every participant is listed unless session_id names an open route session,
+// in which case riders already placed on one of its routes are left out.
+func (h *Handler) HandleDriverReach(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	driverID, err := strconv.ParseInt(query.Get("driver_id"), 10, 64)
+	if err != nil {
+		h.handleValidationError(w, messageInvalidDriverID)
+		return
+	}
+	maxMinutes := float64(defaultDriverReachMinutes)
+	if value := query.Get("max_minutes"); value != "" {
+		maxMinutes, err = strconv.ParseFloat(value, 64)
+		if err != nil || maxMinutes <= 0 {
+			h.handleValidationError(w, messageInvalidMaxMinutes)
+			return
+		}
+	}
+	var locationID int64
+	if value := query.Get("activity_location_id"); value != "" {
+		locationID, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			h.handleValidationError(w, messageChooseValidActivityLocation)
+			return
+		}
+	}
+
+	sessionID := query.Get("session_id")
+
+	log.Printf("[HTTP] GET /api/v1/drivers/reach: driver_id=%d max_minutes=%.0f activity_location_id=%d session=%s", driverID, maxMinutes, locationID, sessionID)
+	driver, err := h.DB.Drivers().GetByID(r.Context(), driverID)
+	if err != nil {
+		if h.checkNotFound(err) {
+			h.handleNotFound(w, messageDriverNotFound)
+			return
+		}
+		h.handleInternalError(w, err)
+		return
+	}
+	var location *models.ActivityLocation
+	if locationID != 0 {
+		location, err = h.DB.ActivityLocations().GetByID(r.Context(), locationID)
+		if err != nil {
+			if h.checkNotFound(err) {
+				h.handleNotFound(w, messageSelectedActivityLocationNotFound)
+				return
+			}
+			h.handleInternalError(w, err)
+			return
+		}
+	}
+	participants, err := h.DB.Participants().List(r.Context(), "")
+	if err != nil {
+		log.Printf("[ERROR] Failed to list participants for driver reach: err=%v", err)
+		h.handleInternalError(w, err)
+		return
+	}
+	if sessionID != "" {
+		snapshot, ok := h.RouteSession.Snapshot(sessionID)
+		if !ok {
+			h.handleNotFound(w, messageSessionNotFound)
+			return
+		}
+		participants = unroutedParticipants(participants, snapshot.Routes)
+	}
+
+	driveTimes, err := h.driverReachDriveTimes(r, driver, location, participants)
+	if err != nil {
+		log.Printf("[ERROR] Failed to calculate driver reach: driver_id=%d err=%v", driverID, err)
+		h.handleInternalError(w, err)
+		return
+	}
+
+	maxSecs := maxMinutes * 60
+	reachable := make([]ReachableParticipant, 0)
+	for i, participant := range participants {
+		if driveTimes[i] <= maxSecs {
+			reachable = append(reachable, ReachableParticipant{Participant: participant, DriveTimeSecs: driveTimes[i]})
+		}
+	}
+	sort.SliceStable(reachable, func(i, j int) bool { return reachable[i].DriveTimeSecs < reachable[j].DriveTimeSecs })
+
+	log.Printf("[HTTP] Driver reach: driver_id=%d reachable=%d of %d", driverID, len(reachable), len(participants))
+	h.writeJSON(w, http.StatusOK, DriverReachResponse{
+		DriverID:           driverID,
+		ActivityLocationID: locationID,
+		SessionID:          sessionID,
+		MaxMinutes:         maxMinutes,
+		Participants:       reachable,
+	})
+}
+
+func (h *Handler) driverReachDriveTimes(r *http.Request, driver *models.Driver, location *models.ActivityLocation, participants []models.Participant) ([]float64, error) {
+	coords := make([]models.Coordinates, len(participants))
+	for i := range participants {
+		coords[i] = participants[i].GetCoords()
+	}
+	if len(coords) == 0 {
+		return nil, nil
+	}
+
+	home := driver.GetCoords()
+	if location == nil {
+		results, err := h.DistanceCalc.GetDistancesFromPoint(r.Context(), home, coords)
+		if err != nil {
+			return nil, err
+		}
+		driveTimes := make([]float64, len(results))
+		for i, result := range results {
+			driveTimes[i] = result.DurationSecs
+		}
+		return driveTimes, nil
+	}
+
+	institute := location.GetCoords()
+	direct, err := h.DistanceCalc.GetDistance(r.Context(), institute, home)
+	if err != nil {
+		return nil, err
+	}
+	outbound, err := h.DistanceCalc.GetDistancesFromPoint(r.Context(), institute, coords)
+	if err != nil {
+		return nil, err
+	}
+	// Fetch every homeward leg in one batched request so the loop below reads
+	// them from the cache instead of asking the provider once per participant.
+	homeward := make([]distance.DistancePair, len(coords))
+	for i, coord := range coords {
+		homeward[i] = distance.DistancePair{Origin: coord, Destination: home}
+	}
+	if err := distance.PrewarmRoutingPairs(r.Context(), h.DistanceCalc, homeward); err != nil {
+		return nil, err
+	}
+	driveTimes := make([]float64, len(coords))
+	for i, coord := range coords {
+		leg, err := h.DistanceCalc.GetDistance(r.Context(), coord, home)
+		if err != nil {
+			return nil, err
+		}
+		driveTimes[i] = max(0, outbound[i].DurationSecs+leg.DurationSecs-direct.DurationSecs)
+	}
+	return driveTimes, nil
+}
+
+// unroutedParticipants drops the participants who ride on one of routes.
+func unroutedParticipants(participants []models.Participant, routes []models.CalculatedRoute) []models.Participant {
+	routed := make(map[int64]bool)
+	for _, route := range routes {
+		for _, stop := range route.Stops {
+			if stop.Participant != nil {
+				routed[stop.Participant.ID] = true
+			}
+		}
+	}
+	return slices.DeleteFunc(participants, func(participant models.Participant) bool {
+		return routed[participant.ID]
+	})
+}
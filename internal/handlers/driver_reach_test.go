@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"ride-home-router/internal/distance"
+	"ride-home-router/internal/models"
+	"ride-home-router/internal/routesession"
+	"strconv"
+	"testing"
+)
+
+func TestHandleDriverReachReturnsOnlyNearParticipants(t *testing.T) {
+	handler, store := newTestManagementHandler(t)
+	handler.DistanceCalc = routeEditDistanceCalculator{}
+	ctx := context.Background()
+
+	driver, err := store.Drivers().Create(ctx, &models.Driver{Name: "Dana", Address: "Home", Lat: 0, Lng: 0, VehicleCapacity: 3})
+	if err != nil {
+		t.Fatalf("create driver: %v", err)
+	}
+	for _, p := range []models.Participant{
+		{Name: "Near", Address: "1 Close St", Lat: 0.05},
+		{Name: "Middle", Address: "2 Mid St", Lat: 0.5},
+		{Name: "Far", Address: "3 Far St", Lat: 2},
+	} {
+		if _, err := store.Participants().Create(ctx, &p); err != nil {
+			t.Fatalf("create participant: %v", err)
+		}
+	}
+
+	reach := func(maxMinutes string) []ReachableParticipant {
+		t.Helper()
+		w := httptest.NewRecorder()
+		target := "/api/v1/drivers/reach?driver_id=" + strconv.FormatInt(driver.ID, 10) + "&max_minutes=" + maxMinutes
+		handler.HandleDriverReach(w, httptest.NewRequestWithContext(ctx, http.MethodGet, target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+		}
+		var response DriverReachResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return response.Participants
+	}
+
+	// The stub calculator takes 1000s per degree, so 1 minute reaches only Near.
+	if got := reach("1"); len(got) != 1 || got[0].Name != "Near" {
+		t.Fatalf("tight bound reach = %#v, want only Near", got)
+	}
+	if got := reach("10"); len(got) != 2 || got[0].Name != "Near" || got[1].Name != "Middle" {
+		t.Fatalf("wider bound reach = %#v, want Near then Middle", got)
+	}
+
+	w := httptest.NewRecorder()
+	handler.HandleDriverReach(w, httptest.NewRequestWithContext(ctx, http.MethodGet, "/api/v1/drivers/reach?driver_id="+strconv.FormatInt(driver.ID, 10)+"&max_minutes=0", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400 for non-positive bound", w.Code)
+	}
+}
+
+// prewarmedReachCalculator refuses single distances from a participant into
+// the driver's home that were not fetched by an earlier PrewarmPairs batch.
+type prewarmedReachCalculator struct {
+	routeEditDistanceCalculator
+	activity  models.Coordinates
+	home      models.Coordinates
+	batches   int
+	prewarmed map[models.Coordinates]bool
+}
+
+func (c *prewarmedReachCalculator) PrewarmPairs(_ context.Context, pairs []distance.DistancePair) error {
+	c.batches++
+	for _, pair := range pairs {
+		c.prewarmed[pair.Origin] = true
+	}
+	return nil
+}
+
+func (c *prewarmedReachCalculator) GetDistance(ctx context.Context, origin, dest models.Coordinates) (*distance.DistanceResult, error) {
+	if dest == c.home && origin != c.activity && !c.prewarmed[origin] {
+		return nil, errors.New("homeward leg fetched one participant at a time")
+	}
+	return c.routeEditDistanceCalculator.GetDistance(ctx, origin, dest)
+}
+
+func TestHandleDriverReachBatchesHomewardLegs(t *testing.T) {
+	handler, store := newTestManagementHandler(t)
+	calc := &prewarmedReachCalculator{activity: models.Coordinates{Lat: 0, Lng: 0}, home: models.Coordinates{Lat: 1, Lng: 0}, prewarmed: map[models.Coordinates]bool{}}
+	handler.DistanceCalc = calc
+	ctx := context.Background()
+
+	driver, err := store.Drivers().Create(ctx, &models.Driver{Name: "Dana", Address: "Home", Lat: 1, Lng: 0, VehicleCapacity: 3})
+	if err != nil {
+		t.Fatalf("create driver: %v", err)
+	}
+	location, err := store.ActivityLocations().Create(ctx, &models.ActivityLocation{Name: "Gym", Address: "1 Main St", Lat: 0, Lng: 0})
+	if err != nil {
+		t.Fatalf("create activity location: %v", err)
+	}
+	for _, p := range []models.Participant{
+		{Name: "On the way", Address: "1 Way St", Lat: 0.5},
+		{Name: "Off the way", Address: "2 Side St", Lat: 0.5, Lng: 1},
+	} {
+		if _, err := store.Participants().Create(ctx, &p); err != nil {
+			t.Fatalf("create participant: %v", err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	target := "/api/v1/drivers/reach?driver_id=" + strconv.FormatInt(driver.ID, 10) +
+		"&activity_location_id=" + strconv.FormatInt(location.ID, 10) + "&max_minutes=1"
+	handler.HandleDriverReach(w, httptest.NewRequestWithContext(ctx, http.MethodGet, target, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	if calc.batches != 1 {
+		t.Fatalf("homeward batches = %d, want one", calc.batches)
+	}
+	var response DriverReachResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(response.Participants) != 1 || response.Participants[0].Name != "On the way" {
+		t.Fatalf("reach = %#v, want only the rider on the way home", response.Participants)
+	}
+}
+
+func TestHandleDriverReachLeavesOutRidersRoutedInTheSession(t *testing.T) {
+	handler, store := newTestManagementHandler(t)
+	handler.DistanceCalc = routeEditDistanceCalculator{}
+	ctx := context.Background()
+
+	driver, err := store.Drivers().Create(ctx, &models.Driver{Name: "Dana", Address: "Home", Lat: 0, Lng: 0, VehicleCapacity: 3})
+	if err != nil {
+		t.Fatalf("create driver: %v", err)
+	}
+	routed, err := store.Participants().Create(ctx, &models.Participant{Name: "Routed", Address: "1 Close St", Lat: 0.05})
+	if err != nil {
+		t.Fatalf("create participant: %v", err)
+	}
+	if _, err := store.Participants().Create(ctx, &models.Participant{Name: "Waiting", Address: "2 Close St", Lat: 0.06}); err != nil {
+		t.Fatalf("create participant: %v", err)
+	}
+	session := handler.RouteSession.Create(routesession.CreateInput{
+		Routes: []models.CalculatedRoute{{Driver: driver, EffectiveCapacity: 3, Stops: []models.RouteStop{{Participant: routed}}}},
+		Mode:   models.RouteModeDropoff,
+	})
+
+	target := "/api/v1/drivers/reach?driver_id=" + strconv.FormatInt(driver.ID, 10) + "&max_minutes=10&session_id="
+	w := httptest.NewRecorder()
+	handler.HandleDriverReach(w, httptest.NewRequestWithContext(ctx, http.MethodGet, target+session.ID, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var response DriverReachResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(response.Participants) != 1 || response.Participants[0].Name != "Waiting" {
+		t.Fatalf("reach = %#v, want only the rider not yet routed", response.Participants)
+	}
+
+	w = httptest.NewRecorder()
+	handler.HandleDriverReach(w, httptest.NewRequestWithContext(ctx, http.MethodGet, target+"missing", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404 for an unknown session", w.Code)
+	}
+}
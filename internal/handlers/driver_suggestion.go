@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"ride-home-router/internal/models"
+	"ride-home-router/internal/routing"
+)
+
+// SuggestDriversRequest is a route selection whose driver pool may be larger
+// than needed.
+type SuggestDriversRequest struct {
+	ParticipantIDs     []int64 `json:"participant_ids"`
+	DriverIDs          []int64 `json:"driver_ids"`
+	ActivityLocationID int64   `json:"activity_location_id"`
+	Mode               string  `json:"mode"`
+}
+
+// SuggestDriversResponse names the fewest drivers that can serve everyone and
+// the routes they would drive.
+type SuggestDriversResponse struct {
+	DriverIDs        []int64                  `json:"driver_ids"`
+	DriversNeeded    int                      `json:"drivers_needed"`
+	DriversAvailable int                      `json:"drivers_available"`
+	Routes           []models.CalculatedRoute `json:"routes"`
+	Summary          models.RoutingSummary    `json:"summary"`
+}
+
+// HandleSuggestDrivers handles POST /api/v1/routes/suggest-drivers
+//
+// It packs the participants into as few of the selected drivers as possible,
+// favoring larger vehicles and drivers who live near the participants. Nothing
+// is persisted and no route session is created.
+func (h *Handler) HandleSuggestDrivers(w http.ResponseWriter, r *http.Request) {
+	var req SuggestDriversRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("[HTTP] POST /api/v1/routes/suggest-drivers: invalid_json err=%v", err)
+		h.handleValidationError(w, messageInvalidRequestBody)
+		return
+	}
+	if len(req.ParticipantIDs) == 0 {
+		h.handleValidationError(w, messageSelectAtLeastOneParticipant)
+		return
+	}
+	if len(req.DriverIDs) == 0 {
+		h.handleValidationError(w, messageSelectAtLeastOneDriver)
+		return
+	}
+	if req.ActivityLocationID == 0 {
+		h.handleValidationError(w, messageChooseActivityLocationForEvent)
+		return
+	}
+	mode, err := normalizeRouteMode(req.Mode)
+	if err != nil {
+		h.handleValidationError(w, err.Error())
+		return
+	}
+
+	settings, err := h.DB.Settings().Get(r.Context())
+	if err != nil {
+		h.handleInternalError(w, err)
+		return
+	}
+	calculation := newRouteCalculation(h.DB, h.Router, h.RouteSession)
+	selection, err := calculation.loadSelection(r.Context(), routeCalculationInput{
+		ParticipantIDs:     req.ParticipantIDs,
+		DriverIDs:          req.DriverIDs,
+		ActivityLocationID: req.ActivityLocationID,
+	})
+	if err != nil {
+		if isRouteSelectionError(err) {
+			h.handleValidationError(w, routeCalculationValidationMessage(err))
+			return
+		}
+		h.handleInternalError(w, err)
+		return
+	}
+
+	routingReq := &routing.RoutingRequest{
+		InstituteCoords:    selection.activityLocation.GetCoords(),
+		Participants:       selection.participants,
+		Drivers:            selection.drivers,
+		Mode:               mode,
+		HouseholdPrecision: settings.HouseholdGroupingPrecision,
+	}
+	routingReq.Distances = h.shareDistanceMatrix(r.Context(), routingReq)
+	suggestion, err := routing.SuggestDrivers(r.Context(), h.Router, routingReq)
+	if err != nil {
+		var routingErr *routing.ErrRoutingFailed
+		if errors.As(err, &routingErr) {
+			h.handleRoutingError(w, routingErr)
+			return
+		}
+		log.Printf("[ERROR] Failed to suggest drivers: err=%v", err)
+		h.handleInternalError(w, err)
+		return
+	}
+
+	// Suggestions carry no van assignments, so every driver counts as a volunteer.
+	applyRouteVehicleSummary(suggestion.Result, nil)
+	response := SuggestDriversResponse{
+		DriverIDs:        make([]int64, len(suggestion.Drivers)),
+		DriversNeeded:    len(suggestion.Drivers),
+		DriversAvailable: len(selection.drivers),
+		Routes:           suggestion.Result.Routes,
+		Summary:          suggestion.Result.Summary,
+	}
+	for i, driver := range suggestion.Drivers {
+		response.DriverIDs[i] = driver.ID
+	}
+
+	log.Printf("[HTTP] POST /api/v1/routes/suggest-drivers: participants=%d needed=%d available=%d", len(selection.participants), response.DriversNeeded, response.DriversAvailable)
+	h.writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"ride-home-router/internal/models"
+	"ride-home-router/internal/routing"
+	"testing"
+)
+
+func TestHandleSuggestDriversCountsVolunteerDrivers(t *testing.T) {
+	handler, store := newTestManagementHandler(t)
+	handler.DistanceCalc = routeEditDistanceCalculator{}
+	handler.Router = routing.NewBalancedRouter(routeEditDistanceCalculator{})
+	ctx := context.Background()
+
+	location, err := store.ActivityLocations().Create(ctx, &models.ActivityLocation{Name: "Gym", Address: "1 Main St", Lat: 0, Lng: 0})
+	if err != nil {
+		t.Fatalf("create activity location: %v", err)
+	}
+	var req SuggestDriversRequest
+	req.ActivityLocationID = location.ID
+	for _, p := range []models.Participant{
+		{Name: "Ada", Address: "1 North St", Lat: 1},
+		{Name: "Ben", Address: "2 North St", Lat: 1.1},
+	} {
+		created, err := store.Participants().Create(ctx, &p)
+		if err != nil {
+			t.Fatalf("create participant: %v", err)
+		}
+		req.ParticipantIDs = append(req.ParticipantIDs, created.ID)
+	}
+	for _, d := range []models.Driver{
+		{Name: "Dana", Address: "3 North St", Lat: 1.2, VehicleCapacity: 4},
+		{Name: "Eli", Address: "4 South St", Lat: -1, VehicleCapacity: 4},
+	} {
+		created, err := store.Drivers().Create(ctx, &d)
+		if err != nil {
+			t.Fatalf("create driver: %v", err)
+		}
+		req.DriverIDs = append(req.DriverIDs, created.ID)
+	}
+
+	body, _ := json.Marshal(req)
+	w := httptest.NewRecorder()
+	handler.HandleSuggestDrivers(w, httptest.NewRequestWithContext(ctx, http.MethodPost, "/api/v1/routes/suggest-drivers", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var response SuggestDriversResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	summary := response.Summary
+	if response.DriversNeeded != 1 || summary.VolunteerDriversUsed != 1 || summary.OrgVehiclesUsed != 0 {
+		t.Fatalf("needed=%d summary=%+v, want one volunteer driver and no vans", response.DriversNeeded, summary)
+	}
+	if summary.VolunteerDistanceMeters <= 0 || summary.VolunteerDistanceMeters != summary.TotalDistanceMeters {
+		t.Fatalf("volunteer distance = %.0f, want the whole %.0f", summary.VolunteerDistanceMeters, summary.TotalDistanceMeters)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"ride-home-router/internal/database"
 	"ride-home-router/internal/models"
 	"strconv"
 	"strings"
@@ -26,9 +27,19 @@ type DriverResponse struct {
 // HandleListDrivers handles GET /api/v1/drivers
 func (h *Handler) HandleListDrivers(w http.ResponseWriter, r *http.Request) {
 	search := r.URL.Query().Get("search")
-	log.Printf("[HTTP] GET /api/v1/drivers: search=%s", search)
+	field, err := database.ParseSearchField(r.URL.Query().Get("field"))
+	if err != nil {
+		log.Printf("[HTTP] GET /api/v1/drivers: invalid search field err=%v", err)
+		if h.isHTMX(r) {
+			h.handleValidationErrorHTMX(w, r, messageSearchFieldInvalid)
+			return
+		}
+		h.handleValidationError(w, messageSearchFieldInvalid)
+		return
+	}
+	log.Printf("[HTTP] GET /api/v1/drivers: search=%s field=%s", search, field)
 
-	drivers, err := h.DB.Drivers().List(r.Context(), search)
+	drivers, err := h.DB.Drivers().Search(r.Context(), search, field)
 	if err != nil {
 		log.Printf("[ERROR] Failed to list drivers: search=%s err=%v", search, err)
 		if h.isHTMX(r) {
@@ -99,10 +110,18 @@ func (h *Handler) HandleGetDriver(w http.ResponseWriter, r *http.Request) {
 // HandleCreateDriver handles POST /api/v1/drivers
 func (h *Handler) HandleCreateDriver(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Name            string  `json:"name"`
-		Address         string  `json:"address"`
-		VehicleCapacity int     `json:"vehicle_capacity"`
-		LabelIDs        []int64 `json:"label_ids"`
+		Name              string            `json:"name"`
+		Address           string            `json:"address"`
+		VehicleCapacity   int               `json:"vehicle_capacity"`
+		LabelIDs          []int64           `json:"label_ids"`
+		Attributes        map[string]string `json:"attributes"`
+		ComfortRadius     float64           `json:"comfort_radius_meters"`
+		FlexCapacity      int               `json:"flex_capacity"`
+		ReturnToInstitute bool              `json:"return_to_institute"`
+		ExcludedIDs       []int64           `json:"excluded_participant_ids"`
+		Active            *bool             `json:"active"`
+		IsAccessible      bool              `json:"is_accessible"`
+		MaxRouteDuration  float64           `json:"max_route_duration_secs"`
 	}
 	var labelIDs []int64
 
@@ -153,6 +172,18 @@ func (h *Handler) HandleCreateDriver(w http.ResponseWriter, r *http.Request) {
 		h.handleValidationError(w, messageVehicleCapacityMustBeGreaterThanZero)
 		return
 	}
+	if req.ComfortRadius < 0 {
+		h.handleValidationError(w, messageInvalidComfortRadius)
+		return
+	}
+	if req.FlexCapacity < 0 {
+		h.handleValidationError(w, messageInvalidFlexCapacity)
+		return
+	}
+	if req.MaxRouteDuration < 0 {
+		h.handleValidationError(w, messageInvalidMaxRouteDuration)
+		return
+	}
 	if err := h.validateLabelIDs(r.Context(), labelIDs); err != nil {
 		log.Printf("[HTTP] POST /api/v1/drivers: invalid_labels err=%v", err)
 		if h.isHTMX(r) {
@@ -176,11 +207,19 @@ func (h *Handler) HandleCreateDriver(w http.ResponseWriter, r *http.Request) {
 	}
 
 	driver := &models.Driver{
-		Name:            req.Name,
-		Address:         req.Address,
-		Lat:             geocodeResult.Coords.Lat,
-		Lng:             geocodeResult.Coords.Lng,
-		VehicleCapacity: req.VehicleCapacity,
+		Name:                   req.Name,
+		Address:                req.Address,
+		Lat:                    geocodeResult.Coords.Lat,
+		Lng:                    geocodeResult.Coords.Lng,
+		VehicleCapacity:        req.VehicleCapacity,
+		Attributes:             req.Attributes,
+		ComfortRadiusMeters:    req.ComfortRadius,
+		FlexCapacity:           req.FlexCapacity,
+		ReturnToInstitute:      req.ReturnToInstitute,
+		ExcludedParticipantIDs: req.ExcludedIDs,
+		Inactive:               req.Active != nil && !*req.Active,
+		IsAccessible:           req.IsAccessible,
+		MaxRouteDurationSecs:   req.MaxRouteDuration,
 	}
 
 	driver, err = h.DB.Drivers().CreateWithLabels(r.Context(), driver, labelIDs)
@@ -259,10 +298,18 @@ func (h *Handler) HandleUpdateDriver(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Name            string   `json:"name"`
-		Address         string   `json:"address"`
-		VehicleCapacity int      `json:"vehicle_capacity"`
-		LabelIDs        *[]int64 `json:"label_ids"`
+		Name              string             `json:"name"`
+		Address           string             `json:"address"`
+		VehicleCapacity   int                `json:"vehicle_capacity"`
+		LabelIDs          *[]int64           `json:"label_ids"`
+		Attributes        *map[string]string `json:"attributes"`
+		ComfortRadius     *float64           `json:"comfort_radius_meters"`
+		FlexCapacity      *int               `json:"flex_capacity"`
+		ReturnToInstitute *bool              `json:"return_to_institute"`
+		ExcludedIDs       *[]int64           `json:"excluded_participant_ids"`
+		Active            *bool              `json:"active"`
+		IsAccessible      *bool              `json:"is_accessible"`
+		MaxRouteDuration  *float64           `json:"max_route_duration_secs"`
 	}
 	var labelIDs []int64
 	shouldSetLabels := false
@@ -318,6 +365,18 @@ func (h *Handler) HandleUpdateDriver(w http.ResponseWriter, r *http.Request) {
 		h.handleValidationError(w, messageVehicleCapacityMustBeGreaterThanZero)
 		return
 	}
+	if req.ComfortRadius != nil && *req.ComfortRadius < 0 {
+		h.handleValidationError(w, messageInvalidComfortRadius)
+		return
+	}
+	if req.FlexCapacity != nil && *req.FlexCapacity < 0 {
+		h.handleValidationError(w, messageInvalidFlexCapacity)
+		return
+	}
+	if req.MaxRouteDuration != nil && *req.MaxRouteDuration < 0 {
+		h.handleValidationError(w, messageInvalidMaxRouteDuration)
+		return
+	}
 	if shouldSetLabels {
 		if err := h.validateLabelIDs(r.Context(), labelIDs); err != nil {
 			log.Printf("[HTTP] PUT /api/v1/drivers/{id}: invalid_labels id=%d err=%v", id, err)
@@ -331,13 +390,45 @@ func (h *Handler) HandleUpdateDriver(w http.ResponseWriter, r *http.Request) {
 	}
 
 	driver := &models.Driver{
-		ID:              id,
-		Name:            req.Name,
-		Address:         req.Address,
-		Lat:             existing.Lat,
-		Lng:             existing.Lng,
-		VehicleCapacity: req.VehicleCapacity,
-		CreatedAt:       existing.CreatedAt,
+		ID:                     id,
+		Name:                   req.Name,
+		Address:                req.Address,
+		Lat:                    existing.Lat,
+		Lng:                    existing.Lng,
+		VehicleCapacity:        req.VehicleCapacity,
+		Attributes:             existing.Attributes,
+		ComfortRadiusMeters:    existing.ComfortRadiusMeters,
+		FlexCapacity:           existing.FlexCapacity,
+		ReturnToInstitute:      existing.ReturnToInstitute,
+		ExcludedParticipantIDs: existing.ExcludedParticipantIDs,
+		Inactive:               existing.Inactive,
+		IsAccessible:           existing.IsAccessible,
+		MaxRouteDurationSecs:   existing.MaxRouteDurationSecs,
+		CreatedAt:              existing.CreatedAt,
+	}
+	if req.Attributes != nil {
+		driver.Attributes = *req.Attributes
+	}
+	if req.ComfortRadius != nil {
+		driver.ComfortRadiusMeters = *req.ComfortRadius
+	}
+	if req.FlexCapacity != nil {
+		driver.FlexCapacity = *req.FlexCapacity
+	}
+	if req.ReturnToInstitute != nil {
+		driver.ReturnToInstitute = *req.ReturnToInstitute
+	}
+	if req.ExcludedIDs != nil {
+		driver.ExcludedParticipantIDs = *req.ExcludedIDs
+	}
+	if req.Active != nil {
+		driver.Inactive = !*req.Active
+	}
+	if req.IsAccessible != nil {
+		driver.IsAccessible = *req.IsAccessible
+	}
+	if req.MaxRouteDuration != nil {
+		driver.MaxRouteDurationSecs = *req.MaxRouteDuration
 	}
 
 	if req.Address != existing.Address {
@@ -406,6 +497,51 @@ func (h *Handler) HandleUpdateDriver(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, http.StatusOK, response)
 }
 
+// HandleSetDriverActive handles PUT /api/v1/drivers/{id}/active
+func (h *Handler) HandleSetDriverActive(w http.ResponseWriter, r *http.Request) {
+	idStr, _ := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/drivers/"), "/active")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		log.Printf("[HTTP] PUT /api/v1/drivers/{id}/active: invalid_id=%s err=%v", idStr, err)
+		h.handleValidationError(w, messageInvalidDriverID)
+		return
+	}
+
+	var req struct {
+		Active *bool `json:"active"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Active == nil {
+		h.handleValidationError(w, messageInvalidRequestBody)
+		return
+	}
+
+	log.Printf("[HTTP] PUT /api/v1/drivers/{id}/active: id=%d active=%t", id, *req.Active)
+	driver, err := h.DB.Drivers().GetByID(r.Context(), id)
+	if err != nil {
+		if h.checkNotFound(err) {
+			h.handleNotFound(w, messageDriverNotFound)
+			return
+		}
+		h.handleInternalError(w, err)
+		return
+	}
+	driver.Inactive = !*req.Active
+	driver, err = h.DB.Drivers().Update(r.Context(), driver)
+	if err != nil {
+		log.Printf("[ERROR] Failed to set driver active: id=%d err=%v", id, err)
+		h.handleInternalError(w, err)
+		return
+	}
+
+	response, err := h.driverResponse(r.Context(), driver)
+	if err != nil {
+		log.Printf("[ERROR] Failed to load driver labels: id=%d err=%v", driver.ID, err)
+		h.handleInternalError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, response)
+}
+
 // HandleDeleteDriver handles DELETE /api/v1/drivers/{id}
 func (h *Handler) HandleDeleteDriver(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/api/v1/drivers/")
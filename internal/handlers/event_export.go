@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"log"
+	"net/http"
+	"ride-home-router/internal/httpx"
+	"strconv"
+	"strings"
+)
+
+// eventExportPageSize is how many events the export reads at a time.
+const eventExportPageSize = 50
+
+// HandleExportEventsCSV handles GET /api/v1/events/export.csv
+//
+// It writes one row per saved stop, newest event first, reading events a page
+// at a time and flushing after each so the full history is never held in
+// memory. Distances use miles or kilometers following the UseMiles setting,
+// and free-text cells are escaped so spreadsheets do not run them as formulas.
+// Once rows have been sent a failure can only end the stream early, so it is
+// logged rather than reported.
+func (h *Handler) HandleExportEventsCSV(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.DB.Settings().Get(r.Context())
+	if err != nil {
+		log.Printf("[ERROR] Failed to get settings for event export: err=%v", err)
+		h.handleInternalError(w, err)
+		return
+	}
+	unit, metersPerUnit := distanceUnit(settings.UseMiles)
+	formatDistance := func(meters float64) string {
+		return strconv.FormatFloat(meters/metersPerUnit, 'f', 2, 64)
+	}
+
+	w.Header().Set(httpx.HeaderContentType, httpx.MediaTypeCSV)
+	w.Header().Set(httpx.HeaderContentDisposition, `attachment; filename="events.csv"`)
+	out := csv.NewWriter(w)
+	_ = out.Write([]string{
+		"event_id", "event_date", "mode", "notes", "driver_name", "org_vehicle_name",
+		"stop_order", "participant_name", "participant_address",
+		"distance_from_prev_" + unit, "cumulative_distance_" + unit, "cumulative_duration_secs",
+	})
+
+	exported := 0
+	for offset := 0; ; offset += eventExportPageSize {
+		events, _, err := h.DB.Events().List(r.Context(), eventExportPageSize, offset)
+		if err != nil {
+			log.Printf("[ERROR] Event export stopped: offset=%d err=%v", offset, err)
+			break
+		}
+		for _, event := range events {
+			_, routes, _, err := h.DB.Events().GetByID(r.Context(), event.ID)
+			if err != nil {
+				log.Printf("[ERROR] Event export skipped event: id=%d err=%v", event.ID, err)
+				continue
+			}
+			for _, route := range routes {
+				for _, stop := range route.Stops {
+					_ = out.Write([]string{
+						strconv.FormatInt(event.ID, 10),
+						event.EventDate.Format("2006-01-02"),
+						string(event.Mode),
+						escapeCSVFormula(event.Notes),
+						escapeCSVFormula(route.DriverName),
+						escapeCSVFormula(route.OrgVehicleName),
+						strconv.Itoa(stop.Order + 1),
+						escapeCSVFormula(stop.ParticipantName),
+						escapeCSVFormula(stop.ParticipantAddress),
+						formatDistance(stop.DistanceFromPrevMeters),
+						formatDistance(stop.CumulativeDistanceMeters),
+						strconv.FormatFloat(stop.CumulativeDurationSecs, 'f', 0, 64),
+					})
+				}
+			}
+			exported++
+		}
+		out.Flush()
+		if err := out.Error(); err != nil {
+			log.Printf("[ERROR] Event export write failed: err=%v", err)
+			return
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		if len(events) < eventExportPageSize {
+			break
+		}
+	}
+
+	log.Printf("[HTTP] GET /api/v1/events/export.csv: events=%d unit=%s", exported, unit)
+}
+
+// escapeCSVFormula prefixes a cell that a spreadsheet would read as a formula
+// with a single quote, so it opens as the text that was typed.
+func escapeCSVFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"ride-home-router/internal/models"
+	"testing"
+)
+
+func TestHandleExportEventsCSVStreamsRowsForEveryEvent(t *testing.T) {
+	handler, store := newTestEventHandler(t, false)
+	if err := store.Settings().Update(context.Background(), &models.Settings{UseMiles: false}); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+	older := createTestEvent(t, store, "2026-03-10", "older")
+	newer := createTestEvent(t, store, "2026-03-12", "newer")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/events/export.csv", nil)
+	w := httptest.NewRecorder()
+	handler.HandleExportEventsCSV(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body=%s", w.Code, w.Body.String())
+	}
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("parse CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("records = %v, want a header and one row per event", records)
+	}
+	if records[0][9] != "distance_from_prev_km" {
+		t.Fatalf("header = %v, want kilometer distances", records[0])
+	}
+	if records[1][0] != int64ToString(newer.ID) || records[2][0] != int64ToString(older.ID) {
+		t.Fatalf("event ids = %s,%s, want newest first", records[1][0], records[2][0])
+	}
+	if row := records[2]; row[4] != "Driver One" || row[7] != "Passenger One" || row[9] != "1.20" {
+		t.Fatalf("row = %v, want Driver One carrying Passenger One 1.20 km", row)
+	}
+}
+
+func TestHandleExportEventsCSVEscapesFormulaCells(t *testing.T) {
+	handler, store := newTestEventHandler(t, false)
+	createTestEvent(t, store, "2026-03-10", `=HYPERLINK("http://example.com")`)
+
+	w := httptest.NewRecorder()
+	handler.HandleExportEventsCSV(w, httptest.NewRequest(http.MethodGet, "/api/v1/events/export.csv", nil))
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("parse CSV: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("records = %v, want a header and one row", records)
+	}
+	if got, want := records[1][3], `'=HYPERLINK("http://example.com")`; got != want {
+		t.Fatalf("notes cell = %q, want %q", got, want)
+	}
+	if got := records[1][4]; got != "Driver One" {
+		t.Fatalf("driver cell = %q, want plain text left alone", got)
+	}
+}
+
+func TestEscapeCSVFormula(t *testing.T) {
+	for value, want := range map[string]string{
+		"=1+1": "'=1+1", "+1": "'+1", "-2": "'-2", "@SUM(A1)": "'@SUM(A1)",
+		"Main St": "Main St", "": "", "a=b": "a=b",
+	} {
+		if got := escapeCSVFormula(value); got != want {
+			t.Fatalf("escapeCSVFormula(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
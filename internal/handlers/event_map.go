@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"ride-home-router/internal/models"
+	"strconv"
+	"strings"
+)
+
+// EventMapResponse is a saved event's routes in the shape the results page
+// maps. Saved routes keep names and addresses but not coordinates, so each
+// driver and participant is placed at their current record's location. Those
+// whose records have since been deleted keep their saved name and address
+// without coordinates and are listed as unlocated so the map can skip them.
+type EventMapResponse struct {
+	EventID int64            `json:"event_id"`
+	Mode    models.RouteMode `json:"mode"`
+	// ActivityLocation is the currently selected activity location, since
+	// events do not record where they started or ended.
+	ActivityLocation        *models.ActivityLocation `json:"activity_location,omitempty"`
+	Routes                  []models.CalculatedRoute `json:"routes"`
+	UnlocatedDriverIDs      []int64                  `json:"unlocated_driver_ids,omitempty"`
+	UnlocatedParticipantIDs []int64                  `json:"unlocated_participant_ids,omitempty"`
+}
+
+// HandleEventMap handles GET /api/v1/events/{id}/map
+func (h *Handler) HandleEventMap(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/events/"), "/map")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		log.Printf("[HTTP] GET /api/v1/events/{id}/map: invalid_id=%s err=%v", idStr, err)
+		h.handleValidationError(w, messageInvalidEventID)
+		return
+	}
+
+	event, eventRoutes, _, err := h.DB.Events().GetByID(r.Context(), id)
+	if err != nil {
+		if h.checkNotFound(err) {
+			h.handleNotFound(w, messageEventNotFound)
+			return
+		}
+		log.Printf("[ERROR] Failed to get event for map: id=%d err=%v", id, err)
+		h.handleInternalError(w, err)
+		return
+	}
+
+	var driverIDs, participantIDs []int64
+	for _, route := range eventRoutes {
+		driverIDs = append(driverIDs, route.DriverID)
+		for _, stop := range route.Stops {
+			participantIDs = append(participantIDs, stop.ParticipantID)
+		}
+	}
+	drivers, err := h.DB.Drivers().GetByIDs(r.Context(), driverIDs)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get drivers for event map: id=%d err=%v", id, err)
+		h.handleInternalError(w, err)
+		return
+	}
+	participants, err := h.DB.Participants().GetByIDs(r.Context(), participantIDs)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get participants for event map: id=%d err=%v", id, err)
+		h.handleInternalError(w, err)
+		return
+	}
+	settings, err := h.DB.Settings().Get(r.Context())
+	if err != nil {
+		log.Printf("[ERROR] Failed to get settings for event map: err=%v", err)
+		h.handleInternalError(w, err)
+		return
+	}
+
+	response := buildEventMap(event, eventRoutes, drivers, participants)
+	if settings.SelectedActivityLocationID != 0 {
+		location, err := h.DB.ActivityLocations().GetByID(r.Context(), settings.SelectedActivityLocationID)
+		if err != nil && !h.checkNotFound(err) {
+			log.Printf("[ERROR] Failed to get activity location for event map: id=%d err=%v", settings.SelectedActivityLocationID, err)
+			h.handleInternalError(w, err)
+			return
+		}
+		response.ActivityLocation = location
+	}
+
+	log.Printf("[HTTP] GET /api/v1/events/{id}/map: id=%d routes=%d unlocated=%d", id, len(response.Routes), len(response.UnlocatedDriverIDs)+len(response.UnlocatedParticipantIDs))
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+// buildEventMap rebuilds an event's saved routes as calculated routes, taking
+// coordinates from the current driver and participant records.
+func buildEventMap(event *models.Event, eventRoutes []models.EventRoute, drivers []models.Driver, participants []models.Participant) EventMapResponse {
+	driversByID := make(map[int64]models.Driver, len(drivers))
+	for _, driver := range drivers {
+		driversByID[driver.ID] = driver
+	}
+	participantsByID := make(map[int64]models.Participant, len(participants))
+	for _, participant := range participants {
+		participantsByID[participant.ID] = participant
+	}
+
+	response := EventMapResponse{EventID: event.ID, Mode: event.Mode, Routes: make([]models.CalculatedRoute, len(eventRoutes))}
+	for i, saved := range eventRoutes {
+		driver, ok := driversByID[saved.DriverID]
+		if !ok {
+			driver = models.Driver{ID: saved.DriverID}
+			response.UnlocatedDriverIDs = append(response.UnlocatedDriverIDs, saved.DriverID)
+		}
+		driver.Name, driver.Address = saved.DriverName, saved.DriverAddress
+
+		stops := make([]models.RouteStop, len(saved.Stops))
+		for j, stop := range saved.Stops {
+			participant, ok := participantsByID[stop.ParticipantID]
+			if !ok {
+				participant = models.Participant{ID: stop.ParticipantID}
+				response.UnlocatedParticipantIDs = append(response.UnlocatedParticipantIDs, stop.ParticipantID)
+			}
+			participant.Name, participant.Address = stop.ParticipantName, stop.ParticipantAddress
+			stops[j] = models.RouteStop{
+				Order: stop.Order, Participant: &participant,
+				DistanceFromPrevMeters: stop.DistanceFromPrevMeters, CumulativeDistanceMeters: stop.CumulativeDistanceMeters,
+				DurationFromPrevSecs: stop.DurationFromPrevSecs, CumulativeDurationSecs: stop.CumulativeDurationSecs,
+			}
+		}
+
+		response.Routes[i] = models.CalculatedRoute{
+			Driver: &driver, Stops: stops,
+			TotalDropoffDistanceMeters: saved.TotalDropoffDistanceMeters, DistanceToDriverHomeMeters: saved.DistanceToDriverHomeMeters,
+			TotalDistanceMeters: saved.TotalDistanceMeters, OrgVehicleID: saved.OrgVehicleID, OrgVehicleName: saved.OrgVehicleName,
+			EffectiveCapacity: saved.EffectiveCapacity, BaselineDurationSecs: saved.BaselineDurationSecs,
+			RouteDurationSecs: saved.RouteDurationSecs, DetourSecs: saved.DetourSecs, Mode: saved.Mode,
+			Color: models.RouteColor(saved.DriverID),
+		}
+	}
+	return response
+}
+
+// HandleEventReport handles GET requests under /api/v1/events/{id},
+// dispatching on the trailing action.
+func (h *Handler) HandleEventReport(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/reimbursement"):
+		h.HandleEventReimbursement(w, r)
+	case strings.HasSuffix(r.URL.Path, "/map"):
+		h.HandleEventMap(w, r)
+	default:
+		h.HandleGetEvent(w, r)
+	}
+}
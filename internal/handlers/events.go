@@ -17,10 +17,12 @@ import (
 
 // EventListResponse represents the list response.
 type EventListResponse struct {
-	Events []EventWithSummary `json:"events"`
-	Total  int                `json:"total"`
-	Limit  int                `json:"limit"`
-	Offset int                `json:"offset"`
+	Events        []EventWithSummary `json:"events"`
+	Total         int                `json:"total"`
+	Limit         int                `json:"limit"`
+	Offset        int                `json:"offset"`
+	DriverID      int64              `json:"driver_id,omitempty"`
+	ParticipantID int64              `json:"participant_id,omitempty"`
 }
 
 // EventWithSummary combines event and summary for list view.
@@ -42,6 +44,8 @@ type EventListViewData struct {
 	NextOffset     int                `json:"next_offset"`
 	PageSize       int                `json:"page_size"`
 	UseMiles       bool               `json:"use_miles"`
+	DriverID       int64              `json:"driver_id,omitempty"`
+	ParticipantID  int64              `json:"participant_id,omitempty"`
 }
 
 const defaultEventListPageSize = 20
@@ -52,6 +56,15 @@ type CreateEventRequest struct {
 	Notes     string                `json:"notes"`
 	Routes    *models.RoutingResult `json:"routes"`
 	SessionID string                `json:"session_id"`
+	DryRun    bool                  `json:"dry_run,omitempty"`
+}
+
+// EventDryRunResponse is what a dry-run create would have stored.
+type EventDryRunResponse struct {
+	DryRun  bool                 `json:"dry_run"`
+	Event   *models.Event        `json:"event"`
+	Routes  []models.EventRoute  `json:"routes"`
+	Summary *models.EventSummary `json:"summary"`
 }
 
 // EventDetailResponse represents the detailed event response.
@@ -107,9 +120,30 @@ func (h *Handler) HandleListEvents(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	log.Printf("[HTTP] GET /api/v1/events: limit=%d offset=%d", limit, offset)
+	var filter models.EventFilter
+	for _, param := range []struct {
+		name string
+		dest *int64
+	}{
+		{name: "driver_id", dest: &filter.DriverID},
+		{name: "participant_id", dest: &filter.ParticipantID},
+	} {
+		value := r.URL.Query().Get(param.name)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id <= 0 {
+			log.Printf("[HTTP] GET /api/v1/events: invalid_%s=%s", param.name, value)
+			h.handleValidationError(w, messageInvalidEventFilter)
+			return
+		}
+		*param.dest = id
+	}
+
+	log.Printf("[HTTP] GET /api/v1/events: limit=%d offset=%d driver=%d participant=%d", limit, offset, filter.DriverID, filter.ParticipantID)
 
-	view, err := h.buildEventListView(r.Context(), limit, offset)
+	view, err := h.buildEventListView(r.Context(), filter, limit, offset)
 	if err != nil {
 		log.Printf("[ERROR] Failed to build event list view: limit=%d offset=%d err=%v", limit, offset, err)
 		h.handleInternalError(w, err)
@@ -126,10 +160,12 @@ func (h *Handler) HandleListEvents(w http.ResponseWriter, r *http.Request) {
 	}
 
 	h.writeJSON(w, http.StatusOK, EventListResponse{
-		Events: view.Events,
-		Total:  view.Total,
-		Limit:  limit,
-		Offset: offset,
+		Events:        view.Events,
+		Total:         view.Total,
+		Limit:         limit,
+		Offset:        offset,
+		DriverID:      filter.DriverID,
+		ParticipantID: filter.ParticipantID,
 	})
 }
 
@@ -185,6 +221,10 @@ func (h *Handler) HandleGetEvent(w http.ResponseWriter, r *http.Request) {
 }
 
 // HandleCreateEvent handles POST /api/v1/events.
+//
+// With dry_run set it runs every validation and returns the event, routes and
+// summary that would be stored, without writing them or ending the route
+// session.
 func (h *Handler) HandleCreateEvent(w http.ResponseWriter, r *http.Request) {
 	var req CreateEventRequest
 	var formRoutesJSON string
@@ -200,6 +240,7 @@ func (h *Handler) HandleCreateEvent(w http.ResponseWriter, r *http.Request) {
 		req.EventDate = r.FormValue("event_date")
 		req.Notes = r.FormValue("notes")
 		req.SessionID = r.FormValue("session_id")
+		req.DryRun = r.FormValue("dry_run") != ""
 		formRoutesJSON = r.FormValue("routes_json")
 
 		if req.SessionID == "" {
@@ -274,6 +315,12 @@ func (h *Handler) HandleCreateEvent(w http.ResponseWriter, r *http.Request) {
 		Mode:      mode,
 	}
 
+	if req.DryRun {
+		log.Printf("[HTTP] POST /api/v1/events: dry_run date=%s routes=%d", req.EventDate, len(routes))
+		h.writeJSON(w, http.StatusOK, EventDryRunResponse{DryRun: true, Event: event, Routes: routes, Summary: summary})
+		return
+	}
+
 	event, err = h.DB.Events().Create(r.Context(), event, routes, summary)
 	if err != nil {
 		log.Printf("[ERROR] Failed to create event: date=%s routes=%d err=%v", req.EventDate, len(routes), err)
@@ -342,7 +389,7 @@ func (h *Handler) HandleDeleteEvent(w http.ResponseWriter, r *http.Request) {
 				limit = l
 			}
 		}
-		view, err := h.buildEventListView(r.Context(), limit, 0)
+		view, err := h.buildEventListView(r.Context(), models.EventFilter{}, limit, 0)
 		if err != nil {
 			h.renderError(w, r, err)
 			return
@@ -354,8 +401,8 @@ func (h *Handler) HandleDeleteEvent(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func (h *Handler) buildEventListView(ctx context.Context, limit, offset int) (*EventListViewData, error) {
-	events, total, err := h.DB.Events().List(ctx, limit, offset)
+func (h *Handler) buildEventListView(ctx context.Context, filter models.EventFilter, limit, offset int) (*EventListViewData, error) {
+	events, total, err := h.DB.Events().ListFiltered(ctx, filter, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -397,6 +444,8 @@ func (h *Handler) buildEventListView(ctx context.Context, limit, offset int) (*E
 		NextOffset:     displayedCount,
 		PageSize:       defaultEventListPageSize,
 		UseMiles:       settings.UseMiles,
+		DriverID:       filter.DriverID,
+		ParticipantID:  filter.ParticipantID,
 	}, nil
 }
 
@@ -107,6 +107,63 @@ func TestHandleCreateEvent_SessionSaveWithoutRoutesJSON(t *testing.T) {
 	}
 }
 
+func TestHandleCreateEvent_DryRunValidatesWithoutSaving(t *testing.T) {
+	handler, store := newTestEventHandler(t, false)
+
+	routes := &models.RoutingResult{
+		Routes: []models.CalculatedRoute{
+			{
+				Driver:            &models.Driver{ID: 7, Name: "Dry Driver", VehicleCapacity: 2},
+				EffectiveCapacity: 2,
+				Stops:             []models.RouteStop{{Participant: &models.Participant{ID: 10, Name: "Alice"}}},
+				Mode:              "dropoff",
+			},
+		},
+		Summary: models.RoutingSummary{TotalParticipants: 1, TotalDriversUsed: 1},
+		Mode:    "dropoff",
+	}
+	post := func(eventDate string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(CreateEventRequest{EventDate: eventDate, Routes: routes, DryRun: true})
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/events", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.HandleCreateEvent(rr, req)
+		return rr
+	}
+
+	rr := post("2026-03-14")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d body=%s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var preview EventDryRunResponse
+	if err := json.NewDecoder(rr.Body).Decode(&preview); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if !preview.DryRun || preview.Event.ID != 0 || len(preview.Routes) != 1 || preview.Routes[0].DriverName != "Dry Driver" {
+		t.Fatalf("preview = %+v, want one unsaved route for Dry Driver", preview)
+	}
+
+	rr = post("03/14/2026")
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d for bad date, got %d body=%s", http.StatusBadRequest, rr.Code, rr.Body.String())
+	}
+	var resp ErrorResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Error.Message != messageInvalidEventDateFormat {
+		t.Fatalf("error = %q, want date format message", resp.Error.Message)
+	}
+
+	_, total, err := store.Events().List(context.Background(), 10, 0)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if total != 0 {
+		t.Fatalf("event count = %d after dry runs, want 0", total)
+	}
+}
+
 func TestHandleCreateEvent_ExpiredSessionReturnsNotFound(t *testing.T) {
 	handler, _ := newTestEventHandler(t, false)
 
@@ -377,6 +434,59 @@ func TestHandleListEvents_ReturnsJSONForStandardRequest(t *testing.T) {
 	}
 }
 
+func TestHandleListEvents_FiltersByDriver(t *testing.T) {
+	handler, store := newTestEventHandler(t, false)
+	createTestEvent(t, store, "2026-03-10", "first driver")
+	for _, eventDate := range []string{"2026-03-11", "2026-03-12"} {
+		date, _ := time.Parse("2006-01-02", eventDate)
+		if _, err := store.Events().Create(context.Background(), &models.Event{EventDate: date, Notes: "second driver " + eventDate, Mode: "dropoff"}, []models.EventRoute{{
+			DriverID:   12,
+			DriverName: "Driver Two",
+			Mode:       "dropoff",
+			Stops:      []models.EventRouteStop{{ParticipantID: 22, ParticipantName: "Passenger Two"}},
+		}}, &models.EventSummary{TotalParticipants: 1, TotalDrivers: 1, Mode: "dropoff"}); err != nil {
+			t.Fatalf("create event: %v", err)
+		}
+	}
+
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/events?driver_id=12&limit=1&offset=1", nil)
+	rr := httptest.NewRecorder()
+
+	handler.HandleListEvents(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d body=%s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var resp EventListResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Total != 2 || resp.DriverID != 12 {
+		t.Fatalf("total = %d driver = %d, want 2 events for driver 12", resp.Total, resp.DriverID)
+	}
+	if len(resp.Events) != 1 || resp.Events[0].Notes != "second driver 2026-03-11" {
+		t.Fatalf("events = %+v, want the older second-driver event on page two", resp.Events)
+	}
+
+	req = httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/events?participant_id=21", nil)
+	rr = httptest.NewRecorder()
+	handler.HandleListEvents(rr, req)
+	resp = EventListResponse{}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Total != 1 || resp.Events[0].Notes != "first driver" {
+		t.Fatalf("participant filter = %+v, want only the first driver's event", resp)
+	}
+
+	req = httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/events?driver_id=abc", nil)
+	rr = httptest.NewRecorder()
+	handler.HandleListEvents(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d for invalid driver_id, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
 func TestHandleListEvents_HTMXRendersHTMLWithoutLegacyNoticeAndIncludesMigratedEvents(t *testing.T) {
 	handler, _ := newTestEventHandler(t, true)
 
@@ -831,3 +941,49 @@ func createLegacyHistoryDB(t *testing.T, dbPath string) {
 func int64ToString(v int64) string {
 	return strconv.FormatInt(v, 10)
 }
+
+func TestHandleEventReport_MapIncludesStopCoordinates(t *testing.T) {
+	handler, store := newTestEventHandler(t, false)
+	ctx := context.Background()
+	driver, err := store.Drivers().Create(ctx, &models.Driver{Name: "Driver One", Address: "1 Driver Way", Lat: 41.35, Lng: -72.95, VehicleCapacity: 4})
+	if err != nil {
+		t.Fatalf("create driver: %v", err)
+	}
+	rider, err := store.Participants().Create(ctx, &models.Participant{Name: "Passenger One", Address: "2 Rider Road", Lat: 41.3, Lng: -72.9})
+	if err != nil {
+		t.Fatalf("create participant: %v", err)
+	}
+	event, err := store.Events().Create(ctx, &models.Event{EventDate: time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC), Mode: models.RouteModeDropoff}, []models.EventRoute{{
+		DriverID: driver.ID, DriverName: "Driver One", DriverAddress: "1 Driver Way", EffectiveCapacity: 4, Mode: models.RouteModeDropoff,
+		Stops: []models.EventRouteStop{
+			{Order: 0, ParticipantID: rider.ID, ParticipantName: "Passenger One", ParticipantAddress: "2 Rider Road"},
+			{Order: 1, ParticipantID: 999, ParticipantName: "Removed Rider", ParticipantAddress: "3 Gone Lane"},
+		},
+	}}, &models.EventSummary{TotalParticipants: 2, TotalDrivers: 1, Mode: models.RouteModeDropoff})
+	if err != nil {
+		t.Fatalf("create event: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.HandleEventReport(rr, httptest.NewRequestWithContext(ctx, http.MethodGet, "/api/v1/events/"+int64ToString(event.ID)+"/map", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var response EventMapResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(response.Routes) != 1 || len(response.Routes[0].Stops) != 2 {
+		t.Fatalf("routes = %+v, want one route with two stops", response.Routes)
+	}
+	route := response.Routes[0]
+	if route.Driver.Lat != 41.35 || route.Driver.Lng != -72.95 {
+		t.Fatalf("driver coordinates = %v,%v, want 41.35,-72.95", route.Driver.Lat, route.Driver.Lng)
+	}
+	if stop := route.Stops[0].Participant; stop.ID != rider.ID || stop.Lat != 41.3 || stop.Lng != -72.9 {
+		t.Fatalf("first stop = %+v, want rider at 41.3,-72.9", stop)
+	}
+	if stop := route.Stops[1].Participant; stop.Name != "Removed Rider" || len(response.UnlocatedParticipantIDs) != 1 || response.UnlocatedParticipantIDs[0] != 999 {
+		t.Fatalf("second stop = %+v unlocated = %v, want removed rider listed as unlocated", stop, response.UnlocatedParticipantIDs)
+	}
+}
@@ -13,17 +13,20 @@ import (
 	"ride-home-router/internal/httpx"
 	"ride-home-router/internal/routesession"
 	"ride-home-router/internal/routing"
+	"ride-home-router/internal/sheets"
 	"ride-home-router/internal/templates"
 )
 
 // Handler provides common handler utilities and dependencies
 type Handler struct {
-	DB           database.DataStore
-	Geocoder     geocoding.Geocoder
-	DistanceCalc distance.DistanceCalculator
-	Router       routing.Router
-	Renderer     *templates.Renderer
-	RouteSession *routesession.Store
+	DB                database.DataStore
+	Geocoder          geocoding.Geocoder
+	DistanceCalc      distance.DistanceCalculator
+	Router            routing.Router
+	Renderer          *templates.Renderer
+	RouteSession      *routesession.Store
+	Sheets            *sheets.Exporter            // Optional; nil disables Google Sheets export
+	AvoidHighwaysCalc distance.DistanceCalculator // Optional; nil when the distance provider cannot avoid highways
 }
 
 // ErrorResponse represents an API error
@@ -183,6 +186,8 @@ func (h *Handler) handleRoutingError(w http.ResponseWriter, err error) {
 			UnassignedCount:   rerr.UnassignedCount,
 			TotalCapacity:     rerr.TotalCapacity,
 			TotalParticipants: rerr.TotalParticipants,
+			OutsideTimeWindow: rerr.OutsideTimeWindow,
+			ExcludedByDriver:  rerr.ExcludedByDriver,
 		})
 		return
 	}
@@ -4,39 +4,93 @@ import "fmt"
 
 const (
 	messageAddressRequired                               = "Address is required"
+	messageAvoidHighwaysUnavailable                      = "Avoiding highways needs OSRM distances"
+	messageBackupNameRequired                            = "Backup name is required"
+	messageBackupNotFound                                = "Backup not found"
+	messageBackupsNotSupported                           = "Backups are not supported by this data store"
+	messageCapacityExceedsVehicle                        = "Capacity exceeds the van's seats"
 	messageChooseActivityLocationForEvent                = "Please choose an activity location for this event."
 	messageChooseRouteTime                               = "please choose a route time"
 	messageChooseValidActivityLocation                   = "Please choose a valid activity location."
+	messageChooseValidDepartureTime                      = "please choose a valid departure time"
 	messageChooseValidRouteTime                          = "please choose a valid route time"
 	messageDatabasePathMustBeAbsolute                    = "Database path must be absolute"
 	messageDatabasePathUpdatedRestart                    = "Database path updated. Restart the application to apply changes."
+	messageDriverImportEmpty                             = "CSV contains no driver rows"
+	messageDriverImportInstituteVehicle                  = "institute vehicles are managed as organization vans; add it on the Vans page"
 	messageDriverNotFound                                = "driver not found"
 	messageEventDateRequired                             = "Event date is required"
 	messageEventNotFound                                 = "Event not found"
+	messageExcludedByDriverMove                          = "That driver has excluded this participant"
 	messageGenericInternalError                          = "An error occurred. Please try again."
+	messageInvalidAge                                    = "Age must be zero or a positive whole number"
+	messageInvalidCandidateCount                         = "Candidate count must be between 1 and 10"
+	messageInvalidCandidateRanking                       = "Rank candidates by distance or fairness"
 	messageInvalidCapacity                               = "Invalid capacity"
+	messageInvalidComfortRadius                          = "Comfort radius must be zero or a positive distance"
+	messageInvalidCoordinates                            = "Latitude must be between -90 and 90 and longitude between -180 and 180"
+	messageInvalidDetourFairness                         = "Detour fairness must be absolute or ratio"
 	messageInvalidDriverID                               = "invalid driver ID"
 	messageInvalidEventDateFormat                        = "Invalid event date format (use YYYY-MM-DD)"
+	messageInvalidDriverImportCSV                        = "Invalid CSV. Expected columns: name,address,capacity,is_institute_vehicle"
+	messageInvalidEventFilter                            = "driver_id and participant_id must be positive IDs"
 	messageInvalidEventID                                = "Invalid event ID"
+	messageInvalidFairnessWeight                         = "Fairness weights must be zero or positive numbers"
+	messageInvalidFlexCapacity                           = "Flex capacity must be zero or a positive whole number"
+	messageInvalidForcedOrder                            = "Forced stop order must be zero or a positive stop index"
 	messageInvalidFormData                               = "Invalid form data"
+	messageInvalidHouseholdGroupingPrecision             = "Household grouping precision must be between 1 and 5 decimal places"
+	messageInvalidHouseholdSplit                         = "Household split must be id, youngest or oldest"
+	messageInvalidMaxDetour                              = "Maximum detour must be zero or a positive number of seconds"
+	messageInvalidMaxMinutes                             = "max_minutes must be a positive number"
+	messageInvalidMaxNeighborhoods                       = "Maximum neighborhoods must be zero or a positive whole number"
+	messageInvalidMaxParticipantRide                     = "Maximum ride time must be zero or a positive number of seconds"
+	messageInvalidMaxRouteDuration                       = "Maximum route duration must be zero or a positive number of seconds"
+	messageInvalidMaxWalk                                = "Maximum walk to a bus stop must be zero or a positive number of meters"
+	messageInvalidNavProvider                            = "Navigation links must use google, apple or osm"
+	messageInvalidOptimizationMetric                     = "Balance routes by duration or distance"
 	messageInvalidOrganizationVehicleID                  = "invalid organization vehicle ID"
+	messageInvalidOverflowPolicy                         = "Overflow policy must be van_first, flex_first or empty"
 	messageInvalidParticipantID                          = "invalid participant ID"
+	messageInvalidParticipantImportCSV                   = "Invalid CSV. Expected columns: name,address"
+	messageInvalidPerturbAttempts                        = "Attempts must be between 0 and 200"
+	messageInvalidPerturbTolerance                       = "Tolerance must be zero or a positive fraction"
+	messageInvalidPickupObjective                        = "Pickup objective must be arrival or max_ride"
+	messageInvalidPrewarmTimeout                         = "timeout_secs must be a positive number of seconds"
+	messageInvalidReimbursementRate                      = "Reimbursement rate must be zero or a positive number"
 	messageInvalidRequestBody                            = "Invalid request body"
 	messageInvalidRouteIndex                             = "Invalid route index"
 	messageInvalidRouteMode                              = "Please choose a valid route mode."
 	messageInvalidRoutesData                             = "Invalid routes data"
+	messageInvalidSessionVersion                         = "Invalid session version"
+	messageInvalidSpareSeatRadius                        = "Radius must be a positive number of meters"
+	messageInvalidTimeWindow                             = "Time window must use zero or positive seconds, with the latest no earlier than the earliest"
 	messageNameAndAddressRequired                        = "name and address are required"
 	messageNameRequired                                  = "Name is required"
+	messageNeedsAccessibleMove                           = "This participant needs an accessible vehicle"
+	messageNothingToUndo                                 = "There are no edits to undo"
 	messageOrganizationVehicleNotFound                   = "organization vehicle not found"
+	messageParticipantImportDuplicate                    = "participant with this name and address already exists"
+	messageParticipantImportEmpty                        = "CSV contains no participant rows"
 	messageParticipantNotFound                           = "participant not found"
 	messagePreferencesSaved                              = "Preferences saved!"
 	messageRoutingProviderConfigUnchanged                = "Google Maps API key unchanged."
 	messageRoutingProviderConfigUpdated                  = "Google Maps API key saved. Distance cache cleared."
+	messageReoptimizeDeclines                            = "Re-optimizing would leave riders over the detour ceiling without a ride. Lock them onto a route first."
+	messageReoptimizeBreaksLimits                        = "Re-optimizing would put a route with locked riders over its time limits. Unlock some riders or adjust the route by hand."
+	messageRouteNotFound                                 = "Route not found"
 	messageRoutesRequired                                = "Routes are required"
+	messageRoutesMustBeBalancedBeforePerturbing          = "Routes must be balanced before trying an alternative"
 	messageRoutesMustBeBalancedBeforeSaving              = "Routes must be balanced before saving"
 	messageMovesRequired                                 = "At least one move is required"
 	messageTooManyMoves                                  = "Too many moves in one request"
+	messageSearchFieldInvalid                            = "Search field must be name or address"
+	messageSessionChanged                                = "Someone else changed this plan. Reload it before making changes."
+	messageSessionFinalized                              = "This plan is finalized. Unlock it before making changes."
+	messageSessionActivityLocationNotFound               = "This session has no activity location"
 	messageSessionNotFound                               = "Session not found"
+	messageSoloRideMove                                  = "A solo rider must be the only passenger on their route"
+	messageSheetsExportNotConfigured                     = "Google Sheets export is not configured"
 	messageSelectedActivityLocationNotFound              = "Selected activity location not found"
 	messageSelectedActivityLocationNotFoundChooseAnother = "Selected activity location not found. Choose another location."
 	messageSelectAtLeastOneDriver                        = "Please select at least one driver."
@@ -50,6 +104,13 @@ const (
 	toastTypeWarning = "warning"
 )
 
+func messageDriversAtActivity(count int) string {
+	if count == 1 {
+		return "Routes calculated, but 1 driver's home matches the activity location. Check their address."
+	}
+	return fmt.Sprintf("Routes calculated, but %d drivers' homes match the activity location. Check their addresses.", count)
+}
+
 func messageEntityAdded(entity, name string) string {
 	return fmt.Sprintf("%s '%s' added!", entity, name)
 }
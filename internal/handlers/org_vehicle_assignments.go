@@ -132,14 +132,3 @@ func buildCapacityShortageViewData(rerr *routing.ErrRoutingFailed, drivers []mod
 		EffectiveCapacityByDriver: effectiveCapacityByDriver,
 	}
 }
-
-func countUsedOrgVehicles(routes []models.CalculatedRoute) int {
-	used := make(map[int64]struct{})
-	for _, route := range routes {
-		if route.OrgVehicleID <= 0 || len(route.Stops) == 0 {
-			continue
-		}
-		used[route.OrgVehicleID] = struct{}{}
-	}
-	return len(used)
-}
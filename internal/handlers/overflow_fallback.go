@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"cmp"
+	"context"
+	"log"
+	"maps"
+	"ride-home-router/internal/models"
+	"ride-home-router/internal/routing"
+	"slices"
+)
+
+// overflowStep adds seats toward shortfall by changing drivers and the van
+// assignments in place, returning how many seats it added.
+type overflowStep func(drivers []models.Driver, driverVehicles map[int64]*models.OrganizationVehicle, shortfall int) int
+
+// coverOverflow retries a calculation that ran out of seats, first with the
+// seats the policy prefers and then, if still short, with the other kind as
+// well. Van capacities are hard limits, so vans never take flex seats. It
+// returns the result with the drivers and van assignments that produced it,
+// or the last routing error when neither kind of seat is enough.
+func (c *routeCalculation) coverOverflow(
+	ctx context.Context,
+	policy models.OverflowPolicy,
+	req routing.RoutingRequest,
+	failure *routing.ErrRoutingFailed,
+	driverVehicles map[int64]*models.OrganizationVehicle,
+) (*models.RoutingResult, []models.Driver, map[int64]*models.OrganizationVehicle, error) {
+	shortfall := failure.TotalParticipants - failure.TotalCapacity
+	if policy == models.OverflowPolicyNone || shortfall <= 0 {
+		return nil, nil, nil, failure
+	}
+
+	vans, err := c.db.OrganizationVehicles().List(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	steps := map[string]overflowStep{
+		"flex": addFlexSeats,
+		"van": func(drivers []models.Driver, driverVehicles map[int64]*models.OrganizationVehicle, shortfall int) int {
+			return assignOverflowVans(drivers, driverVehicles, vans, shortfall)
+		},
+	}
+	order := []string{"van", "flex"}
+	if policy == models.OverflowPolicyFlexFirst {
+		order = []string{"flex", "van"}
+	}
+
+	req.Drivers = slices.Clone(req.Drivers)
+	driverVehicles = maps.Clone(driverVehicles)
+	err = failure
+	for _, name := range order {
+		added := steps[name](req.Drivers, driverVehicles, shortfall)
+		if added == 0 {
+			continue
+		}
+		shortfall -= added
+		log.Printf("[ROUTING] Covering seat shortage: policy=%s step=%s seats_added=%d", policy, name, added)
+
+		var result *models.RoutingResult
+		result, err = c.router.CalculateRoutes(ctx, &req)
+		if err == nil {
+			return result, req.Drivers, driverVehicles, nil
+		}
+		if _, ok := err.(*routing.ErrRoutingFailed); !ok {
+			return nil, nil, nil, err
+		}
+	}
+	return nil, nil, nil, err
+}
+
+// addFlexSeats raises volunteers' capacity by the flex seats they allow,
+// largest allowance first, until the shortfall is covered. Drivers in an
+// organization van are skipped.
+func addFlexSeats(drivers []models.Driver, driverVehicles map[int64]*models.OrganizationVehicle, shortfall int) int {
+	var flexible []int
+	for i, driver := range drivers {
+		if driver.FlexCapacity > 0 && driverVehicles[driver.ID] == nil {
+			flexible = append(flexible, i)
+		}
+	}
+	slices.SortStableFunc(flexible, func(a, b int) int {
+		return cmp.Or(cmp.Compare(drivers[b].FlexCapacity, drivers[a].FlexCapacity), cmp.Compare(drivers[a].ID, drivers[b].ID))
+	})
+
+	added := 0
+	for _, i := range flexible {
+		if added >= shortfall {
+			break
+		}
+		drivers[i].VehicleCapacity += drivers[i].FlexCapacity
+		added += drivers[i].FlexCapacity
+	}
+	return added
+}
+
+// assignOverflowVans hands unassigned organization vans, largest first, to
+// the drivers with the smallest vehicles until the shortfall is covered. A
+// van is only given to a driver whose own vehicle holds fewer people.
+func assignOverflowVans(drivers []models.Driver, driverVehicles map[int64]*models.OrganizationVehicle, vans []models.OrganizationVehicle, shortfall int) int {
+	inUse := make(map[int64]bool, len(driverVehicles))
+	for _, vehicle := range driverVehicles {
+		if vehicle != nil {
+			inUse[vehicle.ID] = true
+		}
+	}
+	var free []*models.OrganizationVehicle
+	for i := range vans {
+		if !inUse[vans[i].ID] {
+			free = append(free, &vans[i])
+		}
+	}
+	slices.SortStableFunc(free, func(a, b *models.OrganizationVehicle) int {
+		return cmp.Or(cmp.Compare(b.Capacity, a.Capacity), cmp.Compare(a.ID, b.ID))
+	})
+
+	var candidates []int
+	for i, driver := range drivers {
+		if driverVehicles[driver.ID] == nil {
+			candidates = append(candidates, i)
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b int) int {
+		return cmp.Or(cmp.Compare(drivers[a].VehicleCapacity, drivers[b].VehicleCapacity), cmp.Compare(drivers[a].ID, drivers[b].ID))
+	})
+
+	added := 0
+	for len(free) > 0 && len(candidates) > 0 && added < shortfall {
+		van, driver := free[0], &drivers[candidates[0]]
+		if van.Capacity <= driver.VehicleCapacity {
+			break
+		}
+		added += van.Capacity - driver.VehicleCapacity
+		driver.VehicleCapacity = van.Capacity
+		driverVehicles[driver.ID] = van
+		free, candidates = free[1:], candidates[1:]
+	}
+	return added
+}
@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"math"
 	"net/http"
 	"ride-home-router/internal/database"
+	"ride-home-router/internal/models"
 )
 
 // HandleIndexPage handles GET /
@@ -45,6 +47,11 @@ func (h *Handler) HandleIndexPage(w http.ResponseWriter, r *http.Request) {
 		h.renderError(w, r, err)
 		return
 	}
+	settings, err := h.DB.Settings().Get(r.Context())
+	if err != nil {
+		h.renderError(w, r, err)
+		return
+	}
 
 	h.renderTemplate(w, "index.html", IndexPageView{
 		BasePageView: BasePageView{
@@ -58,6 +65,8 @@ func (h *Handler) HandleIndexPage(w http.ResponseWriter, r *http.Request) {
 		DriverLabels:      driverLabels,
 		ActivityLocations: activityLocations,
 		OrgVehicles:       orgVehicles,
+		NavProvider:       settings.NavProvider,
+		AvoidHighways:     h.AvoidHighwaysCalc != nil,
 	})
 }
 
@@ -186,6 +195,16 @@ func (h *Handler) HandleSettingsPage(w http.ResponseWriter, r *http.Request) {
 	}
 	defaultDBPath, _ := database.GetDefaultDBPath()
 
+	cacheStats, err := h.distanceCacheStats(r.Context())
+	if err != nil {
+		h.renderError(w, r, err)
+		return
+	}
+	cacheView := DistanceCacheView{Entries: cacheStats.Entries, Hits: cacheStats.Hits, Misses: cacheStats.Misses, SizeKB: (cacheStats.SizeBytes + 1023) / 1024}
+	if reads := cacheStats.Hits + cacheStats.Misses; reads > 0 {
+		cacheView.HitPercent = int(math.Round(float64(cacheStats.Hits) * 100 / float64(reads)))
+	}
+
 	h.renderTemplate(w, "settings.html", SettingsPageView{
 		BasePageView: BasePageView{
 			Title:      "Settings",
@@ -200,12 +219,13 @@ func (h *Handler) HandleSettingsPage(w http.ResponseWriter, r *http.Request) {
 		RoutingProviderConfig: RoutingProviderConfigView{
 			GoogleMapsAPIKeyConfigured: dbConfig.GoogleMapsAPIKey != "",
 		},
+		DistanceCache: cacheView,
 	})
 }
 
 // HandleHistoryPage handles GET /history
 func (h *Handler) HandleHistoryPage(w http.ResponseWriter, r *http.Request) {
-	view, err := h.buildEventListView(r.Context(), 20, 0)
+	view, err := h.buildEventListView(r.Context(), models.EventFilter{}, 20, 0)
 	if err != nil {
 		h.renderError(w, r, err)
 		return
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"ride-home-router/internal/models"
+	"strings"
+)
+
+// ParticipantImportRowResult reports the outcome of one CSV data row. Row is
+// the 1-based line number in the uploaded file and Address is the address as
+// written there.
+type ParticipantImportRowResult struct {
+	Row         int                 `json:"row"`
+	Name        string              `json:"name"`
+	Address     string              `json:"address"`
+	Created     bool                `json:"created"`
+	Duplicate   bool                `json:"duplicate,omitempty"`
+	Participant *models.Participant `json:"participant,omitempty"`
+	ErrorMsg    string              `json:"error,omitempty"`
+}
+
+// ParticipantImportResponse summarizes a participant CSV import.
+type ParticipantImportResponse struct {
+	Created    int                          `json:"created"`
+	Duplicates int                          `json:"duplicates"`
+	Failed     int                          `json:"failed"`
+	Rows       []ParticipantImportRowResult `json:"rows"`
+}
+
+// HandleImportParticipants handles POST /api/v1/participants/import
+//
+// The body is CSV with columns name,address; a header row is skipped when
+// present. Rows matching an existing participant, or an earlier row, by name
+// and address are skipped. Each remaining row is geocoded and created
+// independently, so one bad address does not stop the rest. Rows are geocoded
+// one at a time and the geocoder spaces its requests to stay within
+// Nominatim's rate limit.
+func (h *Handler) HandleImportParticipants(w http.ResponseWriter, r *http.Request) {
+	rows, ok := h.readCSVImport(w, r, "/api/v1/participants/import", messageInvalidParticipantImportCSV, messageParticipantImportEmpty)
+	if !ok {
+		return
+	}
+
+	existing, err := h.DB.Participants().List(r.Context(), "")
+	if err != nil {
+		log.Printf("[ERROR] Failed to list participants for import: err=%v", err)
+		h.handleInternalError(w, err)
+		return
+	}
+	seen := make(map[string]bool, len(existing)+len(rows))
+	for _, participant := range existing {
+		seen[participantImportKey(participant.Name, participant.Address)] = true
+	}
+
+	log.Printf("[HTTP] POST /api/v1/participants/import: rows=%d", len(rows))
+	response := ParticipantImportResponse{Rows: make([]ParticipantImportRowResult, 0, len(rows))}
+	for _, row := range rows {
+		result := h.importParticipantRow(r, row, seen)
+		switch {
+		case result.Created:
+			response.Created++
+		case result.Duplicate:
+			response.Duplicates++
+		default:
+			response.Failed++
+		}
+		response.Rows = append(response.Rows, result)
+	}
+
+	log.Printf("[HTTP] Imported participants: created=%d duplicates=%d failed=%d", response.Created, response.Duplicates, response.Failed)
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) importParticipantRow(r *http.Request, row csvImportRow, seen map[string]bool) ParticipantImportRowResult {
+	result := ParticipantImportRowResult{Row: row.number, Name: row.field(0), Address: row.field(1)}
+	fail := func(err error) ParticipantImportRowResult {
+		result.ErrorMsg = row.fail("Participant", err)
+		return result
+	}
+
+	if result.Name == "" || result.Address == "" {
+		return fail(errors.New(messageNameAndAddressRequired))
+	}
+	key := participantImportKey(result.Name, result.Address)
+	if seen[key] {
+		result.Duplicate = true
+		result.ErrorMsg = messageParticipantImportDuplicate
+		return result
+	}
+
+	geocodeResult, err := h.Geocoder.GeocodeWithRetry(r.Context(), result.Address, 3)
+	if err != nil {
+		return fail(err)
+	}
+	participant, err := h.DB.Participants().Create(r.Context(), &models.Participant{
+		Name:    result.Name,
+		Address: result.Address,
+		Lat:     geocodeResult.Coords.Lat,
+		Lng:     geocodeResult.Coords.Lng,
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	seen[key] = true
+	result.Created = true
+	result.Participant = participant
+	return result
+}
+
+// participantImportKey matches participants by name and address, ignoring
+// case and surrounding whitespace.
+func participantImportKey(name, address string) string {
+	return strings.ToLower(strings.TrimSpace(name)) + "\x00" + strings.ToLower(strings.TrimSpace(address))
+}
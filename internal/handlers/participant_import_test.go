@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"ride-home-router/internal/geocoding"
+	"ride-home-router/internal/models"
+	"strings"
+	"testing"
+)
+
+// unknownAddressGeocoder fails to geocode addresses containing "Nowhere".
+type unknownAddressGeocoder struct{ stubGeocoder }
+
+func (g unknownAddressGeocoder) GeocodeWithRetry(_ context.Context, address string, _ int) (*geocoding.GeocodingResult, error) {
+	if strings.Contains(address, "Nowhere") {
+		return nil, errors.New("no results found")
+	}
+	return &geocoding.GeocodingResult{Coords: models.Coordinates{Lat: 41.25, Lng: -72.75}}, nil
+}
+
+func TestHandleImportParticipantsReportsFailuresAndSkipsDuplicates(t *testing.T) {
+	handler, store := newTestManagementHandler(t)
+	handler.Geocoder = unknownAddressGeocoder{}
+	if _, err := store.Participants().Create(context.Background(), &models.Participant{Name: "Ada", Address: "1 Oak Street", Lat: 41, Lng: -72}); err != nil {
+		t.Fatalf("create participant: %v", err)
+	}
+	csvBody := strings.Join([]string{
+		"name,address",
+		"ada, 1 oak street",
+		"Ben,2 Oak Street",
+		"Cam,9 Nowhere Road",
+		"Ben,2 Oak Street",
+		"Dee,",
+	}, "\n")
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/participants/import", strings.NewReader(csvBody))
+	req.Header.Set("Content-Type", "text/csv")
+	handler.HandleImportParticipants(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body=%s", w.Code, w.Body.String())
+	}
+	var response ParticipantImportResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if response.Created != 1 || response.Duplicates != 2 || response.Failed != 2 || len(response.Rows) != 5 {
+		t.Fatalf("response = %+v, want 1 created, 2 duplicates and 2 failed of 5 rows", response)
+	}
+	if ada := response.Rows[0]; !ada.Duplicate || ada.Created {
+		t.Fatalf("existing participant row = %+v, want duplicate", ada)
+	}
+	if ben := response.Rows[1]; !ben.Created || ben.Participant == nil || ben.Participant.Lat != 41.25 {
+		t.Fatalf("Ben row = %+v, want created with geocoded coordinates", ben)
+	}
+	if cam := response.Rows[2]; cam.Row != 4 || cam.Created || cam.Address != "9 Nowhere Road" || cam.ErrorMsg == "" {
+		t.Fatalf("ungeocodable row = %+v, want row 4 failure with raw address", cam)
+	}
+	if repeat := response.Rows[3]; !repeat.Duplicate {
+		t.Fatalf("repeated row = %+v, want duplicate of the earlier row", repeat)
+	}
+	if dee := response.Rows[4]; dee.ErrorMsg != messageNameAndAddressRequired {
+		t.Fatalf("missing address row = %+v, want rejected", dee)
+	}
+
+	participants, err := store.Participants().List(context.Background(), "")
+	if err != nil {
+		t.Fatalf("list participants: %v", err)
+	}
+	if len(participants) != 2 {
+		t.Fatalf("stored participants = %d, want 2", len(participants))
+	}
+}
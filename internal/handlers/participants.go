@@ -6,6 +6,7 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"ride-home-router/internal/database"
 	"ride-home-router/internal/models"
 	"strconv"
 	"strings"
@@ -26,9 +27,19 @@ type ParticipantResponse struct {
 // HandleListParticipants handles GET /api/v1/participants
 func (h *Handler) HandleListParticipants(w http.ResponseWriter, r *http.Request) {
 	search := r.URL.Query().Get("search")
-	log.Printf("[HTTP] GET /api/v1/participants: search=%s", search)
+	field, err := database.ParseSearchField(r.URL.Query().Get("field"))
+	if err != nil {
+		log.Printf("[HTTP] GET /api/v1/participants: invalid search field err=%v", err)
+		if h.isHTMX(r) {
+			h.handleValidationErrorHTMX(w, r, messageSearchFieldInvalid)
+			return
+		}
+		h.handleValidationError(w, messageSearchFieldInvalid)
+		return
+	}
+	log.Printf("[HTTP] GET /api/v1/participants: search=%s field=%s", search, field)
 
-	participants, err := h.DB.Participants().List(r.Context(), search)
+	participants, err := h.DB.Participants().Search(r.Context(), search, field)
 	if err != nil {
 		log.Printf("[ERROR] Failed to list participants: search=%s err=%v", search, err)
 		if h.isHTMX(r) {
@@ -99,9 +110,19 @@ func (h *Handler) HandleGetParticipant(w http.ResponseWriter, r *http.Request) {
 // HandleCreateParticipant handles POST /api/v1/participants
 func (h *Handler) HandleCreateParticipant(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Name     string  `json:"name"`
-		Address  string  `json:"address"`
-		LabelIDs []int64 `json:"label_ids"`
+		Name             string              `json:"name"`
+		Address          string              `json:"address"`
+		LabelIDs         []int64             `json:"label_ids"`
+		Attributes       map[string]string   `json:"attributes"`
+		RequiredMatches  []string            `json:"required_matches"`
+		MeetingPoint     *models.Coordinates `json:"meeting_point"`
+		SoloRide         bool                `json:"solo_ride"`
+		Accessible       bool                `json:"requires_accessible"`
+		Age              int                 `json:"age"`
+		IntermediateStop *models.Coordinates `json:"intermediate_stop"`
+		EarliestSecs     float64             `json:"earliest_secs"`
+		LatestSecs       float64             `json:"latest_secs"`
+		ForcedOrder      *int                `json:"forced_order"`
 	}
 	var labelIDs []int64
 
@@ -137,6 +158,18 @@ func (h *Handler) HandleCreateParticipant(w http.ResponseWriter, r *http.Request
 		h.handleValidationError(w, messageNameAndAddressRequired)
 		return
 	}
+	if req.Age < 0 {
+		h.handleValidationError(w, messageInvalidAge)
+		return
+	}
+	if !validTimeWindow(req.EarliestSecs, req.LatestSecs) {
+		h.handleValidationError(w, messageInvalidTimeWindow)
+		return
+	}
+	if req.ForcedOrder != nil && *req.ForcedOrder < 0 {
+		h.handleValidationError(w, messageInvalidForcedOrder)
+		return
+	}
 	if err := h.validateLabelIDs(r.Context(), labelIDs); err != nil {
 		log.Printf("[HTTP] POST /api/v1/participants: invalid_labels err=%v", err)
 		if h.isHTMX(r) {
@@ -160,10 +193,20 @@ func (h *Handler) HandleCreateParticipant(w http.ResponseWriter, r *http.Request
 	}
 
 	participant := &models.Participant{
-		Name:    req.Name,
-		Address: req.Address,
-		Lat:     geocodeResult.Coords.Lat,
-		Lng:     geocodeResult.Coords.Lng,
+		Name:               req.Name,
+		Address:            req.Address,
+		Lat:                geocodeResult.Coords.Lat,
+		Lng:                geocodeResult.Coords.Lng,
+		Attributes:         req.Attributes,
+		RequiredMatches:    req.RequiredMatches,
+		MeetingPoint:       req.MeetingPoint,
+		SoloRide:           req.SoloRide,
+		RequiresAccessible: req.Accessible,
+		Age:                req.Age,
+		IntermediateStop:   req.IntermediateStop,
+		EarliestSecs:       req.EarliestSecs,
+		LatestSecs:         req.LatestSecs,
+		ForcedOrder:        req.ForcedOrder,
 	}
 
 	participant, err = h.DB.Participants().CreateWithLabels(r.Context(), participant, labelIDs)
@@ -245,9 +288,19 @@ func (h *Handler) HandleUpdateParticipant(w http.ResponseWriter, r *http.Request
 	}
 
 	var req struct {
-		Name     string   `json:"name"`
-		Address  string   `json:"address"`
-		LabelIDs *[]int64 `json:"label_ids"`
+		Name             string             `json:"name"`
+		Address          string             `json:"address"`
+		LabelIDs         *[]int64           `json:"label_ids"`
+		Attributes       *map[string]string `json:"attributes"`
+		RequiredMatches  *[]string          `json:"required_matches"`
+		MeetingPoint     json.RawMessage    `json:"meeting_point"`
+		SoloRide         *bool              `json:"solo_ride"`
+		Accessible       *bool              `json:"requires_accessible"`
+		Age              *int               `json:"age"`
+		IntermediateStop json.RawMessage    `json:"intermediate_stop"`
+		EarliestSecs     *float64           `json:"earliest_secs"`
+		LatestSecs       *float64           `json:"latest_secs"`
+		ForcedOrder      json.RawMessage    `json:"forced_order"`
 	}
 	var labelIDs []int64
 	shouldSetLabels := false
@@ -285,6 +338,10 @@ func (h *Handler) HandleUpdateParticipant(w http.ResponseWriter, r *http.Request
 		h.handleValidationError(w, messageNameAndAddressRequired)
 		return
 	}
+	if req.Age != nil && *req.Age < 0 {
+		h.handleValidationError(w, messageInvalidAge)
+		return
+	}
 	if shouldSetLabels {
 		if err := h.validateLabelIDs(r.Context(), labelIDs); err != nil {
 			log.Printf("[HTTP] PUT /api/v1/participants/{id}: invalid_labels id=%d err=%v", id, err)
@@ -298,12 +355,76 @@ func (h *Handler) HandleUpdateParticipant(w http.ResponseWriter, r *http.Request
 	}
 
 	participant := &models.Participant{
-		ID:        id,
-		Name:      req.Name,
-		Address:   req.Address,
-		Lat:       existing.Lat,
-		Lng:       existing.Lng,
-		CreatedAt: existing.CreatedAt,
+		ID:                 id,
+		Name:               req.Name,
+		Address:            req.Address,
+		Lat:                existing.Lat,
+		Lng:                existing.Lng,
+		Attributes:         existing.Attributes,
+		RequiredMatches:    existing.RequiredMatches,
+		MeetingPoint:       existing.MeetingPoint,
+		SoloRide:           existing.SoloRide,
+		RequiresAccessible: existing.RequiresAccessible,
+		Age:                existing.Age,
+		IntermediateStop:   existing.IntermediateStop,
+		EarliestSecs:       existing.EarliestSecs,
+		LatestSecs:         existing.LatestSecs,
+		ForcedOrder:        existing.ForcedOrder,
+		CreatedAt:          existing.CreatedAt,
+	}
+	if req.Attributes != nil {
+		participant.Attributes = *req.Attributes
+	}
+	if req.RequiredMatches != nil {
+		participant.RequiredMatches = *req.RequiredMatches
+	}
+	if req.SoloRide != nil {
+		participant.SoloRide = *req.SoloRide
+	}
+	if req.Accessible != nil {
+		participant.RequiresAccessible = *req.Accessible
+	}
+	if req.Age != nil {
+		participant.Age = *req.Age
+	}
+	if req.EarliestSecs != nil {
+		participant.EarliestSecs = *req.EarliestSecs
+	}
+	if req.LatestSecs != nil {
+		participant.LatestSecs = *req.LatestSecs
+	}
+	if !validTimeWindow(participant.EarliestSecs, participant.LatestSecs) {
+		h.handleValidationError(w, messageInvalidTimeWindow)
+		return
+	}
+	// An absent meeting point keeps the existing one; an explicit null clears it.
+	if len(req.MeetingPoint) > 0 {
+		var meetingPoint *models.Coordinates
+		if err := json.Unmarshal(req.MeetingPoint, &meetingPoint); err != nil {
+			h.handleValidationError(w, messageInvalidRequestBody)
+			return
+		}
+		participant.MeetingPoint = meetingPoint
+	}
+	if len(req.IntermediateStop) > 0 {
+		var intermediateStop *models.Coordinates
+		if err := json.Unmarshal(req.IntermediateStop, &intermediateStop); err != nil {
+			h.handleValidationError(w, messageInvalidRequestBody)
+			return
+		}
+		participant.IntermediateStop = intermediateStop
+	}
+	if len(req.ForcedOrder) > 0 {
+		var forcedOrder *int
+		if err := json.Unmarshal(req.ForcedOrder, &forcedOrder); err != nil {
+			h.handleValidationError(w, messageInvalidRequestBody)
+			return
+		}
+		if forcedOrder != nil && *forcedOrder < 0 {
+			h.handleValidationError(w, messageInvalidForcedOrder)
+			return
+		}
+		participant.ForcedOrder = forcedOrder
 	}
 
 	if req.Address != existing.Address {
@@ -528,3 +649,9 @@ func (h *Handler) participantResponses(ctx context.Context, participants []model
 	}
 	return responses, nil
 }
+
+// validTimeWindow reports whether a participant's window is usable: neither
+// side negative, and the latest, when set, no earlier than the earliest.
+func validTimeWindow(earliestSecs, latestSecs float64) bool {
+	return earliestSecs >= 0 && latestSecs >= 0 && (latestSecs == 0 || latestSecs >= earliestSecs)
+}
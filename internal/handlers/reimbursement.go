@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"ride-home-router/internal/models"
+	"strconv"
+	"strings"
+)
+
+const (
+	metersPerMile      = 1609.344
+	metersPerKilometer = 1000.0
+)
+
+// distanceUnit returns the display unit and its length in meters.
+func distanceUnit(useMiles bool) (string, float64) {
+	if useMiles {
+		return "mi", metersPerMile
+	}
+	return "km", metersPerKilometer
+}
+
+// DriverReimbursement is one volunteer driver's line in a reimbursement
+// report. Distance is in the report's unit and Amount is rounded to cents.
+type DriverReimbursement struct {
+	DriverID       int64   `json:"driver_id"`
+	DriverName     string  `json:"driver_name"`
+	DistanceMeters float64 `json:"distance_meters"`
+	Distance       float64 `json:"distance"`
+	Amount         float64 `json:"amount"`
+}
+
+// ReimbursementReport estimates what each volunteer driver is owed for a set
+// of routes. Rate is per mile when Unit is "mi" and per kilometer when it is
+// "km", following the UseMiles setting. Org van routes and routes without
+// stops are left out because no volunteer drove their own car.
+type ReimbursementReport struct {
+	Unit          string                `json:"unit"`
+	Rate          float64               `json:"rate"`
+	Drivers       []DriverReimbursement `json:"drivers"`
+	TotalDistance float64               `json:"total_distance"`
+	TotalAmount   float64               `json:"total_amount"`
+}
+
+// reimbursementRoute is the part of a live or saved route the report needs.
+type reimbursementRoute struct {
+	driverID       int64
+	driverName     string
+	distanceMeters float64
+}
+
+// HandleRouteSessionReimbursement handles GET /api/v1/routes/session/reimbursement
+func (h *Handler) HandleRouteSessionReimbursement(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("session_id")
+	snapshot, ok := h.RouteSession.Snapshot(id)
+	if !ok {
+		h.handleNotFound(w, messageSessionNotFound)
+		return
+	}
+	settings, err := h.DB.Settings().Get(r.Context())
+	if err != nil {
+		log.Printf("[ERROR] Failed to get settings for reimbursement: err=%v", err)
+		h.handleInternalError(w, err)
+		return
+	}
+
+	routes := make([]reimbursementRoute, 0, len(snapshot.Routes))
+	for _, route := range snapshot.Routes {
+		if route.Driver == nil || route.OrgVehicleID != 0 || len(route.Stops) == 0 {
+			continue
+		}
+		routes = append(routes, reimbursementRoute{driverID: route.Driver.ID, driverName: route.Driver.Name, distanceMeters: route.TotalDistanceMeters})
+	}
+
+	log.Printf("[HTTP] GET /api/v1/routes/session/reimbursement: session=%s routes=%d", id, len(routes))
+	h.writeJSON(w, http.StatusOK, buildReimbursementReport(routes, settings))
+}
+
+// HandleEventReimbursement handles GET /api/v1/events/{id}/reimbursement
+func (h *Handler) HandleEventReimbursement(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/events/"), "/reimbursement")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		log.Printf("[HTTP] GET /api/v1/events/{id}/reimbursement: invalid_id=%s err=%v", idStr, err)
+		h.handleValidationError(w, messageInvalidEventID)
+		return
+	}
+
+	_, eventRoutes, _, err := h.DB.Events().GetByID(r.Context(), id)
+	if err != nil {
+		if h.checkNotFound(err) {
+			h.handleNotFound(w, messageEventNotFound)
+			return
+		}
+		log.Printf("[ERROR] Failed to get event for reimbursement: id=%d err=%v", id, err)
+		h.handleInternalError(w, err)
+		return
+	}
+	settings, err := h.DB.Settings().Get(r.Context())
+	if err != nil {
+		log.Printf("[ERROR] Failed to get settings for reimbursement: err=%v", err)
+		h.handleInternalError(w, err)
+		return
+	}
+
+	routes := make([]reimbursementRoute, 0, len(eventRoutes))
+	for _, route := range eventRoutes {
+		if route.OrgVehicleID != 0 || len(route.Stops) == 0 {
+			continue
+		}
+		routes = append(routes, reimbursementRoute{driverID: route.DriverID, driverName: route.DriverName, distanceMeters: route.TotalDistanceMeters})
+	}
+
+	log.Printf("[HTTP] GET /api/v1/events/{id}/reimbursement: id=%d routes=%d", id, len(routes))
+	h.writeJSON(w, http.StatusOK, buildReimbursementReport(routes, settings))
+}
+
+// buildReimbursementReport totals distance per driver, in route order, and
+// prices it at the configured rate. A driver with several routes gets one line.
+func buildReimbursementReport(routes []reimbursementRoute, settings *models.Settings) ReimbursementReport {
+	unit, metersPerUnit := distanceUnit(settings.UseMiles)
+	report := ReimbursementReport{Unit: unit, Rate: settings.ReimbursementRate, Drivers: []DriverReimbursement{}}
+
+	indexByDriver := make(map[int64]int, len(routes))
+	for _, route := range routes {
+		index, ok := indexByDriver[route.driverID]
+		if !ok {
+			index = len(report.Drivers)
+			indexByDriver[route.driverID] = index
+			report.Drivers = append(report.Drivers, DriverReimbursement{DriverID: route.driverID, DriverName: route.driverName})
+		}
+		report.Drivers[index].DistanceMeters += route.distanceMeters
+	}
+
+	for i := range report.Drivers {
+		line := &report.Drivers[i]
+		line.Distance = line.DistanceMeters / metersPerUnit
+		line.Amount = roundToCents(line.Distance * report.Rate)
+		report.TotalDistance += line.Distance
+		report.TotalAmount += line.Amount
+	}
+	report.TotalAmount = roundToCents(report.TotalAmount)
+	return report
+}
+
+func roundToCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"ride-home-router/internal/models"
+	"ride-home-router/internal/routesession"
+	"testing"
+)
+
+func TestHandleRouteSessionReimbursementPricesVolunteerMiles(t *testing.T) {
+	handler, store := newTestEventHandler(t, false)
+	ctx := context.Background()
+	if err := store.Settings().Update(ctx, &models.Settings{UseMiles: true, ReimbursementRate: 0.67}); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+
+	driver := models.Driver{ID: 1, Name: "Volunteer", VehicleCapacity: 2}
+	vanDriver := models.Driver{ID: 2, Name: "Van Driver", VehicleCapacity: 2}
+	session := handler.RouteSession.Create(routesession.CreateInput{
+		Routes: []models.CalculatedRoute{
+			{
+				Driver:              &driver,
+				EffectiveCapacity:   2,
+				Stops:               []models.RouteStop{{Participant: &models.Participant{ID: 10, Name: "Alice"}}},
+				TotalDistanceMeters: 10 * 1609.344,
+				Mode:                models.RouteModeDropoff,
+			},
+			{
+				Driver:              &vanDriver,
+				EffectiveCapacity:   8,
+				OrgVehicleID:        5,
+				Stops:               []models.RouteStop{{Participant: &models.Participant{ID: 11, Name: "Bob"}}},
+				TotalDistanceMeters: 20000,
+				Mode:                models.RouteModeDropoff,
+			},
+		},
+		SelectedDrivers:  []models.Driver{driver, vanDriver},
+		ActivityLocation: &models.ActivityLocation{ID: 1, Name: "HQ", Address: "1 Main"},
+		Mode:             models.RouteModeDropoff,
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/routes/session/reimbursement?session_id="+session.ID, nil)
+	w := httptest.NewRecorder()
+	handler.HandleRouteSessionReimbursement(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body=%s", w.Code, w.Body.String())
+	}
+	var report ReimbursementReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if report.Unit != "mi" || report.Rate != 0.67 {
+		t.Fatalf("unit/rate = %s/%v, want mi/0.67", report.Unit, report.Rate)
+	}
+	if len(report.Drivers) != 1 || report.Drivers[0].DriverID != driver.ID {
+		t.Fatalf("drivers = %+v, want only the volunteer", report.Drivers)
+	}
+	if got := report.Drivers[0].Distance; got < 9.999 || got > 10.001 {
+		t.Fatalf("distance = %v, want 10 miles", got)
+	}
+	if report.Drivers[0].Amount != 6.70 || report.TotalAmount != 6.70 {
+		t.Fatalf("amount = %v total = %v, want 6.70", report.Drivers[0].Amount, report.TotalAmount)
+	}
+}
+
+func TestHandleEventReimbursementUsesKilometersWhenMilesDisabled(t *testing.T) {
+	handler, store := newTestEventHandler(t, false)
+	ctx := context.Background()
+	if err := store.Settings().Update(ctx, &models.Settings{UseMiles: false, ReimbursementRate: 0.4}); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+	event := createTestEvent(t, store, "2026-03-14", "reimbursed event")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/events/"+int64ToString(event.ID)+"/reimbursement", nil)
+	w := httptest.NewRecorder()
+	handler.HandleEventReimbursement(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body=%s", w.Code, w.Body.String())
+	}
+	var report ReimbursementReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if report.Unit != "km" || len(report.Drivers) != 1 {
+		t.Fatalf("report = %+v, want one driver in km", report)
+	}
+	if report.Drivers[0].Distance != 1.5 || report.Drivers[0].Amount != 0.60 {
+		t.Fatalf("distance = %v amount = %v, want 1.5 km and 0.60", report.Drivers[0].Distance, report.Drivers[0].Amount)
+	}
+}
@@ -3,7 +3,9 @@ package handlers
 import (
 	"context"
 	"errors"
+	"log"
 	"ride-home-router/internal/database"
+	"ride-home-router/internal/distance"
 	"ride-home-router/internal/models"
 	"ride-home-router/internal/routesession"
 	"ride-home-router/internal/routing"
@@ -17,6 +19,10 @@ var (
 	errSomeDriversNotFound      = errors.New("some drivers not found")
 )
 
+// driverRotationEvents is how many of the most recent events count toward a
+// driver's recent rides when drivers are rotated, roughly one season.
+const driverRotationEvents = 12
+
 const (
 	routeCalculationUnknown routeCalculationKind = iota
 	routeCalculationSuccess
@@ -27,12 +33,60 @@ const (
 )
 
 type routeCalculationInput struct {
-	ParticipantIDs        []int64
-	DriverIDs             []int64
-	ActivityLocationID    int64
-	RouteTime             string
-	Mode                  models.RouteMode
+	ParticipantIDs     []int64
+	DriverIDs          []int64
+	ActivityLocationID int64
+	RouteTime          string
+	Mode               models.RouteMode
+	// Metric selects the cost routes are balanced by; empty means duration.
+	Metric routing.OptimizationMetric
+	// PickupObjective selects the pickup-mode objective; empty means arrival.
+	PickupObjective       routing.PickupObjective
 	OrgVehicleAssignments map[int64]int64
+	// MaxParticipantRideSecs caps any one participant's ride; zero disables it.
+	MaxParticipantRideSecs float64
+	// MaxDetourSecs is a fairness ceiling on driver detours; riders over it
+	// are declined when DeclineOverDetour is set.
+	MaxDetourSecs     float64
+	DeclineOverDetour bool
+	// CompactDepartures spreads dropoff routes whose first stops are neighbors.
+	CompactDepartures bool
+	// MaxNeighborhoods softly caps the neighborhoods one route visits.
+	MaxNeighborhoods int
+	// YoungestDroppedFirst drops younger participants earlier among equally
+	// good dropoff orders.
+	YoungestDroppedFirst bool
+	// HouseholdSplit picks who splits off first from a household no car can
+	// hold.
+	HouseholdSplit routing.HouseholdSplit
+	// DetourFairness picks whether detours are balanced in absolute time or
+	// relative to each driver's direct trip.
+	DetourFairness routing.DetourFairness
+	// ExcludeHomeLeg leaves the drivers' home legs out of the optimization.
+	ExcludeHomeLeg bool
+	// FairnessWeights weigh driver detours and unused drivers as one cost.
+	FairnessWeights routing.FairnessWeights
+	// BusStops, when set, routes over the stops participants walk to, no
+	// farther than MaxWalkMeters from home.
+	BusStops      []models.Coordinates
+	MaxWalkMeters float64
+	// RotateDrivers favors the drivers with the fewest routes in recent
+	// saved events.
+	RotateDrivers bool
+	// PreferNearbyDrivers fills the drivers nearest the participants first.
+	PreferNearbyDrivers bool
+	// IncludeGeometry adds each stop's road path to the result.
+	IncludeGeometry bool
+	// ExtraDrivers are unsaved drivers routed alongside the selected ones.
+	ExtraDrivers []models.Driver
+	// Distances is a shared matrix for callers that calculate the same
+	// points more than once; nil uses the router's own calculator.
+	Distances distance.DistanceCalculator
+	// AvoidHighways marks Distances as the highway-free calculator, so the
+	// route session keeps measuring edits with it.
+	AvoidHighways bool
+	// Preview skips creating a route session for the result.
+	Preview bool
 }
 
 type routeCalculationOutcome struct {
@@ -69,47 +123,158 @@ func newRouteCalculation(db database.DataStore, router routing.Router, sessions
 	return &routeCalculation{db: db, router: router, sessions: sessions}
 }
 
-func (c *routeCalculation) calculate(ctx context.Context, input routeCalculationInput) routeCalculationOutcome {
-	settings, err := c.db.Settings().Get(ctx)
-	if err != nil {
-		return routeCalculationOutcome{Kind: routeCalculationInternalFailure, Err: err}
-	}
+// routeSelection is the stored data named by a calculation's input.
+type routeSelection struct {
+	activityLocation *models.ActivityLocation
+	participants     []models.Participant
+	drivers          []models.Driver
+}
+
+// loadSelection loads the input's activity location, participants and
+// drivers. A missing record is reported with one of the selection errors.
+func (c *routeCalculation) loadSelection(ctx context.Context, input routeCalculationInput) (routeSelection, error) {
 	activityLocation, err := c.db.ActivityLocations().GetByID(ctx, input.ActivityLocationID)
 	if err != nil {
 		if errors.Is(err, database.ErrNotFound) {
-			return routeCalculationOutcome{Kind: routeCalculationValidationFailure, Err: errActivityLocationNotFound}
+			return routeSelection{}, errActivityLocationNotFound
 		}
-		return routeCalculationOutcome{Kind: routeCalculationInternalFailure, Err: err}
+		return routeSelection{}, err
 	}
 	participants, err := c.db.Participants().GetByIDs(ctx, input.ParticipantIDs)
 	if err != nil {
-		return routeCalculationOutcome{Kind: routeCalculationInternalFailure, Err: err}
+		return routeSelection{}, err
 	}
 	if len(participants) != len(input.ParticipantIDs) {
-		return routeCalculationOutcome{Kind: routeCalculationValidationFailure, Err: errSomeParticipantsNotFound}
+		return routeSelection{}, errSomeParticipantsNotFound
 	}
 	drivers, err := c.db.Drivers().GetByIDs(ctx, input.DriverIDs)
 	if err != nil {
-		return routeCalculationOutcome{Kind: routeCalculationInternalFailure, Err: err}
+		return routeSelection{}, err
 	}
 	if len(drivers) != len(input.DriverIDs) {
-		return routeCalculationOutcome{Kind: routeCalculationValidationFailure, Err: errSomeDriversNotFound}
+		return routeSelection{}, errSomeDriversNotFound
+	}
+	return routeSelection{activityLocation: activityLocation, participants: participants, drivers: drivers}, nil
+}
+
+func isRouteSelectionError(err error) bool {
+	return errors.Is(err, errActivityLocationNotFound) || errors.Is(err, errSomeParticipantsNotFound) || errors.Is(err, errSomeDriversNotFound)
+}
+
+// shareDistanceMatrix fetches one distance matrix over the request's points
+// for handlers that route them several times. Sharing only saves provider
+// calls, so on failure it logs and returns nil and each run fetches its own.
+func (h *Handler) shareDistanceMatrix(ctx context.Context, req *routing.RoutingRequest) distance.DistanceCalculator {
+	if h.DistanceCalc == nil {
+		return nil
+	}
+	if err := routing.ShareDistanceMatrix(ctx, h.DistanceCalc, req); err != nil {
+		log.Printf("[ERROR] Failed to prefetch shared distance matrix: points=%d err=%v", 1+len(req.Participants)+len(req.Drivers), err)
+		return nil
+	}
+	return req.Distances
+}
+
+// preparedRouteCalculation is a calculation's routing request together with
+// the stored data it was built from.
+type preparedRouteCalculation struct {
+	settings          *models.Settings
+	selection         routeSelection
+	drivers           []models.Driver
+	driverOrgVehicles map[int64]*models.OrganizationVehicle
+	request           routing.RoutingRequest
+}
+
+// isRouteCalculationValidationError reports whether a prepare error names
+// input the user can fix rather than a storage failure.
+func isRouteCalculationValidationError(err error) bool {
+	return isRouteSelectionError(err) || errors.Is(err, errSelectedVanNotFound)
+}
+
+// prepare loads the input's selection and settings and builds the routing
+// request. Errors that isRouteCalculationValidationError accepts are the
+// user's to fix.
+func (c *routeCalculation) prepare(ctx context.Context, input routeCalculationInput) (*preparedRouteCalculation, error) {
+	settings, err := c.db.Settings().Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	selection, err := c.loadSelection(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	var recentDriverRides map[int64]int
+	if input.RotateDrivers {
+		recentDriverRides, err = c.db.Events().RecentDriverRouteCounts(ctx, driverRotationEvents)
+		if err != nil {
+			return nil, err
+		}
 	}
 	orgVehicleMap, err := c.loadAssignedOrgVehicles(ctx, input.OrgVehicleAssignments)
 	if err != nil {
-		if errors.Is(err, errSelectedVanNotFound) {
+		return nil, err
+	}
+	modifiedDrivers, driverOrgVehicles := applyOrgVehicleAssignments(selection.drivers, input.OrgVehicleAssignments, orgVehicleMap)
+	modifiedDrivers = append(modifiedDrivers, input.ExtraDrivers...)
+
+	request := routing.RoutingRequest{
+		InstituteCoords:          selection.activityLocation.GetCoords(),
+		Participants:             selection.participants,
+		Drivers:                  modifiedDrivers,
+		Mode:                     input.Mode,
+		Metric:                   input.Metric,
+		PickupObjective:          input.PickupObjective,
+		MaxParticipantRideSecs:   input.MaxParticipantRideSecs,
+		MaxDetourSecs:            input.MaxDetourSecs,
+		DeclineOverDetourCeiling: input.DeclineOverDetour,
+		CompactDepartures:        input.CompactDepartures,
+		MaxNeighborhoods:         input.MaxNeighborhoods,
+		YoungestDroppedFirst:     input.YoungestDroppedFirst,
+		HouseholdSplit:           input.HouseholdSplit,
+		HouseholdPrecision:       settings.HouseholdGroupingPrecision,
+		DetourFairness:           input.DetourFairness,
+		ExcludeHomeLeg:           input.ExcludeHomeLeg,
+		FairnessWeights:          input.FairnessWeights,
+		BusStops:                 input.BusStops,
+		MaxWalkMeters:            input.MaxWalkMeters,
+		RotateDrivers:            input.RotateDrivers,
+		RecentDriverRides:        recentDriverRides,
+		PreferNearbyDrivers:      input.PreferNearbyDrivers,
+		IncludeGeometry:          input.IncludeGeometry,
+		Distances:                input.Distances,
+	}
+	return &preparedRouteCalculation{
+		settings:          settings,
+		selection:         selection,
+		drivers:           modifiedDrivers,
+		driverOrgVehicles: driverOrgVehicles,
+		request:           request,
+	}, nil
+}
+
+func (c *routeCalculation) calculate(ctx context.Context, input routeCalculationInput) routeCalculationOutcome {
+	prepared, err := c.prepare(ctx, input)
+	if err != nil {
+		if isRouteCalculationValidationError(err) {
 			return routeCalculationOutcome{Kind: routeCalculationValidationFailure, Err: err}
 		}
 		return routeCalculationOutcome{Kind: routeCalculationInternalFailure, Err: err}
 	}
-	modifiedDrivers, driverOrgVehicles := applyOrgVehicleAssignments(drivers, input.OrgVehicleAssignments, orgVehicleMap)
+	settings, request := prepared.settings, prepared.request
+	activityLocation, drivers := prepared.selection.activityLocation, prepared.selection.drivers
+	modifiedDrivers, driverOrgVehicles := prepared.drivers, prepared.driverOrgVehicles
 
-	result, err := c.router.CalculateRoutes(ctx, &routing.RoutingRequest{
-		InstituteCoords: activityLocation.GetCoords(),
-		Participants:    participants,
-		Drivers:         modifiedDrivers,
-		Mode:            input.Mode,
-	})
+	result, err := c.router.CalculateRoutes(ctx, &request)
+	if routingFailure, ok := err.(*routing.ErrRoutingFailed); ok && settings.OverflowPolicy != models.OverflowPolicyNone {
+		covered, coveredDrivers, coveredVehicles, coverErr := c.coverOverflow(ctx, settings.OverflowPolicy, request, routingFailure, driverOrgVehicles)
+		if _, stillShort := coverErr.(*routing.ErrRoutingFailed); coverErr != nil && !stillShort {
+			return routeCalculationOutcome{Kind: routeCalculationRouteFailure, Err: coverErr}
+		}
+		if coverErr == nil {
+			result, err = covered, nil
+			modifiedDrivers, driverOrgVehicles = coveredDrivers, coveredVehicles
+		}
+	}
 	if err != nil {
 		if routingFailure, ok := err.(*routing.ErrRoutingFailed); ok {
 			availableOrgVehicles, _ := c.db.OrganizationVehicles().List(ctx)
@@ -133,11 +298,20 @@ func (c *routeCalculation) calculate(ctx context.Context, input routeCalculation
 		return routeCalculationOutcome{Kind: routeCalculationRouteFailure, Err: err}
 	}
 
-	applyAssignedOrgVehicleMetadata(result.Routes, driverOrgVehicles)
-	result.Summary.OrgVehiclesUsed = countUsedOrgVehicles(result.Routes)
+	applyRouteVehicleSummary(result, driverOrgVehicles)
+	if input.Preview {
+		fillDetourSummary(result)
+		return routeCalculationOutcome{
+			Kind:             routeCalculationSuccess,
+			Result:           result,
+			ActivityLocation: activityLocation,
+			UseMiles:         settings.UseMiles,
+		}
+	}
 	session := c.sessions.Create(routesession.CreateInput{
 		Routes: result.Routes, SelectedDrivers: modifiedDrivers, ActivityLocation: activityLocation,
 		UseMiles: settings.UseMiles, RouteTime: input.RouteTime, Mode: input.Mode, DriverOrgVehicles: driverOrgVehicles,
+		Caps: routing.RequestCaps(&request), Options: routing.RequestOptions(&request), AvoidHighways: input.AvoidHighways,
 	})
 
 	return routeCalculationOutcome{
@@ -149,6 +323,27 @@ func (c *routeCalculation) calculate(ctx context.Context, input routeCalculation
 	}
 }
 
+// applyRouteVehicleSummary labels routes with their assigned vans and fills
+// the vehicle and volunteer counts and distance split, which routers leave
+// zero, from CalculateSummary.
+func applyRouteVehicleSummary(result *models.RoutingResult, driverOrgVehicles map[int64]*models.OrganizationVehicle) {
+	applyAssignedOrgVehicleMetadata(result.Routes, driverOrgVehicles)
+	summary := routesession.CalculateSummary(result.Routes)
+	result.Summary.OrgVehiclesUsed = summary.OrgVehiclesUsed
+	result.Summary.VolunteerDriversUsed = summary.VolunteerDriversUsed
+	result.Summary.VolunteerDistanceMeters = summary.VolunteerDistanceMeters
+	result.Summary.InstituteVehicleDistanceMeters = summary.InstituteVehicleDistanceMeters
+}
+
+// fillDetourSummary adds the detour metrics a route session would otherwise
+// fill in, for results that get no session.
+func fillDetourSummary(result *models.RoutingResult) {
+	summary := routesession.CalculateSummary(result.Routes)
+	result.Summary.MaxDetourSecs = summary.MaxDetourSecs
+	result.Summary.SumDetourSecs = summary.SumDetourSecs
+	result.Summary.AverageDetourSecs = summary.AverageDetourSecs
+}
+
 func (c *routeCalculation) loadAssignedOrgVehicles(ctx context.Context, assignments map[int64]int64) (map[int64]*models.OrganizationVehicle, error) {
 	if len(assignments) == 0 {
 		return map[int64]*models.OrganizationVehicle{}, nil
@@ -83,6 +83,68 @@ func TestRouteCalculation_AssignedVehicleSuccessCreatesRestorableSession(t *test
 	}
 }
 
+func TestRouteCalculation_VolunteerDriversUsedExcludesOrgVehicles(t *testing.T) {
+	handler, store := newTestRouteHandler(t)
+	ctx := context.Background()
+
+	riderA, err := store.Participants().Create(ctx, &models.Participant{Name: "Rider A", Address: "1 Rider Rd", Lat: 40.1, Lng: -73.9})
+	if err != nil {
+		t.Fatalf("create participant: %v", err)
+	}
+	riderB, err := store.Participants().Create(ctx, &models.Participant{Name: "Rider B", Address: "4 Rider Rd", Lat: 40.3, Lng: -73.7})
+	if err != nil {
+		t.Fatalf("create participant: %v", err)
+	}
+	volunteer, err := store.Drivers().Create(ctx, &models.Driver{Name: "Volunteer", Address: "2 Driver Rd", Lat: 40.2, Lng: -73.8, VehicleCapacity: 2})
+	if err != nil {
+		t.Fatalf("create driver: %v", err)
+	}
+	vanDriver, err := store.Drivers().Create(ctx, &models.Driver{Name: "Van Driver", Address: "5 Driver Rd", Lat: 40.4, Lng: -73.6, VehicleCapacity: 2})
+	if err != nil {
+		t.Fatalf("create driver: %v", err)
+	}
+	location, err := store.ActivityLocations().Create(ctx, &models.ActivityLocation{Name: "Gym", Address: "3 Event Ave", Lat: 42, Lng: -75})
+	if err != nil {
+		t.Fatalf("create activity location: %v", err)
+	}
+	van, err := store.OrganizationVehicles().Create(ctx, &models.OrganizationVehicle{Name: "Blue Van", Capacity: 8})
+	if err != nil {
+		t.Fatalf("create organization vehicle: %v", err)
+	}
+
+	router := &captureRouter{result: &models.RoutingResult{
+		Routes: []models.CalculatedRoute{
+			{Driver: volunteer, Stops: []models.RouteStop{{Participant: riderA}}},
+			{Driver: vanDriver, Stops: []models.RouteStop{{Participant: riderB}}},
+		},
+		Summary: models.RoutingSummary{TotalDriversUsed: 2},
+	}}
+	calculation := newRouteCalculation(store, router, handler.RouteSession)
+
+	outcome := calculation.calculate(ctx, routeCalculationInput{
+		ParticipantIDs:        []int64{riderA.ID, riderB.ID},
+		DriverIDs:             []int64{volunteer.ID, vanDriver.ID},
+		ActivityLocationID:    location.ID,
+		RouteTime:             "18:30",
+		Mode:                  models.RouteModeDropoff,
+		OrgVehicleAssignments: map[int64]int64{vanDriver.ID: van.ID},
+	})
+
+	if outcome.Kind != routeCalculationSuccess {
+		t.Fatalf("outcome kind = %v, want success; err=%v", outcome.Kind, outcome.Err)
+	}
+	if got := outcome.Result.Summary; got.TotalDriversUsed != 2 || got.VolunteerDriversUsed != 1 || got.OrgVehiclesUsed != 1 {
+		t.Fatalf("summary = %#v, want 2 drivers used, 1 volunteer, 1 org vehicle", got)
+	}
+	session, ok := handler.RouteSession.Snapshot(outcome.Session.ID)
+	if !ok {
+		t.Fatal("expected route session to be restorable")
+	}
+	if got := session.Summary; got.TotalDriversUsed != 2 || got.VolunteerDriversUsed != 1 {
+		t.Fatalf("session summary = %#v, want 2 drivers used, 1 volunteer", got)
+	}
+}
+
 func TestRouteCalculation_CapacityShortageReturnsAssignmentsAndAvailableVehicles(t *testing.T) {
 	handler, store := newTestRouteHandler(t)
 	ctx := context.Background()
@@ -148,3 +210,81 @@ func TestRouteCalculation_CapacityShortageReturnsAssignmentsAndAvailableVehicles
 		t.Fatalf("shortage context = %#v, want selected location, settings, and route time", shortage)
 	}
 }
+
+func TestRouteCalculation_OverflowPolicyChoosesVanOrFlexSeat(t *testing.T) {
+	for _, tc := range []struct {
+		policy    models.OverflowPolicy
+		wantVan   bool
+		wantSeats int
+	}{
+		{policy: models.OverflowPolicyVanFirst, wantVan: true, wantSeats: 6},
+		{policy: models.OverflowPolicyFlexFirst, wantVan: false, wantSeats: 3},
+	} {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			handler, store := newTestRouteHandler(t)
+			ctx := context.Background()
+
+			var participantIDs []int64
+			for i := range 3 {
+				participant, err := store.Participants().Create(ctx, &models.Participant{Name: "Rider", Address: "1 Rider Rd", Lat: 40.1 + float64(i)/100, Lng: -73.9})
+				if err != nil {
+					t.Fatalf("create participant: %v", err)
+				}
+				participantIDs = append(participantIDs, participant.ID)
+			}
+			driver, err := store.Drivers().Create(ctx, &models.Driver{Name: "Volunteer", Address: "2 Driver Rd", Lat: 40.2, Lng: -73.8, VehicleCapacity: 2, FlexCapacity: 1})
+			if err != nil {
+				t.Fatalf("create driver: %v", err)
+			}
+			location, err := store.ActivityLocations().Create(ctx, &models.ActivityLocation{Name: "Gym", Address: "3 Event Ave", Lat: 40, Lng: -74})
+			if err != nil {
+				t.Fatalf("create activity location: %v", err)
+			}
+			van, err := store.OrganizationVehicles().Create(ctx, &models.OrganizationVehicle{Name: "Blue Van", Capacity: 6})
+			if err != nil {
+				t.Fatalf("create organization vehicle: %v", err)
+			}
+			if err := store.Settings().Update(ctx, &models.Settings{OverflowPolicy: tc.policy}); err != nil {
+				t.Fatalf("update settings: %v", err)
+			}
+
+			calculation := newRouteCalculation(store, routing.NewBalancedRouter(routeEditDistanceCalculator{}), handler.RouteSession)
+			outcome := calculation.calculate(ctx, routeCalculationInput{
+				ParticipantIDs:     participantIDs,
+				DriverIDs:          []int64{driver.ID},
+				ActivityLocationID: location.ID,
+				Mode:               models.RouteModeDropoff,
+			})
+			if outcome.Kind != routeCalculationSuccess {
+				t.Fatalf("outcome kind = %v, want success; err=%v", outcome.Kind, outcome.Err)
+			}
+			route := outcome.Result.Routes[0]
+			if gotVan := route.OrgVehicleID == van.ID; gotVan != tc.wantVan {
+				t.Fatalf("route organization vehicle = %d, want van used %t", route.OrgVehicleID, tc.wantVan)
+			}
+			if route.EffectiveCapacity != tc.wantSeats || len(route.Stops) != 3 {
+				t.Fatalf("route capacity = %d with %d stops, want %d seats carrying 3", route.EffectiveCapacity, len(route.Stops), tc.wantSeats)
+			}
+		})
+	}
+
+	t.Run("no policy", func(t *testing.T) {
+		handler, store := newTestRouteHandler(t)
+		ctx := context.Background()
+		first, _ := store.Participants().Create(ctx, &models.Participant{Name: "First", Address: "1 Rider Rd", Lat: 40.1, Lng: -73.9})
+		second, _ := store.Participants().Create(ctx, &models.Participant{Name: "Second", Address: "2 Rider Rd", Lat: 40.11, Lng: -73.9})
+		driver, _ := store.Drivers().Create(ctx, &models.Driver{Name: "Volunteer", Address: "2 Driver Rd", Lat: 40.2, Lng: -73.8, VehicleCapacity: 1, FlexCapacity: 1})
+		location, _ := store.ActivityLocations().Create(ctx, &models.ActivityLocation{Name: "Gym", Address: "3 Event Ave", Lat: 40, Lng: -74})
+
+		calculation := newRouteCalculation(store, routing.NewBalancedRouter(routeEditDistanceCalculator{}), handler.RouteSession)
+		outcome := calculation.calculate(ctx, routeCalculationInput{
+			ParticipantIDs:     []int64{first.ID, second.ID},
+			DriverIDs:          []int64{driver.ID},
+			ActivityLocationID: location.ID,
+			Mode:               models.RouteModeDropoff,
+		})
+		if outcome.Kind != routeCalculationShortage {
+			t.Fatalf("outcome kind = %v, want shortage without an overflow policy", outcome.Kind)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"ride-home-router/internal/models"
+	"ride-home-router/internal/routing"
+)
+
+const (
+	defaultRouteCandidates = 3
+	maxRouteCandidates     = 10
+)
+
+// RouteCandidatesRequest is a route selection plus how many alternative plans
+// to return. Count defaults to 3; Ranking orders the plans by total
+// "distance" (the default) or by "fairness", the largest driver detour.
+type RouteCandidatesRequest struct {
+	ParticipantIDs        []int64         `json:"participant_ids"`
+	DriverIDs             []int64         `json:"driver_ids"`
+	ActivityLocationID    int64           `json:"activity_location_id"`
+	Mode                  string          `json:"mode"`
+	Metric                string          `json:"metric,omitempty"`
+	OrgVehicleAssignments map[int64]int64 `json:"org_vehicle_assignments,omitempty"`
+	Count                 int             `json:"count,omitempty"`
+	Ranking               string          `json:"ranking,omitempty"`
+}
+
+// RouteCandidate is one alternative plan; Rank 1 is the best. The remaining
+// fields carry the same caveats as RouteCalculationResponse.
+type RouteCandidate struct {
+	Rank                 int                            `json:"rank"`
+	Routes               []models.CalculatedRoute       `json:"routes"`
+	Summary              models.RoutingSummary          `json:"summary"`
+	Unassigned           []models.UnassignedParticipant `json:"unassigned,omitempty"`
+	DriversAtActivity    []int64                        `json:"drivers_at_activity,omitempty"`
+	ForcedOrderConflicts []models.ForcedOrderConflict   `json:"forced_order_conflicts,omitempty"`
+	Warnings             []string                       `json:"warnings,omitempty"`
+	BusStops             []models.BusStopAssignment     `json:"bus_stops,omitempty"`
+	DoorToDoor           []int64                        `json:"door_to_door,omitempty"`
+}
+
+// RouteCandidatesResponse lists the distinct plans found, best first. There
+// may be fewer than requested when the selection allows few assignments.
+type RouteCandidatesResponse struct {
+	Ranking    routing.CandidateRanking `json:"ranking"`
+	Candidates []RouteCandidate         `json:"candidates"`
+}
+
+// HandleRouteCandidates handles POST /api/v1/routes/candidates
+//
+// It returns several ranked alternative plans for the selection so the
+// organizer can choose between them. Nothing is persisted and no route
+// session is created.
+func (h *Handler) HandleRouteCandidates(w http.ResponseWriter, r *http.Request) {
+	var req RouteCandidatesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("[HTTP] POST /api/v1/routes/candidates: invalid_json err=%v", err)
+		h.handleValidationError(w, messageInvalidRequestBody)
+		return
+	}
+
+	if len(req.ParticipantIDs) == 0 {
+		h.handleValidationError(w, messageSelectAtLeastOneParticipant)
+		return
+	}
+	if len(req.DriverIDs) == 0 {
+		h.handleValidationError(w, messageSelectAtLeastOneDriver)
+		return
+	}
+	if req.ActivityLocationID == 0 {
+		h.handleValidationError(w, messageChooseActivityLocationForEvent)
+		return
+	}
+	if req.Count == 0 {
+		req.Count = defaultRouteCandidates
+	}
+	if req.Count < 1 || req.Count > maxRouteCandidates {
+		h.handleValidationError(w, messageInvalidCandidateCount)
+		return
+	}
+	ranking, err := routing.ParseCandidateRanking(req.Ranking)
+	if err != nil {
+		h.handleValidationError(w, messageInvalidCandidateRanking)
+		return
+	}
+	mode, err := normalizeRouteMode(req.Mode)
+	if err != nil {
+		h.handleValidationError(w, err.Error())
+		return
+	}
+	metric, err := routing.ParseOptimizationMetric(req.Metric)
+	if err != nil {
+		h.handleValidationError(w, messageInvalidOptimizationMetric)
+		return
+	}
+
+	log.Printf("[HTTP] POST /api/v1/routes/candidates: participants=%d drivers=%d mode=%s count=%d ranking=%s", len(req.ParticipantIDs), len(req.DriverIDs), mode, req.Count, ranking)
+	prepared, err := newRouteCalculation(h.DB, h.Router, h.RouteSession).prepare(r.Context(), routeCalculationInput{
+		ParticipantIDs:        req.ParticipantIDs,
+		DriverIDs:             req.DriverIDs,
+		ActivityLocationID:    req.ActivityLocationID,
+		Mode:                  mode,
+		Metric:                metric,
+		OrgVehicleAssignments: req.OrgVehicleAssignments,
+	})
+	if err != nil {
+		if isRouteCalculationValidationError(err) {
+			h.handleValidationError(w, routeCalculationValidationMessage(err))
+			return
+		}
+		h.handleInternalError(w, err)
+		return
+	}
+
+	results, err := routing.CalculateCandidates(r.Context(), h.Router, &prepared.request, req.Count, ranking)
+	if err != nil {
+		if _, ok := err.(*routing.ErrRoutingFailed); ok {
+			h.handleRoutingError(w, err)
+			return
+		}
+		log.Printf("[ERROR] Route candidate calculation failed: err=%v", err)
+		h.handleRouteCalculationError(w, r, err)
+		return
+	}
+
+	response := RouteCandidatesResponse{Ranking: ranking, Candidates: make([]RouteCandidate, 0, len(results))}
+	for i, result := range results {
+		applyRouteVehicleSummary(result, prepared.driverOrgVehicles)
+		fillDetourSummary(result)
+		response.Candidates = append(response.Candidates, RouteCandidate{
+			Rank: i + 1, Routes: result.Routes, Summary: result.Summary, Unassigned: result.Unassigned,
+			DriversAtActivity: result.DriversAtActivity, ForcedOrderConflicts: result.ForcedOrderConflicts,
+			Warnings: result.Warnings, BusStops: result.BusStops, DoorToDoor: result.DoorToDoor,
+		})
+	}
+	log.Printf("[HTTP] Route candidates calculated: requested=%d returned=%d", req.Count, len(response.Candidates))
+	h.writeJSON(w, http.StatusOK, response)
+}
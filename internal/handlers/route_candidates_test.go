@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"ride-home-router/internal/models"
+	"ride-home-router/internal/routing"
+	"strings"
+	"testing"
+)
+
+func TestHandleRouteCandidatesReturnsRankedPlans(t *testing.T) {
+	handler, store := newTestManagementHandler(t)
+	handler.Router = routing.NewLimitedRouter(routing.NewBalancedRouter(routeEditDistanceCalculator{}), 1)
+	ctx := context.Background()
+
+	location, err := store.ActivityLocations().Create(ctx, &models.ActivityLocation{Name: "Gym", Address: "Gym", Lat: 0, Lng: 0})
+	if err != nil {
+		t.Fatalf("create activity location: %v", err)
+	}
+	var driverIDs []int64
+	for _, coords := range []models.Coordinates{{Lat: 0, Lng: 1}, {Lat: 1, Lng: 0}} {
+		driver, err := store.Drivers().Create(ctx, &models.Driver{Name: "Driver", Address: "Home", Lat: coords.Lat, Lng: coords.Lng, VehicleCapacity: 2})
+		if err != nil {
+			t.Fatalf("create driver: %v", err)
+		}
+		driverIDs = append(driverIDs, driver.ID)
+	}
+	var participantIDs []int64
+	for _, coords := range []models.Coordinates{{Lat: 0, Lng: 2}, {Lat: 2, Lng: 0}, {Lat: 1, Lng: 1}} {
+		participant, err := store.Participants().Create(ctx, &models.Participant{Name: "Rider", Address: "Home", Lat: coords.Lat, Lng: coords.Lng})
+		if err != nil {
+			t.Fatalf("create participant: %v", err)
+		}
+		participantIDs = append(participantIDs, participant.ID)
+	}
+
+	body, _ := json.Marshal(RouteCandidatesRequest{
+		ParticipantIDs:     participantIDs,
+		DriverIDs:          driverIDs,
+		ActivityLocationID: location.ID,
+		Mode:               string(models.RouteModeDropoff),
+		Count:              3,
+	})
+	w := httptest.NewRecorder()
+	handler.HandleRouteCandidates(w, httptest.NewRequest(http.MethodPost, "/api/v1/routes/candidates", bytes.NewReader(body)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body=%s", w.Code, w.Body.String())
+	}
+	var response RouteCandidatesResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if response.Ranking != routing.RankByDistance {
+		t.Fatalf("ranking = %q, want the distance default", response.Ranking)
+	}
+	if len(response.Candidates) < 2 || len(response.Candidates) > 3 {
+		t.Fatalf("candidates = %d, want between 2 and 3", len(response.Candidates))
+	}
+	for i, candidate := range response.Candidates {
+		if candidate.Rank != i+1 {
+			t.Fatalf("candidate %d rank = %d", i, candidate.Rank)
+		}
+		riders := 0
+		for _, route := range candidate.Routes {
+			riders += len(route.Stops)
+		}
+		if riders != len(participantIDs) {
+			t.Fatalf("candidate %d routes %d riders, want %d", i, riders, len(participantIDs))
+		}
+		if i > 0 && candidate.Summary.TotalDistanceMeters < response.Candidates[i-1].Summary.TotalDistanceMeters {
+			t.Fatalf("candidate %d is shorter than the one ranked above it", i)
+		}
+	}
+}
+
+func TestHandleRouteCandidatesRejectsUnknownRanking(t *testing.T) {
+	handler, _ := newTestManagementHandler(t)
+	router := &captureRouter{}
+	handler.Router = router
+
+	body := `{"participant_ids":[1],"driver_ids":[1],"activity_location_id":1,"ranking":"shortest"}`
+	w := httptest.NewRecorder()
+	handler.HandleRouteCandidates(w, httptest.NewRequest(http.MethodPost, "/api/v1/routes/candidates", strings.NewReader(body)))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d body=%s", w.Code, http.StatusBadRequest, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), messageInvalidCandidateRanking) {
+		t.Fatalf("body = %s, want ranking validation message", w.Body.String())
+	}
+	if router.lastRequest != nil {
+		t.Fatal("expected no routing for an invalid ranking")
+	}
+}
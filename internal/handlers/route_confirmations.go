@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"ride-home-router/internal/models"
+	"time"
+)
+
+// ParticipantConfirmation tells one family which driver is taking their
+// participant and roughly when. StopOrder is 1-based. ETA is only set when the
+// request gives a departure time.
+type ParticipantConfirmation struct {
+	ParticipantID   int64  `json:"participant_id"`
+	ParticipantName string `json:"participant_name"`
+	DriverID        int64  `json:"driver_id"`
+	DriverName      string `json:"driver_name"`
+	Vehicle         string `json:"vehicle"`
+	StopOrder       int    `json:"stop_order"`
+	StopCount       int    `json:"stop_count"`
+	ETA             string `json:"eta,omitempty"`
+	// Message is the confirmation as one sentence, ready to merge into an
+	// SMS or email.
+	Message string `json:"message"`
+}
+
+type participantConfirmationsResponse struct {
+	SessionID     string                    `json:"session_id"`
+	Mode          models.RouteMode          `json:"mode"`
+	DepartureTime string                    `json:"departure_time,omitempty"`
+	Confirmations []ParticipantConfirmation `json:"confirmations"`
+}
+
+// HandleRouteSessionConfirmations handles GET /api/v1/routes/session/confirmations
+//
+// It lists, for every participant in the session, their driver, vehicle and
+// stop, in route order. Names are not masked because the confirmations go to
+// the families themselves. An optional departure_time (HH:MM) adds each
+// stop's projected arrival.
+func (h *Handler) HandleRouteSessionConfirmations(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	id := query.Get("session_id")
+	var departure *time.Time
+	departureTime := query.Get("departure_time")
+	if departureTime != "" {
+		parsed, err := time.Parse("15:04", departureTime)
+		if err != nil {
+			h.handleValidationError(w, messageChooseValidDepartureTime)
+			return
+		}
+		departure = &parsed
+	}
+	snapshot, ok := h.RouteSession.Snapshot(id)
+	if !ok {
+		h.handleNotFound(w, messageSessionNotFound)
+		return
+	}
+
+	confirmations := buildParticipantConfirmations(snapshot.Routes, snapshot.Mode, departure)
+	log.Printf("[HTTP] GET /api/v1/routes/session/confirmations: session=%s participants=%d", id, len(confirmations))
+	h.writeJSON(w, http.StatusOK, participantConfirmationsResponse{
+		SessionID:     snapshot.ID,
+		Mode:          snapshot.Mode,
+		DepartureTime: departureTime,
+		Confirmations: confirmations,
+	})
+}
+
+func buildParticipantConfirmations(routes []models.CalculatedRoute, mode models.RouteMode, departure *time.Time) []ParticipantConfirmation {
+	confirmations := []ParticipantConfirmation{}
+	for _, route := range routes {
+		if route.Driver == nil {
+			continue
+		}
+		vehicle := "Personal vehicle"
+		if route.OrgVehicleName != "" {
+			vehicle = route.OrgVehicleName
+		}
+		for i, stop := range route.Stops {
+			if stop.Participant == nil {
+				continue
+			}
+			confirmation := ParticipantConfirmation{
+				ParticipantID:   stop.Participant.ID,
+				ParticipantName: stop.Participant.Name,
+				DriverID:        route.Driver.ID,
+				DriverName:      route.Driver.Name,
+				Vehicle:         vehicle,
+				StopOrder:       i + 1,
+				StopCount:       len(route.Stops),
+			}
+			if departure != nil {
+				confirmation.ETA = departure.Add(time.Duration(stop.CumulativeDurationSecs * float64(time.Second))).Format("15:04")
+			}
+			confirmation.Message = confirmationMessage(confirmation, mode)
+			confirmations = append(confirmations, confirmation)
+		}
+	}
+	return confirmations
+}
+
+func confirmationMessage(c ParticipantConfirmation, mode models.RouteMode) string {
+	action := "dropped off"
+	if mode == models.RouteModePickup {
+		action = "picked up"
+	}
+	message := fmt.Sprintf("%s will be %s by %s (%s), stop %d of %d", c.ParticipantName, action, c.DriverName, c.Vehicle, c.StopOrder, c.StopCount)
+	if c.ETA != "" {
+		message += ", around " + c.ETA
+	}
+	return message + "."
+}
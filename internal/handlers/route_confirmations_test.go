@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"ride-home-router/internal/models"
+	"ride-home-router/internal/routesession"
+	"testing"
+)
+
+func TestHandleRouteSessionConfirmationsMapsParticipantsToDriverAndOrder(t *testing.T) {
+	store := routesession.NewStore(routeEditDistanceCalculator{})
+	t.Cleanup(store.Close)
+	created := store.Create(routesession.CreateInput{
+		Routes: []models.CalculatedRoute{
+			{Driver: &models.Driver{ID: 1, Name: "Dana"}, EffectiveCapacity: 2, Stops: []models.RouteStop{
+				{Participant: &models.Participant{ID: 10, Name: "Ava"}, CumulativeDurationSecs: 300},
+				{Participant: &models.Participant{ID: 11, Name: "Ben"}, CumulativeDurationSecs: 900},
+			}},
+			{Driver: &models.Driver{ID: 2, Name: "Eli"}, OrgVehicleID: 5, OrgVehicleName: "Blue Van", EffectiveCapacity: 8, Stops: []models.RouteStop{
+				{Participant: &models.Participant{ID: 12, Name: "Cam"}, CumulativeDurationSecs: 600},
+			}},
+		},
+		ActivityLocation: &models.ActivityLocation{}, RouteTime: "18:30", Mode: models.RouteModeDropoff,
+	})
+	h := &Handler{RouteSession: store}
+
+	w := httptest.NewRecorder()
+	h.HandleRouteSessionConfirmations(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/routes/session/confirmations?session_id="+created.ID+"&departure_time=18:30", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var response participantConfirmationsResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ParticipantConfirmation{
+		{ParticipantID: 10, ParticipantName: "Ava", DriverID: 1, DriverName: "Dana", Vehicle: "Personal vehicle", StopOrder: 1, StopCount: 2, ETA: "18:35",
+			Message: "Ava will be dropped off by Dana (Personal vehicle), stop 1 of 2, around 18:35."},
+		{ParticipantID: 11, ParticipantName: "Ben", DriverID: 1, DriverName: "Dana", Vehicle: "Personal vehicle", StopOrder: 2, StopCount: 2, ETA: "18:45",
+			Message: "Ben will be dropped off by Dana (Personal vehicle), stop 2 of 2, around 18:45."},
+		{ParticipantID: 12, ParticipantName: "Cam", DriverID: 2, DriverName: "Eli", Vehicle: "Blue Van", StopOrder: 1, StopCount: 1, ETA: "18:40",
+			Message: "Cam will be dropped off by Eli (Blue Van), stop 1 of 1, around 18:40."},
+	}
+	if len(response.Confirmations) != len(want) {
+		t.Fatalf("confirmations = %+v, want %d", response.Confirmations, len(want))
+	}
+	for i := range want {
+		if response.Confirmations[i] != want[i] {
+			t.Fatalf("confirmation %d = %+v, want %+v", i, response.Confirmations[i], want[i])
+		}
+	}
+
+	w = httptest.NewRecorder()
+	h.HandleRouteSessionConfirmations(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/routes/session/confirmations?session_id="+created.ID, nil))
+	response = participantConfirmationsResponse{}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if got := response.Confirmations[0]; got.ETA != "" || got.Message != "Ava will be dropped off by Dana (Personal vehicle), stop 1 of 2." {
+		t.Fatalf("confirmation without departure = %+v, want no ETA", got)
+	}
+}
@@ -7,10 +7,28 @@ import (
 	"net/http"
 	"ride-home-router/internal/models"
 	"ride-home-router/internal/routesession"
+	"ride-home-router/internal/routing"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const maxParticipantMovesPerBatch = 64
 
+type stopETAResponse struct {
+	Order       int                 `json:"order"`
+	Participant *models.Participant `json:"participant"`
+	ArrivalTime string              `json:"arrival_time"`
+}
+
+type routeETAsResponse struct {
+	SessionID     string            `json:"session_id"`
+	RouteIndex    int               `json:"route_index"`
+	DepartureTime string            `json:"departure_time"`
+	Stops         []stopETAResponse `json:"stops"`
+	FinishTime    string            `json:"finish_time"`
+}
+
 type participantMove struct {
 	ParticipantID    int64 `json:"participant_id"`
 	FromRouteIndex   int   `json:"from_route_index"`
@@ -22,6 +40,23 @@ func buildRoutingPayload(routes []models.CalculatedRoute, summary models.Routing
 	return models.RoutingResult{Routes: routes, Summary: summary, Mode: mode}
 }
 
+func buildDriverManifest(routes []models.CalculatedRoute) []DriverManifestEntry {
+	manifest := make([]DriverManifestEntry, 0, len(routes))
+	for _, route := range routes {
+		if route.Driver == nil || len(route.Stops) == 0 {
+			continue
+		}
+		entry := DriverManifestEntry{DriverID: route.Driver.ID, DriverName: route.Driver.Name, Passengers: make([]DriverManifestRider, 0, len(route.Stops))}
+		for _, stop := range route.Stops {
+			if stop.Participant != nil {
+				entry.Passengers = append(entry.Passengers, DriverManifestRider{ParticipantID: stop.Participant.ID, Name: stop.Participant.Name})
+			}
+		}
+		manifest = append(manifest, entry)
+	}
+	return manifest
+}
+
 func buildRouteResultsView(snapshot routesession.Snapshot) RouteResultsView {
 	return RouteResultsView{
 		Routes: snapshot.Routes, OverCapacity: snapshot.OverCapacity, IsOutOfBalance: snapshot.IsOutOfBalance,
@@ -34,12 +69,14 @@ func buildRouteResultsView(snapshot routesession.Snapshot) RouteResultsView {
 
 func (h *Handler) HandleMoveParticipant(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		SessionID        string            `json:"session_id"`
-		ParticipantID    int64             `json:"participant_id"`
-		FromRouteIndex   int               `json:"from_route_index"`
-		ToRouteIndex     int               `json:"to_route_index"`
-		InsertAtPosition int               `json:"insert_at_position"`
-		Moves            []participantMove `json:"moves"`
+		SessionID           string            `json:"session_id"`
+		ParticipantID       int64             `json:"participant_id"`
+		FromRouteIndex      int               `json:"from_route_index"`
+		ToRouteIndex        int               `json:"to_route_index"`
+		InsertAtPosition    int               `json:"insert_at_position"`
+		Moves               []participantMove `json:"moves"`
+		PreserveManualOrder bool              `json:"preserve_manual_order"`
+		Version             int64             `json:"version"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.handleValidationErrorHTMX(w, r, messageInvalidRequestBody)
@@ -65,7 +102,11 @@ func (h *Handler) HandleMoveParticipant(w http.ResponseWriter, r *http.Request)
 		}
 		storeMoves[i] = routesession.Move{ParticipantID: move.ParticipantID, FromRouteIndex: move.FromRouteIndex, ToRouteIndex: move.ToRouteIndex, InsertAtPosition: move.InsertAtPosition}
 	}
-	snapshot, err := h.RouteSession.ApplyMoves(r.Context(), req.SessionID, storeMoves, routesession.ApplyMovesOptions{RequireClaimedSource: legacy})
+	snapshot, err := h.RouteSession.ApplyMoves(r.Context(), req.SessionID, storeMoves, routesession.ApplyMovesOptions{
+		RequireClaimedSource: legacy,
+		PreserveManualOrder:  req.PreserveManualOrder,
+		Version:              req.Version,
+	})
 	if err != nil {
 		h.handleRouteSessionError(w, r, err)
 		return
@@ -83,12 +124,13 @@ func (h *Handler) HandleSwapDrivers(w http.ResponseWriter, r *http.Request) {
 		SessionID   string `json:"session_id"`
 		RouteIndex1 int    `json:"route_index_1"`
 		RouteIndex2 int    `json:"route_index_2"`
+		Version     int64  `json:"version"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.handleValidationErrorHTMX(w, r, messageInvalidRequestBody)
 		return
 	}
-	snapshot, err := h.RouteSession.SwapDrivers(r.Context(), req.SessionID, req.RouteIndex1, req.RouteIndex2)
+	snapshot, err := h.RouteSession.SwapDrivers(r.Context(), req.SessionID, req.Version, req.RouteIndex1, req.RouteIndex2)
 	if err != nil {
 		h.handleRouteSessionError(w, r, err)
 		return
@@ -97,12 +139,63 @@ func (h *Handler) HandleSwapDrivers(w http.ResponseWriter, r *http.Request) {
 	h.writeRouteSession(w, r, snapshot)
 }
 
+// HandleSetRouteCapacity handles POST /api/v1/routes/edit/set-capacity
+func (h *Handler) HandleSetRouteCapacity(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SessionID  string `json:"session_id"`
+		RouteIndex int    `json:"route_index"`
+		Capacity   int    `json:"capacity"`
+		Version    int64  `json:"version"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleValidationErrorHTMX(w, r, messageInvalidRequestBody)
+		return
+	}
+	snapshot, err := h.RouteSession.SetCapacity(req.SessionID, req.Version, req.RouteIndex, req.Capacity)
+	if err != nil {
+		h.handleRouteSessionError(w, r, err)
+		return
+	}
+	log.Printf("[EDIT] Set route %d capacity to %d", req.RouteIndex, req.Capacity)
+	h.writeRouteSession(w, r, snapshot)
+}
+
+// HandleLockStop handles POST /api/v1/routes/edit/lock-stop
+func (h *Handler) HandleLockStop(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SessionID     string `json:"session_id"`
+		ParticipantID int64  `json:"participant_id"`
+		Locked        bool   `json:"locked"`
+		Version       int64  `json:"version"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleValidationErrorHTMX(w, r, messageInvalidRequestBody)
+		return
+	}
+	if req.ParticipantID == 0 {
+		h.handleValidationErrorHTMX(w, r, messageInvalidParticipantID)
+		return
+	}
+	snapshot, err := h.RouteSession.LockStop(req.SessionID, req.Version, req.ParticipantID, req.Locked)
+	if err != nil {
+		h.handleRouteSessionError(w, r, err)
+		return
+	}
+	log.Printf("[EDIT] Set participant %d stop locked=%t", req.ParticipantID, req.Locked)
+	h.writeRouteSession(w, r, snapshot)
+}
+
 func (h *Handler) HandleResetRoutes(w http.ResponseWriter, r *http.Request) {
 	id := r.URL.Query().Get("session_id")
 	if id == "" {
 		id = r.FormValue("session_id")
 	}
-	snapshot, err := h.RouteSession.Reset(id)
+	version, err := sessionVersionParam(r)
+	if err != nil {
+		h.handleValidationErrorHTMX(w, r, err.Error())
+		return
+	}
+	snapshot, err := h.RouteSession.Reset(id, version)
 	if err != nil {
 		h.handleRouteSessionError(w, r, err)
 		return
@@ -117,16 +210,81 @@ func (h *Handler) HandleResetRoutes(w http.ResponseWriter, r *http.Request) {
 	h.writeRouteSession(w, r, snapshot)
 }
 
+// HandleUndoRouteEdit handles POST /api/v1/routes/edit/undo
+func (h *Handler) HandleUndoRouteEdit(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("session_id")
+	if id == "" {
+		id = r.FormValue("session_id")
+	}
+	version, err := sessionVersionParam(r)
+	if err != nil {
+		h.handleValidationErrorHTMX(w, r, err.Error())
+		return
+	}
+	snapshot, err := h.RouteSession.Undo(id, version)
+	if err != nil {
+		h.handleRouteSessionError(w, r, err)
+		return
+	}
+	log.Printf("[EDIT] Undid last edit for session %s", id)
+	if h.isHTMX(r) {
+		view := buildRouteResultsView(snapshot)
+		view.IsEditing = true
+		h.renderTemplate(w, "route_results", view)
+		return
+	}
+	h.writeRouteSession(w, r, snapshot)
+}
+
+// HandleReoptimizeRouteEdit handles POST /api/v1/routes/edit/reoptimize
+//
+// It runs the router again over the session's current drivers and riders,
+// keeping locked stops where they are. The result can be undone.
+func (h *Handler) HandleReoptimizeRouteEdit(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("session_id")
+	if id == "" {
+		id = r.FormValue("session_id")
+	}
+	version, err := sessionVersionParam(r)
+	if err != nil {
+		h.handleValidationErrorHTMX(w, r, err.Error())
+		return
+	}
+	snapshot, err := h.RouteSession.Reoptimize(r.Context(), id, version, h.Router)
+	var routingErr *routing.ErrRoutingFailed
+	if errors.As(err, &routingErr) {
+		if h.isHTMX(r) {
+			h.handleHTMXErrorNoSwap(w, r, http.StatusUnprocessableEntity, "ROUTING_FAILED", routingErr.Reason)
+			return
+		}
+		h.handleRoutingError(w, routingErr)
+		return
+	}
+	if err != nil {
+		h.handleRouteSessionError(w, r, err)
+		return
+	}
+	log.Printf("[EDIT] Re-optimized session %s", id)
+	if h.isHTMX(r) {
+		view := buildRouteResultsView(snapshot)
+		view.IsEditing = true
+		h.renderTemplate(w, "route_results", view)
+		return
+	}
+	h.writeRouteSession(w, r, snapshot)
+}
+
 func (h *Handler) HandleAddDriver(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		SessionID string `json:"session_id"`
 		DriverID  int64  `json:"driver_id"`
+		Version   int64  `json:"version"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.handleValidationErrorHTMX(w, r, messageInvalidRequestBody)
 		return
 	}
-	snapshot, err := h.RouteSession.AddDriver(r.Context(), req.SessionID, req.DriverID)
+	snapshot, err := h.RouteSession.AddDriver(r.Context(), req.SessionID, req.Version, req.DriverID)
 	if err != nil {
 		h.handleRouteSessionError(w, r, err)
 		return
@@ -149,12 +307,368 @@ func (h *Handler) HandleGetRouteSession(w http.ResponseWriter, r *http.Request)
 	h.writeRouteSession(w, r, snapshot)
 }
 
+// HandleMarkNoShow handles POST /api/v1/routes/edit/mark-noshow
+func (h *Handler) HandleMarkNoShow(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SessionID     string `json:"session_id"`
+		ParticipantID int64  `json:"participant_id"`
+		Reoptimize    bool   `json:"reoptimize"`
+		Version       int64  `json:"version"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleValidationErrorHTMX(w, r, messageInvalidRequestBody)
+		return
+	}
+	if req.ParticipantID == 0 {
+		h.handleValidationErrorHTMX(w, r, messageInvalidParticipantID)
+		return
+	}
+	snapshot, err := h.RouteSession.MarkNoShow(r.Context(), req.SessionID, req.Version, req.ParticipantID, req.Reoptimize)
+	if err != nil {
+		h.handleRouteSessionError(w, r, err)
+		return
+	}
+	log.Printf("[EDIT] Marked participant %d as no-show (reoptimize=%t)", req.ParticipantID, req.Reoptimize)
+	h.writeRouteSession(w, r, snapshot)
+}
+
+// HandleRestoreNoShow handles POST /api/v1/routes/edit/restore-noshow
+func (h *Handler) HandleRestoreNoShow(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SessionID     string `json:"session_id"`
+		ParticipantID int64  `json:"participant_id"`
+		Version       int64  `json:"version"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleValidationErrorHTMX(w, r, messageInvalidRequestBody)
+		return
+	}
+	snapshot, err := h.RouteSession.RestoreNoShow(r.Context(), req.SessionID, req.Version, req.ParticipantID)
+	if err != nil {
+		h.handleRouteSessionError(w, r, err)
+		return
+	}
+	log.Printf("[EDIT] Restored no-show participant %d", req.ParticipantID)
+	h.writeRouteSession(w, r, snapshot)
+}
+
+// HandleRefreshParticipant handles POST /api/v1/routes/edit/refresh-participant
+//
+// It reloads a participant edited since the session was calculated, geocoding
+// their address first if the stored record has no coordinates, and
+// re-optimizes the route that carries them. The session version comes from
+// the version query parameter.
+func (h *Handler) HandleRefreshParticipant(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SessionID     string `json:"session_id"`
+		ParticipantID int64  `json:"participant_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleValidationErrorHTMX(w, r, messageInvalidRequestBody)
+		return
+	}
+	if req.ParticipantID == 0 {
+		h.handleValidationErrorHTMX(w, r, messageInvalidParticipantID)
+		return
+	}
+	version, err := sessionVersionParam(r)
+	if err != nil {
+		h.handleValidationErrorHTMX(w, r, err.Error())
+		return
+	}
+
+	participant, err := h.DB.Participants().GetByID(r.Context(), req.ParticipantID)
+	if err != nil {
+		if h.checkNotFound(err) {
+			h.handleNotFound(w, messageParticipantNotFound)
+			return
+		}
+		log.Printf("[ERROR] Failed to load participant for session refresh: id=%d err=%v", req.ParticipantID, err)
+		h.handleInternalError(w, err)
+		return
+	}
+	if participant.Lat == 0 && participant.Lng == 0 && participant.Address != "" {
+		geocodeResult, err := h.Geocoder.GeocodeWithRetry(r.Context(), participant.Address, 3)
+		if err != nil {
+			if h.isHTMX(r) {
+				h.renderError(w, r, err)
+				return
+			}
+			h.handleGeocodingError(w, err)
+			return
+		}
+		participant.Lat = geocodeResult.Coords.Lat
+		participant.Lng = geocodeResult.Coords.Lng
+		if participant, err = h.DB.Participants().Update(r.Context(), participant); err != nil {
+			log.Printf("[ERROR] Failed to save geocoded participant: id=%d err=%v", req.ParticipantID, err)
+			h.handleInternalError(w, err)
+			return
+		}
+	}
+
+	snapshot, err := h.RouteSession.RefreshParticipant(r.Context(), req.SessionID, version, *participant)
+	if err != nil {
+		h.handleRouteSessionError(w, r, err)
+		return
+	}
+	log.Printf("[EDIT] Refreshed participant %d in session", req.ParticipantID)
+	h.writeRouteSession(w, r, snapshot)
+}
+
+// HandleRouteETAs handles GET /api/v1/routes/session/etas
+func (h *Handler) HandleRouteETAs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	id := query.Get("session_id")
+	routeIndex, err := strconv.Atoi(query.Get("route_index"))
+	if err != nil {
+		h.handleValidationError(w, messageInvalidRouteIndex)
+		return
+	}
+	departureTime, err := parseRouteTime(query.Get("departure_time"))
+	if err != nil {
+		h.handleValidationError(w, messageChooseValidDepartureTime)
+		return
+	}
+	departure, _ := time.Parse("15:04", departureTime)
+	etas, err := h.RouteSession.RouteETAs(id, routeIndex, departure)
+	if err != nil {
+		h.handleRouteSessionError(w, r, err)
+		return
+	}
+	response := routeETAsResponse{
+		SessionID: id, RouteIndex: etas.RouteIndex, DepartureTime: departureTime,
+		Stops: make([]stopETAResponse, len(etas.Stops)), FinishTime: etas.FinishAt.Format("15:04"),
+	}
+	for i, stop := range etas.Stops {
+		response.Stops[i] = stopETAResponse{Order: stop.Order, Participant: stop.Participant, ArrivalTime: stop.ArrivalAt.Format("15:04")}
+	}
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+// HandleRouteSessionSummary handles GET /api/v1/routes/edit/{session_id}/summary
+func (h *Handler) HandleRouteSessionSummary(w http.ResponseWriter, r *http.Request) {
+	id, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/routes/edit/"), "/summary")
+	if !ok || id == "" || strings.Contains(id, "/") {
+		h.handleNotFound(w, messageSessionNotFound)
+		return
+	}
+	summary, err := h.RouteSession.Summary(id)
+	if err != nil {
+		h.handleRouteSessionError(w, r, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, summary)
+}
+
+// RouteSessionValidationResponse lists the ways a session's routes break the
+// routing constraints; an empty list means the plan can be finalized.
+type RouteSessionValidationResponse struct {
+	Valid      bool                     `json:"valid"`
+	Violations []routesession.Violation `json:"violations"`
+}
+
+// HandleRouteSessionValidate handles GET /api/v1/routes/edit/{session_id}/validate
+func (h *Handler) HandleRouteSessionValidate(w http.ResponseWriter, r *http.Request) {
+	id, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/routes/edit/"), "/validate")
+	if !ok || id == "" || strings.Contains(id, "/") {
+		h.handleNotFound(w, messageSessionNotFound)
+		return
+	}
+	violations, err := h.RouteSession.Validate(id)
+	if err != nil {
+		h.handleRouteSessionError(w, r, err)
+		return
+	}
+	log.Printf("[HTTP] GET /api/v1/routes/edit/{id}/validate: session=%s violations=%d", id, len(violations))
+	h.writeJSON(w, http.StatusOK, RouteSessionValidationResponse{Valid: len(violations) == 0, Violations: violations})
+}
+
+// removalSavingResponse is what taking one participant off their route saves.
+type removalSavingResponse struct {
+	ParticipantID   int64   `json:"participant_id"`
+	ParticipantName string  `json:"participant_name"`
+	RouteIndex      int     `json:"route_index"`
+	DistanceMeters  float64 `json:"distance_meters"`
+	DetourSecs      float64 `json:"detour_secs"`
+	MaxDetourSecs   float64 `json:"max_detour_secs"`
+}
+
+// RouteSessionRemovalSavingsResponse ranks a session's participants by what
+// removing each one saves, costliest to serve first.
+type RouteSessionRemovalSavingsResponse struct {
+	SessionID    string                  `json:"session_id"`
+	Participants []removalSavingResponse `json:"participants"`
+}
+
+// HandleRouteSessionRemovalSavings handles GET /api/v1/routes/edit/{session_id}/removal-savings
+func (h *Handler) HandleRouteSessionRemovalSavings(w http.ResponseWriter, r *http.Request) {
+	id, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/routes/edit/"), "/removal-savings")
+	if !ok || id == "" || strings.Contains(id, "/") {
+		h.handleNotFound(w, messageSessionNotFound)
+		return
+	}
+	savings, err := h.RouteSession.RemovalSavings(r.Context(), id)
+	if err != nil {
+		h.handleRouteSessionError(w, r, err)
+		return
+	}
+	response := RouteSessionRemovalSavingsResponse{SessionID: id, Participants: make([]removalSavingResponse, len(savings))}
+	for i, saving := range savings {
+		response.Participants[i] = removalSavingResponse{
+			ParticipantID: saving.Participant.ID, ParticipantName: saving.Participant.Name, RouteIndex: saving.RouteIndex,
+			DistanceMeters: saving.DistanceMeters, DetourSecs: saving.DetourSecs, MaxDetourSecs: saving.MaxDetourSecs,
+		}
+	}
+	log.Printf("[HTTP] GET /api/v1/routes/edit/{id}/removal-savings: session=%s participants=%d", id, len(savings))
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+// spareSeatAreaResponse is the free seats pooled around one area.
+type spareSeatAreaResponse struct {
+	Lat            float64 `json:"lat"`
+	Lng            float64 `json:"lng"`
+	Heading        string  `json:"heading"`
+	DistanceMeters float64 `json:"distance_meters"`
+	SpareSeats     int     `json:"spare_seats"`
+	RouteIndexes   []int   `json:"route_indexes"`
+}
+
+// RouteSessionSpareSeatsResponse lists where a session's used routes still
+// have free seats, most seats first.
+type RouteSessionSpareSeatsResponse struct {
+	SessionID    string                  `json:"session_id"`
+	RadiusMeters float64                 `json:"radius_meters"`
+	TotalSeats   int                     `json:"total_spare_seats"`
+	Areas        []spareSeatAreaResponse `json:"areas"`
+}
+
+// HandleRouteSessionSpareSeats handles GET /api/v1/routes/edit/{session_id}/spare-seats
+//
+// The optional radius_meters query value sets how close route centers must be
+// to pool their seats.
+func (h *Handler) HandleRouteSessionSpareSeats(w http.ResponseWriter, r *http.Request) {
+	id, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/routes/edit/"), "/spare-seats")
+	if !ok || id == "" || strings.Contains(id, "/") {
+		h.handleNotFound(w, messageSessionNotFound)
+		return
+	}
+	radius := routing.DefaultSpareSeatRadiusMeters
+	if value := r.URL.Query().Get("radius_meters"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 {
+			h.handleValidationError(w, messageInvalidSpareSeatRadius)
+			return
+		}
+		radius = parsed
+	}
+	areas, err := h.RouteSession.SpareSeats(id, radius)
+	if err != nil {
+		h.handleRouteSessionError(w, r, err)
+		return
+	}
+	response := RouteSessionSpareSeatsResponse{SessionID: id, RadiusMeters: radius, Areas: make([]spareSeatAreaResponse, len(areas))}
+	for i, area := range areas {
+		response.TotalSeats += area.SpareSeats
+		response.Areas[i] = spareSeatAreaResponse{
+			Lat: area.Center.Lat, Lng: area.Center.Lng, Heading: area.Heading,
+			DistanceMeters: area.DistanceMeters, SpareSeats: area.SpareSeats, RouteIndexes: area.RouteIndexes,
+		}
+	}
+	log.Printf("[HTTP] GET /api/v1/routes/edit/{id}/spare-seats: session=%s areas=%d seats=%d", id, len(areas), response.TotalSeats)
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+// RouteSessionVersionResponse is what a coordinator polls to learn whether
+// someone else has edited the session since they last loaded it.
+type RouteSessionVersionResponse struct {
+	SessionID string `json:"session_id"`
+	Version   int64  `json:"version"`
+	Finalized bool   `json:"finalized"`
+}
+
+// HandleRouteSessionVersion handles GET /api/v1/routes/edit/{session_id}/version
+func (h *Handler) HandleRouteSessionVersion(w http.ResponseWriter, r *http.Request) {
+	id, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/routes/edit/"), "/version")
+	if !ok || id == "" || strings.Contains(id, "/") {
+		h.handleNotFound(w, messageSessionNotFound)
+		return
+	}
+	snapshot, ok := h.RouteSession.Snapshot(id)
+	if !ok {
+		h.handleNotFound(w, messageSessionNotFound)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, RouteSessionVersionResponse{SessionID: id, Version: snapshot.Version, Finalized: snapshot.Finalized})
+}
+
+// HandleRouteSessionReport handles GET requests under
+// /api/v1/routes/edit/{session_id}/, dispatching on the trailing action.
+func (h *Handler) HandleRouteSessionReport(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/validate"):
+		h.HandleRouteSessionValidate(w, r)
+	case strings.HasSuffix(r.URL.Path, "/removal-savings"):
+		h.HandleRouteSessionRemovalSavings(w, r)
+	case strings.HasSuffix(r.URL.Path, "/spare-seats"):
+		h.HandleRouteSessionSpareSeats(w, r)
+	case strings.HasSuffix(r.URL.Path, "/version"):
+		h.HandleRouteSessionVersion(w, r)
+	case strings.HasSuffix(r.URL.Path, "/export.gpx"):
+		h.HandleRouteSessionGPX(w, r)
+	case strings.HasSuffix(r.URL.Path, "/qr"):
+		h.HandleRouteQRCode(w, r)
+	default:
+		h.HandleRouteSessionSummary(w, r)
+	}
+}
+
+// HandleRouteSessionLock handles POST /api/v1/routes/edit/{session_id}/finalize
+// and POST /api/v1/routes/edit/{session_id}/unlock
+func (h *Handler) HandleRouteSessionLock(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/routes/edit/")
+	id, action, ok := strings.Cut(path, "/")
+	if !ok || id == "" || (action != "finalize" && action != "unlock") {
+		h.handleNotFound(w, messageSessionNotFound)
+		return
+	}
+	var snapshot routesession.Snapshot
+	var err error
+	if action == "finalize" {
+		snapshot, err = h.RouteSession.Finalize(id)
+	} else {
+		snapshot, err = h.RouteSession.Reopen(id)
+	}
+	if err != nil {
+		h.handleRouteSessionError(w, r, err)
+		return
+	}
+	log.Printf("[HTTP] POST /api/v1/routes/edit/{id}/%s: session=%s", action, id)
+	h.writeRouteSession(w, r, snapshot)
+}
+
+// sessionVersionParam reads the session version an edit sent by query or form
+// was planned against. It is optional; zero skips the version check.
+func sessionVersionParam(r *http.Request) (int64, error) {
+	value := r.URL.Query().Get("version")
+	if value == "" {
+		value = r.FormValue("version")
+	}
+	if value == "" {
+		return 0, nil
+	}
+	version, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || version < 0 {
+		return 0, errors.New(messageInvalidSessionVersion)
+	}
+	return version, nil
+}
+
 func (h *Handler) writeRouteSession(w http.ResponseWriter, r *http.Request, snapshot routesession.Snapshot) {
 	if h.isHTMX(r) {
 		h.renderTemplate(w, "route_results", buildRouteResultsView(snapshot))
 		return
 	}
-	h.writeJSON(w, http.StatusOK, RouteCalculationResponse{Routes: snapshot.Routes, Summary: snapshot.Summary, SessionID: snapshot.ID, Mode: snapshot.Mode})
+	h.writeJSON(w, http.StatusOK, RouteCalculationResponse{Routes: snapshot.Routes, Summary: snapshot.Summary, SessionID: snapshot.ID, Mode: snapshot.Mode, NoShows: snapshot.NoShows, Finalized: snapshot.Finalized, Version: snapshot.Version, DriverManifest: buildDriverManifest(snapshot.Routes)})
 }
 
 func (h *Handler) handleRouteSessionError(w http.ResponseWriter, r *http.Request, err error) {
@@ -171,10 +685,34 @@ func (h *Handler) handleRouteSessionError(w http.ResponseWriter, r *http.Request
 		h.handleValidationErrorHTMX(w, r, "Cannot swap - route is missing a driver")
 	case errors.Is(err, routesession.ErrSwapCapacity):
 		h.handleValidationErrorHTMX(w, r, "Cannot swap - capacity constraints violated")
+	case errors.Is(err, routesession.ErrInvalidCapacity):
+		h.handleValidationErrorHTMX(w, r, messageVehicleCapacityMustBeGreaterThanZero)
+	case errors.Is(err, routesession.ErrCapacityExceedsVehicle):
+		h.handleValidationErrorHTMX(w, r, messageCapacityExceedsVehicle)
 	case errors.Is(err, routesession.ErrDriverNotSelected):
 		h.handleValidationErrorHTMX(w, r, "Driver not found in selected drivers")
 	case errors.Is(err, routesession.ErrDriverAlreadyInRoutes):
 		h.handleValidationErrorHTMX(w, r, "Driver is already in routes")
+	case errors.Is(err, routesession.ErrSoloRide):
+		h.handleValidationErrorHTMX(w, r, messageSoloRideMove)
+	case errors.Is(err, routesession.ErrExcludedByDriver):
+		h.handleValidationErrorHTMX(w, r, messageExcludedByDriverMove)
+	case errors.Is(err, routesession.ErrNeedsAccessible):
+		h.handleValidationErrorHTMX(w, r, messageNeedsAccessibleMove)
+	case errors.Is(err, routesession.ErrNoShowNotFound):
+		h.handleValidationErrorHTMX(w, r, "Participant is not marked as a no-show")
+	case errors.Is(err, routesession.ErrDriverNotInSession):
+		h.handleValidationErrorHTMX(w, r, "The no-show's driver is no longer in this session")
+	case errors.Is(err, routesession.ErrFinalized):
+		h.handleHTMXErrorNoSwap(w, r, http.StatusConflict, "SESSION_FINALIZED", messageSessionFinalized)
+	case errors.Is(err, routesession.ErrNothingToUndo):
+		h.handleHTMXErrorNoSwap(w, r, http.StatusConflict, "NOTHING_TO_UNDO", messageNothingToUndo)
+	case errors.Is(err, routesession.ErrReoptimizeDeclines):
+		h.handleValidationErrorHTMX(w, r, messageReoptimizeDeclines)
+	case errors.Is(err, routesession.ErrReoptimizeBreaksLimits):
+		h.handleValidationErrorHTMX(w, r, messageReoptimizeBreaksLimits)
+	case errors.Is(err, routesession.ErrStaleVersion):
+		h.handleHTMXErrorNoSwap(w, r, http.StatusConflict, "STALE_SESSION_VERSION", messageSessionChanged)
 	default:
 		h.handleInternalError(w, err)
 	}
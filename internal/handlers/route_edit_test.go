@@ -4,12 +4,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"math"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"ride-home-router/internal/distance"
 	"ride-home-router/internal/models"
 	"ride-home-router/internal/routesession"
+	"ride-home-router/internal/routing"
+	"strings"
 	"testing"
 )
 
@@ -45,6 +49,10 @@ func (routeEditDistanceCalculator) PrewarmCache(context.Context, []models.Coordi
 	return nil
 }
 
+func (routeEditDistanceCalculator) GetRouteGeometry(context.Context, []models.Coordinates) (string, error) {
+	return "", distance.ErrRouteGeometryUnavailable
+}
+
 func TestHandleMoveParticipantPreservesLegacyClaimedSourceValidation(t *testing.T) {
 	store := routesession.NewStore(routeEditDistanceCalculator{})
 	t.Cleanup(store.Close)
@@ -97,6 +105,42 @@ func TestHandleSwapDriversReturnsJSON(t *testing.T) {
 	}
 }
 
+func TestHandleSetRouteCapacityAllowsAnExtraParticipant(t *testing.T) {
+	store := routesession.NewStore(routeEditDistanceCalculator{})
+	t.Cleanup(store.Close)
+	h := &Handler{RouteSession: store}
+	created := store.Create(routesession.CreateInput{
+		Routes: []models.CalculatedRoute{
+			{Driver: &models.Driver{ID: 1, VehicleCapacity: 1}, EffectiveCapacity: 1, Stops: []models.RouteStop{{Participant: &models.Participant{ID: 10, Lat: 1}}}},
+			{Driver: &models.Driver{ID: 2, VehicleCapacity: 1}, EffectiveCapacity: 1, Stops: []models.RouteStop{{Participant: &models.Participant{ID: 11, Lat: 2}}}},
+		},
+		ActivityLocation: &models.ActivityLocation{}, RouteTime: "18:30", Mode: models.RouteModeDropoff,
+	})
+	post := func(handle http.HandlerFunc, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handle(w, httptest.NewRequestWithContext(context.Background(), http.MethodPost, path, bytes.NewBufferString(body)))
+		return w
+	}
+
+	if w := post(h.HandleSetRouteCapacity, "/api/v1/routes/edit/set-capacity", `{"session_id":"`+created.ID+`","route_index":0,"capacity":0}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("zero capacity status = %d, want 400; body=%s", w.Code, w.Body.String())
+	}
+	raised := decodeRouteResponse(t, post(h.HandleSetRouteCapacity, "/api/v1/routes/edit/set-capacity", `{"session_id":"`+created.ID+`","route_index":0,"capacity":2}`))
+	if raised.Routes[0].EffectiveCapacity != 2 {
+		t.Fatalf("route 0 capacity = %d, want 2", raised.Routes[0].EffectiveCapacity)
+	}
+
+	moved := decodeRouteResponse(t, post(h.HandleMoveParticipant, "/api/v1/routes/edit/move-participant", `{"session_id":"`+created.ID+`","moves":[{"participant_id":11,"from_route_index":1,"to_route_index":0,"insert_at_position":-1}]}`))
+	if len(moved.Routes[0].Stops) != 2 || moved.Routes[0].EffectiveCapacity != 2 {
+		t.Fatalf("route 0 = %d stops for %d seats, want 2 for 2", len(moved.Routes[0].Stops), moved.Routes[0].EffectiveCapacity)
+	}
+	snapshot, _ := store.Snapshot(created.ID)
+	if snapshot.IsOutOfBalance {
+		t.Fatalf("over capacity = %v, want the raised route to fit its extra rider", snapshot.OverCapacity)
+	}
+}
+
 func TestHandleResetRoutesReturnsOriginalJSON(t *testing.T) {
 	h, created := newRouteEditHandler(t)
 	if _, err := h.RouteSession.ApplyMoves(context.Background(), created.ID, []routesession.Move{{ParticipantID: 10, ToRouteIndex: 1, InsertAtPosition: -1}}, routesession.ApplyMovesOptions{}); err != nil {
@@ -110,6 +154,55 @@ func TestHandleResetRoutesReturnsOriginalJSON(t *testing.T) {
 	}
 }
 
+func TestHandleUndoRouteEditRevertsTheLastMove(t *testing.T) {
+	h, created := newRouteEditHandler(t)
+	if _, err := h.RouteSession.ApplyMoves(context.Background(), created.ID, []routesession.Move{{ParticipantID: 10, ToRouteIndex: 1, InsertAtPosition: -1}}, routesession.ApplyMovesOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	h.HandleUndoRouteEdit(w, httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/routes/edit/undo?session_id="+created.ID, nil))
+	response := decodeRouteResponse(t, w)
+	if len(response.Routes[0].Stops) != 1 || len(response.Routes[1].Stops) != 0 {
+		t.Fatalf("move was not undone: %#v", response.Routes)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandleUndoRouteEdit(w, httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/routes/edit/undo?session_id="+created.ID, nil))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("second undo status=%d body=%s, want %d", w.Code, w.Body.String(), http.StatusConflict)
+	}
+}
+
+func TestHandleReoptimizeRouteEditKeepsLockedStops(t *testing.T) {
+	h, created := newRouteEditHandler(t)
+	h.Router = routing.NewBalancedRouter(routeEditDistanceCalculator{})
+	ctx := context.Background()
+	if _, err := h.RouteSession.ApplyMoves(ctx, created.ID, []routesession.Move{{ParticipantID: 10, ToRouteIndex: 1, InsertAtPosition: -1}}, routesession.ApplyMovesOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.RouteSession.LockStop(created.ID, 0, 10, true); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	h.HandleReoptimizeRouteEdit(w, httptest.NewRequestWithContext(ctx, http.MethodPost, "/api/v1/routes/edit/reoptimize?session_id="+created.ID, nil))
+	response := decodeRouteResponse(t, w)
+	if len(response.Routes[1].Stops) != 1 || !response.Routes[1].Stops[0].Locked {
+		t.Fatalf("locked rider left their route: %#v", response.Routes)
+	}
+}
+
+func TestHandleLockStopMarksTheStopLocked(t *testing.T) {
+	h, created := newRouteEditHandler(t)
+	body := `{"session_id":"` + created.ID + `","participant_id":10,"locked":true}`
+	w := httptest.NewRecorder()
+	h.HandleLockStop(w, httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/routes/edit/lock-stop", bytes.NewBufferString(body)))
+	response := decodeRouteResponse(t, w)
+	if !response.Routes[0].Stops[0].Locked {
+		t.Fatalf("stop was not locked: %#v", response.Routes[0].Stops)
+	}
+}
+
 func TestHandleAddDriverReturnsJSON(t *testing.T) {
 	h, created := newRouteEditHandler(t)
 	body := `{"session_id":"` + created.ID + `","driver_id":3}`
@@ -121,6 +214,293 @@ func TestHandleAddDriverReturnsJSON(t *testing.T) {
 	}
 }
 
+func TestHandleMarkNoShowAndRestoreReturnJSON(t *testing.T) {
+	h, created := newRouteEditHandler(t)
+	post := func(handle http.HandlerFunc, path, body string) RouteCalculationResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handle(w, httptest.NewRequestWithContext(context.Background(), http.MethodPost, path, bytes.NewBufferString(body)))
+		return decodeRouteResponse(t, w)
+	}
+	marked := post(h.HandleMarkNoShow, "/api/v1/routes/edit/mark-noshow", `{"session_id":"`+created.ID+`","participant_id":10,"reoptimize":true}`)
+	if len(marked.Routes[0].Stops) != 0 || len(marked.NoShows) != 1 || marked.NoShows[0].ID != 10 {
+		t.Fatalf("mark no-show response = %#v", marked)
+	}
+	restored := post(h.HandleRestoreNoShow, "/api/v1/routes/edit/restore-noshow", `{"session_id":"`+created.ID+`","participant_id":10}`)
+	if len(restored.Routes[0].Stops) != 1 || len(restored.NoShows) != 0 {
+		t.Fatalf("restore no-show response = %#v", restored)
+	}
+}
+
+func TestHandleRouteETAsShiftWithDepartureTime(t *testing.T) {
+	h, created := newRouteEditHandler(t)
+	// Swapping recalculates route 0, giving its rider a 1000s cumulative duration.
+	if _, err := h.RouteSession.SwapDrivers(context.Background(), created.ID, 0, 0, 1); err != nil {
+		t.Fatal(err)
+	}
+	arrival := func(departure string) string {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.HandleRouteETAs(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/routes/session/etas?session_id="+created.ID+"&route_index=0&departure_time="+departure, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+		}
+		var response routeETAsResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if len(response.Stops) != 1 {
+			t.Fatalf("stops = %#v", response.Stops)
+		}
+		return response.Stops[0].ArrivalTime
+	}
+	if early, late := arrival("17:40"), arrival("18:10"); early != "17:56" || late != "18:26" {
+		t.Fatalf("arrivals = %q, %q; want 17:56 and 18:26", early, late)
+	}
+
+	w := httptest.NewRecorder()
+	h.HandleRouteETAs(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/routes/session/etas?session_id="+created.ID+"&route_index=0&departure_time=late", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+}
+
+func TestHandleRouteSessionSummaryMatchesFullResponse(t *testing.T) {
+	h, created := newRouteEditHandler(t)
+	if _, err := h.RouteSession.SwapDrivers(context.Background(), created.ID, 0, 0, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	h.HandleGetRouteSession(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/routes/session?session_id="+created.ID, nil))
+	full := decodeRouteResponse(t, w)
+
+	w = httptest.NewRecorder()
+	h.HandleRouteSessionSummary(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/routes/edit/"+created.ID+"/summary", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var summary models.RoutingSummary
+	if err := json.NewDecoder(w.Body).Decode(&summary); err != nil {
+		t.Fatalf("decode summary: %v", err)
+	}
+	if !reflect.DeepEqual(summary, full.Summary) {
+		t.Fatalf("summary = %+v, want %+v", summary, full.Summary)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandleRouteSessionSummary(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/routes/edit/missing/summary", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404 for unknown session", w.Code)
+	}
+}
+
+func TestHandleRouteSessionReportValidatesPlan(t *testing.T) {
+	h, created := newRouteEditHandler(t)
+
+	w := httptest.NewRecorder()
+	h.HandleRouteSessionReport(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/routes/edit/"+created.ID+"/validate", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp RouteSessionValidationResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode validation: %v", err)
+	}
+	// The fixture drivers were never geocoded.
+	if resp.Valid || len(resp.Violations) != 2 || resp.Violations[0].Kind != routesession.ViolationMissingCoordinates {
+		t.Fatalf("validation = %+v, want missing driver coordinates", resp)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandleRouteSessionReport(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/routes/edit/missing/validate", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404 for unknown session", w.Code)
+	}
+}
+
+func TestHandleRouteSessionReportRanksRemovalSavings(t *testing.T) {
+	h, created := newRouteEditHandler(t)
+
+	w := httptest.NewRecorder()
+	h.HandleRouteSessionReport(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/routes/edit/"+created.ID+"/removal-savings", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp RouteSessionRemovalSavingsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode savings: %v", err)
+	}
+	if resp.SessionID != created.ID || len(resp.Participants) != 1 || resp.Participants[0].ParticipantID != 10 || resp.Participants[0].DistanceMeters <= 0 {
+		t.Fatalf("savings = %+v, want the one rider with a positive saving", resp)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandleRouteSessionReport(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/routes/edit/missing/removal-savings", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404 for unknown session", w.Code)
+	}
+}
+
+func TestHandleRouteSessionReportPoolsSpareSeats(t *testing.T) {
+	h, created := newRouteEditHandler(t)
+
+	w := httptest.NewRecorder()
+	h.HandleRouteSessionReport(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/routes/edit/"+created.ID+"/spare-seats?radius_meters=2000", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp RouteSessionSpareSeatsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode spare seats: %v", err)
+	}
+	if resp.RadiusMeters != 2000 || resp.TotalSeats != 1 || len(resp.Areas) != 1 || resp.Areas[0].Heading != "north" || resp.Areas[0].Lat != 1 {
+		t.Fatalf("spare seats = %+v, want the used route's one free seat heading north", resp)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandleRouteSessionReport(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/routes/edit/"+created.ID+"/spare-seats?radius_meters=-1", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400 for a negative radius", w.Code)
+	}
+}
+
+func TestHandleRouteSessionLockRejectsEditsUntilUnlocked(t *testing.T) {
+	h, created := newRouteEditHandler(t)
+	lock := func(action string) RouteCalculationResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.HandleRouteSessionLock(w, httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/routes/edit/"+created.ID+"/"+action, nil))
+		return decodeRouteResponse(t, w)
+	}
+	move := func() *httptest.ResponseRecorder {
+		body := `{"session_id":"` + created.ID + `","moves":[{"participant_id":10,"from_route_index":0,"to_route_index":1,"insert_at_position":-1}]}`
+		w := httptest.NewRecorder()
+		h.HandleMoveParticipant(w, httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/routes/edit/move-participant", bytes.NewBufferString(body)))
+		return w
+	}
+
+	if finalized := lock("finalize"); !finalized.Finalized {
+		t.Fatal("finalize response should report the session as finalized")
+	}
+	if w := move(); w.Code != http.StatusConflict {
+		t.Fatalf("move on finalized session status = %d, want 409; body=%s", w.Code, w.Body.String())
+	}
+	w := httptest.NewRecorder()
+	h.HandleResetRoutes(w, httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/routes/edit/reset?session_id="+created.ID, nil))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("reset on finalized session status = %d, want 409", w.Code)
+	}
+
+	if unlocked := lock("unlock"); unlocked.Finalized {
+		t.Fatal("unlock response should report the session as editable")
+	}
+	moved := decodeRouteResponse(t, move())
+	if len(moved.Routes[0].Stops) != 0 || len(moved.Routes[1].Stops) != 1 {
+		t.Fatalf("move after unlock routes = %#v", moved.Routes)
+	}
+}
+
+func TestHandleMoveParticipantRejectsStaleSessionVersion(t *testing.T) {
+	h, created := newRouteEditHandler(t)
+	move := func(participantID int64, toRoute int, version int64) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"session_id":%q,"version":%d,"moves":[{"participant_id":%d,"to_route_index":%d,"insert_at_position":-1}]}`, created.ID, version, participantID, toRoute)
+		w := httptest.NewRecorder()
+		h.HandleMoveParticipant(w, httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/routes/edit/move-participant", bytes.NewBufferString(body)))
+		return w
+	}
+	poll := func() RouteSessionVersionResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.HandleRouteSessionReport(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/routes/edit/"+created.ID+"/version", nil))
+		var resp RouteSessionVersionResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode version: %v", err)
+		}
+		return resp
+	}
+
+	loaded := poll()
+	if loaded.SessionID != created.ID || loaded.Version != created.Version {
+		t.Fatalf("version = %+v, want session %s at %d", loaded, created.ID, created.Version)
+	}
+	first := decodeRouteResponse(t, move(10, 1, loaded.Version))
+	if first.Version != loaded.Version+1 || poll().Version != first.Version {
+		t.Fatalf("version after move = %d, want %d reported to pollers", first.Version, loaded.Version+1)
+	}
+
+	w := move(10, 0, loaded.Version)
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "STALE_SESSION_VERSION") {
+		t.Fatalf("stale move status = %d body=%s, want 409 STALE_SESSION_VERSION", w.Code, w.Body.String())
+	}
+	if current := poll(); current.Version != first.Version {
+		t.Fatalf("version after rejected move = %d, want %d", current.Version, first.Version)
+	}
+	if retried := decodeRouteResponse(t, move(10, 0, first.Version)); len(retried.Routes[0].Stops) != 1 {
+		t.Fatalf("move at the current version routes = %#v", retried.Routes)
+	}
+}
+
+func TestRouteEditHandlersRejectStaleSessionVersion(t *testing.T) {
+	jsonEdit := func(handle func(*Handler) http.HandlerFunc, path, fields string) func(*Handler, string, int64) *httptest.ResponseRecorder {
+		return func(h *Handler, id string, version int64) *httptest.ResponseRecorder {
+			body := fmt.Sprintf(`{"session_id":%q,"version":%d,%s}`, id, version, fields)
+			w := httptest.NewRecorder()
+			handle(h)(w, httptest.NewRequestWithContext(context.Background(), http.MethodPost, path, bytes.NewBufferString(body)))
+			return w
+		}
+	}
+	queryEdit := func(handle func(*Handler) http.HandlerFunc, path string) func(*Handler, string, int64) *httptest.ResponseRecorder {
+		return func(h *Handler, id string, version int64) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			handle(h)(w, httptest.NewRequestWithContext(context.Background(), http.MethodPost, fmt.Sprintf("%s?session_id=%s&version=%d", path, id, version), nil))
+			return w
+		}
+	}
+	tests := []struct {
+		name string
+		edit func(*Handler, string, int64) *httptest.ResponseRecorder
+	}{
+		{"swap drivers", jsonEdit(func(h *Handler) http.HandlerFunc { return h.HandleSwapDrivers }, "/api/v1/routes/edit/swap-drivers", `"route_index_1":0,"route_index_2":1`)},
+		{"set capacity", jsonEdit(func(h *Handler) http.HandlerFunc { return h.HandleSetRouteCapacity }, "/api/v1/routes/edit/set-capacity", `"route_index":0,"capacity":1`)},
+		{"lock stop", jsonEdit(func(h *Handler) http.HandlerFunc { return h.HandleLockStop }, "/api/v1/routes/edit/lock-stop", `"participant_id":10,"locked":true`)},
+		{"reset", queryEdit(func(h *Handler) http.HandlerFunc { return h.HandleResetRoutes }, "/api/v1/routes/edit/reset")},
+		{"undo", queryEdit(func(h *Handler) http.HandlerFunc { return h.HandleUndoRouteEdit }, "/api/v1/routes/edit/undo")},
+		{"mark no-show", jsonEdit(func(h *Handler) http.HandlerFunc { return h.HandleMarkNoShow }, "/api/v1/routes/edit/mark-noshow", `"participant_id":10`)},
+		{"restore no-show", jsonEdit(func(h *Handler) http.HandlerFunc { return h.HandleRestoreNoShow }, "/api/v1/routes/edit/restore-noshow", `"participant_id":10`)},
+		{"add driver", jsonEdit(func(h *Handler) http.HandlerFunc { return h.HandleAddDriver }, "/api/v1/routes/edit/add-driver", `"driver_id":3`)},
+		{"reoptimize", queryEdit(func(h *Handler) http.HandlerFunc { return h.HandleReoptimizeRouteEdit }, "/api/v1/routes/edit/reoptimize")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, created := newRouteEditHandler(t)
+			h.Router = routing.NewBalancedRouter(routeEditDistanceCalculator{})
+			current, err := h.RouteSession.ApplyMoves(context.Background(), created.ID, []routesession.Move{{ParticipantID: 10, ToRouteIndex: 1, InsertAtPosition: -1}}, routesession.ApplyMovesOptions{Version: created.Version})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := tt.edit(h, created.ID, created.Version)
+			if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "STALE_SESSION_VERSION") {
+				t.Fatalf("stale %s status = %d body=%s, want 409 STALE_SESSION_VERSION", tt.name, w.Code, w.Body.String())
+			}
+			after, _ := h.RouteSession.Snapshot(created.ID)
+			if after.Version != current.Version || len(after.Routes) != len(current.Routes) || len(after.Routes[1].Stops) != 1 {
+				t.Fatalf("stale %s changed the session: version %d, routes %#v", tt.name, after.Version, after.Routes)
+			}
+		})
+	}
+}
+
+func TestHandleUndoRouteEditRejectsInvalidVersion(t *testing.T) {
+	h, created := newRouteEditHandler(t)
+	w := httptest.NewRecorder()
+	h.HandleUndoRouteEdit(w, httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/routes/edit/undo?session_id="+created.ID+"&version=latest", nil))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), messageInvalidSessionVersion) {
+		t.Fatalf("status = %d body=%s, want invalid version", w.Code, w.Body.String())
+	}
+}
+
 func TestHandleGetRouteSessionReturnsHTMXFragment(t *testing.T) {
 	h, created := newRouteEditHandler(t)
 	req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/routes/session?session_id="+created.ID, nil)
@@ -218,3 +598,104 @@ func decodeRouteResponse(t *testing.T, w *httptest.ResponseRecorder) RouteCalcul
 	}
 	return response
 }
+
+func TestHandleRefreshParticipantRecalculatesRouteAfterAddressChange(t *testing.T) {
+	h, store := newTestManagementHandler(t)
+	ctx := context.Background()
+	participant, err := store.Participants().Create(ctx, &models.Participant{Name: "Rider", Address: "1 Old Road", Lat: 41, Lng: -73})
+	if err != nil {
+		t.Fatalf("create participant: %v", err)
+	}
+	driver := models.Driver{ID: 1, Name: "Driver", Lat: 41, Lng: -73, VehicleCapacity: 2}
+	created := h.RouteSession.Create(routesession.CreateInput{
+		Routes: []models.CalculatedRoute{
+			{Driver: &driver, EffectiveCapacity: 2, Stops: []models.RouteStop{{Participant: participant}}},
+		},
+		SelectedDrivers:  []models.Driver{driver},
+		ActivityLocation: &models.ActivityLocation{ID: 1, Name: "HQ", Lat: 41, Lng: -73},
+		Mode:             models.RouteModeDropoff,
+	})
+	before := created.Routes[0].TotalDistanceMeters
+
+	// The stub geocoder places the new address at 41.25,-72.75.
+	update := httptest.NewRequestWithContext(ctx, http.MethodPut, "/api/v1/participants/"+int64ToString(participant.ID), strings.NewReader(`{"name":"Rider","address":"9 New Road"}`))
+	update.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.HandleUpdateParticipant(w, update)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update status=%d body=%s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.HandleRefreshParticipant(w, httptest.NewRequestWithContext(ctx, http.MethodPost, "/api/v1/routes/edit/refresh-participant",
+		bytes.NewBufferString(`{"session_id":"`+created.ID+`","participant_id":`+int64ToString(participant.ID)+`}`)))
+	refreshed := decodeRouteResponse(t, w)
+
+	stop := refreshed.Routes[0].Stops[0].Participant
+	if stop.Address != "9 New Road" || stop.Lat != 41.25 || stop.Lng != -72.75 {
+		t.Fatalf("refreshed stop = %+v, want the new address and coordinates", stop)
+	}
+	if after := refreshed.Routes[0].TotalDistanceMeters; after == before || after == 0 {
+		t.Fatalf("route distance = %v, want it recalculated from %v", after, before)
+	}
+}
+
+func TestHandleRefreshParticipantRejectsStaleSessionVersion(t *testing.T) {
+	h, store := newTestManagementHandler(t)
+	ctx := context.Background()
+	participant, err := store.Participants().Create(ctx, &models.Participant{Name: "Rider", Address: "1 Old Road", Lat: 41, Lng: -73})
+	if err != nil {
+		t.Fatalf("create participant: %v", err)
+	}
+	driver := models.Driver{ID: 1, Name: "Driver", Lat: 41, Lng: -73, VehicleCapacity: 2}
+	created := h.RouteSession.Create(routesession.CreateInput{
+		Routes: []models.CalculatedRoute{
+			{Driver: &driver, EffectiveCapacity: 2, Stops: []models.RouteStop{{Participant: participant}}},
+		},
+		SelectedDrivers:  []models.Driver{driver},
+		ActivityLocation: &models.ActivityLocation{ID: 1, Name: "HQ", Lat: 41, Lng: -73},
+		Mode:             models.RouteModeDropoff,
+	})
+	current, err := h.RouteSession.LockStop(created.ID, created.Version, participant.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	h.HandleRefreshParticipant(w, httptest.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("/api/v1/routes/edit/refresh-participant?version=%d", created.Version),
+		bytes.NewBufferString(`{"session_id":"`+created.ID+`","participant_id":`+int64ToString(participant.ID)+`}`)))
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "STALE_SESSION_VERSION") {
+		t.Fatalf("stale refresh status = %d body=%s, want 409 STALE_SESSION_VERSION", w.Code, w.Body.String())
+	}
+	if after, _ := h.RouteSession.Snapshot(created.ID); after.Version != current.Version {
+		t.Fatalf("stale refresh changed the session version to %d, want %d", after.Version, current.Version)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandleRefreshParticipant(w, httptest.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("/api/v1/routes/edit/refresh-participant?version=%d", current.Version),
+		bytes.NewBufferString(`{"session_id":"`+created.ID+`","participant_id":`+int64ToString(participant.ID)+`}`)))
+	if refreshed := decodeRouteResponse(t, w); refreshed.Version != current.Version+1 {
+		t.Fatalf("refresh at the current version = %d, want %d", refreshed.Version, current.Version+1)
+	}
+}
+
+func TestHandlePerturbRouteSessionReturnsNewSession(t *testing.T) {
+	h, created := newRouteEditHandler(t)
+	w := httptest.NewRecorder()
+	h.HandlePerturbRouteSession(w, httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/routes/session/perturb",
+		bytes.NewBufferString(`{"session_id":"`+created.ID+`","tolerance":-1}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("negative tolerance status=%d, want 400", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandlePerturbRouteSession(w, httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/routes/session/perturb",
+		bytes.NewBufferString(`{"session_id":"`+created.ID+`","seed":7}`)))
+	response := decodeRouteResponse(t, w)
+	if response.SessionID == "" || response.SessionID == created.ID {
+		t.Fatalf("session_id = %q, want a new session", response.SessionID)
+	}
+	if _, ok := h.RouteSession.Snapshot(created.ID); !ok {
+		t.Fatal("source session was removed")
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"log"
+	"net/http"
+	"ride-home-router/internal/httpx"
+	"ride-home-router/internal/models"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// gpxDocument is a GPX 1.1 file holding a single route.
+type gpxDocument struct {
+	XMLName xml.Name `xml:"gpx"`
+	Version string   `xml:"version,attr"`
+	Creator string   `xml:"creator,attr"`
+	XMLNS   string   `xml:"xmlns,attr"`
+	Route   gpxRoute `xml:"rte"`
+}
+
+type gpxRoute struct {
+	Name   string        `xml:"name"`
+	Points []gpxWaypoint `xml:"rtept"`
+}
+
+type gpxWaypoint struct {
+	Lat  float64 `xml:"lat,attr"`
+	Lon  float64 `xml:"lon,attr"`
+	Name string  `xml:"name"`
+}
+
+// HandleRouteSessionGPX handles GET /api/v1/routes/edit/{session_id}/export.gpx?driver={route_index}
+//
+// It exports one session route as a GPX route a driver can load into a phone
+// navigation app.
+func (h *Handler) HandleRouteSessionGPX(w http.ResponseWriter, r *http.Request) {
+	id, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/routes/edit/"), "/export.gpx")
+	if !ok || id == "" || strings.Contains(id, "/") {
+		h.handleNotFoundHTMX(w, r, messageSessionNotFound)
+		return
+	}
+	snapshot, ok := h.RouteSession.Snapshot(id)
+	if !ok {
+		h.handleNotFoundHTMX(w, r, messageSessionNotFound)
+		return
+	}
+	routeIndex, err := strconv.Atoi(r.URL.Query().Get("driver"))
+	if err != nil || routeIndex < 0 || routeIndex >= len(snapshot.Routes) || snapshot.Routes[routeIndex].Driver == nil {
+		h.handleNotFoundHTMX(w, r, messageRouteNotFound)
+		return
+	}
+	if snapshot.ActivityLocation == nil {
+		h.handleNotFoundHTMX(w, r, messageSessionActivityLocationNotFound)
+		return
+	}
+	route := snapshot.Routes[routeIndex]
+
+	data, err := xml.MarshalIndent(buildRouteGPX(snapshot.ActivityLocation, route, snapshot.Mode), "", "  ")
+	if err != nil {
+		log.Printf("[ERROR] Failed to encode route GPX: session=%s route=%d err=%v", id, routeIndex, err)
+		h.handleInternalError(w, err)
+		return
+	}
+
+	log.Printf("[HTTP] GET /api/v1/routes/edit/{id}/export.gpx: session=%s route=%d stops=%d", id, routeIndex, len(route.Stops))
+	w.Header().Set(httpx.HeaderContentType, httpx.MediaTypeGPX)
+	w.Header().Set(httpx.HeaderContentDisposition, `attachment; filename="`+gpxFilename(route.Driver.Name)+`"`)
+	_, _ = w.Write([]byte(xml.Header))
+	_, _ = w.Write(data)
+}
+
+// buildRouteGPX lays a route out in driving order, from routeEndpoints'
+// origin through each stop routeStopPoints lists to its destination.
+func buildRouteGPX(activity *models.ActivityLocation, route models.CalculatedRoute, mode models.RouteMode) gpxDocument {
+	origin, destination := routeEndpoints(activity, route, mode)
+	stops := routeStopPoints(route, mode)
+	points := make([]gpxWaypoint, 0, len(stops)+2)
+	for _, point := range slices.Concat([]navigationPoint{origin}, stops, []navigationPoint{destination}) {
+		points = append(points, gpxWaypoint{Lat: point.Coords.Lat, Lon: point.Coords.Lng, Name: point.Name})
+	}
+
+	return gpxDocument{
+		Version: "1.1",
+		Creator: "ride-home-router",
+		XMLNS:   "http://www.topografix.com/GPX/1/1",
+		Route:   gpxRoute{Name: route.Driver.Name, Points: points},
+	}
+}
+
+// gpxFilename names the export after the driver, keeping only characters
+// that are safe in a Content-Disposition filename.
+func gpxFilename(driverName string) string {
+	var name strings.Builder
+	for _, r := range strings.ToLower(driverName) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			name.WriteRune(r)
+		case name.Len() > 0 && !strings.HasSuffix(name.String(), "-"):
+			name.WriteByte('-')
+		}
+	}
+	slug := strings.TrimSuffix(name.String(), "-")
+	if slug == "" {
+		return "route.gpx"
+	}
+	return "route-" + slug + ".gpx"
+}
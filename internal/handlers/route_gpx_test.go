@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"ride-home-router/internal/models"
+	"ride-home-router/internal/routesession"
+	"testing"
+)
+
+func TestHandleRouteSessionGPXExportsRouteInDrivingOrder(t *testing.T) {
+	store := routesession.NewStore(routeEditDistanceCalculator{})
+	t.Cleanup(store.Close)
+	driver := models.Driver{ID: 1, Name: "Ana María", Lat: 41.35, Lng: -72.95, VehicleCapacity: 3}
+	created := store.Create(routesession.CreateInput{
+		Routes: []models.CalculatedRoute{{Driver: &driver, EffectiveCapacity: 3, Stops: []models.RouteStop{
+			{Participant: &models.Participant{ID: 10, Name: "First", Lat: 41.3, Lng: -72.9}},
+			{Participant: &models.Participant{ID: 11, Name: "Second", Lat: 41.32, Lng: -72.91}},
+		}}},
+		SelectedDrivers:  []models.Driver{driver},
+		ActivityLocation: &models.ActivityLocation{ID: 1, Name: "HQ", Lat: 41.31, Lng: -72.92},
+		RouteTime:        "18:30",
+		Mode:             models.RouteModeDropoff,
+	})
+	h := &Handler{RouteSession: store}
+
+	w := httptest.NewRecorder()
+	h.HandleRouteSessionReport(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/routes/edit/"+created.ID+"/export.gpx?driver=0", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/gpx+xml" {
+		t.Fatalf("content type = %q, want application/gpx+xml", got)
+	}
+	if got, want := w.Header().Get("Content-Disposition"), `attachment; filename="route-ana-mar-a.gpx"`; got != want {
+		t.Fatalf("content disposition = %q, want %q", got, want)
+	}
+	var doc gpxDocument
+	if err := xml.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("response is not GPX: %v", err)
+	}
+	want := []gpxWaypoint{
+		{Lat: 41.31, Lon: -72.92, Name: "HQ"},
+		{Lat: 41.3, Lon: -72.9, Name: "First"},
+		{Lat: 41.32, Lon: -72.91, Name: "Second"},
+		{Lat: 41.35, Lon: -72.95, Name: "Ana María (home)"},
+	}
+	if doc.Version != "1.1" || len(doc.Route.Points) != len(want) {
+		t.Fatalf("gpx = %+v, want version 1.1 with %d route points", doc, len(want))
+	}
+	for i := range want {
+		if doc.Route.Points[i] != want[i] {
+			t.Fatalf("point %d = %+v, want %+v", i, doc.Route.Points[i], want[i])
+		}
+	}
+
+	for _, path := range []string{
+		"/api/v1/routes/edit/" + created.ID + "/export.gpx?driver=1",
+		"/api/v1/routes/edit/" + created.ID + "/export.gpx?driver=first",
+		"/api/v1/routes/edit/missing/export.gpx?driver=0",
+	} {
+		w = httptest.NewRecorder()
+		h.HandleRouteSessionReport(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("%s status = %d, want 404", path, w.Code)
+		}
+	}
+}
+
+func TestBuildRouteGPXMatchesTheNavigationLinkStops(t *testing.T) {
+	activity := &models.ActivityLocation{ID: 1, Name: "HQ", Lat: 41.31, Lng: -72.92}
+	route := models.CalculatedRoute{
+		Driver: &models.Driver{ID: 1, Name: "Driver", Lat: 41.35, Lng: -72.95},
+		Stops: []models.RouteStop{
+			{Participant: &models.Participant{ID: 10, Name: "Sibling A", Lat: 41.3, Lng: -72.9, IntermediateStop: &models.Coordinates{Lat: 41.29, Lng: -72.89}}},
+			{Participant: &models.Participant{ID: 11, Name: "Sibling B", Lat: 41.3, Lng: -72.9}},
+		},
+	}
+
+	doc := buildRouteGPX(activity, route, models.RouteModeDropoff)
+	want := []gpxWaypoint{
+		{Lat: 41.31, Lon: -72.92, Name: "HQ"},
+		{Lat: 41.29, Lon: -72.89, Name: "Sibling A (stop)"},
+		{Lat: 41.3, Lon: -72.9, Name: "Sibling A, Sibling B"},
+		{Lat: 41.35, Lon: -72.95, Name: "Driver (home)"},
+	}
+	if len(doc.Route.Points) != len(want) {
+		t.Fatalf("gpx points = %+v, want %+v", doc.Route.Points, want)
+	}
+	for i := range want {
+		if doc.Route.Points[i] != want[i] {
+			t.Fatalf("point %d = %+v, want %+v", i, doc.Route.Points[i], want[i])
+		}
+	}
+	wantURL := "https://www.google.com/maps/dir/?api=1&destination=41.35%2C-72.95&dir_action=navigate&travelmode=driving&waypoints=41.29%2C-72.89%7C41.3%2C-72.9"
+	if got := navigationURL(models.NavProviderGoogle, activity, route, models.RouteModeDropoff); got != wantURL {
+		t.Fatalf("navigation URL = %q, want %q", got, wantURL)
+	}
+}
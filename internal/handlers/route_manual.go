@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"ride-home-router/internal/routesession"
+	"ride-home-router/internal/routing"
+	"slices"
+)
+
+// manualRouteRequest is one driver's riders in drop-off or pickup order.
+type manualRouteRequest struct {
+	DriverID       int64   `json:"driver_id"`
+	ParticipantIDs []int64 `json:"participant_ids"`
+}
+
+// ManualRoutePlanRequest is a coordinator's complete plan. Every participant
+// named must ride with exactly one driver.
+type ManualRoutePlanRequest struct {
+	ActivityLocationID int64                `json:"activity_location_id"`
+	RouteTime          string               `json:"route_time"`
+	Mode               string               `json:"mode"`
+	Routes             []manualRouteRequest `json:"routes"`
+}
+
+// HandleManualRoutePlan handles POST /api/v1/routes/manual
+//
+// The plan is costed as given, without any optimization, and opens a route
+// session so the usual ETAs, reports and edits work on it.
+func (h *Handler) HandleManualRoutePlan(w http.ResponseWriter, r *http.Request) {
+	var req ManualRoutePlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("[HTTP] POST /api/v1/routes/manual: invalid_json err=%v", err)
+		h.handleValidationError(w, messageInvalidRequestBody)
+		return
+	}
+	if len(req.Routes) == 0 {
+		h.handleValidationError(w, messageRoutesRequired)
+		return
+	}
+	if req.ActivityLocationID == 0 {
+		h.handleValidationError(w, messageChooseActivityLocationForEvent)
+		return
+	}
+	routeTime, err := parseRouteTime(req.RouteTime)
+	if err != nil {
+		h.handleValidationError(w, err.Error())
+		return
+	}
+	mode, err := normalizeRouteMode(req.Mode)
+	if err != nil {
+		h.handleValidationError(w, err.Error())
+		return
+	}
+
+	// Repeated IDs are reported by the plan check, so load each record once.
+	input := routeCalculationInput{ActivityLocationID: req.ActivityLocationID, RouteTime: routeTime, Mode: mode}
+	plan := make([]routing.ManualRoute, len(req.Routes))
+	for i, route := range req.Routes {
+		plan[i] = routing.ManualRoute{DriverID: route.DriverID, ParticipantIDs: route.ParticipantIDs}
+		input.DriverIDs = append(input.DriverIDs, route.DriverID)
+		input.ParticipantIDs = append(input.ParticipantIDs, route.ParticipantIDs...)
+	}
+	slices.Sort(input.DriverIDs)
+	input.DriverIDs = slices.Compact(input.DriverIDs)
+	slices.Sort(input.ParticipantIDs)
+	input.ParticipantIDs = slices.Compact(input.ParticipantIDs)
+	if len(input.ParticipantIDs) == 0 {
+		h.handleValidationError(w, messageSelectAtLeastOneParticipant)
+		return
+	}
+
+	log.Printf("[HTTP] POST /api/v1/routes/manual: routes=%d participants=%d mode=%s", len(plan), len(input.ParticipantIDs), mode)
+	settings, err := h.DB.Settings().Get(r.Context())
+	if err != nil {
+		h.handleInternalError(w, err)
+		return
+	}
+	selection, err := newRouteCalculation(h.DB, h.Router, h.RouteSession).loadSelection(r.Context(), input)
+	if err != nil {
+		if isRouteSelectionError(err) {
+			h.handleValidationError(w, routeCalculationValidationMessage(err))
+			return
+		}
+		h.handleInternalError(w, err)
+		return
+	}
+
+	request := routing.RoutingRequest{
+		InstituteCoords: selection.activityLocation.GetCoords(),
+		Participants:    selection.participants,
+		Drivers:         selection.drivers,
+		Mode:            mode,
+	}
+	result, err := routing.BuildManualPlan(r.Context(), h.DistanceCalc, &request, plan)
+	if err != nil {
+		if errors.Is(err, routing.ErrInvalidManualPlan) {
+			h.handleValidationError(w, err.Error())
+			return
+		}
+		log.Printf("[ERROR] Manual plan costing failed: err=%v", err)
+		h.handleRouteCalculationError(w, r, err)
+		return
+	}
+
+	snapshot := h.RouteSession.Create(routesession.CreateInput{
+		Routes: result.Routes, SelectedDrivers: selection.drivers, ActivityLocation: selection.activityLocation,
+		UseMiles: settings.UseMiles, RouteTime: routeTime, Mode: mode,
+	})
+	log.Printf("[HTTP] Manual plan costed: session=%s routes=%d total_distance=%.0f", snapshot.ID, len(result.Routes), result.Summary.TotalDistanceMeters)
+	h.writeRouteSession(w, r, snapshot)
+}
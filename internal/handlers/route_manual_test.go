@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"ride-home-router/internal/models"
+	"testing"
+)
+
+func TestHandleManualRoutePlanCostsPlanAndRejectsOverCapacity(t *testing.T) {
+	handler, store := newTestManagementHandler(t)
+	handler.DistanceCalc = routeEditDistanceCalculator{}
+	ctx := context.Background()
+
+	location, err := store.ActivityLocations().Create(ctx, &models.ActivityLocation{Name: "Gym", Address: "Gym"})
+	if err != nil {
+		t.Fatalf("create activity location: %v", err)
+	}
+	driver, err := store.Drivers().Create(ctx, &models.Driver{Name: "Driver", Address: "Home", Lat: 0, Lng: 2, VehicleCapacity: 1})
+	if err != nil {
+		t.Fatalf("create driver: %v", err)
+	}
+	var participantIDs []int64
+	for _, lat := range []float64{1, 2} {
+		participant, err := store.Participants().Create(ctx, &models.Participant{Name: "Rider", Address: "Stop", Lat: lat})
+		if err != nil {
+			t.Fatalf("create participant: %v", err)
+		}
+		participantIDs = append(participantIDs, participant.ID)
+	}
+	post := func(participants []int64) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(ManualRoutePlanRequest{
+			ActivityLocationID: location.ID, RouteTime: "18:30", Mode: string(models.RouteModeDropoff),
+			Routes: []manualRouteRequest{{DriverID: driver.ID, ParticipantIDs: participants}},
+		})
+		w := httptest.NewRecorder()
+		handler.HandleManualRoutePlan(w, httptest.NewRequestWithContext(ctx, http.MethodPost, "/api/v1/routes/manual", bytes.NewReader(body)))
+		return w
+	}
+
+	response := decodeRouteResponse(t, post(participantIDs[:1]))
+	if response.SessionID == "" || len(response.Routes) != 1 || len(response.Routes[0].Stops) != 1 {
+		t.Fatalf("response = %+v, want one session route with the one rider", response)
+	}
+	// Up to the rider, then diagonally to the driver's home.
+	want := 1000 + math.Sqrt(5)*1000
+	if math.Abs(response.Summary.TotalDistanceMeters-want) > 1e-6 || math.Abs(response.Summary.MaxDetourSecs-(want-2000)) > 1e-6 {
+		t.Fatalf("summary = %+v, want distance %v and detour %v", response.Summary, want, want-2000)
+	}
+
+	if w := post(participantIDs); w.Code != http.StatusBadRequest {
+		t.Fatalf("over-capacity status = %d, want 400; body=%s", w.Code, w.Body.String())
+	}
+}
@@ -1,6 +1,9 @@
 package handlers
 
-import "ride-home-router/internal/models"
+import (
+	"ride-home-router/internal/models"
+	"ride-home-router/internal/routing"
+)
 
 type routeModeValidationError struct {
 	cause error
@@ -15,7 +18,7 @@ func (e routeModeValidationError) Unwrap() error {
 }
 
 func normalizeRouteMode(value string) (models.RouteMode, error) {
-	mode, err := models.ParseRouteMode(value)
+	mode, err := routing.ParseRouteMode(value)
 	if err != nil {
 		return "", routeModeValidationError{cause: err}
 	}
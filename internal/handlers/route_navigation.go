@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"fmt"
+	"ride-home-router/internal/models"
+)
+
+// navigationPoint is one location a driver visits on a route.
+type navigationPoint struct {
+	Coords models.Coordinates
+	Name   string
+}
+
+// routeEndpoints returns where a route starts and ends: the activity and the
+// driver's home for dropoffs, and the reverse for pickups. Org vehicles, and
+// dropoff drivers who return to the activity, use the activity in place of
+// home. The activity and the route's driver must be set.
+func routeEndpoints(activity *models.ActivityLocation, route models.CalculatedRoute, mode models.RouteMode) (origin, destination navigationPoint) {
+	institute := navigationPoint{Coords: activity.GetCoords(), Name: activity.Name}
+	home := institute
+	if route.OrgVehicleID == 0 && !(mode == models.RouteModeDropoff && route.Driver.ReturnToInstitute) {
+		home = navigationPoint{Coords: route.Driver.GetCoords(), Name: route.Driver.Name + " (home)"}
+	}
+	if mode == models.RouteModePickup {
+		return home, institute
+	}
+	return institute, home
+}
+
+// routeStopPoints lists the locations a driver visits between a route's
+// endpoints, in driving order. Dropoffs visit a participant's intermediate
+// stop just before their own. Riders sharing a location are visited once,
+// under all their names. The GPX export and the navigation link both use it,
+// so they send the driver the same way.
+func routeStopPoints(route models.CalculatedRoute, mode models.RouteMode) []navigationPoint {
+	var points []navigationPoint
+	byKey := make(map[string]int)
+	for _, stop := range route.Stops {
+		participant := stop.Participant
+		if participant == nil {
+			continue
+		}
+		coords := []models.Coordinates{participant.GetCoords()}
+		if mode != models.RouteModePickup {
+			coords = participant.DropoffPoints()
+		}
+		for i, point := range coords {
+			name := participant.Name
+			if i < len(coords)-1 {
+				name += " (stop)"
+			}
+			key := fmt.Sprintf("%.5f,%.5f", point.Lat, point.Lng)
+			if index, ok := byKey[key]; ok {
+				points[index].Name += ", " + name
+				continue
+			}
+			byKey[key] = len(points)
+			points = append(points, navigationPoint{Coords: point, Name: name})
+		}
+	}
+	return points
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"ride-home-router/internal/routesession"
+)
+
+// maxPerturbAttempts bounds how many random moves one request may try.
+const maxPerturbAttempts = 200
+
+// HandlePerturbRouteSession handles POST /api/v1/routes/session/perturb
+//
+// It copies the session into a new one with a few random relocations and
+// swaps that keep the plan feasible and within tolerance of its total drive
+// time, so a coordinator can compare an alternative arrangement. The same
+// seed reproduces the same alternative.
+func (h *Handler) HandlePerturbRouteSession(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SessionID string  `json:"session_id"`
+		Seed      uint64  `json:"seed"`
+		Attempts  int     `json:"attempts"`
+		Tolerance float64 `json:"tolerance"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleValidationErrorHTMX(w, r, messageInvalidRequestBody)
+		return
+	}
+	if req.Attempts < 0 || req.Attempts > maxPerturbAttempts {
+		h.handleValidationErrorHTMX(w, r, messageInvalidPerturbAttempts)
+		return
+	}
+	if req.Tolerance < 0 {
+		h.handleValidationErrorHTMX(w, r, messageInvalidPerturbTolerance)
+		return
+	}
+
+	snapshot, err := h.RouteSession.Perturb(r.Context(), req.SessionID, routesession.PerturbOptions{
+		Seed:      req.Seed,
+		Attempts:  req.Attempts,
+		Tolerance: req.Tolerance,
+	})
+	if errors.Is(err, routesession.ErrUnbalanced) {
+		h.handleValidationErrorHTMX(w, r, messageRoutesMustBeBalancedBeforePerturbing)
+		return
+	}
+	if err != nil {
+		h.handleRouteSessionError(w, r, err)
+		return
+	}
+	log.Printf("[EDIT] Perturbed session %s into %s (seed=%d)", req.SessionID, snapshot.ID, req.Seed)
+	h.writeRouteSession(w, r, snapshot)
+}
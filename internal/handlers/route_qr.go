@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"ride-home-router/internal/httpx"
+	"ride-home-router/internal/models"
+	"ride-home-router/internal/qrcode"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// routeQRScale is how many pixels wide each QR module is drawn.
+const routeQRScale = 8
+
+// HandleRouteQRCode handles GET /api/v1/routes/edit/{session_id}/qr?driver={route_index}
+//
+// It returns a PNG QR code for one session route that opens turn-by-turn
+// navigation in the maps app chosen in settings, so a driver can scan it at
+// the activity.
+func (h *Handler) HandleRouteQRCode(w http.ResponseWriter, r *http.Request) {
+	id, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/routes/edit/"), "/qr")
+	if !ok || id == "" || strings.Contains(id, "/") {
+		h.handleNotFound(w, messageSessionNotFound)
+		return
+	}
+	routeIndex, err := strconv.Atoi(r.URL.Query().Get("driver"))
+	if err != nil {
+		h.handleValidationError(w, messageInvalidRouteIndex)
+		return
+	}
+	snapshot, ok := h.RouteSession.Snapshot(id)
+	if !ok {
+		h.handleNotFound(w, messageSessionNotFound)
+		return
+	}
+	if routeIndex < 0 || routeIndex >= len(snapshot.Routes) || snapshot.Routes[routeIndex].Driver == nil {
+		h.handleNotFound(w, messageRouteNotFound)
+		return
+	}
+	if snapshot.ActivityLocation == nil {
+		h.handleNotFound(w, messageSessionActivityLocationNotFound)
+		return
+	}
+
+	settings, err := h.DB.Settings().Get(r.Context())
+	if err != nil {
+		log.Printf("[ERROR] Failed to get settings for route QR code: err=%v", err)
+		h.handleInternalError(w, err)
+		return
+	}
+
+	link := navigationURL(settings.NavProvider, snapshot.ActivityLocation, snapshot.Routes[routeIndex], snapshot.Mode)
+	code, err := qrcode.Encode(link)
+	if err != nil {
+		log.Printf("[ERROR] Failed to encode route QR code: session=%s route=%d err=%v", id, routeIndex, err)
+		h.handleInternalError(w, err)
+		return
+	}
+	image, err := code.PNG(routeQRScale)
+	if err != nil {
+		h.handleInternalError(w, err)
+		return
+	}
+
+	log.Printf("[HTTP] GET /api/v1/routes/edit/{id}/qr: session=%s route=%d version=%d", id, routeIndex, code.Version)
+	w.Header().Set(httpx.HeaderContentType, httpx.MediaTypePNG)
+	_, _ = w.Write(image)
+}
+
+// navigationURL builds the directions link for a route in the provider's URL
+// format, matching generateMapsUrl in event-planner.js with navigation
+// enabled: the trip runs between routeEndpoints' origin and destination
+// through the stops routeStopPoints lists.
+// Google and Apple start from the phone's current location; OpenStreetMap has
+// no such start, so its link begins at the route's origin.
+func navigationURL(provider models.NavProvider, activity *models.ActivityLocation, route models.CalculatedRoute, mode models.RouteMode) string {
+	var stops []string
+	for _, point := range routeStopPoints(route, mode) {
+		stops = append(stops, coordinateParam(point.Coords))
+	}
+
+	start, end := routeEndpoints(activity, route, mode)
+	origin, destination := coordinateParam(start.Coords), coordinateParam(end.Coords)
+
+	switch provider {
+	case models.NavProviderApple:
+		points := make([]string, 0, len(stops)+1)
+		for _, point := range slices.Concat(stops, []string{destination}) {
+			points = append(points, url.QueryEscape(point))
+		}
+		return "https://maps.apple.com/?daddr=" + strings.Join(points, "+to:") + "&dirflg=d"
+	case models.NavProviderOSM:
+		params := url.Values{}
+		params.Set("engine", "fossgis_osrm_car")
+		params.Set("route", strings.Join(slices.Concat([]string{origin}, stops, []string{destination}), ";"))
+		return "https://www.openstreetmap.org/directions?" + params.Encode()
+	}
+
+	params := url.Values{}
+	params.Set("api", "1")
+	params.Set("travelmode", "driving")
+	params.Set("destination", destination)
+	params.Set("dir_action", "navigate")
+	if len(stops) > 0 {
+		params.Set("waypoints", strings.Join(stops, "|"))
+	}
+	return "https://www.google.com/maps/dir/?" + params.Encode()
+}
+
+func coordinateParam(coords models.Coordinates) string {
+	return strconv.FormatFloat(coords.Lat, 'f', -1, 64) + "," + strconv.FormatFloat(coords.Lng, 'f', -1, 64)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"ride-home-router/internal/models"
+	"ride-home-router/internal/qrcode"
+	"ride-home-router/internal/routesession"
+	"testing"
+)
+
+func TestHandleRouteQRCodeEncodesNavigationLink(t *testing.T) {
+	h, _ := newTestRouteHandler(t)
+	driver := models.Driver{ID: 1, Name: "Driver", Lat: 41.35, Lng: -72.95, VehicleCapacity: 3}
+	created := h.RouteSession.Create(routesession.CreateInput{
+		Routes: []models.CalculatedRoute{{Driver: &driver, EffectiveCapacity: 3, Stops: []models.RouteStop{
+			{Participant: &models.Participant{ID: 10, Name: "Sibling A", Lat: 41.3, Lng: -72.9}},
+			{Participant: &models.Participant{ID: 11, Name: "Sibling B", Lat: 41.3, Lng: -72.9}},
+			{Participant: &models.Participant{ID: 12, Name: "Neighbor", Lat: 41.32, Lng: -72.91}},
+		}}},
+		SelectedDrivers:  []models.Driver{driver},
+		ActivityLocation: &models.ActivityLocation{ID: 1, Name: "HQ", Lat: 41.31, Lng: -72.92},
+		RouteTime:        "18:30",
+		Mode:             models.RouteModeDropoff,
+	})
+
+	w := httptest.NewRecorder()
+	h.HandleRouteSessionReport(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/routes/edit/"+created.ID+"/qr?driver=0", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); got != "image/png" {
+		t.Fatalf("content type = %q, want image/png", got)
+	}
+	if _, err := png.Decode(bytes.NewReader(w.Body.Bytes())); err != nil {
+		t.Fatalf("response is not a PNG: %v", err)
+	}
+
+	wantURL := "https://www.google.com/maps/dir/?api=1&destination=41.35%2C-72.95&dir_action=navigate&travelmode=driving&waypoints=41.3%2C-72.9%7C41.32%2C-72.91"
+	if got := navigationURL(models.NavProviderGoogle, created.ActivityLocation, created.Routes[0], created.Mode); got != wantURL {
+		t.Fatalf("navigation URL = %q, want %q", got, wantURL)
+	}
+	code, err := qrcode.Encode(wantURL)
+	if err != nil {
+		t.Fatalf("encode expected URL: %v", err)
+	}
+	want, err := code.PNG(routeQRScale)
+	if err != nil {
+		t.Fatalf("render expected URL: %v", err)
+	}
+	if !bytes.Equal(w.Body.Bytes(), want) {
+		t.Fatal("QR code does not encode the route's navigation link")
+	}
+
+	unplanned := h.RouteSession.Create(routesession.CreateInput{
+		Routes: []models.CalculatedRoute{{Driver: &driver, EffectiveCapacity: 3}, {EffectiveCapacity: 3}},
+		Mode:   models.RouteModeDropoff,
+	})
+	for path, want := range map[string]int{
+		"/api/v1/routes/edit/" + created.ID + "/qr?driver=first": http.StatusBadRequest,
+		"/api/v1/routes/edit/" + created.ID + "/qr?driver=1":     http.StatusNotFound,
+		"/api/v1/routes/edit/" + created.ID + "/qr?driver=-1":    http.StatusNotFound,
+		"/api/v1/routes/edit/missing/qr?driver=0":                http.StatusNotFound,
+		"/api/v1/routes/edit/" + unplanned.ID + "/qr?driver=1":   http.StatusNotFound,
+		"/api/v1/routes/edit/" + unplanned.ID + "/qr?driver=0":   http.StatusNotFound,
+	} {
+		w = httptest.NewRecorder()
+		h.HandleRouteSessionReport(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, path, nil))
+		if w.Code != want {
+			t.Fatalf("%s status = %d, want %d", path, w.Code, want)
+		}
+	}
+}
+
+func TestNavigationURLEndsAtTheActivityWhenTheDriverReturnsThere(t *testing.T) {
+	activity := &models.ActivityLocation{ID: 1, Name: "HQ", Lat: 41.31, Lng: -72.92}
+	route := models.CalculatedRoute{
+		Driver: &models.Driver{ID: 1, Name: "Driver", Lat: 41.35, Lng: -72.95, ReturnToInstitute: true},
+		Stops:  []models.RouteStop{{Participant: &models.Participant{ID: 10, Name: "First", Lat: 41.3, Lng: -72.9}}},
+	}
+	want := "https://www.google.com/maps/dir/?api=1&destination=41.31%2C-72.92&dir_action=navigate&travelmode=driving&waypoints=41.3%2C-72.9"
+	if got := navigationURL(models.NavProviderGoogle, activity, route, models.RouteModeDropoff); got != want {
+		t.Fatalf("navigation URL = %q, want %q", got, want)
+	}
+	doc := buildRouteGPX(activity, route, models.RouteModeDropoff)
+	if last := doc.Route.Points[len(doc.Route.Points)-1]; last.Name != "HQ" {
+		t.Fatalf("GPX ends at %+v, want the activity like the navigation link", last)
+	}
+}
+
+func TestNavigationURLFormatsEachProvider(t *testing.T) {
+	activity := &models.ActivityLocation{ID: 1, Name: "HQ", Lat: 41.31, Lng: -72.92}
+	route := models.CalculatedRoute{
+		Driver: &models.Driver{ID: 1, Name: "Driver", Lat: 41.35, Lng: -72.95},
+		Stops: []models.RouteStop{
+			{Participant: &models.Participant{ID: 10, Name: "First", Lat: 41.3, Lng: -72.9}},
+			{Participant: &models.Participant{ID: 11, Name: "Second", Lat: 41.32, Lng: -72.91}},
+		},
+	}
+
+	tests := []struct {
+		provider models.NavProvider
+		mode     models.RouteMode
+		want     string
+	}{
+		{models.NavProviderGoogle, models.RouteModeDropoff, "https://www.google.com/maps/dir/?api=1&destination=41.35%2C-72.95&dir_action=navigate&travelmode=driving&waypoints=41.3%2C-72.9%7C41.32%2C-72.91"},
+		{"", models.RouteModeDropoff, "https://www.google.com/maps/dir/?api=1&destination=41.35%2C-72.95&dir_action=navigate&travelmode=driving&waypoints=41.3%2C-72.9%7C41.32%2C-72.91"},
+		{models.NavProviderApple, models.RouteModeDropoff, "https://maps.apple.com/?daddr=41.3%2C-72.9+to:41.32%2C-72.91+to:41.35%2C-72.95&dirflg=d"},
+		{models.NavProviderOSM, models.RouteModeDropoff, "https://www.openstreetmap.org/directions?engine=fossgis_osrm_car&route=41.31%2C-72.92%3B41.3%2C-72.9%3B41.32%2C-72.91%3B41.35%2C-72.95"},
+		{models.NavProviderApple, models.RouteModePickup, "https://maps.apple.com/?daddr=41.3%2C-72.9+to:41.32%2C-72.91+to:41.31%2C-72.92&dirflg=d"},
+		{models.NavProviderOSM, models.RouteModePickup, "https://www.openstreetmap.org/directions?engine=fossgis_osrm_car&route=41.35%2C-72.95%3B41.3%2C-72.9%3B41.32%2C-72.91%3B41.31%2C-72.92"},
+	}
+	for _, tt := range tests {
+		if got := navigationURL(tt.provider, activity, route, tt.mode); got != tt.want {
+			t.Errorf("navigationURL(%q, %s) = %q, want %q", tt.provider, tt.mode, got, tt.want)
+		}
+	}
+}
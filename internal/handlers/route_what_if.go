@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"ride-home-router/internal/models"
+	"ride-home-router/internal/routing"
+	"strings"
+)
+
+// hypotheticalDriverID marks the unsaved driver in what-if results. Stored
+// drivers always have positive IDs.
+const hypotheticalDriverID int64 = -1
+
+// WhatIfDriverRequest is the current route selection plus a driver who has
+// not been recruited yet.
+type WhatIfDriverRequest struct {
+	ParticipantIDs     []int64 `json:"participant_ids"`
+	DriverIDs          []int64 `json:"driver_ids"`
+	ActivityLocationID int64   `json:"activity_location_id"`
+	Mode               string  `json:"mode"`
+	DriverAddress      string  `json:"driver_address"`
+	VehicleCapacity    int     `json:"vehicle_capacity"`
+}
+
+// WhatIfDriverResponse compares the selection's summary with and without the
+// hypothetical driver. Baseline is nil when the current drivers cannot cover
+// every participant.
+type WhatIfDriverResponse struct {
+	HypotheticalDriver models.Driver          `json:"hypothetical_driver"`
+	Baseline           *models.RoutingSummary `json:"baseline"`
+	WithDriver         models.RoutingSummary  `json:"with_driver"`
+	HypotheticalUsed   bool                   `json:"hypothetical_driver_used"`
+}
+
+// HandleWhatIfDriver handles POST /api/v1/routes/what-if-driver
+//
+// It geocodes the hypothetical driver's address and routes the selection
+// twice, once without and once with that driver. Nothing is persisted and no
+// route session is created.
+func (h *Handler) HandleWhatIfDriver(w http.ResponseWriter, r *http.Request) {
+	var req WhatIfDriverRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("[HTTP] POST /api/v1/routes/what-if-driver: invalid_json err=%v", err)
+		h.handleValidationError(w, messageInvalidRequestBody)
+		return
+	}
+	req.DriverAddress = strings.TrimSpace(req.DriverAddress)
+
+	if len(req.ParticipantIDs) == 0 {
+		h.handleValidationError(w, messageSelectAtLeastOneParticipant)
+		return
+	}
+	if req.ActivityLocationID == 0 {
+		h.handleValidationError(w, messageChooseActivityLocationForEvent)
+		return
+	}
+	if req.DriverAddress == "" {
+		h.handleValidationError(w, messageAddressRequired)
+		return
+	}
+	if req.VehicleCapacity <= 0 {
+		h.handleValidationError(w, messageVehicleCapacityMustBeGreaterThanZero)
+		return
+	}
+	mode, err := normalizeRouteMode(req.Mode)
+	if err != nil {
+		h.handleValidationError(w, err.Error())
+		return
+	}
+
+	log.Printf("[HTTP] POST /api/v1/routes/what-if-driver: participants=%d drivers=%d mode=%s capacity=%d", len(req.ParticipantIDs), len(req.DriverIDs), mode, req.VehicleCapacity)
+	geocodeResult, err := h.Geocoder.GeocodeWithRetry(r.Context(), req.DriverAddress, 3)
+	if err != nil {
+		log.Printf("[ERROR] Failed to geocode hypothetical driver address: address=%s err=%v", req.DriverAddress, err)
+		h.handleGeocodingError(w, err)
+		return
+	}
+	hypothetical := models.Driver{
+		ID:              hypotheticalDriverID,
+		Name:            "Hypothetical driver",
+		Address:         req.DriverAddress,
+		Lat:             geocodeResult.Coords.Lat,
+		Lng:             geocodeResult.Coords.Lng,
+		VehicleCapacity: req.VehicleCapacity,
+	}
+
+	calculation := newRouteCalculation(h.DB, h.Router, h.RouteSession)
+	input := routeCalculationInput{
+		ParticipantIDs:     req.ParticipantIDs,
+		DriverIDs:          req.DriverIDs,
+		ActivityLocationID: req.ActivityLocationID,
+		Mode:               mode,
+		Preview:            true,
+	}
+
+	// Both runs read the same points, plus the hypothetical driver, so fetch
+	// their distances once. Selection errors are reported by calculate.
+	if selection, err := calculation.loadSelection(r.Context(), input); err == nil {
+		input.Distances = h.shareDistanceMatrix(r.Context(), &routing.RoutingRequest{
+			InstituteCoords: selection.activityLocation.GetCoords(),
+			Participants:    selection.participants,
+			Drivers:         append(selection.drivers, hypothetical),
+		})
+	}
+
+	var baseline *models.RoutingSummary
+	baselineOutcome := calculation.calculate(r.Context(), input)
+	if !h.handleWhatIfOutcome(w, r, baselineOutcome, true) {
+		return
+	}
+	if baselineOutcome.Kind == routeCalculationSuccess {
+		baseline = &baselineOutcome.Result.Summary
+	}
+
+	input.ExtraDrivers = []models.Driver{hypothetical}
+	withDriverOutcome := calculation.calculate(r.Context(), input)
+	if !h.handleWhatIfOutcome(w, r, withDriverOutcome, false) {
+		return
+	}
+
+	result := withDriverOutcome.Result
+	response := WhatIfDriverResponse{
+		HypotheticalDriver: hypothetical,
+		Baseline:           baseline,
+		WithDriver:         result.Summary,
+	}
+	for _, route := range result.Routes {
+		if route.Driver != nil && route.Driver.ID == hypotheticalDriverID && len(route.Stops) > 0 {
+			response.HypotheticalUsed = true
+			break
+		}
+	}
+
+	log.Printf("[HTTP] What-if driver calculated: baseline_available=%t max_detour=%.0f used=%t", baseline != nil, result.Summary.MaxDetourSecs, response.HypotheticalUsed)
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+// handleWhatIfOutcome writes the error response for a failed calculation and
+// reports whether the handler should continue. A capacity shortage is only
+// tolerated for the baseline, which the extra driver may resolve.
+func (h *Handler) handleWhatIfOutcome(w http.ResponseWriter, r *http.Request, outcome routeCalculationOutcome, allowShortage bool) bool {
+	switch outcome.Kind {
+	case routeCalculationSuccess:
+		return true
+	case routeCalculationShortage:
+		if allowShortage {
+			return true
+		}
+		h.handleRoutingError(w, outcome.Shortage.RoutingError)
+	case routeCalculationValidationFailure:
+		h.handleValidationError(w, routeCalculationValidationMessage(outcome.Err))
+	case routeCalculationRouteFailure:
+		log.Printf("[ERROR] What-if route calculation failed: err=%v", outcome.Err)
+		h.handleRouteCalculationError(w, r, outcome.Err)
+	default:
+		h.handleInternalError(w, outcome.Err)
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"ride-home-router/internal/geocoding"
+	"ride-home-router/internal/models"
+	"ride-home-router/internal/routing"
+	"testing"
+)
+
+func TestHandleWhatIfDriverReducesMaxDetourWithoutPersisting(t *testing.T) {
+	handler, store := newTestManagementHandler(t)
+	handler.Router = routing.NewBalancedRouter(routeEditDistanceCalculator{})
+	handler.Geocoder = stubGeocoder{result: &geocoding.GeocodingResult{Coords: models.Coordinates{Lat: 10, Lng: 0.5}}}
+	ctx := context.Background()
+
+	location, err := store.ActivityLocations().Create(ctx, &models.ActivityLocation{Name: "Gym", Address: "Gym", Lat: 0, Lng: 0})
+	if err != nil {
+		t.Fatalf("create activity location: %v", err)
+	}
+	driver, err := store.Drivers().Create(ctx, &models.Driver{Name: "Near", Address: "Near", Lat: 0, Lng: 1, VehicleCapacity: 2})
+	if err != nil {
+		t.Fatalf("create driver: %v", err)
+	}
+	var participantIDs []int64
+	for _, coords := range []models.Coordinates{{Lat: 10, Lng: 0}, {Lat: 10, Lng: 1}} {
+		participant, err := store.Participants().Create(ctx, &models.Participant{Name: "Far", Address: "Far", Lat: coords.Lat, Lng: coords.Lng})
+		if err != nil {
+			t.Fatalf("create participant: %v", err)
+		}
+		participantIDs = append(participantIDs, participant.ID)
+	}
+
+	body, _ := json.Marshal(WhatIfDriverRequest{
+		ParticipantIDs:     participantIDs,
+		DriverIDs:          []int64{driver.ID},
+		ActivityLocationID: location.ID,
+		Mode:               string(models.RouteModeDropoff),
+		DriverAddress:      "Far Street",
+		VehicleCapacity:    2,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/routes/what-if-driver", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.HandleWhatIfDriver(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body=%s", w.Code, w.Body.String())
+	}
+	var response WhatIfDriverResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if response.Baseline == nil {
+		t.Fatal("expected a baseline summary for a feasible selection")
+	}
+	if !response.HypotheticalUsed {
+		t.Fatal("expected the well-placed hypothetical driver to be used")
+	}
+	if response.WithDriver.MaxDetourSecs >= response.Baseline.MaxDetourSecs {
+		t.Fatalf("max detour with driver = %.0f, want below baseline %.0f", response.WithDriver.MaxDetourSecs, response.Baseline.MaxDetourSecs)
+	}
+
+	drivers, err := store.Drivers().List(ctx, "")
+	if err != nil {
+		t.Fatalf("list drivers: %v", err)
+	}
+	if len(drivers) != 1 {
+		t.Fatalf("drivers = %d, want hypothetical driver not persisted", len(drivers))
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"html"
@@ -8,18 +9,65 @@ import (
 	"net/http"
 	"ride-home-router/internal/distance"
 	"ride-home-router/internal/httpx"
+	"ride-home-router/internal/models"
+	"ride-home-router/internal/routing"
 	"strconv"
 	"strings"
 	"time"
 )
 
-// CalculateRoutesRequest represents the request for route calculation
+// CalculateRoutesRequest represents the request for route calculation.
+// MaxParticipantRideSecs caps any one participant's time in the car; zero
+// means no cap. MaxDetourSecs is a fairness ceiling on driver detours; with
+// DeclineOverDetour the riders that break it are returned as unassigned
+// instead of failing the calculation. Metric balances routes by "duration"
+// (the default) or by driven "distance". In pickup mode PickupObjective
+// "max_ride" minimizes the longest time any rider spends in the car instead of
+// the arrival time. CompactDepartures applies the swaps
+// suggested for dropoff routes whose first stops are neighbors.
+// MaxNeighborhoods softly caps how many distinct neighborhoods one route
+// visits; zero means no cap. YoungestDroppedFirst breaks ties between equally
+// good dropoff orders by dropping younger participants earlier.
+// HouseholdSplit picks who splits off first from a household no car can
+// hold: "id" (the default), "youngest" or "oldest". DetourFairness balances
+// driver detours in "absolute" time (the default) or as a "ratio" of each
+// driver's direct trip. ExcludeHomeLeg leaves each driver's drive home, or
+// from home in pickup mode, out of the optimization; it is off by default.
+// MaxDetourWeight, SumDetourWeight and UnusedDriverPenalty weigh driver
+// detours and idle drivers as one cost; all zero keeps the default order.
+// BusStops, JSON only, routes vehicles over the given stops: participants
+// meet at the nearest one within MaxWalkMeters of home (800 m when zero).
+// RotateDrivers favors drivers with the fewest routes in recent saved events.
+// PreferNearbyDrivers fills the drivers nearest the participants first and
+// leaves farther ones idle when they are not needed. IncludeGeometry adds
+// each stop's road path as an encoded polyline. AvoidHighways keeps routes
+// off motorways; it needs OSRM distances.
 type CalculateRoutesRequest struct {
-	ParticipantIDs     []int64 `json:"participant_ids"`
-	DriverIDs          []int64 `json:"driver_ids"`
-	ActivityLocationID int64   `json:"activity_location_id"`
-	RouteTime          string  `json:"route_time"`
-	Mode               string  `json:"mode"`
+	ParticipantIDs         []int64              `json:"participant_ids"`
+	DriverIDs              []int64              `json:"driver_ids"`
+	ActivityLocationID     int64                `json:"activity_location_id"`
+	RouteTime              string               `json:"route_time"`
+	Mode                   string               `json:"mode"`
+	Metric                 string               `json:"metric,omitempty"`
+	PickupObjective        string               `json:"pickup_objective,omitempty"`
+	MaxParticipantRideSecs float64              `json:"max_participant_ride_secs,omitempty"`
+	MaxDetourSecs          float64              `json:"max_detour_secs,omitempty"`
+	DeclineOverDetour      bool                 `json:"decline_over_detour,omitempty"`
+	CompactDepartures      bool                 `json:"compact_departures,omitempty"`
+	MaxNeighborhoods       int                  `json:"max_neighborhoods,omitempty"`
+	YoungestDroppedFirst   bool                 `json:"youngest_dropped_first,omitempty"`
+	HouseholdSplit         string               `json:"household_split,omitempty"`
+	DetourFairness         string               `json:"detour_fairness,omitempty"`
+	ExcludeHomeLeg         bool                 `json:"exclude_home_leg,omitempty"`
+	MaxDetourWeight        float64              `json:"max_detour_weight,omitempty"`
+	SumDetourWeight        float64              `json:"sum_detour_weight,omitempty"`
+	UnusedDriverPenalty    float64              `json:"unused_driver_penalty,omitempty"`
+	BusStops               []models.Coordinates `json:"bus_stops,omitempty"`
+	MaxWalkMeters          float64              `json:"max_walk_meters,omitempty"`
+	RotateDrivers          bool                 `json:"rotate_drivers,omitempty"`
+	PreferNearbyDrivers    bool                 `json:"prefer_nearby_drivers,omitempty"`
+	IncludeGeometry        bool                 `json:"include_geometry,omitempty"`
+	AvoidHighways          bool                 `json:"avoid_highways,omitempty"`
 }
 
 func parseRouteTime(value string) (string, error) {
@@ -73,6 +121,56 @@ func (h *Handler) HandleCalculateRoutes(w http.ResponseWriter, r *http.Request)
 		}
 		req.RouteTime = r.FormValue("route_time")
 		req.Mode = r.FormValue("mode")
+		req.Metric = r.FormValue("metric")
+		req.PickupObjective = r.FormValue("pickup_objective")
+		if value := r.FormValue("max_participant_ride_secs"); value != "" {
+			maxRideSecs, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				h.handleValidationErrorHTMX(w, r, messageInvalidMaxParticipantRide)
+				return
+			}
+			req.MaxParticipantRideSecs = maxRideSecs
+		}
+		if value := r.FormValue("max_detour_secs"); value != "" {
+			maxDetourSecs, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				h.handleValidationErrorHTMX(w, r, messageInvalidMaxDetour)
+				return
+			}
+			req.MaxDetourSecs = maxDetourSecs
+		}
+		req.DeclineOverDetour = r.FormValue("decline_over_detour") != ""
+		req.CompactDepartures = r.FormValue("compact_departures") != ""
+		if value := r.FormValue("max_neighborhoods"); value != "" {
+			maxNeighborhoods, err := strconv.Atoi(value)
+			if err != nil {
+				h.handleValidationErrorHTMX(w, r, messageInvalidMaxNeighborhoods)
+				return
+			}
+			req.MaxNeighborhoods = maxNeighborhoods
+		}
+		req.YoungestDroppedFirst = r.FormValue("youngest_dropped_first") != ""
+		req.HouseholdSplit = r.FormValue("household_split")
+		req.DetourFairness = r.FormValue("detour_fairness")
+		req.ExcludeHomeLeg = r.FormValue("exclude_home_leg") != ""
+		req.RotateDrivers = r.FormValue("rotate_drivers") != ""
+		req.PreferNearbyDrivers = r.FormValue("prefer_nearby_drivers") != ""
+		req.IncludeGeometry = r.FormValue("include_geometry") != ""
+		req.AvoidHighways = r.FormValue("avoid_highways") != ""
+		for name, weight := range map[string]*float64{
+			"max_detour_weight":     &req.MaxDetourWeight,
+			"sum_detour_weight":     &req.SumDetourWeight,
+			"unused_driver_penalty": &req.UnusedDriverPenalty,
+		} {
+			if value := r.FormValue(name); value != "" {
+				parsed, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					h.handleValidationErrorHTMX(w, r, messageInvalidFairnessWeight)
+					return
+				}
+				*weight = parsed
+			}
+		}
 
 		log.Printf("[HTTP] POST /api/v1/routes/calculate: form_data participants=%v drivers=%v", req.ParticipantIDs, req.DriverIDs)
 	} else {
@@ -90,6 +188,13 @@ func (h *Handler) HandleCalculateRoutes(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	activeIDs, err := h.activeDriverIDs(r.Context(), req.DriverIDs)
+	if err != nil {
+		h.handleInternalError(w, err)
+		return
+	}
+	req.DriverIDs = activeIDs
+
 	if len(req.DriverIDs) == 0 {
 		log.Printf("[HTTP] POST /api/v1/routes/calculate: missing drivers")
 		h.handleValidationErrorHTMX(w, r, messageSelectAtLeastOneDriver)
@@ -108,13 +213,63 @@ func (h *Handler) HandleCalculateRoutes(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	metric, err := routing.ParseOptimizationMetric(req.Metric)
+	if err != nil {
+		h.handleValidationErrorHTMX(w, r, messageInvalidOptimizationMetric)
+		return
+	}
+	pickupObjective, err := routing.ParsePickupObjective(req.PickupObjective)
+	if err != nil {
+		h.handleValidationErrorHTMX(w, r, messageInvalidPickupObjective)
+		return
+	}
+	householdSplit, err := routing.ParseHouseholdSplit(req.HouseholdSplit)
+	if err != nil {
+		h.handleValidationErrorHTMX(w, r, messageInvalidHouseholdSplit)
+		return
+	}
+	detourFairness, err := routing.ParseDetourFairness(req.DetourFairness)
+	if err != nil {
+		h.handleValidationErrorHTMX(w, r, messageInvalidDetourFairness)
+		return
+	}
+
+	if req.MaxParticipantRideSecs < 0 {
+		h.handleValidationErrorHTMX(w, r, messageInvalidMaxParticipantRide)
+		return
+	}
+	if req.MaxDetourSecs < 0 {
+		h.handleValidationErrorHTMX(w, r, messageInvalidMaxDetour)
+		return
+	}
+	if req.MaxNeighborhoods < 0 {
+		h.handleValidationErrorHTMX(w, r, messageInvalidMaxNeighborhoods)
+		return
+	}
+	if req.MaxDetourWeight < 0 || req.SumDetourWeight < 0 || req.UnusedDriverPenalty < 0 {
+		h.handleValidationErrorHTMX(w, r, messageInvalidFairnessWeight)
+		return
+	}
+	if req.MaxWalkMeters < 0 {
+		h.handleValidationErrorHTMX(w, r, messageInvalidMaxWalk)
+		return
+	}
+
 	orgVehicleAssignments, err := parseOrgVehicleAssignments(r.Form, req.DriverIDs)
 	if err != nil {
 		h.handleValidationErrorHTMX(w, r, err.Error())
 		return
 	}
+	var distances distance.DistanceCalculator
+	if req.AvoidHighways {
+		if h.AvoidHighwaysCalc == nil {
+			h.handleValidationErrorHTMX(w, r, messageAvoidHighwaysUnavailable)
+			return
+		}
+		distances = h.AvoidHighwaysCalc
+	}
 
-	log.Printf("[HTTP] POST /api/v1/routes/calculate: participants=%d drivers=%d mode=%s", len(req.ParticipantIDs), len(req.DriverIDs), mode)
+	log.Printf("[HTTP] POST /api/v1/routes/calculate: participants=%d drivers=%d mode=%s metric=%s", len(req.ParticipantIDs), len(req.DriverIDs), mode, metric)
 
 	activityLocationID := req.ActivityLocationID
 	if activityLocationID == 0 {
@@ -122,12 +277,35 @@ func (h *Handler) HandleCalculateRoutes(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	outcome := newRouteCalculation(h.DB, h.Router, h.RouteSession).calculate(r.Context(), routeCalculationInput{
-		ParticipantIDs:        req.ParticipantIDs,
-		DriverIDs:             req.DriverIDs,
-		ActivityLocationID:    activityLocationID,
-		RouteTime:             routeTime,
-		Mode:                  mode,
-		OrgVehicleAssignments: orgVehicleAssignments,
+		ParticipantIDs:         req.ParticipantIDs,
+		DriverIDs:              req.DriverIDs,
+		ActivityLocationID:     activityLocationID,
+		RouteTime:              routeTime,
+		Mode:                   mode,
+		Metric:                 metric,
+		PickupObjective:        pickupObjective,
+		OrgVehicleAssignments:  orgVehicleAssignments,
+		MaxParticipantRideSecs: req.MaxParticipantRideSecs,
+		MaxDetourSecs:          req.MaxDetourSecs,
+		DeclineOverDetour:      req.DeclineOverDetour,
+		CompactDepartures:      req.CompactDepartures,
+		MaxNeighborhoods:       req.MaxNeighborhoods,
+		YoungestDroppedFirst:   req.YoungestDroppedFirst,
+		HouseholdSplit:         householdSplit,
+		DetourFairness:         detourFairness,
+		ExcludeHomeLeg:         req.ExcludeHomeLeg,
+		FairnessWeights: routing.FairnessWeights{
+			MaxDetourWeight:     req.MaxDetourWeight,
+			SumDetourWeight:     req.SumDetourWeight,
+			UnusedDriverPenalty: req.UnusedDriverPenalty,
+		},
+		BusStops:            req.BusStops,
+		MaxWalkMeters:       req.MaxWalkMeters,
+		RotateDrivers:       req.RotateDrivers,
+		PreferNearbyDrivers: req.PreferNearbyDrivers,
+		IncludeGeometry:     req.IncludeGeometry,
+		Distances:           distances,
+		AvoidHighways:       req.AvoidHighways,
 	})
 	if outcome.Kind == routeCalculationValidationFailure {
 		message := routeCalculationValidationMessage(outcome.Err)
@@ -177,16 +355,31 @@ func (h *Handler) HandleCalculateRoutes(w http.ResponseWriter, r *http.Request)
 
 	// Return HTML for htmx, JSON for API calls
 	if h.isHTMX(r) {
-		h.setHTMXToast(w, messageRoutesCalculated(result.Summary.TotalDriversUsed), toastTypeSuccess)
+		if len(result.DriversAtActivity) > 0 {
+			h.setHTMXToast(w, messageDriversAtActivity(len(result.DriversAtActivity)), toastTypeWarning)
+		} else if len(result.Warnings) > 0 {
+			h.setHTMXToast(w, result.Warnings[0], toastTypeWarning)
+		} else {
+			h.setHTMXToast(w, messageRoutesCalculated(result.Summary.TotalDriversUsed), toastTypeSuccess)
+		}
 		h.renderTemplate(w, "route_results", buildRouteResultsView(session))
 		return
 	}
 
 	h.writeJSON(w, http.StatusOK, RouteCalculationResponse{
-		Routes:    result.Routes,
-		Summary:   result.Summary,
-		SessionID: session.ID,
-		Mode:      mode,
+		Routes:               result.Routes,
+		Summary:              result.Summary,
+		SessionID:            session.ID,
+		Mode:                 mode,
+		Version:              session.Version,
+		Unassigned:           result.Unassigned,
+		DriverManifest:       buildDriverManifest(result.Routes),
+		DepartureCollisions:  result.DepartureCollisions,
+		DriversAtActivity:    result.DriversAtActivity,
+		ForcedOrderConflicts: result.ForcedOrderConflicts,
+		Warnings:             result.Warnings,
+		BusStops:             result.BusStops,
+		DoorToDoor:           result.DoorToDoor,
 	})
 }
 
@@ -343,3 +536,36 @@ func (h *Handler) handleRouteCalculationError(w http.ResponseWriter, r *http.Req
 
 	h.writeError(w, status, code, message, nil)
 }
+
+// activeDriverIDs drops selected drivers marked inactive, logging which were
+// left out. IDs with no stored driver are kept so the calculation still
+// reports them as missing.
+func (h *Handler) activeDriverIDs(ctx context.Context, ids []int64) ([]int64, error) {
+	if len(ids) == 0 {
+		return ids, nil
+	}
+	drivers, err := h.DB.Drivers().GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	inactive := make(map[int64]bool)
+	for _, driver := range drivers {
+		if driver.Inactive {
+			inactive[driver.ID] = true
+		}
+	}
+	if len(inactive) == 0 {
+		return ids, nil
+	}
+	active := make([]int64, 0, len(ids))
+	var dropped []int64
+	for _, id := range ids {
+		if inactive[id] {
+			dropped = append(dropped, id)
+			continue
+		}
+		active = append(active, id)
+	}
+	log.Printf("[HTTP] POST /api/v1/routes/calculate: dropped inactive drivers=%v", dropped)
+	return active, nil
+}
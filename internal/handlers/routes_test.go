@@ -9,6 +9,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"path/filepath"
+	"reflect"
 	"ride-home-router/internal/database"
 	"ride-home-router/internal/distance"
 	"ride-home-router/internal/models"
@@ -98,7 +99,7 @@ func TestHandleCalculateRoutes_JSONPickupPropagatesTypedMode(t *testing.T) {
 	}
 	handler.Router = router
 
-	body := `{"participant_ids":[` + int64ToString(participant.ID) + `],"driver_ids":[` + int64ToString(driver.ID) + `],"activity_location_id":` + int64ToString(location.ID) + `,"route_time":"18:30","mode":"pickup"}`
+	body := `{"participant_ids":[` + int64ToString(participant.ID) + `],"driver_ids":[` + int64ToString(driver.ID) + `],"activity_location_id":` + int64ToString(location.ID) + `,"route_time":"18:30","mode":"pickup","metric":"distance"}`
 	req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/routes/calculate", strings.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
 	rr := httptest.NewRecorder()
@@ -114,6 +115,9 @@ func TestHandleCalculateRoutes_JSONPickupPropagatesTypedMode(t *testing.T) {
 	if router.lastRequest.Mode != models.RouteModePickup {
 		t.Fatalf("expected pickup mode, got %q", router.lastRequest.Mode)
 	}
+	if router.lastRequest.Metric != routing.MetricDistance {
+		t.Fatalf("expected distance metric, got %q", router.lastRequest.Metric)
+	}
 
 	var resp RouteCalculationResponse
 	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
@@ -128,6 +132,74 @@ func TestHandleCalculateRoutes_JSONPickupPropagatesTypedMode(t *testing.T) {
 	}
 }
 
+func TestHandleCalculateRoutes_JSONDriverManifestMatchesRoutes(t *testing.T) {
+	handler, store := newTestRouteHandler(t)
+	ctx := context.Background()
+
+	var riders []*models.Participant
+	for i, name := range []string{"Ava", "Ben", "Cy"} {
+		rider, err := store.Participants().Create(ctx, &models.Participant{Name: name, Address: fmt.Sprintf("%d Rider Rd", i+1), Lat: 40.1 + float64(i)/10, Lng: -73.9})
+		if err != nil {
+			t.Fatalf("create participant: %v", err)
+		}
+		riders = append(riders, rider)
+	}
+	var drivers []*models.Driver
+	for i, name := range []string{"Dee", "Eli", "Fay"} {
+		driver, err := store.Drivers().Create(ctx, &models.Driver{Name: name, Address: fmt.Sprintf("%d Driver Rd", i+1), Lat: 40.2, Lng: -73.8, VehicleCapacity: 2})
+		if err != nil {
+			t.Fatalf("create driver: %v", err)
+		}
+		drivers = append(drivers, driver)
+	}
+	location, err := store.ActivityLocations().Create(ctx, &models.ActivityLocation{Name: "Gym", Address: "4 Event Ave", Lat: 42, Lng: -75})
+	if err != nil {
+		t.Fatalf("create activity location: %v", err)
+	}
+
+	handler.Router = &captureRouter{result: &models.RoutingResult{
+		Routes: []models.CalculatedRoute{
+			{Driver: drivers[0], Stops: []models.RouteStop{{Order: 0, Participant: riders[1]}, {Order: 1, Participant: riders[0]}}},
+			{Driver: drivers[1], Stops: []models.RouteStop{}},
+			{Driver: drivers[2], Stops: []models.RouteStop{{Order: 0, Participant: riders[2]}}},
+		},
+		Summary: models.RoutingSummary{TotalDriversUsed: 2},
+	}}
+
+	body := fmt.Sprintf(`{"participant_ids":[%d,%d,%d],"driver_ids":[%d,%d,%d],"activity_location_id":%d,"route_time":"18:30"}`,
+		riders[0].ID, riders[1].ID, riders[2].ID, drivers[0].ID, drivers[1].ID, drivers[2].ID, location.ID)
+	rr := httptest.NewRecorder()
+	handler.HandleCalculateRoutes(rr, httptest.NewRequestWithContext(ctx, http.MethodPost, "/api/v1/routes/calculate", strings.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d body=%q", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var resp RouteCalculationResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Routes) != 3 {
+		t.Fatalf("expected detailed routes to be kept, got %d", len(resp.Routes))
+	}
+	var want []DriverManifestEntry
+	for _, route := range resp.Routes {
+		if len(route.Stops) == 0 {
+			continue
+		}
+		entry := DriverManifestEntry{DriverID: route.Driver.ID, DriverName: route.Driver.Name}
+		for _, stop := range route.Stops {
+			entry.Passengers = append(entry.Passengers, DriverManifestRider{ParticipantID: stop.Participant.ID, Name: stop.Participant.Name})
+		}
+		want = append(want, entry)
+	}
+	if got := fmt.Sprint(resp.DriverManifest); got != fmt.Sprint(want) {
+		t.Fatalf("driver manifest = %s, want %s", got, fmt.Sprint(want))
+	}
+	if resp.DriverManifest[0].Passengers[0].Name != "Ben" {
+		t.Fatalf("manifest did not keep stop order: %#v", resp.DriverManifest[0])
+	}
+}
+
 func TestHandleCalculateRoutes_InvalidModeReturnsValidationError(t *testing.T) {
 	handler, _ := newTestRouteHandler(t)
 	router := &captureRouter{}
@@ -159,6 +231,38 @@ func TestHandleCalculateRoutes_InvalidModeReturnsValidationError(t *testing.T) {
 	}
 }
 
+func TestHandleCalculateRoutes_JSONInvalidModeReturnsValidationError(t *testing.T) {
+	handler, _ := newTestRouteHandler(t)
+	router := &captureRouter{}
+	handler.Router = router
+
+	body := `{"participant_ids":[1],"driver_ids":[1],"activity_location_id":1,"route_time":"18:30","mode":"dropof"}`
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/routes/calculate", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	handler.HandleCalculateRoutes(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d body=%q", rr.Code, http.StatusBadRequest, rr.Body.String())
+	}
+	var response struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if response.Error.Code != "VALIDATION_ERROR" || response.Error.Message != messageInvalidRouteMode {
+		t.Fatalf("error = %#v, want route mode validation error", response.Error)
+	}
+	if router.lastRequest != nil {
+		t.Fatalf("expected router to not receive a request, got %#v", router.lastRequest)
+	}
+}
+
 func TestHandleCalculateRoutes_DistanceProviderFailureReturnsVisibleError(t *testing.T) {
 	handler, store := newTestRouteHandler(t)
 
@@ -214,6 +318,138 @@ func TestHandleCalculateRoutes_DistanceProviderFailureReturnsVisibleError(t *tes
 	}
 }
 
+// avoidHighwaysCalculator stands in for the motorway-excluding OSRM calculator.
+type avoidHighwaysCalculator struct {
+	routeEditDistanceCalculator
+}
+
+func TestHandleCalculateRoutes_AvoidHighwaysUsesExcludingCalculator(t *testing.T) {
+	handler, store := newTestRouteHandler(t)
+	ctx := context.Background()
+
+	participant, err := store.Participants().Create(ctx, &models.Participant{Name: "Rider", Address: "1 Rider Rd", Lat: 40.1, Lng: -73.9})
+	if err != nil {
+		t.Fatalf("create participant: %v", err)
+	}
+	driver, err := store.Drivers().Create(ctx, &models.Driver{Name: "Driver", Address: "2 Driver Rd", Lat: 40.2, Lng: -73.8, VehicleCapacity: 4})
+	if err != nil {
+		t.Fatalf("create driver: %v", err)
+	}
+	location, err := store.ActivityLocations().Create(ctx, &models.ActivityLocation{Name: "Event", Address: "3 Event Ave", Lat: 42, Lng: -75})
+	if err != nil {
+		t.Fatalf("create location: %v", err)
+	}
+
+	router := &captureRouter{}
+	handler.Router = router
+	handler.AvoidHighwaysCalc = avoidHighwaysCalculator{}
+
+	calculate := func(avoidHighways bool) {
+		t.Helper()
+		form := url.Values{}
+		form.Add("participant_ids", int64ToString(participant.ID))
+		form.Add("driver_ids", int64ToString(driver.ID))
+		form.Set("activity_location_id", int64ToString(location.ID))
+		form.Set("route_time", "18:30")
+		if avoidHighways {
+			form.Set("avoid_highways", "on")
+		}
+		req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/api/v1/routes/calculate", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		handler.HandleCalculateRoutes(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d body=%q", rr.Code, http.StatusOK, rr.Body.String())
+		}
+	}
+
+	calculate(true)
+	if router.lastRequest == nil || router.lastRequest.Distances != (avoidHighwaysCalculator{}) {
+		t.Fatalf("expected the avoid-highways calculator, got %#v", router.lastRequest)
+	}
+
+	calculate(false)
+	if router.lastRequest.Distances != nil {
+		t.Fatalf("expected the default calculator without avoid_highways, got %#v", router.lastRequest.Distances)
+	}
+}
+
+func TestHandleCalculateRoutes_AvoidHighwaysWithoutOSRMReturnsValidationError(t *testing.T) {
+	handler, _ := newTestRouteHandler(t)
+	router := &captureRouter{}
+	handler.Router = router
+
+	body := `{"participant_ids":[1],"driver_ids":[1],"activity_location_id":1,"route_time":"18:30","avoid_highways":true}`
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/routes/calculate", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	handler.HandleCalculateRoutes(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d body=%q", rr.Code, http.StatusBadRequest, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), messageAvoidHighwaysUnavailable) {
+		t.Fatalf("body = %q, want avoid-highways message", rr.Body.String())
+	}
+	if router.lastRequest != nil {
+		t.Fatalf("expected router to not receive a request, got %#v", router.lastRequest)
+	}
+}
+
+func TestHandleCalculateRoutes_DropsInactiveDrivers(t *testing.T) {
+	handler, store := newTestRouteHandler(t)
+	ctx := context.Background()
+
+	participant, err := store.Participants().Create(ctx, &models.Participant{Name: "Rider", Address: "1 Rider Rd", Lat: 40.1, Lng: -73.9})
+	if err != nil {
+		t.Fatalf("create participant: %v", err)
+	}
+	var drivers []*models.Driver
+	for _, name := range []string{"Staying", "Away"} {
+		driver, err := store.Drivers().Create(ctx, &models.Driver{Name: name, Address: "2 Driver Rd", Lat: 40.2, Lng: -73.8, VehicleCapacity: 2})
+		if err != nil {
+			t.Fatalf("create driver: %v", err)
+		}
+		drivers = append(drivers, driver)
+	}
+	location, err := store.ActivityLocations().Create(ctx, &models.ActivityLocation{Name: "Gym", Address: "3 Event Ave", Lat: 42, Lng: -75})
+	if err != nil {
+		t.Fatalf("create activity location: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.HandleSetDriverActive(rr, httptest.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("/api/v1/drivers/%d/active", drivers[1].ID), strings.NewReader(`{"active":false}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("set active status = %d body=%q", rr.Code, rr.Body.String())
+	}
+	if got, err := store.Drivers().GetByID(ctx, drivers[1].ID); err != nil || !got.Inactive {
+		t.Fatalf("driver after toggle = %+v err=%v, want inactive", got, err)
+	}
+
+	router := &captureRouter{err: &routing.ErrRoutingFailed{Reason: "stop after capture"}}
+	handler.Router = router
+	calculate := func(driverIDs string) *httptest.ResponseRecorder {
+		t.Helper()
+		body := fmt.Sprintf(`{"participant_ids":[%d],"driver_ids":[%s],"activity_location_id":%d,"route_time":"18:30","mode":"dropoff"}`, participant.ID, driverIDs, location.ID)
+		req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/api/v1/routes/calculate", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.HandleCalculateRoutes(rr, req)
+		return rr
+	}
+
+	calculate(fmt.Sprintf("%d,%d", drivers[0].ID, drivers[1].ID))
+	if router.lastRequest == nil || len(router.lastRequest.Drivers) != 1 || router.lastRequest.Drivers[0].ID != drivers[0].ID {
+		t.Fatalf("routed drivers = %+v, want only the active driver", router.lastRequest)
+	}
+
+	rr = calculate(fmt.Sprint(drivers[1].ID))
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), messageSelectAtLeastOneDriver) {
+		t.Fatalf("only inactive drivers: status = %d body=%q, want select-a-driver validation error", rr.Code, rr.Body.String())
+	}
+}
+
 func TestHandleCalculateRoutes_JSONCapacityShortageReturnsRoutingFailure(t *testing.T) {
 	handler, store := newTestRouteHandler(t)
 	ctx := context.Background()
@@ -260,7 +496,7 @@ func TestHandleCalculateRoutes_JSONCapacityShortageReturnsRoutingFailure(t *test
 	if response.Error.Code != "ROUTING_FAILED" || response.Error.Message != "not enough capacity" {
 		t.Fatalf("error = %#v, want ROUTING_FAILED capacity error", response.Error)
 	}
-	if got, want := response.Error.Details, (RoutingErrorDetails{UnassignedCount: 2, TotalCapacity: 1, TotalParticipants: 3}); got != want {
+	if got, want := response.Error.Details, (RoutingErrorDetails{UnassignedCount: 2, TotalCapacity: 1, TotalParticipants: 3}); !reflect.DeepEqual(got, want) {
 		t.Fatalf("routing details = %#v, want %#v", got, want)
 	}
 }
@@ -961,28 +1197,63 @@ func TestHandleCalculateRoutes_OrgVehicleRepositoryFailureReturnsInternalError(t
 	}
 }
 
-func TestCountUsedOrgVehicles_IgnoresUnusedOrDuplicateRoutes(t *testing.T) {
-	routes := []models.CalculatedRoute{
-		{
-			OrgVehicleID: 1,
-			Stops: []models.RouteStop{
-				{Order: 0, Participant: &models.Participant{ID: 1, Name: "Alice"}},
+func TestApplyRouteVehicleSummary_CountsVansOnceAndOnlyOwnCarsAsVolunteers(t *testing.T) {
+	van := &models.OrganizationVehicle{ID: 1, Name: "Van", Capacity: 8}
+	idleVan := &models.OrganizationVehicle{ID: 2, Name: "Idle van", Capacity: 8}
+	result := &models.RoutingResult{
+		Routes: []models.CalculatedRoute{
+			{
+				Driver:              &models.Driver{ID: 1, VehicleCapacity: 4},
+				TotalDistanceMeters: 1000,
+				Stops: []models.RouteStop{
+					{Order: 0, Participant: &models.Participant{ID: 1, Name: "Alice"}},
+				},
 			},
-		},
-		{
-			OrgVehicleID: 2,
-			Stops:        []models.RouteStop{},
-		},
-		{
-			OrgVehicleID: 1,
-			Stops: []models.RouteStop{
-				{Order: 0, Participant: &models.Participant{ID: 2, Name: "Bob"}},
+			{
+				Driver: &models.Driver{ID: 2, VehicleCapacity: 4},
+				Stops:  []models.RouteStop{},
+			},
+			{
+				Driver:              &models.Driver{ID: 3, VehicleCapacity: 4},
+				TotalDistanceMeters: 2000,
+				Stops: []models.RouteStop{
+					{Order: 0, Participant: &models.Participant{ID: 2, Name: "Bob"}},
+				},
+			},
+			{
+				Driver:              &models.Driver{ID: 4, VehicleCapacity: 4},
+				TotalDistanceMeters: 4000,
+				Stops: []models.RouteStop{
+					{Order: 0, Participant: &models.Participant{ID: 3, Name: "Cara"}},
+				},
 			},
 		},
+		Summary: models.RoutingSummary{TotalDriversUsed: 3},
 	}
 
-	if got := countUsedOrgVehicles(routes); got != 1 {
-		t.Fatalf("countUsedOrgVehicles = %d, want 1", got)
+	applyRouteVehicleSummary(result, map[int64]*models.OrganizationVehicle{1: van, 2: idleVan, 3: van})
+
+	got := result.Summary
+	if got.OrgVehiclesUsed != 1 || got.VolunteerDriversUsed != 1 {
+		t.Fatalf("org vehicles = %d, volunteers = %d; want the shared van once and the one own-car driver", got.OrgVehiclesUsed, got.VolunteerDriversUsed)
+	}
+	if got.InstituteVehicleDistanceMeters != 3000 || got.VolunteerDistanceMeters != 4000 {
+		t.Fatalf("institute distance = %.0f, volunteer distance = %.0f; want 3000 and 4000", got.InstituteVehicleDistanceMeters, got.VolunteerDistanceMeters)
+	}
+}
+
+func TestApplyRouteVehicleSummary_IgnoresUnusedVanRoute(t *testing.T) {
+	van := &models.OrganizationVehicle{ID: 2, Name: "Idle van", Capacity: 8}
+	result := &models.RoutingResult{
+		Routes: []models.CalculatedRoute{
+			{Driver: &models.Driver{ID: 1, VehicleCapacity: 4}, Stops: []models.RouteStop{}},
+		},
+	}
+
+	applyRouteVehicleSummary(result, map[int64]*models.OrganizationVehicle{1: van})
+
+	if got := result.Summary; got.OrgVehiclesUsed != 0 || got.VolunteerDriversUsed != 0 {
+		t.Fatalf("org vehicles = %d, volunteers = %d; want an empty van route counted as neither", got.OrgVehiclesUsed, got.VolunteerDriversUsed)
 	}
 }
 
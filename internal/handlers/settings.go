@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"log"
+	"math"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -116,8 +117,12 @@ func (h *Handler) HandleUpdateRoutingProviderConfig(w http.ResponseWriter, r *ht
 // HandleUpdateSettings handles PUT /api/v1/settings
 func (h *Handler) HandleUpdateSettings(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		SelectedActivityLocationID *int64 `json:"selected_activity_location_id"`
-		UseMiles                   bool   `json:"use_miles"`
+		SelectedActivityLocationID *int64   `json:"selected_activity_location_id"`
+		UseMiles                   bool     `json:"use_miles"`
+		ReimbursementRate          *float64 `json:"reimbursement_rate"`
+		OverflowPolicy             *string  `json:"overflow_policy"`
+		NavProvider                *string  `json:"nav_provider"`
+		HouseholdGroupingPrecision *int     `json:"household_grouping_precision"`
 	}
 
 	if h.isHTMX(r) {
@@ -133,6 +138,32 @@ func (h *Handler) HandleUpdateSettings(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 		req.UseMiles = r.FormValue("use_miles") == "on" || r.FormValue("use_miles") == "true"
+		if rateStr := strings.TrimSpace(r.FormValue("reimbursement_rate")); rateStr != "" {
+			rate, err := strconv.ParseFloat(rateStr, 64)
+			if err != nil {
+				h.setHTMXToast(w, messageInvalidReimbursementRate, toastTypeError)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			req.ReimbursementRate = &rate
+		}
+		if _, ok := r.Form["overflow_policy"]; ok {
+			policy := r.FormValue("overflow_policy")
+			req.OverflowPolicy = &policy
+		}
+		if _, ok := r.Form["nav_provider"]; ok {
+			provider := r.FormValue("nav_provider")
+			req.NavProvider = &provider
+		}
+		if value := strings.TrimSpace(r.FormValue("household_grouping_precision")); value != "" {
+			decimals, err := strconv.Atoi(value)
+			if err != nil {
+				h.setHTMXToast(w, messageInvalidHouseholdGroupingPrecision, toastTypeError)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			req.HouseholdGroupingPrecision = &decimals
+		}
 	} else {
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			log.Printf("[HTTP] PUT /api/v1/settings: invalid_body err=%v", err)
@@ -141,6 +172,55 @@ func (h *Handler) HandleUpdateSettings(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if req.ReimbursementRate != nil && (*req.ReimbursementRate < 0 || math.IsNaN(*req.ReimbursementRate) || math.IsInf(*req.ReimbursementRate, 0)) {
+		if h.isHTMX(r) {
+			h.setHTMXToast(w, messageInvalidReimbursementRate, toastTypeError)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		h.handleValidationError(w, messageInvalidReimbursementRate)
+		return
+	}
+	var overflowPolicy *models.OverflowPolicy
+	if req.OverflowPolicy != nil {
+		policy, err := models.ParseOverflowPolicy(*req.OverflowPolicy)
+		if err != nil {
+			if h.isHTMX(r) {
+				h.setHTMXToast(w, messageInvalidOverflowPolicy, toastTypeError)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			h.handleValidationError(w, messageInvalidOverflowPolicy)
+			return
+		}
+		overflowPolicy = &policy
+	}
+	var navProvider *models.NavProvider
+	if req.NavProvider != nil {
+		provider, err := models.ParseNavProvider(*req.NavProvider)
+		if err != nil {
+			if h.isHTMX(r) {
+				h.setHTMXToast(w, messageInvalidNavProvider, toastTypeError)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			h.handleValidationError(w, messageInvalidNavProvider)
+			return
+		}
+		navProvider = &provider
+	}
+	if req.HouseholdGroupingPrecision != nil {
+		if _, err := models.ParseHouseholdGroupingPrecision(*req.HouseholdGroupingPrecision); err != nil {
+			if h.isHTMX(r) {
+				h.setHTMXToast(w, messageInvalidHouseholdGroupingPrecision, toastTypeError)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			h.handleValidationError(w, messageInvalidHouseholdGroupingPrecision)
+			return
+		}
+	}
+
 	currentSettings, err := h.DB.Settings().Get(r.Context())
 	if err != nil {
 		log.Printf("[ERROR] Failed to get existing settings: err=%v", err)
@@ -184,9 +264,27 @@ func (h *Handler) HandleUpdateSettings(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	reimbursementRate := currentSettings.ReimbursementRate
+	if req.ReimbursementRate != nil {
+		reimbursementRate = *req.ReimbursementRate
+	}
+
 	settings := &models.Settings{
 		SelectedActivityLocationID: selectedActivityLocationID,
 		UseMiles:                   req.UseMiles,
+		ReimbursementRate:          reimbursementRate,
+		OverflowPolicy:             currentSettings.OverflowPolicy,
+		NavProvider:                currentSettings.NavProvider,
+		HouseholdGroupingPrecision: currentSettings.HouseholdGroupingPrecision,
+	}
+	if overflowPolicy != nil {
+		settings.OverflowPolicy = *overflowPolicy
+	}
+	if navProvider != nil {
+		settings.NavProvider = *navProvider
+	}
+	if req.HouseholdGroupingPrecision != nil {
+		settings.HouseholdGroupingPrecision = *req.HouseholdGroupingPrecision
 	}
 
 	if err := h.DB.Settings().Update(r.Context(), settings); err != nil {
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"ride-home-router/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestHandleSettings_HouseholdGroupingPrecision(t *testing.T) {
+	handler, _ := newTestPageHandler(t)
+
+	getPrecision := func() int {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		handler.HandleGetSettings(rr, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/settings", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("get status = %d, want %d body=%q", rr.Code, http.StatusOK, rr.Body.String())
+		}
+		var settings models.Settings
+		if err := json.NewDecoder(rr.Body).Decode(&settings); err != nil {
+			t.Fatalf("decode settings: %v", err)
+		}
+		return settings.HouseholdGroupingPrecision
+	}
+	put := func(body string) int {
+		t.Helper()
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodPut, "/api/v1/settings", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.HandleUpdateSettings(rr, req)
+		return rr.Code
+	}
+
+	if got := getPrecision(); got != models.DefaultHouseholdGroupingPrecision {
+		t.Fatalf("default precision = %d, want %d", got, models.DefaultHouseholdGroupingPrecision)
+	}
+	if code := put(`{"use_miles":true,"household_grouping_precision":5}`); code != http.StatusOK {
+		t.Fatalf("update status = %d, want %d", code, http.StatusOK)
+	}
+	if got := getPrecision(); got != 5 {
+		t.Fatalf("precision after update = %d, want 5", got)
+	}
+	if code := put(`{"use_miles":true,"household_grouping_precision":6}`); code != http.StatusBadRequest {
+		t.Fatalf("out-of-range update status = %d, want %d", code, http.StatusBadRequest)
+	}
+	if code := put(`{"use_miles":true}`); code != http.StatusOK {
+		t.Fatalf("update without precision status = %d, want %d", code, http.StatusOK)
+	}
+	if got := getPrecision(); got != 5 {
+		t.Fatalf("precision after unrelated update = %d, want 5 kept", got)
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"ride-home-router/internal/database"
+	"ride-home-router/internal/sheets"
+	"strings"
+	"time"
+)
+
+// SheetsExportResponse reports where a route plan was written.
+type SheetsExportResponse struct {
+	SpreadsheetID string `json:"spreadsheet_id"`
+	Tab           string `json:"tab"`
+	Rows          int    `json:"rows"`
+}
+
+// HandleExportRoutesToSheets handles POST /api/v1/routes/session/export-sheets
+//
+// The tab defaults to the event date and activity location so each event gets
+// its own tab; exporting the same event again replaces that tab's rows.
+func (h *Handler) HandleExportRoutesToSheets(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SessionID     string `json:"session_id"`
+		SpreadsheetID string `json:"spreadsheet_id"`
+		Tab           string `json:"tab"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleValidationError(w, messageInvalidRequestBody)
+		return
+	}
+	if h.Sheets == nil {
+		h.writeError(w, http.StatusNotImplemented, "NOT_CONFIGURED", messageSheetsExportNotConfigured, nil)
+		return
+	}
+
+	snapshot, ok := h.RouteSession.Snapshot(req.SessionID)
+	if !ok {
+		h.handleNotFound(w, messageSessionNotFound)
+		return
+	}
+
+	spreadsheetID := strings.TrimSpace(req.SpreadsheetID)
+	if spreadsheetID == "" {
+		config, err := database.LoadConfig()
+		if err != nil {
+			h.handleInternalError(w, err)
+			return
+		}
+		spreadsheetID = config.GoogleSheets.SpreadsheetID
+	}
+	tab := strings.TrimSpace(req.Tab)
+	if tab == "" {
+		tab = time.Now().Format("2006-01-02")
+		if snapshot.ActivityLocation != nil {
+			tab += " " + snapshot.ActivityLocation.Name
+		}
+	}
+
+	log.Printf("[HTTP] POST /api/v1/routes/session/export-sheets: session_id=%s tab=%s", req.SessionID, tab)
+	if err := h.Sheets.ExportRoutes(r.Context(), spreadsheetID, tab, snapshot.Routes); err != nil {
+		if errors.Is(err, sheets.ErrNotConfigured) {
+			h.writeError(w, http.StatusNotImplemented, "NOT_CONFIGURED", messageSheetsExportNotConfigured, nil)
+			return
+		}
+		log.Printf("[ERROR] Failed to export routes to Google Sheets: session_id=%s err=%v", req.SessionID, err)
+		h.writeError(w, http.StatusBadGateway, "SHEETS_EXPORT_FAILED", err.Error(), nil)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, SheetsExportResponse{
+		SpreadsheetID: spreadsheetID,
+		Tab:           tab,
+		Rows:          len(sheets.PlanRows(snapshot.Routes)) - 1,
+	})
+}
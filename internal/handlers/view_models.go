@@ -29,6 +29,9 @@ type IndexPageView struct {
 	DriverLabels      map[int64][]int64
 	ActivityLocations []models.ActivityLocation
 	OrgVehicles       []models.OrganizationVehicle
+	NavProvider       models.NavProvider
+	// AvoidHighways offers keeping routes off motorways.
+	AvoidHighways bool
 }
 
 type ParticipantsPageView struct {
@@ -70,11 +73,22 @@ type RoutingProviderConfigView struct {
 	GoogleMapsAPIKeyConfigured bool
 }
 
+// DistanceCacheView summarizes the distance cache for the settings page.
+// HitPercent is zero until the cache has been read.
+type DistanceCacheView struct {
+	Entries    int
+	Hits       int64
+	Misses     int64
+	HitPercent int
+	SizeKB     int64
+}
+
 type SettingsPageView struct {
 	BasePageView
 	Settings              *models.Settings
 	DatabaseConfig        DatabaseConfigView
 	RoutingProviderConfig RoutingProviderConfigView
+	DistanceCache         DistanceCacheView
 }
 
 type HistoryPageView struct {
@@ -167,16 +181,47 @@ type RouteResultsView struct {
 }
 
 type RoutingErrorDetails struct {
-	UnassignedCount   int `json:"unassigned_count"`
-	TotalCapacity     int `json:"total_capacity"`
-	TotalParticipants int `json:"total_participants"`
+	UnassignedCount   int     `json:"unassigned_count"`
+	TotalCapacity     int     `json:"total_capacity"`
+	TotalParticipants int     `json:"total_participants"`
+	OutsideTimeWindow []int64 `json:"outside_time_window,omitempty"`
+	ExcludedByDriver  []int64 `json:"excluded_by_driver,omitempty"`
 }
 
 type RouteCalculationResponse struct {
-	Routes    []models.CalculatedRoute `json:"routes"`
-	Summary   models.RoutingSummary    `json:"summary"`
-	SessionID string                   `json:"session_id"`
-	Mode      models.RouteMode         `json:"mode"`
+	Routes         []models.CalculatedRoute       `json:"routes"`
+	Summary        models.RoutingSummary          `json:"summary"`
+	SessionID      string                         `json:"session_id"`
+	Mode           models.RouteMode               `json:"mode"`
+	NoShows        []models.Participant           `json:"no_shows,omitempty"`
+	Finalized      bool                           `json:"finalized,omitempty"`
+	Version        int64                          `json:"version"`
+	Unassigned     []models.UnassignedParticipant `json:"unassigned,omitempty"`
+	DriverManifest []DriverManifestEntry          `json:"driver_manifest"`
+	// DepartureCollisions flags neighboring first stops; see CompactDepartures.
+	DepartureCollisions []models.DepartureCollision `json:"departure_collisions,omitempty"`
+	// DriversAtActivity lists drivers whose home matches the activity location.
+	DriversAtActivity []int64 `json:"drivers_at_activity,omitempty"`
+	// ForcedOrderConflicts lists routes where participants force the same stop.
+	ForcedOrderConflicts []models.ForcedOrderConflict `json:"forced_order_conflicts,omitempty"`
+	// Warnings are caveats about the whole result, e.g. estimated distances.
+	Warnings []string `json:"warnings,omitempty"`
+	// BusStops and DoorToDoor report who walks to which stop when the
+	// request routed over bus stops.
+	BusStops   []models.BusStopAssignment `json:"bus_stops,omitempty"`
+	DoorToDoor []int64                    `json:"door_to_door,omitempty"`
+}
+
+// DriverManifestEntry is a flattened view of one used driver's passengers in stop order.
+type DriverManifestEntry struct {
+	DriverID   int64                 `json:"driver_id"`
+	DriverName string                `json:"driver_name"`
+	Passengers []DriverManifestRider `json:"passengers"`
+}
+
+type DriverManifestRider struct {
+	ParticipantID int64  `json:"participant_id"`
+	Name          string `json:"name"`
 }
 
 type DatabasePathUpdateResponse struct {
@@ -6,17 +6,21 @@ import (
 )
 
 const (
-	HeaderContentType  = "Content-Type"
-	HeaderHXCurrentURL = "HX-Current-URL"
-	HeaderHXRequest    = "HX-Request"
-	HeaderHXReswap     = "HX-Reswap"
-	HeaderHXTarget     = "HX-Target"
-	HeaderHXTrigger    = "HX-Trigger"
+	HeaderContentDisposition = "Content-Disposition"
+	HeaderContentType        = "Content-Type"
+	HeaderHXCurrentURL       = "HX-Current-URL"
+	HeaderHXRequest          = "HX-Request"
+	HeaderHXReswap           = "HX-Reswap"
+	HeaderHXTarget           = "HX-Target"
+	HeaderHXTrigger          = "HX-Trigger"
 
 	MediaTypeJSON      = "application/json"
 	MediaTypeHTML      = "text/html; charset=utf-8"
 	MediaTypeForm      = "application/x-www-form-urlencoded"
 	MediaTypeMultipart = "multipart/form-data"
+	MediaTypeCSV       = "text/csv; charset=utf-8"
+	MediaTypeGPX       = "application/gpx+xml"
+	MediaTypePNG       = "image/png"
 
 	HTMXTrue   = "true"
 	ReswapNone = "none"
@@ -3,7 +3,9 @@ package models
 import (
 	"errors"
 	"math"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -35,38 +37,213 @@ func ParseRouteMode(value string) (RouteMode, error) {
 	}
 }
 
+// OverflowPolicy chooses how a calculation covers a seat shortage before
+// asking the coordinator: with an organization van, or with the flex seats
+// volunteers have agreed to. The empty policy falls back to neither.
+type OverflowPolicy string
+
+const (
+	OverflowPolicyNone      OverflowPolicy = ""
+	OverflowPolicyVanFirst  OverflowPolicy = "van_first"
+	OverflowPolicyFlexFirst OverflowPolicy = "flex_first"
+)
+
+var ErrInvalidOverflowPolicy = errors.New("invalid overflow policy")
+
+// ParseOverflowPolicy normalizes an overflow policy value, keeping blank
+// input as no policy.
+func ParseOverflowPolicy(value string) (OverflowPolicy, error) {
+	switch policy := OverflowPolicy(strings.TrimSpace(value)); policy {
+	case OverflowPolicyNone, OverflowPolicyVanFirst, OverflowPolicyFlexFirst:
+		return policy, nil
+	default:
+		return "", ErrInvalidOverflowPolicy
+	}
+}
+
+// NavProvider chooses which maps app generated navigation links open.
+type NavProvider string
+
+const (
+	NavProviderGoogle NavProvider = "google"
+	NavProviderApple  NavProvider = "apple"
+	NavProviderOSM    NavProvider = "osm"
+)
+
+var ErrInvalidNavProvider = errors.New("invalid navigation provider")
+
+// ParseNavProvider normalizes a navigation provider value, treating blank
+// input as Google.
+func ParseNavProvider(value string) (NavProvider, error) {
+	switch provider := NavProvider(strings.TrimSpace(value)); provider {
+	case "":
+		return NavProviderGoogle, nil
+	case NavProviderGoogle, NavProviderApple, NavProviderOSM:
+		return provider, nil
+	default:
+		return "", ErrInvalidNavProvider
+	}
+}
+
 // RoundCoordinate rounds a coordinate to 5 decimal places (approximately 1 meter precision).
 // This is used for consistent coordinate comparison across the codebase.
 func RoundCoordinate(coord float64) float64 {
 	return math.Round(coord*100000) / 100000
 }
 
-// Participant represents a person to be driven home
+// MaxSamePointPrecision is the finest same-point precision, in decimal
+// places. It matches RoundCoordinate, which keys the distance cache, so two
+// points the cache cannot tell apart are never routed as separate stops.
+const MaxSamePointPrecision = 5
+
+var samePointPrecision atomic.Int32
+
+// SetSamePointPrecision sets the decimal places at which two coordinates are
+// the same point, both for the zero-distance shortcut and for grouping riders
+// into one household stop. Values outside 1..MaxSamePointPrecision restore
+// the default of MaxSamePointPrecision.
+func SetSamePointPrecision(decimals int) {
+	if decimals < 1 || decimals > MaxSamePointPrecision {
+		decimals = 0
+	}
+	samePointPrecision.Store(int32(decimals))
+}
+
+// SamePointPrecision returns the configured same-point precision.
+func SamePointPrecision() int {
+	if decimals := samePointPrecision.Load(); decimals > 0 {
+		return int(decimals)
+	}
+	return MaxSamePointPrecision
+}
+
+// RoundSamePoint rounds a coordinate to the same-point precision.
+func RoundSamePoint(coord float64) float64 {
+	return RoundToDecimals(coord, SamePointPrecision())
+}
+
+// RoundToDecimals rounds a coordinate to the given number of decimal places.
+func RoundToDecimals(coord float64, decimals int) float64 {
+	scale := math.Pow10(decimals)
+	return math.Round(coord*scale) / scale
+}
+
+// DefaultHouseholdGroupingPrecision is the decimal places, about 11 meters,
+// at which riders' stops group them into one household unless settings say
+// otherwise. It is coarser than the same-point precision so family members
+// geocoded to different points on one lot still ride together.
+const DefaultHouseholdGroupingPrecision = 4
+
+var ErrInvalidHouseholdGroupingPrecision = errors.New("invalid household grouping precision")
+
+// ParseHouseholdGroupingPrecision validates a household grouping precision,
+// which must be between 1 and MaxSamePointPrecision decimal places.
+func ParseHouseholdGroupingPrecision(decimals int) (int, error) {
+	if decimals < 1 || decimals > MaxSamePointPrecision {
+		return 0, ErrInvalidHouseholdGroupingPrecision
+	}
+	return decimals, nil
+}
+
+// SamePoint reports whether a and b round to the same point at the
+// same-point precision.
+func SamePoint(a, b Coordinates) bool {
+	return RoundSamePoint(a.Lat) == RoundSamePoint(b.Lat) && RoundSamePoint(a.Lng) == RoundSamePoint(b.Lng)
+}
+
+// Participant represents a person to be driven home.
+// RequiredMatches lists attribute keys (e.g. "language") whose values the
+// assigned driver must share with the participant. MeetingPoint, when set,
+// replaces the home address as the pickup/dropoff location so riders sharing
+// a point form one stop. SoloRide requires the participant to be the only
+// passenger on their route, even apart from their own household.
+// RequiresAccessible limits the participant to drivers whose vehicle is
+// accessible.
+// IntermediateStop, when set, is a second dropoff stop (e.g. an after-school
+// program) the route visits before the participant's own stop.
 type Participant struct {
-	ID        int64     `json:"id"`
-	Name      string    `json:"name"`
-	Address   string    `json:"address"`
-	Lat       float64   `json:"lat"`
-	Lng       float64   `json:"lng"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                 int64             `json:"id"`
+	Name               string            `json:"name"`
+	Address            string            `json:"address"`
+	Lat                float64           `json:"lat"`
+	Lng                float64           `json:"lng"`
+	Attributes         map[string]string `json:"attributes,omitempty"`
+	RequiredMatches    []string          `json:"required_matches,omitempty"`
+	MeetingPoint       *Coordinates      `json:"meeting_point,omitempty"`
+	SoloRide           bool              `json:"solo_ride,omitempty"`
+	RequiresAccessible bool              `json:"requires_accessible,omitempty"`
+	Age                int               `json:"age,omitempty"` // years; zero when unknown
+	IntermediateStop   *Coordinates      `json:"intermediate_stop,omitempty"`
+	// EarliestSecs and LatestSecs bound when the route may reach the
+	// participant's stop, in seconds after it sets off. Zero leaves that side
+	// of the window open.
+	EarliestSecs float64 `json:"earliest_secs,omitempty"`
+	LatestSecs   float64 `json:"latest_secs,omitempty"`
+	// ForcedOrder, when set, is the 0-based stop index the participant must
+	// hold on whatever route they ride, regardless of efficiency.
+	ForcedOrder *int      `json:"forced_order,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
-// GetCoords returns the coordinates of the participant
+// GetCoords returns where the participant is picked up or dropped off: the
+// meeting point when one is set, otherwise their home.
 func (p *Participant) GetCoords() Coordinates {
+	if p.MeetingPoint != nil {
+		return *p.MeetingPoint
+	}
 	return Coordinates{Lat: p.Lat, Lng: p.Lng}
 }
 
+// DropoffPoints returns the points a dropoff route visits for the
+// participant, in order: their intermediate stop when one is set, then
+// GetCoords.
+func (p *Participant) DropoffPoints() []Coordinates {
+	if p.IntermediateStop != nil {
+		return []Coordinates{*p.IntermediateStop, p.GetCoords()}
+	}
+	return []Coordinates{p.GetCoords()}
+}
+
+// HasTimeWindow reports whether either side of the participant's window is set.
+func (p *Participant) HasTimeWindow() bool {
+	return p.EarliestSecs > 0 || p.LatestSecs > 0
+}
+
+// WithinTimeWindow reports whether reaching the stop arrivalSecs after the
+// route sets off respects the participant's window.
+func (p *Participant) WithinTimeWindow(arrivalSecs float64) bool {
+	return arrivalSecs >= p.EarliestSecs && (p.LatestSecs <= 0 || arrivalSecs <= p.LatestSecs)
+}
+
 // Driver represents a person who can drive participants home
 type Driver struct {
-	ID              int64     `json:"id"`
-	Name            string    `json:"name"`
-	Address         string    `json:"address"`
-	Lat             float64   `json:"lat"`
-	Lng             float64   `json:"lng"`
-	VehicleCapacity int       `json:"vehicle_capacity"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID                  int64             `json:"id"`
+	Name                string            `json:"name"`
+	Address             string            `json:"address"`
+	Lat                 float64           `json:"lat"`
+	Lng                 float64           `json:"lng"`
+	VehicleCapacity     int               `json:"vehicle_capacity"`
+	Attributes          map[string]string `json:"attributes,omitempty"`
+	ComfortRadiusMeters float64           `json:"comfort_radius_meters,omitempty"` // off-path distance the driver accepts; zero means no preference
+	FlexCapacity        int               `json:"flex_capacity,omitempty"`         // extra seats the driver allows when seats run short
+	// ReturnToInstitute ends the driver's dropoff routes back at the activity
+	// location instead of at home, so the direct trip the detour is measured
+	// against is the empty loop. Pickup routes end there already.
+	ReturnToInstitute bool `json:"return_to_institute,omitempty"`
+	// ExcludedParticipantIDs are participants this driver must never carry.
+	ExcludedParticipantIDs []int64 `json:"excluded_participant_ids,omitempty"`
+	// Inactive drivers stay on the roster but are left out of route
+	// calculations even when selected.
+	Inactive bool `json:"inactive,omitempty"`
+	// IsAccessible marks a wheelchair-accessible vehicle, the only kind that
+	// may carry participants who require one.
+	IsAccessible bool `json:"is_accessible,omitempty"`
+	// MaxRouteDurationSecs is the longest route, start to finish, the driver
+	// will drive. Zero means no limit.
+	MaxRouteDurationSecs float64   `json:"max_route_duration_secs,omitempty"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
 }
 
 // GetCoords returns the coordinates of the driver
@@ -74,6 +251,34 @@ func (d *Driver) GetCoords() Coordinates {
 	return Coordinates{Lat: d.Lat, Lng: d.Lng}
 }
 
+// SatisfiesRequirements reports whether the driver's attributes match every
+// attribute the participant requires. Participants without requirements can
+// ride with any driver.
+func (d *Driver) SatisfiesRequirements(p *Participant) bool {
+	for _, key := range p.RequiredMatches {
+		want, ok := p.Attributes[key]
+		if !ok {
+			return false
+		}
+		if got, ok := d.Attributes[key]; !ok || got != want {
+			return false
+		}
+	}
+	return true
+}
+
+// Excludes reports whether the driver must never carry the participant.
+func (d *Driver) Excludes(p *Participant) bool {
+	return slices.Contains(d.ExcludedParticipantIDs, p.ID)
+}
+
+// CanCarry reports whether the participant may ride with the driver: the
+// driver meets their requirements, has an accessible vehicle when they need
+// one and has not excluded them.
+func (d *Driver) CanCarry(p *Participant) bool {
+	return !d.Excludes(p) && d.SatisfiesRequirements(p) && (!p.RequiresAccessible || d.IsAccessible)
+}
+
 // Label represents a reusable participant and/or driver cohort.
 type Label struct {
 	ID               int64     `json:"id"`
@@ -109,11 +314,15 @@ type OrganizationVehicle struct {
 
 // Settings holds application configuration
 type Settings struct {
-	InstituteAddress           string  `json:"institute_address"` // Deprecated: use SelectedActivityLocationID
-	InstituteLat               float64 `json:"institute_lat"`     // Deprecated: use SelectedActivityLocationID
-	InstituteLng               float64 `json:"institute_lng"`     // Deprecated: use SelectedActivityLocationID
-	SelectedActivityLocationID int64   `json:"selected_activity_location_id"`
-	UseMiles                   bool    `json:"use_miles"`
+	InstituteAddress           string         `json:"institute_address"` // Deprecated: use SelectedActivityLocationID
+	InstituteLat               float64        `json:"institute_lat"`     // Deprecated: use SelectedActivityLocationID
+	InstituteLng               float64        `json:"institute_lng"`     // Deprecated: use SelectedActivityLocationID
+	SelectedActivityLocationID int64          `json:"selected_activity_location_id"`
+	UseMiles                   bool           `json:"use_miles"`
+	ReimbursementRate          float64        `json:"reimbursement_rate"`
+	OverflowPolicy             OverflowPolicy `json:"overflow_policy"`
+	NavProvider                NavProvider    `json:"nav_provider"`
+	HouseholdGroupingPrecision int            `json:"household_grouping_precision"` // decimal places at which stops make riders one household
 }
 
 // Event represents a historical event record
@@ -125,6 +334,13 @@ type Event struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// EventFilter narrows an event listing to events whose saved routes include
+// a driver or participant. Zero IDs match every event.
+type EventFilter struct {
+	DriverID      int64
+	ParticipantID int64
+}
+
 // EventRoute stores a saved route snapshot for a historical event.
 type EventRoute struct {
 	ID                         int64            `json:"id"`
@@ -180,6 +396,13 @@ type RouteStop struct {
 	CumulativeDistanceMeters float64      `json:"cumulative_distance_meters"`
 	DurationFromPrevSecs     float64      `json:"duration_from_prev_secs"`
 	CumulativeDurationSecs   float64      `json:"cumulative_duration_secs"`
+	// Locked marks a stop the coordinator fixed by hand: re-optimization keeps
+	// it with its driver and in its order relative to the route's other
+	// locked stops.
+	Locked bool `json:"locked,omitempty"`
+	// GeometryPolyline is the encoded road path from the previous point to
+	// this stop, set only when the routing request asks for geometry.
+	GeometryPolyline string `json:"geometry_polyline,omitempty"`
 }
 
 // CalculatedRoute represents a single driver's route
@@ -195,27 +418,122 @@ type CalculatedRoute struct {
 	BaselineDurationSecs       float64     `json:"baseline_duration_secs"`
 	RouteDurationSecs          float64     `json:"route_duration_secs"`
 	DetourSecs                 float64     `json:"detour_secs"`
+	DetourMeters               float64     `json:"detour_meters"` // Route distance beyond the driver's direct trip
+	Neighborhoods              int         `json:"neighborhoods"` // Distinct stop clusters the route visits
 	Mode                       RouteMode   `json:"mode"`
+	Color                      string      `json:"color,omitempty"` // Stable per driver; see RouteColor
+	// Cap compliance against the request's detour ceiling and ride cap, and
+	// the driver's own route duration cap. Each margin is the cap minus the
+	// route's value, so it goes negative when the route is over; each stays
+	// zero when there is no cap.
+	ExceedsDetourCap     bool    `json:"exceeds_detour_cap,omitempty"`
+	DetourSecsUnderCap   float64 `json:"detour_secs_under_cap,omitempty"`
+	ExceedsRideCap       bool    `json:"exceeds_ride_cap,omitempty"`
+	RideSecsUnderCap     float64 `json:"ride_secs_under_cap,omitempty"` // against the longest ride on the route
+	ExceedsDurationCap   bool    `json:"exceeds_duration_cap,omitempty"`
+	DurationSecsUnderCap float64 `json:"duration_secs_under_cap,omitempty"`
+}
+
+// routeColors is the palette RouteColor draws from. Neighboring entries are
+// easy to tell apart on a map.
+var routeColors = [...]string{
+	"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd",
+	"#8c564b", "#e377c2", "#7f7f7f", "#bcbd22", "#17becf",
+}
+
+// RouteColor returns the display color for a driver's route. It depends only
+// on the driver ID, so a driver keeps the same color across sessions and
+// exports.
+func RouteColor(driverID int64) string {
+	return routeColors[uint64(driverID)%uint64(len(routeColors))]
 }
 
 // RoutingSummary contains aggregate stats for a routing calculation
 type RoutingSummary struct {
 	TotalParticipants          int     `json:"total_participants"`
 	TotalDriversUsed           int     `json:"total_drivers_used"`
+	VolunteerDriversUsed       int     `json:"volunteer_drivers_used"` // Drivers used in their own vehicles, excluding org vans
 	TotalDropoffDistanceMeters float64 `json:"total_dropoff_distance_meters"`
 	TotalDistanceMeters        float64 `json:"total_distance_meters"`
-	OrgVehiclesUsed            int     `json:"org_vehicles_used,omitempty"`
-	UnassignedParticipants     []int64 `json:"unassigned_participants"`
-	MaxDetourSecs              float64 `json:"max_detour_secs"`
-	SumDetourSecs              float64 `json:"sum_detour_secs"`
-	AverageDetourSecs          float64 `json:"average_detour_secs"`
+	// VolunteerDistanceMeters and InstituteVehicleDistanceMeters split
+	// TotalDistanceMeters between drivers' own cars and org vehicles.
+	// Routers cannot tell which drivers take an org vehicle, so they leave
+	// these, VolunteerDriversUsed and OrgVehiclesUsed zero; the handlers fill
+	// them in once vans are assigned.
+	VolunteerDistanceMeters        float64 `json:"volunteer_distance_meters"`
+	InstituteVehicleDistanceMeters float64 `json:"institute_vehicle_distance_meters"`
+	OrgVehiclesUsed                int     `json:"org_vehicles_used,omitempty"`
+	UnassignedParticipants         []int64 `json:"unassigned_participants"`
+	MaxDetourSecs                  float64 `json:"max_detour_secs"`
+	SumDetourSecs                  float64 `json:"sum_detour_secs"`
+	AverageDetourSecs              float64 `json:"average_detour_secs"`
 }
 
 // RoutingResult contains the full result of a route calculation
 type RoutingResult struct {
-	Routes  []CalculatedRoute `json:"routes"`
-	Summary RoutingSummary    `json:"summary"`
-	Mode    RouteMode         `json:"mode"`
+	Routes     []CalculatedRoute       `json:"routes"`
+	Summary    RoutingSummary          `json:"summary"`
+	Mode       RouteMode               `json:"mode"`
+	Unassigned []UnassignedParticipant `json:"unassigned,omitempty"`
+	// DepartureCollisions lists dropoff routes whose first stops are
+	// neighbors, with a suggested swap that spreads them out.
+	DepartureCollisions []DepartureCollision `json:"departure_collisions,omitempty"`
+	// DriversAtActivity lists drivers whose home is the activity location,
+	// usually a data entry mistake. Their direct trip is zero, so their whole
+	// route counts as detour.
+	DriversAtActivity []int64 `json:"drivers_at_activity,omitempty"`
+	// ForcedOrderConflicts lists routes where several participants force the
+	// same stop index; only one of them can hold it.
+	ForcedOrderConflicts []ForcedOrderConflict `json:"forced_order_conflicts,omitempty"`
+	// Warnings are caveats about the result as a whole, such as distances
+	// estimated while the routing service was unreachable.
+	Warnings []string `json:"warnings,omitempty"`
+	// BusStops, for requests routed over bus stops, lists which participants
+	// walk to each stop. DoorToDoor lists the participants with no stop
+	// within walking distance, who are still collected from home.
+	BusStops   []BusStopAssignment `json:"bus_stops,omitempty"`
+	DoorToDoor []int64             `json:"door_to_door,omitempty"`
+}
+
+// BusStopAssignment is one bus stop and the participants assigned to it.
+// WalkMeters is the longest straight-line walk from any of their homes.
+type BusStopAssignment struct {
+	Stop           Coordinates `json:"stop"`
+	ParticipantIDs []int64     `json:"participant_ids"`
+	WalkMeters     float64     `json:"walk_meters"`
+}
+
+// DepartureCollision flags two routes that leave the activity for nearly the
+// same first stop. Applied is set when the suggested swap was made.
+type DepartureCollision struct {
+	FirstDriverID       int64   `json:"first_driver_id"`
+	SecondDriverID      int64   `json:"second_driver_id"`
+	FirstParticipantID  int64   `json:"first_participant_id"`
+	SecondParticipantID int64   `json:"second_participant_id"`
+	DistanceMeters      float64 `json:"distance_meters"`
+	Suggestion          string  `json:"suggestion"`
+	Applied             bool    `json:"applied"`
+}
+
+// ForcedOrderConflict names the participants on one driver's route who all
+// force the same stop index.
+type ForcedOrderConflict struct {
+	DriverID       int64   `json:"driver_id"`
+	Index          int     `json:"index"`
+	ParticipantIDs []int64 `json:"participant_ids"`
+}
+
+// UnassignedParticipant is a participant the router declined to place
+type UnassignedParticipant struct {
+	Participant Participant `json:"participant"`
+	Reason      string      `json:"reason"`
+}
+
+// Backup describes a stored copy of the database file
+type Backup struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	SizeBytes int64     `json:"size_bytes"`
 }
 
 // DistanceCacheEntry represents a cached distance lookup
@@ -0,0 +1,220 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/png"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEncode_RoundTripsThroughPNG(t *testing.T) {
+	for _, text := range []string{
+		"https://example.com",
+		"https://www.google.com/maps/dir/?api=1&travelmode=driving&destination=41.31%2C-72.92&dir_action=navigate&waypoints=41.3%2C-72.9%7C41.32%2C-72.91",
+		strings.Repeat("navigate|", 120),
+	} {
+		code, err := Encode(text)
+		if err != nil {
+			t.Fatalf("Encode(%d bytes) error = %v", len(text), err)
+		}
+		image, err := code.PNG(3)
+		if err != nil {
+			t.Fatalf("PNG() error = %v", err)
+		}
+		got, err := decodePNG(image, 3)
+		if err != nil {
+			t.Fatalf("decode version %d symbol: %v", code.Version, err)
+		}
+		if got != text {
+			t.Fatalf("decoded %q, want %q", got, text)
+		}
+	}
+}
+
+func TestEncode_PicksSmallestVersion(t *testing.T) {
+	code, err := Encode("hello")
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if code.Version != 1 || code.Size != 21 {
+		t.Fatalf("version = %d size = %d, want version 1 size 21", code.Version, code.Size)
+	}
+	if _, err := Encode(strings.Repeat("x", 3000)); !errors.Is(err, ErrTooLong) {
+		t.Fatalf("Encode(3000 bytes) error = %v, want ErrTooLong", err)
+	}
+}
+
+func TestErrorCorrectionTablesFillEverySymbol(t *testing.T) {
+	for version := minVersion; version <= maxVersion; version++ {
+		raw := numRawDataModules(version) / 8
+		if blocks := errorCorrectionBlocks[version]; raw/blocks <= eccCodewordsPerBlock[version] {
+			t.Fatalf("version %d: %d blocks of %d codewords leave no room for data", version, blocks, raw/blocks)
+		}
+		if numDataCodewords(version) <= numDataCodewords(version-1) && version > minVersion {
+			t.Fatalf("version %d holds no more data than version %d", version, version-1)
+		}
+	}
+}
+
+func TestReedSolomonMatchesSpecExample(t *testing.T) {
+	// "HELLO WORLD" in alphanumeric mode at 1-M, as worked in the spec annex.
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := reedSolomonRemainder(data, reedSolomonDivisor(10)); !slices.Equal(got, want) {
+		t.Fatalf("error correction = %v, want %v", got, want)
+	}
+}
+
+func TestFormatAndVersionBitsMatchSpecTables(t *testing.T) {
+	wantFormat := []string{
+		"101010000010010", "101000100100101", "101111001111100", "101101101001011",
+		"100010111111001", "100000011001110", "100111110010111", "100101010100000",
+	}
+	for mask, want := range wantFormat {
+		code := newCode(1)
+		code.drawFormatBits(mask)
+		got := ""
+		for _, position := range [][2]int{{0, 8}, {1, 8}, {2, 8}, {3, 8}, {4, 8}, {5, 8}, {7, 8}, {8, 8}, {8, 7}, {8, 5}, {8, 4}, {8, 3}, {8, 2}, {8, 1}, {8, 0}} {
+			if code.Black(position[0], position[1]) {
+				got += "1"
+			} else {
+				got += "0"
+			}
+		}
+		if got != want {
+			t.Fatalf("mask %d format bits = %s, want %s", mask, got, want)
+		}
+	}
+
+	code := newCode(7)
+	got := ""
+	for i := 17; i >= 0; i-- {
+		if code.Black(i/3, code.Size-11+i%3) {
+			got += "1"
+		} else {
+			got += "0"
+		}
+	}
+	if want := "000111110010010100"; got != want {
+		t.Fatalf("version 7 bits = %s, want %s", got, want)
+	}
+}
+
+// decodePNG reads a symbol rendered by PNG back into its text. It checks the
+// format information and each block's error correction rather than using
+// them to repair damage.
+func decodePNG(data []byte, scale int) (string, error) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	size := img.Bounds().Dx()/scale - 2*quietZoneModules
+	version := (size - 17) / 4
+	if version < minVersion || version > maxVersion || version*4+17 != size {
+		return "", fmt.Errorf("symbol size %d is not a QR size", size)
+	}
+	dark := func(x, y int) bool {
+		r, _, _, _ := img.At((x+quietZoneModules)*scale+scale/2, (y+quietZoneModules)*scale+scale/2).RGBA()
+		return r < 0x8000
+	}
+
+	format := 0
+	for i, position := range [][2]int{{8, 0}, {8, 1}, {8, 2}, {8, 3}, {8, 4}, {8, 5}, {8, 7}, {8, 8}, {7, 8}, {5, 8}, {4, 8}, {3, 8}, {2, 8}, {1, 8}, {0, 8}} {
+		if dark(position[0], position[1]) {
+			format |= 1 << i
+		}
+	}
+	format ^= 0x5412
+	formatData := format >> 10
+	rem := formatData
+	for range 10 {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	if formatData<<10|rem != format {
+		return "", fmt.Errorf("format bits %015b fail their BCH check", format)
+	}
+	if level := formatData >> 3; level != formatECLevelM {
+		return "", fmt.Errorf("error correction level %02b, want M", level)
+	}
+	mask := formatData & 7
+
+	layout := newCode(version)
+	var bits bitBuffer
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := range size {
+			for j := range 2 {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = size - 1 - vert
+				}
+				if !layout.function[y][x] {
+					bits = append(bits, dark(x, y) != maskHit(mask, x, y))
+				}
+			}
+		}
+	}
+	codewords := bits.bytes()
+
+	numBlocks := errorCorrectionBlocks[version]
+	eccLen := eccCodewordsPerBlock[version]
+	raw := numRawDataModules(version) / 8
+	numShortBlocks := numBlocks - raw%numBlocks
+	shortDataLen := raw/numBlocks - eccLen
+	blocks := make([][]byte, numBlocks)
+	next := 0
+	for i := 0; i < shortDataLen+1; i++ {
+		for j := range blocks {
+			if i < shortDataLen || j >= numShortBlocks {
+				blocks[j] = append(blocks[j], codewords[next])
+				next++
+			}
+		}
+	}
+	var payload []byte
+	for j := range blocks {
+		payload = append(payload, blocks[j]...)
+	}
+	for i := range eccLen {
+		for j := range blocks {
+			blocks[j] = append(blocks[j], codewords[next+i*numBlocks+j])
+		}
+	}
+	divisor := reedSolomonDivisor(eccLen)
+	for j, block := range blocks {
+		dataLen := len(block) - eccLen
+		if !slices.Equal(reedSolomonRemainder(block[:dataLen], divisor), block[dataLen:]) {
+			return "", fmt.Errorf("block %d error correction does not match its data", j)
+		}
+	}
+
+	var payloadBits bitBuffer
+	for _, b := range payload {
+		payloadBits.append(int(b), 8)
+	}
+	read := func(offset, length int) int {
+		value := 0
+		for _, set := range payloadBits[offset : offset+length] {
+			value <<= 1
+			if set {
+				value |= 1
+			}
+		}
+		return value
+	}
+	if mode := read(0, 4); mode != 0b0100 {
+		return "", fmt.Errorf("mode %04b, want byte mode", mode)
+	}
+	countBits := characterCountBits(version)
+	length := read(4, countBits)
+	text := make([]byte, length)
+	for i := range text {
+		text[i] = byte(read(4+countBits+i*8, 8))
+	}
+	return string(text), nil
+}
@@ -0,0 +1,205 @@
+package routesession
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"ride-home-router/internal/distance"
+	"ride-home-router/internal/models"
+	"ride-home-router/internal/routing"
+	"time"
+)
+
+// persistedSessionTTL is how long a session survives without being touched
+// once sessions are kept on disk, both in memory and across restarts.
+const persistedSessionTTL = 24 * time.Hour
+
+// sessionRecord is a session's on-disk form.
+type sessionRecord struct {
+	ID                string                                `json:"id"`
+	OriginalRoutes    []models.CalculatedRoute              `json:"original_routes"`
+	CurrentRoutes     []models.CalculatedRoute              `json:"current_routes"`
+	DirtyRouteIndexes []int                                 `json:"dirty_route_indexes,omitempty"`
+	ManualOrderRoutes []int                                 `json:"manual_order_routes,omitempty"`
+	NoShows           []noShowRecord                        `json:"no_shows,omitempty"`
+	SelectedDrivers   []models.Driver                       `json:"selected_drivers"`
+	DriverOrgVehicles map[int64]*models.OrganizationVehicle `json:"driver_org_vehicles,omitempty"`
+	ActivityLocation  *models.ActivityLocation              `json:"activity_location,omitempty"`
+	UseMiles          bool                                  `json:"use_miles"`
+	RouteTime         string                                `json:"route_time"`
+	Mode              models.RouteMode                      `json:"mode"`
+	Caps              routing.RouteCaps                     `json:"caps"`
+	Options           routing.RouteOptions                  `json:"options"`
+	AvoidHighways     bool                                  `json:"avoid_highways,omitempty"`
+	Finalized         bool                                  `json:"finalized"`
+	Version           int64                                 `json:"version"`
+	LastAccessedAt    time.Time                             `json:"last_accessed_at"`
+}
+
+type noShowRecord struct {
+	Participant models.Participant `json:"participant"`
+	DriverID    int64              `json:"driver_id"`
+}
+
+// NewPersistentStore returns a store that writes every session change to the
+// file at path and starts with the sessions saved there, so in-progress edits
+// survive a restart. Sessions untouched for a day are dropped. A missing file
+// starts an empty store, as does one that cannot be parsed, which is renamed
+// with a .corrupt suffix.
+func NewPersistentStore(distanceCalc distance.DistanceCalculator, path string) (*Store, error) {
+	records, err := loadRecords(path)
+	if err != nil {
+		return nil, err
+	}
+	store := newStore(distanceCalc, persistedSessionTTL, defaultCleanupInterval, time.Now)
+	store.path = path
+	store.records = make(map[string]json.RawMessage, len(records))
+	now := store.now()
+	pruned := 0
+	for id, raw := range records {
+		var record sessionRecord
+		if err := json.Unmarshal(raw, &record); err != nil || record.ID != id {
+			log.Printf("[WARN] Dropping unreadable route session %s from %s", id, path)
+			pruned++
+			continue
+		}
+		if now.Sub(record.LastAccessedAt) > persistedSessionTTL {
+			pruned++
+			continue
+		}
+		store.sessions[id] = sessionFromRecord(record)
+		store.records[id] = raw
+	}
+	if pruned > 0 {
+		store.persistMu.Lock()
+		err := store.writeRecords()
+		store.persistMu.Unlock()
+		if err != nil {
+			store.Close()
+			return nil, err
+		}
+	}
+	log.Printf("[SESSION] Loaded route sessions: path=%s sessions=%d pruned=%d", path, len(store.sessions), pruned)
+	return store, nil
+}
+
+func loadRecords(path string) (map[string]json.RawMessage, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read route sessions: %w", err)
+	}
+	var records map[string]json.RawMessage
+	if err := json.Unmarshal(data, &records); err != nil {
+		// The file is only a convenience cache, so a damaged one is moved
+		// aside for inspection and the store starts empty.
+		log.Printf("[WARN] Failed to parse route sessions %s: %v", path, err)
+		if err := os.Rename(path, path+".corrupt"); err != nil {
+			log.Printf("[ERROR] Failed to move aside unreadable route sessions %s: %v", path, err)
+		}
+		return nil, nil
+	}
+	return records, nil
+}
+
+// persist writes a locked session out. A failed write is logged rather than
+// failing the edit: the session stays usable in memory.
+func (s *Store) persist(state *session) {
+	if s.path == "" {
+		return
+	}
+	raw, err := json.Marshal(recordOf(state))
+	if err != nil {
+		log.Printf("[ERROR] Failed to encode route session %s: %v", state.id, err)
+		return
+	}
+	s.persistMu.Lock()
+	defer s.persistMu.Unlock()
+	s.records[state.id] = raw
+	if err := s.writeRecords(); err != nil {
+		log.Printf("[ERROR] Failed to save route session %s: %v", state.id, err)
+	}
+}
+
+// forget drops a removed session from the file.
+func (s *Store) forget(id string) {
+	if s.path == "" {
+		return
+	}
+	s.persistMu.Lock()
+	defer s.persistMu.Unlock()
+	if _, ok := s.records[id]; !ok {
+		return
+	}
+	delete(s.records, id)
+	if err := s.writeRecords(); err != nil {
+		log.Printf("[ERROR] Failed to remove route session %s: %v", id, err)
+	}
+}
+
+// writeRecords replaces the file with the current records. The caller holds
+// persistMu.
+func (s *Store) writeRecords() error {
+	data, err := json.Marshal(s.records)
+	if err != nil {
+		return fmt.Errorf("failed to marshal route sessions: %w", err)
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write route sessions: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("failed to rename route sessions: %w", err)
+	}
+	return nil
+}
+
+func recordOf(state *session) sessionRecord {
+	noShows := make([]noShowRecord, len(state.noShows))
+	for i, entry := range state.noShows {
+		noShows[i] = noShowRecord{Participant: entry.participant, DriverID: entry.driverID}
+	}
+	return sessionRecord{
+		ID: state.id, OriginalRoutes: state.originalRoutes, CurrentRoutes: state.currentRoutes,
+		DirtyRouteIndexes: indexList(state.dirtyRouteIndexes), ManualOrderRoutes: indexList(state.manualOrderRoutes),
+		NoShows: noShows, SelectedDrivers: state.selectedDrivers, DriverOrgVehicles: state.driverOrgVehicles,
+		ActivityLocation: state.activityLocation, UseMiles: state.useMiles, RouteTime: state.routeTime,
+		Mode: state.mode, Caps: state.caps, Options: state.options, AvoidHighways: state.avoidHighways,
+		Finalized: state.finalized, Version: state.version, LastAccessedAt: state.lastAccessedAt,
+	}
+}
+
+func sessionFromRecord(record sessionRecord) *session {
+	noShows := make([]noShow, len(record.NoShows))
+	for i, entry := range record.NoShows {
+		noShows[i] = noShow{participant: entry.Participant, driverID: entry.DriverID}
+	}
+	return &session{
+		id: record.ID, originalRoutes: record.OriginalRoutes, currentRoutes: record.CurrentRoutes,
+		dirtyRouteIndexes: indexSet(record.DirtyRouteIndexes), manualOrderRoutes: indexSet(record.ManualOrderRoutes),
+		noShows: noShows, selectedDrivers: record.SelectedDrivers, driverOrgVehicles: record.DriverOrgVehicles,
+		activityLocation: record.ActivityLocation, useMiles: record.UseMiles, routeTime: record.RouteTime,
+		mode: record.Mode, caps: record.Caps, options: record.Options, avoidHighways: record.AvoidHighways,
+		finalized: record.Finalized, version: record.Version, lastAccessedAt: record.LastAccessedAt,
+	}
+}
+
+func indexList(set map[int]struct{}) []int {
+	list := make([]int, 0, len(set))
+	for index := range set {
+		list = append(list, index)
+	}
+	return list
+}
+
+func indexSet(list []int) map[int]struct{} {
+	set := make(map[int]struct{}, len(list))
+	for _, index := range list {
+		set[index] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,205 @@
+package routesession
+
+import (
+	"context"
+	"log"
+	"math/rand/v2"
+	"ride-home-router/internal/models"
+	"ride-home-router/internal/routing"
+)
+
+const (
+	defaultPerturbAttempts  = 20
+	defaultPerturbTolerance = 0.05
+)
+
+// PerturbOptions controls Perturb. Attempts is how many random relocations
+// and swaps are tried; Tolerance is the fraction by which the total route
+// duration may exceed the source plan's. Zero values use the defaults.
+type PerturbOptions struct {
+	Seed      uint64
+	Attempts  int
+	Tolerance float64
+}
+
+// Perturb copies a session into a new one and nudges the copy with random
+// household relocations and swaps between routes. Households with a locked
+// stop never move. Moves that break capacity, solo rides or attribute
+// requirements are skipped, and moves that push the
+// total route duration past the tolerance are undone. The same seed always
+// yields the same plan. The source session is left untouched.
+func (s *Store) Perturb(ctx context.Context, id string, options PerturbOptions) (Snapshot, error) {
+	state, err := s.lockSession(id)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer state.mu.Unlock()
+	if _, unbalanced := capacityState(state.currentRoutes); unbalanced {
+		return Snapshot{}, ErrUnbalanced
+	}
+	if options.Attempts <= 0 {
+		options.Attempts = defaultPerturbAttempts
+	}
+	if options.Tolerance <= 0 {
+		options.Tolerance = defaultPerturbTolerance
+	}
+
+	// Measure the source with fresh metrics so it compares fairly with the
+	// re-optimized candidates.
+	routes := copyRoutes(state.currentRoutes)
+	for i := range routes {
+		if err := s.recalculateRoute(ctx, state, &routes[i]); err != nil {
+			return Snapshot{}, err
+		}
+	}
+	limit := totalRouteDuration(routes) * (1 + options.Tolerance)
+	rng := rand.New(rand.NewPCG(options.Seed, 0))
+	precision := state.options.HouseholdPrecision
+	accepted := 0
+	for range options.Attempts {
+		if len(routes) < 2 {
+			break
+		}
+		first := rng.IntN(len(routes))
+		second := rng.IntN(len(routes) - 1)
+		if second >= first {
+			second++
+		}
+		candidate := copyRoutes(routes)
+		var ok bool
+		if rng.IntN(2) == 0 {
+			ok = relocateHousehold(candidate, first, second, precision, rng)
+		} else {
+			ok = swapHouseholds(candidate, first, second, precision, rng)
+		}
+		if !ok {
+			continue
+		}
+		for _, index := range []int{first, second} {
+			if err := s.optimizeRoute(ctx, state, &candidate[index]); err != nil {
+				return Snapshot{}, err
+			}
+		}
+		if totalRouteDuration(candidate) > limit {
+			continue
+		}
+		routes = candidate
+		accepted++
+	}
+
+	clone := &session{
+		id:                generateID(),
+		originalRoutes:    copyRoutes(routes),
+		currentRoutes:     routes,
+		dirtyRouteIndexes: make(map[int]struct{}),
+		manualOrderRoutes: make(map[int]struct{}),
+		noShows:           append([]noShow(nil), state.noShows...),
+		selectedDrivers:   append([]models.Driver(nil), state.selectedDrivers...),
+		driverOrgVehicles: copyVehicles(state.driverOrgVehicles),
+		activityLocation:  copyLocation(state.activityLocation),
+		useMiles:          state.useMiles,
+		routeTime:         state.routeTime,
+		mode:              state.mode,
+		caps:              state.caps,
+		options:           state.options,
+		avoidHighways:     state.avoidHighways,
+		lastAccessedAt:    s.now(),
+	}
+	s.register(clone)
+	log.Printf("[SESSION] Perturbed route session: id=%s source=%s seed=%d accepted=%d/%d", clone.id, id, options.Seed, accepted, options.Attempts)
+	return snapshotOf(clone), nil
+}
+
+// relocateHousehold moves one randomly chosen household from the first route
+// to the second, reporting false when the move is not allowed.
+func relocateHousehold(routes []models.CalculatedRoute, first, second, precision int, rng *rand.Rand) bool {
+	household := pickHousehold(routes[first].Stops, precision, rng)
+	if household == nil {
+		return false
+	}
+	remaining := removeHousehold(routes[first].Stops, household, precision)
+	capacity, _ := routeCapacity(routes[second])
+	if len(routes[second].Stops)+len(household) > capacity || !householdFits(routes[second].Driver, routes[second].Stops, household) {
+		return false
+	}
+	routes[first].Stops = remaining
+	routes[second].Stops = append(routes[second].Stops, household...)
+	return true
+}
+
+// swapHouseholds exchanges one randomly chosen household between two routes,
+// reporting false when the swap is not allowed.
+func swapHouseholds(routes []models.CalculatedRoute, first, second, precision int, rng *rand.Rand) bool {
+	firstHousehold := pickHousehold(routes[first].Stops, precision, rng)
+	secondHousehold := pickHousehold(routes[second].Stops, precision, rng)
+	if firstHousehold == nil || secondHousehold == nil {
+		return false
+	}
+	firstRemaining := removeHousehold(routes[first].Stops, firstHousehold, precision)
+	secondRemaining := removeHousehold(routes[second].Stops, secondHousehold, precision)
+	firstCapacity, _ := routeCapacity(routes[first])
+	secondCapacity, _ := routeCapacity(routes[second])
+	if len(firstRemaining)+len(secondHousehold) > firstCapacity || len(secondRemaining)+len(firstHousehold) > secondCapacity {
+		return false
+	}
+	if !householdFits(routes[first].Driver, firstRemaining, secondHousehold) || !householdFits(routes[second].Driver, secondRemaining, firstHousehold) {
+		return false
+	}
+	routes[first].Stops = append(firstRemaining, secondHousehold...)
+	routes[second].Stops = append(secondRemaining, firstHousehold...)
+	return true
+}
+
+// pickHousehold returns the stops in a randomly chosen stop's household, at
+// the session's grouping precision, so riders the router grouped always move
+// together. It returns nil when any of them is locked.
+func pickHousehold(stops []models.RouteStop, precision int, rng *rand.Rand) []models.RouteStop {
+	if len(stops) == 0 {
+		return nil
+	}
+	key := routing.HouseholdKey(stops[rng.IntN(len(stops))].Participant, precision)
+	var household []models.RouteStop
+	for _, stop := range stops {
+		if routing.HouseholdKey(stop.Participant, precision) == key {
+			if stop.Locked {
+				return nil
+			}
+			household = append(household, stop)
+		}
+	}
+	return household
+}
+
+func removeHousehold(stops, household []models.RouteStop, precision int) []models.RouteStop {
+	key := routing.HouseholdKey(household[0].Participant, precision)
+	remaining := make([]models.RouteStop, 0, len(stops))
+	for _, stop := range stops {
+		if routing.HouseholdKey(stop.Participant, precision) != key {
+			remaining = append(remaining, stop)
+		}
+	}
+	return remaining
+}
+
+// householdFits reports whether the driver can take every rider in the
+// household alongside the stops already on the route.
+func householdFits(driver *models.Driver, stops, household []models.RouteStop) bool {
+	if driver == nil {
+		return false
+	}
+	for _, stop := range household {
+		if !driver.CanCarry(stop.Participant) || !soloRideAllows(stops, stop.Participant) {
+			return false
+		}
+		stops = append(stops[:len(stops):len(stops)], stop)
+	}
+	return true
+}
+
+func totalRouteDuration(routes []models.CalculatedRoute) float64 {
+	total := 0.0
+	for _, route := range routes {
+		total += route.RouteDurationSecs
+	}
+	return total
+}
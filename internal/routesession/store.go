@@ -4,11 +4,15 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"ride-home-router/internal/distance"
 	"ride-home-router/internal/models"
 	"ride-home-router/internal/routing"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 )
@@ -16,6 +20,8 @@ import (
 const (
 	defaultTTL             = 2 * time.Hour
 	defaultCleanupInterval = 15 * time.Minute
+	// maxUndoDepth bounds how many edits a session can undo.
+	maxUndoDepth = 20
 )
 
 var (
@@ -25,9 +31,21 @@ var (
 	ErrParticipantNotInSource = errors.New("participant not found in source route")
 	ErrSwapMissingDriver      = errors.New("cannot swap - route is missing a driver")
 	ErrSwapCapacity           = errors.New("cannot swap - capacity constraints violated")
+	ErrInvalidCapacity        = errors.New("capacity must be at least 1")
+	ErrCapacityExceedsVehicle = errors.New("capacity exceeds the organization vehicle's seats")
 	ErrDriverNotSelected      = errors.New("driver not found in selected drivers")
 	ErrDriverAlreadyInRoutes  = errors.New("driver is already in routes")
 	ErrUnbalanced             = errors.New("routes must be balanced before saving")
+	ErrNoShowNotFound         = errors.New("participant is not marked as a no-show")
+	ErrDriverNotInSession     = errors.New("the no-show's driver is no longer in the session")
+	ErrFinalized              = errors.New("route session is finalized")
+	ErrSoloRide               = errors.New("a solo rider must be the only passenger on their route")
+	ErrExcludedByDriver       = errors.New("the driver has excluded this participant")
+	ErrNeedsAccessible        = errors.New("the participant needs an accessible vehicle")
+	ErrStaleVersion           = errors.New("route session was changed by another edit")
+	ErrNothingToUndo          = errors.New("there are no edits to undo")
+	ErrReoptimizeDeclines     = errors.New("re-optimizing would leave riders without a ride")
+	ErrReoptimizeBreaksLimits = errors.New("re-optimizing would put a route over its time limits")
 )
 
 type Move struct {
@@ -39,6 +57,15 @@ type Move struct {
 
 type ApplyMovesOptions struct {
 	RequireClaimedSource bool
+	// PreserveManualOrder keeps the stop order of routes the coordinator
+	// reordered by hand. A move within one route marks it manually ordered;
+	// later moves into or out of it recalculate its metrics without
+	// re-optimizing the order. Other affected routes are optimized as usual.
+	PreserveManualOrder bool
+	// Version is the session version the moves were planned against. When
+	// set, the moves are rejected with ErrStaleVersion if another edit has
+	// landed since.
+	Version int64
 }
 
 type CreateInput struct {
@@ -49,6 +76,15 @@ type CreateInput struct {
 	RouteTime         string
 	Mode              models.RouteMode
 	DriverOrgVehicles map[int64]*models.OrganizationVehicle
+	// Caps are the calculation's detour ceiling and ride cap, which edited
+	// routes keep reporting their compliance against.
+	Caps routing.RouteCaps
+	// Options are the calculation's other routing settings, which Reoptimize
+	// plans with again.
+	Options routing.RouteOptions
+	// AvoidHighways keeps the session's edits on the store's highway-free
+	// calculator; see SetAvoidHighwaysCalculator.
+	AvoidHighways bool
 }
 
 type Snapshot struct {
@@ -63,6 +99,33 @@ type Snapshot struct {
 	IsEditing        bool
 	OverCapacity     []bool
 	IsOutOfBalance   bool
+	NoShows          []models.Participant
+	Finalized        bool
+	// Version starts at 1 and goes up with every change to the session, so
+	// a client can tell whether its copy is current.
+	Version int64
+}
+
+// noShow remembers whose car a removed participant rode in so they can be
+// restored to it, wherever swaps have since moved that driver's route.
+type noShow struct {
+	participant models.Participant
+	driverID    int64
+}
+
+// StopETA is a stop's projected arrival for a given departure time.
+type StopETA struct {
+	Order       int
+	Participant *models.Participant
+	ArrivalAt   time.Time
+}
+
+// RouteETAs projects a single route's timeline from a custom departure time.
+type RouteETAs struct {
+	RouteIndex  int
+	DepartureAt time.Time
+	Stops       []StopETA
+	FinishAt    time.Time
 }
 
 type session struct {
@@ -70,27 +133,53 @@ type session struct {
 	originalRoutes    []models.CalculatedRoute
 	currentRoutes     []models.CalculatedRoute
 	dirtyRouteIndexes map[int]struct{}
+	// manualOrderRoutes are route indexes whose stop order was set by hand
+	// under PreserveManualOrder.
+	manualOrderRoutes map[int]struct{}
+	// history holds the state from before each edit, newest last, for Undo.
+	// Reset and RefreshParticipant clear it, since older entries would bring
+	// back a discarded plan or a participant's old details. It is kept in
+	// memory only.
+	history           []undoEntry
+	noShows           []noShow
 	selectedDrivers   []models.Driver
 	driverOrgVehicles map[int64]*models.OrganizationVehicle
 	activityLocation  *models.ActivityLocation
 	useMiles          bool
 	routeTime         string
 	mode              models.RouteMode
+	caps              routing.RouteCaps
+	options           routing.RouteOptions
+	avoidHighways     bool
 	lastAccessedAt    time.Time
+	finalized         bool
+	version           int64
 	deleted           bool
 	mu                sync.Mutex
 }
 
+// Store holds route sessions. Edits take the session version they were
+// planned against and fail with ErrStaleVersion once another edit has landed;
+// a zero version skips the check.
 type Store struct {
-	distanceCalc    distance.DistanceCalculator
-	sessions        map[string]*session
-	mu              sync.Mutex
-	ttl             time.Duration
-	cleanupInterval time.Duration
-	now             func() time.Time
-	stopCleanup     chan struct{}
-	cleanupDone     chan struct{}
-	closeOnce       sync.Once
+	distanceCalc distance.DistanceCalculator
+	// avoidHighwaysCalc measures sessions calculated to avoid highways; nil
+	// measures them with distanceCalc.
+	avoidHighwaysCalc distance.DistanceCalculator
+	sessions          map[string]*session
+	mu                sync.Mutex
+	ttl               time.Duration
+	cleanupInterval   time.Duration
+	now               func() time.Time
+	stopCleanup       chan struct{}
+	cleanupDone       chan struct{}
+	closeOnce         sync.Once
+	// path, when set, is the file every session change is written to; see
+	// NewPersistentStore. records holds each session's last written form and
+	// is guarded by persistMu, which is only ever taken after a session's mu.
+	path      string
+	records   map[string]json.RawMessage
+	persistMu sync.Mutex
 }
 
 func NewStore(distanceCalc distance.DistanceCalculator) *Store {
@@ -107,24 +196,51 @@ func newStore(distanceCalc distance.DistanceCalculator, ttl, cleanupInterval tim
 	return store
 }
 
+// SetAvoidHighwaysCalculator sets the calculator that sessions created with
+// AvoidHighways are measured with. Call it before the store is shared.
+func (s *Store) SetAvoidHighwaysCalculator(calc distance.DistanceCalculator) {
+	s.avoidHighwaysCalc = calc
+}
+
 func (s *Store) Create(input CreateInput) Snapshot {
 	state := &session{
 		id:                generateID(),
 		originalRoutes:    copyRoutes(input.Routes),
 		currentRoutes:     copyRoutes(input.Routes),
 		dirtyRouteIndexes: make(map[int]struct{}),
+		manualOrderRoutes: make(map[int]struct{}),
 		selectedDrivers:   append([]models.Driver(nil), input.SelectedDrivers...),
 		driverOrgVehicles: copyVehicles(input.DriverOrgVehicles),
 		activityLocation:  copyLocation(input.ActivityLocation),
 		useMiles:          input.UseMiles,
 		routeTime:         input.RouteTime,
 		mode:              input.Mode,
+		caps:              input.Caps,
+		options:           input.Options,
+		avoidHighways:     input.AvoidHighways,
 		lastAccessedAt:    s.now(),
 	}
+	s.register(state)
+	log.Printf("[SESSION] Created route session: id=%s routes=%d drivers=%d mode=%s", state.id, len(input.Routes), len(input.SelectedDrivers), input.Mode)
+	return snapshotOf(state)
+}
+
+// register adds a new session at version 1.
+func (s *Store) register(state *session) {
+	state.version = 1
 	s.mu.Lock()
 	s.sessions[state.id] = state
 	s.mu.Unlock()
-	log.Printf("[SESSION] Created route session: id=%s routes=%d drivers=%d mode=%s", state.id, len(input.Routes), len(input.SelectedDrivers), input.Mode)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	s.persist(state)
+}
+
+// changed records an edit to a locked session: it bumps the version, writes
+// the session out and returns its snapshot.
+func (s *Store) changed(state *session) Snapshot {
+	state.version++
+	s.persist(state)
 	return snapshotOf(state)
 }
 
@@ -138,7 +254,7 @@ func (s *Store) Snapshot(id string) (Snapshot, bool) {
 }
 
 func (s *Store) ApplyMoves(ctx context.Context, id string, moves []Move, options ApplyMovesOptions) (Snapshot, error) {
-	state, err := s.lockSession(id)
+	state, err := s.lockEditableVersion(id, options.Version)
 	if err != nil {
 		return Snapshot{}, err
 	}
@@ -146,7 +262,12 @@ func (s *Store) ApplyMoves(ctx context.Context, id string, moves []Move, options
 
 	backupRoutes := copyRoutes(state.currentRoutes)
 	backupDirty := copyDirty(state.dirtyRouteIndexes)
-	rollback := func() { state.currentRoutes = backupRoutes; state.dirtyRouteIndexes = backupDirty }
+	backupManual := copyDirty(state.manualOrderRoutes)
+	rollback := func() {
+		state.currentRoutes = backupRoutes
+		state.dirtyRouteIndexes = backupDirty
+		state.manualOrderRoutes = backupManual
+	}
 	for _, move := range moves {
 		from, ok := findParticipant(state.currentRoutes, move.ParticipantID)
 		if !ok {
@@ -161,19 +282,23 @@ func (s *Store) ApplyMoves(ctx context.Context, id string, moves []Move, options
 			rollback()
 			return Snapshot{}, err
 		}
+		if options.PreserveManualOrder && from == move.ToRouteIndex {
+			state.manualOrderRoutes[from] = struct{}{}
+		}
 		_, unbalanced := capacityState(state.currentRoutes)
 		if !unbalanced {
-			if err := s.recalculateDirty(ctx, state); err != nil {
+			if err := s.recalculateDirty(ctx, state, options.PreserveManualOrder); err != nil {
 				rollback()
 				return Snapshot{}, err
 			}
 		}
 	}
-	return snapshotOf(state), nil
+	pushHistory(state, backupRoutes, backupManual)
+	return s.changed(state), nil
 }
 
-func (s *Store) SwapDrivers(ctx context.Context, id string, first, second int) (Snapshot, error) {
-	state, err := s.lockSession(id)
+func (s *Store) SwapDrivers(ctx context.Context, id string, version int64, first, second int) (Snapshot, error) {
+	state, err := s.lockEditableVersion(id, version)
 	if err != nil {
 		return Snapshot{}, err
 	}
@@ -194,6 +319,12 @@ func (s *Store) SwapDrivers(ctx context.Context, id string, first, second int) (
 	if len(route1.Stops) > cap2 || len(route2.Stops) > cap1 {
 		return Snapshot{}, ErrSwapCapacity
 	}
+	if err := driverAllowsStops(route2.Driver, route1.Stops); err != nil {
+		return Snapshot{}, err
+	}
+	if err := driverAllowsStops(route1.Driver, route2.Stops); err != nil {
+		return Snapshot{}, err
+	}
 	route1.Driver, route2.Driver = route2.Driver, route1.Driver
 	if err := s.recalculateRoute(ctx, state, route1); err != nil {
 		state.currentRoutes = backup
@@ -203,22 +334,318 @@ func (s *Store) SwapDrivers(ctx context.Context, id string, first, second int) (
 		state.currentRoutes = backup
 		return Snapshot{}, err
 	}
-	return snapshotOf(state), nil
+	pushHistory(state, backup, copyDirty(state.manualOrderRoutes))
+	return s.changed(state), nil
+}
+
+// SetCapacity changes a route's effective capacity in place, so later moves
+// can fill it up to the new seat count without re-running the optimizer. An
+// organization vehicle cannot be given more seats than it physically has.
+func (s *Store) SetCapacity(id string, version int64, routeIndex, capacity int) (Snapshot, error) {
+	state, err := s.lockEditableVersion(id, version)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer state.mu.Unlock()
+	if routeIndex < 0 || routeIndex >= len(state.currentRoutes) {
+		return Snapshot{}, ErrInvalidRouteIndex
+	}
+	if capacity < 1 {
+		return Snapshot{}, ErrInvalidCapacity
+	}
+	route := &state.currentRoutes[routeIndex]
+	if route.OrgVehicleID != 0 {
+		for _, vehicle := range state.driverOrgVehicles {
+			if vehicle != nil && vehicle.ID == route.OrgVehicleID && capacity > vehicle.Capacity {
+				return Snapshot{}, ErrCapacityExceedsVehicle
+			}
+		}
+	}
+	pushHistory(state, copyRoutes(state.currentRoutes), copyDirty(state.manualOrderRoutes))
+	route.EffectiveCapacity = capacity
+	return s.changed(state), nil
+}
+
+// LockStop sets whether a participant's stop is locked. A locked stop stays
+// with its driver, and in its place among the route's locked stops, when the
+// route is re-optimized or the session perturbed. A coordinator's own moves
+// still apply and carry the lock along.
+func (s *Store) LockStop(id string, version int64, riderID int64, locked bool) (Snapshot, error) {
+	state, err := s.lockEditableVersion(id, version)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer state.mu.Unlock()
+	routeIndex, ok := findParticipant(state.currentRoutes, riderID)
+	if !ok {
+		return Snapshot{}, ErrParticipantNotFound
+	}
+	pushHistory(state, copyRoutes(state.currentRoutes), copyDirty(state.manualOrderRoutes))
+	for i, stop := range state.currentRoutes[routeIndex].Stops {
+		if participantID(stop.Participant) == riderID {
+			state.currentRoutes[routeIndex].Stops[i].Locked = locked
+		}
+	}
+	return s.changed(state), nil
+}
+
+// Summary recomputes the session's summary from its current routes.
+func (s *Store) Summary(id string) (models.RoutingSummary, error) {
+	state, err := s.lockSession(id)
+	if err != nil {
+		return models.RoutingSummary{}, err
+	}
+	defer state.mu.Unlock()
+	return CalculateSummary(state.currentRoutes), nil
+}
+
+// RemovalSavings ranks the session's participants by what taking each one off
+// their route would save, costliest to serve first. The session is not
+// modified.
+func (s *Store) RemovalSavings(ctx context.Context, id string) ([]routing.RemovalSaving, error) {
+	state, err := s.lockSession(id)
+	if err != nil {
+		return nil, err
+	}
+	defer state.mu.Unlock()
+	if state.activityLocation == nil {
+		return nil, errors.New("activity location is required")
+	}
+	return routing.RemovalSavings(ctx, s.distancesFor(state), state.activityLocation.GetCoords(), state.mode, state.currentRoutes)
+}
+
+// SpareSeats pools the free seats of the session's used routes by where their
+// stops center, chaining routes within radiusMeters of each other.
+func (s *Store) SpareSeats(id string, radiusMeters float64) ([]routing.SpareSeatArea, error) {
+	state, err := s.lockSession(id)
+	if err != nil {
+		return nil, err
+	}
+	defer state.mu.Unlock()
+	if state.activityLocation == nil {
+		return nil, errors.New("activity location is required")
+	}
+	return routing.SpareSeats(state.activityLocation.GetCoords(), state.currentRoutes, radiusMeters), nil
 }
 
-func (s *Store) Reset(id string) (Snapshot, error) {
+// RouteETAs computes arrival times for one route without modifying the session.
+func (s *Store) RouteETAs(id string, routeIndex int, departure time.Time) (RouteETAs, error) {
 	state, err := s.lockSession(id)
+	if err != nil {
+		return RouteETAs{}, err
+	}
+	defer state.mu.Unlock()
+	if routeIndex < 0 || routeIndex >= len(state.currentRoutes) {
+		return RouteETAs{}, ErrInvalidRouteIndex
+	}
+	route := copyRoutes(state.currentRoutes[routeIndex : routeIndex+1])[0]
+	result := RouteETAs{RouteIndex: routeIndex, DepartureAt: departure, Stops: make([]StopETA, len(route.Stops))}
+	for i, stop := range route.Stops {
+		result.Stops[i] = StopETA{Order: stop.Order, Participant: stop.Participant, ArrivalAt: departure.Add(secondsDuration(stop.CumulativeDurationSecs))}
+	}
+	result.FinishAt = departure.Add(secondsDuration(route.RouteDurationSecs))
+	return result, nil
+}
+
+func (s *Store) Reset(id string, version int64) (Snapshot, error) {
+	state, err := s.lockEditableVersion(id, version)
 	if err != nil {
 		return Snapshot{}, err
 	}
 	defer state.mu.Unlock()
 	state.currentRoutes = copyRoutes(state.originalRoutes)
 	state.dirtyRouteIndexes = make(map[int]struct{})
-	return snapshotOf(state), nil
+	state.manualOrderRoutes = make(map[int]struct{})
+	state.noShows = nil
+	state.history = nil
+	return s.changed(state), nil
 }
 
-func (s *Store) AddDriver(ctx context.Context, id string, driverID int64) (Snapshot, error) {
-	state, err := s.lockSession(id)
+// Undo restores the routes, no-shows and hand-ordered routes from before the
+// most recent edit.
+// Only the last maxUndoDepth edits can be undone.
+func (s *Store) Undo(id string, version int64) (Snapshot, error) {
+	state, err := s.lockEditableVersion(id, version)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer state.mu.Unlock()
+	if len(state.history) == 0 {
+		return Snapshot{}, ErrNothingToUndo
+	}
+	last := len(state.history) - 1
+	state.currentRoutes = copyRoutes(state.history[last].routes)
+	state.noShows = state.history[last].noShows
+	state.manualOrderRoutes = state.history[last].manualOrder
+	state.history[last] = undoEntry{}
+	state.history = state.history[:last]
+	// Balanced routes were recalculated when they were saved; unbalanced ones
+	// may hold stale metrics, so the next balancing move refreshes them all.
+	state.dirtyRouteIndexes = make(map[int]struct{})
+	if _, unbalanced := capacityState(state.currentRoutes); unbalanced {
+		for i := range state.currentRoutes {
+			state.dirtyRouteIndexes[i] = struct{}{}
+		}
+	}
+	return s.changed(state), nil
+}
+
+// MarkNoShow removes a participant from their route and recalculates it. When
+// reoptimize is set the affected route's stop order is optimized again.
+func (s *Store) MarkNoShow(ctx context.Context, id string, version int64, participantID int64, reoptimize bool) (Snapshot, error) {
+	state, err := s.lockEditableVersion(id, version)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer state.mu.Unlock()
+	routeIndex, ok := findParticipant(state.currentRoutes, participantID)
+	if !ok {
+		return Snapshot{}, ErrParticipantNotFound
+	}
+	backup := copyRoutes(state.currentRoutes)
+	route := &state.currentRoutes[routeIndex]
+	var removed models.Participant
+	for i, stop := range route.Stops {
+		if stop.Participant != nil && stop.Participant.ID == participantID {
+			removed = *stop.Participant
+			route.Stops = append(route.Stops[:i], route.Stops[i+1:]...)
+			break
+		}
+	}
+	if reoptimize {
+		err = s.optimizeRoute(ctx, state, route)
+	} else {
+		err = s.recalculateRoute(ctx, state, route)
+	}
+	if err != nil {
+		state.currentRoutes = backup
+		return Snapshot{}, err
+	}
+	delete(state.dirtyRouteIndexes, routeIndex)
+	pushHistory(state, backup, copyDirty(state.manualOrderRoutes))
+	state.noShows = append(state.noShows, noShow{participant: removed, driverID: driverID(route.Driver)})
+	return s.changed(state), nil
+}
+
+// RestoreNoShow returns a no-show participant to the driver they were removed
+// from and re-optimizes that route's stop order. The driver must still be
+// allowed to carry them.
+func (s *Store) RestoreNoShow(ctx context.Context, id string, version int64, participantID int64) (Snapshot, error) {
+	state, err := s.lockEditableVersion(id, version)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer state.mu.Unlock()
+	index := -1
+	for i, entry := range state.noShows {
+		if entry.participant.ID == participantID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return Snapshot{}, ErrNoShowNotFound
+	}
+	entry := state.noShows[index]
+	routeIndex := -1
+	for i := range state.currentRoutes {
+		if driverID(state.currentRoutes[i].Driver) == entry.driverID {
+			routeIndex = i
+			break
+		}
+	}
+	if routeIndex < 0 {
+		return Snapshot{}, ErrDriverNotInSession
+	}
+	backup := copyRoutes(state.currentRoutes)
+	participant := entry.participant
+	route := &state.currentRoutes[routeIndex]
+	if !soloRideAllows(route.Stops, &participant) {
+		return Snapshot{}, ErrSoloRide
+	}
+	if err := driverAllows(route.Driver, &participant); err != nil {
+		return Snapshot{}, err
+	}
+	route.Stops = append(route.Stops, models.RouteStop{Participant: &participant})
+	if err := s.optimizeRoute(ctx, state, route); err != nil {
+		state.currentRoutes = backup
+		return Snapshot{}, err
+	}
+	pushHistory(state, backup, copyDirty(state.manualOrderRoutes))
+	state.noShows = append(state.noShows[:index:index], state.noShows[index+1:]...)
+	return s.changed(state), nil
+}
+
+// RefreshParticipant replaces a participant's details, such as a changed
+// address and its coordinates, everywhere the session holds them and
+// re-optimizes the route that carries them. The original plan keeps its stop
+// order but gets fresh metrics, so Reset never brings back the old address.
+func (s *Store) RefreshParticipant(ctx context.Context, id string, version int64, participant models.Participant) (Snapshot, error) {
+	state, err := s.lockEditableVersion(id, version)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer state.mu.Unlock()
+
+	for i := range state.noShows {
+		if state.noShows[i].participant.ID == participant.ID {
+			if err := s.refreshOriginal(ctx, state, participant); err != nil {
+				return Snapshot{}, err
+			}
+			state.noShows[i].participant = participant
+			state.history = nil
+			return s.changed(state), nil
+		}
+	}
+	routeIndex, ok := findParticipant(state.currentRoutes, participant.ID)
+	if !ok {
+		return Snapshot{}, ErrParticipantNotFound
+	}
+	route := &state.currentRoutes[routeIndex]
+	others := make([]models.RouteStop, 0, len(route.Stops))
+	for _, stop := range route.Stops {
+		if participantID(stop.Participant) != participant.ID {
+			others = append(others, stop)
+		}
+	}
+	if !soloRideAllows(others, &participant) {
+		return Snapshot{}, ErrSoloRide
+	}
+
+	backup := copyRoutes(state.currentRoutes)
+	replaceParticipant(route, participant)
+	if err := s.optimizeRoute(ctx, state, route); err != nil {
+		state.currentRoutes = backup
+		return Snapshot{}, err
+	}
+	if err := s.refreshOriginal(ctx, state, participant); err != nil {
+		state.currentRoutes = backup
+		return Snapshot{}, err
+	}
+	delete(state.dirtyRouteIndexes, routeIndex)
+	state.history = nil
+	return s.changed(state), nil
+}
+
+// refreshOriginal replaces the participant in the original plan, keeping its
+// stop order, so Reset never brings back their old details.
+func (s *Store) refreshOriginal(ctx context.Context, state *session, participant models.Participant) error {
+	originalIndex, ok := findParticipant(state.originalRoutes, participant.ID)
+	if !ok {
+		return nil
+	}
+	originalBackup := copyRoutes(state.originalRoutes)
+	original := &state.originalRoutes[originalIndex]
+	replaceParticipant(original, participant)
+	if err := s.recalculateRoute(ctx, state, original); err != nil {
+		state.originalRoutes = originalBackup
+		return err
+	}
+	return nil
+}
+
+func (s *Store) AddDriver(ctx context.Context, id string, version int64, driverID int64) (Snapshot, error) {
+	state, err := s.lockEditableVersion(id, version)
 	if err != nil {
 		return Snapshot{}, err
 	}
@@ -238,15 +665,154 @@ func (s *Store) AddDriver(ctx context.Context, id string, driverID int64) (Snaps
 			return Snapshot{}, ErrDriverAlreadyInRoutes
 		}
 	}
-	newRoute := models.CalculatedRoute{Driver: driver, Stops: []models.RouteStop{}, EffectiveCapacity: driver.VehicleCapacity, Mode: state.mode}
+	newRoute := models.CalculatedRoute{Driver: driver, Stops: []models.RouteStop{}, EffectiveCapacity: driver.VehicleCapacity, Mode: state.mode, Color: models.RouteColor(driver.ID)}
 	if vehicle := state.driverOrgVehicles[driverID]; vehicle != nil {
 		newRoute.OrgVehicleID, newRoute.OrgVehicleName, newRoute.EffectiveCapacity = vehicle.ID, vehicle.Name, vehicle.Capacity
 	}
 	if err := s.recalculateRoute(ctx, state, &newRoute); err != nil {
 		return Snapshot{}, err
 	}
+	pushHistory(state, copyRoutes(state.currentRoutes), copyDirty(state.manualOrderRoutes))
 	state.currentRoutes = append(state.currentRoutes, newRoute)
-	return snapshotOf(state), nil
+	return s.changed(state), nil
+}
+
+// Reoptimize reassigns every unlocked rider across the session's current
+// drivers with router, under the calculation's caps and options, and replaces
+// the current routes with the result. Locked riders stay on their route in
+// their order and use up seats there, and the detour they add uses up the
+// driver's longest route; the original plan is kept for Reset. When the
+// router would decline riders over the detour ceiling, the session is left
+// unchanged and ErrReoptimizeDeclines names them. Routes keep the router's
+// order unless it merged riders into locked or pinned stops; those are
+// re-sorted under the same options, and ErrReoptimizeBreaksLimits leaves the
+// session unchanged when that order breaks a time limit.
+func (s *Store) Reoptimize(ctx context.Context, id string, version int64, router routing.Router) (Snapshot, error) {
+	state, err := s.lockEditableVersion(id, version)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer state.mu.Unlock()
+	if state.activityLocation == nil {
+		return Snapshot{}, errors.New("activity location is required")
+	}
+
+	routes := copyRoutes(state.currentRoutes)
+	request := routing.RoutingRequest{
+		InstituteCoords:        state.activityLocation.GetCoords(),
+		Mode:                   state.mode,
+		MaxParticipantRideSecs: state.caps.MaxRideSecs,
+		MaxDetourSecs:          state.caps.MaxDetourSecs,
+	}
+	state.options.ApplyTo(&request)
+	if state.avoidHighways {
+		request.Distances = s.distancesFor(state)
+	}
+	for i := range routes {
+		route := &routes[i]
+		locked := make([]models.RouteStop, 0, len(route.Stops))
+		for _, stop := range route.Stops {
+			if stop.Locked {
+				locked = append(locked, stop)
+			} else if stop.Participant != nil {
+				request.Participants = append(request.Participants, *stop.Participant)
+			}
+		}
+		route.Stops = locked
+		capacity, _ := routeCapacity(*route)
+		if route.Driver == nil || capacity <= len(locked) {
+			continue
+		}
+		driver := *route.Driver
+		driver.VehicleCapacity = capacity - len(locked)
+		if driver.MaxRouteDurationSecs > 0 && len(locked) > 0 {
+			if err := s.recalculateRoute(ctx, state, route); err != nil {
+				return Snapshot{}, err
+			}
+			driver.MaxRouteDurationSecs -= route.DetourSecs
+			if driver.MaxRouteDurationSecs <= 0 {
+				continue
+			}
+		}
+		request.Drivers = append(request.Drivers, driver)
+	}
+	merged := make(map[int]bool)
+	if len(request.Participants) > 0 {
+		result, err := router.CalculateRoutes(ctx, &request)
+		if err != nil {
+			return Snapshot{}, err
+		}
+		if len(result.Unassigned) > 0 {
+			names := make([]string, len(result.Unassigned))
+			for i, entry := range result.Unassigned {
+				names[i] = entry.Participant.Name
+			}
+			return Snapshot{}, fmt.Errorf("%w: %s", ErrReoptimizeDeclines, strings.Join(names, ", "))
+		}
+		for _, calculated := range result.Routes {
+			for i := range routes {
+				if driverID(routes[i].Driver) == driverID(calculated.Driver) && len(calculated.Stops) > 0 {
+					for _, stop := range calculated.Stops {
+						routes[i].Stops = append(routes[i].Stops, models.RouteStop{Participant: stop.Participant})
+					}
+					merged[i] = true
+				}
+			}
+		}
+	}
+	for i := range routes {
+		route := &routes[i]
+		if !merged[i] || !holdsLockedOrPinnedStops(*route) {
+			if err := s.recalculateRoute(ctx, state, route); err != nil {
+				return Snapshot{}, err
+			}
+			continue
+		}
+		if err := routing.OptimizeRouteOrderFor(ctx, s.distancesFor(state), &request, route); err != nil {
+			if errors.Is(err, routing.ErrRouteOrderOutsideLimits) {
+				return Snapshot{}, fmt.Errorf("%w: route %d", ErrReoptimizeBreaksLimits, i+1)
+			}
+			return Snapshot{}, err
+		}
+		state.caps.Apply(route)
+	}
+
+	pushHistory(state, state.currentRoutes, state.manualOrderRoutes)
+	state.currentRoutes = routes
+	state.dirtyRouteIndexes = make(map[int]struct{})
+	state.manualOrderRoutes = make(map[int]struct{})
+	return s.changed(state), nil
+}
+
+// holdsLockedOrPinnedStops reports whether a route mixes stops the router did
+// not order, or stops whose position a participant forces, so its order must
+// be worked out again after Reoptimize merges them.
+func holdsLockedOrPinnedStops(route models.CalculatedRoute) bool {
+	return slices.ContainsFunc(route.Stops, func(stop models.RouteStop) bool {
+		return stop.Locked || stop.Participant != nil && stop.Participant.ForcedOrder != nil
+	})
+}
+
+// Finalize marks the session read-only. Edits fail with ErrFinalized until
+// Reopen is called.
+func (s *Store) Finalize(id string) (Snapshot, error) {
+	return s.setFinalized(id, true)
+}
+
+// Reopen clears a session's finalized flag so it can be edited again.
+func (s *Store) Reopen(id string) (Snapshot, error) {
+	return s.setFinalized(id, false)
+}
+
+func (s *Store) setFinalized(id string, finalized bool) (Snapshot, error) {
+	state, err := s.lockSession(id)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer state.mu.Unlock()
+	state.finalized = finalized
+	log.Printf("[SESSION] Route session finalized=%t: id=%s", finalized, id)
+	return s.changed(state), nil
 }
 
 func (s *Store) SaveSnapshot(id string) (models.RoutingResult, error) {
@@ -259,7 +825,7 @@ func (s *Store) SaveSnapshot(id string) (models.RoutingResult, error) {
 	if unbalanced {
 		return models.RoutingResult{}, ErrUnbalanced
 	}
-	return models.RoutingResult{Routes: copyRoutes(state.currentRoutes), Summary: calculateSummary(state.currentRoutes), Mode: state.mode}, nil
+	return models.RoutingResult{Routes: copyRoutes(state.currentRoutes), Summary: CalculateSummary(state.currentRoutes), Mode: state.mode}, nil
 }
 
 func (s *Store) Delete(id string) {
@@ -277,6 +843,34 @@ func (s *Store) Delete(id string) {
 
 func (s *Store) Close() { s.closeOnce.Do(func() { close(s.stopCleanup); <-s.cleanupDone }) }
 
+// lockEditableVersion is lockEditableSession for edits planned against a
+// session version. A nonzero version that is no longer current fails with
+// ErrStaleVersion; zero skips the check.
+func (s *Store) lockEditableVersion(id string, version int64) (*session, error) {
+	state, err := s.lockEditableSession(id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && version != state.version {
+		state.mu.Unlock()
+		return nil, ErrStaleVersion
+	}
+	return state, nil
+}
+
+// lockEditableSession is lockSession for operations that modify routes.
+func (s *Store) lockEditableSession(id string) (*session, error) {
+	state, err := s.lockSession(id)
+	if err != nil {
+		return nil, err
+	}
+	if state.finalized {
+		state.mu.Unlock()
+		return nil, ErrFinalized
+	}
+	return state, nil
+}
+
 func (s *Store) lockSession(id string) (*session, error) {
 	s.mu.Lock()
 	state := s.sessions[id]
@@ -302,10 +896,14 @@ func (s *Store) lockSession(id string) (*session, error) {
 
 func (s *Store) remove(id string, state *session) {
 	s.mu.Lock()
-	if s.sessions[id] == state {
+	removed := s.sessions[id] == state
+	if removed {
 		delete(s.sessions, id)
 	}
 	s.mu.Unlock()
+	if removed {
+		s.forget(id)
+	}
 }
 
 func (s *Store) cleanupLoop() {
@@ -349,27 +947,57 @@ func (s *Store) deleteExpired(now time.Time) {
 	}
 }
 
-func (s *Store) recalculateDirty(ctx context.Context, state *session) error {
+// recalculateDirty refreshes every route a move touched. Routes are
+// re-optimized unless preserveManualOrder is set and the coordinator ordered
+// them by hand, in which case only their metrics are recalculated.
+func (s *Store) recalculateDirty(ctx context.Context, state *session, preserveManualOrder bool) error {
 	for index := range state.dirtyRouteIndexes {
 		if index < 0 || index >= len(state.currentRoutes) {
 			continue
 		}
-		if state.activityLocation == nil {
-			return errors.New("activity location is required")
+		if _, manual := state.manualOrderRoutes[index]; preserveManualOrder && manual {
+			if err := s.recalculateRoute(ctx, state, &state.currentRoutes[index]); err != nil {
+				return err
+			}
+			continue
 		}
-		if err := routing.OptimizeRouteOrder(ctx, s.distanceCalc, state.activityLocation.GetCoords(), state.mode, &state.currentRoutes[index]); err != nil {
+		if err := s.optimizeRoute(ctx, state, &state.currentRoutes[index]); err != nil {
 			return err
 		}
+		delete(state.manualOrderRoutes, index)
 	}
 	state.dirtyRouteIndexes = make(map[int]struct{})
 	return nil
 }
 
+// distancesFor returns the calculator the session was calculated with.
+func (s *Store) distancesFor(state *session) distance.DistanceCalculator {
+	if state.avoidHighways && s.avoidHighwaysCalc != nil {
+		return s.avoidHighwaysCalc
+	}
+	return s.distanceCalc
+}
+
+func (s *Store) optimizeRoute(ctx context.Context, state *session, route *models.CalculatedRoute) error {
+	if state.activityLocation == nil {
+		return errors.New("activity location is required")
+	}
+	if err := routing.OptimizeRouteOrder(ctx, s.distancesFor(state), state.activityLocation.GetCoords(), state.mode, route); err != nil {
+		return err
+	}
+	state.caps.Apply(route)
+	return nil
+}
+
 func (s *Store) recalculateRoute(ctx context.Context, state *session, route *models.CalculatedRoute) error {
 	if state.activityLocation == nil {
 		return errors.New("activity location is required")
 	}
-	return routing.PopulateRouteMetrics(ctx, s.distanceCalc, state.activityLocation.GetCoords(), state.mode, route)
+	if err := routing.PopulateRouteMetrics(ctx, s.distancesFor(state), state.activityLocation.GetCoords(), state.mode, route); err != nil {
+		return err
+	}
+	state.caps.Apply(route)
+	return nil
 }
 
 func applyMove(state *session, move Move, from int) error {
@@ -387,9 +1015,17 @@ func applyMove(state *session, move Move, from int) error {
 	if stopIndex < 0 {
 		return ErrParticipantNotFound
 	}
-	participant := fromRoute.Stops[stopIndex].Participant
+	participant, locked := fromRoute.Stops[stopIndex].Participant, fromRoute.Stops[stopIndex].Locked
+	if from != move.ToRouteIndex && !soloRideAllows(toRoute.Stops, participant) {
+		return ErrSoloRide
+	}
+	if from != move.ToRouteIndex {
+		if err := driverAllows(toRoute.Driver, participant); err != nil {
+			return err
+		}
+	}
 	fromRoute.Stops = append(fromRoute.Stops[:stopIndex], fromRoute.Stops[stopIndex+1:]...)
-	newStop := models.RouteStop{Participant: participant}
+	newStop := models.RouteStop{Participant: participant, Locked: locked}
 	if move.InsertAtPosition < 0 || move.InsertAtPosition >= len(toRoute.Stops) {
 		toRoute.Stops = append(toRoute.Stops, newStop)
 	} else {
@@ -400,26 +1036,122 @@ func applyMove(state *session, move Move, from int) error {
 	return nil
 }
 
+// driverAllows reports whether driver may carry participant: ErrExcludedByDriver
+// when the driver has excluded them and ErrNeedsAccessible when they need an
+// accessible vehicle the driver does not have.
+func driverAllows(driver *models.Driver, participant *models.Participant) error {
+	if driver == nil {
+		return nil
+	}
+	if driver.Excludes(participant) {
+		return ErrExcludedByDriver
+	}
+	if participant.RequiresAccessible && !driver.IsAccessible {
+		return ErrNeedsAccessible
+	}
+	return nil
+}
+
+// driverAllowsStops is driverAllows for every participant on stops.
+func driverAllowsStops(driver *models.Driver, stops []models.RouteStop) error {
+	for _, stop := range stops {
+		if stop.Participant == nil {
+			continue
+		}
+		if err := driverAllows(driver, stop.Participant); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// soloRideAllows reports whether the participant can join the stops without
+// sharing a car with a solo rider.
+func soloRideAllows(stops []models.RouteStop, participant *models.Participant) bool {
+	if len(stops) == 0 {
+		return true
+	}
+	if participant.SoloRide {
+		return false
+	}
+	for _, stop := range stops {
+		if stop.Participant != nil && stop.Participant.SoloRide {
+			return false
+		}
+	}
+	return true
+}
+
+func replaceParticipant(route *models.CalculatedRoute, participant models.Participant) {
+	for i := range route.Stops {
+		if participantID(route.Stops[i].Participant) == participant.ID {
+			refreshed := participant
+			route.Stops[i].Participant = &refreshed
+		}
+	}
+}
+
+// undoEntry is a session's routes, no-shows and hand-ordered routes from
+// before one edit.
+type undoEntry struct {
+	routes      []models.CalculatedRoute
+	noShows     []noShow
+	manualOrder map[int]struct{}
+}
+
+// pushHistory records routes and the hand-ordered route set, with the
+// session's current no-shows, as the state the next Undo returns to, dropping
+// the oldest entry once the history is full. Callers push before changing the
+// no-shows.
+func pushHistory(state *session, routes []models.CalculatedRoute, manualOrder map[int]struct{}) {
+	if len(state.history) == maxUndoDepth {
+		state.history[0] = undoEntry{}
+		state.history = state.history[1:]
+	}
+	state.history = append(state.history, undoEntry{routes: routes, noShows: slices.Clone(state.noShows), manualOrder: manualOrder})
+}
+
 func snapshotOf(state *session) Snapshot {
 	routes := copyRoutes(state.currentRoutes)
 	over, out := capacityState(routes)
 	return Snapshot{
-		ID: state.id, Routes: routes, Summary: calculateSummary(routes), ActivityLocation: copyLocation(state.activityLocation),
+		ID: state.id, Routes: routes, Summary: CalculateSummary(routes), ActivityLocation: copyLocation(state.activityLocation),
 		UseMiles: state.useMiles, RouteTime: state.routeTime, Mode: state.mode, UnusedDrivers: unusedDrivers(routes, state.selectedDrivers),
 		IsEditing: !routesEqual(state.originalRoutes, state.currentRoutes), OverCapacity: over, IsOutOfBalance: out,
+		NoShows: noShowParticipants(state.noShows), Finalized: state.finalized, Version: state.version,
+	}
+}
+
+func noShowParticipants(entries []noShow) []models.Participant {
+	if len(entries) == 0 {
+		return nil
 	}
+	result := make([]models.Participant, len(entries))
+	for i, entry := range entries {
+		result[i] = entry.participant
+	}
+	return result
 }
 
-func calculateSummary(routes []models.CalculatedRoute) models.RoutingSummary {
+// CalculateSummary totals the driver, distance and detour metrics of routes.
+func CalculateSummary(routes []models.CalculatedRoute) models.RoutingSummary {
 	var summary models.RoutingSummary
 	usedVehicles := make(map[int64]struct{})
 	for _, route := range routes {
 		summary.TotalParticipants += len(route.Stops)
 		if len(route.Stops) > 0 {
 			summary.TotalDriversUsed++
+			if route.OrgVehicleID == 0 {
+				summary.VolunteerDriversUsed++
+			}
 		}
 		summary.TotalDropoffDistanceMeters += route.TotalDropoffDistanceMeters
 		summary.TotalDistanceMeters += route.TotalDistanceMeters
+		if route.OrgVehicleID == 0 {
+			summary.VolunteerDistanceMeters += route.TotalDistanceMeters
+		} else {
+			summary.InstituteVehicleDistanceMeters += route.TotalDistanceMeters
+		}
 		if route.DetourSecs > summary.MaxDetourSecs {
 			summary.MaxDetourSecs = route.DetourSecs
 		}
@@ -566,6 +1298,10 @@ func copyDirty(source map[int]struct{}) map[int]struct{} {
 	return result
 }
 
+func secondsDuration(secs float64) time.Duration {
+	return time.Duration(secs * float64(time.Second))
+}
+
 func generateID() string {
 	bytes := make([]byte, 16)
 	_, _ = rand.Read(bytes)
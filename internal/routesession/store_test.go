@@ -4,9 +4,14 @@ import (
 	"context"
 	"errors"
 	"math"
+	"os"
+	"path/filepath"
 	"ride-home-router/internal/distance"
 	"ride-home-router/internal/models"
 	"ride-home-router/internal/routesession"
+	"ride-home-router/internal/routing"
+	"slices"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -41,6 +46,10 @@ func (c calculator) GetDistancesFromPoint(ctx context.Context, origin models.Coo
 }
 func (calculator) PrewarmCache(context.Context, []models.Coordinates) error { return nil }
 
+func (calculator) GetRouteGeometry(context.Context, []models.Coordinates) (string, error) {
+	return "", distance.ErrRouteGeometryUnavailable
+}
+
 type failingCalculator struct{ err error }
 
 func (c failingCalculator) GetDistance(context.Context, models.Coordinates, models.Coordinates) (*distance.DistanceResult, error) {
@@ -59,6 +68,10 @@ func (c failingCalculator) PrewarmCache(context.Context, []models.Coordinates) e
 	return c.err
 }
 
+func (failingCalculator) GetRouteGeometry(context.Context, []models.Coordinates) (string, error) {
+	return "", distance.ErrRouteGeometryUnavailable
+}
+
 type blockingCalculator struct {
 	started     chan struct{}
 	release     chan struct{}
@@ -90,6 +103,10 @@ func (c *blockingCalculator) GetDistancesFromPoint(ctx context.Context, origin m
 
 func (c *blockingCalculator) PrewarmCache(context.Context, []models.Coordinates) error { return nil }
 
+func (*blockingCalculator) GetRouteGeometry(context.Context, []models.Coordinates) (string, error) {
+	return "", distance.ErrRouteGeometryUnavailable
+}
+
 func (c *blockingCalculator) unblock() { c.releaseOnce.Do(func() { close(c.release) }) }
 
 func TestCreateReturnsIndependentFreshSnapshot(t *testing.T) {
@@ -184,6 +201,201 @@ func TestApplyMovesOptimizesDestinationRouteForParticipantCompletion(t *testing.
 	}
 }
 
+func TestApplyMovesPreservesManualOrderThroughRebalance(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	newSession := func() routesession.Snapshot {
+		return store.Create(routesession.CreateInput{
+			Routes: []models.CalculatedRoute{
+				{
+					Driver:            &models.Driver{ID: 1, Name: "Manual", Lat: 10, Lng: 0, VehicleCapacity: 3},
+					EffectiveCapacity: 3,
+					Stops: []models.RouteStop{
+						{Participant: &models.Participant{ID: 1, Name: "Near", Lat: 1, Lng: 0}},
+						{Participant: &models.Participant{ID: 2, Name: "Far", Lat: 5, Lng: 0}},
+					},
+				},
+				{
+					Driver:            &models.Driver{ID: 2, Name: "Other", Lat: 10, Lng: 0, VehicleCapacity: 3},
+					EffectiveCapacity: 3,
+					Stops: []models.RouteStop{
+						{Participant: &models.Participant{ID: 3, Name: "Mover", Lat: 2, Lng: 0}},
+						{Participant: &models.Participant{ID: 4, Name: "Stayer", Lat: 3, Lng: 0}},
+					},
+				},
+			},
+			ActivityLocation: &models.ActivityLocation{ID: 1, Name: "HQ", Lat: 0, Lng: 0},
+			RouteTime:        "18:30",
+			Mode:             models.RouteModeDropoff,
+		})
+	}
+	stopNames := func(route models.CalculatedRoute) []string {
+		names := make([]string, len(route.Stops))
+		for i, stop := range route.Stops {
+			names[i] = stop.Participant.Name
+		}
+		return names
+	}
+	// Dropping Near last is worse for every rider, so only a preserved manual
+	// order keeps it there.
+	reorder := routesession.Move{ParticipantID: 1, FromRouteIndex: 0, ToRouteIndex: 0, InsertAtPosition: -1}
+	rebalance := routesession.Move{ParticipantID: 3, FromRouteIndex: 1, ToRouteIndex: 0, InsertAtPosition: -1}
+	preserve := routesession.ApplyMovesOptions{PreserveManualOrder: true}
+
+	created := newSession()
+	if _, err := store.ApplyMoves(context.Background(), created.ID, []routesession.Move{reorder}, preserve); err != nil {
+		t.Fatalf("ApplyMoves(reorder) error = %v", err)
+	}
+	updated, err := store.ApplyMoves(context.Background(), created.ID, []routesession.Move{rebalance}, preserve)
+	if err != nil {
+		t.Fatalf("ApplyMoves(rebalance) error = %v", err)
+	}
+	if got, want := stopNames(updated.Routes[0]), []string{"Far", "Near", "Mover"}; !slices.Equal(got, want) {
+		t.Fatalf("manual route order = %v, want %v", got, want)
+	}
+	if updated.Routes[0].RouteDurationSecs == 0 {
+		t.Fatal("manual route metrics were not refreshed")
+	}
+	if got, want := stopNames(updated.Routes[1]), []string{"Stayer"}; !slices.Equal(got, want) {
+		t.Fatalf("source route = %v, want %v", got, want)
+	}
+
+	// Undoing the reorder forgets that the route was ordered by hand.
+	created = newSession()
+	if _, err := store.ApplyMoves(context.Background(), created.ID, []routesession.Move{reorder}, preserve); err != nil {
+		t.Fatalf("ApplyMoves(reorder) error = %v", err)
+	}
+	if _, err := store.Undo(created.ID, 0); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	updated, err = store.ApplyMoves(context.Background(), created.ID, []routesession.Move{rebalance}, preserve)
+	if err != nil {
+		t.Fatalf("ApplyMoves(rebalance after undo) error = %v", err)
+	}
+	if got, want := stopNames(updated.Routes[0]), []string{"Near", "Mover", "Far"}; !slices.Equal(got, want) {
+		t.Fatalf("route order after undo = %v, want %v", got, want)
+	}
+
+	created = newSession()
+	updated, err = store.ApplyMoves(context.Background(), created.ID, []routesession.Move{reorder, rebalance}, routesession.ApplyMovesOptions{})
+	if err != nil {
+		t.Fatalf("ApplyMoves() error = %v", err)
+	}
+	if got, want := stopNames(updated.Routes[0]), []string{"Near", "Mover", "Far"}; !slices.Equal(got, want) {
+		t.Fatalf("optimized route order = %v, want %v", got, want)
+	}
+}
+
+func TestPerturbReturnsFeasibleReproducibleCopy(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	driver := func(id int64, lat, lng float64, attributes map[string]string) *models.Driver {
+		return &models.Driver{ID: id, Name: "Driver", Lat: lat, Lng: lng, VehicleCapacity: 3, Attributes: attributes}
+	}
+	rider := func(id int64, lat, lng float64) models.RouteStop {
+		return models.RouteStop{Participant: &models.Participant{ID: id, Name: "Rider", Lat: lat, Lng: lng}}
+	}
+	speaker := rider(5, 2, 2)
+	speaker.Participant.Attributes = map[string]string{"language": "es"}
+	speaker.Participant.RequiredMatches = []string{"language"}
+	solo := rider(7, -2, 2)
+	solo.Participant.SoloRide = true
+	created := store.Create(routesession.CreateInput{
+		Routes: []models.CalculatedRoute{
+			{Driver: driver(1, 10, 0, nil), EffectiveCapacity: 3, Stops: []models.RouteStop{rider(1, 3, 0), rider(2, 3, 0), rider(3, 6, 1)}},
+			{Driver: driver(2, 0, 10, map[string]string{"language": "es"}), EffectiveCapacity: 3, Stops: []models.RouteStop{rider(4, 1, 4), speaker}},
+			{Driver: driver(3, -10, 0, nil), EffectiveCapacity: 3, Stops: []models.RouteStop{rider(6, -4, -1)}},
+			{Driver: driver(4, 0, -10, nil), EffectiveCapacity: 3, Stops: []models.RouteStop{solo}},
+		},
+		ActivityLocation: &models.ActivityLocation{ID: 1, Name: "HQ", Lat: 0, Lng: 0},
+		RouteTime:        "18:30",
+		Mode:             models.RouteModeDropoff,
+	})
+	source, _ := store.Snapshot(created.ID)
+	sourceDuration := 0.0
+	for _, route := range source.Routes {
+		sourceDuration += routeDuration(route)
+	}
+
+	changed := false
+	for seed := range uint64(10) {
+		options := routesession.PerturbOptions{Seed: seed, Attempts: 30, Tolerance: 0.5}
+		perturbed, err := store.Perturb(context.Background(), created.ID, options)
+		if err != nil {
+			t.Fatalf("seed %d: Perturb() error = %v", seed, err)
+		}
+		if perturbed.ID == created.ID || perturbed.IsOutOfBalance {
+			t.Fatalf("seed %d: perturbed = %#v, want a new balanced session", seed, perturbed)
+		}
+
+		seen := map[int64]int{}
+		duration := 0.0
+		for i, route := range perturbed.Routes {
+			duration += route.RouteDurationSecs
+			if len(route.Stops) > route.EffectiveCapacity {
+				t.Fatalf("seed %d: route %d has %d stops for %d seats", seed, i, len(route.Stops), route.EffectiveCapacity)
+			}
+			for _, stop := range route.Stops {
+				seen[stop.Participant.ID] = i
+				if !route.Driver.SatisfiesRequirements(stop.Participant) {
+					t.Fatalf("seed %d: driver %d cannot take rider %d", seed, route.Driver.ID, stop.Participant.ID)
+				}
+				if stop.Participant.SoloRide && len(route.Stops) != 1 {
+					t.Fatalf("seed %d: solo rider shares route %d", seed, i)
+				}
+			}
+		}
+		if len(seen) != 7 {
+			t.Fatalf("seed %d: routed %d riders, want 7", seed, len(seen))
+		}
+		if seen[1] != seen[2] {
+			t.Fatalf("seed %d: household split across routes %d and %d", seed, seen[1], seen[2])
+		}
+		if duration > sourceDuration*1.5+1e-6 {
+			t.Fatalf("seed %d: total duration %.0f exceeds tolerance of %.0f", seed, duration, sourceDuration)
+		}
+
+		again, err := store.Perturb(context.Background(), created.ID, options)
+		if err != nil {
+			t.Fatalf("seed %d: second Perturb() error = %v", seed, err)
+		}
+		for i := range perturbed.Routes {
+			if got, want := stopIDs(again.Routes[i]), stopIDs(perturbed.Routes[i]); !slices.Equal(got, want) {
+				t.Fatalf("seed %d: route %d = %v on rerun, want %v", seed, i, got, want)
+			}
+			changed = changed || !slices.Equal(stopIDs(perturbed.Routes[i]), stopIDs(source.Routes[i]))
+		}
+	}
+	if !changed {
+		t.Fatal("no seed produced a different arrangement")
+	}
+
+	after, _ := store.Snapshot(created.ID)
+	for i := range source.Routes {
+		if got, want := stopIDs(after.Routes[i]), stopIDs(source.Routes[i]); !slices.Equal(got, want) {
+			t.Fatalf("source route %d = %v after perturbing, want %v", i, got, want)
+		}
+	}
+}
+
+// routeDuration is the dropoff route's drive time under calculator.
+func routeDuration(route models.CalculatedRoute) float64 {
+	total, prev := 0.0, models.Coordinates{}
+	for _, stop := range route.Stops {
+		total += math.Hypot(stop.Participant.Lat-prev.Lat, stop.Participant.Lng-prev.Lng) * 1000
+		prev = stop.Participant.GetCoords()
+	}
+	return total + math.Hypot(route.Driver.Lat-prev.Lat, route.Driver.Lng-prev.Lng)*1000
+}
+
+func stopIDs(route models.CalculatedRoute) []int64 {
+	ids := make([]int64, len(route.Stops))
+	for i, stop := range route.Stops {
+		ids[i] = stop.Participant.ID
+	}
+	return ids
+}
+
 func TestApplyMovesRollsBackWholeBatchOnValidationFailure(t *testing.T) {
 	store := routesession.NewStore(calculator{})
 	t.Cleanup(store.Close)
@@ -201,6 +413,111 @@ func TestApplyMovesRollsBackWholeBatchOnValidationFailure(t *testing.T) {
 	}
 }
 
+func TestApplyMovesRejectsSharingARouteWithASoloRider(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	input := testInput()
+	input.Routes[0].Stops[0].Participant.SoloRide = true
+	input.Routes[1].Stops = []models.RouteStop{{Participant: &models.Participant{ID: 20, Lat: 2}}}
+	created := store.Create(input)
+
+	for _, move := range []routesession.Move{
+		{ParticipantID: 20, FromRouteIndex: 1, ToRouteIndex: 0, InsertAtPosition: -1},
+		{ParticipantID: 10, FromRouteIndex: 0, ToRouteIndex: 1, InsertAtPosition: -1},
+	} {
+		if _, err := store.ApplyMoves(context.Background(), created.ID, []routesession.Move{move}, routesession.ApplyMovesOptions{}); !errors.Is(err, routesession.ErrSoloRide) {
+			t.Fatalf("move participant %d error = %v, want ErrSoloRide", move.ParticipantID, err)
+		}
+	}
+	got, _ := store.Snapshot(created.ID)
+	if len(got.Routes[0].Stops) != 1 || len(got.Routes[1].Stops) != 1 {
+		t.Fatalf("solo rider's route changed: %#v", got.Routes)
+	}
+}
+
+func TestSwapDriversRejectsAnExcludingDriver(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	input := testInput()
+	input.Routes[1].Driver.ExcludedParticipantIDs = []int64{10}
+	created := store.Create(input)
+
+	if _, err := store.SwapDrivers(context.Background(), created.ID, 0, 0, 1); !errors.Is(err, routesession.ErrExcludedByDriver) {
+		t.Fatalf("SwapDrivers() error = %v, want ErrExcludedByDriver", err)
+	}
+	got, _ := store.Snapshot(created.ID)
+	if got.Routes[0].Driver.ID != 1 || got.Routes[1].Driver.ID != 2 || got.Version != created.Version {
+		t.Fatalf("rejected swap changed the routes: %#v", got.Routes)
+	}
+}
+
+func TestSwapDriversRejectsAnInaccessibleVehicle(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	input := testInput()
+	input.Routes[0].Driver.IsAccessible = true
+	input.Routes[0].Stops[0].Participant.RequiresAccessible = true
+	created := store.Create(input)
+
+	if _, err := store.SwapDrivers(context.Background(), created.ID, 0, 0, 1); !errors.Is(err, routesession.ErrNeedsAccessible) {
+		t.Fatalf("SwapDrivers() error = %v, want ErrNeedsAccessible", err)
+	}
+	got, _ := store.Snapshot(created.ID)
+	if got.Routes[0].Driver.ID != 1 || got.Routes[1].Driver.ID != 2 {
+		t.Fatalf("rejected swap changed the routes: %#v", got.Routes)
+	}
+}
+
+func TestApplyMovesRejectsMovingToAnExcludingDriver(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	input := testInput()
+	input.Routes[1].Driver.ExcludedParticipantIDs = []int64{10}
+	created := store.Create(input)
+
+	move := routesession.Move{ParticipantID: 10, FromRouteIndex: 0, ToRouteIndex: 1, InsertAtPosition: -1}
+	if _, err := store.ApplyMoves(context.Background(), created.ID, []routesession.Move{move}, routesession.ApplyMovesOptions{}); !errors.Is(err, routesession.ErrExcludedByDriver) {
+		t.Fatalf("ApplyMoves() error = %v, want ErrExcludedByDriver", err)
+	}
+	got, _ := store.Snapshot(created.ID)
+	if len(got.Routes[0].Stops) != 1 || len(got.Routes[1].Stops) != 0 {
+		t.Fatalf("excluded participant moved: %#v", got.Routes)
+	}
+}
+
+func TestApplyMovesRejectsMovingToAnInaccessibleVehicle(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	input := testInput()
+	input.Routes[0].Stops[0].Participant.RequiresAccessible = true
+	created := store.Create(input)
+
+	move := routesession.Move{ParticipantID: 10, FromRouteIndex: 0, ToRouteIndex: 1, InsertAtPosition: -1}
+	if _, err := store.ApplyMoves(context.Background(), created.ID, []routesession.Move{move}, routesession.ApplyMovesOptions{}); !errors.Is(err, routesession.ErrNeedsAccessible) {
+		t.Fatalf("ApplyMoves() error = %v, want ErrNeedsAccessible", err)
+	}
+}
+
+func TestSetCapacityCannotExceedOrgVehicleSeats(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	input := testInput()
+	input.Routes[0].OrgVehicleID, input.Routes[0].EffectiveCapacity = 30, 3
+	input.DriverOrgVehicles = map[int64]*models.OrganizationVehicle{input.Routes[0].Driver.ID: {ID: 30, Name: "Van", Capacity: 4}}
+	created := store.Create(input)
+
+	if _, err := store.SetCapacity(created.ID, 0, 0, 5); !errors.Is(err, routesession.ErrCapacityExceedsVehicle) {
+		t.Fatalf("SetCapacity() above the van's seats error = %v, want ErrCapacityExceedsVehicle", err)
+	}
+	got, err := store.SetCapacity(created.ID, 0, 0, 4)
+	if err != nil {
+		t.Fatalf("SetCapacity() error = %v", err)
+	}
+	if got.Routes[0].EffectiveCapacity != 4 {
+		t.Fatalf("capacity = %d, want 4", got.Routes[0].EffectiveCapacity)
+	}
+}
+
 func TestApplyMovesRollsBackWholeBatchOnDistanceFailure(t *testing.T) {
 	distanceFailure := errors.New("distance failed")
 	store := routesession.NewStore(failingCalculator{err: distanceFailure})
@@ -307,7 +624,7 @@ func TestSwapDriversRejectsCapacityAndRollsBackDistanceFailure(t *testing.T) {
 	capacityRoutes[0].Stops = append(capacityRoutes[0].Stops, models.RouteStop{Participant: &models.Participant{ID: 11}})
 	capacityRoutes[1].EffectiveCapacity = 1
 	created := capacityStore.Create(routesession.CreateInput{Routes: capacityRoutes, ActivityLocation: &models.ActivityLocation{}, RouteTime: "18:30", Mode: models.RouteModeDropoff})
-	if _, err := capacityStore.SwapDrivers(context.Background(), created.ID, 0, 1); !errors.Is(err, routesession.ErrSwapCapacity) {
+	if _, err := capacityStore.SwapDrivers(context.Background(), created.ID, 0, 0, 1); !errors.Is(err, routesession.ErrSwapCapacity) {
 		t.Fatalf("SwapDrivers error = %v, want ErrSwapCapacity", err)
 	}
 
@@ -315,7 +632,7 @@ func TestSwapDriversRejectsCapacityAndRollsBackDistanceFailure(t *testing.T) {
 	failureStore := routesession.NewStore(failingCalculator{err: distanceFailure})
 	t.Cleanup(failureStore.Close)
 	created = failureStore.Create(testInput())
-	if _, err := failureStore.SwapDrivers(context.Background(), created.ID, 0, 1); !errors.Is(err, distanceFailure) {
+	if _, err := failureStore.SwapDrivers(context.Background(), created.ID, 0, 0, 1); !errors.Is(err, distanceFailure) {
 		t.Fatalf("SwapDrivers error = %v, want distance failure", err)
 	}
 	got, _ := failureStore.Snapshot(created.ID)
@@ -334,11 +651,11 @@ func TestSwapResetAndAddDriverOperateThroughSnapshots(t *testing.T) {
 		DriverOrgVehicles: map[int64]*models.OrganizationVehicle{3: {ID: 30, Name: "Van", Capacity: 5}},
 	})
 
-	swapped, err := store.SwapDrivers(context.Background(), created.ID, 0, 1)
+	swapped, err := store.SwapDrivers(context.Background(), created.ID, 0, 0, 1)
 	if err != nil || swapped.Routes[0].Driver.ID != 2 || !swapped.IsEditing {
 		t.Fatalf("SwapDrivers = %#v, %v", swapped, err)
 	}
-	added, err := store.AddDriver(context.Background(), created.ID, 3)
+	added, err := store.AddDriver(context.Background(), created.ID, 0, 3)
 	if err != nil {
 		t.Fatalf("AddDriver: %v", err)
 	}
@@ -349,12 +666,465 @@ func TestSwapResetAndAddDriverOperateThroughSnapshots(t *testing.T) {
 	if len(added.UnusedDrivers) != 0 {
 		t.Fatalf("driver rendered on an empty route is still unused: %#v", added.UnusedDrivers)
 	}
-	reset, err := store.Reset(created.ID)
+	reset, err := store.Reset(created.ID, 0)
 	if err != nil || reset.IsEditing || len(reset.Routes) != 2 {
 		t.Fatalf("Reset = %#v, %v", reset, err)
 	}
 }
 
+func TestLockedStopKeepsItsOrderWhenTheRouteIsReoptimized(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	drivers := []models.Driver{{ID: 1, VehicleCapacity: 4}, {ID: 2, VehicleCapacity: 4}}
+	far := &models.Participant{ID: 10, Lat: 3}
+	near := &models.Participant{ID: 11, Lat: 1}
+	created := store.Create(routesession.CreateInput{
+		Routes: []models.CalculatedRoute{
+			{Driver: &drivers[0], Stops: []models.RouteStop{{Participant: far}, {Participant: near}}},
+			{Driver: &drivers[1], Stops: []models.RouteStop{{Participant: &models.Participant{ID: 12, Lat: 2}}}},
+		},
+		SelectedDrivers: drivers, ActivityLocation: &models.ActivityLocation{}, Mode: models.RouteModeDropoff,
+	})
+	for _, id := range []int64{far.ID, near.ID} {
+		if _, err := store.LockStop(created.ID, 0, id, true); err != nil {
+			t.Fatalf("LockStop(%d): %v", id, err)
+		}
+	}
+
+	moved, err := store.ApplyMoves(context.Background(), created.ID, []routesession.Move{{ParticipantID: 12, ToRouteIndex: 0, InsertAtPosition: -1}}, routesession.ApplyMovesOptions{})
+	if err != nil {
+		t.Fatalf("ApplyMoves: %v", err)
+	}
+	var lockedOrder []int64
+	for _, stop := range moved.Routes[0].Stops {
+		if stop.Locked {
+			lockedOrder = append(lockedOrder, stop.Participant.ID)
+		}
+	}
+	if len(moved.Routes[0].Stops) != 3 || !slices.Equal(lockedOrder, []int64{far.ID, near.ID}) {
+		t.Fatalf("route 0 stops = %#v, want the locked far-then-near order kept", moved.Routes[0].Stops)
+	}
+
+	if _, err := store.LockStop(created.ID, 0, 99, true); !errors.Is(err, routesession.ErrParticipantNotFound) {
+		t.Fatalf("LockStop for a missing participant error = %v, want %v", err, routesession.ErrParticipantNotFound)
+	}
+
+	for seed := range uint64(5) {
+		perturbed, err := store.Perturb(context.Background(), created.ID, routesession.PerturbOptions{Seed: seed, Attempts: 30, Tolerance: 10})
+		if err != nil {
+			t.Fatalf("seed %d: Perturb: %v", seed, err)
+		}
+		lockedOnRoute := 0
+		for _, stop := range perturbed.Routes[0].Stops {
+			if stop.Locked {
+				lockedOnRoute++
+			}
+		}
+		if lockedOnRoute != 2 {
+			t.Fatalf("seed %d: route 0 stops = %#v, want both locked stops kept", seed, perturbed.Routes[0].Stops)
+		}
+	}
+}
+
+// captureRouter records the request it is given and routes every participant
+// with the first driver.
+type captureRouter struct {
+	request *routing.RoutingRequest
+}
+
+func (r *captureRouter) CalculateRoutes(_ context.Context, req *routing.RoutingRequest) (*models.RoutingResult, error) {
+	r.request = req
+	route := models.CalculatedRoute{Driver: &req.Drivers[0]}
+	for i := range req.Participants {
+		route.Stops = append(route.Stops, models.RouteStop{Participant: &req.Participants[i]})
+	}
+	return &models.RoutingResult{Routes: []models.CalculatedRoute{route}}, nil
+}
+
+func TestReoptimizeKeepsRequestOptionsAndChargesLockedDetours(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	drivers := []models.Driver{{ID: 1, VehicleCapacity: 4, MaxRouteDurationSecs: 5000}, {ID: 2, VehicleCapacity: 4, MaxRouteDurationSecs: 1500}}
+	options := routing.RouteOptions{Metric: routing.MetricDistance, HouseholdPrecision: 3, FairnessWeights: routing.FairnessWeights{MaxDetourWeight: 2}}
+	created := store.Create(routesession.CreateInput{
+		Routes: []models.CalculatedRoute{
+			{Driver: &drivers[0], Stops: []models.RouteStop{{Participant: &models.Participant{ID: 10, Lat: 1}, Locked: true}}},
+			{Driver: &drivers[1], Stops: []models.RouteStop{{Participant: &models.Participant{ID: 11, Lat: 1}, Locked: true}, {Participant: &models.Participant{ID: 12, Lat: 0.5}}}},
+		},
+		SelectedDrivers: drivers, ActivityLocation: &models.ActivityLocation{}, Mode: models.RouteModeDropoff,
+		Caps: routing.RouteCaps{MaxDetourSecs: 9000}, Options: options,
+	})
+
+	router := &captureRouter{}
+	reoptimized, err := store.Reoptimize(context.Background(), created.ID, 0, router)
+	if err != nil {
+		t.Fatalf("Reoptimize: %v", err)
+	}
+	req := router.request
+	if req.Metric != options.Metric || req.HouseholdPrecision != options.HouseholdPrecision || req.FairnessWeights != options.FairnessWeights || req.MaxDetourSecs != 9000 {
+		t.Fatalf("request = %+v, want the session's options and caps", req)
+	}
+	// Each locked stop adds a 2000s detour: driver 1 keeps 3000s of their
+	// 5000s, and driver 2's 1500s is used up, so they take no one new.
+	if len(req.Drivers) != 1 || req.Drivers[0].ID != 1 || req.Drivers[0].MaxRouteDurationSecs != 3000 {
+		t.Fatalf("request drivers = %+v, want only driver 1 with 3000s left", req.Drivers)
+	}
+	if !slices.Equal(stopIDs(reoptimized.Routes[0]), []int64{10, 12}) && !slices.Equal(stopIDs(reoptimized.Routes[0]), []int64{12, 10}) {
+		t.Fatalf("route 0 stops = %v, want riders 10 and 12", stopIDs(reoptimized.Routes[0]))
+	}
+}
+
+func TestReoptimizeKeepsTheRouterOrderOnRoutesWithoutLockedStops(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	driver := models.Driver{ID: 1, VehicleCapacity: 2}
+	created := store.Create(routesession.CreateInput{
+		Routes: []models.CalculatedRoute{{Driver: &driver, Stops: []models.RouteStop{
+			{Participant: &models.Participant{ID: 10, Lat: 2}},
+			{Participant: &models.Participant{ID: 11, Lat: 1}},
+		}}},
+		SelectedDrivers: []models.Driver{driver}, ActivityLocation: &models.ActivityLocation{}, Mode: models.RouteModeDropoff,
+	})
+
+	reoptimized, err := store.Reoptimize(context.Background(), created.ID, 0, &captureRouter{})
+	if err != nil {
+		t.Fatalf("Reoptimize: %v", err)
+	}
+	if ids := stopIDs(reoptimized.Routes[0]); !slices.Equal(ids, []int64{10, 11}) {
+		t.Fatalf("route stops = %v, want the router's order kept", ids)
+	}
+	if reoptimized.Routes[0].RouteDurationSecs == 0 {
+		t.Fatalf("route metrics were not refreshed: %+v", reoptimized.Routes[0])
+	}
+}
+
+func TestReoptimizeRejectsAMergedOrderOutsideTimeWindows(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	driver := models.Driver{ID: 1, VehicleCapacity: 2}
+	created := store.Create(routesession.CreateInput{
+		Routes: []models.CalculatedRoute{{Driver: &driver, Stops: []models.RouteStop{
+			{Participant: &models.Participant{ID: 10, Lat: 1, LatestSecs: 1500}, Locked: true},
+			{Participant: &models.Participant{ID: 11, Lat: -1, LatestSecs: 1500}},
+		}}},
+		SelectedDrivers: []models.Driver{driver}, ActivityLocation: &models.ActivityLocation{}, Mode: models.RouteModeDropoff,
+	})
+
+	// Whichever rider is dropped second arrives 3000s in, past both windows.
+	_, err := store.Reoptimize(context.Background(), created.ID, 0, &captureRouter{})
+	if !errors.Is(err, routesession.ErrReoptimizeBreaksLimits) {
+		t.Fatalf("Reoptimize() error = %v, want %v", err, routesession.ErrReoptimizeBreaksLimits)
+	}
+	got, _ := store.Snapshot(created.ID)
+	if got.Version != created.Version {
+		t.Fatalf("rejected re-optimization changed the session to version %d", got.Version)
+	}
+}
+
+func TestReoptimizeRefusesToDeclineRidersOverTheDetourCeiling(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	driver := models.Driver{ID: 1, Name: "Driver", Lat: 10, VehicleCapacity: 3}
+	created := store.Create(routesession.CreateInput{
+		Routes: []models.CalculatedRoute{{Driver: &driver, EffectiveCapacity: 3, Stops: []models.RouteStop{
+			{Participant: &models.Participant{ID: 10, Name: "On The Way", Lat: 5}},
+			{Participant: &models.Participant{ID: 11, Name: "Nearby", Lat: 6, Lng: 0.5}},
+			{Participant: &models.Participant{ID: 12, Name: "Outlier", Lat: 5, Lng: 8}},
+		}}},
+		SelectedDrivers: []models.Driver{driver}, ActivityLocation: &models.ActivityLocation{}, Mode: models.RouteModeDropoff,
+		Caps: routing.RouteCaps{MaxDetourSecs: 2000}, Options: routing.RouteOptions{DeclineOverDetourCeiling: true},
+	})
+
+	_, err := store.Reoptimize(context.Background(), created.ID, 0, routing.NewBalancedRouter(calculator{}))
+	if !errors.Is(err, routesession.ErrReoptimizeDeclines) || !strings.Contains(err.Error(), "Outlier") {
+		t.Fatalf("Reoptimize() error = %v, want ErrReoptimizeDeclines naming the outlier", err)
+	}
+	got, _ := store.Snapshot(created.ID)
+	if ids := stopIDs(got.Routes[0]); len(ids) != 3 || got.Version != created.Version {
+		t.Fatalf("refused re-optimization changed the session: stops %v version %d", ids, got.Version)
+	}
+}
+
+func TestReoptimizeReassignsUnlockedRidersAndKeepsLockedOnes(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	drivers := []models.Driver{{ID: 1, Lat: 1, VehicleCapacity: 2}, {ID: 2, Lat: -1, VehicleCapacity: 2}}
+	// Each driver carries a rider living on the other driver's side; rider
+	// 12 is locked onto driver 1 even though it lives beside driver 2.
+	created := store.Create(routesession.CreateInput{
+		Routes: []models.CalculatedRoute{
+			{Driver: &drivers[0], EffectiveCapacity: 2, Stops: []models.RouteStop{{Participant: &models.Participant{ID: 11, Lat: -1.1}}, {Participant: &models.Participant{ID: 12, Lat: -0.9}}}},
+			{Driver: &drivers[1], EffectiveCapacity: 2, Stops: []models.RouteStop{{Participant: &models.Participant{ID: 10, Lat: 1.1}}}},
+		},
+		SelectedDrivers: drivers, ActivityLocation: &models.ActivityLocation{}, Mode: models.RouteModeDropoff,
+	})
+	if _, err := store.LockStop(created.ID, 0, 12, true); err != nil {
+		t.Fatalf("LockStop: %v", err)
+	}
+
+	reoptimized, err := store.Reoptimize(context.Background(), created.ID, 0, routing.NewBalancedRouter(calculator{}))
+	if err != nil {
+		t.Fatalf("Reoptimize: %v", err)
+	}
+	riders := func(route models.CalculatedRoute) []int64 {
+		var ids []int64
+		for _, stop := range route.Stops {
+			ids = append(ids, stop.Participant.ID)
+		}
+		slices.Sort(ids)
+		return ids
+	}
+	if got := riders(reoptimized.Routes[0]); !slices.Equal(got, []int64{10, 12}) {
+		t.Fatalf("driver 1 riders = %v, want the nearby rider and the locked one", got)
+	}
+	if got := riders(reoptimized.Routes[1]); !slices.Equal(got, []int64{11}) {
+		t.Fatalf("driver 2 riders = %v, want the nearby rider", got)
+	}
+	if !reoptimized.IsEditing {
+		t.Fatal("re-optimized session should still differ from its original plan")
+	}
+
+	undone, err := store.Undo(created.ID, 0)
+	if err != nil || !slices.Equal(riders(undone.Routes[0]), []int64{11, 12}) {
+		t.Fatalf("Undo after Reoptimize = %#v, %v; want the edited routes back", undone.Routes, err)
+	}
+}
+
+func TestUndoStepsBackOneEditAtATimeUpToTheDepthCap(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	drivers := []models.Driver{{ID: 1, VehicleCapacity: 2}, {ID: 2, VehicleCapacity: 2}, {ID: 3, VehicleCapacity: 1}}
+	created := store.Create(routesession.CreateInput{
+		Routes: testRoutes(), SelectedDrivers: drivers, ActivityLocation: &models.ActivityLocation{},
+		RouteTime: "18:30", Mode: models.RouteModeDropoff,
+	})
+	ctx := context.Background()
+
+	if _, err := store.SwapDrivers(ctx, created.ID, 0, 0, 1); err != nil {
+		t.Fatalf("SwapDrivers: %v", err)
+	}
+	if _, err := store.AddDriver(ctx, created.ID, 0, 3); err != nil {
+		t.Fatalf("AddDriver: %v", err)
+	}
+	undone, err := store.Undo(created.ID, 0)
+	if err != nil || len(undone.Routes) != 2 || undone.Routes[0].Driver.ID != 2 {
+		t.Fatalf("first Undo = %#v, %v; want the swap kept and the added driver gone", undone.Routes, err)
+	}
+	undone, err = store.Undo(created.ID, 0)
+	if err != nil || undone.Routes[0].Driver.ID != 1 || undone.IsEditing {
+		t.Fatalf("second Undo = %#v, %v; want the original routes", undone, err)
+	}
+	if _, err := store.Undo(created.ID, 0); !errors.Is(err, routesession.ErrNothingToUndo) {
+		t.Fatalf("Undo with no history error = %v, want %v", err, routesession.ErrNothingToUndo)
+	}
+
+	for range 21 {
+		if _, err := store.SwapDrivers(ctx, created.ID, 0, 0, 1); err != nil {
+			t.Fatalf("SwapDrivers: %v", err)
+		}
+	}
+	for i := range 20 {
+		if _, err := store.Undo(created.ID, 0); err != nil {
+			t.Fatalf("Undo %d: %v", i+1, err)
+		}
+	}
+	if _, err := store.Undo(created.ID, 0); !errors.Is(err, routesession.ErrNothingToUndo) {
+		t.Fatalf("Undo past the depth cap error = %v, want %v", err, routesession.ErrNothingToUndo)
+	}
+}
+
+func TestUndoReversesCapacityLockAndNoShowEdits(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	created := store.Create(testInput())
+	ctx := context.Background()
+
+	if _, err := store.SetCapacity(created.ID, 0, 0, 3); err != nil {
+		t.Fatalf("SetCapacity: %v", err)
+	}
+	if _, err := store.LockStop(created.ID, 0, 10, true); err != nil {
+		t.Fatalf("LockStop: %v", err)
+	}
+	if _, err := store.MarkNoShow(ctx, created.ID, 0, 10, false); err != nil {
+		t.Fatalf("MarkNoShow: %v", err)
+	}
+	if _, err := store.RestoreNoShow(ctx, created.ID, 0, 10); err != nil {
+		t.Fatalf("RestoreNoShow: %v", err)
+	}
+
+	undone, err := store.Undo(created.ID, 0)
+	if err != nil || len(undone.Routes[0].Stops) != 0 || len(undone.NoShows) != 1 || undone.NoShows[0].ID != 10 {
+		t.Fatalf("Undo RestoreNoShow = %#v, %v; want rider 10 back among the no-shows", undone, err)
+	}
+	undone, err = store.Undo(created.ID, 0)
+	if err != nil || !slices.Equal(stopIDs(undone.Routes[0]), []int64{10}) || len(undone.NoShows) != 0 || !undone.Routes[0].Stops[0].Locked {
+		t.Fatalf("Undo MarkNoShow = %#v, %v; want locked rider 10 back on the route", undone, err)
+	}
+	undone, err = store.Undo(created.ID, 0)
+	if err != nil || undone.Routes[0].Stops[0].Locked || undone.Routes[0].EffectiveCapacity != 3 {
+		t.Fatalf("Undo LockStop = %#v, %v; want the stop unlocked and the capacity kept", undone.Routes[0], err)
+	}
+	undone, err = store.Undo(created.ID, 0)
+	if err != nil || undone.Routes[0].EffectiveCapacity != 2 || undone.IsEditing {
+		t.Fatalf("Undo SetCapacity = %#v, %v; want the original routes", undone, err)
+	}
+	if _, err := store.Undo(created.ID, 0); !errors.Is(err, routesession.ErrNothingToUndo) {
+		t.Fatalf("Undo with no history error = %v, want %v", err, routesession.ErrNothingToUndo)
+	}
+}
+
+func TestRefreshParticipantClearsUndoHistory(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	created := store.Create(testInput())
+	ctx := context.Background()
+
+	if _, err := store.SwapDrivers(ctx, created.ID, 0, 0, 1); err != nil {
+		t.Fatalf("SwapDrivers: %v", err)
+	}
+	if _, err := store.RefreshParticipant(ctx, created.ID, 0, models.Participant{ID: 10, Lat: 3}); err != nil {
+		t.Fatalf("RefreshParticipant: %v", err)
+	}
+	if _, err := store.Undo(created.ID, 0); !errors.Is(err, routesession.ErrNothingToUndo) {
+		t.Fatalf("Undo after RefreshParticipant error = %v, want %v so the old address cannot return", err, routesession.ErrNothingToUndo)
+	}
+}
+
+func TestRefreshParticipantUpdatesANoShowEverywhere(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	created := store.Create(testInput())
+	ctx := context.Background()
+
+	if _, err := store.MarkNoShow(ctx, created.ID, 0, 10, false); err != nil {
+		t.Fatalf("MarkNoShow: %v", err)
+	}
+	refreshed, err := store.RefreshParticipant(ctx, created.ID, 0, models.Participant{ID: 10, Lat: 3})
+	if err != nil || len(refreshed.NoShows) != 1 || refreshed.NoShows[0].Lat != 3 {
+		t.Fatalf("RefreshParticipant = %#v, %v; want the no-show's new address", refreshed.NoShows, err)
+	}
+	if _, err := store.Undo(created.ID, 0); !errors.Is(err, routesession.ErrNothingToUndo) {
+		t.Fatalf("Undo after refreshing a no-show error = %v, want %v so the old address cannot return", err, routesession.ErrNothingToUndo)
+	}
+	reset, err := store.Reset(created.ID, 0)
+	if err != nil || reset.Routes[0].Stops[0].Participant.Lat != 3 {
+		t.Fatalf("Reset = %#v, %v; want the original plan to carry the new address", reset.Routes[0], err)
+	}
+}
+
+func TestRouteETAsShiftWithDepartureWithoutMutatingSession(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	routes := testRoutes()
+	routes[0].Stops[0].CumulativeDurationSecs = 600
+	routes[0].RouteDurationSecs = 900
+	created := store.Create(routesession.CreateInput{Routes: routes, ActivityLocation: &models.ActivityLocation{}, RouteTime: "18:30", Mode: models.RouteModeDropoff})
+
+	early := time.Date(0, 1, 1, 17, 40, 0, 0, time.UTC)
+	etas, err := store.RouteETAs(created.ID, 0, early)
+	if err != nil {
+		t.Fatalf("RouteETAs: %v", err)
+	}
+	if got := etas.Stops[0].ArrivalAt; !got.Equal(early.Add(10 * time.Minute)) {
+		t.Fatalf("arrival = %v, want 17:50", got)
+	}
+	if !etas.FinishAt.Equal(early.Add(15 * time.Minute)) {
+		t.Fatalf("finish = %v, want 17:55", etas.FinishAt)
+	}
+	late, err := store.RouteETAs(created.ID, 0, early.Add(30*time.Minute))
+	if err != nil {
+		t.Fatalf("RouteETAs: %v", err)
+	}
+	if shift := late.Stops[0].ArrivalAt.Sub(etas.Stops[0].ArrivalAt); shift != 30*time.Minute {
+		t.Fatalf("arrival shift = %v, want 30m", shift)
+	}
+	if _, err := store.RouteETAs(created.ID, 5, early); !errors.Is(err, routesession.ErrInvalidRouteIndex) {
+		t.Fatalf("RouteETAs error = %v, want ErrInvalidRouteIndex", err)
+	}
+	got, _ := store.Snapshot(created.ID)
+	if got.IsEditing || got.RouteTime != "18:30" {
+		t.Fatalf("RouteETAs mutated session: %#v", got)
+	}
+}
+
+func TestMarkNoShowShortensRouteAndRestoreReturnsParticipant(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	routes := testRoutes()
+	routes[0].Stops = append(routes[0].Stops, models.RouteStop{Participant: &models.Participant{ID: 11, Lat: 2}})
+	if err := routing.PopulateRouteMetrics(context.Background(), calculator{}, models.Coordinates{}, models.RouteModeDropoff, &routes[0]); err != nil {
+		t.Fatal(err)
+	}
+	created := store.Create(routesession.CreateInput{Routes: routes, ActivityLocation: &models.ActivityLocation{}, RouteTime: "18:30", Mode: models.RouteModeDropoff})
+
+	marked, err := store.MarkNoShow(context.Background(), created.ID, 0, 11, true)
+	if err != nil {
+		t.Fatalf("MarkNoShow: %v", err)
+	}
+	if len(marked.Routes[0].Stops) != 1 || marked.Routes[0].RouteDurationSecs >= created.Routes[0].RouteDurationSecs {
+		t.Fatalf("route did not shorten: before=%#v after=%#v", created.Routes[0], marked.Routes[0])
+	}
+	if len(marked.NoShows) != 1 || marked.NoShows[0].ID != 11 || marked.Summary.TotalParticipants != 1 {
+		t.Fatalf("no-show not tracked: %#v", marked)
+	}
+	if _, err := store.MarkNoShow(context.Background(), created.ID, 0, 11, false); !errors.Is(err, routesession.ErrParticipantNotFound) {
+		t.Fatalf("second MarkNoShow error = %v, want ErrParticipantNotFound", err)
+	}
+
+	restored, err := store.RestoreNoShow(context.Background(), created.ID, 0, 11)
+	if err != nil {
+		t.Fatalf("RestoreNoShow: %v", err)
+	}
+	if len(restored.Routes[0].Stops) != 2 || len(restored.NoShows) != 0 || restored.IsEditing {
+		t.Fatalf("participant was not restored: %#v", restored)
+	}
+	if _, err := store.RestoreNoShow(context.Background(), created.ID, 0, 11); !errors.Is(err, routesession.ErrNoShowNotFound) {
+		t.Fatalf("second RestoreNoShow error = %v, want ErrNoShowNotFound", err)
+	}
+}
+
+func TestRestoreNoShowFollowsTheirDriverAfterASwap(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	created := store.Create(testInput())
+	ctx := context.Background()
+
+	if _, err := store.MarkNoShow(ctx, created.ID, 0, 10, false); err != nil {
+		t.Fatalf("MarkNoShow: %v", err)
+	}
+	if _, err := store.SwapDrivers(ctx, created.ID, 0, 0, 1); err != nil {
+		t.Fatalf("SwapDrivers: %v", err)
+	}
+	restored, err := store.RestoreNoShow(ctx, created.ID, 0, 10)
+	if err != nil {
+		t.Fatalf("RestoreNoShow: %v", err)
+	}
+	if restored.Routes[1].Driver.ID != 1 || !slices.Equal(stopIDs(restored.Routes[1]), []int64{10}) || len(restored.Routes[0].Stops) != 0 {
+		t.Fatalf("rider was not restored to driver 1: %#v", restored.Routes)
+	}
+}
+
+func TestRestoreNoShowRejectsADriverWhoCannotCarryThem(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	created := store.Create(testInput())
+	ctx := context.Background()
+
+	if _, err := store.MarkNoShow(ctx, created.ID, 0, 10, false); err != nil {
+		t.Fatalf("MarkNoShow: %v", err)
+	}
+	if _, err := store.RefreshParticipant(ctx, created.ID, 0, models.Participant{ID: 10, Lat: 1, RequiresAccessible: true}); err != nil {
+		t.Fatalf("RefreshParticipant: %v", err)
+	}
+	if _, err := store.RestoreNoShow(ctx, created.ID, 0, 10); !errors.Is(err, routesession.ErrNeedsAccessible) {
+		t.Fatalf("RestoreNoShow() error = %v, want ErrNeedsAccessible", err)
+	}
+	got, _ := store.Snapshot(created.ID)
+	if len(got.Routes[0].Stops) != 0 || len(got.NoShows) != 1 {
+		t.Fatalf("rejected restore changed the session: %#v", got)
+	}
+}
+
 func TestSaveSnapshotRejectsUnbalancedAndReturnsIndependentPayload(t *testing.T) {
 	store := routesession.NewStore(calculator{})
 	t.Cleanup(store.Close)
@@ -385,7 +1155,7 @@ func TestStoreSupportsConcurrentSnapshotsAndResets(t *testing.T) {
 	var wait sync.WaitGroup
 	for range 50 {
 		wait.Go(func() { _, _ = store.Snapshot(created.ID) })
-		wait.Go(func() { _, _ = store.Reset(created.ID) })
+		wait.Go(func() { _, _ = store.Reset(created.ID, 0) })
 	}
 	wait.Wait()
 	if _, ok := store.Snapshot(created.ID); !ok {
@@ -447,3 +1217,272 @@ func testInput() routesession.CreateInput {
 		RouteTime: "18:30", Mode: models.RouteModeDropoff,
 	}
 }
+
+func TestValidateReportsManuallyOverfilledRoute(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	input := testInput()
+	for i := range input.Routes {
+		input.Routes[i].Driver.Lat = 1
+	}
+	input.Routes[0].Driver.VehicleCapacity, input.Routes[0].EffectiveCapacity = 1, 1
+	input.Routes[1].Stops = []models.RouteStop{{Participant: &models.Participant{ID: 20, Lat: 2}}}
+	created := store.Create(input)
+
+	violations, err := store.Validate(created.ID)
+	if err != nil || len(violations) != 0 {
+		t.Fatalf("Validate() = %#v, %v; want no violations", violations, err)
+	}
+
+	if _, err := store.ApplyMoves(context.Background(), created.ID, []routesession.Move{
+		{ParticipantID: 20, FromRouteIndex: 1, ToRouteIndex: 0, InsertAtPosition: -1},
+	}, routesession.ApplyMovesOptions{}); err != nil {
+		t.Fatalf("ApplyMoves() error = %v", err)
+	}
+	violations, err = store.Validate(created.ID)
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if len(violations) != 1 || violations[0].Kind != routesession.ViolationOverCapacity || violations[0].RouteIndex != 0 {
+		t.Fatalf("Validate() = %#v, want one over_capacity violation on route 0", violations)
+	}
+}
+
+func TestValidateReportsRoutesOverTheCalculationCaps(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	input := testInput()
+	for i := range input.Routes {
+		input.Routes[i].Driver.Lat = 1
+	}
+	input.Routes[0].DetourSecs = 100
+	input.Routes[0].Stops[0].CumulativeDurationSecs = 500
+	input.Caps = routing.RouteCaps{MaxDetourSecs: 90, MaxRideSecs: 600}
+	created := store.Create(input)
+
+	violations, err := store.Validate(created.ID)
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if len(violations) != 1 || violations[0].Kind != routesession.ViolationDetourCap || violations[0].RouteIndex != 0 {
+		t.Fatalf("Validate() = %#v, want one over_detour_cap violation on route 0", violations)
+	}
+}
+
+func TestValidateReportsMissedTimeWindowsAndForcedStops(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	first, last := 0, 5
+	created := store.Create(routesession.CreateInput{
+		Routes: []models.CalculatedRoute{{
+			Driver:            &models.Driver{ID: 1, Name: "Driver", Lat: 1, VehicleCapacity: 3},
+			EffectiveCapacity: 3,
+			Stops: []models.RouteStop{
+				{Participant: &models.Participant{ID: 10, Name: "Early", Lat: 2, LatestSecs: 100}, CumulativeDurationSecs: 200},
+				{Participant: &models.Participant{ID: 11, Name: "First", Lat: 3, ForcedOrder: &first}, CumulativeDurationSecs: 300},
+				{Participant: &models.Participant{ID: 12, Name: "Last", Lat: 4, ForcedOrder: &last, EarliestSecs: 300}, CumulativeDurationSecs: 400},
+			},
+		}},
+		ActivityLocation: &models.ActivityLocation{ID: 1, Name: "HQ", Lat: 1},
+		RouteTime:        "18:30",
+		Mode:             models.RouteModeDropoff,
+	})
+
+	violations, err := store.Validate(created.ID)
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	want := []routesession.Violation{
+		{Kind: routesession.ViolationTimeWindow, ParticipantID: 10},
+		{Kind: routesession.ViolationForcedOrder, ParticipantID: 11},
+	}
+	if len(violations) != len(want) {
+		t.Fatalf("Validate() = %#v, want %d violations", violations, len(want))
+	}
+	for i, violation := range violations {
+		if violation.Kind != want[i].Kind || violation.ParticipantID != want[i].ParticipantID {
+			t.Fatalf("violation %d = %#v, want %s for participant %d", i, violation, want[i].Kind, want[i].ParticipantID)
+		}
+	}
+}
+
+func TestPersistentStoreRestoresEditedSessionsAndPrunesStaleOnes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "route_sessions.json")
+	stale := `{"stale":{"id":"stale","version":3,"last_accessed_at":"` + time.Now().Add(-25*time.Hour).Format(time.RFC3339) + `"}}`
+	if err := os.WriteFile(path, []byte(stale), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := routesession.NewPersistentStore(calculator{}, path)
+	if err != nil {
+		t.Fatalf("NewPersistentStore() error = %v", err)
+	}
+	if _, ok := store.Snapshot("stale"); ok {
+		t.Fatal("session idle for over a day was restored")
+	}
+	driver := models.Driver{ID: 1, Name: "Driver", VehicleCapacity: 2}
+	created := store.Create(routesession.CreateInput{
+		Routes: []models.CalculatedRoute{{Driver: &driver, Stops: []models.RouteStop{
+			{Participant: &models.Participant{ID: 10, Name: "Rider", Lat: 0, Lng: 1}},
+			{Participant: &models.Participant{ID: 11, Name: "Other", Lat: 0, Lng: 2}},
+		}}},
+		SelectedDrivers: []models.Driver{driver}, ActivityLocation: &models.ActivityLocation{ID: 1, Name: "HQ"},
+		RouteTime: "18:30", Mode: models.RouteModeDropoff,
+	})
+	edited, err := store.MarkNoShow(context.Background(), created.ID, 0, 10, false)
+	if err != nil {
+		t.Fatalf("MarkNoShow() error = %v", err)
+	}
+	store.Close()
+
+	reopened, err := routesession.NewPersistentStore(calculator{}, path)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	t.Cleanup(reopened.Close)
+	restored, ok := reopened.Snapshot(created.ID)
+	if !ok {
+		t.Fatal("edited session was not restored")
+	}
+	if restored.Version != edited.Version || !restored.IsEditing || len(restored.NoShows) != 1 || restored.NoShows[0].ID != 10 {
+		t.Fatalf("restored = version %d editing %t no-shows %v, want version %d with rider 10 a no-show", restored.Version, restored.IsEditing, restored.NoShows, edited.Version)
+	}
+	if got := stopIDs(restored.Routes[0]); !slices.Equal(got, []int64{11}) {
+		t.Fatalf("restored stops = %v, want [11]", got)
+	}
+	if _, err := reopened.RestoreNoShow(context.Background(), created.ID, 0, 10); err != nil {
+		t.Fatalf("RestoreNoShow() after reload error = %v", err)
+	}
+}
+
+func TestRestoreNoShowRejectsADriverNoLongerInTheSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "route_sessions.json")
+	saved := `{"saved":{"id":"saved","version":2,"mode":"dropoff","activity_location":{"id":1},` +
+		`"current_routes":[{"driver":{"id":1,"vehicle_capacity":2},"effective_capacity":2,"stops":[]}],` +
+		`"no_shows":[{"participant":{"id":10,"lat":1},"driver_id":3}],` +
+		`"last_accessed_at":"` + time.Now().Format(time.RFC3339) + `"}}`
+	if err := os.WriteFile(path, []byte(saved), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := routesession.NewPersistentStore(calculator{}, path)
+	if err != nil {
+		t.Fatalf("NewPersistentStore() error = %v", err)
+	}
+	t.Cleanup(store.Close)
+
+	if _, err := store.RestoreNoShow(context.Background(), "saved", 0, 10); !errors.Is(err, routesession.ErrDriverNotInSession) {
+		t.Fatalf("RestoreNoShow() error = %v, want ErrDriverNotInSession", err)
+	}
+}
+
+func TestAvoidHighwaysSessionEditsWithTheHighwayFreeCalculator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "route_sessions.json")
+	// The store's default calculator fails, so only edits measured with the
+	// highway-free one succeed.
+	openStore := func() *routesession.Store {
+		store, err := routesession.NewPersistentStore(failingCalculator{err: errors.New("highways")}, path)
+		if err != nil {
+			t.Fatalf("NewPersistentStore() error = %v", err)
+		}
+		store.SetAvoidHighwaysCalculator(calculator{})
+		return store
+	}
+	store := openStore()
+	input := testInput()
+	input.AvoidHighways = true
+	created := store.Create(input)
+	if _, err := store.MarkNoShow(context.Background(), created.ID, 0, 10, true); err != nil {
+		t.Fatalf("MarkNoShow() error = %v", err)
+	}
+	store.Close()
+
+	reopened := openStore()
+	t.Cleanup(reopened.Close)
+	if _, err := reopened.RestoreNoShow(context.Background(), created.ID, 0, 10); err != nil {
+		t.Fatalf("RestoreNoShow() after restart error = %v", err)
+	}
+}
+
+func TestPerturbKeepsAvoidingHighways(t *testing.T) {
+	store := routesession.NewStore(failingCalculator{err: errors.New("highways")})
+	t.Cleanup(store.Close)
+	store.SetAvoidHighwaysCalculator(calculator{})
+	input := testInput()
+	input.Routes[0].Stops = append(input.Routes[0].Stops, models.RouteStop{Participant: &models.Participant{ID: 11, Lat: 2}})
+	input.AvoidHighways = true
+	created := store.Create(input)
+
+	perturbed, err := store.Perturb(context.Background(), created.ID, routesession.PerturbOptions{Seed: 1})
+	if err != nil {
+		t.Fatalf("Perturb() error = %v", err)
+	}
+	if _, err := store.MarkNoShow(context.Background(), perturbed.ID, 0, 10, true); err != nil {
+		t.Fatalf("MarkNoShow() on the perturbed copy error = %v, want the highway-free calculator", err)
+	}
+}
+
+func TestPerturbMovesHouseholdsAtTheConfiguredPrecision(t *testing.T) {
+	store := routesession.NewStore(calculator{})
+	t.Cleanup(store.Close)
+	created := store.Create(routesession.CreateInput{
+		Routes: []models.CalculatedRoute{
+			{Driver: &models.Driver{ID: 1, Lat: 5, VehicleCapacity: 3}, EffectiveCapacity: 3, Stops: []models.RouteStop{
+				{Participant: &models.Participant{ID: 10, Lat: 3}},
+				{Participant: &models.Participant{ID: 11, Lat: 3.0004}},
+			}},
+			{Driver: &models.Driver{ID: 2, Lat: 3, VehicleCapacity: 3}, EffectiveCapacity: 3, Stops: []models.RouteStop{
+				{Participant: &models.Participant{ID: 20, Lat: 1}},
+			}},
+		},
+		ActivityLocation: &models.ActivityLocation{}, Mode: models.RouteModeDropoff,
+		Options: routing.RouteOptions{HouseholdPrecision: 3},
+	})
+
+	for seed := range uint64(10) {
+		perturbed, err := store.Perturb(context.Background(), created.ID, routesession.PerturbOptions{Seed: seed, Attempts: 30, Tolerance: 10})
+		if err != nil {
+			t.Fatalf("seed %d: Perturb() error = %v", seed, err)
+		}
+		for _, route := range perturbed.Routes {
+			ids := stopIDs(route)
+			if slices.Contains(ids, 10) != slices.Contains(ids, 11) {
+				t.Fatalf("seed %d: route %v split riders 10 and 11, one household at precision 3", seed, ids)
+			}
+		}
+	}
+}
+
+func TestPersistentStoreSetsAsideAnUnparsableFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "route_sessions.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := routesession.NewPersistentStore(calculator{}, path)
+	if err != nil {
+		t.Fatalf("NewPersistentStore() error = %v, want an empty store", err)
+	}
+	t.Cleanup(store.Close)
+
+	if data, err := os.ReadFile(path + ".corrupt"); err != nil || string(data) != "{not json" {
+		t.Fatalf("set-aside file = %q, %v; want the unparsable contents", data, err)
+	}
+	created := store.Create(testInput())
+	if _, ok := store.Snapshot(created.ID); !ok {
+		t.Fatal("store cannot hold new sessions")
+	}
+}
+
+func TestCalculateSummarySplitsDistanceByVehicleType(t *testing.T) {
+	routes := []models.CalculatedRoute{
+		{Driver: &models.Driver{ID: 1}, TotalDistanceMeters: 1200, Stops: []models.RouteStop{{Participant: &models.Participant{ID: 1}}}},
+		{Driver: &models.Driver{ID: 2}, OrgVehicleID: 9, TotalDistanceMeters: 3400, Stops: []models.RouteStop{{Participant: &models.Participant{ID: 2}}}},
+		{Driver: &models.Driver{ID: 3}, TotalDistanceMeters: 500, Stops: []models.RouteStop{{Participant: &models.Participant{ID: 3}}}},
+	}
+
+	summary := routesession.CalculateSummary(routes)
+	if summary.VolunteerDistanceMeters != 1700 || summary.InstituteVehicleDistanceMeters != 3400 {
+		t.Fatalf("distance split = volunteer %.0f institute %.0f, want 1700 and 3400", summary.VolunteerDistanceMeters, summary.InstituteVehicleDistanceMeters)
+	}
+	if got := summary.VolunteerDistanceMeters + summary.InstituteVehicleDistanceMeters; got != summary.TotalDistanceMeters {
+		t.Fatalf("volunteer + institute distance = %.0f, want total %.0f", got, summary.TotalDistanceMeters)
+	}
+}
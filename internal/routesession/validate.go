@@ -0,0 +1,110 @@
+package routesession
+
+import (
+	"fmt"
+	"ride-home-router/internal/models"
+	"ride-home-router/internal/routing"
+)
+
+// Violation kinds reported by Validate.
+const (
+	ViolationOverCapacity       = "over_capacity"
+	ViolationMissingDriver      = "missing_driver"
+	ViolationUnmetRequirement   = "unmet_requirement"
+	ViolationExcludedByDriver   = "excluded_by_driver"
+	ViolationInaccessible       = "inaccessible_vehicle"
+	ViolationSoloRide           = "solo_ride"
+	ViolationMissingCoordinates = "missing_coordinates"
+	ViolationDetourCap          = "over_detour_cap"
+	ViolationRideCap            = "over_ride_cap"
+	ViolationDurationCap        = "over_duration_cap"
+	ViolationTimeWindow         = "outside_time_window"
+	ViolationForcedOrder        = "forced_order"
+)
+
+// Violation is one way a session's current routes break a constraint the
+// router enforces. ParticipantID is zero for route-level violations.
+type Violation struct {
+	Kind          string `json:"kind"`
+	RouteIndex    int    `json:"route_index"`
+	ParticipantID int64  `json:"participant_id,omitempty"`
+	Message       string `json:"message"`
+}
+
+// Validate checks the session's current routes against seat capacity,
+// attribute requirements, driver exclusions, accessible vehicles, solo rides,
+// missing coordinates, participant time windows and forced stop positions,
+// the driver's route duration cap and the calculation's detour and ride caps,
+// so a plan edited by hand can be checked before it is finalized. An empty result
+// means the plan is valid.
+func (s *Store) Validate(id string) ([]Violation, error) {
+	state, err := s.lockSession(id)
+	if err != nil {
+		return nil, err
+	}
+	defer state.mu.Unlock()
+	return validateRoutes(state.currentRoutes, state.caps), nil
+}
+
+func validateRoutes(routes []models.CalculatedRoute, caps routing.RouteCaps) []Violation {
+	violations := []Violation{}
+	over, _ := capacityState(routes)
+	for i, route := range routes {
+		if route.Driver == nil {
+			violations = append(violations, Violation{Kind: ViolationMissingDriver, RouteIndex: i, Message: fmt.Sprintf("Route %d has no driver", i+1)})
+		} else if missingCoordinates(route.Driver.GetCoords()) {
+			violations = append(violations, Violation{Kind: ViolationMissingCoordinates, RouteIndex: i, Message: fmt.Sprintf("%s has no map location", route.Driver.Name)})
+		}
+		if over[i] {
+			capacity, _ := routeCapacity(route)
+			violations = append(violations, Violation{Kind: ViolationOverCapacity, RouteIndex: i, Message: fmt.Sprintf("Route %d carries %d riders in %d seats", i+1, len(route.Stops), capacity)})
+		}
+		caps.Apply(&route)
+		if route.ExceedsDetourCap {
+			violations = append(violations, Violation{Kind: ViolationDetourCap, RouteIndex: i, Message: fmt.Sprintf("Route %d's detour is %.0f seconds over the cap", i+1, -route.DetourSecsUnderCap)})
+		}
+		if route.ExceedsRideCap {
+			violations = append(violations, Violation{Kind: ViolationRideCap, RouteIndex: i, Message: fmt.Sprintf("Route %d's longest ride is %.0f seconds over the cap", i+1, -route.RideSecsUnderCap)})
+		}
+		if route.ExceedsDurationCap {
+			violations = append(violations, Violation{Kind: ViolationDurationCap, RouteIndex: i, Message: fmt.Sprintf("Route %d is %.0f seconds longer than %s will drive", i+1, -route.DurationSecsUnderCap, route.Driver.Name)})
+		}
+
+		for position, stop := range route.Stops {
+			participant := stop.Participant
+			if participant == nil {
+				continue
+			}
+			if route.Driver != nil && route.Driver.Excludes(participant) {
+				violations = append(violations, Violation{Kind: ViolationExcludedByDriver, RouteIndex: i, ParticipantID: participant.ID, Message: fmt.Sprintf("%s cannot ride with %s", participant.Name, route.Driver.Name)})
+			}
+			if route.Driver != nil && !route.Driver.SatisfiesRequirements(participant) {
+				violations = append(violations, Violation{Kind: ViolationUnmetRequirement, RouteIndex: i, ParticipantID: participant.ID, Message: fmt.Sprintf("%s's driver does not meet their requirements", participant.Name)})
+			}
+			if route.Driver != nil && participant.RequiresAccessible && !route.Driver.IsAccessible {
+				violations = append(violations, Violation{Kind: ViolationInaccessible, RouteIndex: i, ParticipantID: participant.ID, Message: fmt.Sprintf("%s needs an accessible vehicle but %s's is not", participant.Name, route.Driver.Name)})
+			}
+			if participant.SoloRide && len(route.Stops) > 1 {
+				violations = append(violations, Violation{Kind: ViolationSoloRide, RouteIndex: i, ParticipantID: participant.ID, Message: fmt.Sprintf("%s must ride alone", participant.Name)})
+			}
+			if missingCoordinates(participant.GetCoords()) {
+				violations = append(violations, Violation{Kind: ViolationMissingCoordinates, RouteIndex: i, ParticipantID: participant.ID, Message: fmt.Sprintf("%s has no map location", participant.Name)})
+			}
+			if !participant.WithinTimeWindow(stop.CumulativeDurationSecs) {
+				violations = append(violations, Violation{Kind: ViolationTimeWindow, RouteIndex: i, ParticipantID: participant.ID, Message: fmt.Sprintf("%s is reached %.0f seconds in, outside their time window", participant.Name, stop.CumulativeDurationSecs)})
+			}
+			if participant.ForcedOrder != nil {
+				// The router pins an index past the end of the route to its last stop.
+				if forced := min(*participant.ForcedOrder, len(route.Stops)-1); position != forced {
+					violations = append(violations, Violation{Kind: ViolationForcedOrder, RouteIndex: i, ParticipantID: participant.ID, Message: fmt.Sprintf("%s must be stop %d but is stop %d", participant.Name, forced+1, position+1)})
+				}
+			}
+		}
+	}
+	return violations
+}
+
+// missingCoordinates reports an ungeocoded point, which is stored as 0,0.
+func missingCoordinates(coords models.Coordinates) bool {
+	return coords.Lat == 0 && coords.Lng == 0
+}
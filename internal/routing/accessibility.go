@@ -0,0 +1,44 @@
+package routing
+
+import (
+	"fmt"
+	"ride-home-router/internal/models"
+)
+
+// accessibleSeatCounts returns how many of the participants need an
+// accessible vehicle and how many seats the accessible drivers offer.
+func accessibleSeatCounts(participants []models.Participant, drivers []models.Driver) (needed, available int) {
+	for _, participant := range participants {
+		if participant.RequiresAccessible {
+			needed++
+		}
+	}
+	for _, driver := range drivers {
+		if driver.IsAccessible {
+			available += driver.VehicleCapacity
+		}
+	}
+	return needed, available
+}
+
+// accessibleShortfall describes the seats participants who need an
+// accessible vehicle are short of.
+func accessibleShortfall(needed, available int) string {
+	return fmt.Sprintf("%d participants need an accessible vehicle but accessible drivers have only %d seats", needed, available)
+}
+
+// accessibleCapacityError explains why the accessible drivers cannot seat
+// everyone who needs an accessible vehicle. It returns nil when nobody needs
+// one or there are enough accessible seats.
+func accessibleCapacityError(req *RoutingRequest) error {
+	needed, available := accessibleSeatCounts(req.Participants, req.Drivers)
+	if needed <= available {
+		return nil
+	}
+	return &ErrRoutingFailed{
+		Reason:            accessibleShortfall(needed, available),
+		UnassignedCount:   len(req.Participants),
+		TotalCapacity:     totalDriverCapacity(req.Drivers),
+		TotalParticipants: len(req.Participants),
+	}
+}
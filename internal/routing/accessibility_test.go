@@ -0,0 +1,60 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"ride-home-router/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestBalancedRouter_AccessibleRiderOnlyRidesWithAccessibleDriver(t *testing.T) {
+	router := NewBalancedRouter(stableDistanceCalculator{})
+
+	result, err := router.CalculateRoutes(context.Background(), &RoutingRequest{
+		InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+		Participants: []models.Participant{
+			{ID: 1, Name: "Wheelchair", Lat: 1.5, Lng: 0, RequiresAccessible: true},
+			{ID: 2, Name: "Near Van", Lat: -1.5, Lng: 0},
+		},
+		Drivers: []models.Driver{
+			{ID: 1, Name: "Nearby Car", Lat: 2, Lng: 0, VehicleCapacity: 3},
+			{ID: 2, Name: "Accessible Van", Lat: -2, Lng: 0, VehicleCapacity: 1, IsAccessible: true},
+		},
+		Mode: RouteModeDropoff,
+	})
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+	for _, route := range result.Routes {
+		for _, stop := range route.Stops {
+			if stop.Participant.ID == 1 && !route.Driver.IsAccessible {
+				t.Fatalf("participant 1 rides with %s, which is not accessible", route.Driver.Name)
+			}
+		}
+	}
+}
+
+func TestBalancedRouter_AccessibleShortfallCountsSeats(t *testing.T) {
+	router := NewBalancedRouter(stableDistanceCalculator{})
+
+	_, err := router.CalculateRoutes(context.Background(), &RoutingRequest{
+		InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+		Participants: []models.Participant{
+			{ID: 1, Name: "First", Lat: 1, Lng: 0, RequiresAccessible: true},
+			{ID: 2, Name: "Second", Lat: 2, Lng: 0, RequiresAccessible: true},
+		},
+		Drivers: []models.Driver{
+			{ID: 1, Name: "Car", Lat: 2, Lng: 0, VehicleCapacity: 4},
+			{ID: 2, Name: "Accessible Van", Lat: -2, Lng: 0, VehicleCapacity: 1, IsAccessible: true},
+		},
+		Mode: RouteModeDropoff,
+	})
+	var routingErr *ErrRoutingFailed
+	if !errors.As(err, &routingErr) {
+		t.Fatalf("CalculateRoutes() error = %v, want ErrRoutingFailed", err)
+	}
+	if !strings.Contains(routingErr.Reason, "2 participants need an accessible vehicle but accessible drivers have only 1 seats") {
+		t.Fatalf("reason = %q, want the accessible seat shortfall", routingErr.Reason)
+	}
+}
@@ -1,7 +1,9 @@
 package routing
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"maps"
@@ -10,13 +12,24 @@ import (
 	"ride-home-router/internal/models"
 	"slices"
 	"sort"
+	"strings"
 	"time"
 )
 
 // BalancedRouter assigns participants under vehicle and household constraints,
 // then improves the complete solution using the participant-first objective.
 type BalancedRouter struct {
-	distanceCalc distance.DistanceCalculator
+	distanceCalc     distance.DistanceCalculator
+	insertionWorkers int
+}
+
+// BalancedOptions tunes a BalancedRouter. InsertionWorkers is how many
+// candidate insertions each seeding step costs at once; zero or one costs
+// them in turn. The chosen plan is the same either way. The distance
+// calculator and its cache repository must be safe for concurrent use when
+// it is above one.
+type BalancedOptions struct {
+	InsertionWorkers int
 }
 
 const (
@@ -26,26 +39,51 @@ const (
 
 // NewBalancedRouter creates a participant-first bounded-search router.
 func NewBalancedRouter(distanceCalc distance.DistanceCalculator) Router {
+	return NewBalancedRouterWithOptions(distanceCalc, BalancedOptions{})
+}
+
+// NewBalancedRouterWithOptions creates a BalancedRouter tuned by options.
+func NewBalancedRouterWithOptions(distanceCalc distance.DistanceCalculator, options BalancedOptions) Router {
 	return &BalancedRouter{
-		distanceCalc: distanceCalc,
+		distanceCalc:     distanceCalc,
+		insertionWorkers: options.InsertionWorkers,
 	}
 }
 
 func (r *BalancedRouter) CalculateRoutes(ctx context.Context, req *RoutingRequest) (*models.RoutingResult, error) {
+	results, err := r.calculateWith(ctx, req, []solveOptions{{}})
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// calculateWith runs the full calculation once per solve option, sharing the
+// bus stop assignment, validation and prewarmed distances between them. A
+// failed solve is skipped; the first error is returned only when every solve
+// fails.
+func (r *BalancedRouter) calculateWith(ctx context.Context, req *RoutingRequest, options []solveOptions) ([]*models.RoutingResult, error) {
 	totalStart := time.Now()
 
-	rc := newRouteContext(r.distanceCalc, req.InstituteCoords, req.Mode)
+	var busStops []models.BusStopAssignment
+	var doorToDoor []int64
+	if len(req.BusStops) > 0 {
+		req, busStops, doorToDoor = assignBusStops(req)
+	}
+
+	calc := r.calculatorFor(req)
+	rc := newRequestRouteContext(calc, req)
 
-	log.Printf("[BALANCED] Starting calculation: participants=%d drivers=%d mode=%s",
-		len(req.Participants), len(req.Drivers), rc.mode)
+	log.Printf("[BALANCED] Starting calculation: participants=%d drivers=%d mode=%s metric=%s",
+		len(req.Participants), len(req.Drivers), rc.mode, rc.metric)
 
 	// Handle empty participants
 	if len(req.Participants) == 0 {
-		return &models.RoutingResult{
+		return []*models.RoutingResult{{
 			Routes:  []models.CalculatedRoute{},
 			Summary: models.RoutingSummary{},
 			Mode:    rc.mode,
-		}, nil
+		}}, nil
 	}
 
 	// Handle empty drivers
@@ -57,15 +95,73 @@ func (r *BalancedRouter) CalculateRoutes(ctx context.Context, req *RoutingReques
 			TotalParticipants: len(req.Participants),
 		}
 	}
+	if err := soloRideCapacityError(req); err != nil {
+		return nil, err
+	}
+	if err := accessibleCapacityError(req); err != nil {
+		return nil, err
+	}
 
 	// Prewarm distance cache with only the directed pairs needed for this solve.
 	prewarmStart := time.Now()
-	if err := prewarmRoutingDistances(ctx, r.distanceCalc, req, rc.mode); err != nil {
+	if err := prewarmRoutingDistances(ctx, calc, req, rc.mode); err != nil {
 		return nil, err
 	}
 	log.Printf("[TIMING] Prewarm cache: %v", time.Since(prewarmStart))
-	rc.distanceCalc = newSolveDistanceCache(r.distanceCalc)
+	solveCalc := newSolveDistanceCache(calc)
+	rc.distanceCalc = solveCalc
+
+	results := make([]*models.RoutingResult, 0, len(options))
+	var firstErr error
+	for _, opts := range options {
+		result, err := r.solveWithinDetourCeiling(ctx, rc, req, opts)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			if len(options) > 1 {
+				log.Printf("[BALANCED] Skipping failed solve (start=%d skip_assignment_search=%t): %v", opts.startDriverIndex, opts.skipAssignmentSearch, err)
+			}
+			continue
+		}
+		result.DriversAtActivity = driversAtActivity(req.InstituteCoords, req.Drivers)
+		result.ForcedOrderConflicts = forcedOrderConflicts(result.Routes)
+		result.Warnings = append(result.Warnings, rc.splitHouseholdWarnings(result.Routes)...)
+		if solveCalc.estimated {
+			result.Warnings = append(result.Warnings, WarningEstimatedDistances)
+		}
+		result.BusStops, result.DoorToDoor = busStops, doorToDoor
+		log.Printf("[BALANCED] Complete: drivers_used=%d total_distance=%.0fm",
+			result.Summary.TotalDriversUsed, result.Summary.TotalDropoffDistanceMeters)
+		results = append(results, result)
+	}
+	if len(results) == 0 {
+		return nil, firstErr
+	}
+	log.Printf("[TIMING] TOTAL: %v", time.Since(totalStart))
+
+	return results, nil
+}
 
+// calculatorFor returns the request's shared calculator when it has one.
+func (r *BalancedRouter) calculatorFor(req *RoutingRequest) distance.DistanceCalculator {
+	if req.Distances != nil {
+		return req.Distances
+	}
+	return r.distanceCalc
+}
+
+// solveOptions perturbs a single solve so callers can explore alternatives.
+type solveOptions struct {
+	startDriverIndex     int
+	skipAssignmentSearch bool
+}
+
+// solve runs the seeding and improvement phases against prewarmed distances.
+func (r *BalancedRouter) solve(ctx context.Context, rc routeContext, req *RoutingRequest, opts solveOptions) (*models.RoutingResult, error) {
 	// Initialize routes for each driver
 	routes := make(map[int64]*balancedRoute)
 	driverIDs := make([]int64, 0, len(req.Drivers))
@@ -87,11 +183,16 @@ func (r *BalancedRouter) CalculateRoutes(ctx context.Context, req *RoutingReques
 	// Phase 1: Build a feasible rider-score seed. The complete lexicographic
 	// objective is applied by the ordering and assignment phases below.
 	phase1Start := time.Now()
-	unassigned, err := r.roundRobinInsertion(ctx, rc, routes, driverIDs, unassigned)
+	unassigned, err := r.roundRobinInsertionFrom(ctx, rc, routes, driverIDs, unassigned, opts.startDriverIndex)
 	if err != nil {
 		return nil, err
 	}
 	log.Printf("[TIMING] Phase 1 (round-robin): %v", time.Since(phase1Start))
+	if idle := countIdleRoutes(routes); idle > 0 {
+		// Extra drivers stay empty unless the assignment search finds a better
+		// solution using them; buildResult omits any that remain unused.
+		log.Printf("[BALANCED] %d of %d drivers have no riders after seeding", idle, len(driverIDs))
+	}
 
 	// Phase 2: Improve route order in the context of the complete solution.
 	phase2Start := time.Now()
@@ -102,12 +203,14 @@ func (r *BalancedRouter) CalculateRoutes(ctx context.Context, req *RoutingReques
 
 	// Phase 3: Always search relocations and household swaps, including swaps
 	// between saturated vehicles.
-	phase3Start := time.Now()
-	iterations, err := r.optimizeAssignments(ctx, rc, routes, driverIDs)
-	if err != nil {
-		return nil, err
+	if !opts.skipAssignmentSearch {
+		phase3Start := time.Now()
+		iterations, err := r.optimizeAssignments(ctx, rc, routes, driverIDs)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("[TIMING] Phase 3 (assignment search): %v (iterations=%d)", time.Since(phase3Start), iterations)
 	}
-	log.Printf("[TIMING] Phase 3 (assignment search): %v (iterations=%d)", time.Since(phase3Start), iterations)
 
 	// Check for unassigned participants
 	if len(unassigned) > 0 {
@@ -115,24 +218,67 @@ func (r *BalancedRouter) CalculateRoutes(ctx context.Context, req *RoutingReques
 		for _, d := range req.Drivers {
 			totalCapacity += d.VehicleCapacity
 		}
+		reason := "Cannot assign all participants"
+		if rc.maxRideSecs > 0 {
+			reason = fmt.Sprintf("Cannot assign all participants within the %.0f minute ride limit", rc.maxRideSecs/60)
+		}
+		var outsideWindow []int64
+		var outsideNames []string
+		for _, participant := range unassigned {
+			if participant.HasTimeWindow() {
+				outsideWindow = append(outsideWindow, participant.ID)
+				outsideNames = append(outsideNames, participant.Name)
+			}
+		}
+		if len(outsideNames) > 0 {
+			reason = fmt.Sprintf("%s; no route reaches %s within their time window", reason, strings.Join(outsideNames, ", "))
+		}
+		var excluded []int64
+		var excludedNames []string
+		for _, participant := range unassigned {
+			if excludedByAnyDriver(routes, participant) {
+				excluded = append(excluded, participant.ID)
+				excludedNames = append(excludedNames, participant.Name)
+			}
+		}
+		if len(excludedNames) > 0 {
+			reason = fmt.Sprintf("%s; driver exclusions leave no seat for %s", reason, strings.Join(excludedNames, ", "))
+		}
+		var capped []string
+		for _, driverID := range driverIDs {
+			route := routes[driverID]
+			if route.driver.MaxRouteDurationSecs > 0 && len(route.stops) < route.driver.VehicleCapacity {
+				capped = append(capped, fmt.Sprintf("%s (%.0f minutes)", route.driver.Name, route.driver.MaxRouteDurationSecs/60))
+			}
+		}
+		if len(capped) > 0 {
+			reason = fmt.Sprintf("%s; route duration limits leave seats empty for %s", reason, strings.Join(capped, ", "))
+		}
+		if slices.ContainsFunc(unassigned, func(p *models.Participant) bool { return p.RequiresAccessible }) {
+			needed, available := accessibleSeatCounts(req.Participants, req.Drivers)
+			reason = fmt.Sprintf("%s; %s", reason, accessibleShortfall(needed, available))
+		}
 		return nil, &ErrRoutingFailed{
-			Reason:            "Cannot assign all participants",
+			Reason:            reason,
 			UnassignedCount:   len(unassigned),
 			TotalCapacity:     totalCapacity,
 			TotalParticipants: len(req.Participants),
+			OutsideTimeWindow: outsideWindow,
+			ExcludedByDriver:  excluded,
 		}
 	}
 
-	// Build result
-	result, err := r.buildResult(ctx, rc, routes, len(req.Participants))
+	// Phase 4: Flag, and optionally spread, neighboring first stops.
+	collisions, err := r.compactDepartures(ctx, rc, routes, driverIDs, req.CompactDepartures)
 	if err != nil {
 		return nil, err
 	}
 
-	log.Printf("[BALANCED] Complete: drivers_used=%d total_distance=%.0fm",
-		result.Summary.TotalDriversUsed, result.Summary.TotalDropoffDistanceMeters)
-	log.Printf("[TIMING] TOTAL: %v", time.Since(totalStart))
-
+	result, err := r.buildResult(ctx, rc, routes, len(req.Participants))
+	if err != nil {
+		return nil, err
+	}
+	result.DepartureCollisions = collisions
 	return result, nil
 }
 
@@ -145,11 +291,30 @@ type balancedRoute struct {
 // roundRobinInsertion assigns participants by cycling through drivers
 // Groups participants from the same household and assigns them together
 func (r *BalancedRouter) roundRobinInsertion(ctx context.Context, rc routeContext, routes map[int64]*balancedRoute, driverIDs []int64, unassigned []*models.Participant) ([]*models.Participant, error) {
-	// Sort drivers by ID for consistent ordering
+	return r.roundRobinInsertionFrom(ctx, rc, routes, driverIDs, unassigned, 0)
+}
+
+// roundRobinInsertionFrom is roundRobinInsertion starting the cycle at the
+// given position in the sorted driver list.
+func (r *BalancedRouter) roundRobinInsertionFrom(ctx context.Context, rc routeContext, routes map[int64]*balancedRoute, driverIDs []int64, unassigned []*models.Participant, startIndex int) ([]*models.Participant, error) {
+	// Sort drivers by ID for consistent ordering, then, when rotating, put
+	// the drivers with the fewest recent rides first so they are seeded.
+	// Preferring nearby drivers instead fills the nearest first.
 	slices.Sort(driverIDs)
+	if rc.recentDriverRides != nil {
+		slices.SortStableFunc(driverIDs, func(a, b int64) int {
+			return cmp.Compare(rc.recentDriverRides[a], rc.recentDriverRides[b])
+		})
+	}
+	if rc.nearbyDriverRanks != nil {
+		slices.SortStableFunc(driverIDs, func(a, b int64) int {
+			return cmp.Compare(rc.nearbyDriverRanks[a], rc.nearbyDriverRanks[b])
+		})
+		startIndex = 0
+	}
 
 	// Group participants by address
-	groups := groupParticipantsByAddress(unassigned)
+	groups := rc.groupParticipantsByAddress(unassigned)
 	maxVehicleCapacity := maxRouteVehicleCapacity(routes)
 	splittableHouseholds := make(map[string]struct{})
 	for _, group := range groups {
@@ -163,7 +328,7 @@ func (r *BalancedRouter) roundRobinInsertion(ctx context.Context, rc routeContex
 		totalParticipants, len(groups), len(driverIDs))
 
 	// Round-robin through drivers, assigning best-fit group to each
-	driverIndex := 0
+	driverIndex := startIndex % len(driverIDs)
 	maxRounds := totalParticipants * len(driverIDs) * 2 // Safety limit (based on participants, not groups, to handle household splitting)
 
 	for len(groups) > 0 && maxRounds > 0 {
@@ -200,11 +365,7 @@ func (r *BalancedRouter) roundRobinInsertion(ctx context.Context, rc routeContex
 		}
 
 		// Find best group and position for this driver (minimize insertion cost)
-		bestCost := math.Inf(1)
-		var bestGroup *participantGroup
-		var bestGroupIndex int
-		var bestPosition int
-
+		var candidates []insertionCandidate
 		for groupIdx, group := range groups {
 			groupSize := len(group.members)
 
@@ -213,29 +374,36 @@ func (r *BalancedRouter) roundRobinInsertion(ctx context.Context, rc routeContex
 				// Group too large - skip; we'll try splitting individuals below
 				continue
 			}
+			if !groupSatisfiedBy(route.driver, group) {
+				continue
+			}
+			if !soloRideAllowsJoining(route.stops, group.members...) {
+				continue
+			}
 			if !assignmentPreservesCapacityFeasibility(routes, currentDriverID, groups, groupIdx, groupSize, splittableHouseholds) {
 				continue
 			}
+			if !assignmentPreservesRequirementFeasibility(routes, currentDriverID, groups, groupIdx, groupSize) {
+				continue
+			}
+			if !assignmentPreservesSoloFeasibility(routes, currentDriverID, groups, groupIdx, groupSize) {
+				continue
+			}
 
-			// Try all insertion positions for this group
-			for _, pos := range householdBoundaryPositions(route.stops) {
-				cost, err := rc.groupInsertionDeltaRiderScoreFrom(ctx, route.driver, route.stops, group, pos, routeScore)
-				if err != nil {
-					return nil, err
-				}
-
-				if cost < bestCost {
-					bestCost = cost
-					bestGroup = group
-					bestGroupIndex = groupIdx
-					bestPosition = pos
-				}
+			// Try the insertion positions for this group
+			for _, pos := range rc.insertionPositions(route.driver, route.stops, group) {
+				candidates = append(candidates, insertionCandidate{group: group, groupIndex: groupIdx, pos: pos})
 			}
 		}
+		best, bestCost, err := r.cheapestInsertion(ctx, rc, route.driver, route.stops, routeScore, candidates)
+		if err != nil {
+			return nil, err
+		}
 
 		// If no whole group fits this vehicle, split only households that cannot
 		// fit in any selected vehicle.
-		if bestGroup == nil {
+		if best < 0 {
+			candidates = candidates[:0]
 			for groupIdx, group := range groups {
 				if len(group.members) == 0 {
 					continue
@@ -243,35 +411,42 @@ func (r *BalancedRouter) roundRobinInsertion(ctx context.Context, rc routeContex
 				if _, ok := splittableHouseholds[participantGroupKey(group)]; !ok {
 					continue
 				}
+				member := rc.splitMember(group)
+				if !route.driver.CanCarry(member) {
+					continue
+				}
+				if !soloRideAllowsJoining(route.stops, member) {
+					continue
+				}
 				if !assignmentPreservesCapacityFeasibility(routes, currentDriverID, groups, groupIdx, 1, splittableHouseholds) {
 					continue
 				}
+				if !assignmentPreservesRequirementFeasibility(routes, currentDriverID, groups, groupIdx, 1) {
+					continue
+				}
+				if !assignmentPreservesSoloFeasibility(routes, currentDriverID, groups, groupIdx, 1) {
+					continue
+				}
 
-				// Try just the first member of the group, but still only at
+				// Try just the member the split policy picks, but still only at
 				// household boundaries so existing same-address riders stay adjacent.
-				for _, pos := range householdBoundaryPositions(route.stops) {
-					singleGroup := &participantGroup{
-						members: []*models.Participant{group.members[0]},
-						address: group.address,
-						lat:     group.lat,
-						lng:     group.lng,
-					}
-					cost, err := rc.groupInsertionDeltaRiderScoreFrom(ctx, route.driver, route.stops, singleGroup, pos, routeScore)
-					if err != nil {
-						return nil, err
-					}
-
-					if cost < bestCost {
-						bestCost = cost
-						bestGroup = singleGroup
-						bestGroupIndex = groupIdx
-						bestPosition = pos
-					}
+				singleGroup := &participantGroup{
+					members: []*models.Participant{member},
+					address: group.address,
+					lat:     group.lat,
+					lng:     group.lng,
+				}
+				for _, pos := range rc.insertionPositions(route.driver, route.stops, singleGroup) {
+					candidates = append(candidates, insertionCandidate{group: singleGroup, groupIndex: groupIdx, pos: pos})
 				}
 			}
+			best, bestCost, err = r.cheapestInsertion(ctx, rc, route.driver, route.stops, routeScore, candidates)
+			if err != nil {
+				return nil, err
+			}
 		}
 
-		if bestGroup == nil {
+		if best < 0 {
 			driverIndex = (driverIndex + 1) % len(driverIDs)
 			if driverIndex == startIndex {
 				break
@@ -280,6 +455,7 @@ func (r *BalancedRouter) roundRobinInsertion(ctx context.Context, rc routeContex
 		}
 
 		// Insert the group
+		bestGroup, bestGroupIndex, bestPosition := candidates[best].group, candidates[best].groupIndex, candidates[best].pos
 		route.stops = insertGroupAt(route.stops, bestGroup, bestPosition)
 
 		memberNames := make([]string, len(bestGroup.members))
@@ -313,8 +489,11 @@ func (r *BalancedRouter) roundRobinInsertion(ctx context.Context, rc routeContex
 			originalGroup.members = newMembers
 		}
 
-		// Move to next driver (round-robin)
-		driverIndex = (driverIndex + 1) % len(driverIDs)
+		// Move to next driver (round-robin), unless filling the nearest
+		// drivers first.
+		if rc.nearbyDriverRanks == nil {
+			driverIndex = (driverIndex + 1) % len(driverIDs)
+		}
 	}
 
 	// Build list of unassigned participants from remaining groups
@@ -326,25 +505,45 @@ func (r *BalancedRouter) roundRobinInsertion(ctx context.Context, rc routeContex
 	return unassignedResult, nil
 }
 
+// routeObjectiveMetrics values are in the context's optimization metric:
+// seconds by default, meters when optimizing distance.
 type routeObjectiveMetrics struct {
+	excessNeighborhoods            int
 	latestParticipantCompletion    float64
 	aggregateParticipantCompletion float64
 	driverDetour                   float64
+	detourRatio                    float64
 	driveDuration                  float64
+	ageInversions                  int
 	used                           bool
 }
 
 type solutionScore struct {
+	excessNeighborhoods            int
+	farthestNearbyRank             int
 	latestParticipantCompletion    float64
+	maxDetourRatio                 float64
+	idleRotationCost               float64
+	weightedDriverCost             float64
 	maxDriverDetour                float64
 	aggregateParticipantCompletion float64
 	aggregateDriveDuration         float64
 	usedDrivers                    int
+	ageInversions                  int
 }
 
 func (score solutionScore) betterThan(other solutionScore) bool {
+	if score.excessNeighborhoods != other.excessNeighborhoods {
+		return score.excessNeighborhoods < other.excessNeighborhoods
+	}
+	if score.farthestNearbyRank != other.farthestNearbyRank {
+		return score.farthestNearbyRank < other.farthestNearbyRank
+	}
 	for _, values := range [][2]float64{
 		{score.latestParticipantCompletion, other.latestParticipantCompletion},
+		{score.maxDetourRatio, other.maxDetourRatio},
+		{score.idleRotationCost, other.idleRotationCost},
+		{score.weightedDriverCost, other.weightedDriverCost},
 		{score.maxDriverDetour, other.maxDriverDetour},
 		{score.aggregateParticipantCompletion, other.aggregateParticipantCompletion},
 		{score.aggregateDriveDuration, other.aggregateDriveDuration},
@@ -357,7 +556,10 @@ func (score solutionScore) betterThan(other solutionScore) bool {
 		}
 	}
 
-	return score.usedDrivers > other.usedDrivers
+	if score.usedDrivers != other.usedDrivers {
+		return score.usedDrivers > other.usedDrivers
+	}
+	return score.ageInversions < other.ageInversions
 }
 
 func (rc routeContext) evaluateRouteObjective(ctx context.Context, driver *models.Driver, stops []*models.Participant) (routeObjectiveMetrics, error) {
@@ -370,40 +572,82 @@ func (rc routeContext) evaluateRouteObjective(ctx context.Context, driver *model
 		return routeObjectiveMetrics{}, err
 	}
 
+	fullCost, detour := rc.routeCosts(metrics)
+	routeCost := fullCost
+	if rc.excludeHomeLeg {
+		// Without the driver's own leg there is no direct trip to measure a
+		// detour against, so only the passenger legs are weighed.
+		routeCost -= rc.homeLegCost(metrics)
+		detour = 0
+	}
+	// Stops beyond the driver's comfort radius weigh on them like extra
+	// detour, so the assignment search steers those riders elsewhere when it
+	// can.
 	result := routeObjectiveMetrics{
-		driverDetour:  metrics.DetourSecs,
-		driveDuration: metrics.RouteDurationSecs,
-		used:          true,
+		excessNeighborhoods: rc.excessNeighborhoods(stops),
+		ageInversions:       rc.ageInversions(stops),
+		driverDetour:        detour + rc.comfortPenalty(driver, stops),
+		driveDuration:       routeCost,
+		used:                true,
+	}
+	if rc.detourFairness == DetourFairnessRatio {
+		result.detourRatio = rc.detourRatio(metrics, result.driverDetour)
+	}
+	if rc.mode == RouteModePickup && rc.pickupObjective == PickupObjectiveMaxRide {
+		// Each rider is in the car from their stop until the activity.
+		for _, stop := range metrics.Stops {
+			ride := fullCost - rc.stopCost(stop)
+			result.latestParticipantCompletion = max(result.latestParticipantCompletion, ride)
+			result.aggregateParticipantCompletion += ride
+		}
+		return result, nil
 	}
 	if rc.mode == RouteModePickup {
-		result.latestParticipantCompletion = metrics.RouteDurationSecs
-		result.aggregateParticipantCompletion = metrics.RouteDurationSecs * float64(len(stops))
+		result.latestParticipantCompletion = routeCost
+		result.aggregateParticipantCompletion = routeCost * float64(len(stops))
 		return result, nil
 	}
 
 	for _, stop := range metrics.Stops {
-		result.latestParticipantCompletion = max(result.latestParticipantCompletion, stop.CumulativeDurationSecs)
-		result.aggregateParticipantCompletion += stop.CumulativeDurationSecs
+		result.latestParticipantCompletion = max(result.latestParticipantCompletion, rc.stopCost(stop))
+		result.aggregateParticipantCompletion += rc.stopCost(stop)
 	}
 	return result, nil
 }
 
-func scoreSolution(routeMetrics map[int64]routeObjectiveMetrics, driverIDs []int64) solutionScore {
+func (rc routeContext) scoreSolution(routeMetrics map[int64]routeObjectiveMetrics, driverIDs []int64) solutionScore {
 	result := solutionScore{maxDriverDetour: math.Inf(-1)}
+	sumDriverDetour := 0.0
 	for _, driverID := range driverIDs {
 		metrics := routeMetrics[driverID]
 		if !metrics.used {
+			if rc.recentDriverRides != nil {
+				// An idle driver costs more the less they have driven lately.
+				result.idleRotationCost += 1 / float64(1+rc.recentDriverRides[driverID])
+			}
 			continue
 		}
+		result.excessNeighborhoods += metrics.excessNeighborhoods
+		if rc.nearbyDriverRanks != nil {
+			result.farthestNearbyRank = rc.farthestNearbyRank(driverID, result.farthestNearbyRank)
+		}
 		result.latestParticipantCompletion = max(result.latestParticipantCompletion, metrics.latestParticipantCompletion)
+		result.maxDetourRatio = max(result.maxDetourRatio, metrics.detourRatio)
 		result.maxDriverDetour = max(result.maxDriverDetour, metrics.driverDetour)
+		sumDriverDetour += metrics.driverDetour
 		result.aggregateParticipantCompletion += metrics.aggregateParticipantCompletion
 		result.aggregateDriveDuration += metrics.driveDuration
 		result.usedDrivers++
+		result.ageInversions += metrics.ageInversions
 	}
 	if result.usedDrivers == 0 {
 		result.maxDriverDetour = 0
 	}
+	if weights := rc.fairnessWeights; weights != (FairnessWeights{}) {
+		result.weightedDriverCost = weights.MaxDetourWeight*result.maxDriverDetour +
+			weights.SumDetourWeight*sumDriverDetour +
+			weights.UnusedDriverPenalty*float64(len(driverIDs)-result.usedDrivers)
+	}
 	return result
 }
 
@@ -412,7 +656,7 @@ func (r *BalancedRouter) optimizeRouteOrders(ctx context.Context, rc routeContex
 	candidateStops := make(map[int64][]*models.Participant, len(driverIDs))
 	for _, driverID := range driverIDs {
 		route := routes[driverID]
-		stops := coalesceHouseholdStops(route.stops)
+		stops := pinForcedOrder(rc.coalesceHouseholdStops(route.stops))
 		metrics, err := rc.evaluateRouteObjective(ctx, route.driver, stops)
 		if err != nil {
 			return err
@@ -445,7 +689,7 @@ func (r *BalancedRouter) optimizeStopsForSolution(
 	currentMetrics := make(map[int64]routeObjectiveMetrics, len(baseMetrics))
 	maps.Copy(currentMetrics, baseMetrics)
 	for driverID, stops := range changedStops {
-		stops = coalesceHouseholdStops(stops)
+		stops = pinForcedOrder(rc.coalesceHouseholdStops(stops))
 		metrics, err := rc.evaluateRouteObjective(ctx, routes[driverID].driver, stops)
 		if err != nil {
 			return nil, nil, solutionScore{}, err
@@ -454,7 +698,7 @@ func (r *BalancedRouter) optimizeStopsForSolution(
 		currentMetrics[driverID] = metrics
 	}
 
-	currentScore := scoreSolution(currentMetrics, driverIDs)
+	currentScore := rc.scoreSolution(currentMetrics, driverIDs)
 	const maxOrderIterations = 50
 	for range maxOrderIterations {
 		bestDriverID := int64(0)
@@ -468,12 +712,19 @@ func (r *BalancedRouter) optimizeStopsForSolution(
 			if !affected {
 				continue
 			}
-			blocks := routeHouseholdBlocks(stops)
+			blocks := rc.routeHouseholdBlocks(stops)
 			for i := 0; i < len(blocks)-1; i++ {
 				for j := i + 2; j <= len(blocks); j++ {
 					candidateBlocks := append([]*participantGroup(nil), blocks...)
 					reverseParticipantGroups(candidateBlocks, i, j-1)
-					candidateStops := flattenParticipantGroups(candidateBlocks)
+					candidateStops := pinForcedOrder(flattenParticipantGroups(candidateBlocks))
+					withinCap, err := rc.withinTimeLimits(ctx, routes[driverID].driver, candidateStops)
+					if err != nil {
+						return nil, nil, solutionScore{}, err
+					}
+					if !withinCap {
+						continue
+					}
 					candidateMetrics, err := rc.evaluateRouteObjective(ctx, routes[driverID].driver, candidateStops)
 					if err != nil {
 						return nil, nil, solutionScore{}, err
@@ -481,7 +732,7 @@ func (r *BalancedRouter) optimizeStopsForSolution(
 
 					previousMetrics := currentMetrics[driverID]
 					currentMetrics[driverID] = candidateMetrics
-					candidateScore := scoreSolution(currentMetrics, driverIDs)
+					candidateScore := rc.scoreSolution(currentMetrics, driverIDs)
 					currentMetrics[driverID] = previousMetrics
 					if !candidateScore.betterThan(currentScore) || found && !candidateScore.betterThan(bestScore) {
 						continue
@@ -531,7 +782,7 @@ func (r *BalancedRouter) optimizeAssignments(ctx context.Context, rc routeContex
 
 	const maxIterations = 50
 	for iteration := range maxIterations {
-		currentScore := scoreSolution(routeMetrics, driverIDs)
+		currentScore := rc.scoreSolution(routeMetrics, driverIDs)
 		best := assignmentChange{}
 		budgetExhausted := false
 
@@ -559,6 +810,15 @@ func (r *BalancedRouter) optimizeAssignments(ctx context.Context, rc routeContex
 			if !candidateScore.betterThan(currentScore) || best.found && !candidateScore.betterThan(best.score) {
 				return nil
 			}
+			for _, driverID := range []int64{firstDriverID, secondDriverID} {
+				withinCap, err := rc.withinTimeLimits(ctx, routes[driverID].driver, optimizedStops[driverID])
+				if err != nil {
+					return err
+				}
+				if !withinCap {
+					return nil
+				}
+			}
 
 			best = assignmentChange{
 				firstDriverID:  firstDriverID,
@@ -574,7 +834,10 @@ func (r *BalancedRouter) optimizeAssignments(ctx context.Context, rc routeContex
 	relocationSearch:
 		for _, sourceDriverID := range driverIDs {
 			sourceRoute := routes[sourceDriverID]
-			sourceBlocks := routeHouseholdBlocks(sourceRoute.stops)
+			if len(sourceRoute.stops) == 0 {
+				continue
+			}
+			sourceBlocks := rc.routeHouseholdBlocks(sourceRoute.stops)
 			sourcePosition := 0
 			for _, sourceGroup := range sourceBlocks {
 				groupSize := len(sourceGroup.members)
@@ -586,8 +849,14 @@ func (r *BalancedRouter) optimizeAssignments(ctx context.Context, rc routeContex
 					if len(destinationRoute.stops)+groupSize > destinationRoute.driver.VehicleCapacity {
 						continue
 					}
+					if !groupSatisfiedBy(destinationRoute.driver, sourceGroup) {
+						continue
+					}
+					if !soloRideAllowsJoining(destinationRoute.stops, sourceGroup.members...) {
+						continue
+					}
 
-					for _, destinationPosition := range householdBoundaryPositions(destinationRoute.stops) {
+					for _, destinationPosition := range rc.insertionPositions(destinationRoute.driver, destinationRoute.stops, sourceGroup) {
 						newSourceStops := removeRange(sourceRoute.stops, sourcePosition, sourcePosition+groupSize)
 						newDestinationStops := insertGroupAt(destinationRoute.stops, sourceGroup, destinationPosition)
 						if err := consider(sourceDriverID, destinationDriverID, newSourceStops, newDestinationStops); err != nil {
@@ -609,15 +878,19 @@ func (r *BalancedRouter) optimizeAssignments(ctx context.Context, rc routeContex
 			}
 			firstRoute := routes[firstDriverID]
 			firstPosition := 0
-			for _, firstGroup := range routeHouseholdBlocks(firstRoute.stops) {
+			for _, firstGroup := range rc.routeHouseholdBlocks(firstRoute.stops) {
 				firstSize := len(firstGroup.members)
 				for _, secondDriverID := range driverIDs[firstIndex+1:] {
 					secondRoute := routes[secondDriverID]
 					secondPosition := 0
-					for _, secondGroup := range routeHouseholdBlocks(secondRoute.stops) {
+					for _, secondGroup := range rc.routeHouseholdBlocks(secondRoute.stops) {
 						secondSize := len(secondGroup.members)
 						if len(firstRoute.stops)-firstSize+secondSize <= firstRoute.driver.VehicleCapacity &&
-							len(secondRoute.stops)-secondSize+firstSize <= secondRoute.driver.VehicleCapacity {
+							len(secondRoute.stops)-secondSize+firstSize <= secondRoute.driver.VehicleCapacity &&
+							groupSatisfiedBy(firstRoute.driver, secondGroup) &&
+							groupSatisfiedBy(secondRoute.driver, firstGroup) &&
+							soloRideAllowsJoining(removeRange(firstRoute.stops, firstPosition, firstPosition+firstSize), secondGroup.members...) &&
+							soloRideAllowsJoining(removeRange(secondRoute.stops, secondPosition, secondPosition+secondSize), firstGroup.members...) {
 							newFirstStops := replaceRangeWithGroup(firstRoute.stops, firstPosition, firstPosition+firstSize, secondGroup)
 							newSecondStops := replaceRangeWithGroup(secondRoute.stops, secondPosition, secondPosition+secondSize, firstGroup)
 							if err := consider(firstDriverID, secondDriverID, newFirstStops, newSecondStops); err != nil {
@@ -685,45 +958,22 @@ func (r *BalancedRouter) buildResult(ctx context.Context, rc routeContext, route
 	for _, driverID := range driverIDs {
 		route := routes[driverID]
 		// Defensive: keep same-household riders adjacent in the final payload even
-		// if a future optimizer path forgets to normalize before build.
-		route.stops = coalesceHouseholdStops(route.stops)
+		// if a future optimizer path forgets to normalize before build. Forced
+		// stop orders are pinned last so they win over household grouping.
+		route.stops = pinForcedOrder(rc.coalesceHouseholdStops(route.stops))
 		if len(route.stops) == 0 {
 			continue
 		}
 
 		driversUsed++
 
-		routeStops := make([]models.RouteStop, len(route.stops))
-		metrics, err := rc.evaluateParticipants(ctx, route.driver, route.stops)
+		calculated, err := rc.buildRoute(ctx, route.driver, route.stops)
 		if err != nil {
 			return nil, err
 		}
-		for i, p := range route.stops {
-			routeStops[i] = models.RouteStop{
-				Order:                    i,
-				Participant:              p,
-				DistanceFromPrevMeters:   metrics.Stops[i].DistanceFromPrevMeters,
-				CumulativeDistanceMeters: metrics.Stops[i].CumulativeDistanceMeters,
-				DurationFromPrevSecs:     metrics.Stops[i].DurationFromPrevSecs,
-				CumulativeDurationSecs:   metrics.Stops[i].CumulativeDurationSecs,
-			}
-		}
-
-		totalDropoff += metrics.TotalStopDistanceMeters
-		totalDist += metrics.TotalDistanceMeters
-
-		calculatedRoutes = append(calculatedRoutes, models.CalculatedRoute{
-			Driver:                     route.driver,
-			Stops:                      routeStops,
-			TotalDropoffDistanceMeters: metrics.TotalStopDistanceMeters,
-			DistanceToDriverHomeMeters: metrics.FinalLegDistanceMeters,
-			TotalDistanceMeters:        metrics.TotalDistanceMeters,
-			EffectiveCapacity:          route.driver.VehicleCapacity,
-			BaselineDurationSecs:       metrics.BaselineDurationSecs,
-			RouteDurationSecs:          metrics.RouteDurationSecs,
-			DetourSecs:                 metrics.DetourSecs,
-			Mode:                       rc.mode,
-		})
+		totalDropoff += calculated.TotalDropoffDistanceMeters
+		totalDist += calculated.TotalDistanceMeters
+		calculatedRoutes = append(calculatedRoutes, calculated)
 	}
 
 	return &models.RoutingResult{
@@ -739,138 +989,14 @@ func (r *BalancedRouter) buildResult(ctx context.Context, rc routeContext, route
 	}, nil
 }
 
-// participantGroup represents participants from the same household
-type participantGroup struct {
-	members []*models.Participant
-	address string
-	lat     float64
-	lng     float64
-}
-
-// groupParticipantsByAddress groups participants by their address coordinates
-// Participants with the same rounded lat/lng are considered to be from the same household
-func groupParticipantsByAddress(participants []*models.Participant) []*participantGroup {
-	// Map address coordinates to group
-	addressMap := make(map[string]*participantGroup)
-
-	for _, p := range participants {
-		key := householdKey(p)
-
-		if group, exists := addressMap[key]; exists {
-			// Add to existing group
-			group.members = append(group.members, p)
-		} else {
-			// Create new group
-			addressMap[key] = newParticipantGroup(p)
-		}
-	}
-
-	// Convert map to slice
-	groups := make([]*participantGroup, 0, len(addressMap))
-	for _, group := range addressMap {
-		groups = append(groups, group)
-	}
-
-	// Sort groups by size (larger groups first) for better initial assignment
-	sort.Slice(groups, func(i, j int) bool {
-		if len(groups[i].members) != len(groups[j].members) {
-			return len(groups[i].members) > len(groups[j].members)
-		}
-		return participantGroupKey(groups[i]) < participantGroupKey(groups[j])
-	})
-
-	return groups
-}
-
-// coordinateKey creates a unique key for a coordinate pair
-func coordinateKey(lat, lng float64) string {
-	return fmt.Sprintf("%.5f,%.5f", lat, lng)
-}
-
-func householdKey(participant *models.Participant) string {
-	if participant == nil {
-		return ""
-	}
-	return coordinateKey(models.RoundCoordinate(participant.Lat), models.RoundCoordinate(participant.Lng))
-}
-
-func participantGroupKey(group *participantGroup) string {
-	if group == nil {
-		return ""
-	}
-	return coordinateKey(group.lat, group.lng)
-}
-
-func newParticipantGroup(participant *models.Participant) *participantGroup {
-	return &participantGroup{
-		members: []*models.Participant{participant},
-		address: participant.Address,
-		lat:     models.RoundCoordinate(participant.Lat),
-		lng:     models.RoundCoordinate(participant.Lng),
-	}
-}
-
-func routeHouseholdBlocks(stops []*models.Participant) []*participantGroup {
-	if len(stops) == 0 {
-		return nil
-	}
-
-	blocks := make([]*participantGroup, 0, len(stops))
-	for _, stop := range stops {
-		if len(blocks) == 0 || householdKey(blocks[len(blocks)-1].members[0]) != householdKey(stop) {
-			blocks = append(blocks, newParticipantGroup(stop))
-			continue
+func countIdleRoutes(routes map[int64]*balancedRoute) int {
+	idle := 0
+	for _, route := range routes {
+		if len(route.stops) == 0 {
+			idle++
 		}
-		blocks[len(blocks)-1].members = append(blocks[len(blocks)-1].members, stop)
 	}
-
-	return blocks
-}
-
-func householdBoundaryPositions(stops []*models.Participant) []int {
-	if len(stops) == 0 {
-		return []int{0}
-	}
-
-	positions := make([]int, 0, len(stops)+1)
-	positions = append(positions, 0)
-	pos := 0
-	for _, block := range routeHouseholdBlocks(stops) {
-		pos += len(block.members)
-		positions = append(positions, pos)
-	}
-
-	return positions
-}
-
-func coalesceHouseholdStops(stops []*models.Participant) []*models.Participant {
-	if len(stops) < 2 {
-		return stops
-	}
-
-	orderedKeys := make([]string, 0, len(stops))
-	grouped := make(map[string]*participantGroup, len(stops))
-	for _, stop := range stops {
-		key := householdKey(stop)
-		if group, exists := grouped[key]; exists {
-			group.members = append(group.members, stop)
-			continue
-		}
-
-		orderedKeys = append(orderedKeys, key)
-		grouped[key] = newParticipantGroup(stop)
-	}
-
-	result := make([]*models.Participant, 0, len(stops))
-	for _, key := range orderedKeys {
-		result = append(result, grouped[key].members...)
-	}
-
-	if slices.Equal(result, stops) {
-		return stops
-	}
-
-	return result
+	return idle
 }
 
 func maxRouteVehicleCapacity(routes map[int64]*balancedRoute) int {
@@ -974,3 +1100,66 @@ func insertGroupAt(stops []*models.Participant, group *participantGroup, pos int
 
 	return newStops
 }
+
+// buildRoute costs one driver's stops in the given order and applies the
+// context's caps.
+func (rc routeContext) buildRoute(ctx context.Context, driver *models.Driver, stops []*models.Participant) (models.CalculatedRoute, error) {
+	metrics, err := rc.evaluateParticipants(ctx, driver, stops)
+	if err != nil {
+		return models.CalculatedRoute{}, err
+	}
+	routeStops := make([]models.RouteStop, len(stops))
+	for i, p := range stops {
+		routeStops[i] = models.RouteStop{
+			Order:                    i,
+			Participant:              p,
+			DistanceFromPrevMeters:   metrics.Stops[i].DistanceFromPrevMeters,
+			CumulativeDistanceMeters: metrics.Stops[i].CumulativeDistanceMeters,
+			DurationFromPrevSecs:     metrics.Stops[i].DurationFromPrevSecs,
+			CumulativeDurationSecs:   metrics.Stops[i].CumulativeDurationSecs,
+		}
+	}
+
+	route := models.CalculatedRoute{
+		Driver:                     driver,
+		Stops:                      routeStops,
+		TotalDropoffDistanceMeters: metrics.TotalStopDistanceMeters,
+		DistanceToDriverHomeMeters: metrics.FinalLegDistanceMeters,
+		TotalDistanceMeters:        metrics.TotalDistanceMeters,
+		EffectiveCapacity:          driver.VehicleCapacity,
+		BaselineDurationSecs:       metrics.BaselineDurationSecs,
+		RouteDurationSecs:          metrics.RouteDurationSecs,
+		DetourSecs:                 metrics.DetourSecs,
+		DetourMeters:               metrics.DetourMeters,
+		Neighborhoods:              countNeighborhoods(stops),
+		Mode:                       rc.mode,
+		Color:                      models.RouteColor(driver.ID),
+	}
+	RouteCaps{MaxDetourSecs: rc.maxDetourSecs, MaxRideSecs: rc.maxRideSecs}.Apply(&route)
+	if rc.includeGeometry {
+		rc.fillStopGeometry(ctx, driver, stops, routeStops)
+	}
+	return route, nil
+}
+
+// fillStopGeometry sets each stop's road path from the previous point. The
+// path is only drawn on the map, so a provider failure leaves the remaining
+// stops without geometry instead of failing the route.
+func (rc routeContext) fillStopGeometry(ctx context.Context, driver *models.Driver, stops []*models.Participant, routeStops []models.RouteStop) {
+	prev := rc.origin(driver)
+	for i, stop := range stops {
+		points := append([]models.Coordinates{prev}, rc.legPoints(stops, i)...)
+		prev = stop.GetCoords()
+		if len(points) == 2 && models.SamePoint(points[0], points[1]) {
+			continue
+		}
+		polyline, err := rc.distanceCalc.GetRouteGeometry(ctx, points)
+		if err != nil {
+			if !errors.Is(err, distance.ErrRouteGeometryUnavailable) {
+				log.Printf("[BALANCED] Route geometry unavailable for driver %s: %v", driver.Name, err)
+			}
+			return
+		}
+		routeStops[i].GeometryPolyline = polyline
+	}
+}
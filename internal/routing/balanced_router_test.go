@@ -2,9 +2,12 @@ package routing
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"ride-home-router/internal/distance"
 	"ride-home-router/internal/models"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -34,7 +37,7 @@ func TestGroupParticipantsByAddress(t *testing.T) {
 		{ID: 6, Name: "Frank", Address: "789 Elm St", Lat: 40.34567, Lng: -74.34567},
 	}
 
-	groups := groupParticipantsByAddress(participants)
+	groups := routeContext{}.groupParticipantsByAddress(participants)
 
 	// Should have 3 groups
 	if len(groups) != 3 {
@@ -75,7 +78,7 @@ func TestGroupParticipantsByAddress_SlightlyDifferentCoordinates(t *testing.T) {
 		{ID: 3, Name: "Charlie", Lat: 40.123550, Lng: -74.123550}, // Beyond rounding precision
 	}
 
-	groups := groupParticipantsByAddress(participants)
+	groups := routeContext{}.groupParticipantsByAddress(participants)
 
 	// Alice and Bob should be in the same group (both round to 40.12345, -74.12345)
 	// Charlie should be in a different group (rounds to 40.12355, -74.12355)
@@ -367,7 +370,7 @@ func TestBalancedRouter_SwapsFullRoutesToMinimizeLatestDropoff(t *testing.T) {
 				t.Fatalf("participant %d assigned more than once", stop.Participant.ID)
 			}
 			seenParticipants[stop.Participant.ID] = true
-			key := householdKey(stop.Participant)
+			key := routeContext{}.householdKey(stop.Participant)
 			if driverID, ok := householdDriver[key]; ok && driverID != route.Driver.ID {
 				t.Fatalf("household %s split across drivers %d and %d", key, driverID, route.Driver.ID)
 			}
@@ -573,6 +576,204 @@ func TestBalancedRouter_CanLeaveASelectedDriverUnusedForAHigherPriorityObjective
 	}
 }
 
+func TestBalancedRouter_AttributeRequirementForcesDriver(t *testing.T) {
+	router := NewBalancedRouter(stableDistanceCalculator{})
+
+	result, err := router.CalculateRoutes(context.Background(), &RoutingRequest{
+		InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+		Participants: []models.Participant{
+			{
+				ID: 1, Name: "First", Lat: 1, Lng: 0,
+				Attributes:      map[string]string{"language": "es"},
+				RequiredMatches: []string{"language"},
+			},
+			{ID: 2, Name: "Second", Lat: 2, Lng: 0},
+		},
+		Drivers: []models.Driver{
+			{ID: 1, Name: "Nearby Driver", Lat: 2, Lng: 0, VehicleCapacity: 2, Attributes: map[string]string{"language": "en"}},
+			{ID: 2, Name: "Opposite Driver", Lat: -100, Lng: 0, VehicleCapacity: 2, Attributes: map[string]string{"language": "es"}},
+		},
+		Mode: RouteModeDropoff,
+	})
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+
+	drivers := make(map[int64]int64)
+	for _, route := range result.Routes {
+		for _, stop := range route.Stops {
+			drivers[stop.Participant.ID] = route.Driver.ID
+		}
+	}
+	if drivers[1] != 2 {
+		t.Fatalf("participant 1 rides with driver %d, want the Spanish-speaking driver 2", drivers[1])
+	}
+	if drivers[2] != 1 {
+		t.Fatalf("participant 2 rides with driver %d, want nearby driver 1", drivers[2])
+	}
+}
+
+func TestBalancedRouter_DriverExclusionKeepsParticipantOut(t *testing.T) {
+	router := NewBalancedRouter(stableDistanceCalculator{})
+	participants := []models.Participant{
+		{ID: 1, Name: "Excluded", Lat: 1, Lng: 0},
+		{ID: 2, Name: "Other", Lat: 2, Lng: 0},
+	}
+
+	result, err := router.CalculateRoutes(context.Background(), &RoutingRequest{
+		InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+		Participants:    participants,
+		Drivers: []models.Driver{
+			{ID: 1, Name: "Nearby Driver", Lat: 2, Lng: 0, VehicleCapacity: 2, ExcludedParticipantIDs: []int64{1}},
+			{ID: 2, Name: "Opposite Driver", Lat: -100, Lng: 0, VehicleCapacity: 2},
+		},
+		Mode: RouteModeDropoff,
+	})
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+	for _, route := range result.Routes {
+		for _, stop := range route.Stops {
+			if stop.Participant.ID == 1 && route.Driver.ID == 1 {
+				t.Fatalf("participant 1 rides with driver 1, which excludes them")
+			}
+		}
+	}
+
+	_, err = router.CalculateRoutes(context.Background(), &RoutingRequest{
+		InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+		Participants:    participants,
+		Drivers: []models.Driver{
+			{ID: 1, Name: "Nearby Driver", Lat: 2, Lng: 0, VehicleCapacity: 2, ExcludedParticipantIDs: []int64{1}},
+		},
+		Mode: RouteModeDropoff,
+	})
+	var routingErr *ErrRoutingFailed
+	if !errors.As(err, &routingErr) || !slices.Equal(routingErr.ExcludedByDriver, []int64{1}) {
+		t.Fatalf("CalculateRoutes() with only an excluding driver error = %v, want ErrRoutingFailed excluding participant 1", err)
+	}
+}
+
+func TestRoundRobinInsertion_ReservesSeatForAttributeRequirement(t *testing.T) {
+	router := &BalancedRouter{distanceCalc: stableDistanceCalculator{}}
+	rc := newRouteContext(router.distanceCalc, models.Coordinates{}, RouteModeDropoff)
+	routes := map[int64]*balancedRoute{
+		1: {driver: &models.Driver{ID: 1, Name: "Plain", Lat: -5, Lng: 0, VehicleCapacity: 1}},
+		2: {driver: &models.Driver{ID: 2, Name: "Booster", Lat: 5, Lng: 0, VehicleCapacity: 1, Attributes: map[string]string{"car_seat": "yes"}}},
+	}
+	unassigned, err := router.roundRobinInsertionFrom(context.Background(), rc, routes, []int64{1, 2}, []*models.Participant{
+		{ID: 1, Name: "Near Booster", Lat: 4, Lng: 0},
+		{ID: 2, Name: "Needs Seat", Lat: -4, Lng: 0, Attributes: map[string]string{"car_seat": "yes"}, RequiredMatches: []string{"car_seat"}},
+	}, 1)
+	if err != nil {
+		t.Fatalf("roundRobinInsertionFrom() error = %v", err)
+	}
+	if len(unassigned) != 0 {
+		t.Fatalf("unassigned = %d, want 0", len(unassigned))
+	}
+	if len(routes[2].stops) != 1 || routes[2].stops[0].ID != 2 {
+		t.Fatalf("booster driver stops = %+v, want only the participant needing a car seat", routes[2].stops)
+	}
+}
+
+func TestBalancedRouter_MoreDriversThanParticipantsLeavesExtrasUnused(t *testing.T) {
+	router := NewBalancedRouter(stableDistanceCalculator{})
+	req := &RoutingRequest{
+		InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+		Participants: []models.Participant{
+			{ID: 1, Name: "East Rider", Lat: 1, Lng: 0},
+			{ID: 2, Name: "West Rider", Lat: -1, Lng: 0},
+		},
+		Drivers: []models.Driver{
+			{ID: 1, Name: "Far North", Lat: 0, Lng: 50, VehicleCapacity: 4},
+			{ID: 2, Name: "Far South", Lat: 0, Lng: -50, VehicleCapacity: 4},
+			{ID: 3, Name: "East Driver", Lat: 2, Lng: 0, VehicleCapacity: 4},
+			{ID: 4, Name: "West Driver", Lat: -2, Lng: 0, VehicleCapacity: 4},
+			{ID: 5, Name: "Far Corner", Lat: 50, Lng: 50, VehicleCapacity: 4},
+		},
+		Mode: RouteModeDropoff,
+	}
+
+	result, err := router.CalculateRoutes(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+	if len(result.Routes) != 2 || result.Summary.TotalDriversUsed != 2 {
+		t.Fatalf("routes = %d, drivers used = %d, want exactly 2", len(result.Routes), result.Summary.TotalDriversUsed)
+	}
+	want := map[int64]int64{3: 1, 4: 2}
+	for _, route := range result.Routes {
+		if len(route.Stops) != 1 || want[route.Driver.ID] != route.Stops[0].Participant.ID {
+			t.Fatalf("driver %d stops = %+v, want only participant %d", route.Driver.ID, route.Stops, want[route.Driver.ID])
+		}
+	}
+
+	candidates, err := router.(*BalancedRouter).CalculateCandidates(context.Background(), req, 3, RankByDistance)
+	if err != nil {
+		t.Fatalf("CalculateCandidates() error = %v", err)
+	}
+	for i, candidate := range candidates {
+		for _, route := range candidate.Routes {
+			if len(route.Stops) == 0 {
+				t.Fatalf("candidate %d includes empty route for driver %d", i, route.Driver.ID)
+			}
+		}
+	}
+}
+
+func TestBalancedRouter_MeetingPointRidersShareOneStop(t *testing.T) {
+	router := NewBalancedRouter(stableDistanceCalculator{})
+	corner := &models.Coordinates{Lat: 0.5, Lng: 0.5}
+
+	result, err := router.CalculateRoutes(context.Background(), &RoutingRequest{
+		InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+		Participants: []models.Participant{
+			{ID: 1, Name: "North Home", Address: "1 North St", Lat: 1, Lng: 0, MeetingPoint: corner},
+			{ID: 2, Name: "East Home", Address: "2 East St", Lat: 0, Lng: 1, MeetingPoint: corner},
+			{ID: 3, Name: "South Home", Address: "3 South St", Lat: -1, Lng: 0, MeetingPoint: corner},
+			{ID: 4, Name: "Door To Door", Address: "4 West St", Lat: -0.5, Lng: -0.5},
+		},
+		Drivers: []models.Driver{
+			{ID: 1, Name: "Corner Driver", Lat: 1, Lng: 1, VehicleCapacity: 3},
+			{ID: 2, Name: "West Driver", Lat: -1, Lng: -1, VehicleCapacity: 3},
+		},
+		Mode: RouteModeDropoff,
+	})
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+
+	for _, route := range result.Routes {
+		if route.Driver.ID != 1 {
+			continue
+		}
+		if len(route.Stops) != 3 {
+			t.Fatalf("corner driver stops = %d, want the three meeting-point riders", len(route.Stops))
+		}
+		for i, stop := range route.Stops {
+			if stop.Participant.MeetingPoint != corner {
+				t.Fatalf("stop %d is %s, want a meeting-point rider", i, stop.Participant.Name)
+			}
+			if i > 0 && stop.DistanceFromPrevMeters != 0 {
+				t.Fatalf("stop %d distance from previous = %.0f, want 0 for a shared stop", i, stop.DistanceFromPrevMeters)
+			}
+		}
+		if got := len(routeContext{}.routeHouseholdBlocks(routeStopParticipants(route.Stops))); got != 1 {
+			t.Fatalf("corner route has %d stop blocks, want 1", got)
+		}
+		return
+	}
+	t.Fatal("corner driver has no route")
+}
+
+func routeStopParticipants(stops []models.RouteStop) []*models.Participant {
+	participants := make([]*models.Participant, len(stops))
+	for i := range stops {
+		participants[i] = stops[i].Participant
+	}
+	return participants
+}
+
 func TestBalancedRouter_PrefersUsingMoreDriversOnlyAfterObjectiveTies(t *testing.T) {
 	router := NewBalancedRouter(newOverrideDistanceAdapter(0))
 
@@ -662,8 +863,8 @@ func TestCoordinateKey(t *testing.T) {
 	}
 
 	for i, tt := range tests {
-		key1 := coordinateKey(tt.lat1, tt.lng1)
-		key2 := coordinateKey(tt.lat2, tt.lng2)
+		key1 := coordinateKey(tt.lat1, tt.lng1, 5)
+		key2 := coordinateKey(tt.lat2, tt.lng2, 5)
 
 		matches := (key1 == key2)
 		if matches != tt.shouldMatch {
@@ -736,6 +937,10 @@ func (a *overrideDistanceAdapter) PrewarmCache(ctx context.Context, points []mod
 	return nil
 }
 
+func (*overrideDistanceAdapter) GetRouteGeometry(context.Context, []models.Coordinates) (string, error) {
+	return "", distance.ErrRouteGeometryUnavailable
+}
+
 func TestRoundRobinInsertion_KeepsPickupHouseholdsIntact(t *testing.T) {
 	driverHome := models.Coordinates{Lat: 10, Lng: 10}
 	activity := models.Coordinates{Lat: 0, Lng: 0}
@@ -836,10 +1041,625 @@ func TestRoundRobinInsertion_SingleParticipantFallbackPreservesExistingHousehold
 }
 
 func hasAdjacentHouseholdPair(stops []*models.Participant) bool {
+	var rc routeContext
 	for i := 0; i < len(stops)-1; i++ {
-		if householdKey(stops[i]) == householdKey(stops[i+1]) {
+		if rc.householdKey(stops[i]) == rc.householdKey(stops[i+1]) {
 			return true
 		}
 	}
 	return false
 }
+
+// legTableDistanceCalculator returns fixed legs keyed by rounded coordinates,
+// falling back to zero-cost legs for pairs not in the table.
+type legTableDistanceCalculator struct {
+	stableDistanceCalculator
+	legs map[string]distance.DistanceResult
+}
+
+func (c legTableDistanceCalculator) GetDistance(_ context.Context, origin, dest models.Coordinates) (*distance.DistanceResult, error) {
+	result := c.legs[distance.PairCacheKey(origin, dest)]
+	return &result, nil
+}
+
+func TestBalancedRouter_MetricSelectsStopOrder(t *testing.T) {
+	institute := models.Coordinates{Lat: 0, Lng: 0}
+	a := models.Participant{ID: 1, Name: "A", Lat: 1, Lng: 0}
+	b := models.Participant{ID: 2, Name: "B", Lat: 0, Lng: 1}
+	driver := models.Driver{ID: 1, Name: "Driver", Lat: 1, Lng: 1, VehicleCapacity: 2}
+	leg := func(origin, dest models.Coordinates) string { return distance.PairCacheKey(origin, dest) }
+	// Visiting A first is shortest; visiting B first is fastest.
+	calc := legTableDistanceCalculator{legs: map[string]distance.DistanceResult{
+		leg(institute, a.GetCoords()):          {DistanceMeters: 1, DurationSecs: 10},
+		leg(a.GetCoords(), b.GetCoords()):      {DistanceMeters: 1, DurationSecs: 10},
+		leg(institute, b.GetCoords()):          {DistanceMeters: 10, DurationSecs: 1},
+		leg(b.GetCoords(), a.GetCoords()):      {DistanceMeters: 10, DurationSecs: 1},
+		leg(a.GetCoords(), driver.GetCoords()): {DistanceMeters: 5, DurationSecs: 5},
+		leg(b.GetCoords(), driver.GetCoords()): {DistanceMeters: 5, DurationSecs: 5},
+		leg(institute, driver.GetCoords()):     {DistanceMeters: 5, DurationSecs: 5},
+	}}
+
+	for _, tt := range []struct {
+		metric    OptimizationMetric
+		wantFirst int64
+	}{
+		{metric: MetricDistance, wantFirst: a.ID},
+		{metric: MetricDuration, wantFirst: b.ID},
+		{metric: "", wantFirst: b.ID},
+	} {
+		t.Run(string(tt.metric), func(t *testing.T) {
+			router := NewBalancedRouter(calc)
+			result, err := router.CalculateRoutes(context.Background(), &RoutingRequest{
+				InstituteCoords: institute,
+				Participants:    []models.Participant{a, b},
+				Drivers:         []models.Driver{driver},
+				Mode:            RouteModeDropoff,
+				Metric:          tt.metric,
+			})
+			if err != nil {
+				t.Fatalf("CalculateRoutes() error = %v", err)
+			}
+			if got := result.Routes[0].Stops[0].Participant.ID; got != tt.wantFirst {
+				t.Fatalf("first stop = %d, want %d", got, tt.wantFirst)
+			}
+		})
+	}
+}
+
+func TestBalancedRouter_MetricSelectsBalancedAssignment(t *testing.T) {
+	institute := models.Coordinates{Lat: 0, Lng: 0}
+	a := models.Participant{ID: 1, Name: "A", Lat: 1, Lng: 0}
+	b := models.Participant{ID: 2, Name: "B", Lat: -1, Lng: 0}
+	first := models.Driver{ID: 1, Name: "First", Lat: 0, Lng: 1, VehicleCapacity: 1}
+	second := models.Driver{ID: 2, Name: "Second", Lat: 0, Lng: -1, VehicleCapacity: 1}
+	leg := func(origin, dest models.Coordinates) string { return distance.PairCacheKey(origin, dest) }
+	// Each rider's drive home is short to one driver and quick to the other,
+	// so the fairest split depends on which cost is balanced.
+	calc := legTableDistanceCalculator{legs: map[string]distance.DistanceResult{
+		leg(institute, a.GetCoords()):          {DistanceMeters: 10, DurationSecs: 10},
+		leg(institute, b.GetCoords()):          {DistanceMeters: 10, DurationSecs: 10},
+		leg(a.GetCoords(), first.GetCoords()):  {DistanceMeters: 1, DurationSecs: 100},
+		leg(a.GetCoords(), second.GetCoords()): {DistanceMeters: 100, DurationSecs: 1},
+		leg(b.GetCoords(), first.GetCoords()):  {DistanceMeters: 100, DurationSecs: 1},
+		leg(b.GetCoords(), second.GetCoords()): {DistanceMeters: 1, DurationSecs: 100},
+	}}
+
+	for _, tt := range []struct {
+		metric     OptimizationMetric
+		wantFirstA bool
+	}{
+		{metric: MetricDistance, wantFirstA: true},
+		{metric: MetricDuration, wantFirstA: false},
+	} {
+		t.Run(string(tt.metric), func(t *testing.T) {
+			router := NewBalancedRouter(calc)
+			result, err := router.CalculateRoutes(context.Background(), &RoutingRequest{
+				InstituteCoords: institute,
+				Participants:    []models.Participant{a, b},
+				Drivers:         []models.Driver{first, second},
+				Mode:            RouteModeDropoff,
+				Metric:          tt.metric,
+			})
+			if err != nil {
+				t.Fatalf("CalculateRoutes() error = %v", err)
+			}
+			wantRider := b.ID
+			if tt.wantFirstA {
+				wantRider = a.ID
+			}
+			for _, route := range result.Routes {
+				if route.Driver.ID == first.ID {
+					if got := route.Stops[0].Participant.ID; got != wantRider {
+						t.Fatalf("first driver carries %d, want %d", got, wantRider)
+					}
+					return
+				}
+			}
+			t.Fatalf("first driver has no route: %+v", result.Routes)
+		})
+	}
+}
+
+func TestRoundRobinInsertion_EquidistantTieChoosesLowestParticipantID(t *testing.T) {
+	for range 5 {
+		router := &BalancedRouter{distanceCalc: stableDistanceCalculator{}}
+		rc := newRouteContext(router.distanceCalc, models.Coordinates{}, RouteModeDropoff)
+		routes := map[int64]*balancedRoute{
+			1: {driver: &models.Driver{ID: 1, Name: "First", VehicleCapacity: 1}},
+			2: {driver: &models.Driver{ID: 2, Name: "Second", VehicleCapacity: 1}},
+		}
+		// Both riders are one unit from the shared start; the higher ID also
+		// has the lower coordinate key and comes first in the input.
+		unassigned, err := router.roundRobinInsertion(context.Background(), rc, routes, []int64{1, 2}, []*models.Participant{
+			{ID: 7, Name: "South", Lat: 0, Lng: -1},
+			{ID: 3, Name: "North", Lat: 0, Lng: 1},
+		})
+		if err != nil {
+			t.Fatalf("roundRobinInsertion() error = %v", err)
+		}
+		if len(unassigned) != 0 {
+			t.Fatalf("unassigned = %d, want 0", len(unassigned))
+		}
+		if len(routes[1].stops) != 1 || routes[1].stops[0].ID != 3 {
+			t.Fatalf("first driver stops = %+v, want the lower-ID participant 3", routes[1].stops)
+		}
+	}
+}
+
+func TestBalancedRouter_MaxParticipantRideForcesSplit(t *testing.T) {
+	newRequest := func(maxRideSecs float64) *RoutingRequest {
+		return &RoutingRequest{
+			InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+			Participants: []models.Participant{
+				{ID: 1, Name: "South", Lat: 2, Lng: 0},
+				{ID: 2, Name: "Middle", Lat: 1, Lng: 1},
+			},
+			Drivers: []models.Driver{
+				{ID: 1, Name: "Near", Lat: 2, Lng: 1, VehicleCapacity: 2},
+				{ID: 2, Name: "Far", Lat: 10, Lng: 0, VehicleCapacity: 2},
+			},
+			Mode:                   RouteModePickup,
+			MaxParticipantRideSecs: maxRideSecs,
+		}
+	}
+	router := NewBalancedRouter(stableDistanceCalculator{})
+
+	uncapped, err := router.CalculateRoutes(context.Background(), newRequest(0))
+	if err != nil {
+		t.Fatalf("CalculateRoutes() without cap error = %v", err)
+	}
+	if len(uncapped.Routes) != 1 {
+		t.Fatalf("routes without cap = %d, want both riders in the near driver's car", len(uncapped.Routes))
+	}
+
+	// Sharing the car makes one rider's trip at least 2828s; alone each rides
+	// at most 2000s.
+	const maxRideSecs = 2500
+	capped, err := router.CalculateRoutes(context.Background(), newRequest(maxRideSecs))
+	if err != nil {
+		t.Fatalf("CalculateRoutes() with cap error = %v", err)
+	}
+	if len(capped.Routes) != 2 {
+		t.Fatalf("routes with cap = %d, want riders split across both drivers", len(capped.Routes))
+	}
+	for _, route := range capped.Routes {
+		for _, stop := range route.Stops {
+			if ride := route.RouteDurationSecs - stop.CumulativeDurationSecs; ride > maxRideSecs {
+				t.Fatalf("%s rides %.0fs with %s, want at most %d", stop.Participant.Name, ride, route.Driver.Name, maxRideSecs)
+			}
+		}
+	}
+
+	_, err = router.CalculateRoutes(context.Background(), newRequest(1000))
+	var routingErr *ErrRoutingFailed
+	if !errors.As(err, &routingErr) {
+		t.Fatalf("CalculateRoutes() with impossible cap error = %v, want ErrRoutingFailed", err)
+	}
+}
+
+func TestBalancedRouter_DeclinesOutlierOverDetourCeiling(t *testing.T) {
+	newRequest := func(decline bool) *RoutingRequest {
+		return &RoutingRequest{
+			InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+			Participants: []models.Participant{
+				{ID: 1, Name: "On The Way", Lat: 5, Lng: 0},
+				{ID: 2, Name: "Nearby", Lat: 6, Lng: 0.5},
+				{ID: 3, Name: "Outlier", Lat: 5, Lng: 8},
+			},
+			Drivers: []models.Driver{
+				{ID: 1, Name: "Driver", Lat: 10, Lng: 0, VehicleCapacity: 3},
+			},
+			Mode:                     RouteModeDropoff,
+			MaxDetourSecs:            2000,
+			DeclineOverDetourCeiling: decline,
+		}
+	}
+	router := NewBalancedRouter(stableDistanceCalculator{})
+
+	_, err := router.CalculateRoutes(context.Background(), newRequest(false))
+	var routingErr *ErrRoutingFailed
+	if !errors.As(err, &routingErr) {
+		t.Fatalf("CalculateRoutes() without decline error = %v, want ErrRoutingFailed", err)
+	}
+
+	result, err := router.CalculateRoutes(context.Background(), newRequest(true))
+	if err != nil {
+		t.Fatalf("CalculateRoutes() with decline error = %v", err)
+	}
+	if len(result.Unassigned) != 1 || result.Unassigned[0].Participant.ID != 3 || result.Unassigned[0].Reason == "" {
+		t.Fatalf("unassigned = %+v, want only the outlier with a reason", result.Unassigned)
+	}
+	if !slices.Equal(result.Summary.UnassignedParticipants, []int64{3}) {
+		t.Fatalf("summary unassigned = %v, want [3]", result.Summary.UnassignedParticipants)
+	}
+	if len(result.Routes) != 1 || len(result.Routes[0].Stops) != 2 {
+		t.Fatalf("routes = %+v, want the two nearby riders placed", result.Routes)
+	}
+	if detour := result.Routes[0].DetourSecs; detour > 2000 {
+		t.Fatalf("detour = %.0f, want within the ceiling", detour)
+	}
+}
+
+func TestBalancedRouter_SoloRiderRouteStaysSingle(t *testing.T) {
+	participants := []models.Participant{
+		{ID: 1, Name: "Solo", Lat: 5, Lng: 0, SoloRide: true},
+		{ID: 2, Name: "Sibling", Lat: 5, Lng: 0},
+		{ID: 3, Name: "Neighbor", Lat: 5, Lng: 1},
+	}
+	drivers := []models.Driver{
+		{ID: 1, Name: "Near", Lat: 5, Lng: 0.5, VehicleCapacity: 4},
+		{ID: 2, Name: "Far", Lat: 10, Lng: 10, VehicleCapacity: 4},
+	}
+	router := NewBalancedRouter(stableDistanceCalculator{})
+
+	result, err := router.CalculateRoutes(context.Background(), &RoutingRequest{
+		InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+		Participants:    participants,
+		Drivers:         drivers,
+		Mode:            RouteModeDropoff,
+	})
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+	if len(result.Routes) != 2 {
+		t.Fatalf("routes = %d, want the solo rider split from everyone else", len(result.Routes))
+	}
+	for _, route := range result.Routes {
+		for _, stop := range route.Stops {
+			if stop.Participant.SoloRide && len(route.Stops) != 1 {
+				t.Fatalf("solo rider shares %s's car with %d others", route.Driver.Name, len(route.Stops)-1)
+			}
+		}
+	}
+
+	_, err = router.CalculateRoutes(context.Background(), &RoutingRequest{
+		InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+		Participants: []models.Participant{
+			{ID: 1, Name: "Solo", Lat: 5, Lng: 0, SoloRide: true},
+			{ID: 2, Name: "Also Solo", Lat: 5, Lng: 1, SoloRide: true},
+		},
+		Drivers: drivers[:1],
+		Mode:    RouteModeDropoff,
+	})
+	var routingErr *ErrRoutingFailed
+	if !errors.As(err, &routingErr) || !strings.Contains(routingErr.Reason, "ride alone") {
+		t.Fatalf("CalculateRoutes() with more solo riders than drivers error = %v, want ride-alone ErrRoutingFailed", err)
+	}
+}
+
+func TestBalancedRouter_FlagsNeighboringFirstStops(t *testing.T) {
+	router := NewBalancedRouter(stableDistanceCalculator{})
+	result, err := router.CalculateRoutes(context.Background(), &RoutingRequest{
+		InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+		Participants: []models.Participant{
+			{ID: 1, Name: "Alice", Lat: 1, Lng: 0},
+			{ID: 2, Name: "Bob", Lat: 1.0004, Lng: 0},
+		},
+		Drivers: []models.Driver{
+			{ID: 1, Name: "First", Lat: 2, Lng: 1, VehicleCapacity: 1},
+			{ID: 2, Name: "Second", Lat: 2, Lng: -1, VehicleCapacity: 1},
+		},
+		Mode:              RouteModeDropoff,
+		CompactDepartures: true,
+	})
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+	if len(result.DepartureCollisions) != 1 {
+		t.Fatalf("departure collisions = %+v, want one", result.DepartureCollisions)
+	}
+	collision := result.DepartureCollisions[0]
+	if collision.Applied || collision.DistanceMeters > departureCollisionMeters || collision.Suggestion == "" {
+		t.Fatalf("collision = %+v, want an unapplied suggestion since neither car has a spare seat", collision)
+	}
+}
+
+func TestCompactDepartures_AppliesSwapWhenEnabled(t *testing.T) {
+	router := &BalancedRouter{distanceCalc: stableDistanceCalculator{}}
+	rc := newRouteContext(router.distanceCalc, models.Coordinates{}, RouteModeDropoff)
+	alice := &models.Participant{ID: 1, Name: "Alice", Lat: 1, Lng: 0}
+	bob := &models.Participant{ID: 2, Name: "Bob", Lat: 1.0004, Lng: 0}
+	carol := &models.Participant{ID: 3, Name: "Carol", Lat: 1, Lng: 0.001}
+	newRoutes := func() map[int64]*balancedRoute {
+		return map[int64]*balancedRoute{
+			1: {driver: &models.Driver{ID: 1, Name: "First", VehicleCapacity: 2}, stops: []*models.Participant{alice, carol}},
+			2: {driver: &models.Driver{ID: 2, Name: "Second", VehicleCapacity: 2}, stops: []*models.Participant{bob}},
+		}
+	}
+
+	routes := newRoutes()
+	collisions, err := router.compactDepartures(context.Background(), rc, routes, []int64{1, 2}, false)
+	if err != nil {
+		t.Fatalf("compactDepartures() error = %v", err)
+	}
+	if len(collisions) != 1 || collisions[0].Applied || routes[2].stops[0] != bob {
+		t.Fatalf("collisions = %+v, want one suggestion left unapplied", collisions)
+	}
+	if want := "First could take Bob right after Alice, and Second could start with Carol"; collisions[0].Suggestion != want {
+		t.Fatalf("suggestion = %q, want %q", collisions[0].Suggestion, want)
+	}
+
+	routes = newRoutes()
+	collisions, err = router.compactDepartures(context.Background(), rc, routes, []int64{1, 2}, true)
+	if err != nil {
+		t.Fatalf("compactDepartures() error = %v", err)
+	}
+	if len(collisions) != 1 || !collisions[0].Applied {
+		t.Fatalf("collisions = %+v, want the swap applied", collisions)
+	}
+	if routes[1].stops[1] != bob || routes[2].stops[0] != carol {
+		t.Fatalf("stops = %v / %v, want Alice,Bob and Carol", routes[1].stops, routes[2].stops)
+	}
+}
+
+func TestBalancedRouter_RouteColorFollowsDriverID(t *testing.T) {
+	drivers := []models.Driver{
+		{ID: 7, Name: "Seven", Lat: 1, Lng: 1, VehicleCapacity: 1},
+		{ID: 8, Name: "Eight", Lat: -1, Lng: -1, VehicleCapacity: 1},
+	}
+	participants := []models.Participant{
+		{ID: 1, Name: "North", Lat: 1, Lng: 0},
+		{ID: 2, Name: "South", Lat: -1, Lng: 0},
+	}
+	colors := func(drivers []models.Driver) map[int64]string {
+		t.Helper()
+		result, err := NewBalancedRouter(stableDistanceCalculator{}).CalculateRoutes(context.Background(), &RoutingRequest{
+			Participants: participants,
+			Drivers:      drivers,
+			Mode:         RouteModeDropoff,
+		})
+		if err != nil {
+			t.Fatalf("CalculateRoutes() error = %v", err)
+		}
+		byDriver := make(map[int64]string, len(result.Routes))
+		for _, route := range result.Routes {
+			byDriver[route.Driver.ID] = route.Color
+		}
+		return byDriver
+	}
+
+	first := colors(drivers)
+	second := colors([]models.Driver{drivers[1], drivers[0]})
+	for _, driver := range drivers {
+		want := models.RouteColor(driver.ID)
+		if first[driver.ID] != want || second[driver.ID] != want {
+			t.Fatalf("driver %d colors = %q, %q; want %q both times", driver.ID, first[driver.ID], second[driver.ID], want)
+		}
+	}
+	if first[7] == first[8] {
+		t.Fatalf("drivers 7 and 8 share color %q", first[7])
+	}
+}
+
+func TestBalancedRouter_MaxRidePickupObjectiveShortensLongestRide(t *testing.T) {
+	institute := models.Coordinates{Lat: 0, Lng: 0}
+	a := models.Participant{ID: 1, Name: "A", Lat: 1, Lng: 0}
+	b := models.Participant{ID: 2, Name: "B", Lat: 0, Lng: 1}
+	driver := models.Driver{ID: 1, Name: "Driver", Lat: 1, Lng: 1, VehicleCapacity: 2}
+	leg := func(origin, dest models.Coordinates) string { return distance.PairCacheKey(origin, dest) }
+	// Picking up A first arrives soonest (21s vs 25s) but leaves A riding 20s;
+	// picking up B first keeps every ride to 10s.
+	calc := legTableDistanceCalculator{legs: map[string]distance.DistanceResult{
+		leg(driver.GetCoords(), a.GetCoords()): {DistanceMeters: 1, DurationSecs: 1},
+		leg(a.GetCoords(), b.GetCoords()):      {DistanceMeters: 10, DurationSecs: 10},
+		leg(b.GetCoords(), institute):          {DistanceMeters: 10, DurationSecs: 10},
+		leg(driver.GetCoords(), b.GetCoords()): {DistanceMeters: 15, DurationSecs: 15},
+		leg(b.GetCoords(), a.GetCoords()):      {DistanceMeters: 5, DurationSecs: 5},
+		leg(a.GetCoords(), institute):          {DistanceMeters: 5, DurationSecs: 5},
+	}}
+
+	for _, tt := range []struct {
+		objective PickupObjective
+		wantFirst int64
+	}{
+		{objective: "", wantFirst: a.ID},
+		{objective: PickupObjectiveArrival, wantFirst: a.ID},
+		{objective: PickupObjectiveMaxRide, wantFirst: b.ID},
+	} {
+		t.Run(string(tt.objective), func(t *testing.T) {
+			result, err := NewBalancedRouter(calc).CalculateRoutes(context.Background(), &RoutingRequest{
+				InstituteCoords: institute,
+				Participants:    []models.Participant{a, b},
+				Drivers:         []models.Driver{driver},
+				Mode:            RouteModePickup,
+				PickupObjective: tt.objective,
+			})
+			if err != nil {
+				t.Fatalf("CalculateRoutes() error = %v", err)
+			}
+			if got := result.Routes[0].Stops[0].Participant.ID; got != tt.wantFirst {
+				t.Fatalf("first pickup = %d, want %d", got, tt.wantFirst)
+			}
+		})
+	}
+}
+
+func TestBalancedRouter_RatioFairnessWeighsDetourAgainstDirectTrip(t *testing.T) {
+	router := NewBalancedRouter(stableDistanceCalculator{})
+	// The rider costs the short-commute driver 500 on a 1000 trip, and the
+	// long-commute driver about 1320 on a 10000 trip.
+	driverFor := func(fairness DetourFairness) int64 {
+		t.Helper()
+		result, err := router.CalculateRoutes(context.Background(), &RoutingRequest{
+			InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+			Participants:    []models.Participant{{ID: 1, Name: "Rider", Lat: 0.5, Lng: 0.559}},
+			Drivers: []models.Driver{
+				{ID: 1, Name: "Short Commute", Lat: 1, Lng: 0, VehicleCapacity: 2},
+				{ID: 2, Name: "Long Commute", Lat: 0, Lng: -10, VehicleCapacity: 2},
+			},
+			Mode:           RouteModeDropoff,
+			DetourFairness: fairness,
+		})
+		if err != nil {
+			t.Fatalf("CalculateRoutes(%q) error = %v", fairness, err)
+		}
+		if len(result.Routes) != 1 {
+			t.Fatalf("CalculateRoutes(%q) routes = %+v, want one", fairness, result.Routes)
+		}
+		return result.Routes[0].Driver.ID
+	}
+
+	if got := driverFor(DetourFairnessAbsolute); got != 1 {
+		t.Fatalf("absolute fairness driver = %d, want the smaller detour of Short Commute", got)
+	}
+	if got := driverFor(DetourFairnessRatio); got != 2 {
+		t.Fatalf("ratio fairness driver = %d, want the smaller share of Long Commute's trip", got)
+	}
+}
+
+func TestDetourRatio_FloorsNearZeroDirectTrips(t *testing.T) {
+	rc := newRouteContext(stableDistanceCalculator{}, models.Coordinates{}, RouteModeDropoff)
+	if got := rc.detourRatio(&routeMetrics{BaselineDurationSecs: 0}, 600); got != 2 {
+		t.Fatalf("detourRatio at a zero baseline = %v, want 600 over the 300 second floor", got)
+	}
+	rc.metric = MetricDistance
+	if got := rc.detourRatio(&routeMetrics{BaselineDistanceMeters: 10000}, 2500); got != 0.25 {
+		t.Fatalf("detourRatio by distance = %v, want 0.25", got)
+	}
+}
+
+func TestBalancedRouter_ExcludeHomeLegChangesPickupAssignment(t *testing.T) {
+	router := NewBalancedRouter(stableDistanceCalculator{})
+	// Near lives at the east rider's door; Far lives well past them. Counting
+	// the drive from home, Near collecting both riders arrives first. Without
+	// it, each driver collecting one rider arrives sooner.
+	routesFor := func(excludeHomeLeg bool) map[int64][]int64 {
+		t.Helper()
+		result, err := router.CalculateRoutes(context.Background(), &RoutingRequest{
+			InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+			Participants: []models.Participant{
+				{ID: 1, Name: "East", Lat: 1, Lng: 0},
+				{ID: 2, Name: "West", Lat: -1, Lng: 0},
+			},
+			Drivers: []models.Driver{
+				{ID: 1, Name: "Near", Lat: 1, Lng: 0, VehicleCapacity: 2},
+				{ID: 2, Name: "Far", Lat: 10, Lng: 0, VehicleCapacity: 2},
+			},
+			Mode:           RouteModePickup,
+			ExcludeHomeLeg: excludeHomeLeg,
+		})
+		if err != nil {
+			t.Fatalf("CalculateRoutes(excludeHomeLeg=%t) error = %v", excludeHomeLeg, err)
+		}
+		assigned := make(map[int64][]int64)
+		for _, route := range result.Routes {
+			for _, stop := range route.Stops {
+				assigned[route.Driver.ID] = append(assigned[route.Driver.ID], stop.Participant.ID)
+			}
+		}
+		return assigned
+	}
+
+	if got := routesFor(false); len(got) != 1 || len(got[1]) != 2 {
+		t.Fatalf("with the home leg, routes = %v, want Near collecting both riders", got)
+	}
+	got := routesFor(true)
+	if len(got) != 2 || len(got[1]) != 1 || len(got[2]) != 1 {
+		t.Fatalf("without the home leg, routes = %v, want one rider per driver", got)
+	}
+}
+
+func TestBalancedRouter_FairnessWeightsTradeWorstDetourForTotal(t *testing.T) {
+	router := NewBalancedRouter(stableDistanceCalculator{})
+	// Either pairing keeps each rider alone in a car. Pairing Low with Far
+	// and High with Near keeps the worst detour near 3500; the other pairing
+	// saves about 500 of total detour but leaves High with about 5000.
+	driverFor := func(weights FairnessWeights) map[int64]int64 {
+		t.Helper()
+		result, err := router.CalculateRoutes(context.Background(), &RoutingRequest{
+			InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+			Participants: []models.Participant{
+				{ID: 1, Name: "Far", Lat: 2, Lng: 2},
+				{ID: 2, Name: "Near", Lat: 0.5, Lng: 1.5},
+			},
+			Drivers: []models.Driver{
+				{ID: 1, Name: "Low", Lat: 0.5, Lng: 1, VehicleCapacity: 2},
+				{ID: 2, Name: "High", Lat: 0.5, Lng: -2, VehicleCapacity: 2},
+			},
+			Mode:            RouteModeDropoff,
+			FairnessWeights: weights,
+		})
+		if err != nil {
+			t.Fatalf("CalculateRoutes(%+v) error = %v", weights, err)
+		}
+		drivers := make(map[int64]int64)
+		for _, route := range result.Routes {
+			for _, stop := range route.Stops {
+				drivers[stop.Participant.ID] = route.Driver.ID
+			}
+		}
+		return drivers
+	}
+
+	if got := driverFor(FairnessWeights{}); got[1] != 1 || got[2] != 2 {
+		t.Fatalf("default drivers = %v, want Low taking Far for the smaller worst detour", got)
+	}
+	if got := driverFor(FairnessWeights{SumDetourWeight: 1}); got[1] != 2 || got[2] != 1 {
+		t.Fatalf("sum-weighted drivers = %v, want Low taking Near for the smaller total detour", got)
+	}
+	if got := driverFor(FairnessWeights{MaxDetourWeight: 1, SumDetourWeight: 0.1}); got[1] != 1 || got[2] != 2 {
+		t.Fatalf("max-weighted drivers = %v, want the smaller worst detour again", got)
+	}
+}
+
+// geometryDistanceCalculator draws each path as the list of points it passes
+// through.
+type geometryDistanceCalculator struct {
+	stableDistanceCalculator
+	calls int
+}
+
+func (c *geometryDistanceCalculator) GetRouteGeometry(_ context.Context, points []models.Coordinates) (string, error) {
+	c.calls++
+	parts := make([]string, len(points))
+	for i, point := range points {
+		parts[i] = fmt.Sprintf("%g,%g", point.Lat, point.Lng)
+	}
+	return strings.Join(parts, ";"), nil
+}
+
+func TestBalancedRouter_IncludeGeometry(t *testing.T) {
+	newRequest := func(includeGeometry bool) *RoutingRequest {
+		return &RoutingRequest{
+			InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+			Participants: []models.Participant{
+				{ID: 1, Name: "Near", Lat: 0, Lng: 1},
+				{ID: 2, Name: "Far", Lat: 0, Lng: 2},
+			},
+			Drivers:         []models.Driver{{ID: 1, Name: "Driver", Lat: 0, Lng: 3, VehicleCapacity: 2}},
+			Mode:            RouteModeDropoff,
+			IncludeGeometry: includeGeometry,
+		}
+	}
+
+	t.Run("on", func(t *testing.T) {
+		calc := &geometryDistanceCalculator{}
+		result, err := NewBalancedRouter(calc).CalculateRoutes(context.Background(), newRequest(true))
+		if err != nil {
+			t.Fatalf("CalculateRoutes: %v", err)
+		}
+		stops := result.Routes[0].Stops
+		if got, want := stops[0].GeometryPolyline, "0,0;0,1"; got != want {
+			t.Fatalf("first stop geometry = %q, want %q", got, want)
+		}
+		if got, want := stops[1].GeometryPolyline, "0,1;0,2"; got != want {
+			t.Fatalf("second stop geometry = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("off", func(t *testing.T) {
+		calc := &geometryDistanceCalculator{}
+		result, err := NewBalancedRouter(calc).CalculateRoutes(context.Background(), newRequest(false))
+		if err != nil {
+			t.Fatalf("CalculateRoutes: %v", err)
+		}
+		if calc.calls != 0 {
+			t.Fatalf("geometry requested %d times without IncludeGeometry", calc.calls)
+		}
+		for _, stop := range result.Routes[0].Stops {
+			if stop.GeometryPolyline != "" {
+				t.Fatalf("stop %s has geometry %q without IncludeGeometry", stop.Participant.Name, stop.GeometryPolyline)
+			}
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package routing
+
+import (
+	"log"
+	"ride-home-router/internal/models"
+)
+
+// DefaultMaxWalkMeters is how far, in a straight line from home, a
+// participant is assigned to walk to a bus stop when the request sets no limit.
+const DefaultMaxWalkMeters = 800.0
+
+// assignBusStops returns a copy of the request whose participants meet at
+// their nearest bus stop, so riders sharing a stop form one household group
+// and vehicles are routed over the stops. Participants with a meeting point
+// of their own keep it; those with no stop within walking distance of home
+// stay door to door and are reported in the second return value. The
+// assignments list every stop, in request order, including stops nobody
+// walks to.
+func assignBusStops(req *RoutingRequest) (*RoutingRequest, []models.BusStopAssignment, []int64) {
+	maxWalk := req.MaxWalkMeters
+	if maxWalk <= 0 {
+		maxWalk = DefaultMaxWalkMeters
+	}
+
+	assignments := make([]models.BusStopAssignment, len(req.BusStops))
+	for i, stop := range req.BusStops {
+		assignments[i] = models.BusStopAssignment{Stop: stop, ParticipantIDs: []int64{}}
+	}
+	participants := make([]models.Participant, len(req.Participants))
+	var doorToDoor []int64
+	for i, participant := range req.Participants {
+		participants[i] = participant
+		if participant.MeetingPoint != nil {
+			continue
+		}
+		home := models.Coordinates{Lat: participant.Lat, Lng: participant.Lng}
+		nearest, nearestMeters := -1, 0.0
+		for j, stop := range req.BusStops {
+			if meters := greatCircleMeters(home, stop); meters <= maxWalk && (nearest < 0 || meters < nearestMeters) {
+				nearest, nearestMeters = j, meters
+			}
+		}
+		if nearest < 0 {
+			doorToDoor = append(doorToDoor, participant.ID)
+			continue
+		}
+		stop := req.BusStops[nearest]
+		participants[i].MeetingPoint = &stop
+		assignments[nearest].ParticipantIDs = append(assignments[nearest].ParticipantIDs, participant.ID)
+		assignments[nearest].WalkMeters = max(assignments[nearest].WalkMeters, nearestMeters)
+	}
+	if len(doorToDoor) > 0 {
+		log.Printf("[BALANCED] %d participants have no bus stop within %.0fm and are routed door to door", len(doorToDoor), maxWalk)
+	}
+
+	stopped := *req
+	stopped.Participants = participants
+	return &stopped, assignments, doorToDoor
+}
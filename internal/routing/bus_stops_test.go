@@ -0,0 +1,64 @@
+package routing
+
+import (
+	"context"
+	"ride-home-router/internal/models"
+	"slices"
+	"testing"
+)
+
+func TestBalancedRouter_RoutesOverAssignedBusStops(t *testing.T) {
+	east := models.Coordinates{Lat: 0, Lng: 1}
+	west := models.Coordinates{Lat: 0, Lng: -1}
+	router := NewBalancedRouter(stableDistanceCalculator{})
+	result, err := router.CalculateRoutes(context.Background(), &RoutingRequest{
+		InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+		Participants: []models.Participant{
+			{ID: 1, Name: "East A", Lat: 0.002, Lng: 1},
+			{ID: 2, Name: "East B", Lat: -0.003, Lng: 1.001},
+			{ID: 3, Name: "West A", Lat: 0.001, Lng: -1},
+			{ID: 4, Name: "West B", Lat: 0, Lng: -1.002},
+			{ID: 5, Name: "Far Out", Lat: 0.5, Lng: 0},
+		},
+		Drivers: []models.Driver{
+			{ID: 1, Name: "East Driver", Lat: 0, Lng: 2, VehicleCapacity: 3},
+			{ID: 2, Name: "West Driver", Lat: 0, Lng: -2, VehicleCapacity: 3},
+		},
+		Mode:          RouteModeDropoff,
+		BusStops:      []models.Coordinates{east, west},
+		MaxWalkMeters: 500,
+	})
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+
+	if len(result.BusStops) != 2 || !slices.Equal(result.BusStops[0].ParticipantIDs, []int64{1, 2}) || !slices.Equal(result.BusStops[1].ParticipantIDs, []int64{3, 4}) {
+		t.Fatalf("bus stops = %+v, want the east pair at the first stop and the west pair at the second", result.BusStops)
+	}
+	if walk := result.BusStops[1].WalkMeters; walk < 200 || walk > 250 {
+		t.Fatalf("west walk = %v, want the longest walk of about 222 m", walk)
+	}
+	if !slices.Equal(result.DoorToDoor, []int64{5}) {
+		t.Fatalf("door to door = %v, want only the participant beyond walking distance", result.DoorToDoor)
+	}
+
+	for _, route := range result.Routes {
+		for _, stop := range route.Stops {
+			coords := stop.Participant.GetCoords()
+			switch stop.Participant.ID {
+			case 1, 2:
+				if coords != east || route.Driver.ID != 1 {
+					t.Fatalf("%s rides with driver %d from %+v, want the east driver at the east stop", stop.Participant.Name, route.Driver.ID, coords)
+				}
+			case 3, 4:
+				if coords != west || route.Driver.ID != 2 {
+					t.Fatalf("%s rides with driver %d from %+v, want the west driver at the west stop", stop.Participant.Name, route.Driver.ID, coords)
+				}
+			case 5:
+				if stop.Participant.MeetingPoint != nil {
+					t.Fatalf("far participant meets at %+v, want home", *stop.Participant.MeetingPoint)
+				}
+			}
+		}
+	}
+}
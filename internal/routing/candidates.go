@@ -0,0 +1,136 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"ride-home-router/internal/models"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CandidateRanking selects the objective used to order alternative solutions.
+type CandidateRanking string
+
+const (
+	// RankByDistance orders candidates by total driven distance.
+	RankByDistance CandidateRanking = "distance"
+	// RankByFairness orders candidates by the largest single driver detour.
+	RankByFairness CandidateRanking = "fairness"
+)
+
+var ErrInvalidCandidateRanking = errors.New("invalid candidate ranking")
+
+// ParseCandidateRanking normalizes a ranking value, defaulting blank input to distance.
+func ParseCandidateRanking(value string) (CandidateRanking, error) {
+	switch strings.TrimSpace(value) {
+	case "", string(RankByDistance):
+		return RankByDistance, nil
+	case string(RankByFairness):
+		return RankByFairness, nil
+	default:
+		return "", ErrInvalidCandidateRanking
+	}
+}
+
+// CalculateCandidates asks router for up to k ranked solutions. A router that
+// is not a CandidateRouter yields its single solution.
+func CalculateCandidates(ctx context.Context, router Router, req *RoutingRequest, k int, ranking CandidateRanking) ([]*models.RoutingResult, error) {
+	if candidates, ok := router.(CandidateRouter); ok {
+		return candidates.CalculateCandidates(ctx, req, k, ranking)
+	}
+	result, err := router.CalculateRoutes(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return []*models.RoutingResult{result}, nil
+}
+
+// CalculateCandidates returns up to k distinct solutions ranked by the given
+// objective. Candidates come from restarting the seed at each driver, both
+// with and without the assignment search, and each goes through the same bus
+// stop, detour ceiling and warning handling as CalculateRoutes. Identical
+// assignments are reported once. Preferring nearby drivers fixes the seed
+// order, so that request restarts only at the nearest driver.
+func (r *BalancedRouter) CalculateCandidates(ctx context.Context, req *RoutingRequest, k int, ranking CandidateRanking) ([]*models.RoutingResult, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("candidate count must be at least 1")
+	}
+	starts := max(len(req.Drivers), 1)
+	if req.PreferNearbyDrivers {
+		starts = 1
+	}
+	options := make([]solveOptions, 0, 2*starts)
+	for _, skipAssignmentSearch := range []bool{false, true} {
+		for start := range starts {
+			options = append(options, solveOptions{startDriverIndex: start, skipAssignmentSearch: skipAssignmentSearch})
+		}
+	}
+
+	totalStart := time.Now()
+	results, err := r.calculateWith(ctx, req, options)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{})
+	candidates := make([]*models.RoutingResult, 0, k)
+	for _, result := range results {
+		key := assignmentKey(result)
+		if _, duplicate := seen[key]; duplicate {
+			continue
+		}
+		seen[key] = struct{}{}
+		candidates = append(candidates, result)
+	}
+
+	sortCandidates(candidates, ranking)
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+	log.Printf("[BALANCED] Generated %d distinct candidates (ranking=%s) in %v", len(candidates), ranking, time.Since(totalStart))
+	return candidates, nil
+}
+
+func sortCandidates(candidates []*models.RoutingResult, ranking CandidateRanking) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if ranking == RankByFairness {
+			detourA, detourB := maxRouteDetour(a), maxRouteDetour(b)
+			if detourA != detourB {
+				return detourA < detourB
+			}
+		}
+		return a.Summary.TotalDistanceMeters < b.Summary.TotalDistanceMeters
+	})
+}
+
+func maxRouteDetour(result *models.RoutingResult) float64 {
+	detour := 0.0
+	for _, route := range result.Routes {
+		detour = max(detour, route.DetourSecs)
+	}
+	return detour
+}
+
+// assignmentKey identifies which participants ride with which driver,
+// ignoring stop order, so equivalent candidates can be deduplicated.
+func assignmentKey(result *models.RoutingResult) string {
+	parts := make([]string, 0, len(result.Routes))
+	for _, route := range result.Routes {
+		if route.Driver == nil || len(route.Stops) == 0 {
+			continue
+		}
+		ids := make([]int64, 0, len(route.Stops))
+		for _, stop := range route.Stops {
+			ids = append(ids, stop.Participant.ID)
+		}
+		slices.Sort(ids)
+		parts = append(parts, fmt.Sprintf("%d:%v", route.Driver.ID, ids))
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, ";")
+}
@@ -0,0 +1,102 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"ride-home-router/internal/models"
+	"testing"
+)
+
+func TestCalculateCandidatesReturnsDistinctValidSolutions(t *testing.T) {
+	router := &BalancedRouter{distanceCalc: stableDistanceCalculator{}}
+	participants := []models.Participant{
+		{ID: 1, Name: "A", Lat: 1, Lng: 0},
+		{ID: 2, Name: "B", Lat: 0, Lng: 1},
+		{ID: 3, Name: "C", Lat: -1, Lng: 0},
+		{ID: 4, Name: "D", Lat: 0, Lng: -1},
+	}
+	drivers := []models.Driver{
+		{ID: 1, Name: "North", Lat: 2, Lng: 0, VehicleCapacity: 2},
+		{ID: 2, Name: "East", Lat: 0, Lng: 2, VehicleCapacity: 2},
+		{ID: 3, Name: "South", Lat: -2, Lng: 0, VehicleCapacity: 2},
+	}
+
+	candidates, err := router.CalculateCandidates(context.Background(), &RoutingRequest{
+		Participants: participants,
+		Drivers:      drivers,
+		Mode:         RouteModeDropoff,
+	}, 3, RankByDistance)
+	if err != nil {
+		t.Fatalf("CalculateCandidates() error = %v", err)
+	}
+	if len(candidates) != 3 {
+		t.Fatalf("got %d candidates, want 3", len(candidates))
+	}
+
+	seen := make(map[string]bool)
+	for i, candidate := range candidates {
+		key := assignmentKey(candidate)
+		if seen[key] {
+			t.Fatalf("candidate %d duplicates assignment %s", i, key)
+		}
+		seen[key] = true
+
+		assigned := make(map[int64]int)
+		for _, route := range candidate.Routes {
+			if len(route.Stops) > route.Driver.VehicleCapacity {
+				t.Fatalf("candidate %d overloads driver %d", i, route.Driver.ID)
+			}
+			for _, stop := range route.Stops {
+				assigned[stop.Participant.ID]++
+			}
+		}
+		for _, participant := range participants {
+			if assigned[participant.ID] != 1 {
+				t.Fatalf("candidate %d assigns participant %d %d times", i, participant.ID, assigned[participant.ID])
+			}
+		}
+		if i > 0 && candidate.Summary.TotalDistanceMeters < candidates[i-1].Summary.TotalDistanceMeters {
+			t.Fatalf("candidates are not ranked by distance: %.0f before %.0f",
+				candidates[i-1].Summary.TotalDistanceMeters, candidate.Summary.TotalDistanceMeters)
+		}
+	}
+}
+
+func TestCalculateCandidatesAppliesTheDetourCeiling(t *testing.T) {
+	newRequest := func(decline bool) *RoutingRequest {
+		return &RoutingRequest{
+			InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+			Participants: []models.Participant{
+				{ID: 1, Name: "On The Way", Lat: 5, Lng: 0},
+				{ID: 2, Name: "Nearby", Lat: 6, Lng: 0.5},
+				{ID: 3, Name: "Outlier", Lat: 5, Lng: 8},
+			},
+			Drivers: []models.Driver{
+				{ID: 1, Name: "Driver", Lat: 10, Lng: 0, VehicleCapacity: 3},
+			},
+			Mode:                     RouteModeDropoff,
+			MaxDetourSecs:            2000,
+			DeclineOverDetourCeiling: decline,
+		}
+	}
+	router := &BalancedRouter{distanceCalc: stableDistanceCalculator{}}
+
+	_, err := router.CalculateCandidates(context.Background(), newRequest(false), 3, RankByDistance)
+	var routingErr *ErrRoutingFailed
+	if !errors.As(err, &routingErr) {
+		t.Fatalf("CalculateCandidates() without decline error = %v, want ErrRoutingFailed", err)
+	}
+
+	candidates, err := router.CalculateCandidates(context.Background(), newRequest(true), 3, RankByDistance)
+	if err != nil {
+		t.Fatalf("CalculateCandidates() with decline error = %v", err)
+	}
+	for i, candidate := range candidates {
+		if len(candidate.Unassigned) != 1 || candidate.Unassigned[0].Participant.ID != 3 {
+			t.Fatalf("candidate %d unassigned = %+v, want only the outlier", i, candidate.Unassigned)
+		}
+		if detour := candidate.Routes[0].DetourSecs; detour > 2000 {
+			t.Fatalf("candidate %d detour = %.0f, want within the ceiling", i, detour)
+		}
+	}
+}
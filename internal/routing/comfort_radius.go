@@ -0,0 +1,50 @@
+package routing
+
+import (
+	"math"
+	"ride-home-router/internal/models"
+)
+
+// comfortPenaltyMetersPerSecond converts off-path meters into seconds when
+// optimizing duration, assuming typical suburban driving speed.
+const comfortPenaltyMetersPerSecond = 50 / 3.6
+
+// offPathMeters is how far point lies from the straight segment between start
+// and end: its cross-track distance when it falls alongside the segment, or
+// its distance to the nearer endpoint when it falls beyond either end.
+func offPathMeters(start, end, point models.Coordinates) float64 {
+	toPoint := greatCircleMeters(start, point)
+	length := greatCircleMeters(start, end)
+	if length == 0 || toPoint == 0 {
+		return toPoint
+	}
+	angle := (initialBearing(start, point) - initialBearing(start, end)) * math.Pi / 180
+	if math.Cos(angle) < 0 {
+		return toPoint
+	}
+	crossTrack := math.Asin(math.Sin(toPoint/earthRadiusMeters)*math.Sin(angle)) * earthRadiusMeters
+	alongTrack := math.Acos(math.Min(1, math.Cos(toPoint/earthRadiusMeters)/math.Cos(crossTrack/earthRadiusMeters))) * earthRadiusMeters
+	if alongTrack > length {
+		return greatCircleMeters(end, point)
+	}
+	return math.Abs(crossTrack)
+}
+
+// comfortPenalty discourages giving a driver stops beyond their comfort
+// radius from the straight line between the activity and their home. Each
+// meter past the radius costs the out-and-back drive to cover it, in the
+// context's optimization metric. Drivers without a radius are never
+// penalized.
+func (rc routeContext) comfortPenalty(driver *models.Driver, stops []*models.Participant) float64 {
+	if driver == nil || driver.ComfortRadiusMeters <= 0 {
+		return 0
+	}
+	excess := 0.0
+	for _, stop := range stops {
+		excess += max(0, offPathMeters(rc.instituteCoords, driver.GetCoords(), stop.GetCoords())-driver.ComfortRadiusMeters)
+	}
+	if rc.metric == MetricDistance {
+		return 2 * excess
+	}
+	return 2 * excess / comfortPenaltyMetersPerSecond
+}
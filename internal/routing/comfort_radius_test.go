@@ -0,0 +1,71 @@
+package routing
+
+import (
+	"context"
+	"math"
+	"ride-home-router/internal/models"
+	"testing"
+)
+
+func TestBalancedRouter_ComfortRadiusSteersOutOfWayRider(t *testing.T) {
+	router := NewBalancedRouter(stableDistanceCalculator{})
+	// East lives due east of the activity and North due north. The rider sits
+	// a little north of East's path, so East's detour is the smaller one, but
+	// the rider is still about 2 km off that path.
+	newRequest := func(eastRadius float64) *RoutingRequest {
+		return &RoutingRequest{
+			InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+			Participants: []models.Participant{
+				{ID: 1, Name: "Rider", Lat: 0.02, Lng: 0.06},
+			},
+			Drivers: []models.Driver{
+				{ID: 1, Name: "East", Lat: 0, Lng: 0.1, VehicleCapacity: 2, ComfortRadiusMeters: eastRadius},
+				{ID: 2, Name: "North", Lat: 0.1, Lng: 0, VehicleCapacity: 2},
+			},
+			Mode: RouteModeDropoff,
+		}
+	}
+
+	for _, tc := range []struct {
+		name       string
+		eastRadius float64
+		wantDriver string
+	}{
+		{name: "no radius", eastRadius: 0, wantDriver: "East"},
+		{name: "generous radius", eastRadius: 5000, wantDriver: "East"},
+		{name: "tight radius", eastRadius: 500, wantDriver: "North"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := router.CalculateRoutes(context.Background(), newRequest(tc.eastRadius))
+			if err != nil {
+				t.Fatalf("CalculateRoutes() error = %v", err)
+			}
+			if len(result.Routes) != 1 {
+				t.Fatalf("routes = %+v, want one used route", result.Routes)
+			}
+			if got := result.Routes[0].Driver.Name; got != tc.wantDriver {
+				t.Fatalf("rider assigned to %s, want %s", got, tc.wantDriver)
+			}
+		})
+	}
+}
+
+func TestOffPathMeters(t *testing.T) {
+	start := models.Coordinates{Lat: 0, Lng: 0}
+	end := models.Coordinates{Lat: 0, Lng: 0.1}
+	for _, tc := range []struct {
+		name  string
+		point models.Coordinates
+		want  float64
+	}{
+		{name: "alongside", point: models.Coordinates{Lat: 0.01, Lng: 0.05}, want: greatCircleMeters(models.Coordinates{Lng: 0.05}, models.Coordinates{Lat: 0.01, Lng: 0.05})},
+		{name: "before start", point: models.Coordinates{Lat: 0, Lng: -0.02}, want: greatCircleMeters(start, models.Coordinates{Lng: -0.02})},
+		{name: "past end", point: models.Coordinates{Lat: 0, Lng: 0.13}, want: greatCircleMeters(end, models.Coordinates{Lng: 0.13})},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := offPathMeters(start, end, tc.point); math.Abs(got-tc.want) > 1 {
+				t.Fatalf("offPathMeters() = %.1f, want %.1f", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"ride-home-router/internal/models"
+	"strings"
+)
+
+const (
+	// departureCollisionMeters is how close two first stops must be, in a
+	// straight line, to count as the same departure.
+	departureCollisionMeters = 150.0
+	// departureCollisionBearingDegrees is how closely their bearings from the
+	// activity location must agree.
+	departureCollisionBearingDegrees = 20.0
+	// compactDepartureSlack is how much the latest drop-off may grow when a
+	// compacting swap is applied.
+	compactDepartureSlack = 0.05
+	earthRadiusMeters     = 6371000.0
+)
+
+// compactDepartures flags dropoff routes whose first stops are neighbors, so
+// two cars leave the activity for the same street. For each collision it
+// suggests handing the second driver's first stop to the first driver, right
+// after their own first stop, in exchange for the first driver's next stop.
+// When apply is set the swap is made if it keeps capacity, solo riders, the
+// ride cap and time windows intact and costs at most compactDepartureSlack of
+// the latest drop-off.
+func (r *BalancedRouter) compactDepartures(ctx context.Context, rc routeContext, routes map[int64]*balancedRoute, driverIDs []int64, apply bool) ([]models.DepartureCollision, error) {
+	if rc.mode != RouteModeDropoff {
+		return nil, nil
+	}
+
+	var collisions []models.DepartureCollision
+	for i, firstID := range driverIDs {
+		for _, secondID := range driverIDs[i+1:] {
+			first, second := routes[firstID], routes[secondID]
+			if len(first.stops) == 0 || len(second.stops) == 0 {
+				continue
+			}
+			separation, ok := rc.departureCollision(first.stops[0].GetCoords(), second.stops[0].GetCoords())
+			if !ok {
+				continue
+			}
+
+			collision := models.DepartureCollision{
+				FirstDriverID:       firstID,
+				SecondDriverID:      secondID,
+				FirstParticipantID:  first.stops[0].ID,
+				SecondParticipantID: second.stops[0].ID,
+				DistanceMeters:      separation,
+			}
+			firstStops, secondStops, suggestion := rc.compactDepartureSwap(first, second)
+			collision.Suggestion = suggestion
+
+			if apply && firstStops != nil {
+				applied, err := r.applyDepartureSwap(ctx, rc, routes, driverIDs, firstID, secondID, firstStops, secondStops)
+				if err != nil {
+					return nil, err
+				}
+				collision.Applied = applied
+			}
+			if collision.Applied {
+				log.Printf("[BALANCED] Compacted departures: %s", suggestion)
+			}
+			collisions = append(collisions, collision)
+		}
+	}
+	return collisions, nil
+}
+
+// departureCollision reports the separation of two first stops and whether
+// they are close enough, in distance and heading, to leave together.
+func (rc routeContext) departureCollision(a, b models.Coordinates) (float64, bool) {
+	separation := greatCircleMeters(a, b)
+	if separation > departureCollisionMeters {
+		return separation, false
+	}
+	bearingGap := math.Abs(initialBearing(rc.instituteCoords, a) - initialBearing(rc.instituteCoords, b))
+	bearingGap = math.Min(bearingGap, 360-bearingGap)
+	return separation, bearingGap <= departureCollisionBearingDegrees
+}
+
+// compactDepartureSwap builds the stops after moving the second route's first
+// household to follow the first route's first household, with the first
+// route's next household, if any, taking its place. It returns nil stops when
+// no swap is possible.
+func (rc routeContext) compactDepartureSwap(first, second *balancedRoute) ([]*models.Participant, []*models.Participant, string) {
+	firstBlocks := rc.routeHouseholdBlocks(first.stops)
+	secondBlocks := rc.routeHouseholdBlocks(second.stops)
+	moved := secondBlocks[0]
+
+	var returned *participantGroup
+	firstStops := append([]*models.Participant{}, firstBlocks[0].members...)
+	firstStops = append(firstStops, moved.members...)
+	if len(firstBlocks) > 1 {
+		returned = firstBlocks[1]
+		firstStops = append(firstStops, flattenParticipantGroups(firstBlocks[2:])...)
+	}
+	secondStops := []*models.Participant{}
+	if returned != nil {
+		secondStops = append(secondStops, returned.members...)
+	}
+	secondStops = append(secondStops, flattenParticipantGroups(secondBlocks[1:])...)
+
+	suggestion := fmt.Sprintf("%s could take %s right after %s", first.driver.Name, participantNames(moved.members), participantNames(firstBlocks[0].members))
+	if returned != nil {
+		suggestion += fmt.Sprintf(", and %s could start with %s", second.driver.Name, participantNames(returned.members))
+	}
+
+	if len(firstStops) > first.driver.VehicleCapacity || len(secondStops) > second.driver.VehicleCapacity ||
+		!soloRidersAlone(firstStops) || !soloRidersAlone(secondStops) {
+		return nil, nil, suggestion
+	}
+	return firstStops, secondStops, suggestion
+}
+
+// applyDepartureSwap installs the swapped stops when they respect the ride cap
+// and time windows and stay within compactDepartureSlack of the current latest
+// drop-off.
+func (r *BalancedRouter) applyDepartureSwap(ctx context.Context, rc routeContext, routes map[int64]*balancedRoute, driverIDs []int64, firstID, secondID int64, firstStops, secondStops []*models.Participant) (bool, error) {
+	for driverID, stops := range map[int64][]*models.Participant{firstID: firstStops, secondID: secondStops} {
+		ok, err := rc.withinTimeLimits(ctx, routes[driverID].driver, stops)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	current := make(map[int64]routeObjectiveMetrics, len(routes))
+	for _, driverID := range driverIDs {
+		metrics, err := rc.evaluateRouteObjective(ctx, routes[driverID].driver, routes[driverID].stops)
+		if err != nil {
+			return false, err
+		}
+		current[driverID] = metrics
+	}
+	candidate := make(map[int64]routeObjectiveMetrics, len(current))
+	for driverID, metrics := range current {
+		candidate[driverID] = metrics
+	}
+	for driverID, stops := range map[int64][]*models.Participant{firstID: firstStops, secondID: secondStops} {
+		metrics, err := rc.evaluateRouteObjective(ctx, routes[driverID].driver, stops)
+		if err != nil {
+			return false, err
+		}
+		candidate[driverID] = metrics
+	}
+
+	before := rc.scoreSolution(current, driverIDs).latestParticipantCompletion
+	after := rc.scoreSolution(candidate, driverIDs).latestParticipantCompletion
+	if after > before*(1+compactDepartureSlack)+scoreImprovementEpsilon {
+		return false, nil
+	}
+
+	routes[firstID].stops = firstStops
+	routes[secondID].stops = secondStops
+	return true, nil
+}
+
+func participantNames(participants []*models.Participant) string {
+	names := make([]string, len(participants))
+	for i, participant := range participants {
+		names[i] = participant.Name
+	}
+	return strings.Join(names, " & ")
+}
+
+// greatCircleMeters is the haversine distance between two points.
+func greatCircleMeters(a, b models.Coordinates) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// initialBearing is the compass heading, in degrees, from a toward b.
+func initialBearing(a, b models.Coordinates) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+	y := math.Sin(dLng) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLng)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
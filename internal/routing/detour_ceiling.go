@@ -0,0 +1,113 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"ride-home-router/internal/models"
+)
+
+// solveWithinDetourCeiling solves the request and enforces MaxDetourSecs.
+// With DeclineOverDetourCeiling set, it repeatedly drops the rider whose
+// removal most shortens the worst route's detour and solves again, reporting
+// each dropped rider in the result's Unassigned list.
+func (r *BalancedRouter) solveWithinDetourCeiling(ctx context.Context, rc routeContext, req *RoutingRequest, opts solveOptions) (*models.RoutingResult, error) {
+	result, err := r.solve(ctx, rc, req, opts)
+	if err != nil || req.MaxDetourSecs <= 0 {
+		return result, err
+	}
+
+	remaining := append([]models.Participant(nil), req.Participants...)
+	var declined []models.UnassignedParticipant
+	for maxRouteDetour(result) > req.MaxDetourSecs {
+		if !req.DeclineOverDetourCeiling {
+			return nil, &ErrRoutingFailed{
+				Reason:            fmt.Sprintf("No plan keeps every driver's detour under %.0f minutes", req.MaxDetourSecs/60),
+				TotalCapacity:     totalDriverCapacity(req.Drivers),
+				TotalParticipants: len(req.Participants),
+			}
+		}
+
+		worst := worstDetourRoute(result)
+		participant, err := r.costliestDetourStop(ctx, rc, worst)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("[BALANCED] Declining %s to keep %s's detour under the ceiling (detour=%.0fs ceiling=%.0fs)",
+			participant.Name, worst.Driver.Name, worst.DetourSecs, req.MaxDetourSecs)
+		declined = append(declined, models.UnassignedParticipant{
+			Participant: *participant,
+			Reason:      fmt.Sprintf("Placing this rider pushes %s's detour to %.0f minutes, over the %.0f minute ceiling", worst.Driver.Name, worst.DetourSecs/60, req.MaxDetourSecs/60),
+		})
+		remaining = withoutParticipant(remaining, participant.ID)
+
+		if len(remaining) == 0 {
+			result = &models.RoutingResult{Routes: []models.CalculatedRoute{}, Mode: rc.mode}
+			break
+		}
+		reduced := *req
+		reduced.Participants = remaining
+		result, err = r.solve(ctx, rc, &reduced, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result.Unassigned = declined
+	result.Summary.UnassignedParticipants = make([]int64, 0, len(declined))
+	for _, entry := range declined {
+		result.Summary.UnassignedParticipants = append(result.Summary.UnassignedParticipants, entry.Participant.ID)
+	}
+	return result, nil
+}
+
+// costliestDetourStop returns the rider whose removal leaves the route with
+// the smallest detour. Earlier stops win ties.
+func (r *BalancedRouter) costliestDetourStop(ctx context.Context, rc routeContext, route *models.CalculatedRoute) (*models.Participant, error) {
+	stops := make([]*models.Participant, len(route.Stops))
+	for i := range route.Stops {
+		stops[i] = route.Stops[i].Participant
+	}
+
+	var best *models.Participant
+	bestDetour := 0.0
+	for i := range stops {
+		metrics, err := rc.evaluateParticipants(ctx, route.Driver, removeRange(stops, i, i+1))
+		if err != nil {
+			return nil, err
+		}
+		if best == nil || metrics.DetourSecs < bestDetour {
+			best = stops[i]
+			bestDetour = metrics.DetourSecs
+		}
+	}
+	return best, nil
+}
+
+func worstDetourRoute(result *models.RoutingResult) *models.CalculatedRoute {
+	var worst *models.CalculatedRoute
+	for i := range result.Routes {
+		if worst == nil || result.Routes[i].DetourSecs > worst.DetourSecs {
+			worst = &result.Routes[i]
+		}
+	}
+	return worst
+}
+
+func withoutParticipant(participants []models.Participant, id int64) []models.Participant {
+	kept := make([]models.Participant, 0, len(participants))
+	for _, participant := range participants {
+		if participant.ID != id {
+			kept = append(kept, participant)
+		}
+	}
+	return kept
+}
+
+func totalDriverCapacity(drivers []models.Driver) int {
+	total := 0
+	for _, driver := range drivers {
+		total += driver.VehicleCapacity
+	}
+	return total
+}
@@ -0,0 +1,54 @@
+package routing
+
+import (
+	"context"
+	"ride-home-router/internal/models"
+	"testing"
+)
+
+func TestBalancedRouter_RotateDriversSeedsTheIdleDriver(t *testing.T) {
+	request := func(rotate bool) *RoutingRequest {
+		return &RoutingRequest{
+			InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+			Participants: []models.Participant{
+				{ID: 1, Name: "East", Lat: 0, Lng: 1},
+				{ID: 2, Name: "West", Lat: 0, Lng: -1},
+			},
+			Drivers: []models.Driver{
+				{ID: 1, Name: "East Regular", Lat: 0, Lng: 2, VehicleCapacity: 1},
+				{ID: 2, Name: "West Regular", Lat: 0, Lng: -2, VehicleCapacity: 1},
+				{ID: 3, Name: "Always Idle", Lat: 0.3, Lng: -2, VehicleCapacity: 1},
+			},
+			Mode:              RouteModeDropoff,
+			RotateDrivers:     rotate,
+			RecentDriverRides: map[int64]int{1: 5, 2: 5},
+		}
+	}
+	driverFor := func(result *models.RoutingResult, participantID int64) int64 {
+		for _, route := range result.Routes {
+			for _, stop := range route.Stops {
+				if stop.Participant.ID == participantID {
+					return route.Driver.ID
+				}
+			}
+		}
+		return 0
+	}
+	router := NewBalancedRouter(stableDistanceCalculator{})
+
+	result, err := router.CalculateRoutes(context.Background(), request(false))
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+	if got := driverFor(result, 2); got != 2 {
+		t.Fatalf("without rotation West rides with driver %d, want the regular driving straight home", got)
+	}
+
+	result, err = router.CalculateRoutes(context.Background(), request(true))
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+	if east, west := driverFor(result, 1), driverFor(result, 2); east != 1 || west != 3 {
+		t.Fatalf("with rotation East and West ride with drivers %d and %d, want 1 and the idle driver 3", east, west)
+	}
+}
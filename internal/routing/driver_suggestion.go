@@ -0,0 +1,93 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"log"
+	"ride-home-router/internal/models"
+	"slices"
+)
+
+// DriverSuggestion is the smallest driver subset SuggestDrivers found that
+// serves every participant, with the routes it produces.
+type DriverSuggestion struct {
+	Drivers []models.Driver
+	Result  *models.RoutingResult
+}
+
+// SuggestDrivers picks the fewest of req.Drivers that can carry everyone.
+// Drivers are ranked by capacity, largest first, then by how close their home
+// is to the participants, and taken in that order until their seats cover the
+// riders and any solo riders. If routing still fails with that subset, for
+// example under a ride-time cap, the next driver is added and routing retried.
+// It returns the router's error when even the full pool cannot serve everyone.
+func SuggestDrivers(ctx context.Context, router Router, req *RoutingRequest) (*DriverSuggestion, error) {
+	ranked := rankDriversForPacking(req.Drivers, req.Participants)
+
+	seats := 0
+	needed := 0
+	for needed < len(ranked) && seats < len(req.Participants) {
+		seats += ranked[needed].VehicleCapacity
+		needed++
+	}
+	needed = max(needed, 1)
+
+	var lastErr error
+	for ; needed <= len(ranked); needed++ {
+		subset := *req
+		subset.Drivers = ranked[:needed]
+		if err := soloRideCapacityError(&subset); err != nil {
+			lastErr = err
+			continue
+		}
+		if err := accessibleCapacityError(&subset); err != nil {
+			lastErr = err
+			continue
+		}
+		result, err := router.CalculateRoutes(ctx, &subset)
+		if err != nil {
+			var routingErr *ErrRoutingFailed
+			if !errors.As(err, &routingErr) {
+				return nil, err
+			}
+			lastErr = err
+			continue
+		}
+		log.Printf("[BALANCED] Suggested %d of %d drivers for %d participants", needed, len(ranked), len(req.Participants))
+		return &DriverSuggestion{Drivers: slices.Clone(subset.Drivers), Result: result}, nil
+	}
+	if lastErr == nil {
+		return nil, &ErrRoutingFailed{Reason: "No drivers available", UnassignedCount: len(req.Participants), TotalParticipants: len(req.Participants)}
+	}
+	return nil, lastErr
+}
+
+// rankDriversForPacking orders drivers by capacity, then by distance from
+// home to the participants' centroid, then by ID.
+func rankDriversForPacking(drivers []models.Driver, participants []models.Participant) []models.Driver {
+	var centroid models.Coordinates
+	for _, participant := range participants {
+		centroid.Lat += participant.Lat
+		centroid.Lng += participant.Lng
+	}
+	if len(participants) > 0 {
+		centroid.Lat /= float64(len(participants))
+		centroid.Lng /= float64(len(participants))
+	}
+
+	ranked := slices.Clone(drivers)
+	slices.SortStableFunc(ranked, func(a, b models.Driver) int {
+		if a.VehicleCapacity != b.VehicleCapacity {
+			return b.VehicleCapacity - a.VehicleCapacity
+		}
+		distanceA, distanceB := greatCircleMeters(a.GetCoords(), centroid), greatCircleMeters(b.GetCoords(), centroid)
+		switch {
+		case distanceA < distanceB:
+			return -1
+		case distanceA > distanceB:
+			return 1
+		}
+		return int(a.ID - b.ID)
+	})
+	return ranked
+}
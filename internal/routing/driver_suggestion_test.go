@@ -0,0 +1,62 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"ride-home-router/internal/models"
+	"slices"
+	"testing"
+)
+
+func TestSuggestDrivers_PicksLargestNearestDriversThatFit(t *testing.T) {
+	router := NewBalancedRouter(stableDistanceCalculator{})
+
+	var participants []models.Participant
+	for i := range 7 {
+		participants = append(participants, models.Participant{ID: int64(i + 1), Name: "Rider", Lat: float64(i + 1), Lng: float64(i % 3)})
+	}
+	suggestion, err := SuggestDrivers(context.Background(), router, &RoutingRequest{
+		InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+		Participants:    participants,
+		Drivers: []models.Driver{
+			{ID: 1, Name: "Small", Lat: 4, Lng: 1, VehicleCapacity: 1},
+			{ID: 2, Name: "Near Van", Lat: 4, Lng: 1, VehicleCapacity: 3},
+			{ID: 3, Name: "Far Van", Lat: 40, Lng: 1, VehicleCapacity: 3},
+			{ID: 4, Name: "Near Sedan", Lat: 5, Lng: 1, VehicleCapacity: 2},
+			{ID: 5, Name: "Far Sedan", Lat: 50, Lng: 1, VehicleCapacity: 2},
+		},
+		Mode: RouteModeDropoff,
+	})
+	if err != nil {
+		t.Fatalf("SuggestDrivers() error = %v", err)
+	}
+
+	var driverIDs []int64
+	for _, driver := range suggestion.Drivers {
+		driverIDs = append(driverIDs, driver.ID)
+	}
+	if !slices.Equal(driverIDs, []int64{2, 3, 4}) {
+		t.Fatalf("suggested drivers = %v, want [2 3 4]", driverIDs)
+	}
+	if suggestion.Result.Summary.TotalParticipants != len(participants) || suggestion.Result.Summary.TotalDriversUsed > 3 {
+		t.Fatalf("summary = %+v, want all %d participants on at most 3 drivers", suggestion.Result.Summary, len(participants))
+	}
+}
+
+func TestSuggestDrivers_ReportsWhenPoolIsTooSmall(t *testing.T) {
+	router := NewBalancedRouter(stableDistanceCalculator{})
+
+	_, err := SuggestDrivers(context.Background(), router, &RoutingRequest{
+		InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+		Participants: []models.Participant{
+			{ID: 1, Name: "A", Lat: 1, Lng: 0},
+			{ID: 2, Name: "B", Lat: 2, Lng: 0},
+		},
+		Drivers: []models.Driver{{ID: 1, Name: "Solo", Lat: 3, Lng: 0, VehicleCapacity: 1}},
+		Mode:    RouteModeDropoff,
+	})
+	var routingErr *ErrRoutingFailed
+	if !errors.As(err, &routingErr) {
+		t.Fatalf("SuggestDrivers() error = %v, want ErrRoutingFailed", err)
+	}
+}
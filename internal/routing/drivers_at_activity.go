@@ -0,0 +1,23 @@
+package routing
+
+import (
+	"log"
+	"ride-home-router/internal/models"
+)
+
+// driversAtActivity returns the IDs of drivers whose home is the same point as
+// the activity location. The router still plans their routes; the baseline
+// trip is simply zero, so the coordinator is warned to check the address.
+func driversAtActivity(institute models.Coordinates, drivers []models.Driver) []int64 {
+	activityKey := coordinateKey(institute.Lat, institute.Lng, models.SamePointPrecision())
+	var ids []int64
+	for _, driver := range drivers {
+		home := driver.GetCoords()
+		if coordinateKey(home.Lat, home.Lng, models.SamePointPrecision()) != activityKey {
+			continue
+		}
+		log.Printf("[BALANCED] Warning: driver %s lives at the activity location; their whole route counts as detour", driver.Name)
+		ids = append(ids, driver.ID)
+	}
+	return ids
+}
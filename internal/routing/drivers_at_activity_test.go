@@ -0,0 +1,108 @@
+package routing
+
+import (
+	"context"
+	"math"
+	"ride-home-router/internal/distance"
+	"ride-home-router/internal/models"
+	"slices"
+	"testing"
+)
+
+func TestBalancedRouter_WarnsAboutDriverLivingAtActivity(t *testing.T) {
+	router := NewBalancedRouter(stableDistanceCalculator{})
+	result, err := router.CalculateRoutes(context.Background(), &RoutingRequest{
+		InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+		Participants: []models.Participant{
+			{ID: 1, Name: "Near", Lat: 1, Lng: 0},
+			{ID: 2, Name: "Far", Lat: 2, Lng: 0},
+		},
+		Drivers: []models.Driver{
+			{ID: 1, Name: "At Activity", Lat: 0, Lng: 0, VehicleCapacity: 1},
+			{ID: 2, Name: "Elsewhere", Lat: 5, Lng: 0, VehicleCapacity: 1},
+		},
+		Mode: RouteModeDropoff,
+	})
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+	if !slices.Equal(result.DriversAtActivity, []int64{1}) {
+		t.Fatalf("DriversAtActivity = %v, want [1]", result.DriversAtActivity)
+	}
+
+	for _, route := range result.Routes {
+		if route.DetourSecs < 0 || math.IsInf(route.DetourSecs, 0) || math.IsNaN(route.DetourSecs) {
+			t.Fatalf("driver %d detour = %v, want a finite non-negative value", route.Driver.ID, route.DetourSecs)
+		}
+		if route.Driver.ID == 1 {
+			if route.BaselineDurationSecs != 0 || route.DetourSecs != route.RouteDurationSecs {
+				t.Fatalf("co-located driver baseline = %v detour = %v route = %v, want the whole route as detour",
+					route.BaselineDurationSecs, route.DetourSecs, route.RouteDurationSecs)
+			}
+		}
+	}
+	summary := result.Summary
+	for _, value := range []float64{summary.MaxDetourSecs, summary.SumDetourSecs, summary.AverageDetourSecs} {
+		if value < 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+			t.Fatalf("summary detours = %+v, want finite non-negative values", summary)
+		}
+	}
+}
+
+func TestPopulateRouteMetrics_NeverReportsNegativeDetour(t *testing.T) {
+	institute := models.Coordinates{Lat: 0, Lng: 0}
+	driver := &models.Driver{ID: 1, Lat: 2, Lng: 0}
+	calc := newOverrideDistanceAdapter(100)
+	// The direct trip is slower than going through the stop, as real road
+	// data sometimes is.
+	calc.setDuration(institute, driver.GetCoords(), 500)
+
+	route := &models.CalculatedRoute{
+		Driver: driver,
+		Stops:  []models.RouteStop{{Participant: &models.Participant{ID: 1, Lat: 1, Lng: 0}}},
+	}
+	if err := PopulateRouteMetrics(context.Background(), calc, institute, RouteModeDropoff, route); err != nil {
+		t.Fatalf("PopulateRouteMetrics() error = %v", err)
+	}
+	if route.DetourSecs != 0 {
+		t.Fatalf("DetourSecs = %v, want 0 when the route beats the direct trip", route.DetourSecs)
+	}
+}
+
+type estimatingDistanceCalculator struct {
+	stableDistanceCalculator
+}
+
+func (calc estimatingDistanceCalculator) GetDistance(ctx context.Context, origin, dest models.Coordinates) (*distance.DistanceResult, error) {
+	result, err := calc.stableDistanceCalculator.GetDistance(ctx, origin, dest)
+	if err != nil {
+		return nil, err
+	}
+	result.Estimated = true
+	return result, nil
+}
+
+func TestBalancedRouter_WarnsAboutEstimatedDistances(t *testing.T) {
+	req := &RoutingRequest{
+		InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+		Participants:    []models.Participant{{ID: 1, Name: "Rider", Lat: 1, Lng: 0}},
+		Drivers:         []models.Driver{{ID: 1, Name: "Driver", Lat: 2, Lng: 0, VehicleCapacity: 1}},
+		Mode:            RouteModeDropoff,
+	}
+
+	result, err := NewBalancedRouter(stableDistanceCalculator{}).CalculateRoutes(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Fatalf("Warnings = %v, want none for real distances", result.Warnings)
+	}
+
+	result, err = NewBalancedRouter(estimatingDistanceCalculator{}).CalculateRoutes(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+	if !slices.Equal(result.Warnings, []string{WarningEstimatedDistances}) {
+		t.Fatalf("Warnings = %v, want the estimated distances warning", result.Warnings)
+	}
+}
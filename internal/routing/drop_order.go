@@ -0,0 +1,26 @@
+package routing
+
+import "ride-home-router/internal/models"
+
+// ageInversions counts the pairs of stops where an older participant is
+// dropped off before a younger one. It is the last tie-break between
+// otherwise equal solutions, so it only settles orders the costs leave open.
+// It is zero unless the context drops the youngest first in dropoff mode,
+// and participants without an age never count.
+func (rc routeContext) ageInversions(stops []*models.Participant) int {
+	if !rc.youngestFirst || rc.mode == RouteModePickup {
+		return 0
+	}
+	inversions := 0
+	for i, earlier := range stops {
+		if earlier.Age <= 0 {
+			continue
+		}
+		for _, later := range stops[i+1:] {
+			if later.Age > 0 && later.Age < earlier.Age {
+				inversions++
+			}
+		}
+	}
+	return inversions
+}
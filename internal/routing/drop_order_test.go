@@ -0,0 +1,65 @@
+package routing
+
+import (
+	"context"
+	"ride-home-router/internal/models"
+	"testing"
+)
+
+func TestBalancedRouter_YoungestDroppedFirstBreaksCostTies(t *testing.T) {
+	router := NewBalancedRouter(stableDistanceCalculator{})
+	// The riders sit on opposite sides of the activity and the driver lives
+	// due north of it, so either drop order costs exactly the same.
+	newRequest := func(eastAge, westAge int) *RoutingRequest {
+		return &RoutingRequest{
+			InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+			Participants: []models.Participant{
+				{ID: 1, Name: "East", Lat: 0, Lng: 1, Age: eastAge},
+				{ID: 2, Name: "West", Lat: 0, Lng: -1, Age: westAge},
+			},
+			Drivers: []models.Driver{
+				{ID: 1, Name: "Driver", Lat: 5, Lng: 0, VehicleCapacity: 2},
+			},
+			Mode:                 RouteModeDropoff,
+			YoungestDroppedFirst: true,
+		}
+	}
+
+	for _, tc := range []struct {
+		name             string
+		eastAge, westAge int
+		wantFirst        string
+	}{
+		{name: "west younger", eastAge: 12, westAge: 8, wantFirst: "West"},
+		{name: "east younger", eastAge: 8, westAge: 12, wantFirst: "East"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := router.CalculateRoutes(context.Background(), newRequest(tc.eastAge, tc.westAge))
+			if err != nil {
+				t.Fatalf("CalculateRoutes() error = %v", err)
+			}
+			if len(result.Routes) != 1 || len(result.Routes[0].Stops) != 2 {
+				t.Fatalf("routes = %+v, want one route with both riders", result.Routes)
+			}
+			if got := result.Routes[0].Stops[0].Participant.Name; got != tc.wantFirst {
+				t.Fatalf("first drop = %s, want %s", got, tc.wantFirst)
+			}
+		})
+	}
+}
+
+func TestAgeInversions_IgnoresUnknownAgesAndPickups(t *testing.T) {
+	stops := []*models.Participant{{ID: 1, Age: 14}, {ID: 2}, {ID: 3, Age: 9}, {ID: 4, Age: 11}}
+	rc := routeContext{mode: RouteModeDropoff, youngestFirst: true}
+	if got := rc.ageInversions(stops); got != 2 {
+		t.Fatalf("ageInversions() = %d, want 2", got)
+	}
+	rc.mode = RouteModePickup
+	if got := rc.ageInversions(stops); got != 0 {
+		t.Fatalf("ageInversions() in pickup mode = %d, want 0", got)
+	}
+	rc = routeContext{mode: RouteModeDropoff}
+	if got := rc.ageInversions(stops); got != 0 {
+		t.Fatalf("ageInversions() without the option = %d, want 0", got)
+	}
+}
@@ -0,0 +1,95 @@
+package routing
+
+import (
+	"cmp"
+	"ride-home-router/internal/models"
+	"slices"
+)
+
+// pinForcedOrder moves participants with a ForcedOrder to that stop index,
+// keeping everyone else in their current relative order. An index past the
+// end of the route pins to the last stop. When two participants force the
+// same index, the one already earlier in the route keeps it and the other
+// takes the nearest free index after it, or before it when none is left;
+// forcedOrderConflicts reports such routes.
+func pinForcedOrder(stops []*models.Participant) []*models.Participant {
+	type pin struct {
+		participant *models.Participant
+		index       int
+		position    int
+	}
+	var pins []pin
+	for position, stop := range stops {
+		if stop.ForcedOrder != nil {
+			pins = append(pins, pin{participant: stop, index: min(*stop.ForcedOrder, len(stops)-1), position: position})
+		}
+	}
+	if len(pins) == 0 {
+		return stops
+	}
+	slices.SortStableFunc(pins, func(a, b pin) int {
+		return cmp.Or(cmp.Compare(a.index, b.index), cmp.Compare(a.position, b.position))
+	})
+
+	result := make([]*models.Participant, len(stops))
+	for _, p := range pins {
+		index := p.index
+		for index < len(result) && result[index] != nil {
+			index++
+		}
+		if index == len(result) {
+			index = p.index
+			for result[index] != nil {
+				index--
+			}
+		}
+		result[index] = p.participant
+	}
+	next := 0
+	for _, stop := range stops {
+		if stop.ForcedOrder != nil {
+			continue
+		}
+		for result[next] != nil {
+			next++
+		}
+		result[next] = stop
+	}
+
+	if slices.Equal(result, stops) {
+		return stops
+	}
+	return result
+}
+
+// forcedOrderConflicts lists, per route, each stop index that two or more of
+// its participants force.
+func forcedOrderConflicts(routes []models.CalculatedRoute) []models.ForcedOrderConflict {
+	var conflicts []models.ForcedOrderConflict
+	for _, route := range routes {
+		byIndex := make(map[int][]int64)
+		var indexes []int
+		for _, stop := range route.Stops {
+			if stop.Participant == nil || stop.Participant.ForcedOrder == nil {
+				continue
+			}
+			index := *stop.Participant.ForcedOrder
+			if _, ok := byIndex[index]; !ok {
+				indexes = append(indexes, index)
+			}
+			byIndex[index] = append(byIndex[index], stop.Participant.ID)
+		}
+		slices.Sort(indexes)
+		for _, index := range indexes {
+			if len(byIndex[index]) < 2 {
+				continue
+			}
+			conflict := models.ForcedOrderConflict{Index: index, ParticipantIDs: byIndex[index]}
+			if route.Driver != nil {
+				conflict.DriverID = route.Driver.ID
+			}
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	return conflicts
+}
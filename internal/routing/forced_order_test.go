@@ -0,0 +1,67 @@
+package routing
+
+import (
+	"context"
+	"ride-home-router/internal/models"
+	"slices"
+	"testing"
+)
+
+func TestOptimizeRouteOrder_KeepsForceFirstParticipantFirst(t *testing.T) {
+	first := 0
+	for _, forced := range []bool{false, true} {
+		forcedParticipant := &models.Participant{ID: 2, Name: "Mid Route", Lat: 8, Lng: 0}
+		if forced {
+			forcedParticipant.ForcedOrder = &first
+		}
+		route := &models.CalculatedRoute{
+			Driver: &models.Driver{ID: 1, Name: "Driver", Lat: 10, Lng: 0},
+			Stops: []models.RouteStop{
+				{Participant: &models.Participant{ID: 1, Name: "Near Home", Lat: 9, Lng: 0}},
+				{Participant: forcedParticipant},
+				{Participant: &models.Participant{ID: 3, Name: "Near Activity", Lat: 1, Lng: 0}},
+			},
+		}
+
+		if err := OptimizeRouteOrder(context.Background(), stableDistanceCalculator{}, models.Coordinates{Lat: 0, Lng: 0}, RouteModeDropoff, route); err != nil {
+			t.Fatalf("OptimizeRouteOrder() error = %v", err)
+		}
+
+		if got := route.Stops[0].Participant.ID == 2; got != forced {
+			t.Fatalf("forced=%t: first stop = %d, want participant 2 first only when forced", forced, route.Stops[0].Participant.ID)
+		}
+		if route.Stops[0].Order != 0 {
+			t.Fatalf("forced=%t: first stop order = %d, want 0", forced, route.Stops[0].Order)
+		}
+	}
+}
+
+func TestPinForcedOrder_ResolvesAndReportsConflicts(t *testing.T) {
+	first, last := 0, 9
+	stops := []*models.Participant{
+		{ID: 1},
+		{ID: 2, ForcedOrder: &last},
+		{ID: 3, ForcedOrder: &first},
+		{ID: 4},
+		{ID: 5, ForcedOrder: &first},
+	}
+
+	pinned := pinForcedOrder(stops)
+	got := make([]int64, len(pinned))
+	routeStops := make([]models.RouteStop, len(pinned))
+	for i, stop := range pinned {
+		got[i] = stop.ID
+		routeStops[i] = models.RouteStop{Order: i, Participant: stop}
+	}
+	if want := []int64{3, 5, 1, 4, 2}; !slices.Equal(got, want) {
+		t.Fatalf("pinned order = %v, want %v", got, want)
+	}
+
+	conflicts := forcedOrderConflicts([]models.CalculatedRoute{{Driver: &models.Driver{ID: 7}, Stops: routeStops}})
+	if len(conflicts) != 1 {
+		t.Fatalf("conflicts = %+v, want one", conflicts)
+	}
+	if conflicts[0].DriverID != 7 || conflicts[0].Index != 0 || !slices.Equal(conflicts[0].ParticipantIDs, []int64{3, 5}) {
+		t.Fatalf("conflict = %+v, want driver 7 index 0 participants [3 5]", conflicts[0])
+	}
+}
@@ -55,6 +55,10 @@ func (a *mockDistanceAdapter) PrewarmCache(ctx context.Context, points []models.
 	return a.mock.PrewarmCache(ctx, points)
 }
 
+func (*mockDistanceAdapter) GetRouteGeometry(context.Context, []models.Coordinates) (string, error) {
+	return "", distance.ErrRouteGeometryUnavailable
+}
+
 func (a *mockDistanceAdapter) PrewarmPairs(ctx context.Context, pairs []distance.DistancePair) error {
 	return a.mock.PrewarmPairs(ctx, pairs)
 }
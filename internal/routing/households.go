@@ -0,0 +1,245 @@
+package routing
+
+import (
+	"cmp"
+	"fmt"
+	"ride-home-router/internal/models"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Household grouping is the pre-pass every solve shares: riders at the same
+// rounded stop coordinates form one group that is seated, moved and swapped
+// as a unit, since the leg between them costs nothing. A group is only split
+// when no vehicle in the request can hold it.
+
+// participantGroup represents participants from the same household or
+// meeting point, who share a single stop
+type participantGroup struct {
+	members []*models.Participant
+	address string
+	lat     float64
+	lng     float64
+}
+
+// groupParticipantsByAddress groups participants by their stop coordinates
+// Participants with the same rounded lat/lng (home or meeting point) are
+// considered to be from the same household
+func (rc routeContext) groupParticipantsByAddress(participants []*models.Participant) []*participantGroup {
+	// Map address coordinates to group
+	addressMap := make(map[string]*participantGroup)
+
+	for _, p := range participants {
+		key := rc.householdKey(p)
+
+		if group, exists := addressMap[key]; exists {
+			// Add to existing group
+			group.members = append(group.members, p)
+		} else {
+			// Create new group
+			addressMap[key] = newParticipantGroup(p)
+		}
+	}
+
+	// Convert map to slice
+	groups := make([]*participantGroup, 0, len(addressMap))
+	for _, group := range addressMap {
+		slices.SortFunc(group.members, func(a, b *models.Participant) int { return cmp.Compare(a.ID, b.ID) })
+		groups = append(groups, group)
+	}
+
+	// Sort groups by size (larger groups first) for better initial assignment.
+	// Equal sizes go lowest participant ID first so insertion-cost ties, which
+	// keep the first candidate, resolve the same way on every run.
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i].members) != len(groups[j].members) {
+			return len(groups[i].members) > len(groups[j].members)
+		}
+		if groups[i].members[0].ID != groups[j].members[0].ID {
+			return groups[i].members[0].ID < groups[j].members[0].ID
+		}
+		return participantGroupKey(groups[i]) < participantGroupKey(groups[j])
+	})
+
+	return groups
+}
+
+// splitMember returns the member the context's household split policy
+// splits off first. Ties, and every member under the default policy, go to
+// the lowest participant ID, which members are already sorted by.
+func (rc routeContext) splitMember(group *participantGroup) *models.Participant {
+	chosen := group.members[0]
+	for _, member := range group.members[1:] {
+		if rc.splitsBefore(member, chosen) {
+			chosen = member
+		}
+	}
+	return chosen
+}
+
+func (rc routeContext) splitsBefore(a, b *models.Participant) bool {
+	if rc.householdSplit != HouseholdSplitYoungest && rc.householdSplit != HouseholdSplitOldest {
+		return false
+	}
+	if a.Age <= 0 || b.Age <= 0 {
+		return a.Age > 0 && b.Age <= 0
+	}
+	if rc.householdSplit == HouseholdSplitYoungest {
+		return a.Age < b.Age
+	}
+	return a.Age > b.Age
+}
+
+// coordinateKey creates a unique key for a coordinate pair rounded to the
+// given decimal places
+func coordinateKey(lat, lng float64, decimals int) string {
+	return fmt.Sprintf("%.*f,%.*f", decimals, models.RoundToDecimals(lat, decimals), decimals, models.RoundToDecimals(lng, decimals))
+}
+
+// householdDecimals returns the decimal places at which stops group riders
+// into one household: the request's precision, or the same-point precision
+// when it sets none.
+func (rc routeContext) householdDecimals() int {
+	if rc.householdPrecision < 1 || rc.householdPrecision > models.MaxSamePointPrecision {
+		return models.SamePointPrecision()
+	}
+	return rc.householdPrecision
+}
+
+// HouseholdKey returns the key the router groups participants into one
+// household stop by, at the given grouping precision. Zero uses the
+// same-point precision.
+func HouseholdKey(participant *models.Participant, precision int) string {
+	return routeContext{householdPrecision: precision}.householdKey(participant)
+}
+
+func (rc routeContext) householdKey(participant *models.Participant) string {
+	if participant == nil {
+		return ""
+	}
+	decimals := rc.householdDecimals()
+	coords := participant.GetCoords()
+	key := coordinateKey(coords.Lat, coords.Lng, decimals)
+	if stop := participant.IntermediateStop; stop != nil {
+		// Only riders going through the same intermediate stop share a stop.
+		key += "|" + coordinateKey(stop.Lat, stop.Lng, decimals)
+	}
+	if participant.SoloRide {
+		// Solo riders never share a stop, even with their own household.
+		return fmt.Sprintf("%s#%d", key, participant.ID)
+	}
+	return key
+}
+
+func participantGroupKey(group *participantGroup) string {
+	if group == nil {
+		return ""
+	}
+	return coordinateKey(group.lat, group.lng, models.MaxSamePointPrecision)
+}
+
+func newParticipantGroup(participant *models.Participant) *participantGroup {
+	coords := participant.GetCoords()
+	return &participantGroup{
+		members: []*models.Participant{participant},
+		address: participant.Address,
+		lat:     models.RoundSamePoint(coords.Lat),
+		lng:     models.RoundSamePoint(coords.Lng),
+	}
+}
+
+func (rc routeContext) routeHouseholdBlocks(stops []*models.Participant) []*participantGroup {
+	if len(stops) == 0 {
+		return nil
+	}
+
+	blocks := make([]*participantGroup, 0, len(stops))
+	for _, stop := range stops {
+		if len(blocks) == 0 || rc.householdKey(blocks[len(blocks)-1].members[0]) != rc.householdKey(stop) {
+			blocks = append(blocks, newParticipantGroup(stop))
+			continue
+		}
+		blocks[len(blocks)-1].members = append(blocks[len(blocks)-1].members, stop)
+	}
+
+	return blocks
+}
+
+func (rc routeContext) householdBoundaryPositions(stops []*models.Participant) []int {
+	if len(stops) == 0 {
+		return []int{0}
+	}
+
+	positions := make([]int, 0, len(stops)+1)
+	positions = append(positions, 0)
+	pos := 0
+	for _, block := range rc.routeHouseholdBlocks(stops) {
+		pos += len(block.members)
+		positions = append(positions, pos)
+	}
+
+	return positions
+}
+
+func (rc routeContext) coalesceHouseholdStops(stops []*models.Participant) []*models.Participant {
+	if len(stops) < 2 {
+		return stops
+	}
+
+	orderedKeys := make([]string, 0, len(stops))
+	grouped := make(map[string]*participantGroup, len(stops))
+	for _, stop := range stops {
+		key := rc.householdKey(stop)
+		if group, exists := grouped[key]; exists {
+			group.members = append(group.members, stop)
+			continue
+		}
+
+		orderedKeys = append(orderedKeys, key)
+		grouped[key] = newParticipantGroup(stop)
+	}
+
+	result := make([]*models.Participant, 0, len(stops))
+	for _, key := range orderedKeys {
+		result = append(result, grouped[key].members...)
+	}
+
+	if slices.Equal(result, stops) {
+		return stops
+	}
+
+	return result
+}
+
+// splitHouseholdWarnings describes each household the plan seats in more than
+// one car, which only happens when no vehicle can hold the whole group.
+func (rc routeContext) splitHouseholdWarnings(routes []models.CalculatedRoute) []string {
+	var keys []string
+	names := make(map[string][]string)
+	cars := make(map[string]map[int]struct{})
+	for i, route := range routes {
+		for _, stop := range route.Stops {
+			if stop.Participant == nil {
+				continue
+			}
+			key := rc.householdKey(stop.Participant)
+			if _, ok := cars[key]; !ok {
+				keys = append(keys, key)
+				cars[key] = make(map[int]struct{})
+			}
+			names[key] = append(names[key], stop.Participant.Name)
+			cars[key][i] = struct{}{}
+		}
+	}
+
+	var warnings []string
+	for _, key := range keys {
+		if len(cars[key]) < 2 {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("%s share an address but ride in %d cars because no vehicle could seat all %d",
+			strings.Join(names[key], ", "), len(cars[key]), len(names[key])))
+	}
+	return warnings
+}
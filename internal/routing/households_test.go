@@ -0,0 +1,204 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"ride-home-router/internal/distance"
+	"ride-home-router/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestSolvers_KeepSiblingsAtOneAddressInOneCar(t *testing.T) {
+	newRequest := func(mode RouteMode, metric OptimizationMetric, objective PickupObjective) *RoutingRequest {
+		return &RoutingRequest{
+			InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+			Participants: []models.Participant{
+				{ID: 1, Name: "Near East", Lat: 0, Lng: 1},
+				{ID: 2, Name: "Sibling A", Lat: 3, Lng: 3},
+				{ID: 3, Name: "Near West", Lat: 0, Lng: -1},
+				{ID: 4, Name: "Sibling B", Lat: 3, Lng: 3},
+				{ID: 5, Name: "Far East", Lat: 2, Lng: 4},
+				{ID: 6, Name: "Far West", Lat: 2, Lng: -4},
+			},
+			Drivers: []models.Driver{
+				{ID: 1, Name: "East", Lat: 4, Lng: 4, VehicleCapacity: 3},
+				{ID: 2, Name: "West", Lat: 4, Lng: -4, VehicleCapacity: 3},
+				{ID: 3, Name: "North", Lat: 6, Lng: 0, VehicleCapacity: 2},
+			},
+			Mode:            mode,
+			Metric:          metric,
+			PickupObjective: objective,
+		}
+	}
+	router := NewBalancedRouter(stableDistanceCalculator{}).(*BalancedRouter)
+
+	type solver func(context.Context, *RoutingRequest) ([]*models.RoutingResult, error)
+	single := func(router Router) solver {
+		return func(ctx context.Context, req *RoutingRequest) ([]*models.RoutingResult, error) {
+			result, err := router.CalculateRoutes(ctx, req)
+			return []*models.RoutingResult{result}, err
+		}
+	}
+	candidates := func(ranking CandidateRanking) solver {
+		return func(ctx context.Context, req *RoutingRequest) ([]*models.RoutingResult, error) {
+			return router.CalculateCandidates(ctx, req, 10, ranking)
+		}
+	}
+	suggested := func(ctx context.Context, req *RoutingRequest) ([]*models.RoutingResult, error) {
+		suggestion, err := SuggestDrivers(ctx, router, req)
+		if err != nil {
+			return nil, err
+		}
+		return []*models.RoutingResult{suggestion.Result}, nil
+	}
+	solvers := map[string]solver{
+		"balanced":             single(router),
+		"limited":              single(NewLimitedRouter(router, 1)),
+		"fairness candidates":  candidates(RankByFairness),
+		"distance candidates":  candidates(RankByDistance),
+		"suggested driver set": suggested,
+	}
+	requests := map[string]*RoutingRequest{
+		"dropoff by duration": newRequest(RouteModeDropoff, MetricDuration, ""),
+		"dropoff by distance": newRequest(RouteModeDropoff, MetricDistance, ""),
+		"pickup by arrival":   newRequest(RouteModePickup, MetricDuration, PickupObjectiveArrival),
+		"pickup by max ride":  newRequest(RouteModePickup, MetricDistance, PickupObjectiveMaxRide),
+	}
+
+	for solverName, solve := range solvers {
+		for requestName, req := range requests {
+			results, err := solve(context.Background(), req)
+			if err != nil {
+				t.Fatalf("%s, %s: error = %v", solverName, requestName, err)
+			}
+			for _, result := range results {
+				driverOf := make(map[int64]int64)
+				for _, route := range result.Routes {
+					for _, stop := range route.Stops {
+						driverOf[stop.Participant.ID] = route.Driver.ID
+					}
+				}
+				if driverOf[2] == 0 || driverOf[2] != driverOf[4] {
+					t.Fatalf("%s, %s: siblings rode with drivers %d and %d, want one car", solverName, requestName, driverOf[2], driverOf[4])
+				}
+				if len(result.Warnings) != 0 {
+					t.Fatalf("%s, %s: warnings = %q, want none for a household kept together", solverName, requestName, result.Warnings)
+				}
+			}
+		}
+	}
+}
+
+func TestBalancedRouter_HouseholdSplitPolicyPicksWhoSplitsOff(t *testing.T) {
+	router := NewBalancedRouter(stableDistanceCalculator{})
+	newRequest := func(policy HouseholdSplit) *RoutingRequest {
+		return &RoutingRequest{
+			InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+			// Three siblings share an address no single car can hold.
+			Participants: []models.Participant{
+				{ID: 1, Name: "Middle", Lat: 0, Lng: 0.5, Age: 12},
+				{ID: 2, Name: "Eldest", Lat: 0, Lng: 0.5, Age: 15},
+				{ID: 3, Name: "Youngest", Lat: 0, Lng: 0.5, Age: 8},
+			},
+			Drivers: []models.Driver{
+				{ID: 1, Name: "North", Lat: 1, Lng: 0.5, VehicleCapacity: 2},
+				{ID: 2, Name: "South", Lat: -1, Lng: 0.5, VehicleCapacity: 2},
+			},
+			Mode:           RouteModeDropoff,
+			HouseholdSplit: policy,
+		}
+	}
+
+	for _, tc := range []struct {
+		policy    HouseholdSplit
+		wantAlone string
+	}{
+		{policy: "", wantAlone: "Middle"},
+		{policy: HouseholdSplitYoungest, wantAlone: "Youngest"},
+		{policy: HouseholdSplitOldest, wantAlone: "Eldest"},
+	} {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			result, err := router.CalculateRoutes(context.Background(), newRequest(tc.policy))
+			if err != nil {
+				t.Fatalf("CalculateRoutes() error = %v", err)
+			}
+			if len(result.Routes) != 2 {
+				t.Fatalf("routes = %+v, want the household split across both cars", result.Routes)
+			}
+			var alone []string
+			for _, route := range result.Routes {
+				if len(route.Stops) == 1 {
+					alone = append(alone, route.Stops[0].Participant.Name)
+				}
+			}
+			if len(alone) != 1 || alone[0] != tc.wantAlone {
+				t.Fatalf("split off %v, want [%s]", alone, tc.wantAlone)
+			}
+			if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "ride in 2 cars") {
+				t.Fatalf("warnings = %q, want one naming the split household", result.Warnings)
+			}
+		})
+	}
+}
+
+func TestBalancedRouter_WholeGroupAtOneAddressRidesAsOneStop(t *testing.T) {
+	calc := &countingSolveDistanceCalculator{}
+	router := NewBalancedRouter(calc)
+	participants := make([]models.Participant, 5)
+	for i := range participants {
+		participants[i] = models.Participant{ID: int64(i + 1), Name: fmt.Sprintf("Resident %d", i+1), Address: "1 Dorm Way", Lat: 1, Lng: 1}
+	}
+
+	result, err := router.CalculateRoutes(context.Background(), &RoutingRequest{
+		InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+		Participants:    participants,
+		Drivers:         []models.Driver{{ID: 1, Name: "Van", Lat: 2, Lng: 2, VehicleCapacity: 6}},
+		Mode:            RouteModeDropoff,
+	})
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+
+	if len(result.Routes) != 1 || len(result.Routes[0].Stops) != 5 {
+		t.Fatalf("routes = %#v, want one route carrying all five residents", result.Routes)
+	}
+	route := result.Routes[0]
+	if got := len(routeContext{}.routeHouseholdBlocks(routeStopParticipants(route.Stops))); got != 1 {
+		t.Fatalf("route has %d stop blocks, want 1", got)
+	}
+	for i, stop := range route.Stops[1:] {
+		if stop.DistanceFromPrevMeters != 0 {
+			t.Fatalf("stop %d distance from previous = %.0f, want 0 at a shared address", i+2, stop.DistanceFromPrevMeters)
+		}
+	}
+	if route.EffectiveCapacity != 6 || result.Summary.TotalParticipants != 5 {
+		t.Fatalf("capacity=%d participants=%d, want 6 seats with 5 counted", route.EffectiveCapacity, result.Summary.TotalParticipants)
+	}
+	for pair := range calc.calls {
+		if pair == distance.PairCacheKey(participants[0].GetCoords(), participants[0].GetCoords()) {
+			t.Fatalf("the shared address was sent to the distance calculator as a pair with itself")
+		}
+	}
+}
+
+func TestGroupParticipantsByAddress_HouseholdPrecision(t *testing.T) {
+	// Two members of one family geocoded about 4 meters apart on the same lot.
+	participants := []*models.Participant{
+		{ID: 1, Name: "Sibling A", Lat: 40.12341, Lng: -74.12342},
+		{ID: 2, Name: "Sibling B", Lat: 40.12344, Lng: -74.12339},
+	}
+
+	for _, tt := range []struct {
+		precision int
+		want      int
+	}{
+		{precision: 4, want: 1},
+		{precision: 5, want: 2},
+	} {
+		rc := newRequestRouteContext(nil, &RoutingRequest{HouseholdPrecision: tt.precision})
+		if got := len(rc.groupParticipantsByAddress(participants)); got != tt.want {
+			t.Fatalf("precision %d: got %d household groups, want %d", tt.precision, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package routing
+
+import (
+	"cmp"
+	"ride-home-router/internal/models"
+	"slices"
+)
+
+// insertionPositions returns the household boundaries worth trying when
+// inserting group into stops. Without a limit that is every boundary. With
+// one, boundaries are screened by the straight-line detour of visiting the
+// group between the stops on either side, the route's origin or destination
+// standing in at either end, and only the cheapest
+// maxInsertionPositions are kept, in route order, for the full evaluation.
+func (rc routeContext) insertionPositions(driver *models.Driver, stops []*models.Participant, group *participantGroup) []int {
+	positions := rc.householdBoundaryPositions(stops)
+	if rc.maxInsertionPositions <= 0 || len(positions) <= rc.maxInsertionPositions {
+		return positions
+	}
+
+	target := group.members[0].GetCoords()
+	detours := make(map[int]float64, len(positions))
+	for _, pos := range positions {
+		prev := rc.origin(driver)
+		if pos > 0 {
+			prev = stops[pos-1].GetCoords()
+		}
+		next := rc.destination(driver)
+		if pos < len(stops) {
+			next = stops[pos].GetCoords()
+		}
+		detours[pos] = greatCircleMeters(prev, target) + greatCircleMeters(target, next) - greatCircleMeters(prev, next)
+	}
+
+	ranked := slices.Clone(positions)
+	slices.SortStableFunc(ranked, func(a, b int) int {
+		return cmp.Compare(detours[a], detours[b])
+	})
+	kept := ranked[:rc.maxInsertionPositions]
+	slices.Sort(kept)
+	return kept
+}
@@ -0,0 +1,99 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"ride-home-router/internal/models"
+	"slices"
+	"testing"
+)
+
+func largeInsertionRequest(maxInsertionPositions int) *RoutingRequest {
+	req := &RoutingRequest{
+		InstituteCoords:       models.Coordinates{Lat: 0, Lng: 0},
+		Participants:          make([]models.Participant, 18),
+		Drivers:               make([]models.Driver, 4),
+		Mode:                  RouteModeDropoff,
+		MaxInsertionPositions: maxInsertionPositions,
+	}
+	for i := range req.Participants {
+		req.Participants[i] = models.Participant{
+			ID:   int64(i + 1),
+			Name: fmt.Sprintf("Rider %d", i+1),
+			Lat:  float64(i%6) * 0.7,
+			Lng:  float64(i/6)*0.9 - 1,
+		}
+	}
+	for i := range req.Drivers {
+		req.Drivers[i] = models.Driver{
+			ID:              int64(i + 1),
+			Name:            fmt.Sprintf("Driver %d", i+1),
+			Lat:             float64(i) - 2,
+			Lng:             3,
+			VehicleCapacity: 5,
+		}
+	}
+	return req
+}
+
+func TestInsertionPositions_KeepsCheapestBoundariesInRouteOrder(t *testing.T) {
+	driver := &models.Driver{ID: 1, Lat: 0, Lng: 10}
+	stops := []*models.Participant{
+		{ID: 1, Lat: 0, Lng: 2},
+		{ID: 2, Lat: 0, Lng: 4},
+		{ID: 3, Lat: 0, Lng: 6},
+		{ID: 4, Lat: 0, Lng: 8},
+	}
+	group := newParticipantGroup(&models.Participant{ID: 5, Lat: 0.01, Lng: 5.2})
+
+	rc := newRouteContext(stableDistanceCalculator{}, models.Coordinates{}, RouteModeDropoff)
+	if got := rc.insertionPositions(driver, stops, group); !slices.Equal(got, []int{0, 1, 2, 3, 4}) {
+		t.Fatalf("unlimited positions = %v, want every boundary", got)
+	}
+	rc.maxInsertionPositions = 2
+	if got := rc.insertionPositions(driver, stops, group); !slices.Equal(got, []int{2, 3}) {
+		t.Fatalf("pruned positions = %v, want the two around the rider", got)
+	}
+}
+
+func TestBalancedRouter_PrunedInsertionStaysFeasible(t *testing.T) {
+	router := NewBalancedRouter(stableDistanceCalculator{})
+	for _, maxInsertionPositions := range []int{0, 1, 2} {
+		req := largeInsertionRequest(maxInsertionPositions)
+		result, err := router.CalculateRoutes(context.Background(), req)
+		if err != nil {
+			t.Fatalf("K=%d: CalculateRoutes() error = %v", maxInsertionPositions, err)
+		}
+
+		seen := make(map[int64]bool)
+		for _, route := range result.Routes {
+			if len(route.Stops) > route.Driver.VehicleCapacity {
+				t.Fatalf("K=%d: driver %d carries %d riders in %d seats", maxInsertionPositions, route.Driver.ID, len(route.Stops), route.Driver.VehicleCapacity)
+			}
+			for _, stop := range route.Stops {
+				if seen[stop.Participant.ID] {
+					t.Fatalf("K=%d: participant %d routed twice", maxInsertionPositions, stop.Participant.ID)
+				}
+				seen[stop.Participant.ID] = true
+			}
+		}
+		if len(seen) != len(req.Participants) || len(result.Summary.UnassignedParticipants) != 0 {
+			t.Fatalf("K=%d: routed %d of %d participants, unassigned %v", maxInsertionPositions, len(seen), len(req.Participants), result.Summary.UnassignedParticipants)
+		}
+	}
+}
+
+func BenchmarkBalancedRouter_InsertionPositions(b *testing.B) {
+	for _, maxInsertionPositions := range []int{0, 2} {
+		b.Run(fmt.Sprintf("K=%d", maxInsertionPositions), func(b *testing.B) {
+			router := NewBalancedRouter(stableDistanceCalculator{})
+			req := largeInsertionRequest(maxInsertionPositions)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := router.CalculateRoutes(context.Background(), req); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
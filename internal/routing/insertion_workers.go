@@ -0,0 +1,95 @@
+package routing
+
+import (
+	"context"
+	"math"
+	"ride-home-router/internal/models"
+	"sync"
+)
+
+// insertionCandidate is one group and position a seeding step may insert.
+type insertionCandidate struct {
+	group      *participantGroup
+	groupIndex int
+	pos        int
+}
+
+// cheapestInsertion returns the index of the candidate with the lowest
+// insertion cost that keeps the route within its time limits, and that cost,
+// or -1 when none does. With more than one insertion worker the costs are
+// evaluated concurrently, but ties still go to the earliest candidate, so the
+// choice matches the sequential search.
+func (r *BalancedRouter) cheapestInsertion(ctx context.Context, rc routeContext, driver *models.Driver, stops []*models.Participant, routeScore float64, candidates []insertionCandidate) (int, float64, error) {
+	costs := make([]float64, len(candidates))
+	evaluate := func(ctx context.Context, i int) error {
+		candidate := candidates[i]
+		withinCap, err := rc.withinTimeLimits(ctx, driver, insertGroupAt(stops, candidate.group, candidate.pos))
+		if err != nil {
+			return err
+		}
+		if !withinCap {
+			costs[i] = math.Inf(1)
+			return nil
+		}
+		cost, err := rc.groupInsertionDeltaRiderScoreFrom(ctx, driver, stops, candidate.group, candidate.pos, routeScore)
+		if err != nil {
+			return err
+		}
+		costs[i] = cost + rc.comfortPenalty(driver, candidate.group.members)
+		return nil
+	}
+
+	if r.insertionWorkers <= 1 || len(candidates) < 2 {
+		for i := range candidates {
+			if err := evaluate(ctx, i); err != nil {
+				return -1, 0, err
+			}
+		}
+	} else if err := evaluateConcurrently(ctx, len(candidates), r.insertionWorkers, evaluate); err != nil {
+		return -1, 0, err
+	}
+
+	best, bestCost := -1, math.Inf(1)
+	for i, cost := range costs {
+		if cost < bestCost {
+			best, bestCost = i, cost
+		}
+	}
+	return best, bestCost, nil
+}
+
+// evaluateConcurrently calls evaluate for every index below n on at most
+// workers goroutines. The first error cancels the evaluations not yet
+// started and is returned.
+func evaluateConcurrently(ctx context.Context, n, workers int, evaluate func(context.Context, int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	slots := make(chan struct{}, workers)
+	for i := range n {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-slots; wg.Done() }()
+			if err := evaluate(ctx, i); err != nil {
+				errOnce.Do(func() { firstErr = err; cancel() })
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
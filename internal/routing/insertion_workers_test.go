@@ -0,0 +1,43 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBalancedRouter_InsertionWorkersChooseTheSequentialPlan(t *testing.T) {
+	sequential, err := NewBalancedRouter(stableDistanceCalculator{}).CalculateRoutes(context.Background(), largeInsertionRequest(0))
+	if err != nil {
+		t.Fatalf("sequential CalculateRoutes() error = %v", err)
+	}
+	router := NewBalancedRouterWithOptions(stableDistanceCalculator{}, BalancedOptions{InsertionWorkers: 4})
+	parallel, err := router.CalculateRoutes(context.Background(), largeInsertionRequest(0))
+	if err != nil {
+		t.Fatalf("parallel CalculateRoutes() error = %v", err)
+	}
+	if !reflect.DeepEqual(sequential.Routes, parallel.Routes) {
+		t.Fatalf("parallel routes differ from the sequential plan:\n%#v\n%#v", parallel.Routes, sequential.Routes)
+	}
+}
+
+func TestEvaluateConcurrently_StopsAtTheFirstError(t *testing.T) {
+	failed := errors.New("lookup failed")
+	var calls atomic.Int32
+	err := evaluateConcurrently(context.Background(), 100, 2, func(ctx context.Context, i int) error {
+		calls.Add(1)
+		if i == 0 {
+			return failed
+		}
+		<-ctx.Done()
+		return nil
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("evaluateConcurrently() error = %v, want %v", err, failed)
+	}
+	if calls.Load() > 2 {
+		t.Fatalf("%d evaluations started, want the rest skipped once the first failed", calls.Load())
+	}
+}
@@ -2,8 +2,11 @@ package routing
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"ride-home-router/internal/distance"
 	"ride-home-router/internal/models"
+	"strings"
 )
 
 // RouteMode defines the direction of the route calculation.
@@ -14,12 +17,156 @@ const (
 	RouteModePickup  RouteMode = models.RouteModePickup  // Driver Home → Participants → Activity Location
 )
 
+// RouteModes lists every mode the router accepts.
+var RouteModes = []RouteMode{RouteModeDropoff, RouteModePickup}
+
+// ParseRouteMode normalizes a route mode from a request, defaulting blank
+// input to dropoff. Anything outside RouteModes is models.ErrInvalidRouteMode.
+func ParseRouteMode(value string) (RouteMode, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return RouteModeDropoff, nil
+	}
+	for _, mode := range RouteModes {
+		if string(mode) == value {
+			return mode, nil
+		}
+	}
+	return "", models.ErrInvalidRouteMode
+}
+
+// OptimizationMetric selects which leg cost the router minimizes.
+type OptimizationMetric string
+
+const (
+	MetricDuration OptimizationMetric = "duration" // Default: optimize travel time
+	MetricDistance OptimizationMetric = "distance" // Optimize driven distance
+)
+
+// PickupObjective selects what the router minimizes first in pickup mode.
+type PickupObjective string
+
+const (
+	PickupObjectiveArrival PickupObjective = "arrival"  // Default: earliest arrival at the activity
+	PickupObjectiveMaxRide PickupObjective = "max_ride" // Shortest longest in-car time for any rider
+)
+
+var ErrInvalidPickupObjective = errors.New("invalid pickup objective")
+
+// ParsePickupObjective normalizes a pickup objective, defaulting blank input to arrival.
+func ParsePickupObjective(value string) (PickupObjective, error) {
+	switch strings.TrimSpace(value) {
+	case "", string(PickupObjectiveArrival):
+		return PickupObjectiveArrival, nil
+	case string(PickupObjectiveMaxRide):
+		return PickupObjectiveMaxRide, nil
+	default:
+		return "", ErrInvalidPickupObjective
+	}
+}
+
+// HouseholdSplit selects which member leaves a household too large for any
+// vehicle in the request.
+type HouseholdSplit string
+
+const (
+	HouseholdSplitByID     HouseholdSplit = "id"       // Default: lowest participant ID splits off first
+	HouseholdSplitYoungest HouseholdSplit = "youngest" // Youngest splits off first so older members stay together
+	HouseholdSplitOldest   HouseholdSplit = "oldest"   // Oldest splits off first so younger members stay together
+)
+
+var ErrInvalidHouseholdSplit = errors.New("invalid household split")
+
+// ParseHouseholdSplit normalizes a household split policy, defaulting blank input to by-ID.
+func ParseHouseholdSplit(value string) (HouseholdSplit, error) {
+	switch strings.TrimSpace(value) {
+	case "", string(HouseholdSplitByID):
+		return HouseholdSplitByID, nil
+	case string(HouseholdSplitYoungest):
+		return HouseholdSplitYoungest, nil
+	case string(HouseholdSplitOldest):
+		return HouseholdSplitOldest, nil
+	default:
+		return "", ErrInvalidHouseholdSplit
+	}
+}
+
+// DetourFairness selects how the router compares driver detours when
+// balancing a plan.
+type DetourFairness string
+
+const (
+	DetourFairnessAbsolute DetourFairness = "absolute" // Default: smallest largest detour
+	DetourFairnessRatio    DetourFairness = "ratio"    // Smallest largest detour relative to the driver's direct trip
+)
+
+var ErrInvalidDetourFairness = errors.New("invalid detour fairness")
+
+// ParseDetourFairness normalizes a detour fairness policy, defaulting blank input to absolute.
+func ParseDetourFairness(value string) (DetourFairness, error) {
+	switch strings.TrimSpace(value) {
+	case "", string(DetourFairnessAbsolute):
+		return DetourFairnessAbsolute, nil
+	case string(DetourFairnessRatio):
+		return DetourFairnessRatio, nil
+	default:
+		return "", ErrInvalidDetourFairness
+	}
+}
+
+// FairnessWeights trade the largest driver detour, the sum of all detours and
+// drivers left unused against each other as one weighted cost, in the
+// optimization metric's units. The router weighs it after participant
+// completion times and any detour ratio, and before its default
+// largest-detour comparison. All zero weights leave that default order
+// unchanged.
+type FairnessWeights struct {
+	MaxDetourWeight     float64
+	SumDetourWeight     float64
+	UnusedDriverPenalty float64 // Cost per selected driver given no riders
+}
+
+var ErrInvalidOptimizationMetric = errors.New("invalid optimization metric")
+
+// ParseOptimizationMetric normalizes a metric value, defaulting blank input to duration.
+func ParseOptimizationMetric(value string) (OptimizationMetric, error) {
+	switch strings.TrimSpace(value) {
+	case "", string(MetricDuration):
+		return MetricDuration, nil
+	case string(MetricDistance):
+		return MetricDistance, nil
+	default:
+		return "", ErrInvalidOptimizationMetric
+	}
+}
+
 // RoutingRequest contains the input for route calculation
 type RoutingRequest struct {
-	InstituteCoords models.Coordinates
-	Participants    []models.Participant
-	Drivers         []models.Driver
-	Mode            RouteMode
+	InstituteCoords          models.Coordinates
+	Participants             []models.Participant
+	Drivers                  []models.Driver
+	Mode                     RouteMode
+	Metric                   OptimizationMetric          // Empty means MetricDuration
+	PickupObjective          PickupObjective             // Pickup mode only; empty means PickupObjectiveArrival
+	MaxParticipantRideSecs   float64                     // Cap on any one rider's time in the car; zero means no cap
+	MaxDetourSecs            float64                     // Ceiling on any one driver's detour; zero means no ceiling
+	DeclineOverDetourCeiling bool                        // Leave the worst-detour riders unassigned instead of failing over MaxDetourSecs
+	CompactDepartures        bool                        // Apply suggested swaps for neighboring first dropoffs instead of only reporting them
+	MaxNeighborhoods         int                         // Soft cap on distinct neighborhoods per route; zero means no cap
+	YoungestDroppedFirst     bool                        // Break dropoff order ties by dropping younger riders first
+	HouseholdSplit           HouseholdSplit              // Empty means HouseholdSplitByID
+	IncludeGeometry          bool                        // Fill each stop's GeometryPolyline where the provider can draw paths
+	HouseholdPrecision       int                         // Decimal places household stops must match at; zero means the same-point precision
+	DetourFairness           DetourFairness              // Empty means DetourFairnessAbsolute
+	ExcludeHomeLeg           bool                        // Leave the driver's home leg out of the objective; reported metrics keep it
+	FairnessWeights          FairnessWeights             // Weighted detour and unused-driver cost; all zero keeps the default order
+	BusStops                 []models.Coordinates        // Shared meeting points riders walk to instead of door-to-door stops
+	MaxWalkMeters            float64                     // Straight-line walk to a bus stop; zero means DefaultMaxWalkMeters
+	RotateDrivers            bool                        // Favor drivers with the fewest RecentDriverRides
+	RecentDriverRides        map[int64]int               // Recent rides per driver ID; missing drivers count as none
+	PreferNearbyDrivers      bool                        // Fill drivers nearest the participants' centroid first
+	MaxInsertionPositions    int                         // Try only the cheapest straight-line positions per insertion; zero tries all
+	Distances                distance.DistanceCalculator // Replaces the router's calculator for this request when set
 }
 
 // Router provides route optimization
@@ -27,12 +174,25 @@ type Router interface {
 	CalculateRoutes(ctx context.Context, req *RoutingRequest) (*models.RoutingResult, error)
 }
 
+// CandidateRouter is a Router that can also return several distinct
+// solutions, ranked best first.
+type CandidateRouter interface {
+	Router
+	CalculateCandidates(ctx context.Context, req *RoutingRequest, k int, ranking CandidateRanking) ([]*models.RoutingResult, error)
+}
+
 // ErrRoutingFailed is returned when no valid route solution exists
 type ErrRoutingFailed struct {
 	Reason            string
 	UnassignedCount   int
 	TotalCapacity     int
 	TotalParticipants int
+	// OutsideTimeWindow lists the unassigned participants with a time window,
+	// whose windows may be why no route could take them.
+	OutsideTimeWindow []int64
+	// ExcludedByDriver lists the unassigned participants some selected driver
+	// has excluded, which may be why no route could take them.
+	ExcludedByDriver []int64
 }
 
 func (e *ErrRoutingFailed) Error() string {
@@ -0,0 +1,46 @@
+package routing
+
+import (
+	"errors"
+	"ride-home-router/internal/models"
+	"testing"
+)
+
+func TestParseRouteMode_AcceptsOnlyKnownModes(t *testing.T) {
+	for _, mode := range RouteModes {
+		got, err := ParseRouteMode(" " + string(mode) + " ")
+		if err != nil || got != mode {
+			t.Fatalf("ParseRouteMode(%q) = %q, %v", mode, got, err)
+		}
+	}
+	if got, err := ParseRouteMode(""); err != nil || got != RouteModeDropoff {
+		t.Fatalf("ParseRouteMode(blank) = %q, %v; want dropoff", got, err)
+	}
+	for _, value := range []string{"Pickup", "drop-off", "sideways"} {
+		if _, err := ParseRouteMode(value); !errors.Is(err, models.ErrInvalidRouteMode) {
+			t.Fatalf("ParseRouteMode(%q) error = %v, want ErrInvalidRouteMode", value, err)
+		}
+	}
+}
+
+func TestParseHouseholdSplit(t *testing.T) {
+	for value, want := range map[string]HouseholdSplit{"": HouseholdSplitByID, " youngest ": HouseholdSplitYoungest, "oldest": HouseholdSplitOldest} {
+		if got, err := ParseHouseholdSplit(value); err != nil || got != want {
+			t.Fatalf("ParseHouseholdSplit(%q) = %q, %v; want %q", value, got, err, want)
+		}
+	}
+	if _, err := ParseHouseholdSplit("tallest"); !errors.Is(err, ErrInvalidHouseholdSplit) {
+		t.Fatalf("ParseHouseholdSplit(tallest) error = %v", err)
+	}
+}
+
+func TestParseDetourFairness(t *testing.T) {
+	for value, want := range map[string]DetourFairness{"": DetourFairnessAbsolute, "absolute": DetourFairnessAbsolute, " ratio ": DetourFairnessRatio} {
+		if got, err := ParseDetourFairness(value); err != nil || got != want {
+			t.Fatalf("ParseDetourFairness(%q) = %q, %v; want %q", value, got, err, want)
+		}
+	}
+	if _, err := ParseDetourFairness("relative"); !errors.Is(err, ErrInvalidDetourFairness) {
+		t.Fatalf("ParseDetourFairness(relative) error = %v", err)
+	}
+}
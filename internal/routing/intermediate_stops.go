@@ -0,0 +1,47 @@
+package routing
+
+import (
+	"context"
+	"ride-home-router/internal/distance"
+	"ride-home-router/internal/models"
+)
+
+// stopPoints returns the points the route visits for one stop, in order.
+// Dropoffs drive a participant with an intermediate stop there first and
+// then on to their own stop; both points still take one seat, and nothing is
+// ever ordered between them. Pickups ignore intermediate stops.
+func (rc routeContext) stopPoints(stop *models.Participant) []models.Coordinates {
+	if rc.mode == RouteModePickup {
+		return []models.Coordinates{stop.GetCoords()}
+	}
+	return stop.DropoffPoints()
+}
+
+// legPoints returns the points driven through to reach stops[i], ending at
+// the stop's own coordinates. A household member following another at the
+// same stop was already driven through the intermediate stop.
+func (rc routeContext) legPoints(stops []*models.Participant, i int) []models.Coordinates {
+	stop := stops[i]
+	points := rc.stopPoints(stop)
+	if i > 0 && stops[i-1] != nil && rc.householdKey(stops[i-1]) == rc.householdKey(stop) {
+		points = points[len(points)-1:]
+	}
+	return points
+}
+
+// driveTo returns the drive from prev through the leg points of stops[i].
+func (rc routeContext) driveTo(ctx context.Context, prev models.Coordinates, stops []*models.Participant, i int) (*distance.DistanceResult, error) {
+	points := rc.legPoints(stops, i)
+
+	total := &distance.DistanceResult{}
+	for _, point := range points {
+		dist, err := rc.distanceCalc.GetDistance(ctx, prev, point)
+		if err != nil {
+			return nil, err
+		}
+		total.DistanceMeters += dist.DistanceMeters
+		total.DurationSecs += dist.DurationSecs
+		prev = point
+	}
+	return total, nil
+}
@@ -0,0 +1,74 @@
+package routing
+
+import (
+	"context"
+	"math"
+	"ride-home-router/internal/models"
+	"testing"
+)
+
+func TestBalancedRouter_IntermediateStopPrecedesParticipantStop(t *testing.T) {
+	router := NewBalancedRouter(stableDistanceCalculator{})
+	// Everything lies due east of the activity. Program's after-school stop is
+	// past their home, so the route must double back to drop them, and the
+	// car only has a seat for each rider.
+	req := &RoutingRequest{
+		InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+		Participants: []models.Participant{
+			{ID: 1, Name: "Program", Lat: 0, Lng: 0.4, IntermediateStop: &models.Coordinates{Lat: 0, Lng: 0.7}},
+			{ID: 2, Name: "Near", Lat: 0, Lng: 0.5},
+		},
+		Drivers: []models.Driver{
+			{ID: 1, Name: "Driver", Lat: 0, Lng: 1, VehicleCapacity: 2},
+		},
+		Mode: RouteModeDropoff,
+	}
+
+	result, err := router.CalculateRoutes(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+	if len(result.Routes) != 1 || len(result.Routes[0].Stops) != 2 {
+		t.Fatalf("routes = %+v, want one route with both riders", result.Routes)
+	}
+	route := result.Routes[0]
+	if route.Stops[0].Participant.Name != "Near" || route.Stops[1].Participant.Name != "Program" {
+		t.Fatalf("stops = %s, %s; want Near then Program", route.Stops[0].Participant.Name, route.Stops[1].Participant.Name)
+	}
+	// Near's stop to the intermediate stop, then back to Program's home.
+	if got := route.Stops[1].DistanceFromPrevMeters; math.Abs(got-500) > 1e-6 {
+		t.Fatalf("Program's leg = %.1f m, want 500 via the intermediate stop", got)
+	}
+	if math.Abs(route.TotalDistanceMeters-1600) > 1e-6 {
+		t.Fatalf("route distance = %.1f m, want 1600", route.TotalDistanceMeters)
+	}
+}
+
+func TestDriveTo_HouseholdSharesIntermediateStop(t *testing.T) {
+	rc := newRouteContext(stableDistanceCalculator{}, models.Coordinates{}, RouteModeDropoff)
+	program := &models.Coordinates{Lat: 0, Lng: 0.3}
+	stops := []*models.Participant{
+		{ID: 1, Lat: 0, Lng: 0.1, IntermediateStop: program},
+		{ID: 2, Lat: 0, Lng: 0.1, IntermediateStop: program},
+	}
+	first, err := rc.driveTo(context.Background(), models.Coordinates{}, stops, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := rc.driveTo(context.Background(), stops[0].GetCoords(), stops, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(first.DistanceMeters-500) > 1e-6 || second.DistanceMeters != 0 {
+		t.Fatalf("legs = %.1f, %.1f; want 500, 0", first.DistanceMeters, second.DistanceMeters)
+	}
+
+	rc.mode = RouteModePickup
+	pickup, err := rc.driveTo(context.Background(), models.Coordinates{}, stops, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(pickup.DistanceMeters-100) > 1e-6 {
+		t.Fatalf("pickup leg = %.1f, want 100 ignoring the intermediate stop", pickup.DistanceMeters)
+	}
+}
@@ -0,0 +1,59 @@
+package routing
+
+import (
+	"context"
+	"log"
+	"ride-home-router/internal/models"
+)
+
+// limitedRouter caps how many calculations run at once. Callers over the
+// limit queue until a slot frees up or their context ends.
+type limitedRouter struct {
+	inner Router
+	slots chan struct{}
+}
+
+// NewLimitedRouter wraps inner so at most limit calculations run at a time.
+// A limit of zero or less returns inner unchanged.
+func NewLimitedRouter(inner Router, limit int) Router {
+	if limit <= 0 {
+		return inner
+	}
+	return &limitedRouter{inner: inner, slots: make(chan struct{}, limit)}
+}
+
+func (r *limitedRouter) CalculateRoutes(ctx context.Context, req *RoutingRequest) (*models.RoutingResult, error) {
+	if err := r.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer r.release()
+	return r.inner.CalculateRoutes(ctx, req)
+}
+
+// CalculateCandidates takes one slot for the whole candidate search.
+func (r *limitedRouter) CalculateCandidates(ctx context.Context, req *RoutingRequest, k int, ranking CandidateRanking) ([]*models.RoutingResult, error) {
+	if err := r.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer r.release()
+	return CalculateCandidates(ctx, r.inner, req, k, ranking)
+}
+
+func (r *limitedRouter) acquire(ctx context.Context) error {
+	select {
+	case r.slots <- struct{}{}:
+		return nil
+	default:
+		log.Printf("[BALANCED] Waiting for one of %d calculation slots", cap(r.slots))
+		select {
+		case r.slots <- struct{}{}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (r *limitedRouter) release() {
+	<-r.slots
+}
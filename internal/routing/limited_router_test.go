@@ -0,0 +1,95 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"ride-home-router/internal/models"
+	"testing"
+	"time"
+)
+
+type blockingRouter struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r blockingRouter) CalculateRoutes(context.Context, *RoutingRequest) (*models.RoutingResult, error) {
+	r.started <- struct{}{}
+	<-r.release
+	return &models.RoutingResult{}, nil
+}
+
+func TestLimitedRouter_SecondCalculationWaitsForFirst(t *testing.T) {
+	inner := blockingRouter{started: make(chan struct{}, 2), release: make(chan struct{})}
+	router := NewLimitedRouter(inner, 1)
+
+	done := make(chan error, 2)
+	for range 2 {
+		go func() {
+			_, err := router.CalculateRoutes(context.Background(), &RoutingRequest{})
+			done <- err
+		}()
+	}
+
+	<-inner.started
+	select {
+	case <-inner.started:
+		t.Fatal("second calculation started while the first was still running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	inner.release <- struct{}{}
+	select {
+	case <-inner.started:
+	case <-time.After(time.Second):
+		t.Fatal("second calculation did not start after the first finished")
+	}
+	inner.release <- struct{}{}
+	for range 2 {
+		if err := <-done; err != nil {
+			t.Fatalf("CalculateRoutes() error = %v", err)
+		}
+	}
+}
+
+func TestLimitedRouter_QueuedCalculationHonorsCancellation(t *testing.T) {
+	inner := blockingRouter{started: make(chan struct{}, 1), release: make(chan struct{})}
+	router := NewLimitedRouter(inner, 1)
+	go func() { _, _ = router.CalculateRoutes(context.Background(), &RoutingRequest{}) }()
+	<-inner.started
+	defer close(inner.release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := router.CalculateRoutes(ctx, &RoutingRequest{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("queued CalculateRoutes() error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestLimitedRouter_CandidatesShareCalculationSlots(t *testing.T) {
+	inner := blockingRouter{started: make(chan struct{}, 1), release: make(chan struct{})}
+	router := NewLimitedRouter(inner, 1)
+	go func() { _, _ = router.CalculateRoutes(context.Background(), &RoutingRequest{}) }()
+	<-inner.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := CalculateCandidates(ctx, router, &RoutingRequest{}, 3, RankByDistance); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("queued CalculateCandidates() error = %v, want deadline exceeded", err)
+	}
+
+	inner.release <- struct{}{}
+	done := make(chan error, 1)
+	go func() {
+		candidates, err := CalculateCandidates(context.Background(), router, &RoutingRequest{}, 3, RankByDistance)
+		if err == nil && len(candidates) != 1 {
+			err = errors.New("want the inner router's single solution")
+		}
+		done <- err
+	}()
+	<-inner.started
+	inner.release <- struct{}{}
+	if err := <-done; err != nil {
+		t.Fatalf("CalculateCandidates() error = %v", err)
+	}
+}
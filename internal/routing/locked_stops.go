@@ -0,0 +1,25 @@
+package routing
+
+import (
+	"ride-home-router/internal/models"
+	"slices"
+)
+
+// keepLockedOrder puts the locked participants back in the order the
+// coordinator left them, using the slots the optimizer gave them, so the
+// unlocked stops are still optimized around them. locked is in its original
+// route order.
+func keepLockedOrder(stops []*models.Participant, locked []*models.Participant) []*models.Participant {
+	if len(locked) < 2 {
+		return stops
+	}
+	result := slices.Clone(stops)
+	next := 0
+	for i, participant := range result {
+		if slices.Contains(locked, participant) {
+			result[i] = locked[next]
+			next++
+		}
+	}
+	return result
+}
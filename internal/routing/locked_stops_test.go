@@ -0,0 +1,51 @@
+package routing
+
+import (
+	"context"
+	"ride-home-router/internal/models"
+	"testing"
+)
+
+func TestKeepLockedOrderRestoresLockedSequenceInTheirSlots(t *testing.T) {
+	a, b, c, d := &models.Participant{ID: 1}, &models.Participant{ID: 2}, &models.Participant{ID: 3}, &models.Participant{ID: 4}
+
+	got := keepLockedOrder([]*models.Participant{d, c, b, a}, []*models.Participant{a, c})
+
+	want := []*models.Participant{d, a, b, c}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("stop %d = participant %d, want %d", i, got[i].ID, want[i].ID)
+		}
+	}
+}
+
+func TestOptimizeRouteOrder_KeepsLockedStopsInTheirOrder(t *testing.T) {
+	far := &models.Participant{ID: 1, Name: "Far", Lat: 3, Lng: 0}
+	near := &models.Participant{ID: 2, Name: "Near", Lat: 1, Lng: 0}
+	middle := &models.Participant{ID: 3, Name: "Middle", Lat: 2, Lng: 0}
+	route := &models.CalculatedRoute{
+		Driver: &models.Driver{ID: 1, Lat: 4, Lng: 0, VehicleCapacity: 3},
+		Stops: []models.RouteStop{
+			{Participant: far, Locked: true},
+			{Participant: middle},
+			{Participant: near, Locked: true},
+		},
+	}
+
+	if err := OptimizeRouteOrder(context.Background(), stableDistanceCalculator{}, models.Coordinates{}, RouteModeDropoff, route); err != nil {
+		t.Fatalf("OptimizeRouteOrder() error = %v", err)
+	}
+
+	var lockedOrder []int64
+	for _, stop := range route.Stops {
+		if stop.Locked {
+			lockedOrder = append(lockedOrder, stop.Participant.ID)
+		}
+	}
+	if len(lockedOrder) != 2 || lockedOrder[0] != far.ID || lockedOrder[1] != near.ID {
+		t.Fatalf("locked stop order = %v, want far before near as locked", lockedOrder)
+	}
+	if len(route.Stops) != 3 || route.Stops[1].Participant != middle || route.Stops[1].Locked {
+		t.Fatalf("stops = %#v, want the unlocked stop between the locked ones", route.Stops)
+	}
+}
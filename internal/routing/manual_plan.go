@@ -0,0 +1,90 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ride-home-router/internal/distance"
+	"ride-home-router/internal/models"
+)
+
+var ErrInvalidManualPlan = errors.New("invalid manual plan")
+
+// ManualRoute is one driver's riders in the order a coordinator chose.
+type ManualRoute struct {
+	DriverID       int64
+	ParticipantIDs []int64
+}
+
+// BuildManualPlan costs a coordinator's complete plan exactly as given: no
+// rider is moved and no stop is reordered. Every participant in req must ride
+// with exactly one of req's drivers, and no route may exceed its driver's
+// capacity; otherwise the error wraps ErrInvalidManualPlan. Routes keep the
+// plan's order, and drivers given no riders are left out.
+func BuildManualPlan(ctx context.Context, distanceCalc distance.DistanceCalculator, req *RoutingRequest, plan []ManualRoute) (*models.RoutingResult, error) {
+	drivers := make(map[int64]*models.Driver, len(req.Drivers))
+	for i := range req.Drivers {
+		drivers[req.Drivers[i].ID] = &req.Drivers[i]
+	}
+	participants := make(map[int64]*models.Participant, len(req.Participants))
+	for i := range req.Participants {
+		participants[req.Participants[i].ID] = &req.Participants[i]
+	}
+
+	seenDrivers := make(map[int64]bool, len(plan))
+	seenParticipants := make(map[int64]bool, len(req.Participants))
+	routes := make([]balancedRoute, 0, len(plan))
+	for _, manual := range plan {
+		driver, ok := drivers[manual.DriverID]
+		if !ok {
+			return nil, fmt.Errorf("%w: driver %d is not selected", ErrInvalidManualPlan, manual.DriverID)
+		}
+		if seenDrivers[driver.ID] {
+			return nil, fmt.Errorf("%w: %s has more than one route", ErrInvalidManualPlan, driver.Name)
+		}
+		seenDrivers[driver.ID] = true
+		if len(manual.ParticipantIDs) > driver.VehicleCapacity {
+			return nil, fmt.Errorf("%w: %s has %d riders for %d seats", ErrInvalidManualPlan, driver.Name, len(manual.ParticipantIDs), driver.VehicleCapacity)
+		}
+
+		stops := make([]*models.Participant, len(manual.ParticipantIDs))
+		for i, participantID := range manual.ParticipantIDs {
+			participant, ok := participants[participantID]
+			if !ok {
+				return nil, fmt.Errorf("%w: participant %d is not selected", ErrInvalidManualPlan, participantID)
+			}
+			if seenParticipants[participantID] {
+				return nil, fmt.Errorf("%w: %s is assigned more than once", ErrInvalidManualPlan, participant.Name)
+			}
+			seenParticipants[participantID] = true
+			stops[i] = participant
+		}
+		routes = append(routes, balancedRoute{driver: driver, stops: stops})
+	}
+	for _, participant := range req.Participants {
+		if !seenParticipants[participant.ID] {
+			return nil, fmt.Errorf("%w: %s has no driver", ErrInvalidManualPlan, participant.Name)
+		}
+	}
+
+	rc := newRequestRouteContext(distanceCalc, req)
+	result := &models.RoutingResult{
+		Routes:  make([]models.CalculatedRoute, 0, len(routes)),
+		Summary: models.RoutingSummary{TotalParticipants: len(req.Participants), UnassignedParticipants: []int64{}},
+		Mode:    rc.mode,
+	}
+	for _, route := range routes {
+		if len(route.stops) == 0 {
+			continue
+		}
+		calculated, err := rc.buildRoute(ctx, route.driver, route.stops)
+		if err != nil {
+			return nil, err
+		}
+		result.Routes = append(result.Routes, calculated)
+		result.Summary.TotalDriversUsed++
+		result.Summary.TotalDropoffDistanceMeters += calculated.TotalDropoffDistanceMeters
+		result.Summary.TotalDistanceMeters += calculated.TotalDistanceMeters
+	}
+	return result, nil
+}
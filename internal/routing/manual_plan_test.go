@@ -0,0 +1,66 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"math"
+	"ride-home-router/internal/models"
+	"testing"
+)
+
+func TestBuildManualPlan_CostsStopsInTheGivenOrder(t *testing.T) {
+	req := &RoutingRequest{
+		InstituteCoords: models.Coordinates{},
+		Participants: []models.Participant{
+			{ID: 1, Name: "Near", Lat: 1, Lng: 0},
+			{ID: 2, Name: "Corner", Lat: 1, Lng: 1},
+		},
+		Drivers: []models.Driver{
+			{ID: 1, Name: "Driver", Lat: 0, Lng: 2, VehicleCapacity: 2},
+			{ID: 2, Name: "Idle", Lat: 0, Lng: -2, VehicleCapacity: 2},
+		},
+		Mode: RouteModeDropoff,
+	}
+	plan := []ManualRoute{{DriverID: 1, ParticipantIDs: []int64{2, 1}}, {DriverID: 2}}
+
+	result, err := BuildManualPlan(context.Background(), stableDistanceCalculator{}, req, plan)
+	if err != nil {
+		t.Fatalf("BuildManualPlan() error = %v", err)
+	}
+	if len(result.Routes) != 1 || result.Summary.TotalDriversUsed != 1 || result.Summary.TotalParticipants != 2 {
+		t.Fatalf("result = %+v, want one used route carrying both riders", result)
+	}
+	route := result.Routes[0]
+	if route.Stops[0].Participant.ID != 2 || route.Stops[1].Participant.ID != 1 {
+		t.Fatalf("stops = %+v, want the coordinator's order kept", route.Stops)
+	}
+	// Corner first: 1414 to the corner, 1000 back down, then 2236 home.
+	wantTotal := math.Sqrt2*1000 + 1000 + math.Sqrt(5)*1000
+	if math.Abs(route.TotalDistanceMeters-wantTotal) > 1e-6 || math.Abs(result.Summary.TotalDistanceMeters-wantTotal) > 1e-6 {
+		t.Fatalf("total distance = %v (summary %v), want %v", route.TotalDistanceMeters, result.Summary.TotalDistanceMeters, wantTotal)
+	}
+	if math.Abs(route.Stops[1].CumulativeDurationSecs-(math.Sqrt2*1000+1000)) > 1e-6 {
+		t.Fatalf("second stop arrives at %v", route.Stops[1].CumulativeDurationSecs)
+	}
+	if math.Abs(route.DetourSecs-(wantTotal-2000)) > 1e-6 || route.BaselineDurationSecs != 2000 {
+		t.Fatalf("detour = %v baseline = %v, want %v over a 2000 direct trip", route.DetourSecs, route.BaselineDurationSecs, wantTotal-2000)
+	}
+}
+
+func TestBuildManualPlan_RejectsInvalidPlans(t *testing.T) {
+	req := &RoutingRequest{
+		Participants: []models.Participant{{ID: 1, Name: "A", Lat: 1}, {ID: 2, Name: "B", Lat: 2}},
+		Drivers:      []models.Driver{{ID: 1, Name: "Small", VehicleCapacity: 1}, {ID: 2, Name: "Other", VehicleCapacity: 1}},
+	}
+	for name, plan := range map[string][]ManualRoute{
+		"over capacity":       {{DriverID: 1, ParticipantIDs: []int64{1, 2}}},
+		"missing rider":       {{DriverID: 1, ParticipantIDs: []int64{1}}},
+		"rider twice":         {{DriverID: 1, ParticipantIDs: []int64{1}}, {DriverID: 2, ParticipantIDs: []int64{1}}},
+		"unknown driver":      {{DriverID: 9, ParticipantIDs: []int64{1, 2}}},
+		"unknown participant": {{DriverID: 1, ParticipantIDs: []int64{1}}, {DriverID: 2, ParticipantIDs: []int64{9}}},
+	} {
+		if _, err := BuildManualPlan(context.Background(), stableDistanceCalculator{}, req, plan); !errors.Is(err, ErrInvalidManualPlan) {
+			t.Fatalf("%s: error = %v, want ErrInvalidManualPlan", name, err)
+		}
+	}
+}
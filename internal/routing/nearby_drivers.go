@@ -0,0 +1,46 @@
+package routing
+
+import (
+	"cmp"
+	"ride-home-router/internal/models"
+	"slices"
+)
+
+// nearbyDriverRanks ranks drivers by the straight-line distance from their
+// home to the centroid of the participants, nearest first, with ties broken by
+// ID. Rank 0 is the nearest driver.
+func nearbyDriverRanks(participants []models.Participant, drivers []models.Driver) map[int64]int {
+	var centroid models.Coordinates
+	for i := range participants {
+		coords := participants[i].GetCoords()
+		centroid.Lat += coords.Lat
+		centroid.Lng += coords.Lng
+	}
+	if len(participants) > 0 {
+		centroid.Lat /= float64(len(participants))
+		centroid.Lng /= float64(len(participants))
+	}
+
+	distances := make(map[int64]float64, len(drivers))
+	ids := make([]int64, len(drivers))
+	for i := range drivers {
+		ids[i] = drivers[i].ID
+		distances[drivers[i].ID] = greatCircleMeters(drivers[i].GetCoords(), centroid)
+	}
+	slices.SortFunc(ids, func(a, b int64) int {
+		return cmp.Or(cmp.Compare(distances[a], distances[b]), cmp.Compare(a, b))
+	})
+
+	ranks := make(map[int64]int, len(ids))
+	for rank, id := range ids {
+		ranks[id] = rank
+	}
+	return ranks
+}
+
+// farthestNearbyRank is one past the rank of the farthest driver given riders,
+// so a plan using only nearer drivers scores lower. It is zero when no driver
+// is used.
+func (rc routeContext) farthestNearbyRank(driverID int64, current int) int {
+	return max(current, rc.nearbyDriverRanks[driverID]+1)
+}
@@ -0,0 +1,42 @@
+package routing
+
+import (
+	"context"
+	"ride-home-router/internal/models"
+	"testing"
+)
+
+func TestBalancedRouter_PreferNearbyDriversLeavesDistantDriverIdle(t *testing.T) {
+	request := func(preferNearby bool) *RoutingRequest {
+		return &RoutingRequest{
+			InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+			Participants: []models.Participant{
+				{ID: 1, Name: "North", Lat: 1, Lng: 0.1},
+				{ID: 2, Name: "South", Lat: 1, Lng: -0.1},
+			},
+			Drivers: []models.Driver{
+				{ID: 1, Name: "Distant", Lat: 0, Lng: -3, VehicleCapacity: 2},
+				{ID: 2, Name: "Nearby", Lat: 1.2, Lng: 0, VehicleCapacity: 2},
+			},
+			Mode:                RouteModeDropoff,
+			PreferNearbyDrivers: preferNearby,
+		}
+	}
+	router := NewBalancedRouter(stableDistanceCalculator{})
+
+	result, err := router.CalculateRoutes(context.Background(), request(false))
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+	if len(result.Routes) != 2 {
+		t.Fatalf("without the preference routes = %d, want both drivers used", len(result.Routes))
+	}
+
+	result, err = router.CalculateRoutes(context.Background(), request(true))
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+	if len(result.Routes) != 1 || result.Routes[0].Driver.ID != 2 || len(result.Routes[0].Stops) != 2 {
+		t.Fatalf("with the preference routes = %+v, want the nearby driver taking both riders", result.Routes)
+	}
+}
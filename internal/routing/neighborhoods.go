@@ -0,0 +1,43 @@
+package routing
+
+import "ride-home-router/internal/models"
+
+// neighborhoodRadiusMeters is how close, in a straight line, a stop must be to
+// another stop to count as the same neighborhood. Stops chain: two stops 2 km
+// apart share a neighborhood when a third stop sits between them.
+const neighborhoodRadiusMeters = 1500.0
+
+// countNeighborhoods returns how many distinct neighborhoods the stops fall
+// into, clustering them by single linkage within neighborhoodRadiusMeters.
+func countNeighborhoods(stops []*models.Participant) int {
+	seen := make([]bool, len(stops))
+	neighborhoods := 0
+	for i := range stops {
+		if seen[i] {
+			continue
+		}
+		neighborhoods++
+		seen[i] = true
+		queue := []int{i}
+		for len(queue) > 0 {
+			current := stops[queue[0]].GetCoords()
+			queue = queue[1:]
+			for j := range stops {
+				if !seen[j] && greatCircleMeters(current, stops[j].GetCoords()) <= neighborhoodRadiusMeters {
+					seen[j] = true
+					queue = append(queue, j)
+				}
+			}
+		}
+	}
+	return neighborhoods
+}
+
+// excessNeighborhoods is how many neighborhoods the stops visit beyond the
+// context's cap. It is zero when no cap is set.
+func (rc routeContext) excessNeighborhoods(stops []*models.Participant) int {
+	if rc.maxNeighborhoods <= 0 {
+		return 0
+	}
+	return max(0, countNeighborhoods(stops)-rc.maxNeighborhoods)
+}
@@ -0,0 +1,79 @@
+package routing
+
+import (
+	"context"
+	"ride-home-router/internal/models"
+	"testing"
+)
+
+func TestCountNeighborhoods_SeparatesDistantAreas(t *testing.T) {
+	stops := []*models.Participant{
+		{ID: 1, Lat: 41.300, Lng: -72.900},
+		{ID: 2, Lat: 41.305, Lng: -72.905},
+		{ID: 3, Lat: 41.310, Lng: -72.900},
+		{ID: 4, Lat: 41.500, Lng: -72.600},
+		{ID: 5, Lat: 41.502, Lng: -72.601},
+	}
+	if got := countNeighborhoods(stops); got != 2 {
+		t.Fatalf("countNeighborhoods() = %d, want 2", got)
+	}
+	if got := countNeighborhoods(stops[:3]); got != 1 {
+		t.Fatalf("countNeighborhoods(first area) = %d, want 1", got)
+	}
+	if got := countNeighborhoods(nil); got != 0 {
+		t.Fatalf("countNeighborhoods(nil) = %d, want 0", got)
+	}
+}
+
+func TestBalancedRouter_ReportsAndSoftlyCapsNeighborhoods(t *testing.T) {
+	router := NewBalancedRouter(stableDistanceCalculator{})
+	newRequest := func(maxNeighborhoods int) *RoutingRequest {
+		return &RoutingRequest{
+			InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+			Participants: []models.Participant{
+				{ID: 1, Name: "North A", Lat: 1, Lng: 0},
+				{ID: 2, Name: "North B", Lat: 1.005, Lng: 0},
+				{ID: 3, Name: "East A", Lat: 0, Lng: 1},
+				{ID: 4, Name: "East B", Lat: 0, Lng: 1.005},
+			},
+			Drivers: []models.Driver{
+				{ID: 1, Name: "First", Lat: 0.5, Lng: 0.5, VehicleCapacity: 2},
+				{ID: 2, Name: "Second", Lat: 0.5, Lng: 0.5, VehicleCapacity: 2},
+			},
+			Mode:             RouteModeDropoff,
+			MaxNeighborhoods: maxNeighborhoods,
+		}
+	}
+
+	result, err := router.CalculateRoutes(context.Background(), newRequest(1))
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+	for _, route := range result.Routes {
+		if route.Neighborhoods != 1 {
+			t.Fatalf("driver %d visits %d neighborhoods, want 1 under the cap", route.Driver.ID, route.Neighborhoods)
+		}
+	}
+
+	// Two seats per car and three riders up north force one car to cross
+	// over; the cap is soft, so routing still succeeds.
+	crowded := newRequest(1)
+	crowded.Participants[2] = models.Participant{ID: 3, Name: "North C", Lat: 1.002, Lng: 0.002}
+	result, err = router.CalculateRoutes(context.Background(), crowded)
+	if err != nil {
+		t.Fatalf("CalculateRoutes() over an unreachable cap error = %v", err)
+	}
+	if len(result.Summary.UnassignedParticipants) != 0 {
+		t.Fatalf("unassigned = %v, want every rider routed", result.Summary.UnassignedParticipants)
+	}
+
+	single := newRequest(0)
+	single.Drivers = []models.Driver{{ID: 1, Name: "Only", Lat: 0.5, Lng: 0.5, VehicleCapacity: 4}}
+	result, err = router.CalculateRoutes(context.Background(), single)
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+	if got := result.Routes[0].Neighborhoods; got != 2 {
+		t.Fatalf("single route neighborhoods = %d, want 2", got)
+	}
+}
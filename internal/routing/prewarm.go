@@ -11,13 +11,42 @@ func prewarmRoutingDistances(ctx context.Context, calc distance.DistanceCalculat
 	return distance.PrewarmRoutingPairs(ctx, calc, pairs)
 }
 
+// RequiredDistancePairs lists the directed pairs a solve of the request reads,
+// which is the set the router prewarms before solving.
+func RequiredDistancePairs(req *RoutingRequest) []distance.DistancePair {
+	mode := req.Mode
+	if mode == "" {
+		mode = RouteModeDropoff
+	}
+	return collectRoutingPrewarmPairs(mode, req.InstituteCoords, req.Participants, req.Drivers)
+}
+
+// ShareDistanceMatrix fetches one matrix over every point in the request and
+// sets it as the request's Distances, so repeated runs over these points, or a
+// subset of them, make no further provider calls.
+func ShareDistanceMatrix(ctx context.Context, calc distance.DistanceCalculator, req *RoutingRequest) error {
+	points := make([]models.Coordinates, 0, 1+len(req.Participants)+len(req.Drivers))
+	points = append(points, req.InstituteCoords)
+	for i := range req.Participants {
+		points = append(points, req.Participants[i].DropoffPoints()...)
+	}
+	for i := range req.Drivers {
+		points = append(points, req.Drivers[i].GetCoords())
+	}
+	matrix, err := distance.NewMatrixCalculator(ctx, calc, points)
+	if err != nil {
+		return err
+	}
+	req.Distances = matrix
+	return nil
+}
+
 func collectRoutingPrewarmPairs(mode RouteMode, institute models.Coordinates, participants []models.Participant, drivers []models.Driver) []distance.DistancePair {
 	seen := make(map[string]struct{})
 	pairs := make([]distance.DistancePair, 0)
 
 	addPair := func(origin, dest models.Coordinates) {
-		if models.RoundCoordinate(origin.Lat) == models.RoundCoordinate(dest.Lat) &&
-			models.RoundCoordinate(origin.Lng) == models.RoundCoordinate(dest.Lng) {
+		if models.SamePoint(origin, dest) {
 			return
 		}
 		key := distance.PairCacheKey(origin, dest)
@@ -36,6 +65,9 @@ func collectRoutingPrewarmPairs(mode RouteMode, institute models.Coordinates, pa
 	driverCoords := make([]models.Coordinates, len(drivers))
 	for i := range drivers {
 		driverCoords[i] = drivers[i].GetCoords()
+		if mode != RouteModePickup && drivers[i].ReturnToInstitute {
+			driverCoords[i] = institute
+		}
 	}
 
 	if mode == RouteModePickup {
@@ -74,6 +106,21 @@ func collectRoutingPrewarmPairs(mode RouteMode, institute models.Coordinates, pa
 	for _, driverCoord := range driverCoords {
 		addPair(institute, driverCoord)
 	}
+	// An intermediate stop is always reached from the activity or another
+	// stop and always followed by its own participant's stop.
+	for i := range participants {
+		if participants[i].IntermediateStop == nil {
+			continue
+		}
+		intermediate := *participants[i].IntermediateStop
+		addPair(institute, intermediate)
+		for j := range participantCoords {
+			if j != i {
+				addPair(participantCoords[j], intermediate)
+			}
+		}
+		addPair(intermediate, participantCoords[i])
+	}
 
 	return pairs
 }
@@ -163,3 +163,74 @@ func (c *cacheOnlyPrewarmCalculator) PrewarmCache(ctx context.Context, points []
 	c.prewarmCacheCalls++
 	return nil
 }
+
+// providerCallCounter counts every call that would reach the distance
+// provider.
+type providerCallCounter struct {
+	stableDistanceCalculator
+	matrixCalls int
+	otherCalls  int
+}
+
+func (c *providerCallCounter) GetDistance(ctx context.Context, origin, dest models.Coordinates) (*distance.DistanceResult, error) {
+	c.otherCalls++
+	return c.stableDistanceCalculator.GetDistance(ctx, origin, dest)
+}
+
+func (c *providerCallCounter) GetDistanceMatrix(ctx context.Context, points []models.Coordinates) ([][]distance.DistanceResult, error) {
+	c.matrixCalls++
+	return c.stableDistanceCalculator.GetDistanceMatrix(ctx, points)
+}
+
+func (c *providerCallCounter) PrewarmPairs(context.Context, []distance.DistancePair) error {
+	c.otherCalls++
+	return nil
+}
+
+func (c *providerCallCounter) PrewarmCache(context.Context, []models.Coordinates) error {
+	c.otherCalls++
+	return nil
+}
+
+func TestShareDistanceMatrix_FetchesOnceAcrossStrategyRuns(t *testing.T) {
+	provider := &providerCallCounter{}
+	router := NewBalancedRouter(provider).(*BalancedRouter)
+	req := &RoutingRequest{
+		InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+		Participants: []models.Participant{
+			{ID: 1, Name: "A", Lat: 1, Lng: 0},
+			{ID: 2, Name: "B", Lat: 2, Lng: 1},
+			{ID: 3, Name: "C", Lat: -1, Lng: 2},
+			{ID: 4, Name: "D", Lat: -2, Lng: -1},
+		},
+		Drivers: []models.Driver{
+			{ID: 1, Name: "One", Lat: 3, Lng: 0, VehicleCapacity: 2},
+			{ID: 2, Name: "Two", Lat: -3, Lng: 0, VehicleCapacity: 2},
+			{ID: 3, Name: "Three", Lat: 0, Lng: 3, VehicleCapacity: 1},
+		},
+		Mode: RouteModeDropoff,
+	}
+	if err := ShareDistanceMatrix(context.Background(), provider, req); err != nil {
+		t.Fatalf("ShareDistanceMatrix() error = %v", err)
+	}
+
+	byDistance := *req
+	byDistance.Metric = MetricDistance
+	pickup := *req
+	pickup.Mode = RouteModePickup
+	for _, run := range []*RoutingRequest{req, &byDistance, &pickup} {
+		if _, err := router.CalculateRoutes(context.Background(), run); err != nil {
+			t.Fatalf("CalculateRoutes() error = %v", err)
+		}
+	}
+	if _, err := router.CalculateCandidates(context.Background(), req, 3, RankByFairness); err != nil {
+		t.Fatalf("CalculateCandidates() error = %v", err)
+	}
+	if _, err := SuggestDrivers(context.Background(), router, req); err != nil {
+		t.Fatalf("SuggestDrivers() error = %v", err)
+	}
+
+	if provider.matrixCalls != 1 || provider.otherCalls != 0 {
+		t.Fatalf("provider calls: matrix=%d other=%d, want one matrix fetch and nothing else", provider.matrixCalls, provider.otherCalls)
+	}
+}
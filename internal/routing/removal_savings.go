@@ -0,0 +1,93 @@
+package routing
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"ride-home-router/internal/distance"
+	"ride-home-router/internal/models"
+	"slices"
+)
+
+// RemovalSaving is what the plan saves if one participant is taken off their
+// route, say because a parent picks them up. The rest of the route keeps its
+// order.
+type RemovalSaving struct {
+	Participant *models.Participant
+	RouteIndex  int
+	// DistanceMeters is the driven distance saved beyond the driver's own
+	// trip home or to the activity.
+	DistanceMeters float64
+	// DetourSecs is the route's detour saved and MaxDetourSecs how much the
+	// plan's largest detour shrinks.
+	DetourSecs    float64
+	MaxDetourSecs float64
+}
+
+// RemovalSavings evaluates removing each participant from routes in turn and
+// ranks them by distance saved, then by how much the plan's largest detour
+// shrinks. The first entry is the costliest participant to serve.
+func RemovalSavings(ctx context.Context, distanceCalc distance.DistanceCalculator, instituteCoords models.Coordinates, mode RouteMode, routes []models.CalculatedRoute) ([]RemovalSaving, error) {
+	rc := newRouteContext(distanceCalc, instituteCoords, mode)
+
+	before := make([]*routeMetrics, len(routes))
+	routeStops := make([][]*models.Participant, len(routes))
+	for i, route := range routes {
+		if route.Driver == nil {
+			return nil, fmt.Errorf("route %d driver is required", i)
+		}
+		routeStops[i] = make([]*models.Participant, len(route.Stops))
+		for j := range route.Stops {
+			routeStops[i][j] = route.Stops[j].Participant
+		}
+		metrics, err := rc.evaluateParticipants(ctx, route.Driver, routeStops[i])
+		if err != nil {
+			return nil, err
+		}
+		before[i] = metrics
+	}
+	maxDetour := func(skip int, replacement float64) float64 {
+		largest := 0.0
+		for i, metrics := range before {
+			detour := metrics.DetourSecs
+			if i == skip {
+				detour = replacement
+			}
+			largest = max(largest, detour)
+		}
+		return largest
+	}
+	currentMax := maxDetour(-1, 0)
+
+	savings := make([]RemovalSaving, 0)
+	for i, route := range routes {
+		for j, stop := range routeStops[i] {
+			after, err := rc.evaluateParticipants(ctx, route.Driver, removeRange(routeStops[i], j, j+1))
+			if err != nil {
+				return nil, err
+			}
+			savings = append(savings, RemovalSaving{
+				Participant:    stop,
+				RouteIndex:     i,
+				DistanceMeters: extraDistance(before[i]) - extraDistance(after),
+				DetourSecs:     before[i].DetourSecs - after.DetourSecs,
+				MaxDetourSecs:  currentMax - maxDetour(i, after.DetourSecs),
+			})
+		}
+	}
+
+	slices.SortStableFunc(savings, func(a, b RemovalSaving) int {
+		return cmp.Or(
+			cmp.Compare(b.DistanceMeters, a.DistanceMeters),
+			cmp.Compare(b.MaxDetourSecs, a.MaxDetourSecs),
+			cmp.Compare(a.Participant.ID, b.Participant.ID),
+		)
+	})
+	return savings, nil
+}
+
+// extraDistance is how much farther the route drives than the direct trip
+// its driver makes anyway.
+func extraDistance(metrics *routeMetrics) float64 {
+	return max(0, metrics.TotalDistanceMeters-metrics.BaselineDistanceMeters)
+}
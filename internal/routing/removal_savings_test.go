@@ -0,0 +1,47 @@
+package routing
+
+import (
+	"context"
+	"math"
+	"ride-home-router/internal/models"
+	"testing"
+)
+
+func TestRemovalSavings_RanksCostliestParticipantFirst(t *testing.T) {
+	east := &models.Driver{ID: 1, Name: "East", Lat: 0, Lng: 1, VehicleCapacity: 3}
+	north := &models.Driver{ID: 2, Name: "North", Lat: 1, Lng: 0, VehicleCapacity: 3}
+	routes := []models.CalculatedRoute{
+		{Driver: east, Stops: []models.RouteStop{
+			{Participant: &models.Participant{ID: 1, Name: "OnTheWay", Lat: 0, Lng: 0.3}},
+			{Participant: &models.Participant{ID: 2, Name: "FarOff", Lat: 0.4, Lng: 0.5}},
+			{Participant: &models.Participant{ID: 3, Name: "NearHome", Lat: 0, Lng: 0.7}},
+		}},
+		{Driver: north, Stops: []models.RouteStop{
+			{Participant: &models.Participant{ID: 4, Name: "Neighbor", Lat: 0.5, Lng: 0.05}},
+		}},
+	}
+
+	savings, err := RemovalSavings(context.Background(), stableDistanceCalculator{}, models.Coordinates{}, RouteModeDropoff, routes)
+	if err != nil {
+		t.Fatalf("RemovalSavings() error = %v", err)
+	}
+	if len(savings) != 4 {
+		t.Fatalf("savings = %+v, want one entry per participant", savings)
+	}
+	first := savings[0]
+	if first.Participant.Name != "FarOff" || first.RouteIndex != 0 {
+		t.Fatalf("costliest = %s on route %d, want FarOff on route 0", first.Participant.Name, first.RouteIndex)
+	}
+	// Dropping FarOff straightens East's route to the direct trip home, and
+	// North's small detour becomes the largest one.
+	detour := 2*math.Hypot(0.4, 0.2)*1000 - 400
+	northDetour := 2*math.Hypot(0.5, 0.05)*1000 - 1000
+	if math.Abs(first.DistanceMeters-detour) > 1e-6 || math.Abs(first.MaxDetourSecs-(detour-northDetour)) > 1e-6 {
+		t.Fatalf("FarOff saving = %+v, want %.1f of distance and %.1f off the largest detour", first, detour, detour-northDetour)
+	}
+	for _, saving := range savings[1:] {
+		if saving.DistanceMeters > first.DistanceMeters {
+			t.Fatalf("savings not ranked: %+v", savings)
+		}
+	}
+}
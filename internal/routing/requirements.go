@@ -0,0 +1,60 @@
+package routing
+
+import "ride-home-router/internal/models"
+
+// groupSatisfiedBy reports whether every member of the group may ride with
+// the driver under their attribute requirements, accessibility needs and the
+// driver's exclusions.
+func groupSatisfiedBy(driver *models.Driver, group *participantGroup) bool {
+	for _, participant := range group.members {
+		if !driver.CanCarry(participant) {
+			return false
+		}
+	}
+	return true
+}
+
+// assignmentPreservesRequirementFeasibility rejects an assignment that would
+// leave a remaining participant with attribute requirements, needing an
+// accessible vehicle or excluded by some driver, without any compatible driver
+// that still has a free seat. The first assignedCount members of the assigned
+// group are the ones being placed.
+func assignmentPreservesRequirementFeasibility(routes map[int64]*balancedRoute, currentDriverID int64, groups []*participantGroup, assignedGroupIndex, assignedCount int) bool {
+	for groupIdx, group := range groups {
+		members := group.members
+		if groupIdx == assignedGroupIndex {
+			members = members[min(assignedCount, len(members)):]
+		}
+		for _, participant := range members {
+			if len(participant.RequiredMatches) == 0 && !participant.RequiresAccessible && !excludedByAnyDriver(routes, participant) {
+				continue
+			}
+			if !hasCompatibleSeat(routes, currentDriverID, assignedCount, participant) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func hasCompatibleSeat(routes map[int64]*balancedRoute, currentDriverID int64, assignedCount int, participant *models.Participant) bool {
+	for driverID, route := range routes {
+		capacity := route.driver.VehicleCapacity - len(route.stops)
+		if driverID == currentDriverID {
+			capacity -= assignedCount
+		}
+		if capacity > 0 && route.driver.CanCarry(participant) {
+			return true
+		}
+	}
+	return false
+}
+
+func excludedByAnyDriver(routes map[int64]*balancedRoute, participant *models.Participant) bool {
+	for _, route := range routes {
+		if route.driver.Excludes(participant) {
+			return true
+		}
+	}
+	return false
+}
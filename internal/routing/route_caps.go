@@ -0,0 +1,49 @@
+package routing
+
+import "ride-home-router/internal/models"
+
+// RouteCaps are the request-wide limits each route's cap compliance is
+// reported against. Zero means no cap.
+type RouteCaps struct {
+	MaxDetourSecs float64
+	MaxRideSecs   float64
+}
+
+// RequestCaps returns the caps a request routes under.
+func RequestCaps(req *RoutingRequest) RouteCaps {
+	return RouteCaps{MaxDetourSecs: req.MaxDetourSecs, MaxRideSecs: req.MaxParticipantRideSecs}
+}
+
+// Apply sets route's cap compliance fields from its current metrics. The
+// duration cap comes from the route's driver rather than the request.
+func (c RouteCaps) Apply(route *models.CalculatedRoute) {
+	route.ExceedsDetourCap, route.DetourSecsUnderCap = false, 0
+	if c.MaxDetourSecs > 0 {
+		route.DetourSecsUnderCap = c.MaxDetourSecs - route.DetourSecs
+		route.ExceedsDetourCap = route.DetourSecsUnderCap < 0
+	}
+	route.ExceedsRideCap, route.RideSecsUnderCap = false, 0
+	if c.MaxRideSecs > 0 {
+		route.RideSecsUnderCap = c.MaxRideSecs - longestRideSecs(route)
+		route.ExceedsRideCap = route.RideSecsUnderCap < 0
+	}
+	route.ExceedsDurationCap, route.DurationSecsUnderCap = false, 0
+	if route.Driver != nil && route.Driver.MaxRouteDurationSecs > 0 {
+		route.DurationSecsUnderCap = route.Driver.MaxRouteDurationSecs - route.RouteDurationSecs
+		route.ExceedsDurationCap = route.DurationSecsUnderCap < 0
+	}
+}
+
+// longestRideSecs returns the longest time any one participant spends in the
+// car on route, from its populated stop metrics.
+func longestRideSecs(route *models.CalculatedRoute) float64 {
+	longest := 0.0
+	for _, stop := range route.Stops {
+		ride := stop.CumulativeDurationSecs
+		if route.Mode == RouteModePickup {
+			ride = route.RouteDurationSecs - stop.CumulativeDurationSecs
+		}
+		longest = max(longest, ride)
+	}
+	return longest
+}
@@ -0,0 +1,87 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"math"
+	"ride-home-router/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestBalancedRouter_ReportsCapMarginsPerRoute(t *testing.T) {
+	router := NewBalancedRouter(stableDistanceCalculator{})
+	// The rider sits just off the driver's way home, so the route's detour
+	// is about 20 seconds and the rider's ride about 510.
+	req := &RoutingRequest{
+		InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+		Participants:    []models.Participant{{ID: 1, Name: "Rider", Lat: 0.1, Lng: 0.5}},
+		Drivers:         []models.Driver{{ID: 1, Name: "Driver", Lat: 0, Lng: 1, VehicleCapacity: 2}},
+		Mode:            RouteModeDropoff,
+		MaxDetourSecs:   30,
+	}
+	ride := math.Hypot(0.1, 0.5) * 1000
+	detour := 2*ride - 1000
+
+	result, err := router.CalculateRoutes(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+	route := result.Routes[0]
+	if route.ExceedsDetourCap || math.Abs(route.DetourSecsUnderCap-(30-detour)) > 1e-6 {
+		t.Fatalf("detour margin = %.2f exceeds=%v, want %.2f within the cap", route.DetourSecsUnderCap, route.ExceedsDetourCap, 30-detour)
+	}
+	if route.ExceedsRideCap || route.RideSecsUnderCap != 0 {
+		t.Fatalf("ride margin = %.2f exceeds=%v, want none without a ride cap", route.RideSecsUnderCap, route.ExceedsRideCap)
+	}
+
+	RouteCaps{MaxDetourSecs: 15, MaxRideSecs: 600}.Apply(&route)
+	if !route.ExceedsDetourCap || route.DetourSecsUnderCap >= 0 {
+		t.Fatalf("detour margin = %.2f exceeds=%v, want over a 15 second cap", route.DetourSecsUnderCap, route.ExceedsDetourCap)
+	}
+	if route.ExceedsRideCap || math.Abs(route.RideSecsUnderCap-(600-ride)) > 1e-6 {
+		t.Fatalf("ride margin = %.2f, want %.2f", route.RideSecsUnderCap, 600-ride)
+	}
+}
+
+func TestBalancedRouter_DriverRouteDurationCapIsHard(t *testing.T) {
+	newRequest := func(drivers ...models.Driver) *RoutingRequest {
+		return &RoutingRequest{
+			InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+			Participants: []models.Participant{
+				{ID: 1, Name: "On The Way", Lat: 1, Lng: 0},
+				{ID: 2, Name: "Off To The Side", Lat: 1, Lng: 1},
+			},
+			Drivers: drivers,
+			Mode:    RouteModeDropoff,
+		}
+	}
+	router := NewBalancedRouter(stableDistanceCalculator{})
+	// Alone with the first rider the near driver's route takes 2000s; any
+	// route through the second rider takes at least 2828s.
+	near := models.Driver{ID: 1, Name: "Near", Lat: 2, Lng: 0, VehicleCapacity: 2, MaxRouteDurationSecs: 2500}
+	far := models.Driver{ID: 2, Name: "Far", Lat: 0, Lng: 2, VehicleCapacity: 2}
+
+	result, err := router.CalculateRoutes(context.Background(), newRequest(near, far))
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+	for _, route := range result.Routes {
+		if route.Driver.ID != near.ID {
+			continue
+		}
+		if route.RouteDurationSecs > near.MaxRouteDurationSecs || route.ExceedsDurationCap {
+			t.Fatalf("near route takes %.0fs, want at most %.0fs", route.RouteDurationSecs, near.MaxRouteDurationSecs)
+		}
+		if math.Abs(route.DurationSecsUnderCap-(near.MaxRouteDurationSecs-route.RouteDurationSecs)) > 1e-6 {
+			t.Fatalf("duration margin = %.2f, want the cap minus the route duration", route.DurationSecsUnderCap)
+		}
+	}
+
+	near.MaxRouteDurationSecs = 1500
+	_, err = router.CalculateRoutes(context.Background(), newRequest(near))
+	var routingErr *ErrRoutingFailed
+	if !errors.As(err, &routingErr) || !strings.Contains(routingErr.Reason, "Near (25 minutes)") {
+		t.Fatalf("CalculateRoutes() with an impossible cap error = %v, want ErrRoutingFailed naming Near", err)
+	}
+}
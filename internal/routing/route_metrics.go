@@ -2,15 +2,39 @@ package routing
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"ride-home-router/internal/distance"
 	"ride-home-router/internal/models"
+	"slices"
 )
 
 type routeContext struct {
-	distanceCalc    distance.DistanceCalculator
-	instituteCoords models.Coordinates
-	mode            RouteMode
+	distanceCalc     distance.DistanceCalculator
+	instituteCoords  models.Coordinates
+	mode             RouteMode
+	metric           OptimizationMetric
+	pickupObjective  PickupObjective
+	maxRideSecs      float64
+	maxDetourSecs    float64
+	maxNeighborhoods int
+	youngestFirst    bool
+	householdSplit   HouseholdSplit
+	detourFairness   DetourFairness
+	excludeHomeLeg   bool
+	fairnessWeights  FairnessWeights
+	// recentDriverRides is set only when drivers are rotated.
+	recentDriverRides map[int64]int
+	// nearbyDriverRanks is set only when nearby drivers are preferred.
+	nearbyDriverRanks map[int64]int
+	// maxInsertionPositions limits how many positions each insertion
+	// evaluates; zero evaluates them all.
+	maxInsertionPositions int
+	// householdPrecision is the request's household grouping precision;
+	// see householdDecimals.
+	householdPrecision int
+	// includeGeometry fills built routes' stop geometry.
+	includeGeometry bool
 }
 
 type routeStopMetric struct {
@@ -29,7 +53,9 @@ type routeMetrics struct {
 	FinalLegDurationSecs    float64
 	RouteDurationSecs       float64
 	BaselineDurationSecs    float64
+	BaselineDistanceMeters  float64
 	DetourSecs              float64
+	DetourMeters            float64
 }
 
 func newRouteContext(distanceCalc distance.DistanceCalculator, instituteCoords models.Coordinates, mode RouteMode) routeContext {
@@ -44,6 +70,98 @@ func newRouteContext(distanceCalc distance.DistanceCalculator, instituteCoords m
 	}
 }
 
+// newRequestRouteContext applies a request's metric, pickup objective,
+// ride-time cap, detour ceiling, neighborhood cap, household split and
+// grouping precision, detour fairness, home-leg exclusion, fairness weights,
+// driver rotation, the nearby-driver preference and stop geometry.
+func newRequestRouteContext(distanceCalc distance.DistanceCalculator, req *RoutingRequest) routeContext {
+	rc := newRouteContext(distanceCalc, req.InstituteCoords, req.Mode)
+	rc.metric = req.Metric
+	rc.pickupObjective = req.PickupObjective
+	rc.maxRideSecs = req.MaxParticipantRideSecs
+	rc.maxDetourSecs = req.MaxDetourSecs
+	rc.maxNeighborhoods = req.MaxNeighborhoods
+	rc.youngestFirst = req.YoungestDroppedFirst
+	rc.householdSplit = req.HouseholdSplit
+	rc.householdPrecision = req.HouseholdPrecision
+	rc.includeGeometry = req.IncludeGeometry
+	rc.detourFairness = req.DetourFairness
+	rc.excludeHomeLeg = req.ExcludeHomeLeg
+	rc.fairnessWeights = req.FairnessWeights
+	if req.RotateDrivers {
+		rc.recentDriverRides = req.RecentDriverRides
+		if rc.recentDriverRides == nil {
+			rc.recentDriverRides = map[int64]int{}
+		}
+	}
+	if req.PreferNearbyDrivers {
+		rc.nearbyDriverRanks = nearbyDriverRanks(req.Participants, req.Drivers)
+	}
+	rc.maxInsertionPositions = req.MaxInsertionPositions
+	return rc
+}
+
+// legCost returns the cost of one leg under the context's optimization metric.
+func (rc routeContext) legCost(dist *distance.DistanceResult) float64 {
+	if rc.metric == MetricDistance {
+		return dist.DistanceMeters
+	}
+	return dist.DurationSecs
+}
+
+// stopCost returns a stop's cumulative cost under the optimization metric.
+func (rc routeContext) stopCost(stop routeStopMetric) float64 {
+	if rc.metric == MetricDistance {
+		return stop.CumulativeDistanceMeters
+	}
+	return stop.CumulativeDurationSecs
+}
+
+// routeCosts returns the full route cost and its detour over the direct trip
+// under the optimization metric.
+func (rc routeContext) routeCosts(metrics *routeMetrics) (total, detour float64) {
+	if rc.metric == MetricDistance {
+		return metrics.TotalDistanceMeters, metrics.DetourMeters
+	}
+	return metrics.RouteDurationSecs, metrics.DetourSecs
+}
+
+// Direct trips shorter than these floors count as the floor when a detour is
+// measured against them, so a driver who lives next to the activity does not
+// turn every stop into an unbounded ratio.
+const (
+	minDetourRatioBaselineSecs   = 300.0
+	minDetourRatioBaselineMeters = 5000.0
+)
+
+// detourRatio returns detour as a share of the route's direct trip under the
+// optimization metric.
+func (rc routeContext) detourRatio(metrics *routeMetrics, detour float64) float64 {
+	if rc.metric == MetricDistance {
+		return detour / max(metrics.BaselineDistanceMeters, minDetourRatioBaselineMeters)
+	}
+	return detour / max(metrics.BaselineDurationSecs, minDetourRatioBaselineSecs)
+}
+
+// homeLegCost returns the cost of the leg between the driver's home and the
+// route under the optimization metric: the drive home after the last dropoff,
+// or the drive from home to the first pickup.
+func (rc routeContext) homeLegCost(metrics *routeMetrics) float64 {
+	if rc.mode == RouteModePickup {
+		if len(metrics.Stops) == 0 {
+			return 0
+		}
+		if rc.metric == MetricDistance {
+			return metrics.Stops[0].DistanceFromPrevMeters
+		}
+		return metrics.Stops[0].DurationFromPrevSecs
+	}
+	if rc.metric == MetricDistance {
+		return metrics.FinalLegDistanceMeters
+	}
+	return metrics.FinalLegDurationSecs
+}
+
 func (rc routeContext) origin(driver *models.Driver) models.Coordinates {
 	if rc.mode == RouteModePickup {
 		return driver.GetCoords()
@@ -52,7 +170,7 @@ func (rc routeContext) origin(driver *models.Driver) models.Coordinates {
 }
 
 func (rc routeContext) destination(driver *models.Driver) models.Coordinates {
-	if rc.mode == RouteModePickup {
+	if rc.mode == RouteModePickup || driver.ReturnToInstitute {
 		return rc.instituteCoords
 	}
 	return driver.GetCoords()
@@ -74,11 +192,15 @@ func (rc routeContext) riderScore(ctx context.Context, driver *models.Driver, st
 			return 0, fmt.Errorf("route stop %d is missing participant data", i)
 		}
 
-		dist, err := rc.distanceCalc.GetDistance(ctx, prev, stop.GetCoords())
+		dist, err := rc.driveTo(ctx, prev, stops, i)
 		if err != nil {
 			return 0, err
 		}
-		cumulative += dist.DurationSecs
+		// Pickup riders wait on the drive from the driver's home only when
+		// that leg is part of the objective.
+		if i > 0 || rc.mode != RouteModePickup || !rc.excludeHomeLeg {
+			cumulative += rc.legCost(dist)
+		}
 		total += cumulative
 		prev = stop.GetCoords()
 	}
@@ -108,7 +230,7 @@ func (rc routeContext) evaluateParticipants(ctx context.Context, driver *models.
 			return nil, fmt.Errorf("route stop %d is missing participant data", i)
 		}
 
-		dist, err := rc.distanceCalc.GetDistance(ctx, prev, stop.GetCoords())
+		dist, err := rc.driveTo(ctx, prev, stops, i)
 		if err != nil {
 			return nil, err
 		}
@@ -138,11 +260,62 @@ func (rc routeContext) evaluateParticipants(ctx context.Context, driver *models.
 	metrics.TotalDistanceMeters = metrics.TotalStopDistanceMeters + finalLeg.DistanceMeters
 	metrics.RouteDurationSecs = metrics.TotalStopDurationSecs + finalLeg.DurationSecs
 	metrics.BaselineDurationSecs = baseline.DurationSecs
-	metrics.DetourSecs = metrics.RouteDurationSecs - baseline.DurationSecs
+	metrics.BaselineDistanceMeters = baseline.DistanceMeters
+	// Road data can make the direct trip slower than a route through stops;
+	// that is no detour rather than a negative one.
+	metrics.DetourSecs = max(0, metrics.RouteDurationSecs-baseline.DurationSecs)
+	metrics.DetourMeters = max(0, metrics.TotalDistanceMeters-baseline.DistanceMeters)
 
 	return metrics, nil
 }
 
+// withinTimeLimits reports whether every participant's time in the car stays
+// under the context's ride cap, every stop is reached inside its
+// participant's time window and the whole route stays under the driver's
+// duration cap. Dropoff riders board at the activity and ride until their
+// stop; pickup riders board at their stop and ride until the activity.
+func (rc routeContext) withinTimeLimits(ctx context.Context, driver *models.Driver, stops []*models.Participant) (bool, error) {
+	if len(stops) == 0 || rc.maxRideSecs <= 0 && driver.MaxRouteDurationSecs <= 0 && !slices.ContainsFunc(stops, (*models.Participant).HasTimeWindow) {
+		return true, nil
+	}
+	metrics, err := rc.evaluateParticipants(ctx, driver, stops)
+	if err != nil {
+		return false, err
+	}
+	if rc.maxRideSecs > 0 && metrics.maxRideSecs(rc.mode) > rc.maxRideSecs {
+		return false, nil
+	}
+	if driver.MaxRouteDurationSecs > 0 && metrics.RouteDurationSecs > driver.MaxRouteDurationSecs {
+		return false, nil
+	}
+	for i, stop := range stops {
+		if !stop.WithinTimeWindow(metrics.Stops[i].CumulativeDurationSecs) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// maxRideSecs returns the longest single participant ride on the route.
+func (m *routeMetrics) maxRideSecs(mode RouteMode) float64 {
+	longest := 0.0
+	for _, stop := range m.Stops {
+		ride := stop.CumulativeDurationSecs
+		if mode == RouteModePickup {
+			ride = m.RouteDurationSecs - stop.CumulativeDurationSecs
+		}
+		longest = max(longest, ride)
+	}
+	return longest
+}
+
+// groupInsertionDeltaRiderScore is the cost of inserting group at pos: the
+// change in the summed cost of reaching every stop. Mid-route, that is the new
+// riders' own cost plus the detour pushed onto every later stop. At the end of
+// the route it is the new riders' cost alone, which already includes the whole
+// drive to them. The leg from the last stop to the route's destination is left
+// out in both cases because it reaches no stop, so a tail insertion is not
+// discounted against a mid-route one for skipping it.
 func (rc routeContext) groupInsertionDeltaRiderScore(ctx context.Context, driver *models.Driver, stops []*models.Participant, group *participantGroup, pos int) (float64, error) {
 	before, err := rc.riderScore(ctx, driver, stops)
 	if err != nil {
@@ -198,6 +371,8 @@ func PopulateRouteMetrics(ctx context.Context, distanceCalc distance.DistanceCal
 	route.BaselineDurationSecs = metrics.BaselineDurationSecs
 	route.RouteDurationSecs = metrics.RouteDurationSecs
 	route.DetourSecs = metrics.DetourSecs
+	route.DetourMeters = metrics.DetourMeters
+	route.Neighborhoods = countNeighborhoods(participants)
 	route.Mode = rc.mode
 	if route.EffectiveCapacity == 0 && route.Driver != nil {
 		route.EffectiveCapacity = route.Driver.VehicleCapacity
@@ -206,9 +381,40 @@ func PopulateRouteMetrics(ctx context.Context, distanceCalc distance.DistanceCal
 	return nil
 }
 
+// ErrRouteOrderOutsideLimits is returned by OptimizeRouteOrderFor when the
+// reordered route breaks the ride cap, a time window or the driver's duration cap.
+var ErrRouteOrderOutsideLimits = errors.New("route order breaks a time limit")
+
 // OptimizeRouteOrder reorders one calculated route using the participant-first
-// lexicographic objective, then refreshes its displayed metrics.
+// lexicographic objective, then refreshes its displayed metrics. Locked stops
+// keep their order relative to each other; see keepLockedOrder.
 func OptimizeRouteOrder(ctx context.Context, distanceCalc distance.DistanceCalculator, instituteCoords models.Coordinates, mode RouteMode, route *models.CalculatedRoute) error {
+	return optimizeRouteOrder(ctx, newRouteContext(distanceCalc, instituteCoords, mode), route)
+}
+
+// OptimizeRouteOrderFor reorders a route like OptimizeRouteOrder under the
+// request's metric, objectives and caps, and returns
+// ErrRouteOrderOutsideLimits when the new order breaks one of its time limits.
+func OptimizeRouteOrderFor(ctx context.Context, distanceCalc distance.DistanceCalculator, req *RoutingRequest, route *models.CalculatedRoute) error {
+	rc := newRequestRouteContext(distanceCalc, req)
+	if err := optimizeRouteOrder(ctx, rc, route); err != nil {
+		return err
+	}
+	stops := make([]*models.Participant, len(route.Stops))
+	for i := range route.Stops {
+		stops[i] = route.Stops[i].Participant
+	}
+	within, err := rc.withinTimeLimits(ctx, route.Driver, stops)
+	if err != nil {
+		return err
+	}
+	if !within {
+		return ErrRouteOrderOutsideLimits
+	}
+	return nil
+}
+
+func optimizeRouteOrder(ctx context.Context, rc routeContext, route *models.CalculatedRoute) error {
 	if route == nil {
 		return fmt.Errorf("route is required")
 	}
@@ -216,10 +422,13 @@ func OptimizeRouteOrder(ctx context.Context, distanceCalc distance.DistanceCalcu
 		return fmt.Errorf("route driver is required")
 	}
 
-	rc := newRouteContext(distanceCalc, instituteCoords, mode)
 	participants := make([]*models.Participant, len(route.Stops))
+	var locked []*models.Participant
 	for i := range route.Stops {
 		participants[i] = route.Stops[i].Participant
+		if route.Stops[i].Locked {
+			locked = append(locked, route.Stops[i].Participant)
+		}
 	}
 
 	driverID := route.Driver.ID
@@ -229,16 +438,17 @@ func OptimizeRouteOrder(ctx context.Context, distanceCalc distance.DistanceCalcu
 			stops:  participants,
 		},
 	}
-	router := &BalancedRouter{distanceCalc: distanceCalc}
+	router := &BalancedRouter{distanceCalc: rc.distanceCalc}
 	if err := router.optimizeRouteOrders(ctx, rc, routes, []int64{driverID}); err != nil {
 		return err
 	}
-	optimized := routes[driverID].stops
+	optimized := keepLockedOrder(routes[driverID].stops, locked)
 
 	route.Stops = make([]models.RouteStop, len(optimized))
 	for i, participant := range optimized {
 		route.Stops[i].Participant = participant
+		route.Stops[i].Locked = slices.Contains(locked, participant)
 	}
 
-	return PopulateRouteMetrics(ctx, distanceCalc, instituteCoords, mode, route)
+	return PopulateRouteMetrics(ctx, rc.distanceCalc, rc.instituteCoords, rc.mode, route)
 }
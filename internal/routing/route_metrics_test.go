@@ -50,6 +50,10 @@ func (stableDistanceCalculator) PrewarmCache(ctx context.Context, points []model
 	return nil
 }
 
+func (stableDistanceCalculator) GetRouteGeometry(context.Context, []models.Coordinates) (string, error) {
+	return "", distance.ErrRouteGeometryUnavailable
+}
+
 func TestRouteContextRiderScoreWeightsCumulativeStopTimes(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -140,6 +144,45 @@ func TestGroupInsertionDeltaRiderScorePrefersEarlierPickup(t *testing.T) {
 	}
 }
 
+func TestGroupInsertionDeltaRiderScore_EndAndMidRouteCosts(t *testing.T) {
+	rc := newRouteContext(stableDistanceCalculator{}, models.Coordinates{Lat: 0, Lng: 0}, RouteModeDropoff)
+	driver := &models.Driver{ID: 1, Name: "Driver", Lat: 0, Lng: 10, VehicleCapacity: 3}
+	stops := []*models.Participant{
+		{ID: 1, Name: "Two", Lat: 0, Lng: 2},
+		{ID: 2, Name: "Four", Lat: 0, Lng: 4},
+	}
+
+	tests := []struct {
+		name    string
+		lng     float64
+		midCost float64
+		endCost float64
+	}{
+		// Between the stops the new rider is on the way to "Four", costing
+		// only their own 3000, against 5000 to reach them at the end.
+		{name: "between stops", lng: 3, midCost: 3000, endCost: 5000},
+		// Past the last stop: the tail costs the new rider's 6000, while
+		// mid-route adds 6000 for them and 4000 for "Four".
+		{name: "past last stop", lng: 6, midCost: 10000, endCost: 6000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := newParticipantGroup(&models.Participant{ID: 3, Name: "New", Lat: 0, Lng: tt.lng})
+			mid, err := rc.groupInsertionDeltaRiderScore(context.Background(), driver, stops, group, 1)
+			if err != nil {
+				t.Fatalf("mid delta error = %v", err)
+			}
+			end, err := rc.groupInsertionDeltaRiderScore(context.Background(), driver, stops, group, 2)
+			if err != nil {
+				t.Fatalf("end delta error = %v", err)
+			}
+			if math.Abs(mid-tt.midCost) > 1e-6 || math.Abs(end-tt.endCost) > 1e-6 {
+				t.Fatalf("mid, end = %.0f, %.0f, want %.0f, %.0f", mid, end, tt.midCost, tt.endCost)
+			}
+		})
+	}
+}
+
 func TestPopulateRouteMetrics_PickupIncludesActivityDestination(t *testing.T) {
 	route := &models.CalculatedRoute{
 		Driver: &models.Driver{ID: 1, Name: "Driver", Lat: 10, Lng: 0, VehicleCapacity: 4},
@@ -395,3 +438,59 @@ func TestBalancedRouterConcurrentMixedModes(t *testing.T) {
 		}
 	}
 }
+
+func TestDetourMeters_IsRouteDistanceBeyondTheDirectTrip(t *testing.T) {
+	driver := models.Driver{ID: 1, Name: "Driver", Lat: 0, Lng: 2, VehicleCapacity: 2}
+	rider := models.Participant{ID: 1, Name: "Corner", Lat: 1, Lng: 1}
+	wantDetour := 2*math.Sqrt2*1000 - 2000
+
+	result, err := NewBalancedRouter(stableDistanceCalculator{}).CalculateRoutes(context.Background(), &RoutingRequest{
+		Participants: []models.Participant{rider},
+		Drivers:      []models.Driver{driver},
+		Mode:         RouteModeDropoff,
+	})
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+	calculated := result.Routes[0]
+	if math.Abs(calculated.DetourMeters-wantDetour) > 1e-6 || math.Abs(calculated.DetourMeters-(calculated.TotalDistanceMeters-2000)) > 1e-6 {
+		t.Fatalf("calculated DetourMeters = %v, want total %v minus the 2000 direct trip", calculated.DetourMeters, calculated.TotalDistanceMeters)
+	}
+
+	edited := models.CalculatedRoute{Driver: &driver, Stops: []models.RouteStop{{Participant: &rider}}}
+	if err := PopulateRouteMetrics(context.Background(), stableDistanceCalculator{}, models.Coordinates{}, RouteModeDropoff, &edited); err != nil {
+		t.Fatalf("PopulateRouteMetrics() error = %v", err)
+	}
+	if math.Abs(edited.DetourMeters-wantDetour) > 1e-6 {
+		t.Fatalf("recalculated DetourMeters = %v, want %v", edited.DetourMeters, wantDetour)
+	}
+}
+
+func TestReturnToInstitute_EndsDropoffRouteAtTheActivity(t *testing.T) {
+	driver := models.Driver{ID: 1, Name: "Debrief", Lat: 0, Lng: 3, VehicleCapacity: 2, ReturnToInstitute: true}
+	riders := []models.Participant{{ID: 1, Name: "Near", Lat: 0, Lng: 1}, {ID: 2, Name: "Far", Lat: 0, Lng: 2}}
+
+	result, err := NewBalancedRouter(stableDistanceCalculator{}).CalculateRoutes(context.Background(), &RoutingRequest{
+		Participants: riders,
+		Drivers:      []models.Driver{driver},
+		Mode:         RouteModeDropoff,
+	})
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+	calculated := result.Routes[0]
+	if calculated.TotalDistanceMeters != 4000 || calculated.DistanceToDriverHomeMeters != 2000 {
+		t.Fatalf("total = %.0f final leg = %.0f, want 4000 with a 2000 leg back to the activity", calculated.TotalDistanceMeters, calculated.DistanceToDriverHomeMeters)
+	}
+	if calculated.BaselineDurationSecs != 0 || calculated.DetourSecs != calculated.RouteDurationSecs {
+		t.Fatalf("baseline = %.0f detour = %.0f, want the whole loop counted as detour", calculated.BaselineDurationSecs, calculated.DetourSecs)
+	}
+
+	edited := models.CalculatedRoute{Driver: &driver, Stops: []models.RouteStop{{Participant: &riders[1]}, {Participant: &riders[0]}}}
+	if err := PopulateRouteMetrics(context.Background(), stableDistanceCalculator{}, models.Coordinates{}, RouteModeDropoff, &edited); err != nil {
+		t.Fatalf("PopulateRouteMetrics() error = %v", err)
+	}
+	if edited.TotalDistanceMeters != 4000 || edited.DistanceToDriverHomeMeters != 1000 || edited.DetourSecs != 4000 {
+		t.Fatalf("edited total = %.0f final leg = %.0f detour = %.0f, want the reversed loop back to the activity", edited.TotalDistanceMeters, edited.DistanceToDriverHomeMeters, edited.DetourSecs)
+	}
+}
@@ -0,0 +1,65 @@
+package routing
+
+// RouteOptions are the request settings, other than its caps, that shape how
+// routes are planned. A route session keeps them so re-optimizing plans the
+// way the original calculation did.
+type RouteOptions struct {
+	Metric                   OptimizationMetric
+	PickupObjective          PickupObjective
+	DeclineOverDetourCeiling bool
+	CompactDepartures        bool
+	MaxNeighborhoods         int
+	YoungestDroppedFirst     bool
+	HouseholdSplit           HouseholdSplit
+	HouseholdPrecision       int
+	DetourFairness           DetourFairness
+	ExcludeHomeLeg           bool
+	FairnessWeights          FairnessWeights
+	RotateDrivers            bool
+	RecentDriverRides        map[int64]int
+	PreferNearbyDrivers      bool
+	IncludeGeometry          bool
+	MaxInsertionPositions    int
+}
+
+// RequestOptions returns the options a request routes under.
+func RequestOptions(req *RoutingRequest) RouteOptions {
+	return RouteOptions{
+		Metric:                   req.Metric,
+		PickupObjective:          req.PickupObjective,
+		DeclineOverDetourCeiling: req.DeclineOverDetourCeiling,
+		CompactDepartures:        req.CompactDepartures,
+		MaxNeighborhoods:         req.MaxNeighborhoods,
+		YoungestDroppedFirst:     req.YoungestDroppedFirst,
+		HouseholdSplit:           req.HouseholdSplit,
+		HouseholdPrecision:       req.HouseholdPrecision,
+		DetourFairness:           req.DetourFairness,
+		ExcludeHomeLeg:           req.ExcludeHomeLeg,
+		FairnessWeights:          req.FairnessWeights,
+		RotateDrivers:            req.RotateDrivers,
+		RecentDriverRides:        req.RecentDriverRides,
+		PreferNearbyDrivers:      req.PreferNearbyDrivers,
+		IncludeGeometry:          req.IncludeGeometry,
+		MaxInsertionPositions:    req.MaxInsertionPositions,
+	}
+}
+
+// ApplyTo sets the options on req.
+func (o RouteOptions) ApplyTo(req *RoutingRequest) {
+	req.Metric = o.Metric
+	req.PickupObjective = o.PickupObjective
+	req.DeclineOverDetourCeiling = o.DeclineOverDetourCeiling
+	req.CompactDepartures = o.CompactDepartures
+	req.MaxNeighborhoods = o.MaxNeighborhoods
+	req.YoungestDroppedFirst = o.YoungestDroppedFirst
+	req.HouseholdSplit = o.HouseholdSplit
+	req.HouseholdPrecision = o.HouseholdPrecision
+	req.DetourFairness = o.DetourFairness
+	req.ExcludeHomeLeg = o.ExcludeHomeLeg
+	req.FairnessWeights = o.FairnessWeights
+	req.RotateDrivers = o.RotateDrivers
+	req.RecentDriverRides = o.RecentDriverRides
+	req.PreferNearbyDrivers = o.PreferNearbyDrivers
+	req.IncludeGeometry = o.IncludeGeometry
+	req.MaxInsertionPositions = o.MaxInsertionPositions
+}
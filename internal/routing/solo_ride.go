@@ -0,0 +1,115 @@
+package routing
+
+import (
+	"fmt"
+	"ride-home-router/internal/models"
+	"slices"
+)
+
+func isSoloRider(participant *models.Participant) bool {
+	return participant.SoloRide
+}
+
+// soloRidersAlone reports whether a route's stops leave every solo rider as
+// the only passenger.
+func soloRidersAlone(stops []*models.Participant) bool {
+	return len(stops) <= 1 || !slices.ContainsFunc(stops, isSoloRider)
+}
+
+// soloRideAllowsJoining reports whether the riders can join the stops without
+// sharing a car with a solo rider.
+func soloRideAllowsJoining(stops []*models.Participant, riders ...*models.Participant) bool {
+	return soloRidersAlone(append(slices.Clip(stops), riders...))
+}
+
+// assignmentPreservesSoloFeasibility rejects an assignment that would leave
+// too few empty routes for the remaining solo riders, or too few seats for
+// everyone else once those routes are set aside. Solo riders take the
+// smallest empty vehicles so the larger ones stay open for shared rides.
+func assignmentPreservesSoloFeasibility(routes map[int64]*balancedRoute, currentDriverID int64, groups []*participantGroup, assignedGroupIndex, assignedCount int) bool {
+	remainingSolo, remainingShared := 0, 0
+	assignedSolo := false
+	for groupIdx, group := range groups {
+		members := group.members
+		if groupIdx == assignedGroupIndex {
+			assigned := min(assignedCount, len(members))
+			assignedSolo = slices.ContainsFunc(members[:assigned], isSoloRider)
+			members = members[assigned:]
+		}
+		for _, participant := range members {
+			if participant.SoloRide {
+				remainingSolo++
+			} else {
+				remainingShared++
+			}
+		}
+	}
+
+	emptyCapacities := make([]int, 0, len(routes))
+	freeSeats := 0
+	for driverID, route := range routes {
+		occupied := len(route.stops)
+		closed := slices.ContainsFunc(route.stops, isSoloRider)
+		if driverID == currentDriverID {
+			occupied += assignedCount
+			closed = closed || assignedSolo
+		}
+		switch {
+		case closed:
+		case occupied == 0:
+			emptyCapacities = append(emptyCapacities, route.driver.VehicleCapacity)
+		default:
+			freeSeats += route.driver.VehicleCapacity - occupied
+		}
+	}
+	if remainingSolo > len(emptyCapacities) {
+		return false
+	}
+
+	slices.Sort(emptyCapacities)
+	for _, capacity := range emptyCapacities[remainingSolo:] {
+		freeSeats += capacity
+	}
+	return remainingShared <= freeSeats
+}
+
+// soloRideCapacityError explains why the drivers cannot give every solo
+// rider a car of their own while still seating everyone else. It returns nil
+// when the request has no solo riders or enough room for them.
+func soloRideCapacityError(req *RoutingRequest) error {
+	soloRiders := 0
+	for i := range req.Participants {
+		if req.Participants[i].SoloRide {
+			soloRiders++
+		}
+	}
+	if soloRiders == 0 {
+		return nil
+	}
+
+	failure := func(reason string) error {
+		return &ErrRoutingFailed{
+			Reason:            reason,
+			UnassignedCount:   len(req.Participants),
+			TotalCapacity:     totalDriverCapacity(req.Drivers),
+			TotalParticipants: len(req.Participants),
+		}
+	}
+	if soloRiders > len(req.Drivers) {
+		return failure(fmt.Sprintf("%d participants must ride alone but only %d drivers are available", soloRiders, len(req.Drivers)))
+	}
+
+	capacities := make([]int, len(req.Drivers))
+	for i, driver := range req.Drivers {
+		capacities[i] = driver.VehicleCapacity
+	}
+	slices.Sort(capacities)
+	sharedSeats := 0
+	for _, capacity := range capacities[soloRiders:] {
+		sharedSeats += capacity
+	}
+	if sharedRiders := len(req.Participants) - soloRiders; sharedRiders > sharedSeats {
+		return failure(fmt.Sprintf("After giving %d solo riders their own driver, %d seats remain for %d other participants", soloRiders, sharedSeats, sharedRiders))
+	}
+	return nil
+}
@@ -4,13 +4,22 @@ import (
 	"context"
 	"ride-home-router/internal/distance"
 	"ride-home-router/internal/models"
+	"sync"
 )
 
+// WarningEstimatedDistances is added to a result's warnings when any distance
+// behind it was a straight-line estimate.
+const WarningEstimatedDistances = "Some distances are straight-line estimates because the routing service was unreachable"
+
 // solveDistanceCache keeps repeated optimizer scoring from reloading the same
-// prewarmed pair through the persistent cache during one calculation.
+// prewarmed pair through the persistent cache during one calculation. It is
+// safe for concurrent use, as insertion workers read it in parallel.
 type solveDistanceCache struct {
 	distance.DistanceCalculator
+	mu     sync.RWMutex
 	values map[string]distance.DistanceResult
+	// estimated is set once any distance read was a straight-line estimate.
+	estimated bool
 }
 
 func newSolveDistanceCache(calc distance.DistanceCalculator) *solveDistanceCache {
@@ -21,17 +30,28 @@ func newSolveDistanceCache(calc distance.DistanceCalculator) *solveDistanceCache
 }
 
 func (c *solveDistanceCache) GetDistance(ctx context.Context, origin, dest models.Coordinates) (*distance.DistanceResult, error) {
+	// Riders at one address share a household stop, and a group that all
+	// lives at one address asks for this leg between every pair of them.
+	// It is zero for every provider, so it never reaches the calculator.
+	if models.SamePoint(origin, dest) {
+		return &distance.DistanceResult{}, nil
+	}
 	key := distance.PairCacheKey(origin, dest)
-	if cached, ok := c.values[key]; ok {
-		result := cached
-		return &result, nil
+	c.mu.RLock()
+	cached, ok := c.values[key]
+	c.mu.RUnlock()
+	if ok {
+		return &cached, nil
 	}
 
 	result, err := c.DistanceCalculator.GetDistance(ctx, origin, dest)
 	if err != nil {
 		return nil, err
 	}
+	c.mu.Lock()
 	c.values[key] = *result
+	c.estimated = c.estimated || result.Estimated
+	c.mu.Unlock()
 	copy := *result
 	return &copy, nil
 }
@@ -0,0 +1,115 @@
+package routing
+
+import (
+	"cmp"
+	"math"
+	"ride-home-router/internal/models"
+	"slices"
+)
+
+// DefaultSpareSeatRadiusMeters is how close, in a straight line, two routes'
+// centers must be to pool their spare seats into one area.
+const DefaultSpareSeatRadiusMeters = 5000.0
+
+// compassPoints names the eight headings, clockwise from north.
+var compassPoints = []string{"north", "northeast", "east", "southeast", "south", "southwest", "west", "northwest"}
+
+// SpareSeatArea pools the free seats of used routes whose stops center near
+// each other, so a coordinator can say where there is room for another rider.
+type SpareSeatArea struct {
+	// Center is the average of the area's stops. Heading and DistanceMeters
+	// place it relative to the activity location.
+	Center         models.Coordinates
+	Heading        string
+	DistanceMeters float64
+	SpareSeats     int
+	RouteIndexes   []int
+}
+
+// SpareSeats groups the routes that carry at least one participant and still
+// have a free seat by the center of their stops, chaining routes whose centers
+// lie within radiusMeters of each other. Areas are ordered by spare seats,
+// most first. A non-positive radius uses DefaultSpareSeatRadiusMeters.
+func SpareSeats(instituteCoords models.Coordinates, routes []models.CalculatedRoute, radiusMeters float64) []SpareSeatArea {
+	if radiusMeters <= 0 {
+		radiusMeters = DefaultSpareSeatRadiusMeters
+	}
+
+	var indexes []int
+	var centers []models.Coordinates
+	for i, route := range routes {
+		if len(route.Stops) == 0 || spareSeats(route) <= 0 {
+			continue
+		}
+		indexes = append(indexes, i)
+		centers = append(centers, stopCenter(route.Stops))
+	}
+
+	seen := make([]bool, len(indexes))
+	var areas []SpareSeatArea
+	for i := range indexes {
+		if seen[i] {
+			continue
+		}
+		seen[i] = true
+		members := []int{i}
+		for queue := []int{i}; len(queue) > 0; queue = queue[1:] {
+			for j := range indexes {
+				if !seen[j] && greatCircleMeters(centers[queue[0]], centers[j]) <= radiusMeters {
+					seen[j] = true
+					queue = append(queue, j)
+					members = append(members, j)
+				}
+			}
+		}
+		slices.Sort(members)
+
+		area := SpareSeatArea{}
+		var stops []models.RouteStop
+		for _, member := range members {
+			route := routes[indexes[member]]
+			area.SpareSeats += spareSeats(route)
+			area.RouteIndexes = append(area.RouteIndexes, indexes[member])
+			stops = append(stops, route.Stops...)
+		}
+		area.Center = stopCenter(stops)
+		area.DistanceMeters = greatCircleMeters(instituteCoords, area.Center)
+		area.Heading = compassHeading(initialBearing(instituteCoords, area.Center))
+		areas = append(areas, area)
+	}
+
+	slices.SortStableFunc(areas, func(a, b SpareSeatArea) int {
+		return cmp.Compare(b.SpareSeats, a.SpareSeats)
+	})
+	return areas
+}
+
+// spareSeats is how many seats the route has left, using the driver's own
+// capacity when the route has no effective capacity recorded.
+func spareSeats(route models.CalculatedRoute) int {
+	capacity := route.EffectiveCapacity
+	if capacity == 0 && route.Driver != nil {
+		capacity = route.Driver.VehicleCapacity
+	}
+	return capacity - len(route.Stops)
+}
+
+// stopCenter averages the stops' coordinates.
+func stopCenter(stops []models.RouteStop) models.Coordinates {
+	var center models.Coordinates
+	for _, stop := range stops {
+		coords := stop.Participant.GetCoords()
+		center.Lat += coords.Lat
+		center.Lng += coords.Lng
+	}
+	center.Lat /= float64(len(stops))
+	center.Lng /= float64(len(stops))
+	return center
+}
+
+// compassHeading names the nearest of the eight compass points to a bearing
+// in degrees.
+func compassHeading(bearing float64) string {
+	point := int(math.Round(bearing/45)) % len(compassPoints)
+	return compassPoints[point]
+}
@@ -0,0 +1,46 @@
+package routing
+
+import (
+	"math"
+	"ride-home-router/internal/models"
+	"slices"
+	"testing"
+)
+
+func TestSpareSeats_SumsSeatsAroundRouteCenters(t *testing.T) {
+	stop := func(id int64, lat, lng float64) models.RouteStop {
+		return models.RouteStop{Participant: &models.Participant{ID: id, Lat: lat, Lng: lng}}
+	}
+	routes := []models.CalculatedRoute{
+		{Driver: &models.Driver{ID: 1}, EffectiveCapacity: 4, Stops: []models.RouteStop{stop(1, 1, 0), stop(2, 1, 0.02)}},
+		{Driver: &models.Driver{ID: 2}, EffectiveCapacity: 1, Stops: []models.RouteStop{stop(3, -1, 0)}},
+		{Driver: &models.Driver{ID: 3, VehicleCapacity: 3}, Stops: []models.RouteStop{stop(4, 1.02, 0.01)}},
+		{Driver: &models.Driver{ID: 4}, EffectiveCapacity: 4},
+		{Driver: &models.Driver{ID: 5}, EffectiveCapacity: 2, Stops: []models.RouteStop{stop(5, -1, 0.01)}},
+	}
+
+	areas := SpareSeats(models.Coordinates{}, routes, 0)
+	if len(areas) != 2 {
+		t.Fatalf("areas = %+v, want the northern pair pooled and one southern route", areas)
+	}
+
+	north := areas[0]
+	if north.SpareSeats != 4 || !slices.Equal(north.RouteIndexes, []int{0, 2}) || north.Heading != "north" {
+		t.Fatalf("north area = %+v, want 4 seats from routes 0 and 2 heading north", north)
+	}
+	if wantLat := (1 + 1 + 1.02) / 3.0; math.Abs(north.Center.Lat-wantLat) > 1e-9 || math.Abs(north.Center.Lng-0.01) > 1e-9 {
+		t.Fatalf("north center = %+v, want the average of its three stops", north.Center)
+	}
+	if math.Abs(north.DistanceMeters-greatCircleMeters(models.Coordinates{}, north.Center)) > 1e-6 {
+		t.Fatalf("north distance = %v, want the straight line from the activity", north.DistanceMeters)
+	}
+
+	south := areas[1]
+	if south.SpareSeats != 1 || !slices.Equal(south.RouteIndexes, []int{4}) || south.Heading != "south" {
+		t.Fatalf("south area = %+v, want the one free seat on route 4 heading south", south)
+	}
+
+	if split := SpareSeats(models.Coordinates{}, routes, 100); len(split) != 3 {
+		t.Fatalf("areas with a 100 m radius = %+v, want the northern routes kept apart", split)
+	}
+}
@@ -0,0 +1,54 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"ride-home-router/internal/models"
+	"slices"
+	"testing"
+)
+
+func TestBalancedRouter_TimeWindowsReorderStops(t *testing.T) {
+	router := NewBalancedRouter(stableDistanceCalculator{})
+	req := &RoutingRequest{
+		InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+		Participants: []models.Participant{
+			{ID: 1, Name: "Parent Home Late", Lat: 0, Lng: 1, EarliestSecs: 1500},
+			{ID: 2, Name: "Further Out", Lat: 0, Lng: 2},
+		},
+		Drivers: []models.Driver{{ID: 1, Name: "Driver", Lat: 0, Lng: 3, VehicleCapacity: 3}},
+		Mode:    RouteModeDropoff,
+	}
+
+	result, err := router.CalculateRoutes(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CalculateRoutes() error = %v", err)
+	}
+	stops := result.Routes[0].Stops
+	if len(stops) != 2 || stops[0].Participant.ID != 2 || stops[1].Participant.ID != 1 {
+		t.Fatalf("stops = %+v, want the further rider first so the window opens", stops)
+	}
+	if stops[1].CumulativeDurationSecs < 1500 {
+		t.Fatalf("windowed stop reached at %v, want no earlier than 1500", stops[1].CumulativeDurationSecs)
+	}
+}
+
+func TestBalancedRouter_ReportsParticipantsOutsideTheirWindow(t *testing.T) {
+	router := NewBalancedRouter(stableDistanceCalculator{})
+	_, err := router.CalculateRoutes(context.Background(), &RoutingRequest{
+		InstituteCoords: models.Coordinates{Lat: 0, Lng: 0},
+		Participants: []models.Participant{
+			{ID: 1, Name: "Open", Lat: 0, Lng: 1},
+			{ID: 2, Name: "Too Soon", Lat: 0, Lng: 2, LatestSecs: 500},
+		},
+		Drivers: []models.Driver{{ID: 1, Name: "Driver", Lat: 0, Lng: 3, VehicleCapacity: 3}},
+		Mode:    RouteModeDropoff,
+	})
+	var failure *ErrRoutingFailed
+	if !errors.As(err, &failure) {
+		t.Fatalf("CalculateRoutes() error = %v, want ErrRoutingFailed", err)
+	}
+	if !slices.Equal(failure.OutsideTimeWindow, []int64{2}) || failure.UnassignedCount != 1 {
+		t.Fatalf("failure = %+v, want only Too Soon reported outside their window", failure)
+	}
+}
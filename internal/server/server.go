@@ -8,6 +8,8 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"os"
+	"path/filepath"
 	"ride-home-router/internal/browser"
 	"ride-home-router/internal/database"
 	"ride-home-router/internal/distance"
@@ -15,10 +17,13 @@ import (
 	"ride-home-router/internal/handlers"
 	"ride-home-router/internal/httpx"
 	"ride-home-router/internal/logutil"
+	"ride-home-router/internal/models"
 	"ride-home-router/internal/routesession"
 	"ride-home-router/internal/routing"
+	"ride-home-router/internal/sheets"
 	"ride-home-router/internal/sqlite"
 	"ride-home-router/internal/templates"
+	"ride-home-router/internal/templateutil"
 	"ride-home-router/web"
 	"strings"
 	"time"
@@ -37,10 +42,38 @@ type Server struct {
 // Config holds server configuration
 type Config struct {
 	Addr   string // e.g., "127.0.0.1:8080" or "127.0.0.1:0" for random port
-	DBPath string // Optional: path to SQLite database, uses config file or default if empty
+	DBPath string // Optional: path to SQLite database, uses DATA_FILE, the config file or the default if empty
+	// BackupInterval enables periodic database backups when positive. When
+	// DBPath is empty it is read from the config file instead.
+	BackupInterval time.Duration
+	// DurationHourThreshold is the shortest duration rendered in hours.
+	// Zero uses the template default; with an empty DBPath it is read from
+	// the config file.
+	DurationHourThreshold time.Duration
+	// SamePointPrecision is the decimal places at which two coordinates are
+	// one stop. Zero uses the default; with an empty DBPath it is read from
+	// the config file.
+	SamePointPrecision int
+	// MaxConcurrentCalculations caps simultaneous route calculations; later
+	// ones wait their turn. Zero means no limit; with an empty DBPath it is
+	// read from the config file.
+	MaxConcurrentCalculations int
+	// InsertionWorkers is how many candidate insertions one calculation costs
+	// in parallel. Zero costs them in turn; with an empty DBPath it is read
+	// from the config file.
+	InsertionWorkers int
 }
 
 const (
+	// dataFileEnv names the environment variable that relocates the database
+	// when Config.DBPath is empty. It wins over the config file's path.
+	dataFileEnv = "DATA_FILE"
+	// osrmBaseURLEnv and osrmProfileEnv switch distances from Google to an
+	// OSRM server when either is set, e.g. a self-hosted instance or a
+	// cycling or walking profile. Each defaults to the public server's value.
+	osrmBaseURLEnv = "OSRM_BASE_URL"
+	osrmProfileEnv = "OSRM_PROFILE"
+
 	serverReadTimeout  = 15 * time.Second
 	serverWriteTimeout = 60 * time.Second
 	serverIdleTimeout  = 120 * time.Second
@@ -58,6 +91,11 @@ const (
 func New(cfg Config) (*Server, error) {
 	// Determine database path
 	dbPath := cfg.DBPath
+	backupInterval := cfg.BackupInterval
+	hourThreshold := cfg.DurationHourThreshold
+	samePointPrecision := cfg.SamePointPrecision
+	maxCalculations := cfg.MaxConcurrentCalculations
+	insertionWorkers := cfg.InsertionWorkers
 	if dbPath == "" {
 		// Load from config file or use default
 		appConfig, err := database.LoadConfig()
@@ -65,16 +103,26 @@ func New(cfg Config) (*Server, error) {
 			return nil, fmt.Errorf("failed to load config: %w", err)
 		}
 		dbPath = appConfig.DatabasePath
+		if dataFile := os.Getenv(dataFileEnv); dataFile != "" {
+			dbPath = dataFile
+		}
+		backupInterval = time.Duration(appConfig.BackupIntervalMinutes) * time.Minute
+		hourThreshold = time.Duration(appConfig.DurationHourThresholdMinutes) * time.Minute
+		samePointPrecision = appConfig.SamePointPrecision
+		maxCalculations = appConfig.MaxConcurrentCalculations
+		insertionWorkers = appConfig.InsertionWorkers
 	}
+	models.SetSamePointPrecision(samePointPrecision)
 
 	log.Printf("Initializing SQLite data store at: %s", dbPath)
 	db, err := sqlite.New(dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize data store: %w", err)
 	}
+	db.StartBackups(backupInterval)
 
 	log.Printf("Loading templates...")
-	renderer, err := templates.New(web.Templates)
+	renderer, err := templates.NewWithOptions(web.Templates, templateutil.Options{HourThreshold: hourThreshold})
 	if err != nil {
 		_ = db.Close()
 		return nil, fmt.Errorf("failed to load templates: %w", err)
@@ -88,16 +136,42 @@ func New(cfg Config) (*Server, error) {
 		}
 		return config.GoogleMapsAPIKey, nil
 	})
-	router := routing.NewBalancedRouter(distanceCalc)
-	routeSession := routesession.NewStore(distanceCalc)
+	var avoidHighwaysCalc distance.DistanceCalculator
+	if baseURL, profile := os.Getenv(osrmBaseURLEnv), os.Getenv(osrmProfileEnv); baseURL != "" || profile != "" {
+		log.Printf("Using OSRM distances: base_url=%q profile=%q", baseURL, profile)
+		distanceCalc = distance.NewOSRMCalculatorWithOptions(db.DistanceCache(), distance.OSRMOptions{BaseURL: baseURL, Profile: profile})
+		avoidHighwaysCalc = distance.NewOSRMCalculatorWithOptions(db.DistanceCache(), distance.OSRMOptions{BaseURL: baseURL, Profile: profile, Exclude: "motorway"})
+	}
+	balanced := routing.NewBalancedRouterWithOptions(distanceCalc, routing.BalancedOptions{InsertionWorkers: insertionWorkers})
+	router := routing.NewLimitedRouter(balanced, maxCalculations)
+	routeSession, err := routesession.NewPersistentStore(distanceCalc, filepath.Join(filepath.Dir(dbPath), "route_sessions.json"))
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to load route sessions: %w", err)
+	}
+	if avoidHighwaysCalc != nil {
+		routeSession.SetAvoidHighwaysCalculator(avoidHighwaysCalc)
+	}
 
 	handler := &handlers.Handler{
-		DB:           db,
-		Geocoder:     geocoder,
-		DistanceCalc: distanceCalc,
-		Router:       router,
-		Renderer:     renderer,
-		RouteSession: routeSession,
+		DB:                db,
+		Geocoder:          geocoder,
+		DistanceCalc:      distanceCalc,
+		AvoidHighwaysCalc: avoidHighwaysCalc,
+		Router:            router,
+		Renderer:          renderer,
+		RouteSession:      routeSession,
+		Sheets: sheets.NewExporter(sheets.NewGoogleClient(func() (sheets.Credentials, error) {
+			config, err := database.LoadConfig()
+			if err != nil {
+				return sheets.Credentials{}, err
+			}
+			return sheets.Credentials{
+				ClientID:     config.GoogleSheets.ClientID,
+				ClientSecret: config.GoogleSheets.ClientSecret,
+				RefreshToken: config.GoogleSheets.RefreshToken,
+			}, nil
+		})),
 	}
 
 	mux := setupRoutes(handler, web.Static)
@@ -235,34 +309,69 @@ func setupRoutes(handler *handlers.Handler, staticFS fs.FS) *http.ServeMux {
 	mux.HandleFunc("/api/v1/open-url", requireMethod(http.MethodPost, handleOpenURL))
 	mux.HandleFunc("/api/v1/settings", handleMethods(handler.HandleGetSettings, nil, handler.HandleUpdateSettings, nil))
 	mux.HandleFunc("/api/v1/config/database", handleMethods(handler.HandleGetDatabaseConfig, nil, handler.HandleUpdateDatabaseConfig, nil))
+	mux.HandleFunc("/api/v1/backups", requireMethod(http.MethodGet, handler.HandleListBackups))
+	mux.HandleFunc("/api/v1/backups/restore", requireMethod(http.MethodPost, handler.HandleRestoreBackup))
 	mux.HandleFunc("/api/v1/config/routing-provider", handleMethods(handler.HandleGetRoutingProviderConfig, nil, handler.HandleUpdateRoutingProviderConfig, nil))
 	mux.HandleFunc("/api/v1/participants", handleMethods(handler.HandleListParticipants, handler.HandleCreateParticipant, nil, nil))
 	mux.HandleFunc("/api/v1/participants/labels/add", requireMethod(http.MethodPost, handler.HandleAddParticipantsToLabel))
 	mux.HandleFunc("/api/v1/participants/labels/remove", requireMethod(http.MethodPost, handler.HandleRemoveParticipantsFromLabel))
+	mux.HandleFunc("/api/v1/participants/import", requireMethod(http.MethodPost, handler.HandleImportParticipants))
 	mux.HandleFunc("/api/v1/participants/new", requireMethod(http.MethodGet, handler.HandleParticipantForm))
 	mux.HandleFunc("/api/v1/participants/", handleResourcePath("/api/v1/participants/", "/edit", handler.HandleParticipantForm, handler.HandleGetParticipant, handler.HandleUpdateParticipant, handler.HandleDeleteParticipant))
 	mux.HandleFunc("/api/v1/drivers", handleMethods(handler.HandleListDrivers, handler.HandleCreateDriver, nil, nil))
 	mux.HandleFunc("/api/v1/drivers/labels/add", requireMethod(http.MethodPost, handler.HandleAddDriversToLabel))
 	mux.HandleFunc("/api/v1/drivers/labels/remove", requireMethod(http.MethodPost, handler.HandleRemoveDriversFromLabel))
+	mux.HandleFunc("/api/v1/drivers/reach", requireMethod(http.MethodGet, handler.HandleDriverReach))
+	mux.HandleFunc("/api/v1/drivers/import", requireMethod(http.MethodPost, handler.HandleImportDrivers))
 	mux.HandleFunc("/api/v1/drivers/new", requireMethod(http.MethodGet, handler.HandleDriverForm))
-	mux.HandleFunc("/api/v1/drivers/", handleResourcePath("/api/v1/drivers/", "/edit", handler.HandleDriverForm, handler.HandleGetDriver, handler.HandleUpdateDriver, handler.HandleDeleteDriver))
+	driverResource := handleResourcePath("/api/v1/drivers/", "/edit", handler.HandleDriverForm, handler.HandleGetDriver, handler.HandleUpdateDriver, handler.HandleDeleteDriver)
+	mux.HandleFunc("/api/v1/drivers/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/active") {
+			requireMethod(http.MethodPut, handler.HandleSetDriverActive)(w, r)
+			return
+		}
+		driverResource(w, r)
+	})
 	mux.HandleFunc("/api/v1/labels", handleMethods(handler.HandleListLabels, handler.HandleCreateLabel, nil, nil))
 	mux.HandleFunc("/api/v1/labels/new", requireMethod(http.MethodGet, handler.HandleLabelForm))
 	mux.HandleFunc("/api/v1/labels/", handleResourcePath("/api/v1/labels/", "/edit", handler.HandleLabelForm, handler.HandleGetLabel, handler.HandleUpdateLabel, handler.HandleDeleteLabel))
 	mux.HandleFunc("/api/v1/routes/calculate", requireMethod(http.MethodPost, handler.HandleCalculateRoutes))
+	mux.HandleFunc("/api/v1/routes/candidates", requireMethod(http.MethodPost, handler.HandleRouteCandidates))
 	mux.HandleFunc("/api/v1/routes/calculate-with-org-vehicles", requireMethod(http.MethodPost, handler.HandleCalculateRoutesWithOrgVehicles))
+	mux.HandleFunc("/api/v1/routes/cache-coverage", requireMethod(http.MethodPost, handler.HandleDistanceCacheCoverage))
+	mux.HandleFunc("/api/v1/routes/cache-invalidate", requireMethod(http.MethodPost, handler.HandleInvalidateDistanceCache))
+	mux.HandleFunc("/api/v1/distance-cache/prewarm", requireMethod(http.MethodPost, handler.HandlePrewarmDistanceCache))
+	mux.HandleFunc("/api/v1/distance-cache/stats", requireMethod(http.MethodGet, handler.HandleDistanceCacheStats))
+	mux.HandleFunc("/api/v1/routes/preview-capacity", requireMethod(http.MethodPost, handler.HandlePreviewCapacity))
+	mux.HandleFunc("/api/v1/routes/suggest-drivers", requireMethod(http.MethodPost, handler.HandleSuggestDrivers))
+	mux.HandleFunc("/api/v1/routes/what-if-driver", requireMethod(http.MethodPost, handler.HandleWhatIfDriver))
+	mux.HandleFunc("/api/v1/routes/manual", requireMethod(http.MethodPost, handler.HandleManualRoutePlan))
 	mux.HandleFunc("/api/v1/routes/edit/move-participant", requireMethod(http.MethodPost, handler.HandleMoveParticipant))
 	mux.HandleFunc("/api/v1/routes/edit/swap-drivers", requireMethod(http.MethodPost, handler.HandleSwapDrivers))
+	mux.HandleFunc("/api/v1/routes/edit/lock-stop", requireMethod(http.MethodPost, handler.HandleLockStop))
+	mux.HandleFunc("/api/v1/routes/edit/set-capacity", requireMethod(http.MethodPost, handler.HandleSetRouteCapacity))
 	mux.HandleFunc("/api/v1/routes/edit/reset", requireMethod(http.MethodPost, handler.HandleResetRoutes))
+	mux.HandleFunc("/api/v1/routes/edit/undo", requireMethod(http.MethodPost, handler.HandleUndoRouteEdit))
+	mux.HandleFunc("/api/v1/routes/edit/reoptimize", requireMethod(http.MethodPost, handler.HandleReoptimizeRouteEdit))
 	mux.HandleFunc("/api/v1/routes/edit/add-driver", requireMethod(http.MethodPost, handler.HandleAddDriver))
+	mux.HandleFunc("/api/v1/routes/edit/mark-noshow", requireMethod(http.MethodPost, handler.HandleMarkNoShow))
+	mux.HandleFunc("/api/v1/routes/edit/restore-noshow", requireMethod(http.MethodPost, handler.HandleRestoreNoShow))
+	mux.HandleFunc("/api/v1/routes/edit/refresh-participant", requireMethod(http.MethodPost, handler.HandleRefreshParticipant))
+	mux.HandleFunc("/api/v1/routes/edit/", handleMethods(handler.HandleRouteSessionReport, handler.HandleRouteSessionLock, nil, nil))
 	mux.HandleFunc("/api/v1/routes/session", requireMethod(http.MethodGet, handler.HandleGetRouteSession))
+	mux.HandleFunc("/api/v1/routes/session/etas", requireMethod(http.MethodGet, handler.HandleRouteETAs))
+	mux.HandleFunc("/api/v1/routes/session/perturb", requireMethod(http.MethodPost, handler.HandlePerturbRouteSession))
+	mux.HandleFunc("/api/v1/routes/session/confirmations", requireMethod(http.MethodGet, handler.HandleRouteSessionConfirmations))
+	mux.HandleFunc("/api/v1/routes/session/reimbursement", requireMethod(http.MethodGet, handler.HandleRouteSessionReimbursement))
+	mux.HandleFunc("/api/v1/routes/session/export-sheets", requireMethod(http.MethodPost, handler.HandleExportRoutesToSheets))
 	mux.HandleFunc("/api/v1/address-search", requireMethod(http.MethodGet, handler.HandleAddressSearch))
 	mux.HandleFunc("/api/v1/activity-locations", handleMethods(handler.HandleListActivityLocations, handler.HandleCreateActivityLocation, nil, nil))
 	mux.HandleFunc("/api/v1/activity-locations/", handleResourcePath("/api/v1/activity-locations/", "/edit", handler.HandleActivityLocationForm, handler.HandleGetActivityLocation, handler.HandleUpdateActivityLocation, handler.HandleDeleteActivityLocation))
 	mux.HandleFunc("/api/v1/org-vehicles", handleMethods(handler.HandleListOrgVehicles, handler.HandleCreateOrgVehicle, nil, nil))
 	mux.HandleFunc("/api/v1/org-vehicles/", handleResourcePath("/api/v1/org-vehicles/", "/edit", handler.HandleOrgVehicleForm, handler.HandleGetOrgVehicle, handler.HandleUpdateOrgVehicle, handler.HandleDeleteOrgVehicle))
 	mux.HandleFunc("/api/v1/events", handleMethods(handler.HandleListEvents, handler.HandleCreateEvent, nil, nil))
-	mux.HandleFunc("/api/v1/events/", handleResourcePath("/api/v1/events/", "", nil, handler.HandleGetEvent, nil, handler.HandleDeleteEvent))
+	mux.HandleFunc("/api/v1/events/export.csv", requireMethod(http.MethodGet, handler.HandleExportEventsCSV))
+	mux.HandleFunc("/api/v1/events/", handleResourcePath("/api/v1/events/", "", nil, handler.HandleEventReport, nil, handler.HandleDeleteEvent))
 
 	// Page routes
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -4,9 +4,30 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
+func TestNew_UsesDataFileFromEnvironment(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dataFile := filepath.Join(t.TempDir(), "test-program", "data.db")
+	t.Setenv(dataFileEnv, dataFile)
+
+	srv, err := New(Config{Addr: "127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = srv.Shutdown(context.Background()) })
+
+	if got := srv.GetDBPath(); got != dataFile {
+		t.Fatalf("GetDBPath() = %q, want %q", got, dataFile)
+	}
+	if _, err := os.Stat(dataFile); err != nil {
+		t.Fatalf("data file was not created: %v", err)
+	}
+}
+
 func TestHandleMethods_RejectsUnsupportedMethod(t *testing.T) {
 	handler := handleMethods(func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusNoContent)
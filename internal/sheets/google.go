@@ -0,0 +1,197 @@
+package sheets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	googleSheetsBaseURL = "https://sheets.googleapis.com/v4/spreadsheets"
+	googleTokenURL      = "https://oauth2.googleapis.com/token"
+	googleHTTPTimeout   = 30 * time.Second
+)
+
+// Credentials are the OAuth client and refresh token used to call the Sheets API.
+type Credentials struct {
+	ClientID     string
+	ClientSecret string
+	RefreshToken string
+}
+
+func (c Credentials) complete() bool {
+	return c.ClientID != "" && c.ClientSecret != "" && c.RefreshToken != ""
+}
+
+// CredentialsProvider returns the current credentials, read lazily so config
+// changes apply without a restart.
+type CredentialsProvider func() (Credentials, error)
+
+type googleClient struct {
+	httpClient  *http.Client
+	credentials CredentialsProvider
+	baseURL     string
+	tokenURL    string
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewGoogleClient creates a Sheets API client that exchanges the configured
+// refresh token for access tokens as needed.
+func NewGoogleClient(credentials CredentialsProvider) Client {
+	return &googleClient{
+		httpClient:  &http.Client{Timeout: googleHTTPTimeout},
+		credentials: credentials,
+		baseURL:     googleSheetsBaseURL,
+		tokenURL:    googleTokenURL,
+	}
+}
+
+func (c *googleClient) EnsureTab(ctx context.Context, spreadsheetID, tab string) error {
+	var spreadsheet struct {
+		Sheets []struct {
+			Properties struct {
+				Title string `json:"title"`
+			} `json:"properties"`
+		} `json:"sheets"`
+	}
+	endpoint := c.baseURL + "/" + url.PathEscape(spreadsheetID) + "?fields=sheets.properties.title"
+	if err := c.do(ctx, http.MethodGet, endpoint, nil, &spreadsheet); err != nil {
+		return err
+	}
+	for _, sheet := range spreadsheet.Sheets {
+		if sheet.Properties.Title == tab {
+			return nil
+		}
+	}
+
+	body := map[string]any{
+		"requests": []any{
+			map[string]any{"addSheet": map[string]any{"properties": map[string]any{"title": tab}}},
+		},
+	}
+	return c.do(ctx, http.MethodPost, c.baseURL+"/"+url.PathEscape(spreadsheetID)+":batchUpdate", body, nil)
+}
+
+func (c *googleClient) ReplaceRows(ctx context.Context, spreadsheetID, tab string, rows [][]string) error {
+	sheetRange := quoteSheetName(tab)
+	valuesURL := c.baseURL + "/" + url.PathEscape(spreadsheetID) + "/values/" + url.PathEscape(sheetRange)
+	if err := c.do(ctx, http.MethodPost, valuesURL+":clear", map[string]any{}, nil); err != nil {
+		return err
+	}
+
+	body := map[string]any{
+		"range":          sheetRange,
+		"majorDimension": "ROWS",
+		"values":         rows,
+	}
+	return c.do(ctx, http.MethodPut, valuesURL+"?valueInputOption=RAW", body, nil)
+}
+
+// quoteSheetName builds an A1 range covering the whole tab.
+func quoteSheetName(tab string) string {
+	return "'" + strings.ReplaceAll(tab, "'", "''") + "'"
+}
+
+func (c *googleClient) do(ctx context.Context, method, endpoint string, body, out any) error {
+	token, err := c.currentToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		responseBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("google sheets HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(responseBody)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *googleClient) currentToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.accessToken != "" && time.Now().Before(c.expiresAt) {
+		return c.accessToken, nil
+	}
+	if c.credentials == nil {
+		return "", ErrNotConfigured
+	}
+	credentials, err := c.credentials()
+	if err != nil {
+		return "", err
+	}
+	if !credentials.complete() {
+		return "", ErrNotConfigured
+	}
+
+	form := url.Values{
+		"client_id":     {credentials.ClientID},
+		"client_secret": {credentials.ClientSecret},
+		"refresh_token": {credentials.RefreshToken},
+		"grant_type":    {"refresh_token"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		responseBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("google oauth token HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(responseBody)))
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+
+	c.accessToken = token.AccessToken
+	// Refresh a minute early so requests never race the expiry.
+	c.expiresAt = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return c.accessToken, nil
+}
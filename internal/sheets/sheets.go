@@ -0,0 +1,75 @@
+// Package sheets exports calculated route plans to Google Sheets.
+package sheets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"ride-home-router/internal/models"
+	"strconv"
+)
+
+// ErrNotConfigured is returned when Google Sheets credentials are missing.
+var ErrNotConfigured = errors.New("google sheets export is not configured")
+
+// Client is the subset of the Sheets API the exporter needs.
+type Client interface {
+	// EnsureTab creates the named tab if the spreadsheet does not have it yet.
+	EnsureTab(ctx context.Context, spreadsheetID, tab string) error
+	// ReplaceRows clears the tab and writes rows starting at A1.
+	ReplaceRows(ctx context.Context, spreadsheetID, tab string, rows [][]string) error
+}
+
+// Exporter writes route plans to a spreadsheet, one tab per event.
+type Exporter struct {
+	client Client
+}
+
+// NewExporter creates an exporter backed by the given client.
+func NewExporter(client Client) *Exporter {
+	return &Exporter{client: client}
+}
+
+// ExportRoutes replaces the contents of the tab with the plan's rows.
+func (e *Exporter) ExportRoutes(ctx context.Context, spreadsheetID, tab string, routes []models.CalculatedRoute) error {
+	if e == nil || e.client == nil || spreadsheetID == "" {
+		return ErrNotConfigured
+	}
+	if err := e.client.EnsureTab(ctx, spreadsheetID, tab); err != nil {
+		return fmt.Errorf("failed to prepare sheet tab %q: %w", tab, err)
+	}
+	rows := PlanRows(routes)
+	if err := e.client.ReplaceRows(ctx, spreadsheetID, tab, rows); err != nil {
+		return fmt.Errorf("failed to write sheet tab %q: %w", tab, err)
+	}
+	log.Printf("[SHEETS] Exported %d rows to tab %q", len(rows)-1, tab)
+	return nil
+}
+
+// PlanRows flattens routes into a header row followed by one row per stop.
+// The driver column shows the org vehicle in parentheses when one is assigned.
+func PlanRows(routes []models.CalculatedRoute) [][]string {
+	rows := [][]string{{"Driver", "Stop", "Participant", "Address"}}
+	for _, route := range routes {
+		driverName := ""
+		if route.Driver != nil {
+			driverName = route.Driver.Name
+		}
+		if route.OrgVehicleName != "" {
+			driverName = fmt.Sprintf("%s (%s)", driverName, route.OrgVehicleName)
+		}
+		for _, stop := range route.Stops {
+			if stop.Participant == nil {
+				continue
+			}
+			rows = append(rows, []string{
+				driverName,
+				strconv.Itoa(stop.Order + 1),
+				stop.Participant.Name,
+				stop.Participant.Address,
+			})
+		}
+	}
+	return rows
+}
@@ -0,0 +1,138 @@
+package sheets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"ride-home-router/internal/models"
+	"strings"
+	"testing"
+)
+
+type stubClient struct {
+	tabs map[string][][]string
+}
+
+func (c *stubClient) EnsureTab(ctx context.Context, spreadsheetID, tab string) error {
+	if c.tabs == nil {
+		c.tabs = make(map[string][][]string)
+	}
+	if _, ok := c.tabs[tab]; !ok {
+		c.tabs[tab] = nil
+	}
+	return nil
+}
+
+func (c *stubClient) ReplaceRows(ctx context.Context, spreadsheetID, tab string, rows [][]string) error {
+	if _, ok := c.tabs[tab]; !ok {
+		return errors.New("tab not created")
+	}
+	c.tabs[tab] = rows
+	return nil
+}
+
+func testRoutes() []models.CalculatedRoute {
+	return []models.CalculatedRoute{
+		{
+			Driver: &models.Driver{ID: 1, Name: "Dana"},
+			Stops: []models.RouteStop{
+				{Order: 0, Participant: &models.Participant{ID: 1, Name: "Ada", Address: "1 Elm St"}},
+				{Order: 1, Participant: &models.Participant{ID: 2, Name: "Ben", Address: "2 Oak St"}},
+			},
+		},
+		{
+			Driver:         &models.Driver{ID: 2, Name: "Eli"},
+			OrgVehicleName: "Van 1",
+			Stops: []models.RouteStop{
+				{Order: 0, Participant: &models.Participant{ID: 3, Name: "Cy", Address: "3 Pine St"}},
+			},
+		},
+	}
+}
+
+func TestExporterWritesOneRowPerStop(t *testing.T) {
+	client := &stubClient{}
+	exporter := NewExporter(client)
+
+	if err := exporter.ExportRoutes(context.Background(), "sheet-1", "2026-10-15 Gym", testRoutes()); err != nil {
+		t.Fatalf("ExportRoutes() error = %v", err)
+	}
+
+	want := [][]string{
+		{"Driver", "Stop", "Participant", "Address"},
+		{"Dana", "1", "Ada", "1 Elm St"},
+		{"Dana", "2", "Ben", "2 Oak St"},
+		{"Eli (Van 1)", "1", "Cy", "3 Pine St"},
+	}
+	if got := client.tabs["2026-10-15 Gym"]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("rows = %v, want %v", got, want)
+	}
+
+	if err := exporter.ExportRoutes(context.Background(), "", "tab", testRoutes()); !errors.Is(err, ErrNotConfigured) {
+		t.Fatalf("ExportRoutes(no spreadsheet) error = %v, want ErrNotConfigured", err)
+	}
+}
+
+func TestGoogleClientRefreshesTokenAndWritesValues(t *testing.T) {
+	var written struct {
+		Range  string     `json:"range"`
+		Values [][]string `json:"values"`
+	}
+	var addedTab string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "token-1", "expires_in": 3600})
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer token-1" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		switch {
+		case r.Method == http.MethodGet:
+			_, _ = w.Write([]byte(`{"sheets":[{"properties":{"title":"Sheet1"}}]}`))
+		case strings.HasSuffix(r.URL.Path, ":batchUpdate"):
+			var body struct {
+				Requests []struct {
+					AddSheet struct {
+						Properties struct {
+							Title string `json:"title"`
+						} `json:"properties"`
+					} `json:"addSheet"`
+				} `json:"requests"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			addedTab = body.Requests[0].AddSheet.Properties.Title
+		case r.Method == http.MethodPut:
+			_ = json.NewDecoder(r.Body).Decode(&written)
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := NewGoogleClient(func() (Credentials, error) {
+		return Credentials{ClientID: "id", ClientSecret: "secret", RefreshToken: "refresh"}, nil
+	}).(*googleClient)
+	client.baseURL = server.URL + "/v4/spreadsheets"
+	client.tokenURL = server.URL + "/token"
+
+	if err := NewExporter(client).ExportRoutes(context.Background(), "sheet-1", "Friday", testRoutes()); err != nil {
+		t.Fatalf("ExportRoutes() error = %v", err)
+	}
+	if addedTab != "Friday" {
+		t.Fatalf("added tab = %q, want Friday", addedTab)
+	}
+	if written.Range != "'Friday'" || len(written.Values) != 4 || written.Values[3][0] != "Eli (Van 1)" {
+		t.Fatalf("written = %+v, want header plus three stop rows on 'Friday'", written)
+	}
+}
+
+func TestGoogleClientWithoutCredentialsIsNotConfigured(t *testing.T) {
+	client := NewGoogleClient(func() (Credentials, error) { return Credentials{}, nil })
+	err := NewExporter(client).ExportRoutes(context.Background(), "sheet-1", "Friday", testRoutes())
+	if !errors.Is(err, ErrNotConfigured) {
+		t.Fatalf("ExportRoutes() error = %v, want ErrNotConfigured", err)
+	}
+}
@@ -0,0 +1,210 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"ride-home-router/internal/database"
+	"ride-home-router/internal/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// BackupRetention is the number of rotating backups kept next to the database.
+	BackupRetention = 5
+
+	backupTimestampLayout = "20060102-150405.000000000"
+)
+
+// backupPrefix returns the file name prefix shared by all backups of the
+// database, e.g. "data.backup-" for data.db.
+func (s *Store) backupPrefix() string {
+	base := filepath.Base(s.dbPath)
+	return strings.TrimSuffix(base, filepath.Ext(base)) + ".backup-"
+}
+
+// Backup writes a consistent copy of the database alongside the main file
+// and prunes copies beyond BackupRetention.
+func (s *Store) Backup(ctx context.Context) (*models.Backup, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	createdAt := time.Now().UTC()
+	name := s.backupPrefix() + createdAt.Format(backupTimestampLayout) + ".db"
+	path := filepath.Join(filepath.Dir(s.dbPath), name)
+	if _, err := s.db.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return nil, fmt.Errorf("failed to back up database: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat backup: %w", err)
+	}
+	if err := s.pruneBackups(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("SQLite backup written: %s", path)
+	return &models.Backup{Name: name, CreatedAt: createdAt, SizeBytes: info.Size()}, nil
+}
+
+// ListBackups returns the available backups, newest first.
+func (s *Store) ListBackups() ([]models.Backup, error) {
+	dir := filepath.Dir(s.dbPath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	prefix := s.backupPrefix()
+	backups := make([]models.Backup, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".db") {
+			continue
+		}
+		createdAt, err := time.Parse(backupTimestampLayout, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".db"))
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat backup %s: %w", name, err)
+		}
+		backups = append(backups, models.Backup{Name: name, CreatedAt: createdAt, SizeBytes: info.Size()})
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+	return backups, nil
+}
+
+func (s *Store) pruneBackups() error {
+	backups, err := s.ListBackups()
+	if err != nil {
+		return err
+	}
+	for _, backup := range backups[min(BackupRetention, len(backups)):] {
+		if err := os.Remove(filepath.Join(filepath.Dir(s.dbPath), backup.Name)); err != nil {
+			return fmt.Errorf("failed to remove old backup %s: %w", backup.Name, err)
+		}
+	}
+	return nil
+}
+
+// RestoreBackup replaces the database with the named backup and reopens it.
+// It returns database.ErrNotFound when no backup has that name.
+func (s *Store) RestoreBackup(ctx context.Context, name string) error {
+	backups, err := s.ListBackups()
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, backup := range backups {
+		if backup.Name == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return database.ErrNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, _ = s.db.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)")
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("failed to close database before restore: %w", err)
+	}
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(s.dbPath + suffix); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s file: %w", suffix, err)
+		}
+	}
+	copyErr := copyFile(filepath.Join(filepath.Dir(s.dbPath), name), s.dbPath)
+
+	// Reopen even if the copy failed so the store keeps serving the old data.
+	db, err := openDatabase(s.dbPath)
+	if err != nil {
+		return err
+	}
+	s.db = db
+	if copyErr != nil {
+		return fmt.Errorf("failed to restore backup %s: %w", name, copyErr)
+	}
+	if err := s.initSchema(); err != nil {
+		return fmt.Errorf("failed to migrate restored backup: %w", err)
+	}
+
+	log.Printf("SQLite database restored from backup: %s", name)
+	return nil
+}
+
+// copyFile writes src to a temporary file next to dst and renames it into
+// place so a failed copy never leaves a truncated database behind.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src) //nolint:gosec // G304: src is a listed backup in the database directory.
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), filepath.Base(dst)+".restore-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+// StartBackups writes a backup every interval until StopBackups or Close is
+// called. A non-positive interval disables periodic backups.
+func (s *Store) StartBackups(interval time.Duration) {
+	if interval <= 0 || s.backupStop != nil {
+		return
+	}
+	s.backupStop = make(chan struct{})
+	s.backupDone = make(chan struct{})
+
+	go func(stop <-chan struct{}, done chan<- struct{}) {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if _, err := s.Backup(context.Background()); err != nil {
+					log.Printf("[ERROR] Periodic SQLite backup failed: err=%v", err)
+				}
+			}
+		}
+	}(s.backupStop, s.backupDone)
+
+	log.Printf("SQLite periodic backups enabled: interval=%v retention=%d", interval, BackupRetention)
+}
+
+// StopBackups stops periodic backups started by StartBackups.
+func (s *Store) StopBackups() {
+	if s.backupStop == nil {
+		return
+	}
+	close(s.backupStop)
+	<-s.backupDone
+	s.backupStop = nil
+	s.backupDone = nil
+}
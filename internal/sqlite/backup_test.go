@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"ride-home-router/internal/database"
+	"testing"
+)
+
+func TestStoreBackupCanBeRestored(t *testing.T) {
+	store := newTestLabelStore(t)
+	ctx := context.Background()
+
+	participant := createTestParticipant(t, store, "Rider One")
+	backup, err := store.Backup(ctx)
+	if err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	if err := store.Participants().Delete(ctx, participant.ID); err != nil {
+		t.Fatalf("delete participant: %v", err)
+	}
+	createTestParticipant(t, store, "Rider Two")
+
+	backups, err := store.ListBackups()
+	if err != nil {
+		t.Fatalf("ListBackups() error = %v", err)
+	}
+	if len(backups) != 1 || backups[0].Name != backup.Name || backups[0].SizeBytes == 0 {
+		t.Fatalf("backups = %+v, want the single backup %s", backups, backup.Name)
+	}
+
+	if err := store.RestoreBackup(ctx, backup.Name); err != nil {
+		t.Fatalf("RestoreBackup() error = %v", err)
+	}
+	participants, err := store.Participants().List(ctx, "")
+	if err != nil {
+		t.Fatalf("list participants after restore: %v", err)
+	}
+	if len(participants) != 1 || participants[0].Name != "Rider One" {
+		t.Fatalf("participants after restore = %+v, want only Rider One", participants)
+	}
+
+	if err := store.RestoreBackup(ctx, "../data.db"); !errors.Is(err, database.ErrNotFound) {
+		t.Fatalf("RestoreBackup(unknown) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestStoreBackupKeepsOnlyRecentCopies(t *testing.T) {
+	store := newTestLabelStore(t)
+	ctx := context.Background()
+
+	var newest string
+	for range BackupRetention + 2 {
+		backup, err := store.Backup(ctx)
+		if err != nil {
+			t.Fatalf("Backup() error = %v", err)
+		}
+		newest = backup.Name
+	}
+
+	backups, err := store.ListBackups()
+	if err != nil {
+		t.Fatalf("ListBackups() error = %v", err)
+	}
+	if len(backups) != BackupRetention {
+		t.Fatalf("got %d backups, want %d", len(backups), BackupRetention)
+	}
+	if backups[0].Name != newest {
+		t.Fatalf("newest backup = %s, want %s", backups[0].Name, newest)
+	}
+}
@@ -9,8 +9,28 @@ import (
 	"strings"
 )
 
+// distanceCacheRepository stores distances under a scope so each routing
+// provider keeps its own entries. The store's repository reads and writes the
+// default scope, while its DeleteTouching, Clear and Count span every scope;
+// a view from Scoped confines all of them to its scope.
 type distanceCacheRepository struct {
-	store *Store
+	store  *Store
+	scope  string
+	scoped bool
+}
+
+// Scoped returns a view of the cache holding only scope's entries.
+func (r *distanceCacheRepository) Scoped(scope string) database.DistanceCacheRepository {
+	return &distanceCacheRepository{store: r.store, scope: scope, scoped: true}
+}
+
+// scopeFilter returns the WHERE condition that limits maintenance queries to
+// the view's scope, or none for the store's repository.
+func (r *distanceCacheRepository) scopeFilter() (string, []any) {
+	if !r.scoped {
+		return "1 = 1", nil
+	}
+	return "scope = ?", []any{r.scope}
 }
 
 func makeCacheKey(origin, dest models.Coordinates) string {
@@ -29,7 +49,7 @@ func (r *distanceCacheRepository) Get(ctx context.Context, origin, dest models.C
 
 	query := `SELECT origin_lat, origin_lng, dest_lat, dest_lng, distance_meters, duration_secs
 	          FROM distance_cache
-	          WHERE origin_lat = ? AND origin_lng = ? AND dest_lat = ? AND dest_lng = ?`
+	          WHERE scope = ? AND origin_lat = ? AND origin_lng = ? AND dest_lat = ? AND dest_lng = ?`
 
 	originLat := models.RoundCoordinate(origin.Lat)
 	originLng := models.RoundCoordinate(origin.Lng)
@@ -37,7 +57,7 @@ func (r *distanceCacheRepository) Get(ctx context.Context, origin, dest models.C
 	destLng := models.RoundCoordinate(dest.Lng)
 
 	var entry models.DistanceCacheEntry
-	err := r.store.db.QueryRowContext(ctx, query, originLat, originLng, destLat, destLng).Scan(
+	err := r.store.db.QueryRowContext(ctx, query, r.scope, originLat, originLng, destLat, destLng).Scan(
 		&entry.Origin.Lat, &entry.Origin.Lng,
 		&entry.Destination.Lat, &entry.Destination.Lng,
 		&entry.DistanceMeters, &entry.DurationSecs,
@@ -82,7 +102,7 @@ func (r *distanceCacheRepository) GetBatch(ctx context.Context, pairs []struct{
 		end := min(start+distanceCacheBatchSize, len(uniquePairs))
 		chunk := uniquePairs[start:end]
 
-		query, args := buildDistanceCacheBatchQuery(chunk)
+		query, args := buildDistanceCacheBatchQuery(r.scope, chunk)
 		if err := func() error {
 			rows, err := r.store.db.QueryContext(ctx, query, args...)
 			if err != nil {
@@ -114,9 +134,9 @@ func (r *distanceCacheRepository) GetBatch(ctx context.Context, pairs []struct{
 	return result, nil
 }
 
-func buildDistanceCacheBatchQuery(pairs []struct{ Origin, Dest models.Coordinates }) (string, []any) {
+func buildDistanceCacheBatchQuery(scope string, pairs []struct{ Origin, Dest models.Coordinates }) (string, []any) {
 	valuePlaceholders := make([]string, len(pairs))
-	args := make([]any, 0, len(pairs)*4)
+	args := make([]any, 0, len(pairs)*4+1)
 	for i, pair := range pairs {
 		valuePlaceholders[i] = "(?, ?, ?, ?)"
 		args = append(
@@ -138,9 +158,10 @@ func buildDistanceCacheBatchQuery(pairs []struct{ Origin, Dest models.Coordinate
 	  ON dc.origin_lat = r.origin_lat
 	 AND dc.origin_lng = r.origin_lng
 	 AND dc.dest_lat = r.dest_lat
-	 AND dc.dest_lng = r.dest_lng`, strings.Join(valuePlaceholders, ", "))
+	 AND dc.dest_lng = r.dest_lng
+	WHERE dc.scope = ?`, strings.Join(valuePlaceholders, ", "))
 
-	return query, args
+	return query, append(args, scope)
 }
 
 func (r *distanceCacheRepository) Set(ctx context.Context, entry *models.DistanceCacheEntry) error {
@@ -148,8 +169,8 @@ func (r *distanceCacheRepository) Set(ctx context.Context, entry *models.Distanc
 	defer r.store.mu.Unlock()
 
 	query := `INSERT OR REPLACE INTO distance_cache
-	          (origin_lat, origin_lng, dest_lat, dest_lng, distance_meters, duration_secs)
-	          VALUES (?, ?, ?, ?, ?, ?)`
+	          (scope, origin_lat, origin_lng, dest_lat, dest_lng, distance_meters, duration_secs)
+	          VALUES (?, ?, ?, ?, ?, ?, ?)`
 
 	originLat := models.RoundCoordinate(entry.Origin.Lat)
 	originLng := models.RoundCoordinate(entry.Origin.Lng)
@@ -158,7 +179,7 @@ func (r *distanceCacheRepository) Set(ctx context.Context, entry *models.Distanc
 
 	_, err := r.store.db.ExecContext(
 		ctx, query,
-		originLat, originLng, destLat, destLng,
+		r.scope, originLat, originLng, destLat, destLng,
 		entry.DistanceMeters, entry.DurationSecs,
 	)
 	if err != nil {
@@ -183,8 +204,8 @@ func (r *distanceCacheRepository) SetBatch(ctx context.Context, entries []models
 	defer func() { _ = tx.Rollback() }()
 
 	query := `INSERT OR REPLACE INTO distance_cache
-	          (origin_lat, origin_lng, dest_lat, dest_lng, distance_meters, duration_secs)
-	          VALUES (?, ?, ?, ?, ?, ?)`
+	          (scope, origin_lat, origin_lng, dest_lat, dest_lng, distance_meters, duration_secs)
+	          VALUES (?, ?, ?, ?, ?, ?, ?)`
 
 	stmt, err := tx.PrepareContext(ctx, query)
 	if err != nil {
@@ -198,7 +219,7 @@ func (r *distanceCacheRepository) SetBatch(ctx context.Context, entries []models
 		destLat := models.RoundCoordinate(entry.Destination.Lat)
 		destLng := models.RoundCoordinate(entry.Destination.Lng)
 
-		_, err := stmt.ExecContext(ctx, originLat, originLng, destLat, destLng,
+		_, err := stmt.ExecContext(ctx, r.scope, originLat, originLng, destLat, destLng,
 			entry.DistanceMeters, entry.DurationSecs)
 		if err != nil {
 			return fmt.Errorf("failed to insert batch entry: %w", err)
@@ -212,14 +233,87 @@ func (r *distanceCacheRepository) SetBatch(ctx context.Context, entries []models
 	return nil
 }
 
+func (r *distanceCacheRepository) Delete(ctx context.Context, origin, dest models.Coordinates) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	query := `DELETE FROM distance_cache
+	          WHERE scope = ? AND origin_lat = ? AND origin_lng = ? AND dest_lat = ? AND dest_lng = ?`
+
+	_, err := r.store.db.ExecContext(
+		ctx, query, r.scope,
+		models.RoundCoordinate(origin.Lat), models.RoundCoordinate(origin.Lng),
+		models.RoundCoordinate(dest.Lat), models.RoundCoordinate(dest.Lng),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete distance cache entry: %w", err)
+	}
+
+	return nil
+}
+
+func (r *distanceCacheRepository) DeleteTouching(ctx context.Context, point models.Coordinates) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	filter, args := r.scopeFilter()
+	query := `DELETE FROM distance_cache
+	          WHERE ((origin_lat = ? AND origin_lng = ?) OR (dest_lat = ? AND dest_lng = ?)) AND ` + filter
+
+	lat := models.RoundCoordinate(point.Lat)
+	lng := models.RoundCoordinate(point.Lng)
+	result, err := r.store.db.ExecContext(ctx, query, append([]any{lat, lng, lat, lng}, args...)...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete distance cache entries: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rows), nil
+}
+
 func (r *distanceCacheRepository) Clear(ctx context.Context) error {
 	r.store.mu.Lock()
 	defer r.store.mu.Unlock()
 
-	_, err := r.store.db.ExecContext(ctx, "DELETE FROM distance_cache")
+	filter, args := r.scopeFilter()
+	_, err := r.store.db.ExecContext(ctx, "DELETE FROM distance_cache WHERE "+filter, args...)
 	if err != nil {
 		return fmt.Errorf("failed to clear distance cache: %w", err)
 	}
 
 	return nil
 }
+
+func (r *distanceCacheRepository) Count(ctx context.Context) (int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	filter, args := r.scopeFilter()
+	var count int
+	if err := r.store.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM distance_cache WHERE "+filter, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count distance cache entries: %w", err)
+	}
+
+	return count, nil
+}
+
+// SizeBytes sums the pages the cache table and its indexes occupy, across
+// every scope.
+func (r *distanceCacheRepository) SizeBytes(ctx context.Context) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	query := `SELECT COALESCE(SUM(pgsize), 0) FROM dbstat
+	          WHERE name IN (SELECT name FROM sqlite_master WHERE tbl_name = 'distance_cache')`
+
+	var size int64
+	if err := r.store.db.QueryRowContext(ctx, query).Scan(&size); err != nil {
+		return 0, fmt.Errorf("failed to measure distance cache size: %w", err)
+	}
+
+	return size, nil
+}
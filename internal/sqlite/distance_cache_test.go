@@ -2,7 +2,10 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"path/filepath"
+	"ride-home-router/internal/database"
 	"ride-home-router/internal/models"
 	"testing"
 )
@@ -89,3 +92,164 @@ func TestDistanceCacheGetBatch_EmptyInput(t *testing.T) {
 		t.Fatalf("expected empty result map, got %d entries", len(result))
 	}
 }
+
+func TestDistanceCacheDelete_RemovesOnlyTargetedPairs(t *testing.T) {
+	store, err := New(filepath.Join(t.TempDir(), "distance-cache-delete.db"))
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	ctx := context.Background()
+	corrected := models.Coordinates{Lat: 40.1, Lng: -74.1}
+	other := models.Coordinates{Lat: 40.2, Lng: -74.2}
+	third := models.Coordinates{Lat: 40.3, Lng: -74.3}
+	if err := store.DistanceCache().SetBatch(ctx, []models.DistanceCacheEntry{
+		{Origin: corrected, Destination: other, DistanceMeters: 1},
+		{Origin: other, Destination: corrected, DistanceMeters: 2},
+		{Origin: third, Destination: corrected, DistanceMeters: 3},
+		{Origin: other, Destination: third, DistanceMeters: 4},
+		{Origin: third, Destination: other, DistanceMeters: 5},
+	}); err != nil {
+		t.Fatalf("SetBatch() error = %v", err)
+	}
+
+	if err := store.DistanceCache().Delete(ctx, third, other); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.DistanceCache().Get(ctx, third, other); !errors.Is(err, database.ErrCacheMiss) {
+		t.Fatalf("Get(deleted pair) error = %v, want cache miss", err)
+	}
+	if _, err := store.DistanceCache().Get(ctx, other, third); err != nil {
+		t.Fatalf("Get(reverse pair) error = %v, want it kept", err)
+	}
+
+	removed, err := store.DistanceCache().DeleteTouching(ctx, models.Coordinates{Lat: 40.100001, Lng: -74.100001})
+	if err != nil {
+		t.Fatalf("DeleteTouching() error = %v", err)
+	}
+	if removed != 3 {
+		t.Fatalf("removed = %d, want 3", removed)
+	}
+	for _, pair := range [][2]models.Coordinates{{corrected, other}, {other, corrected}, {third, corrected}} {
+		if _, err := store.DistanceCache().Get(ctx, pair[0], pair[1]); !errors.Is(err, database.ErrCacheMiss) {
+			t.Fatalf("Get(%v -> %v) error = %v, want cache miss", pair[0], pair[1], err)
+		}
+	}
+	entry, err := store.DistanceCache().Get(ctx, other, third)
+	if err != nil || entry.DistanceMeters != 4 {
+		t.Fatalf("Get(other -> third) = %+v, %v, want the unrelated pair kept", entry, err)
+	}
+}
+
+func TestDistanceCacheCountAndSizeBytes(t *testing.T) {
+	store, err := New(filepath.Join(t.TempDir(), "distance-cache-stats.db"))
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	ctx := context.Background()
+	if count, err := store.DistanceCache().Count(ctx); err != nil || count != 0 {
+		t.Fatalf("Count() on an empty cache = %d, %v; want 0", count, err)
+	}
+	if err := store.DistanceCache().SetBatch(ctx, []models.DistanceCacheEntry{
+		{Origin: models.Coordinates{Lat: 40.1, Lng: -74.1}, Destination: models.Coordinates{Lat: 40.2, Lng: -74.2}, DistanceMeters: 1},
+		{Origin: models.Coordinates{Lat: 40.2, Lng: -74.2}, Destination: models.Coordinates{Lat: 40.1, Lng: -74.1}, DistanceMeters: 2},
+	}); err != nil {
+		t.Fatalf("SetBatch() error = %v", err)
+	}
+	if count, err := store.DistanceCache().Count(ctx); err != nil || count != 2 {
+		t.Fatalf("Count() = %d, %v; want 2", count, err)
+	}
+	if size, err := store.DistanceCache().SizeBytes(ctx); err != nil || size <= 0 {
+		t.Fatalf("SizeBytes() = %d, %v; want the table's pages", size, err)
+	}
+}
+
+func TestDistanceCacheScopesKeepEntriesApart(t *testing.T) {
+	store, err := New(filepath.Join(t.TempDir(), "distance-cache-scopes.db"))
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	ctx := context.Background()
+	origin := models.Coordinates{Lat: 40.1, Lng: -74.1}
+	dest := models.Coordinates{Lat: 40.2, Lng: -74.2}
+	first := store.DistanceCache().(database.ScopedDistanceCache).Scoped("osrm:first")
+	second := store.DistanceCache().(database.ScopedDistanceCache).Scoped("osrm:second")
+	for cache, meters := range map[database.DistanceCacheRepository]float64{store.DistanceCache(): 1000, first: 2000, second: 3000} {
+		if err := cache.Set(ctx, &models.DistanceCacheEntry{Origin: origin, Destination: dest, DistanceMeters: meters}); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+
+	for cache, want := range map[database.DistanceCacheRepository]float64{store.DistanceCache(): 1000, first: 2000, second: 3000} {
+		entry, err := cache.Get(ctx, origin, dest)
+		if err != nil || entry.DistanceMeters != want {
+			t.Fatalf("Get() = %+v, %v; want %.0f from the cache's own scope", entry, err, want)
+		}
+		batch, err := cache.GetBatch(ctx, []struct{ Origin, Dest models.Coordinates }{{Origin: origin, Dest: dest}})
+		if err != nil || batch[makeCacheKey(origin, dest)].DistanceMeters != want {
+			t.Fatalf("GetBatch() = %+v, %v; want %.0f from the cache's own scope", batch, err, want)
+		}
+	}
+	if count, err := first.Count(ctx); err != nil || count != 1 {
+		t.Fatalf("scoped Count() = %d, %v; want 1", count, err)
+	}
+	if count, err := store.DistanceCache().Count(ctx); err != nil || count != 3 {
+		t.Fatalf("Count() = %d, %v; want every scope's 3 entries", count, err)
+	}
+
+	if err := first.Clear(ctx); err != nil {
+		t.Fatalf("scoped Clear() error = %v", err)
+	}
+	if count, _ := store.DistanceCache().Count(ctx); count != 2 {
+		t.Fatalf("Count() after clearing one scope = %d, want 2", count)
+	}
+	if removed, err := store.DistanceCache().DeleteTouching(ctx, dest); err != nil || removed != 2 {
+		t.Fatalf("DeleteTouching() = %d, %v; want both remaining scopes' entries removed", removed, err)
+	}
+}
+
+func TestStoreMigrationStartsTheScopedDistanceCacheEmpty(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "distance-cache-v21.db")
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	if _, err := db.Exec(`
+		CREATE TABLE schema_version (version INTEGER PRIMARY KEY);
+		INSERT INTO schema_version (version) VALUES (21);
+		CREATE TABLE distance_cache (
+			origin_lat REAL NOT NULL,
+			origin_lng REAL NOT NULL,
+			dest_lat REAL NOT NULL,
+			dest_lng REAL NOT NULL,
+			distance_meters REAL NOT NULL,
+			duration_secs REAL NOT NULL,
+			PRIMARY KEY (origin_lat, origin_lng, dest_lat, dest_lng)
+		);
+		INSERT INTO distance_cache VALUES (40.1, -74.1, 40.2, -74.2, 1000, 60);
+	`); err != nil {
+		t.Fatalf("create v21 database: %v", err)
+	}
+	_ = db.Close()
+
+	store, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	assertSchemaVersion(t, store.db, 22)
+	ctx := context.Background()
+	if count, err := store.DistanceCache().Count(ctx); err != nil || count != 0 {
+		t.Fatalf("Count() = %d, %v; want the unattributed entry dropped", count, err)
+	}
+	scoped := store.DistanceCache().(database.ScopedDistanceCache).Scoped("osrm:first")
+	if err := scoped.Set(ctx, &models.DistanceCacheEntry{Origin: models.Coordinates{Lat: 40.1, Lng: -74.1}, Destination: models.Coordinates{Lat: 40.2, Lng: -74.2}, DistanceMeters: 2000}); err != nil {
+		t.Fatalf("scoped Set() after migration error = %v", err)
+	}
+}
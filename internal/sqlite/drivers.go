@@ -15,6 +15,10 @@ type driverRepository struct {
 }
 
 func (r *driverRepository) List(ctx context.Context, search string) ([]models.Driver, error) {
+	return r.Search(ctx, search, database.SearchFieldAny)
+}
+
+func (r *driverRepository) Search(ctx context.Context, search string, field database.SearchField) ([]models.Driver, error) {
 	r.store.mu.RLock()
 	defer r.store.mu.RUnlock()
 
@@ -22,13 +26,14 @@ func (r *driverRepository) List(ctx context.Context, search string) ([]models.Dr
 	var err error
 
 	if search != "" {
-		query := `SELECT id, name, address, lat, lng, vehicle_capacity, created_at, updated_at
+		where, args := searchClause(search, field)
+		query := `SELECT id, name, address, lat, lng, vehicle_capacity, attributes, comfort_radius_meters, flex_capacity, return_to_institute, excluded_participant_ids, NOT active, is_accessible, max_route_duration_secs, created_at, updated_at
 		          FROM drivers
-		          WHERE name LIKE ?
+		          WHERE ` + where + `
 		          ORDER BY name`
-		rows, err = r.store.db.QueryContext(ctx, query, "%"+search+"%")
+		rows, err = r.store.db.QueryContext(ctx, query, args...)
 	} else {
-		query := `SELECT id, name, address, lat, lng, vehicle_capacity, created_at, updated_at
+		query := `SELECT id, name, address, lat, lng, vehicle_capacity, attributes, comfort_radius_meters, flex_capacity, return_to_institute, excluded_participant_ids, NOT active, is_accessible, max_route_duration_secs, created_at, updated_at
 		          FROM drivers
 		          ORDER BY name`
 		rows, err = r.store.db.QueryContext(ctx, query)
@@ -42,7 +47,7 @@ func (r *driverRepository) List(ctx context.Context, search string) ([]models.Dr
 	var drivers []models.Driver
 	for rows.Next() {
 		var d models.Driver
-		if err := rows.Scan(&d.ID, &d.Name, &d.Address, &d.Lat, &d.Lng, &d.VehicleCapacity, &d.CreatedAt, &d.UpdatedAt); err != nil {
+		if err := rows.Scan(&d.ID, &d.Name, &d.Address, &d.Lat, &d.Lng, &d.VehicleCapacity, scanJSON(&d.Attributes), &d.ComfortRadiusMeters, &d.FlexCapacity, &d.ReturnToInstitute, scanJSON(&d.ExcludedParticipantIDs), &d.Inactive, &d.IsAccessible, &d.MaxRouteDurationSecs, &d.CreatedAt, &d.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan driver: %w", err)
 		}
 		drivers = append(drivers, d)
@@ -59,12 +64,12 @@ func (r *driverRepository) GetByID(ctx context.Context, id int64) (*models.Drive
 	r.store.mu.RLock()
 	defer r.store.mu.RUnlock()
 
-	query := `SELECT id, name, address, lat, lng, vehicle_capacity, created_at, updated_at
+	query := `SELECT id, name, address, lat, lng, vehicle_capacity, attributes, comfort_radius_meters, flex_capacity, return_to_institute, excluded_participant_ids, NOT active, is_accessible, max_route_duration_secs, created_at, updated_at
 	          FROM drivers WHERE id = ?`
 
 	var d models.Driver
 	err := r.store.db.QueryRowContext(ctx, query, id).Scan(
-		&d.ID, &d.Name, &d.Address, &d.Lat, &d.Lng, &d.VehicleCapacity, &d.CreatedAt, &d.UpdatedAt,
+		&d.ID, &d.Name, &d.Address, &d.Lat, &d.Lng, &d.VehicleCapacity, scanJSON(&d.Attributes), &d.ComfortRadiusMeters, &d.FlexCapacity, &d.ReturnToInstitute, scanJSON(&d.ExcludedParticipantIDs), &d.Inactive, &d.IsAccessible, &d.MaxRouteDurationSecs, &d.CreatedAt, &d.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -93,7 +98,7 @@ func (r *driverRepository) GetByIDs(ctx context.Context, ids []int64) ([]models.
 	}
 
 	query := fmt.Sprintf( //nolint:gosec // G201: placeholder list is "?" only; values are bound args.
-		`SELECT id, name, address, lat, lng, vehicle_capacity, created_at, updated_at
+		`SELECT id, name, address, lat, lng, vehicle_capacity, attributes, comfort_radius_meters, flex_capacity, return_to_institute, excluded_participant_ids, NOT active, is_accessible, max_route_duration_secs, created_at, updated_at
 		 FROM drivers WHERE id IN (%s)`,
 		strings.Join(placeholders, ","),
 	)
@@ -107,7 +112,7 @@ func (r *driverRepository) GetByIDs(ctx context.Context, ids []int64) ([]models.
 	var drivers []models.Driver
 	for rows.Next() {
 		var d models.Driver
-		if err := rows.Scan(&d.ID, &d.Name, &d.Address, &d.Lat, &d.Lng, &d.VehicleCapacity, &d.CreatedAt, &d.UpdatedAt); err != nil {
+		if err := rows.Scan(&d.ID, &d.Name, &d.Address, &d.Lat, &d.Lng, &d.VehicleCapacity, scanJSON(&d.Attributes), &d.ComfortRadiusMeters, &d.FlexCapacity, &d.ReturnToInstitute, scanJSON(&d.ExcludedParticipantIDs), &d.Inactive, &d.IsAccessible, &d.MaxRouteDurationSecs, &d.CreatedAt, &d.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan driver: %w", err)
 		}
 		drivers = append(drivers, d)
@@ -124,11 +129,11 @@ func (r *driverRepository) Create(ctx context.Context, d *models.Driver) (*model
 	d.CreatedAt = now
 	d.UpdatedAt = now
 
-	query := `INSERT INTO drivers (name, address, lat, lng, vehicle_capacity, created_at, updated_at)
-	          VALUES (?, ?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO drivers (name, address, lat, lng, vehicle_capacity, attributes, comfort_radius_meters, flex_capacity, return_to_institute, excluded_participant_ids, active, is_accessible, max_route_duration_secs, created_at, updated_at)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	result, err := r.store.db.ExecContext(ctx, query,
-		d.Name, d.Address, d.Lat, d.Lng, d.VehicleCapacity, d.CreatedAt, d.UpdatedAt,
+		d.Name, d.Address, d.Lat, d.Lng, d.VehicleCapacity, jsonValue(d.Attributes), d.ComfortRadiusMeters, d.FlexCapacity, d.ReturnToInstitute, jsonValue(d.ExcludedParticipantIDs), !d.Inactive, d.IsAccessible, d.MaxRouteDurationSecs, d.CreatedAt, d.UpdatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create driver: %w", err)
@@ -158,9 +163,9 @@ func (r *driverRepository) CreateWithLabels(ctx context.Context, d *models.Drive
 	d.UpdatedAt = now
 
 	result, err := tx.ExecContext(ctx, `
-		INSERT INTO drivers (name, address, lat, lng, vehicle_capacity, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-	`, d.Name, d.Address, d.Lat, d.Lng, d.VehicleCapacity, d.CreatedAt, d.UpdatedAt)
+		INSERT INTO drivers (name, address, lat, lng, vehicle_capacity, attributes, comfort_radius_meters, flex_capacity, return_to_institute, excluded_participant_ids, active, is_accessible, max_route_duration_secs, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, d.Name, d.Address, d.Lat, d.Lng, d.VehicleCapacity, jsonValue(d.Attributes), d.ComfortRadiusMeters, d.FlexCapacity, d.ReturnToInstitute, jsonValue(d.ExcludedParticipantIDs), !d.Inactive, d.IsAccessible, d.MaxRouteDurationSecs, d.CreatedAt, d.UpdatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create driver: %w", err)
 	}
@@ -189,11 +194,11 @@ func (r *driverRepository) Update(ctx context.Context, d *models.Driver) (*model
 	d.UpdatedAt = time.Now()
 
 	query := `UPDATE drivers
-	          SET name = ?, address = ?, lat = ?, lng = ?, vehicle_capacity = ?, updated_at = ?
+	          SET name = ?, address = ?, lat = ?, lng = ?, vehicle_capacity = ?, attributes = ?, comfort_radius_meters = ?, flex_capacity = ?, return_to_institute = ?, excluded_participant_ids = ?, active = ?, is_accessible = ?, max_route_duration_secs = ?, updated_at = ?
 	          WHERE id = ?`
 
 	result, err := r.store.db.ExecContext(ctx, query,
-		d.Name, d.Address, d.Lat, d.Lng, d.VehicleCapacity, d.UpdatedAt, d.ID,
+		d.Name, d.Address, d.Lat, d.Lng, d.VehicleCapacity, jsonValue(d.Attributes), d.ComfortRadiusMeters, d.FlexCapacity, d.ReturnToInstitute, jsonValue(d.ExcludedParticipantIDs), !d.Inactive, d.IsAccessible, d.MaxRouteDurationSecs, d.UpdatedAt, d.ID,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update driver: %w", err)
@@ -224,9 +229,9 @@ func (r *driverRepository) UpdateWithLabels(ctx context.Context, d *models.Drive
 
 	result, err := tx.ExecContext(ctx, `
 		UPDATE drivers
-		SET name = ?, address = ?, lat = ?, lng = ?, vehicle_capacity = ?, updated_at = ?
+		SET name = ?, address = ?, lat = ?, lng = ?, vehicle_capacity = ?, attributes = ?, comfort_radius_meters = ?, flex_capacity = ?, return_to_institute = ?, excluded_participant_ids = ?, active = ?, is_accessible = ?, max_route_duration_secs = ?, updated_at = ?
 		WHERE id = ?
-	`, d.Name, d.Address, d.Lat, d.Lng, d.VehicleCapacity, d.UpdatedAt, d.ID)
+	`, d.Name, d.Address, d.Lat, d.Lng, d.VehicleCapacity, jsonValue(d.Attributes), d.ComfortRadiusMeters, d.FlexCapacity, d.ReturnToInstitute, jsonValue(d.ExcludedParticipantIDs), !d.Inactive, d.IsAccessible, d.MaxRouteDurationSecs, d.UpdatedAt, d.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update driver: %w", err)
 	}
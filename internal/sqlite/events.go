@@ -15,20 +15,49 @@ type eventRepository struct {
 }
 
 func (r *eventRepository) List(ctx context.Context, limit, offset int) ([]models.Event, int, error) {
+	return r.ListFiltered(ctx, models.EventFilter{}, limit, offset)
+}
+
+// ListFiltered lists events whose saved routes match the filter. A driver
+// matches the routes they drove; a participant matches the stops they rode in.
+func (r *eventRepository) ListFiltered(ctx context.Context, filter models.EventFilter, limit, offset int) ([]models.Event, int, error) {
 	r.store.mu.RLock()
 	defer r.store.mu.RUnlock()
 
+	var conditions []string
+	var args []any
+	if filter.DriverID != 0 {
+		conditions = append(conditions, `EXISTS (
+			SELECT 1 FROM event_routes er
+			WHERE er.event_id = events.id AND er.driver_id = ?
+		)`)
+		args = append(args, filter.DriverID)
+	}
+	if filter.ParticipantID != 0 {
+		conditions = append(conditions, `EXISTS (
+			SELECT 1 FROM event_routes er
+			JOIN event_route_stops ers ON ers.event_route_id = er.id
+			WHERE er.event_id = events.id AND ers.participant_id = ?
+		)`)
+		args = append(args, filter.ParticipantID)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
 	var total int
-	if err := r.store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM events`).Scan(&total); err != nil {
+	if err := r.store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM events `+where, args...).Scan(&total); err != nil {
 		return nil, 0, fmt.Errorf("failed to count events: %w", err)
 	}
 
 	rows, err := r.store.db.QueryContext(ctx, `
 		SELECT id, event_date, notes, mode, created_at
 		FROM events
+		`+where+`
 		ORDER BY event_date DESC
 		LIMIT ? OFFSET ?
-	`, limit, offset)
+	`, append(args, limit, offset)...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to query events: %w", err)
 	}
@@ -382,6 +411,36 @@ func (r *eventRepository) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
+func (r *eventRepository) RecentDriverRouteCounts(ctx context.Context, eventLimit int) (map[int64]int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows, err := r.store.db.QueryContext(ctx, `
+		SELECT driver_id, COUNT(*)
+		FROM event_routes
+		WHERE event_id IN (SELECT id FROM events ORDER BY event_date DESC, id DESC LIMIT ?)
+		GROUP BY driver_id
+	`, eventLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recent driver routes: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := make(map[int64]int)
+	for rows.Next() {
+		var driverID int64
+		var count int
+		if err := rows.Scan(&driverID, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan recent driver routes: %w", err)
+		}
+		counts[driverID] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating recent driver routes: %w", err)
+	}
+	return counts, nil
+}
+
 func (r *eventRepository) HasLegacyArchive(ctx context.Context) (bool, error) {
 	r.store.mu.RLock()
 	defer r.store.mu.RUnlock()
@@ -23,7 +23,7 @@ func TestStoreMigratesLegacyEventTablesAndPreservesVisibleHistory(t *testing.T)
 		}
 	})
 
-	assertSchemaVersion(t, store.db, 4)
+	assertSchemaVersion(t, store.db, 22)
 
 	for _, tableName := range []string{
 		"events_legacy",
@@ -124,7 +124,7 @@ func TestStoreFreshSchemaCreatesV4Tables(t *testing.T) {
 		}
 	})
 
-	assertSchemaVersion(t, store.db, 4)
+	assertSchemaVersion(t, store.db, 22)
 
 	for _, tableName := range []string{"events", "event_routes", "event_route_stops", "event_summaries"} {
 		assertTableExists(t, store.db, tableName)
@@ -465,3 +465,43 @@ func assertRowCount(t *testing.T, db *sql.DB, tableName string, want int) {
 		t.Fatalf("row count for %q = %d, want %d", tableName, count, want)
 	}
 }
+
+func TestEventRepositoryRecentDriverRouteCounts(t *testing.T) {
+	store, err := New(filepath.Join(t.TempDir(), "recent-drivers.db"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() {
+		if err := store.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+	})
+
+	ctx := context.Background()
+	createEvent := func(eventDate string, driverIDs ...int64) {
+		date, err := time.Parse("2006-01-02", eventDate)
+		if err != nil {
+			t.Fatalf("time.Parse() error = %v", err)
+		}
+		routes := make([]models.EventRoute, len(driverIDs))
+		for i, driverID := range driverIDs {
+			routes[i] = models.EventRoute{RouteOrder: i, DriverID: driverID, DriverName: "Driver", DriverAddress: "1 Driver Way", Mode: "dropoff"}
+		}
+		summary := &models.EventSummary{TotalDrivers: len(driverIDs), Mode: "dropoff"}
+		if _, err := store.Events().Create(ctx, &models.Event{EventDate: date, Mode: "dropoff"}, routes, summary); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	createEvent("2026-01-10", 3)
+	createEvent("2026-02-10", 1, 2)
+	createEvent("2026-03-10", 1)
+
+	counts, err := store.Events().RecentDriverRouteCounts(ctx, 2)
+	if err != nil {
+		t.Fatalf("RecentDriverRouteCounts() error = %v", err)
+	}
+	if len(counts) != 2 || counts[1] != 2 || counts[2] != 1 {
+		t.Fatalf("counts = %v, want drivers 1 and 2 from the two newest events only", counts)
+	}
+}
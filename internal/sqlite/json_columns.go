@@ -0,0 +1,52 @@
+package sqlite
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// jsonScanner decodes a JSON text column into target. Empty or NULL columns
+// leave the target untouched so rows written before the column existed load
+// as nil maps and slices.
+type jsonScanner struct {
+	target any
+}
+
+func scanJSON(target any) *jsonScanner {
+	return &jsonScanner{target: target}
+}
+
+func (s *jsonScanner) Scan(src any) error {
+	var raw []byte
+	switch value := src.(type) {
+	case nil:
+		return nil
+	case string:
+		raw = []byte(value)
+	case []byte:
+		raw = value
+	default:
+		return fmt.Errorf("unsupported JSON column type %T", src)
+	}
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, s.target); err != nil {
+		return fmt.Errorf("failed to decode JSON column: %w", err)
+	}
+	return nil
+}
+
+// jsonValue encodes a map or slice for a JSON text column, storing empty
+// values as an empty string.
+func jsonValue(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	switch string(data) {
+	case "null", "{}", "[]":
+		return ""
+	}
+	return string(data)
+}
@@ -370,12 +370,20 @@ func TestStoreMigratesV3DatabaseToV4LabelsSchema(t *testing.T) {
 		}
 	})
 
-	assertSchemaVersion(t, store.db, 4)
+	assertSchemaVersion(t, store.db, 22)
 	for _, tableName := range []string{"labels", "participant_labels", "driver_labels"} {
 		assertTableExists(t, store.db, tableName)
 	}
 	assertRowCount(t, store.db, "participants", 1)
 	assertRowCount(t, store.db, "drivers", 1)
+
+	participants, err := store.Participants().List(context.Background(), "")
+	if err != nil {
+		t.Fatalf("List() after migration error = %v", err)
+	}
+	if len(participants) != 1 || participants[0].Attributes != nil || participants[0].RequiredMatches != nil {
+		t.Fatalf("migrated participants = %+v, want one participant without attributes", participants)
+	}
 }
 
 func newTestLabelStore(t *testing.T) *Store {
@@ -15,6 +15,10 @@ type participantRepository struct {
 }
 
 func (r *participantRepository) List(ctx context.Context, search string) ([]models.Participant, error) {
+	return r.Search(ctx, search, database.SearchFieldAny)
+}
+
+func (r *participantRepository) Search(ctx context.Context, search string, field database.SearchField) ([]models.Participant, error) {
 	r.store.mu.RLock()
 	defer r.store.mu.RUnlock()
 
@@ -22,13 +26,14 @@ func (r *participantRepository) List(ctx context.Context, search string) ([]mode
 	var err error
 
 	if search != "" {
-		query := `SELECT id, name, address, lat, lng, created_at, updated_at
+		where, args := searchClause(search, field)
+		query := `SELECT id, name, address, lat, lng, attributes, required_matches, meeting_point, solo_ride, age, intermediate_stop, earliest_secs, latest_secs, forced_order, requires_accessible, created_at, updated_at
 		          FROM participants
-		          WHERE name LIKE ?
+		          WHERE ` + where + `
 		          ORDER BY name`
-		rows, err = r.store.db.QueryContext(ctx, query, "%"+search+"%")
+		rows, err = r.store.db.QueryContext(ctx, query, args...)
 	} else {
-		query := `SELECT id, name, address, lat, lng, created_at, updated_at
+		query := `SELECT id, name, address, lat, lng, attributes, required_matches, meeting_point, solo_ride, age, intermediate_stop, earliest_secs, latest_secs, forced_order, requires_accessible, created_at, updated_at
 		          FROM participants
 		          ORDER BY name`
 		rows, err = r.store.db.QueryContext(ctx, query)
@@ -42,7 +47,7 @@ func (r *participantRepository) List(ctx context.Context, search string) ([]mode
 	var participants []models.Participant
 	for rows.Next() {
 		var p models.Participant
-		if err := rows.Scan(&p.ID, &p.Name, &p.Address, &p.Lat, &p.Lng, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		if err := rows.Scan(&p.ID, &p.Name, &p.Address, &p.Lat, &p.Lng, scanJSON(&p.Attributes), scanJSON(&p.RequiredMatches), scanJSON(&p.MeetingPoint), &p.SoloRide, &p.Age, scanJSON(&p.IntermediateStop), &p.EarliestSecs, &p.LatestSecs, &p.ForcedOrder, &p.RequiresAccessible, &p.CreatedAt, &p.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan participant: %w", err)
 		}
 		participants = append(participants, p)
@@ -59,12 +64,12 @@ func (r *participantRepository) GetByID(ctx context.Context, id int64) (*models.
 	r.store.mu.RLock()
 	defer r.store.mu.RUnlock()
 
-	query := `SELECT id, name, address, lat, lng, created_at, updated_at
+	query := `SELECT id, name, address, lat, lng, attributes, required_matches, meeting_point, solo_ride, age, intermediate_stop, earliest_secs, latest_secs, forced_order, requires_accessible, created_at, updated_at
 	          FROM participants WHERE id = ?`
 
 	var p models.Participant
 	err := r.store.db.QueryRowContext(ctx, query, id).Scan(
-		&p.ID, &p.Name, &p.Address, &p.Lat, &p.Lng, &p.CreatedAt, &p.UpdatedAt,
+		&p.ID, &p.Name, &p.Address, &p.Lat, &p.Lng, scanJSON(&p.Attributes), scanJSON(&p.RequiredMatches), scanJSON(&p.MeetingPoint), &p.SoloRide, &p.Age, scanJSON(&p.IntermediateStop), &p.EarliestSecs, &p.LatestSecs, &p.ForcedOrder, &p.RequiresAccessible, &p.CreatedAt, &p.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -94,7 +99,7 @@ func (r *participantRepository) GetByIDs(ctx context.Context, ids []int64) ([]mo
 	}
 
 	query := fmt.Sprintf( //nolint:gosec // G201: placeholder list is "?" only; values are bound args.
-		`SELECT id, name, address, lat, lng, created_at, updated_at
+		`SELECT id, name, address, lat, lng, attributes, required_matches, meeting_point, solo_ride, age, intermediate_stop, earliest_secs, latest_secs, forced_order, requires_accessible, created_at, updated_at
 		 FROM participants WHERE id IN (%s)`,
 		strings.Join(placeholders, ","),
 	)
@@ -108,7 +113,7 @@ func (r *participantRepository) GetByIDs(ctx context.Context, ids []int64) ([]mo
 	var participants []models.Participant
 	for rows.Next() {
 		var p models.Participant
-		if err := rows.Scan(&p.ID, &p.Name, &p.Address, &p.Lat, &p.Lng, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		if err := rows.Scan(&p.ID, &p.Name, &p.Address, &p.Lat, &p.Lng, scanJSON(&p.Attributes), scanJSON(&p.RequiredMatches), scanJSON(&p.MeetingPoint), &p.SoloRide, &p.Age, scanJSON(&p.IntermediateStop), &p.EarliestSecs, &p.LatestSecs, &p.ForcedOrder, &p.RequiresAccessible, &p.CreatedAt, &p.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan participant: %w", err)
 		}
 		participants = append(participants, p)
@@ -125,11 +130,11 @@ func (r *participantRepository) Create(ctx context.Context, p *models.Participan
 	p.CreatedAt = now
 	p.UpdatedAt = now
 
-	query := `INSERT INTO participants (name, address, lat, lng, created_at, updated_at)
-	          VALUES (?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO participants (name, address, lat, lng, attributes, required_matches, meeting_point, solo_ride, age, intermediate_stop, earliest_secs, latest_secs, forced_order, requires_accessible, created_at, updated_at)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	result, err := r.store.db.ExecContext(ctx, query,
-		p.Name, p.Address, p.Lat, p.Lng, p.CreatedAt, p.UpdatedAt,
+		p.Name, p.Address, p.Lat, p.Lng, jsonValue(p.Attributes), jsonValue(p.RequiredMatches), jsonValue(p.MeetingPoint), p.SoloRide, p.Age, jsonValue(p.IntermediateStop), p.EarliestSecs, p.LatestSecs, p.ForcedOrder, p.RequiresAccessible, p.CreatedAt, p.UpdatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create participant: %w", err)
@@ -159,9 +164,9 @@ func (r *participantRepository) CreateWithLabels(ctx context.Context, p *models.
 	p.UpdatedAt = now
 
 	result, err := tx.ExecContext(ctx, `
-		INSERT INTO participants (name, address, lat, lng, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, p.Name, p.Address, p.Lat, p.Lng, p.CreatedAt, p.UpdatedAt)
+		INSERT INTO participants (name, address, lat, lng, attributes, required_matches, meeting_point, solo_ride, age, intermediate_stop, earliest_secs, latest_secs, forced_order, requires_accessible, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, p.Name, p.Address, p.Lat, p.Lng, jsonValue(p.Attributes), jsonValue(p.RequiredMatches), jsonValue(p.MeetingPoint), p.SoloRide, p.Age, jsonValue(p.IntermediateStop), p.EarliestSecs, p.LatestSecs, p.ForcedOrder, p.RequiresAccessible, p.CreatedAt, p.UpdatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create participant: %w", err)
 	}
@@ -190,11 +195,11 @@ func (r *participantRepository) Update(ctx context.Context, p *models.Participan
 	p.UpdatedAt = time.Now()
 
 	query := `UPDATE participants
-	          SET name = ?, address = ?, lat = ?, lng = ?, updated_at = ?
+	          SET name = ?, address = ?, lat = ?, lng = ?, attributes = ?, required_matches = ?, meeting_point = ?, solo_ride = ?, age = ?, intermediate_stop = ?, earliest_secs = ?, latest_secs = ?, forced_order = ?, requires_accessible = ?, updated_at = ?
 	          WHERE id = ?`
 
 	result, err := r.store.db.ExecContext(ctx, query,
-		p.Name, p.Address, p.Lat, p.Lng, p.UpdatedAt, p.ID,
+		p.Name, p.Address, p.Lat, p.Lng, jsonValue(p.Attributes), jsonValue(p.RequiredMatches), jsonValue(p.MeetingPoint), p.SoloRide, p.Age, jsonValue(p.IntermediateStop), p.EarliestSecs, p.LatestSecs, p.ForcedOrder, p.RequiresAccessible, p.UpdatedAt, p.ID,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update participant: %w", err)
@@ -225,9 +230,9 @@ func (r *participantRepository) UpdateWithLabels(ctx context.Context, p *models.
 
 	result, err := tx.ExecContext(ctx, `
 		UPDATE participants
-		SET name = ?, address = ?, lat = ?, lng = ?, updated_at = ?
+		SET name = ?, address = ?, lat = ?, lng = ?, attributes = ?, required_matches = ?, meeting_point = ?, solo_ride = ?, age = ?, intermediate_stop = ?, earliest_secs = ?, latest_secs = ?, forced_order = ?, requires_accessible = ?, updated_at = ?
 		WHERE id = ?
-	`, p.Name, p.Address, p.Lat, p.Lng, p.UpdatedAt, p.ID)
+	`, p.Name, p.Address, p.Lat, p.Lng, jsonValue(p.Attributes), jsonValue(p.RequiredMatches), jsonValue(p.MeetingPoint), p.SoloRide, p.Age, jsonValue(p.IntermediateStop), p.EarliestSecs, p.LatestSecs, p.ForcedOrder, p.RequiresAccessible, p.UpdatedAt, p.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update participant: %w", err)
 	}
@@ -0,0 +1,172 @@
+package sqlite
+
+import (
+	"context"
+	"ride-home-router/internal/database"
+	"ride-home-router/internal/models"
+	"slices"
+	"testing"
+)
+
+func TestParticipantAndDriverRoutingFieldsRoundTrip(t *testing.T) {
+	store := newTestLabelStore(t)
+	ctx := context.Background()
+	forcedOrder := 0
+
+	participant, err := store.Participants().Create(ctx, &models.Participant{
+		Name:               "Rider",
+		Address:            "1 Rider Way",
+		Attributes:         map[string]string{"language": "es"},
+		RequiredMatches:    []string{"language"},
+		MeetingPoint:       &models.Coordinates{Lat: 40.5, Lng: -73.5},
+		SoloRide:           true,
+		RequiresAccessible: true,
+		Age:                11,
+		IntermediateStop:   &models.Coordinates{Lat: 40.6, Lng: -73.6},
+		EarliestSecs:       600,
+		LatestSecs:         1800,
+		ForcedOrder:        &forcedOrder,
+	})
+	if err != nil {
+		t.Fatalf("create participant: %v", err)
+	}
+	driver, err := store.Drivers().Create(ctx, &models.Driver{
+		Name:                   "Driver",
+		Address:                "1 Driver Way",
+		VehicleCapacity:        3,
+		Attributes:             map[string]string{"language": "es"},
+		ComfortRadiusMeters:    1500,
+		FlexCapacity:           1,
+		ReturnToInstitute:      true,
+		ExcludedParticipantIDs: []int64{99},
+		Inactive:               true,
+		IsAccessible:           true,
+		MaxRouteDurationSecs:   2700,
+	})
+	if err != nil {
+		t.Fatalf("create driver: %v", err)
+	}
+
+	gotParticipant, err := store.Participants().GetByID(ctx, participant.ID)
+	if err != nil {
+		t.Fatalf("get participant: %v", err)
+	}
+	if gotParticipant.Attributes["language"] != "es" || !slices.Equal(gotParticipant.RequiredMatches, []string{"language"}) {
+		t.Fatalf("participant = %+v, want stored attributes and requirements", gotParticipant)
+	}
+	if gotParticipant.MeetingPoint == nil || *gotParticipant.MeetingPoint != (models.Coordinates{Lat: 40.5, Lng: -73.5}) {
+		t.Fatalf("meeting point = %v, want stored coordinates", gotParticipant.MeetingPoint)
+	}
+	if !gotParticipant.SoloRide || !gotParticipant.RequiresAccessible {
+		t.Fatalf("solo ride = %t requires accessible = %t, want both stored", gotParticipant.SoloRide, gotParticipant.RequiresAccessible)
+	}
+	if gotParticipant.Age != 11 {
+		t.Fatalf("age = %d, want 11", gotParticipant.Age)
+	}
+	if gotParticipant.IntermediateStop == nil || *gotParticipant.IntermediateStop != (models.Coordinates{Lat: 40.6, Lng: -73.6}) {
+		t.Fatalf("intermediate stop = %v, want stored coordinates", gotParticipant.IntermediateStop)
+	}
+	if gotParticipant.EarliestSecs != 600 || gotParticipant.LatestSecs != 1800 {
+		t.Fatalf("time window = %v-%v, want 600-1800", gotParticipant.EarliestSecs, gotParticipant.LatestSecs)
+	}
+	if gotParticipant.ForcedOrder == nil || *gotParticipant.ForcedOrder != 0 {
+		t.Fatalf("forced order = %v, want stored 0", gotParticipant.ForcedOrder)
+	}
+	gotDriver, err := store.Drivers().GetByID(ctx, driver.ID)
+	if err != nil {
+		t.Fatalf("get driver: %v", err)
+	}
+	if !gotDriver.SatisfiesRequirements(gotParticipant) {
+		t.Fatalf("driver attributes = %v, want to satisfy participant requirements", gotDriver.Attributes)
+	}
+	if gotDriver.ComfortRadiusMeters != 1500 || gotDriver.FlexCapacity != 1 || !gotDriver.ReturnToInstitute {
+		t.Fatalf("comfort radius = %v flex capacity = %d return = %t, want 1500, 1 and true", gotDriver.ComfortRadiusMeters, gotDriver.FlexCapacity, gotDriver.ReturnToInstitute)
+	}
+	if !gotDriver.Inactive || !gotDriver.IsAccessible {
+		t.Fatalf("inactive = %t accessible = %t, want both stored", gotDriver.Inactive, gotDriver.IsAccessible)
+	}
+	if gotDriver.MaxRouteDurationSecs != 2700 {
+		t.Fatalf("max route duration = %v, want 2700", gotDriver.MaxRouteDurationSecs)
+	}
+	if !slices.Equal(gotDriver.ExcludedParticipantIDs, []int64{99}) {
+		t.Fatalf("excluded participants = %v, want [99]", gotDriver.ExcludedParticipantIDs)
+	}
+
+	gotParticipant.Attributes = nil
+	gotParticipant.RequiredMatches = nil
+	gotParticipant.MeetingPoint = nil
+	if _, err := store.Participants().Update(ctx, gotParticipant); err != nil {
+		t.Fatalf("update participant: %v", err)
+	}
+	cleared, err := store.Participants().GetByID(ctx, participant.ID)
+	if err != nil {
+		t.Fatalf("get cleared participant: %v", err)
+	}
+	if cleared.Attributes != nil || cleared.RequiredMatches != nil || cleared.MeetingPoint != nil {
+		t.Fatalf("cleared participant = %+v, want no attributes", cleared)
+	}
+}
+
+func TestParticipantAndDriverSearchMatchesAddress(t *testing.T) {
+	store := newTestLabelStore(t)
+	ctx := context.Background()
+
+	for _, participant := range []models.Participant{
+		{Name: "Ada", Address: "12 Oak Street"},
+		{Name: "Ben", Address: "4 OAK STREET"},
+		{Name: "Oakley", Address: "9 Pine Road"},
+	} {
+		if _, err := store.Participants().Create(ctx, &participant); err != nil {
+			t.Fatalf("create participant %s: %v", participant.Name, err)
+		}
+	}
+	for _, driver := range []models.Driver{
+		{Name: "Cara", Address: "30 Oak Street", VehicleCapacity: 4},
+		{Name: "Dan", Address: "2 Elm Avenue", VehicleCapacity: 4},
+	} {
+		if _, err := store.Drivers().Create(ctx, &driver); err != nil {
+			t.Fatalf("create driver %s: %v", driver.Name, err)
+		}
+	}
+
+	participantNames := func(field database.SearchField, search string) []string {
+		t.Helper()
+		participants, err := store.Participants().Search(ctx, search, field)
+		if err != nil {
+			t.Fatalf("search participants %q in %q: %v", search, field, err)
+		}
+		names := make([]string, 0, len(participants))
+		for _, participant := range participants {
+			names = append(names, participant.Name)
+		}
+		return names
+	}
+
+	if got, want := participantNames(database.SearchFieldAny, "oak street"), []string{"Ada", "Ben"}; !slices.Equal(got, want) {
+		t.Fatalf("address search = %v, want %v", got, want)
+	}
+	if got, want := participantNames(database.SearchFieldAny, "oak"), []string{"Ada", "Ben", "Oakley"}; !slices.Equal(got, want) {
+		t.Fatalf("name or address search = %v, want %v", got, want)
+	}
+	if got, want := participantNames(database.SearchFieldName, "oak"), []string{"Oakley"}; !slices.Equal(got, want) {
+		t.Fatalf("name-only search = %v, want %v", got, want)
+	}
+	if got, want := participantNames(database.SearchFieldAddress, "oak"), []string{"Ada", "Ben"}; !slices.Equal(got, want) {
+		t.Fatalf("address-only search = %v, want %v", got, want)
+	}
+
+	drivers, err := store.Drivers().List(ctx, "Oak Street")
+	if err != nil {
+		t.Fatalf("list drivers: %v", err)
+	}
+	if len(drivers) != 1 || drivers[0].Name != "Cara" {
+		t.Fatalf("driver address search = %+v, want only Cara", drivers)
+	}
+	drivers, err = store.Drivers().Search(ctx, "Oak Street", database.SearchFieldName)
+	if err != nil {
+		t.Fatalf("search drivers by name: %v", err)
+	}
+	if len(drivers) != 0 {
+		t.Fatalf("driver name-only search = %+v, want none", drivers)
+	}
+}
@@ -0,0 +1,19 @@
+package sqlite
+
+import (
+	"ride-home-router/internal/database"
+	"strings"
+)
+
+// searchClause builds a case-insensitive substring match of search against
+// the columns selected by field. SQLite's LIKE ignores ASCII case.
+func searchClause(search string, field database.SearchField) (string, []any) {
+	columns := field.Columns()
+	conditions := make([]string, 0, len(columns))
+	args := make([]any, 0, len(columns))
+	for _, column := range columns {
+		conditions = append(conditions, column+" LIKE ?")
+		args = append(args, "%"+search+"%")
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
@@ -14,13 +14,13 @@ func (r *settingsRepository) Get(ctx context.Context) (*models.Settings, error)
 	r.store.mu.RLock()
 	defer r.store.mu.RUnlock()
 
-	query := `SELECT selected_activity_location_id, use_miles FROM settings WHERE id = 1`
+	query := `SELECT selected_activity_location_id, use_miles, reimbursement_rate, overflow_policy, nav_provider, household_grouping_precision FROM settings WHERE id = 1`
 
 	var s models.Settings
 	var selectedLocationID *int64
 	var useMiles int
 
-	err := r.store.db.QueryRowContext(ctx, query).Scan(&selectedLocationID, &useMiles)
+	err := r.store.db.QueryRowContext(ctx, query).Scan(&selectedLocationID, &useMiles, &s.ReimbursementRate, &s.OverflowPolicy, &s.NavProvider, &s.HouseholdGroupingPrecision)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get settings: %w", err)
 	}
@@ -47,8 +47,8 @@ func (r *settingsRepository) Update(ctx context.Context, s *models.Settings) err
 		selectedLocationID = &s.SelectedActivityLocationID
 	}
 
-	query := `UPDATE settings SET selected_activity_location_id = ?, use_miles = ? WHERE id = 1`
-	_, err := r.store.db.ExecContext(ctx, query, selectedLocationID, useMiles)
+	query := `UPDATE settings SET selected_activity_location_id = ?, use_miles = ?, reimbursement_rate = ?, overflow_policy = ?, nav_provider = ?, household_grouping_precision = ? WHERE id = 1`
+	_, err := r.store.db.ExecContext(ctx, query, selectedLocationID, useMiles, s.ReimbursementRate, s.OverflowPolicy, s.NavProvider, s.HouseholdGroupingPrecision)
 	if err != nil {
 		return fmt.Errorf("failed to update settings: %w", err)
 	}
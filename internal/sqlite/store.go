@@ -15,7 +15,7 @@ import (
 
 const (
 	DefaultDBFileName   = "data.db"
-	schemaVersion       = 4
+	schemaVersion       = 22
 	sqliteCacheSizeKB   = -64000 // 64MB cache (negative = KiB)
 	sqliteBusyTimeoutMS = 5000
 )
@@ -34,6 +34,9 @@ type Store struct {
 	eventRepo               database.EventRepository
 	distanceCacheRepo       database.DistanceCacheRepository
 	labelRepo               database.LabelRepository
+
+	backupStop chan struct{}
+	backupDone chan struct{}
 }
 
 // New creates a new SQLite store at the specified path
@@ -46,25 +49,9 @@ func New(dbPath string) (*Store, error) {
 
 	log.Printf("Opening SQLite database at: %s", dbPath)
 
-	db, err := sql.Open("sqlite", dbPath)
+	db, err := openDatabase(dbPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
-	}
-
-	// Enable foreign keys and WAL mode for better performance
-	pragmas := []string{
-		"PRAGMA foreign_keys = ON",
-		"PRAGMA journal_mode = WAL",
-		"PRAGMA synchronous = NORMAL",
-		fmt.Sprintf("PRAGMA cache_size = %d", sqliteCacheSizeKB),
-		fmt.Sprintf("PRAGMA busy_timeout = %d", sqliteBusyTimeoutMS),
-	}
-
-	for _, pragma := range pragmas {
-		if _, err := db.ExecContext(context.Background(), pragma); err != nil {
-			_ = db.Close()
-			return nil, fmt.Errorf("failed to set pragma %s: %w", pragma, err)
-		}
+		return nil, err
 	}
 
 	store := &Store{
@@ -91,6 +78,31 @@ func New(dbPath string) (*Store, error) {
 	return store, nil
 }
 
+func openDatabase(dbPath string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Enable foreign keys and WAL mode for better performance
+	pragmas := []string{
+		"PRAGMA foreign_keys = ON",
+		"PRAGMA journal_mode = WAL",
+		"PRAGMA synchronous = NORMAL",
+		fmt.Sprintf("PRAGMA cache_size = %d", sqliteCacheSizeKB),
+		fmt.Sprintf("PRAGMA busy_timeout = %d", sqliteBusyTimeoutMS),
+	}
+
+	for _, pragma := range pragmas {
+		if _, err := db.ExecContext(context.Background(), pragma); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("failed to set pragma %s: %w", pragma, err)
+		}
+	}
+
+	return db, nil
+}
+
 // GetDBPath returns the current database file path
 func (s *Store) GetDBPath() string {
 	return s.dbPath
@@ -136,6 +148,16 @@ func (s *Store) createSchema() error {
 		address TEXT NOT NULL,
 		lat REAL NOT NULL,
 		lng REAL NOT NULL,
+		attributes TEXT NOT NULL DEFAULT '',
+		required_matches TEXT NOT NULL DEFAULT '',
+		meeting_point TEXT NOT NULL DEFAULT '',
+		solo_ride INTEGER NOT NULL DEFAULT 0,
+		age INTEGER NOT NULL DEFAULT 0,
+		intermediate_stop TEXT NOT NULL DEFAULT '',
+		earliest_secs REAL NOT NULL DEFAULT 0,
+		latest_secs REAL NOT NULL DEFAULT 0,
+		forced_order INTEGER,
+		requires_accessible INTEGER NOT NULL DEFAULT 0,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
@@ -148,6 +170,14 @@ func (s *Store) createSchema() error {
 		lat REAL NOT NULL,
 		lng REAL NOT NULL,
 		vehicle_capacity INTEGER NOT NULL DEFAULT 4,
+		attributes TEXT NOT NULL DEFAULT '',
+		comfort_radius_meters REAL NOT NULL DEFAULT 0,
+		flex_capacity INTEGER NOT NULL DEFAULT 0,
+		return_to_institute INTEGER NOT NULL DEFAULT 0,
+		excluded_participant_ids TEXT NOT NULL DEFAULT '',
+		active INTEGER NOT NULL DEFAULT 1,
+		is_accessible INTEGER NOT NULL DEFAULT 0,
+		max_route_duration_secs REAL NOT NULL DEFAULT 0,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
@@ -190,6 +220,10 @@ func (s *Store) createSchema() error {
 		id INTEGER PRIMARY KEY CHECK (id = 1),
 		selected_activity_location_id INTEGER,
 		use_miles INTEGER NOT NULL DEFAULT 1,
+		reimbursement_rate REAL NOT NULL DEFAULT 0,
+		overflow_policy TEXT NOT NULL DEFAULT '',
+		nav_provider TEXT NOT NULL DEFAULT 'google',
+		household_grouping_precision INTEGER NOT NULL DEFAULT 4,
 		FOREIGN KEY (selected_activity_location_id) REFERENCES activity_locations(id) ON DELETE SET NULL
 	);
 	INSERT OR IGNORE INTO settings (id, use_miles) VALUES (1, 1);
@@ -254,13 +288,14 @@ func (s *Store) createSchema() error {
 
 	-- Distance cache
 	CREATE TABLE IF NOT EXISTS distance_cache (
+		scope TEXT NOT NULL DEFAULT '',
 		origin_lat REAL NOT NULL,
 		origin_lng REAL NOT NULL,
 		dest_lat REAL NOT NULL,
 		dest_lng REAL NOT NULL,
 		distance_meters REAL NOT NULL,
 		duration_secs REAL NOT NULL,
-		PRIMARY KEY (origin_lat, origin_lng, dest_lat, dest_lng)
+		PRIMARY KEY (scope, origin_lat, origin_lng, dest_lat, dest_lng)
 	);
 
 	-- Indexes for common queries
@@ -436,6 +471,249 @@ func (s *Store) runMigrations(fromVersion int) error {
 		}
 	}
 
+	if fromVersion < 5 {
+		for _, column := range []struct{ table, name string }{
+			{"participants", "attributes"},
+			{"participants", "required_matches"},
+			{"drivers", "attributes"},
+		} {
+			exists, err := tableExists(tx, column.table)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				continue
+			}
+			if err := ensureColumn(tx, column.table, column.name, "TEXT NOT NULL DEFAULT ''"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if fromVersion < 6 {
+		exists, err := tableExists(tx, "participants")
+		if err != nil {
+			return err
+		}
+		if exists {
+			if err := ensureColumn(tx, "participants", "meeting_point", "TEXT NOT NULL DEFAULT ''"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if fromVersion < 7 {
+		exists, err := tableExists(tx, "settings")
+		if err != nil {
+			return err
+		}
+		if exists {
+			if err := ensureColumn(tx, "settings", "reimbursement_rate", "REAL NOT NULL DEFAULT 0"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if fromVersion < 8 {
+		exists, err := tableExists(tx, "participants")
+		if err != nil {
+			return err
+		}
+		if exists {
+			if err := ensureColumn(tx, "participants", "solo_ride", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if fromVersion < 9 {
+		exists, err := tableExists(tx, "participants")
+		if err != nil {
+			return err
+		}
+		if exists {
+			if err := ensureColumn(tx, "participants", "age", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if fromVersion < 10 {
+		exists, err := tableExists(tx, "drivers")
+		if err != nil {
+			return err
+		}
+		if exists {
+			if err := ensureColumn(tx, "drivers", "comfort_radius_meters", "REAL NOT NULL DEFAULT 0"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if fromVersion < 11 {
+		for _, column := range []struct{ table, name, definition string }{
+			{"drivers", "flex_capacity", "INTEGER NOT NULL DEFAULT 0"},
+			{"settings", "overflow_policy", "TEXT NOT NULL DEFAULT ''"},
+		} {
+			exists, err := tableExists(tx, column.table)
+			if err != nil {
+				return err
+			}
+			if exists {
+				if err := ensureColumn(tx, column.table, column.name, column.definition); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if fromVersion < 12 {
+		exists, err := tableExists(tx, "participants")
+		if err != nil {
+			return err
+		}
+		if exists {
+			if err := ensureColumn(tx, "participants", "intermediate_stop", "TEXT NOT NULL DEFAULT ''"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if fromVersion < 13 {
+		exists, err := tableExists(tx, "participants")
+		if err != nil {
+			return err
+		}
+		if exists {
+			for _, name := range []string{"earliest_secs", "latest_secs"} {
+				if err := ensureColumn(tx, "participants", name, "REAL NOT NULL DEFAULT 0"); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if fromVersion < 14 {
+		exists, err := tableExists(tx, "drivers")
+		if err != nil {
+			return err
+		}
+		if exists {
+			if err := ensureColumn(tx, "drivers", "return_to_institute", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if fromVersion < 15 {
+		exists, err := tableExists(tx, "participants")
+		if err != nil {
+			return err
+		}
+		if exists {
+			if err := ensureColumn(tx, "participants", "forced_order", "INTEGER"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if fromVersion < 16 {
+		exists, err := tableExists(tx, "drivers")
+		if err != nil {
+			return err
+		}
+		if exists {
+			if err := ensureColumn(tx, "drivers", "excluded_participant_ids", "TEXT NOT NULL DEFAULT ''"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if fromVersion < 17 {
+		exists, err := tableExists(tx, "drivers")
+		if err != nil {
+			return err
+		}
+		if exists {
+			if err := ensureColumn(tx, "drivers", "active", "INTEGER NOT NULL DEFAULT 1"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if fromVersion < 18 {
+		exists, err := tableExists(tx, "settings")
+		if err != nil {
+			return err
+		}
+		if exists {
+			if err := ensureColumn(tx, "settings", "nav_provider", "TEXT NOT NULL DEFAULT 'google'"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if fromVersion < 19 {
+		for _, column := range []struct{ table, name string }{
+			{"participants", "requires_accessible"},
+			{"drivers", "is_accessible"},
+		} {
+			exists, err := tableExists(tx, column.table)
+			if err != nil {
+				return err
+			}
+			if exists {
+				if err := ensureColumn(tx, column.table, column.name, "INTEGER NOT NULL DEFAULT 0"); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if fromVersion < 20 {
+		exists, err := tableExists(tx, "drivers")
+		if err != nil {
+			return err
+		}
+		if exists {
+			if err := ensureColumn(tx, "drivers", "max_route_duration_secs", "REAL NOT NULL DEFAULT 0"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if fromVersion < 21 {
+		exists, err := tableExists(tx, "settings")
+		if err != nil {
+			return err
+		}
+		if exists {
+			if err := ensureColumn(tx, "settings", "household_grouping_precision", "INTEGER NOT NULL DEFAULT 4"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if fromVersion < 22 {
+		// Older caches mixed every provider's distances under one key, so
+		// there is no telling whose an entry is; start the scoped table empty.
+		if _, err := tx.ExecContext(context.Background(), `
+			DROP TABLE IF EXISTS distance_cache;
+			CREATE TABLE distance_cache (
+				scope TEXT NOT NULL DEFAULT '',
+				origin_lat REAL NOT NULL,
+				origin_lng REAL NOT NULL,
+				dest_lat REAL NOT NULL,
+				dest_lng REAL NOT NULL,
+				distance_meters REAL NOT NULL,
+				duration_secs REAL NOT NULL,
+				PRIMARY KEY (scope, origin_lat, origin_lng, dest_lat, dest_lng)
+			);
+		`); err != nil {
+			return fmt.Errorf("failed to scope distance cache: %w", err)
+		}
+	}
+
 	if _, err := tx.ExecContext(context.Background(), "UPDATE schema_version SET version = ?", schemaVersion); err != nil {
 		return fmt.Errorf("failed to update schema version: %w", err)
 	}
@@ -448,15 +726,19 @@ func (s *Store) runMigrations(fromVersion int) error {
 }
 
 func ensureEventRouteColumn(tx *sql.Tx, name, definition string) error {
-	exists, err := columnExists(tx, "event_routes", name)
+	return ensureColumn(tx, "event_routes", name, definition)
+}
+
+func ensureColumn(tx *sql.Tx, table, name, definition string) error {
+	exists, err := columnExists(tx, table, name)
 	if err != nil {
 		return err
 	}
 	if exists {
 		return nil
 	}
-	if _, err := tx.ExecContext(context.Background(), fmt.Sprintf("ALTER TABLE event_routes ADD COLUMN %s %s", name, definition)); err != nil {
-		return fmt.Errorf("failed to add event_routes.%s: %w", name, err)
+	if _, err := tx.ExecContext(context.Background(), fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, name, definition)); err != nil {
+		return fmt.Errorf("failed to add %s.%s: %w", table, name, err)
 	}
 	return nil
 }
@@ -821,6 +1103,7 @@ func backfillLegacySummary(tx *sql.Tx, eventID int64, mode string) error {
 
 // Close closes the database connection
 func (s *Store) Close() error {
+	s.StopBackups()
 	if s.db != nil {
 		// Checkpoint WAL before closing
 		_, _ = s.db.ExecContext(context.Background(), "PRAGMA wal_checkpoint(TRUNCATE)")
@@ -28,7 +28,12 @@ type Renderer struct {
 
 // New loads and precompiles all required templates from templatesFS.
 func New(templatesFS fs.FS) (*Renderer, error) {
-	base := template.New("").Funcs(templateutil.FuncMap())
+	return NewWithOptions(templatesFS, templateutil.Options{})
+}
+
+// NewWithOptions is New with configurable template helper formatting.
+func NewWithOptions(templatesFS fs.FS, opts templateutil.Options) (*Renderer, error) {
+	base := template.New("").Funcs(templateutil.FuncMapWithOptions(opts))
 
 	layout, err := fs.ReadFile(templatesFS, "templates/layout.html")
 	if err != nil {
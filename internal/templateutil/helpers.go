@@ -13,10 +13,31 @@ import (
 const (
 	metersPerMile      = 1609.344
 	metersPerKilometer = 1000.0
+
+	// DefaultHourThreshold is the duration from which formatDuration switches
+	// to hours and minutes.
+	DefaultHourThreshold = time.Hour
 )
 
+// Options tunes how the template helpers format values.
+type Options struct {
+	// HourThreshold is the shortest duration rendered as "1h 35m" rather than
+	// "95m". Zero uses DefaultHourThreshold.
+	HourThreshold time.Duration
+}
+
 // FuncMap returns the shared template helper functions used in production and tests.
 func FuncMap() template.FuncMap {
+	return FuncMapWithOptions(Options{})
+}
+
+// FuncMapWithOptions returns the shared template helpers configured by opts.
+func FuncMapWithOptions(opts Options) template.FuncMap {
+	hourThreshold := opts.HourThreshold
+	if hourThreshold <= 0 {
+		hourThreshold = DefaultHourThreshold
+	}
+
 	return template.FuncMap{
 		"formatDate": func(t time.Time) string {
 			return t.Format("2006-01-02")
@@ -48,15 +69,7 @@ func FuncMap() template.FuncMap {
 			return fmt.Sprintf("%.2f km", meters/metersPerKilometer)
 		},
 		"formatDuration": func(seconds float64) string {
-			mins := int(seconds / 60)
-			secs := int(seconds) % 60
-			if mins == 0 {
-				return fmt.Sprintf("%ds", secs)
-			}
-			if secs == 0 {
-				return fmt.Sprintf("%dm", mins)
-			}
-			return fmt.Sprintf("%dm %ds", mins, secs)
+			return FormatDuration(seconds, hourThreshold)
 		},
 		"initials": func(name string) string {
 			parts := strings.Fields(strings.TrimSpace(name))
@@ -119,3 +132,30 @@ func FuncMap() template.FuncMap {
 		},
 	}
 }
+
+// FormatDuration renders seconds as "45s", "12m 5s" or, from hourThreshold
+// upward, "1h 35m". Seconds are dropped once hours are shown.
+func FormatDuration(seconds float64, hourThreshold time.Duration) string {
+	if hourThreshold > 0 && seconds >= hourThreshold.Seconds() {
+		totalMins := int(seconds / 60)
+		hours := totalMins / 60
+		mins := totalMins % 60
+		if hours == 0 {
+			return fmt.Sprintf("%dm", mins)
+		}
+		if mins == 0 {
+			return fmt.Sprintf("%dh", hours)
+		}
+		return fmt.Sprintf("%dh %dm", hours, mins)
+	}
+
+	mins := int(seconds / 60)
+	secs := int(seconds) % 60
+	if mins == 0 {
+		return fmt.Sprintf("%ds", secs)
+	}
+	if secs == 0 {
+		return fmt.Sprintf("%dm", mins)
+	}
+	return fmt.Sprintf("%dm %ds", mins, secs)
+}
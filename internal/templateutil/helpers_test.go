@@ -0,0 +1,48 @@
+package templateutil
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		name      string
+		seconds   float64
+		threshold time.Duration
+		want      string
+	}{
+		{name: "zero", seconds: 0, threshold: time.Hour, want: "0s"},
+		{name: "seconds only", seconds: 45, threshold: time.Hour, want: "45s"},
+		{name: "whole minutes", seconds: 600, threshold: time.Hour, want: "10m"},
+		{name: "minutes and seconds", seconds: 725, threshold: time.Hour, want: "12m 5s"},
+		{name: "just under an hour", seconds: 3599, threshold: time.Hour, want: "59m 59s"},
+		{name: "exactly an hour", seconds: 3600, threshold: time.Hour, want: "1h"},
+		{name: "hour plus", seconds: 5700, threshold: time.Hour, want: "1h 35m"},
+		{name: "hour plus drops seconds", seconds: 5729, threshold: time.Hour, want: "1h 35m"},
+		{name: "multiple hours", seconds: 7200, threshold: time.Hour, want: "2h"},
+		{name: "lower threshold", seconds: 2700, threshold: 30 * time.Minute, want: "45m"},
+		{name: "higher threshold keeps short form", seconds: 5700, threshold: 2 * time.Hour, want: "95m"},
+		{name: "disabled threshold", seconds: 5700, threshold: 0, want: "95m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatDuration(tt.seconds, tt.threshold); got != tt.want {
+				t.Fatalf("FormatDuration(%v, %v) = %q, want %q", tt.seconds, tt.threshold, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuncMapWithOptionsUsesThreshold(t *testing.T) {
+	format := FuncMapWithOptions(Options{HourThreshold: 2 * time.Hour})["formatDuration"].(func(float64) string)
+	if got := format(5700); got != "95m" {
+		t.Fatalf("formatDuration(5700) = %q, want %q", got, "95m")
+	}
+
+	format = FuncMap()["formatDuration"].(func(float64) string)
+	if got := format(5700); got != "1h 35m" {
+		t.Fatalf("default formatDuration(5700) = %q, want %q", got, "1h 35m")
+	}
+}
@@ -186,12 +186,35 @@ func (c *MockDistanceCache) SetBatch(ctx context.Context, entries []models.Dista
 	return nil
 }
 
+func (c *MockDistanceCache) Delete(ctx context.Context, origin, dest models.Coordinates) error {
+	delete(c.entries, c.cacheKey(origin, dest))
+	return nil
+}
+
+func (c *MockDistanceCache) DeleteTouching(ctx context.Context, point models.Coordinates) (int, error) {
+	point = models.Coordinates{Lat: models.RoundCoordinate(point.Lat), Lng: models.RoundCoordinate(point.Lng)}
+	removed := 0
+	for key, entry := range c.entries {
+		origin := models.Coordinates{Lat: models.RoundCoordinate(entry.Origin.Lat), Lng: models.RoundCoordinate(entry.Origin.Lng)}
+		dest := models.Coordinates{Lat: models.RoundCoordinate(entry.Destination.Lat), Lng: models.RoundCoordinate(entry.Destination.Lng)}
+		if origin == point || dest == point {
+			delete(c.entries, key)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
 func (c *MockDistanceCache) Clear(ctx context.Context) error {
 	c.entries = make(map[string]*models.DistanceCacheEntry)
 	return nil
 }
 
 // Count returns the number of entries in the cache
-func (c *MockDistanceCache) Count() int {
-	return len(c.entries)
+func (c *MockDistanceCache) Count(ctx context.Context) (int, error) {
+	return len(c.entries), nil
+}
+
+func (c *MockDistanceCache) SizeBytes(ctx context.Context) (int64, error) {
+	return 0, nil
 }
@@ -99,6 +99,21 @@
         const [origin, ...rest] = resolvedLocations;
         const destination = rest[rest.length - 1];
         const waypoints = rest.slice(0, -1);
+
+        // Keep in step with navigationURL in route_qr.go.
+        if (options.provider === 'apple') {
+            const daddr = rest.map(encodeURIComponent).join('+to:');
+            const saddr = options.navigation === true ? '' : `saddr=${encodeURIComponent(origin)}&`;
+            return `https://maps.apple.com/?${saddr}daddr=${daddr}&dirflg=d`;
+        }
+        if (options.provider === 'osm') {
+            const params = new URLSearchParams({
+                engine: 'fossgis_osrm_car',
+                route: resolvedLocations.join(';'),
+            });
+            return `https://www.openstreetmap.org/directions?${params.toString()}`;
+        }
+
         const params = new URLSearchParams({
             api: '1',
             travelmode: 'driving',
@@ -137,7 +152,7 @@
         });
 
         if (includeMapsLink) {
-            const mapsUrl = generateMapsUrl(activityLocation, driverLocation, stops, mode, { navigation: true });
+            const mapsUrl = generateMapsUrl(activityLocation, driverLocation, stops, mode, { navigation: true, provider: options.navProvider });
             text += `\nMaps: ${mapsUrl}\n`;
         }
 
@@ -355,6 +370,14 @@
             return container ? container.dataset.sessionId : null;
         }
 
+        /**
+         * Gets the maps app chosen in settings for navigation links
+         */
+        function getNavProvider() {
+            const element = document.querySelector('[data-nav-provider]');
+            return (element && element.dataset.navProvider) || 'google';
+        }
+
         /**
          * Moves a participant from one route to another
          */
@@ -528,6 +551,74 @@
             }
         }
 
+        /**
+         * Undoes the last route edit
+         */
+        async function undoRouteEdit() {
+            const sessionId = getSessionId();
+            if (!sessionId) {
+                showToast('Session not found', 'error');
+                return;
+            }
+
+            try {
+                const response = await fetch('/api/v1/routes/edit/undo?session_id=' + encodeURIComponent(sessionId), {
+                    method: 'POST',
+                    headers: {
+                        'HX-Request': 'true'
+                    }
+                });
+
+                const html = await response.text();
+                const routeResults = document.getElementById('results-section');
+                if (routeResults) {
+                    if (!response.ok) {
+                        showRouteError(html);
+                    } else {
+                        routeResults.innerHTML = html;
+                        populateStopEtas();
+                    }
+                }
+            } catch (err) {
+                console.error('Failed to undo route edit:', err);
+                showRouteError('Failed to undo route edit: ' + err.message);
+            }
+        }
+
+        /**
+         * Re-runs optimization over the edited routes, keeping locked stops
+         */
+        async function reoptimizeRouteEdit() {
+            const sessionId = getSessionId();
+            if (!sessionId) {
+                showToast('Session not found', 'error');
+                return;
+            }
+
+            try {
+                const response = await fetch('/api/v1/routes/edit/reoptimize?session_id=' + encodeURIComponent(sessionId), {
+                    method: 'POST',
+                    headers: {
+                        'HX-Request': 'true'
+                    }
+                });
+
+                const html = await response.text();
+                const routeResults = document.getElementById('results-section');
+                if (routeResults) {
+                    if (!response.ok) {
+                        showRouteError(html);
+                    } else {
+                        routeResults.innerHTML = html;
+                        populateStopEtas();
+                    }
+                }
+            } catch (err) {
+                console.error('Failed to re-optimize routes:', err);
+                showRouteError('Failed to re-optimize routes: ' + err.message);
+            }
+        }
+
         /**
          * Adds an unused driver to the routes as an empty route
          */
@@ -644,6 +735,7 @@
                 includeParticipantAddresses: !isParentCopy,
                 includeDriverAddress: !isParentCopy,
                 includeMapsLink: !isParentCopy,
+                navProvider: getNavProvider(),
             });
 
             try {
@@ -708,7 +800,7 @@
                     allText += `${index + 1}. ${prefix}${stop.name} - ${stop.address}\n`;
                 });
 
-                const mapsUrl = generateMapsUrl(activityLocation, driverLocation, stops, mode, { navigation: true });
+                const mapsUrl = generateMapsUrl(activityLocation, driverLocation, stops, mode, { navigation: true, provider: getNavProvider() });
                 allText += `Maps: ${mapsUrl}\n`;
             });
 
@@ -755,7 +847,7 @@
             };
             const stops = getStopsFromRouteCard(routeCard);
 
-            const mapsUrl = generateMapsUrl(activityLocation, driverLocation, stops, mode);
+            const mapsUrl = generateMapsUrl(activityLocation, driverLocation, stops, mode, { provider: getNavProvider() });
             if (mapsUrl) {
                 fetch('/api/v1/open-url', {
                     method: 'POST',
@@ -1126,6 +1218,9 @@
                 statsEl.classList.toggle('text-danger', participantsCount > totalCapacity && driversCount > 0);
                 statsEl.classList.toggle('text-muted', !(participantsCount > totalCapacity && driversCount > 0));
             }
+
+            const previewEl = document.getElementById('capacity-preview');
+            if (previewEl && window.htmx) htmx.trigger(previewEl, 'selection-changed');
         }
 
         function getActiveLabelFilters(listId) {
@@ -1463,6 +1558,8 @@
         root.moveParticipant = moveParticipant;
         root.swapDrivers = swapDrivers;
         root.resetRoutes = resetRoutes;
+        root.undoRouteEdit = undoRouteEdit;
+        root.reoptimizeRouteEdit = reoptimizeRouteEdit;
         root.addUnusedDriver = addUnusedDriver;
         root.copyRoute = copyRoute;
         root.copyAllRoutes = copyAllRoutes;
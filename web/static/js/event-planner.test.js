@@ -62,6 +62,24 @@ test('pickup Maps URL starts at the driver, deduplicates stops, and ends at the
     );
 });
 
+test('Apple and OpenStreetMap links use their own URL formats', () => {
+    const activity = { address: 'Church', lat: '40.4', lng: '-74.4' };
+    const driver = { address: 'Driver', lat: '40.1', lng: '-74.1' };
+    const stops = [
+        { address: 'One', lat: '40.2', lng: '-74.2' },
+        { address: 'Two', lat: '40.3', lng: '-74.3' },
+    ];
+
+    assert.equal(
+        generateMapsUrl(activity, driver, stops, 'dropoff', { navigation: true, provider: 'apple' }),
+        'https://maps.apple.com/?daddr=40.2%2C-74.2+to:40.3%2C-74.3+to:40.1%2C-74.1&dirflg=d',
+    );
+    assert.equal(
+        generateMapsUrl(activity, driver, stops, 'dropoff', { navigation: true, provider: 'osm' }),
+        'https://www.openstreetmap.org/directions?engine=fossgis_osrm_car&route=40.4%2C-74.4%3B40.2%2C-74.2%3B40.3%2C-74.3%3B40.1%2C-74.1',
+    );
+});
+
 test('parent copy text omits private addresses and the Maps link', () => {
     const text = formatRouteText(
         'Wednesday Night Church',